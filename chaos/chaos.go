@@ -0,0 +1,349 @@
+// Package chaos injects faults into the queue, the task and worker stores and
+// task handlers, so a staging deployment can exercise the retry, recovery
+// and alerting paths that real outages would. It is opt-in: an Injector
+// with a zero Config passes every call through unchanged.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/worker"
+)
+
+// ErrInjected is wrapped by every error an Injector makes up.
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Config sets how often each kind of fault is injected. Rates are
+// probabilities between 0 and 1; zero disables the fault.
+type Config struct {
+	// QueueErrorRate fails Enqueue and Len calls on the queue.
+	QueueErrorRate float64 `json:"queue_error_rate"`
+	// RepoLatency delays a RepoLatencyRate share of task and worker store
+	// calls by this long.
+	RepoLatency     time.Duration `json:"repo_latency"`
+	RepoLatencyRate float64       `json:"repo_latency_rate"`
+	// HandlerFailureRate fails task executions before their handler runs.
+	HandlerFailureRate float64 `json:"handler_failure_rate"`
+	// HeartbeatDropRate discards worker heartbeats, so the worker's
+	// LastHeartAt falls behind as if they had been lost in transit.
+	HeartbeatDropRate float64 `json:"heartbeat_drop_rate"`
+	// Seed makes the injected faults reproducible; zero picks a random one.
+	Seed uint64 `json:"seed"`
+}
+
+// Enabled reports whether c injects any fault.
+func (c Config) Enabled() bool {
+	return c.QueueErrorRate > 0 || (c.RepoLatency > 0 && c.RepoLatencyRate > 0) ||
+		c.HandlerFailureRate > 0 || c.HeartbeatDropRate > 0
+}
+
+// Stats counts the faults an Injector has injected.
+type Stats struct {
+	QueueErrors       int64 `json:"queue_errors"`
+	DelayedCalls      int64 `json:"delayed_calls"`
+	HandlerFailures   int64 `json:"handler_failures"`
+	DroppedHeartbeats int64 `json:"dropped_heartbeats"`
+}
+
+// Injector wraps components so that they misbehave as its Config says. It is
+// safe for concurrent use.
+type Injector struct {
+	cfg   Config
+	clock clock.Clock
+
+	mu  sync.Mutex
+	rng *rand.Rand
+
+	queueErrors, delayedCalls, handlerFailures, droppedHeartbeats atomic.Int64
+}
+
+// Option is a functional option for configuring an Injector.
+type Option func(*Injector)
+
+// WithClock sets the clock injected latency waits on. The default is
+// clock.Real.
+func WithClock(c clock.Clock) Option {
+	return func(in *Injector) { in.clock = c }
+}
+
+// New returns an Injector injecting the faults cfg describes.
+func New(cfg Config, opts ...Option) *Injector {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	in := &Injector{
+		cfg:   cfg,
+		clock: clock.Real,
+		rng:   rand.New(rand.NewPCG(seed, seed)),
+	}
+	for _, o := range opts {
+		o(in)
+	}
+	return in
+}
+
+// Config returns the Injector's configuration.
+func (in *Injector) Config() Config {
+	return in.cfg
+}
+
+// Stats returns how many faults of each kind have been injected so far.
+func (in *Injector) Stats() Stats {
+	return Stats{
+		QueueErrors:       in.queueErrors.Load(),
+		DelayedCalls:      in.delayedCalls.Load(),
+		HandlerFailures:   in.handlerFailures.Load(),
+		DroppedHeartbeats: in.droppedHeartbeats.Load(),
+	}
+}
+
+// roll reports whether an event with probability rate happens.
+func (in *Injector) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.rng.Float64() < rate
+}
+
+// delay waits RepoLatency for a RepoLatencyRate share of calls. It returns
+// ctx's error if ctx ends first.
+func (in *Injector) delay(ctx context.Context) error {
+	if in.cfg.RepoLatency <= 0 || !in.roll(in.cfg.RepoLatencyRate) {
+		return nil
+	}
+	in.delayedCalls.Add(1)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-in.clock.After(in.cfg.RepoLatency):
+		return nil
+	}
+}
+
+// Queue wraps q so Enqueue and Len fail at QueueErrorRate. Dequeue is left
+// alone: a worker treats a failed Dequeue as fatal, which would turn every
+// injected error into a restart rather than a retry.
+func (in *Injector) Queue(q domain.Queue) domain.Queue {
+	return &faultyQueue{Queue: q, in: in}
+}
+
+type faultyQueue struct {
+	domain.Queue
+	in *Injector
+}
+
+func (q *faultyQueue) Enqueue(ctx context.Context, task *domain.Task) error {
+	if q.in.roll(q.in.cfg.QueueErrorRate) {
+		q.in.queueErrors.Add(1)
+		return fmt.Errorf("%w: enqueue task %s", ErrInjected, task.ID)
+	}
+	return q.Queue.Enqueue(ctx, task)
+}
+
+func (q *faultyQueue) Len(ctx context.Context) (int, error) {
+	if q.in.roll(q.in.cfg.QueueErrorRate) {
+		q.in.queueErrors.Add(1)
+		return 0, fmt.Errorf("%w: queue length", ErrInjected)
+	}
+	return q.Queue.Len(ctx)
+}
+
+// Ack passes through to a queue that takes acknowledgements; faults are
+// not injected here, as a lost ack only means a redelivery.
+func (q *faultyQueue) Ack(ctx context.Context, task *domain.Task) error {
+	if aq, ok := q.Queue.(domain.AckQueue); ok {
+		return aq.Ack(ctx, task)
+	}
+	return nil
+}
+
+// Tasks wraps r so its calls are delayed at RepoLatencyRate. The wrapper is
+// a domain.IdempotencyStore if r is.
+func (in *Injector) Tasks(r domain.TaskRepository) domain.TaskRepository {
+	if s, ok := r.(domain.IdempotencyStore); ok {
+		return &slowClaims{slowTasks: slowTasks{TaskRepository: r, in: in}, store: s}
+	}
+	return &slowTasks{TaskRepository: r, in: in}
+}
+
+type slowTasks struct {
+	domain.TaskRepository
+	in *Injector
+}
+
+func (r *slowTasks) Save(ctx context.Context, task *domain.Task) error {
+	if err := r.in.delay(ctx); err != nil {
+		return err
+	}
+	return r.TaskRepository.Save(ctx, task)
+}
+
+func (r *slowTasks) FindByID(ctx context.Context, id string) (*domain.Task, error) {
+	if err := r.in.delay(ctx); err != nil {
+		return nil, err
+	}
+	return r.TaskRepository.FindByID(ctx, id)
+}
+
+func (r *slowTasks) FindByStatus(ctx context.Context, status domain.TaskStatus) ([]*domain.Task, error) {
+	if err := r.in.delay(ctx); err != nil {
+		return nil, err
+	}
+	return r.TaskRepository.FindByStatus(ctx, status)
+}
+
+func (r *slowTasks) Delete(ctx context.Context, id string) error {
+	if err := r.in.delay(ctx); err != nil {
+		return err
+	}
+	return r.TaskRepository.Delete(ctx, id)
+}
+
+// slowClaims is a slowTasks over a domain.IdempotencyStore.
+type slowClaims struct {
+	slowTasks
+	store domain.IdempotencyStore
+}
+
+func (r *slowClaims) ClaimKey(ctx context.Context, task *domain.Task, since time.Time) (*domain.Task, error) {
+	if err := r.in.delay(ctx); err != nil {
+		return nil, err
+	}
+	return r.store.ClaimKey(ctx, task, since)
+}
+
+// Workers wraps r so its calls are delayed at RepoLatencyRate and saves that
+// advance a worker's LastHeartAt are dropped at HeartbeatDropRate.
+func (in *Injector) Workers(r domain.WorkerRepository) domain.WorkerRepository {
+	return &flakyWorkers{WorkerRepository: r, in: in}
+}
+
+type flakyWorkers struct {
+	domain.WorkerRepository
+	in *Injector
+}
+
+func (r *flakyWorkers) Save(ctx context.Context, w *domain.Worker) error {
+	if err := r.in.delay(ctx); err != nil {
+		return err
+	}
+	if r.in.cfg.HeartbeatDropRate > 0 {
+		prev, err := r.WorkerRepository.FindByID(ctx, w.ID)
+		if err == nil && w.LastHeartAt.After(prev.LastHeartAt) && r.in.roll(r.in.cfg.HeartbeatDropRate) {
+			r.in.droppedHeartbeats.Add(1)
+			return nil
+		}
+	}
+	return r.WorkerRepository.Save(ctx, w)
+}
+
+func (r *flakyWorkers) FindByID(ctx context.Context, id string) (*domain.Worker, error) {
+	if err := r.in.delay(ctx); err != nil {
+		return nil, err
+	}
+	return r.WorkerRepository.FindByID(ctx, id)
+}
+
+func (r *flakyWorkers) FindAvailable(ctx context.Context, f domain.WorkerFilter) ([]*domain.Worker, error) {
+	if err := r.in.delay(ctx); err != nil {
+		return nil, err
+	}
+	return r.WorkerRepository.FindAvailable(ctx, f)
+}
+
+func (r *flakyWorkers) Delete(ctx context.Context, id string) error {
+	if err := r.in.delay(ctx); err != nil {
+		return err
+	}
+	return r.WorkerRepository.Delete(ctx, id)
+}
+
+// Handler wraps h so executions fail with ErrInjected at HandlerFailureRate,
+// without running h.
+func (in *Injector) Handler(h worker.Handler) worker.Handler {
+	return func(ctx context.Context, task *domain.Task) error {
+		if in.roll(in.cfg.HandlerFailureRate) {
+			in.handlerFailures.Add(1)
+			return fmt.Errorf("%w: task %s failed", ErrInjected, task.Name)
+		}
+		return h(ctx, task)
+	}
+}
+
+// ParseConfig parses a comma-separated list of key=value settings, e.g.
+// "queue_errors=0.05,handler_failures=0.1,repo_latency=250ms". Keys:
+//
+//	queue_errors        QueueErrorRate
+//	repo_latency        RepoLatency, a duration
+//	repo_latency_rate   RepoLatencyRate; 1 if repo_latency is set without it
+//	handler_failures    HandlerFailureRate
+//	heartbeat_drops     HeartbeatDropRate
+//	seed                Seed
+//
+// An empty string returns a zero Config.
+func ParseConfig(s string) (Config, error) {
+	var cfg Config
+	rateSet := false
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return Config{}, fmt.Errorf("chaos %q: must be key=value", entry)
+		}
+		var err error
+		switch key {
+		case "queue_errors":
+			cfg.QueueErrorRate, err = parseRate(value)
+		case "repo_latency":
+			cfg.RepoLatency, err = time.ParseDuration(value)
+			if err == nil && cfg.RepoLatency < 0 {
+				err = errors.New("must not be negative")
+			}
+		case "repo_latency_rate":
+			cfg.RepoLatencyRate, err = parseRate(value)
+			rateSet = true
+		case "handler_failures":
+			cfg.HandlerFailureRate, err = parseRate(value)
+		case "heartbeat_drops":
+			cfg.HeartbeatDropRate, err = parseRate(value)
+		case "seed":
+			cfg.Seed, err = strconv.ParseUint(value, 10, 64)
+		default:
+			return Config{}, fmt.Errorf("chaos %q: unknown key %q", entry, key)
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("chaos %q: %v", entry, err)
+		}
+	}
+	if cfg.RepoLatency > 0 && !rateSet {
+		cfg.RepoLatencyRate = 1
+	}
+	return cfg, nil
+}
+
+func parseRate(s string) (float64, error) {
+	r, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if r < 0 || r > 1 {
+		return 0, errors.New("must be between 0 and 1")
+	}
+	return r, nil
+}
@@ -0,0 +1,179 @@
+package chaos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/chaos"
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+func TestInjector_ZeroConfigPassesThrough(t *testing.T) {
+	ctx := context.Background()
+	in := chaos.New(chaos.Config{})
+	q := in.Queue(scheduler.NewMemQueue())
+	if err := q.Enqueue(ctx, &domain.Task{ID: "t1"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if n, err := q.Len(ctx); err != nil || n != 1 {
+		t.Fatalf("Len: got %d, %v", n, err)
+	}
+	h := in.Handler(func(context.Context, *domain.Task) error { return nil })
+	if err := h(ctx, &domain.Task{}); err != nil {
+		t.Errorf("handler: %v", err)
+	}
+	if in.Stats() != (chaos.Stats{}) {
+		t.Errorf("stats: got %+v, want none", in.Stats())
+	}
+}
+
+func TestInjector_QueueErrors(t *testing.T) {
+	ctx := context.Background()
+	in := chaos.New(chaos.Config{QueueErrorRate: 1})
+	mem := scheduler.NewMemQueue()
+	q := in.Queue(mem)
+	if err := q.Enqueue(ctx, &domain.Task{ID: "t1"}); !errors.Is(err, chaos.ErrInjected) {
+		t.Fatalf("Enqueue: got %v, want ErrInjected", err)
+	}
+	if _, err := q.Len(ctx); !errors.Is(err, chaos.ErrInjected) {
+		t.Fatalf("Len: got %v, want ErrInjected", err)
+	}
+	if n, _ := mem.Len(ctx); n != 0 {
+		t.Errorf("failed Enqueue reached the queue")
+	}
+	if got := in.Stats().QueueErrors; got != 2 {
+		t.Errorf("QueueErrors: got %d, want 2", got)
+	}
+}
+
+func TestInjector_HandlerFailures(t *testing.T) {
+	in := chaos.New(chaos.Config{HandlerFailureRate: 1})
+	called := false
+	h := in.Handler(func(context.Context, *domain.Task) error { called = true; return nil })
+	if err := h(context.Background(), &domain.Task{Name: "a"}); !errors.Is(err, chaos.ErrInjected) {
+		t.Fatalf("handler: got %v, want ErrInjected", err)
+	}
+	if called {
+		t.Error("wrapped handler ran despite the injected failure")
+	}
+	if got := in.Stats().HandlerFailures; got != 1 {
+		t.Errorf("HandlerFailures: got %d, want 1", got)
+	}
+}
+
+func TestInjector_RepoLatency(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	in := chaos.New(chaos.Config{RepoLatency: time.Second, RepoLatencyRate: 1}, chaos.WithClock(fake))
+	tasks := in.Tasks(scheduler.NewMemTaskRepo())
+
+	done := make(chan error, 1)
+	go func() { done <- tasks.Save(context.Background(), &domain.Task{ID: "t1", Name: "a"}) }()
+	fake.BlockUntil(1)
+	select {
+	case err := <-done:
+		t.Fatalf("Save returned before the injected latency: %v", err)
+	default:
+	}
+	fake.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if got := in.Stats().DelayedCalls; got != 1 {
+		t.Errorf("DelayedCalls: got %d, want 1", got)
+	}
+}
+
+func TestInjector_RepoLatencyHonoursContext(t *testing.T) {
+	in := chaos.New(chaos.Config{RepoLatency: time.Hour, RepoLatencyRate: 1})
+	workers := in.Workers(scheduler.NewMemWorkerRepo())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := workers.FindByID(ctx, "w1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("FindByID: got %v, want context.Canceled", err)
+	}
+}
+
+func TestInjector_HeartbeatDrops(t *testing.T) {
+	ctx := context.Background()
+	in := chaos.New(chaos.Config{HeartbeatDropRate: 1})
+	mem := scheduler.NewMemWorkerRepo()
+	workers := in.Workers(mem)
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Registration is not a heartbeat and always goes through.
+	if err := workers.Save(ctx, &domain.Worker{ID: "w1", Status: domain.WorkerStatusIdle, LastHeartAt: t0}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := workers.Save(ctx, &domain.Worker{ID: "w1", Status: domain.WorkerStatusBusy, LastHeartAt: t0.Add(15 * time.Second)}); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	got, err := mem.FindByID(ctx, "w1")
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if !got.LastHeartAt.Equal(t0) {
+		t.Errorf("LastHeartAt: got %v, want the dropped heartbeat not saved", got.LastHeartAt)
+	}
+	// Saves that do not advance the heartbeat are kept.
+	if err := workers.Save(ctx, &domain.Worker{ID: "w1", Status: domain.WorkerStatusDrained, LastHeartAt: t0}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if got, _ := mem.FindByID(ctx, "w1"); got.Status != domain.WorkerStatusDrained {
+		t.Errorf("status: got %s, want drained", got.Status)
+	}
+	if got := in.Stats().DroppedHeartbeats; got != 1 {
+		t.Errorf("DroppedHeartbeats: got %d, want 1", got)
+	}
+}
+
+func TestInjector_SeedIsReproducible(t *testing.T) {
+	outcomes := func() []bool {
+		in := chaos.New(chaos.Config{HandlerFailureRate: 0.5, Seed: 42})
+		h := in.Handler(func(context.Context, *domain.Task) error { return nil })
+		out := make([]bool, 32)
+		for i := range out {
+			out[i] = h(context.Background(), &domain.Task{}) != nil
+		}
+		return out
+	}
+	a, b := outcomes(), outcomes()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("outcome %d differs between runs with the same seed", i)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := chaos.ParseConfig("queue_errors=0.05, handler_failures=0.1,heartbeat_drops=0.3,repo_latency=250ms,seed=7")
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	want := chaos.Config{
+		QueueErrorRate: 0.05, HandlerFailureRate: 0.1, HeartbeatDropRate: 0.3,
+		RepoLatency: 250 * time.Millisecond, RepoLatencyRate: 1, Seed: 7,
+	}
+	if cfg != want {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+	if !cfg.Enabled() {
+		t.Error("Enabled: want true")
+	}
+
+	if cfg, err := chaos.ParseConfig(""); err != nil || cfg.Enabled() {
+		t.Errorf("empty: got %+v, %v; want disabled", cfg, err)
+	}
+	if cfg, _ := chaos.ParseConfig("repo_latency=1s,repo_latency_rate=0.2"); cfg.RepoLatencyRate != 0.2 {
+		t.Errorf("repo_latency_rate: got %v, want 0.2", cfg.RepoLatencyRate)
+	}
+	for _, bad := range []string{"queue_errors", "queue_errors=2", "handler_failures=-0.1", "repo_latency=soon", "bogus=1", "seed=-1"} {
+		if _, err := chaos.ParseConfig(bad); err == nil {
+			t.Errorf("ParseConfig(%q): want error", bad)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package client talks to the scheduler's REST API from Go programs. For
+// now it covers the live updates: Subscribe connects to /ws/updates,
+// reconnects when the connection drops, replays what was missed meanwhile
+// from GET /events/history, and delivers each event decoded into the type
+// its producer published.
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// Client is a client of the API served at one base URL. It is safe for
+// concurrent use.
+type Client struct {
+	base   *url.URL
+	http   *http.Client
+	dialer *websocket.Dialer
+	header http.Header
+}
+
+// Option is a functional option for configuring a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the client the REST requests are sent with. The
+// default is http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) { cl.http = c }
+}
+
+// WithDialer sets the dialer WebSocket connections are opened with. The
+// default is websocket.DefaultDialer.
+func WithDialer(d *websocket.Dialer) Option {
+	return func(cl *Client) { cl.dialer = d }
+}
+
+// WithAPIKey sends key as the X-API-Key header the API's rate limits and
+// usage are accounted by.
+func WithAPIKey(key string) Option {
+	return WithHeader("X-API-Key", key)
+}
+
+// WithHeader sends the header with every request and WebSocket handshake,
+// e.g. X-User and X-Roles behind an authenticating proxy.
+func WithHeader(key, value string) Option {
+	return func(cl *Client) { cl.header.Set(key, value) }
+}
+
+// New returns a Client of the API at baseURL, e.g.
+// "http://scheduler-api:8080".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("client: invalid base URL %q: want http or https", baseURL)
+	}
+	c := &Client{base: u, http: http.DefaultClient, dialer: websocket.DefaultDialer, header: make(http.Header)}
+	for _, o := range opts {
+		o(c)
+	}
+	return c, nil
+}
+
+// endpoint returns the URL of path on the API, with the given query.
+func (c *Client) endpoint(path string, query url.Values) *url.URL {
+	u := *c.base
+	u.Path += path
+	u.RawQuery = query.Encode()
+	return &u
+}
@@ -0,0 +1,193 @@
+package client_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/client"
+	"github.com/sauravritesh63/GoLang-Project-/internal/api"
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/service"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+)
+
+// apiServer serves the API with an event history, keeping the connections
+// upgraded to WebSocket so tests can drop them.
+type apiServer struct {
+	*httptest.Server
+	bus events.Bus
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newAPIServer(t *testing.T) *apiServer {
+	history := mock.NewEventRepo()
+	bus, err := events.OpenHistory(events.NewMemBus(), history, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := api.NewRouter(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithEvents(bus), service.WithEventHistory(history))
+	s := &apiServer{Server: httptest.NewUnstartedServer(r), bus: bus}
+	s.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateHijacked {
+			s.mu.Lock()
+			s.conns = append(s.conns, c)
+			s.mu.Unlock()
+		}
+	}
+	s.Start()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// dropConnections closes every WebSocket connection.
+func (s *apiServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		_ = c.Close()
+	}
+	s.conns = nil
+}
+
+func (s *apiServer) publish(t *testing.T, e events.Event) {
+	if err := s.bus.Publish(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// awaitConnected publishes heartbeats until one arrives on ch, so the API
+// has registered the subscription's connection.
+func (s *apiServer) awaitConnected(t *testing.T, ch <-chan client.Event) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		s.publish(t, events.Event{Type: events.WorkerHeartbeat, Payload: events.Heartbeat{WorkerID: "probe"}})
+		select {
+		case e := <-ch:
+			if e.Heartbeat == nil {
+				t.Fatalf("event = %+v, want a heartbeat", e)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("subscription not connected within 5s")
+		}
+	}
+}
+
+// next returns the next event on ch other than a heartbeat.
+func next(t *testing.T, ch <-chan client.Event) client.Event {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				t.Fatal("subscription closed")
+			}
+			if e.Type != client.EventWorkerHeartbeat {
+				return e
+			}
+		case <-deadline:
+			t.Fatal("no event within 5s")
+		}
+	}
+}
+
+func TestSubscribe_DeliversTypedEventsAndReplaysAfterReconnect(t *testing.T) {
+	srv := newAPIServer(t)
+	c, err := client.New(srv.URL, client.WithAPIKey("ci"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var errs []error
+	var errMu sync.Mutex
+	updates, err := c.Subscribe(ctx,
+		client.WithReconnectBackoff(200*time.Millisecond, time.Second),
+		client.WithReplayOverlap(0),
+		client.WithSubscribeErrors(func(err error) { errMu.Lock(); errs = append(errs, err); errMu.Unlock() }))
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	srv.awaitConnected(t, updates)
+
+	run := domain.WorkflowRun{ID: uuid.New(), WorkflowID: uuid.New(), Status: domain.StatusRunning}
+	srv.publish(t, events.Event{Type: events.WorkflowStatus, Payload: run})
+	e := next(t, updates)
+	if e.Type != client.EventWorkflowStatus || e.WorkflowRun == nil || e.WorkflowRun.ID != run.ID || e.Replayed {
+		t.Fatalf("live event = %+v, want the workflow run", e)
+	}
+
+	// Events published while the connection is down come back replayed,
+	// before the live ones.
+	srv.dropConnections()
+	srv.publish(t, events.Event{Type: events.TaskStatus, Payload: events.TaskUpdate{TaskID: "t1", Status: "running", WorkerID: "w1"}})
+	e = next(t, updates)
+	if e.Type != client.EventTaskStatus || e.TaskUpdate == nil || e.TaskUpdate.WorkerID != "w1" || !e.Replayed || e.At.IsZero() {
+		t.Fatalf("replayed event = %+v, want the worker's task update", e)
+	}
+
+	taskRun := domain.TaskRun{ID: uuid.New(), WorkflowRunID: run.ID, Status: domain.StatusSuccess}
+	srv.publish(t, events.Event{Type: events.TaskStatus, Payload: taskRun})
+	e = next(t, updates)
+	if e.TaskRun == nil || e.TaskRun.ID != taskRun.ID || e.TaskUpdate != nil || e.Replayed {
+		t.Fatalf("live event after reconnect = %+v, want the task run", e)
+	}
+
+	cancel()
+	for range updates {
+	}
+	errMu.Lock()
+	defer errMu.Unlock()
+	if len(errs) == 0 {
+		t.Error("the dropped connection was not reported")
+	}
+}
+
+func TestSubscribe_FiltersEventTypes(t *testing.T) {
+	srv := newAPIServer(t)
+	c, _ := client.New(srv.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := c.Subscribe(ctx, client.WithEventTypes(client.EventAlert))
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	// Publish until the connection is registered and the alert comes
+	// through; nothing else may.
+	deadline := time.After(5 * time.Second)
+	for {
+		srv.publish(t, events.Event{Type: events.WorkflowChanged, Payload: events.WorkflowChange{WorkflowID: "wf", Action: "updated"}})
+		srv.publish(t, events.Event{Type: events.Alert, Payload: events.AlertUpdate{Name: "queue_depth", Firing: true}})
+		select {
+		case e := <-updates:
+			if e.Alert == nil || e.Alert.Name != "queue_depth" {
+				t.Errorf("event = %+v, want only the alert", e)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no alert within 5s")
+		}
+	}
+}
+
+func TestNew_RejectsNonHTTPURLs(t *testing.T) {
+	for _, u := range []string{"ws://localhost:8080", "localhost:8080", "://"} {
+		if _, err := client.New(u); err == nil {
+			t.Errorf("New(%q): expected an error", u)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+)
+
+const (
+	// DefaultReplayOverlap is how far before the last event received over a
+	// dropped connection a replay starts, to absorb clock skew between the
+	// client and the API.
+	DefaultReplayOverlap = time.Second
+
+	// Default bounds of the delay between reconnection attempts, which
+	// doubles after each failed attempt.
+	DefaultMinReconnect = 500 * time.Millisecond
+	DefaultMaxReconnect = 30 * time.Second
+
+	// eventBuffer is how many events Subscribe holds for a slow receiver,
+	// and how many live events it holds while a replay is delivered.
+	eventBuffer = 256
+
+	// replayPage is the number of events each history request asks for.
+	replayPage = 1000
+)
+
+// EventType labels the kind of event, as on the WebSocket envelope.
+type EventType = events.Type
+
+// The event types /ws/updates delivers.
+const (
+	EventTaskStatus        = events.TaskStatus
+	EventWorkflowStatus    = events.WorkflowStatus
+	EventWorkerHeartbeat   = events.WorkerHeartbeat
+	EventWorkerQuarantined = events.WorkerQuarantined
+	EventAlert             = events.Alert
+	EventWorkflowChanged   = events.WorkflowChanged
+)
+
+// The payload types of the events, as their producers publish them.
+type (
+	WorkflowRun    = domain.WorkflowRun
+	TaskRun        = domain.TaskRun
+	TaskUpdate     = events.TaskUpdate
+	Heartbeat      = events.Heartbeat
+	Quarantine     = events.Quarantine
+	AlertUpdate    = events.AlertUpdate
+	WorkflowChange = events.WorkflowChange
+)
+
+// Event is one update, with its payload decoded into the field matching its
+// Type: WorkflowRun for workflow_status; TaskRun for the task_status events
+// of the orchestrator and TaskUpdate for those of workers and the
+// scheduler; Heartbeat, Quarantine, Alert or WorkflowChange for the others.
+// Payload always holds the raw JSON, the only content of event types this
+// package does not know.
+type Event struct {
+	Type    EventType
+	Payload json.RawMessage
+
+	// Replayed marks an event missed while disconnected and fetched from
+	// the event history; At is then when the API recorded it.
+	Replayed bool
+	At       time.Time
+
+	WorkflowRun    *WorkflowRun
+	TaskRun        *TaskRun
+	TaskUpdate     *TaskUpdate
+	Heartbeat      *Heartbeat
+	Quarantine     *Quarantine
+	Alert          *AlertUpdate
+	WorkflowChange *WorkflowChange
+}
+
+// decodeEvent decodes payload into the field of an Event of type typ.
+func decodeEvent(typ EventType, payload json.RawMessage) (Event, error) {
+	e := Event{Type: typ, Payload: payload}
+	var dst any
+	switch typ {
+	case EventWorkflowStatus:
+		e.WorkflowRun = new(WorkflowRun)
+		dst = e.WorkflowRun
+	case EventTaskStatus:
+		// Only the orchestrator's task runs carry a workflow_run_id.
+		var probe struct {
+			WorkflowRunID json.RawMessage `json:"workflow_run_id"`
+		}
+		_ = json.Unmarshal(payload, &probe)
+		if probe.WorkflowRunID != nil {
+			e.TaskRun = new(TaskRun)
+			dst = e.TaskRun
+		} else {
+			e.TaskUpdate = new(TaskUpdate)
+			dst = e.TaskUpdate
+		}
+	case EventWorkerHeartbeat:
+		e.Heartbeat = new(Heartbeat)
+		dst = e.Heartbeat
+	case EventWorkerQuarantined:
+		e.Quarantine = new(Quarantine)
+		dst = e.Quarantine
+	case EventAlert:
+		e.Alert = new(AlertUpdate)
+		dst = e.Alert
+	case EventWorkflowChanged:
+		e.WorkflowChange = new(WorkflowChange)
+		dst = e.WorkflowChange
+	default:
+		return e, nil
+	}
+	if err := json.Unmarshal(payload, dst); err != nil {
+		return Event{Type: typ, Payload: payload}, fmt.Errorf("client: decode %s event: %w", typ, err)
+	}
+	return e, nil
+}
+
+// SubscribeOption is a functional option for configuring Subscribe.
+type SubscribeOption func(*subscription)
+
+// WithEventTypes delivers only events of the given types.
+func WithEventTypes(types ...EventType) SubscribeOption {
+	return func(s *subscription) {
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+}
+
+// WithReconnectBackoff sets the delay before the first reconnection
+// attempt and the most it doubles to. The defaults are DefaultMinReconnect
+// and DefaultMaxReconnect.
+func WithReconnectBackoff(min, max time.Duration) SubscribeOption {
+	return func(s *subscription) { s.minBackoff, s.maxBackoff = min, max }
+}
+
+// WithReplayOverlap sets how far before the last event received a replay
+// starts. The default is DefaultReplayOverlap.
+func WithReplayOverlap(d time.Duration) SubscribeOption {
+	return func(s *subscription) { s.overlap = d }
+}
+
+// WithoutReplay reconnects without fetching the events missed meanwhile.
+func WithoutReplay() SubscribeOption {
+	return func(s *subscription) { s.replay = false }
+}
+
+// WithSubscribeErrors reports the errors Subscribe recovers from, such as
+// a lost connection, a failed reconnection attempt or replay, or a payload
+// that does not decode, to fn instead of the standard logger. fn is called
+// from the subscription's goroutines and must not block.
+func WithSubscribeErrors(fn func(error)) SubscribeOption {
+	return func(s *subscription) { s.onError = fn }
+}
+
+// subscription is one Subscribe call.
+type subscription struct {
+	c *Client
+
+	types                  map[EventType]bool
+	minBackoff, maxBackoff time.Duration
+	overlap                time.Duration
+	replay                 bool
+	onError                func(error)
+
+	out chan Event
+}
+
+// received is a live event with the time it arrived.
+type received struct {
+	Event
+	at time.Time
+}
+
+// Subscribe connects to /ws/updates and returns a channel delivering every
+// event the API broadcasts from then on, until ctx is cancelled, when the
+// channel is closed. Only the first connection's failure is returned; when
+// a connection drops later, Subscribe reconnects with exponential backoff
+// and first delivers, marked Replayed, the events the event history
+// recorded while it was away. The replay starts a little before the last
+// event received (see WithReplayOverlap), so an event may arrive twice
+// around a reconnection; events are lost only if the API keeps no event
+// history, which is reported as an error.
+//
+// Events wait in a buffer for a slow receiver. Once it is full the
+// connection is no longer read, which holds up the API's broadcasts to
+// every client, so keep receiving.
+func (c *Client) Subscribe(ctx context.Context, opts ...SubscribeOption) (<-chan Event, error) {
+	s := &subscription{
+		c:          c,
+		types:      make(map[EventType]bool),
+		minBackoff: DefaultMinReconnect,
+		maxBackoff: DefaultMaxReconnect,
+		overlap:    DefaultReplayOverlap,
+		replay:     true,
+		onError:    func(err error) { log.Print(err) },
+		out:        make(chan Event, eventBuffer),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	go s.run(ctx, conn)
+	return s.out, nil
+}
+
+// run delivers the events of conn, and of each connection replacing it,
+// until ctx is cancelled.
+func (s *subscription) run(ctx context.Context, conn *websocket.Conn) {
+	defer close(s.out)
+	var lastSeen time.Time
+	for {
+		connected := time.Now()
+		live := s.read(ctx, conn)
+		if s.replay && !lastSeen.IsZero() {
+			if err := s.replayMissed(ctx, lastSeen.Add(-s.overlap)); err != nil && ctx.Err() == nil {
+				s.onError(err)
+			}
+		}
+		lastSeen = connected
+		for e := range live {
+			lastSeen = e.at
+			if !s.deliver(ctx, e.Event) {
+				break
+			}
+		}
+		if conn = s.reconnect(ctx); conn == nil {
+			return
+		}
+	}
+}
+
+// read returns a channel receiving the events of conn until it fails or
+// ctx is cancelled, which closes it.
+func (s *subscription) read(ctx context.Context, conn *websocket.Conn) <-chan received {
+	live := make(chan received, eventBuffer)
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	go func() {
+		defer close(live)
+		defer stop()
+		defer conn.Close()
+		for {
+			var env struct {
+				Type    EventType       `json:"type"`
+				Payload json.RawMessage `json:"payload"`
+			}
+			if err := conn.ReadJSON(&env); err != nil {
+				if ctx.Err() == nil {
+					s.onError(fmt.Errorf("client: updates connection lost: %w", err))
+				}
+				return
+			}
+			e, err := decodeEvent(env.Type, env.Payload)
+			if err != nil {
+				s.onError(err)
+			}
+			select {
+			case live <- received{Event: e, at: time.Now()}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return live
+}
+
+// reconnect dials until a connection succeeds, waiting longer after each
+// failure, and returns nil once ctx is cancelled.
+func (s *subscription) reconnect(ctx context.Context) *websocket.Conn {
+	wait := s.minBackoff
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+		conn, err := s.dial(ctx)
+		if err == nil {
+			return conn
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		s.onError(err)
+		if wait = 2 * wait; wait > s.maxBackoff {
+			wait = s.maxBackoff
+		}
+	}
+}
+
+// dial opens a connection to /ws/updates.
+func (s *subscription) dial(ctx context.Context) (*websocket.Conn, error) {
+	u := s.c.endpoint("/ws/updates", nil)
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	conn, resp, err := s.c.dialer.DialContext(ctx, u.String(), s.c.header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("client: connect to %s: %s", u.Path, resp.Status)
+		}
+		return nil, fmt.Errorf("client: connect to %s: %w", u.Path, err)
+	}
+	return conn, nil
+}
+
+// replayMissed delivers the events the API recorded since then, page by
+// page. It reads up to the present rather than to the reconnection, so an
+// event broadcast before the API registered the new connection is not
+// lost; the events of both arrive twice.
+func (s *subscription) replayMissed(ctx context.Context, since time.Time) error {
+	query := url.Values{
+		"since": {since.UTC().Format(time.RFC3339Nano)},
+		"limit": {strconv.Itoa(replayPage)},
+	}
+	for t := range s.types {
+		query.Add("type", string(t))
+	}
+	for {
+		page, err := s.historyPage(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, rec := range page.Events {
+			e, err := decodeEvent(EventType(rec.Type), rec.Payload)
+			if err != nil {
+				s.onError(err)
+			}
+			e.Replayed, e.At = true, rec.At
+			if !s.deliver(ctx, e) {
+				return nil
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+}
+
+// historyPage is a page of GET /events/history.
+type historyPage struct {
+	Events     []domain.EventRecord `json:"events"`
+	NextCursor string               `json:"next_cursor"`
+}
+
+func (s *subscription) historyPage(ctx context.Context, query url.Values) (*historyPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.c.endpoint("/events/history", query).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = s.c.header.Clone()
+	resp, err := s.c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("client: replay missed events: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("client: replay missed events: %s", resp.Status)
+	}
+	var page historyPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("client: replay missed events: %w", err)
+	}
+	return &page, nil
+}
+
+// deliver sends e to the receiver unless its type is filtered out, and
+// reports false if ctx was cancelled first.
+func (s *subscription) deliver(ctx context.Context, e Event) bool {
+	if len(s.types) > 0 && !s.types[e.Type] {
+		return true
+	}
+	select {
+	case s.out <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,216 @@
+// Package clock abstracts the passage of time so the scheduler and worker
+// can be driven by a Fake clock in tests instead of sleeping in real time.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the subset of the time package the services depend on.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for d to elapse and then sends the current time on the
+	// returned channel.
+	After(d time.Duration) <-chan time.Time
+	// NewTicker returns a Ticker that ticks every d.
+	NewTicker(d time.Duration) Ticker
+	// AfterFunc calls f in its own goroutine once d has elapsed.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Ticker delivers ticks at intervals, like time.Ticker.
+type Ticker interface {
+	// C returns the channel ticks are delivered on.
+	C() <-chan time.Time
+	// Stop turns off the ticker.
+	Stop()
+}
+
+// Timer is a pending AfterFunc call.
+type Timer interface {
+	// Stop prevents the call from happening. It reports whether the call
+	// was stopped before it ran.
+	Stop() bool
+}
+
+// Real is the Clock backed by the time package.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+type realTicker struct{ t *time.Ticker }
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+func (r realTicker) Stop()               { r.t.Stop() }
+
+// Fake is a Clock whose time only moves when Advance or Set is called.
+// Timers, tickers and After channels fire synchronously inside Advance, in
+// deadline order; AfterFunc callbacks run in their own goroutines as they do
+// with the real clock.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+	added   chan struct{}
+}
+
+type waiter struct {
+	at     time.Time
+	period time.Duration // non-zero for tickers
+	ch     chan time.Time
+	fn     func()
+	clock  *Fake
+}
+
+// NewFake creates a Fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, added: make(chan struct{}, 1)}
+}
+
+// Now returns the fake current time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After returns a channel that receives the fake time once it reaches
+// Now()+d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	w := &waiter{ch: make(chan time.Time, 1)}
+	f.add(w, d)
+	return w.ch
+}
+
+// NewTicker returns a Ticker that ticks each time the fake time passes a
+// multiple of d from now. As with time.Ticker, ticks are dropped for slow
+// receivers.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := &waiter{period: d, ch: make(chan time.Time, 1)}
+	f.add(w, d)
+	return fakeTicker{w}
+}
+
+// AfterFunc calls fn in its own goroutine once the fake time reaches
+// Now()+d.
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	w := &waiter{fn: fn}
+	f.add(w, d)
+	return w
+}
+
+// Advance moves the fake time forward by d, firing every timer that falls
+// due along the way.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the fake time to t, firing every timer due at or before t. Time
+// never moves backwards; an earlier t is ignored.
+func (f *Fake) Set(t time.Time) {
+	for {
+		f.mu.Lock()
+		if len(f.waiters) == 0 || f.waiters[0].at.After(t) {
+			if t.After(f.now) {
+				f.now = t
+			}
+			f.mu.Unlock()
+			return
+		}
+		w := f.waiters[0]
+		f.waiters = f.waiters[1:]
+		if w.at.After(f.now) {
+			f.now = w.at
+		}
+		now := f.now
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+			f.insertLocked(w)
+		}
+		f.mu.Unlock()
+
+		if w.fn != nil {
+			go w.fn()
+			continue
+		}
+		select {
+		case w.ch <- now:
+		default:
+		}
+	}
+}
+
+// Waiters returns the number of pending timers, tickers and After calls.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil blocks until at least n timers, tickers or After calls are
+// pending. Tests use it to wait for a goroutine to start waiting before
+// advancing the clock.
+func (f *Fake) BlockUntil(n int) {
+	for {
+		if f.Waiters() >= n {
+			return
+		}
+		select {
+		case <-f.added:
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func (f *Fake) add(w *waiter, d time.Duration) {
+	f.mu.Lock()
+	w.clock = f
+	w.at = f.now.Add(d)
+	f.insertLocked(w)
+	f.mu.Unlock()
+	select {
+	case f.added <- struct{}{}:
+	default:
+	}
+}
+
+func (f *Fake) insertLocked(w *waiter) {
+	i := sort.Search(len(f.waiters), func(i int) bool { return f.waiters[i].at.After(w.at) })
+	f.waiters = append(f.waiters, nil)
+	copy(f.waiters[i+1:], f.waiters[i:])
+	f.waiters[i] = w
+}
+
+// remove unregisters w and reports whether it was still pending.
+func (f *Fake) remove(w *waiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, x := range f.waiters {
+		if x == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Stop implements Timer.
+func (w *waiter) Stop() bool { return w.clock.remove(w) }
+
+type fakeTicker struct{ w *waiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.ch }
+func (t fakeTicker) Stop()               { t.w.clock.remove(t.w) }
@@ -0,0 +1,98 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFake_AfterFiresOnlyWhenDue(t *testing.T) {
+	c := clock.NewFake(epoch)
+	ch := c.After(time.Minute)
+
+	c.Advance(59 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("fired early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case got := <-ch:
+		if !got.Equal(epoch.Add(time.Minute)) {
+			t.Errorf("fired at %s", got)
+		}
+	default:
+		t.Fatal("did not fire when due")
+	}
+	if c.Waiters() != 0 {
+		t.Errorf("Waiters: got %d, want 0", c.Waiters())
+	}
+}
+
+func TestFake_TickerRearmsAndStops(t *testing.T) {
+	c := clock.NewFake(epoch)
+	tk := c.NewTicker(10 * time.Second)
+
+	for i := 1; i <= 3; i++ {
+		c.Advance(10 * time.Second)
+		select {
+		case got := <-tk.C():
+			if want := epoch.Add(time.Duration(i) * 10 * time.Second); !got.Equal(want) {
+				t.Errorf("tick %d: got %s, want %s", i, got, want)
+			}
+		default:
+			t.Fatalf("tick %d missing", i)
+		}
+	}
+
+	tk.Stop()
+	c.Advance(time.Minute)
+	select {
+	case <-tk.C():
+		t.Error("ticked after Stop")
+	default:
+	}
+}
+
+func TestFake_AfterFuncAndStop(t *testing.T) {
+	c := clock.NewFake(epoch)
+	done := make(chan struct{})
+	c.AfterFunc(time.Second, func() { close(done) })
+	stopped := c.AfterFunc(time.Second, func() { t.Error("stopped timer ran") })
+	if !stopped.Stop() {
+		t.Error("Stop on a pending timer should report true")
+	}
+
+	c.Advance(time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("AfterFunc callback did not run")
+	}
+}
+
+func TestFake_AdvanceFiresInDeadlineOrder(t *testing.T) {
+	c := clock.NewFake(epoch)
+	late := c.After(2 * time.Second)
+	early := c.After(time.Second)
+
+	c.Advance(5 * time.Second)
+	if e, l := <-early, <-late; !e.Before(l) {
+		t.Errorf("early fired at %s, late at %s", e, l)
+	}
+	if !c.Now().Equal(epoch.Add(5 * time.Second)) {
+		t.Errorf("Now: got %s", c.Now())
+	}
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	c := clock.NewFake(epoch)
+	go func() { <-c.After(time.Second) }()
+	c.BlockUntil(1) // returns once the goroutine is waiting
+	c.Advance(time.Second)
+}
@@ -1,51 +1,176 @@
 // Package main is the entry point for the distributed task scheduler API server.
 // It reads configuration from environment variables, connects to PostgreSQL,
-// and serves the HTTP API using Gin.
+// and serves the HTTP API using Gin until SIGINT or SIGTERM, when it drains
+// in-flight requests before exiting.
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/sauravritesh63/GoLang-Project-/internal/api"
-	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
-	pgRepo "github.com/sauravritesh63/GoLang-Project-/internal/repository/postgres"
-	pgdriver "gorm.io/driver/postgres"
-	"gorm.io/gorm"
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/ratelimit"
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/service"
+	"github.com/sauravritesh63/GoLang-Project-/internal/backend"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/queue"
 )
 
 func main() {
 	port := getEnv("PORT", "8080")
 
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL != "" {
-		db, err := gorm.Open(pgdriver.Open(dbURL), &gorm.Config{})
-		if err != nil {
-			log.Fatalf("failed to connect to postgres: %v", err)
-		}
-		r := api.NewRouter(
-			pgRepo.NewWorkflowRepo(db),
-			pgRepo.NewWorkflowRunRepo(db),
-			pgRepo.NewTaskRunRepo(db),
-			pgRepo.NewWorkerRepo(db),
-		)
-		log.Printf("API server listening on :%s (postgres)", port)
-		if err := r.Run(":" + port); err != nil {
-			log.Fatalf("server error: %v", err)
+	// API_READ_ONLY=true serves workflows and run history without letting
+	// anything change them, e.g. to a broad audience: write endpoints
+	// answer 403 and the database session refuses writes. DATABASE_READ_URL
+	// then points it at a replica or a restored snapshot, when set, instead
+	// of DATABASE_URL.
+	readOnly := false
+	if v := os.Getenv("API_READ_ONLY"); v != "" {
+		var err error
+		if readOnly, err = strconv.ParseBool(v); err != nil {
+			log.Fatalf("invalid API_READ_ONLY %q", v)
 		}
-	} else {
+	}
+
+	// DATABASE_URL is shared with the scheduler and worker: workflows and
+	// runs created here are picked up there.
+	open, databaseURL := backend.OpenStores, os.Getenv("DATABASE_URL")
+	if readOnly {
+		open, databaseURL = backend.OpenReadOnlyStores, getEnv("DATABASE_READ_URL", databaseURL)
+	}
+	stores, err := open(databaseURL)
+	if err != nil {
+		log.Fatalf("failed to open stores: %v", err)
+	}
+	mode := "postgres"
+	if !stores.Shared {
 		log.Println("DATABASE_URL not set — using in-memory repositories")
-		r := api.NewRouter(
-			mock.NewWorkflowRepo(),
-			mock.NewWorkflowRunRepo(),
-			mock.NewTaskRunRepo(),
-			mock.NewWorkerRepo(),
-		)
-		log.Printf("API server listening on :%s (in-memory)", port)
-		if err := r.Run(":" + port); err != nil {
-			log.Fatalf("server error: %v", err)
+		mode = "in-memory"
+	}
+	if readOnly {
+		mode += ", read-only"
+	}
+
+	// EVENTS_URL carries state changes from the scheduler and workers to
+	// WebSocket clients.
+	bus, err := events.Open(os.Getenv("EVENTS_URL"))
+	if err != nil {
+		log.Fatalf("failed to open event bus: %v", err)
+	}
+	// EVENT_HISTORY_RETENTION is how long the events published here are
+	// kept for GET /events/history (168h by default; 0 records none).
+	if bus, err = events.OpenHistory(bus, stores.Events, os.Getenv("EVENT_HISTORY_RETENTION")); err != nil {
+		log.Fatalf("invalid EVENT_HISTORY_RETENTION: %v", err)
+	}
+
+	// API_RATE_LIMITS caps requests per API key, e.g. "ci-token=120/10,*=600"
+	// (requests/triggers per minute). Usage is accounted even without it.
+	limits, err := ratelimit.ParseLimits(os.Getenv("API_RATE_LIMITS"))
+	if err != nil {
+		log.Fatalf("invalid API_RATE_LIMITS: %v", err)
+	}
+
+	// USAGE_RATES prices a second of task execution per resource class for
+	// GET /usage, e.g. "gpu=0.002,*=0.0001"; without it the report has no
+	// costs.
+	rates, err := service.ParseUsageRates(os.Getenv("USAGE_RATES"))
+	if err != nil {
+		log.Fatalf("invalid USAGE_RATES: %v", err)
+	}
+
+	// HTTP_READ_TIMEOUT, HTTP_READ_HEADER_TIMEOUT, HTTP_WRITE_TIMEOUT,
+	// HTTP_IDLE_TIMEOUT and SHUTDOWN_TIMEOUT take Go durations, e.g. "45s";
+	// TLS_CERT_FILE and TLS_KEY_FILE together switch the server to HTTPS.
+	cfg := api.ServerConfig{
+		Addr:              ":" + port,
+		ReadTimeout:       getDuration("HTTP_READ_TIMEOUT"),
+		ReadHeaderTimeout: getDuration("HTTP_READ_HEADER_TIMEOUT"),
+		WriteTimeout:      getDuration("HTTP_WRITE_TIMEOUT"),
+		IdleTimeout:       getDuration("HTTP_IDLE_TIMEOUT"),
+		ShutdownTimeout:   getDuration("SHUTDOWN_TIMEOUT"),
+		TLSCertFile:       os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:        os.Getenv("TLS_KEY_FILE"),
+	}
+
+	opts := []service.Option{
+		service.WithApprovals(stores.Approvals),
+		service.WithBackfills(stores.Backfills),
+		service.WithStats(stores.Stats),
+		service.WithLineage(stores.Lineage),
+		service.WithCalendars(stores.Calendars),
+		service.WithRetention(stores.Retention),
+		service.WithTasks(stores.Tasks, stores.TaskDeps),
+		service.WithWorkerNodes(stores.QueueWorkers, stores.QueueTasks, stores.Heartbeats),
+		service.WithAttempts(stores.Attempts),
+		service.WithDispatchFreeze(stores.Freeze),
+		service.WithSecrets(stores.Secrets),
+		service.WithEvents(bus),
+		service.WithEventHistory(stores.Events),
+		service.WithRateLimiter(ratelimit.New(limits)),
+		service.WithUsageRates(rates),
+	}
+	if readOnly {
+		opts = append(opts, service.WithReadOnly())
+	}
+
+	// With QUEUE_URL set, POST /tasks/{id}/test queues test executions on
+	// the worker group TASK_TEST_GROUP (task-test by default); start a
+	// worker with WORKER_GROUP set to it to run them. The same queues back
+	// POST /admin/queues/rebalance.
+	if url := os.Getenv("QUEUE_URL"); url != "" {
+		queues, err := queue.OpenGroups(url)
+		if err != nil {
+			log.Fatalf("failed to open queue: %v", err)
+		}
+		group := getEnv("TASK_TEST_GROUP", service.DefaultTestWorkerGroup)
+		q, err := queues.Queue(group)
+		if err != nil {
+			log.Fatalf("invalid TASK_TEST_GROUP: %v", err)
 		}
+		opts = append(opts, service.WithTaskTesting(q, group), service.WithQueues(queues))
+	}
+
+	srv, err := api.NewServer(cfg,
+		stores.Workflows,
+		stores.WorkflowRuns,
+		stores.TaskRuns,
+		stores.Workers,
+		opts...,
+	)
+	if err != nil {
+		log.Fatalf("invalid server configuration: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	scheme := "http"
+	if cfg.TLSCertFile != "" {
+		scheme = "https"
+	}
+	log.Printf("API server listening on :%s (%s, %s)", port, scheme, mode)
+	if err := srv.Run(ctx); err != nil {
+		log.Fatalf("server error: %v", err)
+	}
+	log.Println("API server stopped")
+}
+
+// getDuration parses the duration in environment variable key; unset is 0,
+// leaving the server's default.
+func getDuration(key string) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Fatalf("invalid %s %q", key, v)
 	}
+	return d
 }
 
 func getEnv(key, fallback string) string {
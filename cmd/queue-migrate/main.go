@@ -0,0 +1,120 @@
+// Package main is a one-shot tool that drains the tasks waiting in one queue
+// backend and republishes them to another, for moving to a new backend
+// without losing queued work:
+//
+//	queue-migrate -from file:queue.jsonl -to redis://redis:6379/0
+//	queue-migrate -from redis://old:6379/0 -to redis://new:6379/0?key=jobs
+//
+// Each side is a QUEUE_URL as the scheduler and workers take it, or
+// file:PATH for a queue snapshot of JSON lines. Stop the scheduler and
+// workers first so nothing is enqueued on or taken from the source
+// meanwhile.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/queue"
+)
+
+// snapshotPrefix marks a queue URL naming a snapshot file.
+const snapshotPrefix = "file:"
+
+func main() {
+	from := flag.String("from", "", "source queue URL, or file:PATH of a snapshot")
+	to := flag.String("to", "", "destination queue URL, or file:PATH to write a snapshot to")
+	dryRun := flag.Bool("dry-run", false, "report how many tasks are waiting without moving them")
+	flag.Parse()
+	if *from == "" || (*to == "" && !*dryRun) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	src, err := openSource(*from)
+	if err != nil {
+		log.Fatalf("open source: %v", err)
+	}
+	defer closeQueue(src)
+	if *dryRun {
+		n, err := src.Len(ctx)
+		if err != nil {
+			log.Fatalf("source length: %v", err)
+		}
+		log.Printf("%d tasks waiting in %s", n, *from)
+		return
+	}
+	dst, err := openSink(*to)
+	if err != nil {
+		log.Fatalf("open destination: %v", err)
+	}
+	moved, err := queue.Migrate(ctx, dst, src)
+	if cerr := closeQueue(dst); err == nil {
+		err = cerr
+	}
+	log.Printf("moved %d tasks from %s to %s", moved, *from, *to)
+	if err != nil {
+		log.Fatalf("migration stopped: %v", err)
+	}
+}
+
+// openSource returns the queue url names; a snapshot is loaded in full.
+func openSource(url string) (domain.Queue, error) {
+	path, ok := strings.CutPrefix(url, snapshotPrefix)
+	if !ok {
+		return queue.Open(url)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return queue.ReadSnapshot(f)
+}
+
+// openSink returns the queue url names, or a writer of a new snapshot.
+func openSink(url string) (queue.Sink, error) {
+	path, ok := strings.CutPrefix(url, snapshotPrefix)
+	if !ok {
+		return queue.Open(url)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotFile{SnapshotWriter: queue.NewSnapshotWriter(f), f: f}, nil
+}
+
+// snapshotFile is a snapshot being written, synced to disk on Close.
+type snapshotFile struct {
+	*queue.SnapshotWriter
+	f *os.File
+}
+
+func (s *snapshotFile) Close() error {
+	return errors.Join(s.f.Sync(), s.f.Close())
+}
+
+// closeQueue releases q's connections or file, if it holds any.
+func closeQueue(q any) error {
+	c, ok := q.(io.Closer)
+	if !ok {
+		return nil
+	}
+	if err := c.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return nil
+}
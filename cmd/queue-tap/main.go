@@ -0,0 +1,70 @@
+// Package main reads the tasks a Redis queue mirrors to its stream as one
+// consumer group and writes them to stdout as JSON lines, e.g. to feed an
+// analytics pipeline without taking tasks away from the workers:
+//
+//	queue-tap -queue 'redis://redis:6379/0?stream=100000' -group analytics >> tasks.jsonl
+//
+// The queue must be opened with the stream parameter wherever tasks are
+// enqueued. Each task is acknowledged once written, so a restarted tap
+// resumes where its group left off; the output is a queue snapshot that
+// queue-migrate can load.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/queue"
+)
+
+func main() {
+	hostname, _ := os.Hostname()
+	url := flag.String("queue", os.Getenv("QUEUE_URL"), "Redis queue URL; defaults to QUEUE_URL")
+	group := flag.String("group", "", "consumer group to read as")
+	consumer := flag.String("consumer", hostname, "consumer name within the group")
+	fromStart := flag.Bool("from-start", false, "start a new group at the oldest task kept instead of the next one")
+	flag.Parse()
+	if *url == "" || *group == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	g, err := queue.OpenConsumerGroup(ctx, *url, *group, *consumer, *fromStart)
+	if err != nil {
+		log.Fatalf("open consumer group: %v", err)
+	}
+	defer g.Close()
+
+	out := queue.NewSnapshotWriter(os.Stdout)
+	for {
+		d, err := g.Read(ctx)
+		if errors.Is(err, domain.ErrQueueEmpty) {
+			return
+		}
+		if err != nil {
+			log.Printf("read: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		if err := out.Enqueue(ctx, d.Task); err != nil {
+			log.Fatalf("write: %v", err)
+		}
+		if err := g.Ack(ctx, d.ID); err != nil {
+			log.Printf("ack %s: %v", d.ID, err)
+		}
+	}
+}
@@ -1,40 +1,119 @@
 // Package main is the entry point for the distributed task scheduler service.
-// It wires up the in-memory queue, task and worker repositories, the cron
-// trigger, and waits for shutdown. In production, replace the in-memory stores
-// with Redis / Postgres backed implementations via environment variables.
+// It connects to the stores and queue shared with the API and workers
+// (DATABASE_URL, QUEUE_URL), runs the cron trigger, backfiller, retention
+// job and workflow orchestrator, and waits for shutdown. With either variable unset it falls
+// back to in-memory stores visible only to this process. With LEADER_LOCK
+// set, replicas elect one leader to fire cron schedules and advance runs.
 package main
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
+	"strconv"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/sauravritesh63/GoLang-Project-/domain"
-	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/chaos"
+	"github.com/sauravritesh63/GoLang-Project-/internal/backend"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/leader"
+	"github.com/sauravritesh63/GoLang-Project-/internal/queue"
 	"github.com/sauravritesh63/GoLang-Project-/observability/metrics"
+	"github.com/sauravritesh63/GoLang-Project-/schedkit"
 	"github.com/sauravritesh63/GoLang-Project-/scheduler"
 )
 
 func main() {
 	metricsPort := getEnv("METRICS_PORT", "9090")
 
-	// Register Prometheus metrics for this scheduler process. The Collector is
-	// not stored because promauto registers all metrics with the default registry
-	// on construction; the /metrics handler will serve them automatically.
-	_ = metrics.New()
+	// Register Prometheus metrics for this scheduler process. promauto
+	// registers all metrics with the default registry on construction, so the
+	// /metrics handler serves them; the Collector is kept for the components
+	// that report to it.
+	collector := metrics.New()
+
+	// Circuit breaker — pauses dispatch for a task name or workflow after
+	// BREAKER_THRESHOLD consecutive failures (0 disables it). Open circuits
+	// close after BREAKER_COOLDOWN, or only via POST /breakers/reset if unset.
+	threshold, err := strconv.Atoi(getEnv("BREAKER_THRESHOLD", strconv.Itoa(scheduler.DefaultBreakerThreshold)))
+	if err != nil || threshold < 0 {
+		log.Fatalf("invalid BREAKER_THRESHOLD %q", os.Getenv("BREAKER_THRESHOLD"))
+	}
+	var cooldown time.Duration
+	if v := os.Getenv("BREAKER_COOLDOWN"); v != "" {
+		if cooldown, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("invalid BREAKER_COOLDOWN: %v", err)
+		}
+	}
+	var breaker *scheduler.CircuitBreaker
+	if threshold > 0 {
+		breaker = scheduler.NewCircuitBreaker(scheduler.BreakerConfig{Threshold: threshold, Cooldown: cooldown})
+	}
+
+	// BACKPRESSURE raises alerts on the event bus while the queue crosses a
+	// threshold, e.g. "queue_depth=1000,oldest_task_age=10m,failure_rate=0.2",
+	// and reports the scheduler degraded on /healthz meanwhile.
+	thresholds, err := scheduler.ParseThresholds(os.Getenv("BACKPRESSURE"))
+	if err != nil {
+		log.Fatalf("invalid BACKPRESSURE: %v", err)
+	}
+	alerts := events.NewAlertBoard()
+
+	// LEADER_LOCK, e.g. redis://redis:6379/0 or the DATABASE_URL, is the lock
+	// replicas of this binary elect a leader with: only the leader fires
+	// cron schedules and runs the scheduler, orchestrator and other loops
+	// that advance runs, and another replica takes over within
+	// LEADER_ELECTION_INTERVAL (5s by default) of it letting go. Unset, this
+	// instance always fires them; run a single replica then.
+	lock, err := leader.Open(os.Getenv("LEADER_LOCK"))
+	if err != nil {
+		log.Fatalf("invalid LEADER_LOCK: %v", err)
+	}
+	electionEvery := scheduler.DefaultElectionInterval
+	if v := os.Getenv("LEADER_ELECTION_INTERVAL"); v != "" {
+		if electionEvery, err = time.ParseDuration(v); err != nil || electionEvery <= 0 {
+			log.Fatalf("invalid LEADER_ELECTION_INTERVAL %q", v)
+		}
+	}
+	// built holds the engine once it is built, for /healthz to report
+	// leadership.
+	var built atomic.Pointer[schedkit.Engine]
 
 	// Expose /metrics and /healthz on a dedicated port.
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		body := map[string]any{"status": "ok", "service": "task-scheduler-scheduler"}
+		if e := built.Load(); e != nil && lock != nil {
+			body["leader"] = e.Leader()
+		}
+		if firing := alerts.Firing(); len(firing) > 0 {
+			body["status"], body["degraded"], body["alerts"] = "degraded", true, firing
+		}
 		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write([]byte(`{"status":"ok","service":"task-scheduler-scheduler"}`))
+		_ = json.NewEncoder(w).Encode(body)
 	})
+	if breaker != nil {
+		mux.HandleFunc("GET /breakers", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(breaker.States())
+		})
+		mux.HandleFunc("POST /breakers/reset", func(w http.ResponseWriter, r *http.Request) {
+			key := r.URL.Query().Get("key")
+			if key == "" {
+				http.Error(w, "missing key", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"key": key, "was_open": breaker.Reset(key)})
+		})
+	}
 	go func() {
 		log.Printf("Scheduler metrics server listening on :%s", metricsPort)
 		if err := http.ListenAndServe(":"+metricsPort, mux); err != nil && err != http.ErrServerClosed {
@@ -42,143 +121,311 @@ func main() {
 		}
 	}()
 
-	queue := scheduler.NewMemQueue()
-	taskRepo := newMemTaskRepo()
-	workerRepo := newMemWorkerRepo()
-
-	// In-memory workflow and workflow-run repositories (replace with Postgres in
-	// production). The CronTrigger reads active workflows at startup and
-	// schedules WorkflowRun creation according to each workflow's ScheduleCron
-	// field.
-	wfRepo := mock.NewWorkflowRepo()
-	wfRunRepo := mock.NewWorkflowRunRepo()
-
-	// Scheduler — validates and enqueues tasks.
-	sched := scheduler.New(taskRepo, workerRepo, queue)
-	log.Printf("Scheduler initialised (queue depth: %T)", sched)
+	// Workflows, runs and task state live in DATABASE_URL; dispatched tasks
+	// are pushed to QUEUE_URL for the workers.
+	stores, err := backend.OpenStores(os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatalf("failed to open stores: %v", err)
+	}
+	queues, err := queue.OpenGroups(os.Getenv("QUEUE_URL"))
+	if err != nil {
+		log.Fatalf("failed to open queue: %v", err)
+	}
+	if !stores.Shared || os.Getenv("QUEUE_URL") == "" {
+		log.Println("DATABASE_URL or QUEUE_URL not set — scheduler state is not shared with the API and workers")
+	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer cancel()
+	// PAYLOAD_STORE takes task payloads larger than PAYLOAD_OFFLOAD_BYTES
+	// (128 KiB by default) out of the queue messages, e.g.
+	// PAYLOAD_STORE=s3://bucket/payloads in front of SQS. Workers need the
+	// same PAYLOAD_STORE to load them back.
+	if url := os.Getenv("PAYLOAD_STORE"); url != "" {
+		store, err := queue.OpenPayloadStore(url)
+		if err != nil {
+			log.Fatalf("failed to open payload store: %v", err)
+		}
+		threshold, err := strconv.Atoi(getEnv("PAYLOAD_OFFLOAD_BYTES", "0"))
+		if err != nil || threshold < 0 {
+			log.Fatalf("invalid PAYLOAD_OFFLOAD_BYTES %q", os.Getenv("PAYLOAD_OFFLOAD_BYTES"))
+		}
+		if queues, err = queue.OffloadGroups(queues, store, threshold); err != nil {
+			log.Fatalf("failed to open queue: %v", err)
+		}
+	}
+	bus, err := events.Open(os.Getenv("EVENTS_URL"))
+	if err != nil {
+		log.Fatalf("failed to open event bus: %v", err)
+	}
+	// EVENT_HISTORY_RETENTION is how long the events published here are
+	// kept for GET /events/history (168h by default; 0 records none).
+	if bus, err = events.OpenHistory(bus, stores.Events, os.Getenv("EVENT_HISTORY_RETENTION")); err != nil {
+		log.Fatalf("invalid EVENT_HISTORY_RETENTION: %v", err)
+	}
 
-	// CronTrigger — creates WorkflowRuns on schedule.
-	ct := scheduler.NewCronTrigger(wfRepo, wfRunRepo)
-	if err := ct.Start(ctx); err != nil {
-		log.Printf("CronTrigger: failed to start: %v", err)
+	// CHAOS injects faults for staging, e.g.
+	// CHAOS="queue_errors=0.05,handler_failures=0.1,heartbeat_drops=0.3"; the
+	// chaos package lists the keys. GET /debug/chaos counts what was injected.
+	chaosCfg, err := chaos.ParseConfig(os.Getenv("CHAOS"))
+	if err != nil {
+		log.Fatalf("invalid CHAOS: %v", err)
+	}
+	var injector *chaos.Injector
+	if chaosCfg.Enabled() {
+		injector = chaos.New(chaosCfg)
+		log.Printf("CHAOS enabled — injecting faults: %+v", chaosCfg)
+		mux.HandleFunc("GET /debug/chaos", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(injector.Stats())
+		})
 	}
-	defer ct.Stop()
 
-	log.Println("Scheduler service started; waiting for shutdown signal")
-	<-ctx.Done()
-	log.Println("Scheduler service stopped")
-}
+	// Resource pools cap how many tasks referencing each pool run at once,
+	// e.g. POOLS="warehouse=4,gpu=2".
+	poolSizes, err := scheduler.ParsePools(os.Getenv("POOLS"))
+	if err != nil {
+		log.Fatalf("invalid POOLS: %v", err)
+	}
+	schedOpts := []scheduler.Option{
+		scheduler.WithPools(scheduler.NewPools(poolSizes)),
+		scheduler.WithPauseObserver(collector),
+	}
+	if breaker != nil {
+		schedOpts = append(schedOpts, scheduler.WithCircuitBreaker(breaker))
+	}
 
-func getEnv(key, fallback string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+	// CONCURRENCY_LIMITS caps how many tasks run at once: in total under
+	// "*", per task name or per workflow ID, e.g.
+	// CONCURRENCY_LIMITS="*=50,task:load-warehouse=2,workflow:<id>=4".
+	limits, err := scheduler.ParseConcurrencyLimits(os.Getenv("CONCURRENCY_LIMITS"))
+	if err != nil {
+		log.Fatalf("invalid CONCURRENCY_LIMITS: %v", err)
+	}
+	if len(limits) > 0 {
+		schedOpts = append(schedOpts, scheduler.WithConcurrencyLimits(scheduler.NewConcurrencyLimits(limits)))
 	}
-	return fallback
-}
 
-// ── in-memory stores (replace with Redis/Postgres in production) ──────────────
+	// SUBMIT_RATE_LIMITS caps task submissions per second, per task name or
+	// for every task under "*", e.g. SUBMIT_RATE_LIMITS="*=100,send-email=5:20"
+	// with an optional burst after the colon.
+	rateLimits, err := scheduler.ParseRateLimits(os.Getenv("SUBMIT_RATE_LIMITS"))
+	if err != nil {
+		log.Fatalf("invalid SUBMIT_RATE_LIMITS: %v", err)
+	}
+	if len(rateLimits) > 0 {
+		schedOpts = append(schedOpts, scheduler.WithRateLimits(scheduler.NewSubmitLimiter(rateLimits)))
+	}
 
-type memTaskRepo struct {
-	mu    sync.RWMutex
-	store map[string]*domain.Task
-}
+	// SUBMIT_IDEMPOTENCY_WINDOW, e.g. 10m, rejects a task submitted less
+	// than that long after another task it duplicates: one with the same
+	// idempotency key, or with SUBMIT_DEDUP_BY=content also one doing the
+	// same work in the same run.
+	var idemWindow time.Duration
+	if v := os.Getenv("SUBMIT_IDEMPOTENCY_WINDOW"); v != "" {
+		if idemWindow, err = time.ParseDuration(v); err != nil || idemWindow <= 0 {
+			log.Fatalf("invalid SUBMIT_IDEMPOTENCY_WINDOW %q", v)
+		}
+		schedOpts = append(schedOpts, scheduler.WithIdempotencyWindow(idemWindow))
+	}
+	dedupMode, err := scheduler.ParseDedupMode(getEnv("SUBMIT_DEDUP_BY", string(scheduler.DedupByKey)))
+	if err != nil {
+		log.Fatalf("invalid SUBMIT_DEDUP_BY: %v", err)
+	}
+	if dedupMode == scheduler.DedupByContent && idemWindow == 0 {
+		log.Fatal("SUBMIT_DEDUP_BY=content needs SUBMIT_IDEMPOTENCY_WINDOW")
+	}
+	schedOpts = append(schedOpts, scheduler.WithDedupMode(dedupMode))
 
-func newMemTaskRepo() *memTaskRepo {
-	return &memTaskRepo{store: make(map[string]*domain.Task)}
-}
+	// STICKY_ROUTING, e.g. 30s, pins tasks with a routing key to one of the
+	// workers of their group that heartbeated within that long.
+	// CAPACITY_ASSIGNMENT, e.g. 30s, assigns every other task to the one of
+	// those workers with the most free slots. Workers need the same settings
+	// to serve the queue their tasks are routed to. HEARTBEAT_TIMEOUT, 45s
+	// by default, is how long a worker may go without a heartbeat before it
+	// is marked offline; keep it a few times the workers'
+	// HEARTBEAT_INTERVAL.
+	var sticky, assign, hbTimeout time.Duration
+	for key, d := range map[string]*time.Duration{
+		"STICKY_ROUTING":      &sticky,
+		"CAPACITY_ASSIGNMENT": &assign,
+		"HEARTBEAT_TIMEOUT":   &hbTimeout,
+	} {
+		if v := os.Getenv(key); v != "" {
+			if *d, err = time.ParseDuration(v); err != nil || *d <= 0 {
+				log.Fatalf("invalid %s %q", key, v)
+			}
+		}
+	}
 
-func (r *memTaskRepo) Save(_ context.Context, t *domain.Task) error {
-	r.mu.Lock()
-	cp := *t
-	r.store[t.ID] = &cp
-	r.mu.Unlock()
-	return nil
-}
+	// ORPHANED_TASKS decides what the reaper does with tasks left running
+	// by a worker past HEARTBEAT_TIMEOUT: "retry" (the default) queues them
+	// again while they have retries left, "requeue" always does, "fail"
+	// fails them and "ignore" leaves them running.
+	orphans := scheduler.OrphanPolicy(getEnv("ORPHANED_TASKS", string(scheduler.OrphanRetry)))
+	if !orphans.Valid() {
+		log.Fatalf("invalid ORPHANED_TASKS %q: want retry, requeue, fail or ignore", orphans)
+	}
 
-func (r *memTaskRepo) FindByID(_ context.Context, id string) (*domain.Task, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	t, ok := r.store[id]
-	if !ok {
-		return nil, domain.ErrTaskNotFound
+	// STALE_RUN_TIMEOUT, e.g. 1h, turns on the run janitor: workflow runs
+	// left pending, or running without a task dispatched, for that long are
+	// handled per STALE_RUN_POLICY: "resubmit" (the default) starts them or
+	// submits their lost tasks again and fails them at twice the timeout,
+	// "fail" fails them at once.
+	var staleAfter time.Duration
+	if v := os.Getenv("STALE_RUN_TIMEOUT"); v != "" {
+		if staleAfter, err = time.ParseDuration(v); err != nil || staleAfter < 0 {
+			log.Fatalf("invalid STALE_RUN_TIMEOUT %q", v)
+		}
+	}
+	stalePolicy := scheduler.StalePolicy(getEnv("STALE_RUN_POLICY", string(scheduler.StaleResubmit)))
+	if !stalePolicy.Valid() {
+		log.Fatalf("invalid STALE_RUN_POLICY %q: want resubmit or fail", stalePolicy)
 	}
-	cp := *t
-	return &cp, nil
-}
 
-func (r *memTaskRepo) FindByStatus(_ context.Context, status domain.TaskStatus) ([]*domain.Task, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	var out []*domain.Task
-	for _, t := range r.store {
-		if t.Status == status {
-			cp := *t
-			out = append(out, &cp)
+	// CRON_JITTER, e.g. 5m, spreads the fires of workflows sharing a
+	// schedule over that long. CRON_MISFIRE=skip records a fire the
+	// trigger wakes up for more than CRON_MISFIRE_GRACE (1m by default)
+	// late as a skipped run instead of starting it late.
+	var cronOpts []scheduler.CronTriggerOption
+	if v := os.Getenv("CRON_JITTER"); v != "" {
+		jitter, err := time.ParseDuration(v)
+		if err != nil || jitter < 0 {
+			log.Fatalf("invalid CRON_JITTER %q", v)
 		}
+		cronOpts = append(cronOpts, scheduler.WithJitter(jitter))
 	}
-	return out, nil
-}
+	misfire, err := scheduler.ParseMisfirePolicy(os.Getenv("CRON_MISFIRE"))
+	if err != nil {
+		log.Fatalf("invalid CRON_MISFIRE: %v", err)
+	}
+	var grace time.Duration
+	if v := os.Getenv("CRON_MISFIRE_GRACE"); v != "" {
+		if grace, err = time.ParseDuration(v); err != nil || grace <= 0 {
+			log.Fatalf("invalid CRON_MISFIRE_GRACE %q", v)
+		}
+	}
+	cronOpts = append(cronOpts, scheduler.WithMisfirePolicy(misfire, grace))
 
-func (r *memTaskRepo) Delete(_ context.Context, id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if _, ok := r.store[id]; !ok {
-		return domain.ErrTaskNotFound
+	// CRON_RELOAD_INTERVAL (1m by default; 0 disables) is how often the
+	// trigger reloads every schedule, on top of the reload each
+	// workflow_changed event on the bus causes, so workflows changed
+	// outside the API are picked up too.
+	reloadEvery := scheduler.DefaultCronReloadInterval
+	if v := os.Getenv("CRON_RELOAD_INTERVAL"); v != "" {
+		if reloadEvery, err = time.ParseDuration(v); err != nil || reloadEvery < 0 {
+			log.Fatalf("invalid CRON_RELOAD_INTERVAL %q", v)
+		}
 	}
-	delete(r.store, id)
-	return nil
-}
+	cronOpts = append(cronOpts, scheduler.WithReloadInterval(reloadEvery))
 
-type memWorkerRepo struct {
-	mu    sync.RWMutex
-	store map[string]*domain.Worker
-}
+	// The engine runs the dispatch loop, the cron and dataset triggers, the
+	// backfiller, the retention job, the worker reaper and the orchestrator
+	// that starts runs created by the API and submits their tasks in
+	// dependency order.
+	engine := schedkit.New(
+		schedkit.WithStores(stores),
+		schedkit.WithGroupQueues(queues),
+		schedkit.WithBus(bus),
+		schedkit.WithSchedulerOptions(schedOpts...),
+		schedkit.WithCronOptions(cronOpts...),
+		schedkit.WithoutWorker(),
+		schedkit.WithChaos(injector),
+		schedkit.WithBackpressure(thresholds),
+		schedkit.WithStickyRouting(sticky),
+		schedkit.WithCapacityAssignment(assign),
+		schedkit.WithHeartbeatTimeout(hbTimeout),
+		schedkit.WithOrphanPolicy(orphans),
+		schedkit.WithStaleRunJanitor(staleAfter, stalePolicy, scheduler.WithStaleRunObserver(collector)),
+		schedkit.WithLeaderElection(lock, scheduler.WithElectionInterval(electionEvery)),
+	)
+	built.Store(engine)
 
-func newMemWorkerRepo() *memWorkerRepo {
-	return &memWorkerRepo{store: make(map[string]*domain.Worker)}
-}
+	// GET /dispatch on the metrics port reports whether dispatching is
+	// paused; POST /dispatch/pause stops this replica dispatching tasks,
+	// which are still accepted and held pending, and POST /dispatch/resume
+	// dispatches them.
+	sched := engine.Scheduler()
+	dispatchStatus := func(w http.ResponseWriter) {
+		paused, since := sched.Paused()
+		body := map[string]any{"paused": paused}
+		if paused {
+			body["paused_since"] = since
+		}
+		if sched.Draining() {
+			body["draining"] = true
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}
+	mux.HandleFunc("GET /dispatch", func(w http.ResponseWriter, _ *http.Request) {
+		dispatchStatus(w)
+	})
+	mux.HandleFunc("POST /dispatch/pause", func(w http.ResponseWriter, r *http.Request) {
+		sched.Pause(r.Context())
+		dispatchStatus(w)
+	})
+	mux.HandleFunc("POST /dispatch/resume", func(w http.ResponseWriter, r *http.Request) {
+		sched.Resume(r.Context())
+		dispatchStatus(w)
+	})
 
-func (r *memWorkerRepo) Save(_ context.Context, w *domain.Worker) error {
-	r.mu.Lock()
-	cp := *w
-	r.store[w.ID] = &cp
-	r.mu.Unlock()
-	return nil
-}
+	// /debug/scheduler on the metrics port reports queue depths, held and
+	// in-flight tasks, dispatch loop timing and upcoming cron fires.
+	mux.Handle("GET /debug/scheduler", &scheduler.Inspector{
+		Scheduler: engine.Scheduler(), Cron: engine.CronTrigger(), Queue: queues, Tasks: stores.QueueTasks,
+	})
 
-func (r *memWorkerRepo) FindByID(_ context.Context, id string) (*domain.Worker, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	w, ok := r.store[id]
-	if !ok {
-		return nil, domain.ErrWorkerNotFound
+	// DRAIN_TIMEOUT (25s by default; 0 disables) is how long a shutdown
+	// signal waits for the workers to empty the queue and finish their
+	// running tasks before the scheduler stops; tasks submitted meanwhile
+	// stay pending for the next scheduler. Without QUEUE_URL no worker can
+	// reach the queue, so there is nothing to wait for.
+	drainTimeout := 25 * time.Second
+	if v := os.Getenv("DRAIN_TIMEOUT"); v != "" {
+		if drainTimeout, err = time.ParseDuration(v); err != nil || drainTimeout < 0 {
+			log.Fatalf("invalid DRAIN_TIMEOUT %q", v)
+		}
 	}
-	cp := *w
-	return &cp, nil
-}
+	if os.Getenv("QUEUE_URL") == "" {
+		drainTimeout = 0
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	// The engine keeps running while it drains: the dispatch loop is what
+	// notices tasks finishing.
+	runCtx, stopRun := context.WithCancel(context.Background())
+	defer stopRun()
+	go func() {
+		<-ctx.Done()
+		if drainTimeout > 0 {
+			dctx, cancelDrain := context.WithTimeout(context.Background(), drainTimeout)
+			if err := sched.Drain(dctx); err != nil {
+				log.Printf("Scheduler: %v; stopping anyway", err)
+			}
+			cancelDrain()
+		}
+		stopRun()
+	}()
 
-func (r *memWorkerRepo) FindAvailable(_ context.Context) ([]*domain.Worker, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	var out []*domain.Worker
-	for _, w := range r.store {
-		if w.HasCapacity() {
-			cp := *w
-			out = append(out, &cp)
+	// /healthz follows the alerts on the bus, as the API's does.
+	if thresholds.Enabled() {
+		sub, err := bus.Subscribe(ctx)
+		if err != nil {
+			log.Fatalf("failed to subscribe to event bus: %v", err)
 		}
+		go alerts.Watch(sub)
+	}
+
+	log.Println("Scheduler service started; waiting for shutdown signal")
+	if err := engine.Run(runCtx); err != nil {
+		log.Fatalf("scheduler error: %v", err)
 	}
-	return out, nil
+	log.Println("Scheduler service stopped")
 }
 
-func (r *memWorkerRepo) Delete(_ context.Context, id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if _, ok := r.store[id]; !ok {
-		return domain.ErrWorkerNotFound
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
 	}
-	delete(r.store, id)
-	return nil
+	return fallback
 }
@@ -1,32 +1,52 @@
 // Package main is the entry point for the distributed task worker service.
-// It wires up the in-memory queue and repositories, registers the worker, and
-// processes tasks until a shutdown signal is received.
+// It connects to the queue and task store shared with the scheduler
+// (QUEUE_URL, DATABASE_URL), registers the worker, and processes tasks until a
+// shutdown signal is received.
 package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sauravritesh63/GoLang-Project-/chaos"
 	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/backend"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/queue"
 	"github.com/sauravritesh63/GoLang-Project-/observability/metrics"
-	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+	"github.com/sauravritesh63/GoLang-Project-/schedkit"
 	"github.com/sauravritesh63/GoLang-Project-/worker"
 )
 
 func main() {
 	workerID := getEnv("WORKER_ID", "worker-1")
+	// WORKER_GROUP dedicates the worker to the workflows of one worker
+	// group; unset, it serves the default group.
+	workerGroup := os.Getenv("WORKER_GROUP")
+	// WORKER_QUEUES, a comma-separated list such as "gpu,etl-heavy",
+	// dedicates it to the tasks routed to those named queues instead.
+	var workerQueues []string
+	for _, name := range strings.Split(os.Getenv("WORKER_QUEUES"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			workerQueues = append(workerQueues, name)
+		}
+	}
 	metricsPort := getEnv("METRICS_PORT", "9091")
 
-	// Register Prometheus metrics for this worker process. The Collector is not
-	// stored because promauto registers all metrics with the default registry on
-	// construction; the /metrics handler will serve them automatically.
-	_ = metrics.New()
+	// Register Prometheus metrics for this worker process. promauto registers
+	// all metrics with the default registry on construction, so the /metrics
+	// handler serves them; the worker reports each attempt's timing to it.
+	collector := metrics.New()
 
 	// Expose /metrics and /healthz on a dedicated port so Prometheus can scrape
 	// this service independently from the API server.
@@ -43,129 +63,238 @@ func main() {
 		}
 	}()
 
-	queue := scheduler.NewMemQueue()
-	taskRepo := newMemTaskRepo()
-	workerRepo := newMemWorkerRepo()
-
-	w := worker.New(workerID, queue, taskRepo, workerRepo, worker.MockShellHandler)
-
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer cancel()
+	// Tasks are dequeued from QUEUE_URL and their status is written to
+	// DATABASE_URL, where the scheduler reads it back. With either unset this
+	// process only sees tasks it enqueues itself.
+	stores, err := backend.OpenStores(os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatalf("failed to open stores: %v", err)
+	}
+	queues, err := queue.OpenGroups(os.Getenv("QUEUE_URL"))
+	if err != nil {
+		log.Fatalf("failed to open queue: %v", err)
+	}
+	if !stores.Shared || os.Getenv("QUEUE_URL") == "" {
+		log.Println("DATABASE_URL or QUEUE_URL not set — worker state is not shared with the scheduler")
+	}
+	bus, err := events.Open(os.Getenv("EVENTS_URL"))
+	if err != nil {
+		log.Fatalf("failed to open event bus: %v", err)
+	}
+	// EVENT_HISTORY_RETENTION is how long the events published here are
+	// kept for GET /events/history (168h by default; 0 records none).
+	if bus, err = events.OpenHistory(bus, stores.Events, os.Getenv("EVENT_HISTORY_RETENTION")); err != nil {
+		log.Fatalf("invalid EVENT_HISTORY_RETENTION: %v", err)
+	}
+	concurrency, err := strconv.Atoi(getEnv("WORKER_CONCURRENCY", "1"))
+	if err != nil || concurrency < 1 {
+		log.Fatalf("invalid WORKER_CONCURRENCY %q", os.Getenv("WORKER_CONCURRENCY"))
+	}
 
-	log.Printf("Worker %s starting", workerID)
-	if err := w.Run(ctx); err != nil {
-		log.Fatalf("worker error: %v", err)
+	// CHAOS injects faults for staging, e.g.
+	// CHAOS="queue_errors=0.05,handler_failures=0.1,heartbeat_drops=0.3"; the
+	// chaos package lists the keys. GET /debug/chaos counts what was injected.
+	chaosCfg, err := chaos.ParseConfig(os.Getenv("CHAOS"))
+	if err != nil {
+		log.Fatalf("invalid CHAOS: %v", err)
+	}
+	var injector *chaos.Injector
+	if chaosCfg.Enabled() {
+		injector = chaos.New(chaosCfg)
+		log.Printf("CHAOS enabled — injecting faults: %+v", chaosCfg)
+		mux.HandleFunc("GET /debug/chaos", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(injector.Stats())
+		})
 	}
-	log.Printf("Worker %s stopped", workerID)
-}
 
-func getEnv(key, fallback string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+	// NOTIFY_WEBHOOKS names the notifiers task notify hooks can use, e.g.
+	// NOTIFY_WEBHOOKS="ops=https://hooks.slack.com/services/...,data=https://...".
+	var workerOpts []worker.Option
+	for _, entry := range strings.Split(os.Getenv("NOTIFY_WEBHOOKS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, url, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || url == "" {
+			log.Fatalf("invalid NOTIFY_WEBHOOKS entry %q: want name=url", entry)
+		}
+		workerOpts = append(workerOpts, worker.WithNotifier(name, worker.WebhookNotifier{URL: url}))
 	}
-	return fallback
-}
 
-// ── in-memory stores (replace with Redis/Postgres in production) ──────────────
+	// WORKER_LONG_POLL, WORKER_POLL_INTERVAL and WORKER_MAX_IDLE_BACKOFF
+	// tune how the worker polls its queue (see worker.WithPolling).
+	var poll domain.PollConfig
+	for key, d := range map[string]*time.Duration{
+		"WORKER_LONG_POLL":        &poll.LongPoll,
+		"WORKER_POLL_INTERVAL":    &poll.Interval,
+		"WORKER_MAX_IDLE_BACKOFF": &poll.MaxIdleBackoff,
+	} {
+		if v := os.Getenv(key); v != "" {
+			if *d, err = time.ParseDuration(v); err != nil {
+				log.Fatalf("invalid %s %q: %v", key, v, err)
+			}
+		}
+	}
+	if err := poll.Validate(); err != nil {
+		log.Fatalf("invalid worker polling: %v", err)
+	}
+	workerOpts = append(workerOpts, worker.WithPolling(poll), worker.WithAttemptObserver(collector))
 
-type memTaskRepo struct {
-	mu    sync.RWMutex
-	store map[string]*domain.Task
-}
+	// HEARTBEAT_INTERVAL, 15s by default, is how often the worker records a
+	// heartbeat; the scheduler's HEARTBEAT_TIMEOUT should span a few.
+	if v := os.Getenv("HEARTBEAT_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			log.Fatalf("invalid HEARTBEAT_INTERVAL %q", v)
+		}
+		workerOpts = append(workerOpts, worker.WithHeartbeatInterval(interval))
+	}
 
-func newMemTaskRepo() *memTaskRepo {
-	return &memTaskRepo{store: make(map[string]*domain.Task)}
-}
+	// WORKER_HEALTH makes the worker hold back as its attempts fail, e.g.
+	// WORKER_HEALTH="quarantine_at=0.5,quarantine=10m,max_poll_delay=5s".
+	health, err := worker.ParseHealthPolicy(os.Getenv("WORKER_HEALTH"))
+	if err != nil {
+		log.Fatalf("invalid WORKER_HEALTH: %v", err)
+	}
+	workerOpts = append(workerOpts, worker.WithHealthPolicy(health))
 
-func (r *memTaskRepo) Save(_ context.Context, t *domain.Task) error {
-	r.mu.Lock()
-	cp := *t
-	r.store[t.ID] = &cp
-	r.mu.Unlock()
-	return nil
-}
+	// On a Redis queue retries wait out their backoff in the group queue's
+	// retry set, released WORKER_RETRY_BATCH at a time (0 releases them
+	// all) every WORKER_RETRY_POLL; in process, and on a worker serving
+	// WORKER_QUEUES, they wait on timers.
+	intake, err := queues.Queue(workerGroup)
+	if err != nil {
+		log.Fatalf("failed to open queue: %v", err)
+	}
+	if rq, ok := intake.(*queue.RedisQueue); ok && len(workerQueues) == 0 {
+		retryPoll, err := time.ParseDuration(getEnv("WORKER_RETRY_POLL", "1s"))
+		if err != nil {
+			log.Fatalf("invalid WORKER_RETRY_POLL %q: %v", os.Getenv("WORKER_RETRY_POLL"), err)
+		}
+		retryBatch, err := strconv.Atoi(getEnv("WORKER_RETRY_BATCH", "0"))
+		if err != nil || retryBatch < 0 {
+			log.Fatalf("invalid WORKER_RETRY_BATCH %q", os.Getenv("WORKER_RETRY_BATCH"))
+		}
+		workerOpts = append(workerOpts, worker.WithRetryQueue(queue.NewRedisRetryQueue(rq), retryPoll, retryBatch))
+	}
 
-func (r *memTaskRepo) FindByID(_ context.Context, id string) (*domain.Task, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	t, ok := r.store[id]
-	if !ok {
-		return nil, domain.ErrTaskNotFound
+	// PAYLOAD_STORE, as the scheduler has it, holds the payloads too large
+	// for the queue; retries enqueued here are offloaded the same way.
+	if url := os.Getenv("PAYLOAD_STORE"); url != "" {
+		store, err := queue.OpenPayloadStore(url)
+		if err != nil {
+			log.Fatalf("failed to open payload store: %v", err)
+		}
+		threshold, err := strconv.Atoi(getEnv("PAYLOAD_OFFLOAD_BYTES", "0"))
+		if err != nil || threshold < 0 {
+			log.Fatalf("invalid PAYLOAD_OFFLOAD_BYTES %q", os.Getenv("PAYLOAD_OFFLOAD_BYTES"))
+		}
+		if queues, err = queue.OffloadGroups(queues, store, threshold); err != nil {
+			log.Fatalf("failed to open queue: %v", err)
+		}
+		workerOpts = append(workerOpts, worker.WithPayloadStore(store))
 	}
-	cp := *t
-	return &cp, nil
-}
 
-func (r *memTaskRepo) FindByStatus(_ context.Context, status domain.TaskStatus) ([]*domain.Task, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	var out []*domain.Task
-	for _, t := range r.store {
-		if t.Status == status {
-			cp := *t
-			out = append(out, &cp)
+	// WORKER_PROFILES is a JSON file of the isolation profiles tasks may
+	// name (see worker.ParseProfiles); WORKER_EXECUTOR picks what runs task
+	// commands: "mock" (the default), "shell", or "docker" in containers of
+	// WORKER_DOCKER_IMAGE unless the task's profile names an image.
+	if path := os.Getenv("WORKER_PROFILES"); path != "" {
+		profiles, err := worker.LoadProfiles(path)
+		if err != nil {
+			log.Fatalf("invalid WORKER_PROFILES: %v", err)
 		}
+		workerOpts = append(workerOpts, worker.WithProfiles(profiles...))
+	}
+	var handler schedkit.Handler
+	switch executor := getEnv("WORKER_EXECUTOR", "mock"); executor {
+	case "mock":
+		handler = worker.MockShellHandler
+	case "shell":
+		handler = worker.ShellHandler
+	case "docker":
+		handler = worker.DockerHandler(os.Getenv("WORKER_DOCKER_IMAGE"))
+	default:
+		log.Fatalf("invalid WORKER_EXECUTOR %q: want mock, shell or docker", executor)
 	}
-	return out, nil
-}
 
-func (r *memTaskRepo) Delete(_ context.Context, id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if _, ok := r.store[id]; !ok {
-		return domain.ErrTaskNotFound
+	// STICKY_ROUTING and CAPACITY_ASSIGNMENT, set as on the scheduler, make
+	// the worker also serve the queue of the tasks routed to it.
+	var sticky, assign time.Duration
+	for key, d := range map[string]*time.Duration{"STICKY_ROUTING": &sticky, "CAPACITY_ASSIGNMENT": &assign} {
+		if v := os.Getenv(key); v != "" {
+			if *d, err = time.ParseDuration(v); err != nil || *d <= 0 {
+				log.Fatalf("invalid %s %q", key, v)
+			}
+		}
 	}
-	delete(r.store, id)
-	return nil
-}
 
-type memWorkerRepo struct {
-	mu    sync.RWMutex
-	store map[string]*domain.Worker
-}
+	engine := schedkit.New(
+		schedkit.WithStores(stores),
+		schedkit.WithGroupQueues(queues),
+		schedkit.WithBus(bus),
+		schedkit.WithoutScheduler(),
+		schedkit.WithWorkerID(workerID),
+		schedkit.WithWorkerGroup(workerGroup),
+		schedkit.WithWorkerQueues(workerQueues...),
+		schedkit.WithConcurrency(concurrency),
+		schedkit.WithHandler(handler),
+		schedkit.WithChaos(injector),
+		schedkit.WithWorkerOptions(workerOpts...),
+		schedkit.WithStickyRouting(sticky),
+		schedkit.WithCapacityAssignment(assign),
+	)
 
-func newMemWorkerRepo() *memWorkerRepo {
-	return &memWorkerRepo{store: make(map[string]*domain.Worker)}
-}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
 
-func (r *memWorkerRepo) Save(_ context.Context, w *domain.Worker) error {
-	r.mu.Lock()
-	cp := *w
-	r.store[w.ID] = &cp
-	r.mu.Unlock()
-	return nil
-}
+	// The engine's triggerer resumes deferred tasks when their external
+	// operation finishes. Operations without a poller report completion via
+	// POST /deferred/{id}/complete with {"result": ..., "error": "..."}.
+	trig := engine.Triggerer()
+	mux.HandleFunc("POST /deferred/{id}/complete", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Result json.RawMessage `json:"result"`
+			Error  string          `json:"error"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var opErr error
+		if body.Error != "" {
+			opErr = errors.New(body.Error)
+		}
+		switch err := trig.Complete(r.Context(), r.PathValue("id"), body.Result, opErr); {
+		case errors.Is(err, domain.ErrTaskNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, worker.ErrNotDeferred):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
 
-func (r *memWorkerRepo) FindByID(_ context.Context, id string) (*domain.Worker, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	w, ok := r.store[id]
-	if !ok {
-		return nil, domain.ErrWorkerNotFound
+	switch {
+	case len(workerQueues) > 0:
+		log.Printf("Worker %s starting on queues %s", workerID, strings.Join(workerQueues, ", "))
+	case workerGroup != "":
+		log.Printf("Worker %s starting in worker group %s", workerID, workerGroup)
+	default:
+		log.Printf("Worker %s starting", workerID)
 	}
-	cp := *w
-	return &cp, nil
-}
-
-func (r *memWorkerRepo) FindAvailable(_ context.Context) ([]*domain.Worker, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	var out []*domain.Worker
-	for _, w := range r.store {
-		if w.HasCapacity() {
-			cp := *w
-			out = append(out, &cp)
-		}
+	if err := engine.Run(ctx); err != nil {
+		log.Fatalf("worker error: %v", err)
 	}
-	return out, nil
+	log.Printf("Worker %s stopped", workerID)
 }
 
-func (r *memWorkerRepo) Delete(_ context.Context, id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if _, ok := r.store[id]; !ok {
-		return domain.ErrWorkerNotFound
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
 	}
-	delete(r.store, id)
-	return nil
+	return fallback
 }
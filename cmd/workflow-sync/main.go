@@ -0,0 +1,151 @@
+// Package main promotes workflow definitions from one deployment to another,
+// e.g. staging to prod. It prints how the target's workflows differ from the
+// source's and, with -apply, makes them match:
+//
+//	workflow-sync -from http://staging:8080 -to http://prod:8080
+//	workflow-sync -from defs.json -to http://prod:8080 -apply -H 'X-User: release' -H 'X-Roles: admin'
+//
+// The source is an API base URL or a bundle saved from GET
+// /workflow-definitions. Only definitions are copied; run history stays
+// where it is. Applying needs the admin role on the target and fails if its
+// workflows changed between the plan and the apply.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/service"
+)
+
+// headers collects repeated -H flags.
+type headers []string
+
+func (h *headers) String() string     { return strings.Join(*h, ", ") }
+func (h *headers) Set(v string) error { *h = append(*h, v); return nil }
+
+func main() {
+	var hdrs headers
+	from := flag.String("from", "", "source API base URL, or a bundle file")
+	to := flag.String("to", "", "target API base URL")
+	apply := flag.Bool("apply", false, "apply the plan instead of only printing it")
+	flag.Var(&hdrs, "H", "header to send to both deployments, as 'Name: value' (repeatable)")
+	flag.Parse()
+	if *from == "" || *to == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	c := &client{http: &http.Client{Timeout: time.Minute}, header: http.Header{}}
+	for _, h := range hdrs {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			log.Fatalf("header %q: want 'Name: value'", h)
+		}
+		c.header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	bundle, err := c.bundle(*from)
+	if err != nil {
+		log.Fatalf("read definitions: %v", err)
+	}
+	var plan service.SyncPlan
+	if err := c.post(*to+"/workflow-definitions/plan", bundle, &plan); err != nil {
+		log.Fatalf("plan: %v", err)
+	}
+	report(os.Stdout, &plan)
+	if !*apply {
+		return
+	}
+	target := *to + "/workflow-definitions/apply?fingerprint=" + url.QueryEscape(plan.Fingerprint)
+	if err := c.post(target, bundle, &plan); err != nil {
+		log.Fatalf("apply: %v", err)
+	}
+	fmt.Println("applied")
+}
+
+type client struct {
+	http   *http.Client
+	header http.Header
+}
+
+// bundle returns the definitions at src: an API's export, or a file.
+func (c *client) bundle(src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return os.ReadFile(src)
+	}
+	req, err := http.NewRequest(http.MethodGet, src+"/workflow-definitions", nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+// post sends body to url and decodes the JSON response into out.
+func (c *client) post(url string, body []byte, out any) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	b, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func (c *client) do(req *http.Request) ([]byte, error) {
+	for name, values := range c.header {
+		req.Header[name] = values
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, bytes.TrimSpace(b))
+	}
+	return b, nil
+}
+
+// report prints plan as a diff, one workflow per paragraph.
+func report(w io.Writer, plan *service.SyncPlan) {
+	for _, wf := range plan.Workflows {
+		switch wf.Action {
+		case service.SyncUnchanged:
+			fmt.Fprintf(w, "= %s\n", wf.Name)
+			continue
+		case service.SyncCreate:
+			fmt.Fprintf(w, "+ %s\n", wf.Name)
+		case service.SyncUpdate:
+			fmt.Fprintf(w, "~ %s\n", wf.Name)
+		case service.SyncConflict:
+			fmt.Fprintf(w, "! %s: %s\n", wf.Name, wf.Reason)
+		}
+		for _, ch := range wf.Changes {
+			fmt.Fprintf(w, "    %s: %s -> %s\n", ch.Field, compact(ch.From), compact(ch.To))
+		}
+	}
+	for _, name := range plan.Unmanaged {
+		fmt.Fprintf(w, "? %s (only on the target; left alone)\n", name)
+	}
+}
+
+func compact(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,5 @@
+-- 000002_approvals.down.sql
+-- Rolls back the approval gate migration.
+
+DROP TABLE IF EXISTS approvals;
+ALTER TABLE tasks DROP COLUMN IF EXISTS type;
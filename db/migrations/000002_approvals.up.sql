@@ -0,0 +1,16 @@
+-- 000002_approvals.up.sql
+-- Adds task types and the approval audit trail for manual approval gates.
+
+ALTER TABLE tasks ADD COLUMN type TEXT NOT NULL DEFAULT 'command';
+
+-- approvals: append-only record of human decisions on approval task runs.
+CREATE TABLE approvals (
+    id          UUID        NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+    task_run_id UUID        NOT NULL REFERENCES task_runs (id) ON DELETE CASCADE,
+    decision    TEXT        NOT NULL,
+    approver    TEXT        NOT NULL,
+    comment     TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_approvals_task_run_id ON approvals (task_run_id);
@@ -0,0 +1,5 @@
+-- 000003_task_pools.down.sql
+-- Rolls back the task pools migration.
+
+DROP INDEX IF EXISTS idx_tasks_pool;
+ALTER TABLE tasks DROP COLUMN IF EXISTS pool;
@@ -0,0 +1,6 @@
+-- 000003_task_pools.up.sql
+-- Lets tasks reference a named resource pool that caps global concurrency.
+
+ALTER TABLE tasks ADD COLUMN pool TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_tasks_pool ON tasks (pool);
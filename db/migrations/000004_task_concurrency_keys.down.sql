@@ -0,0 +1,4 @@
+-- 000004_task_concurrency_keys.down.sql
+-- Rolls back the task concurrency key migration.
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS concurrency_key;
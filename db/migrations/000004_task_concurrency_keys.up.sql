@@ -0,0 +1,4 @@
+-- 000004_task_concurrency_keys.up.sql
+-- Lets tasks declare a concurrency key so tasks sharing it never overlap.
+
+ALTER TABLE tasks ADD COLUMN concurrency_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- 000005_workflow_timezone.down.sql
+-- Rolls back the workflow timezone migration.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,4 @@
+-- 000005_workflow_timezone.up.sql
+-- Adds the IANA timezone a workflow's cron schedule is evaluated in.
+
+ALTER TABLE workflows ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
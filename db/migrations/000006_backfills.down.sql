@@ -0,0 +1,7 @@
+-- 000006_backfills.down.sql
+-- Rolls back the backfill migration.
+
+DROP INDEX IF EXISTS idx_workflow_runs_backfill_id;
+DROP TABLE IF EXISTS backfills;
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS backfill_id;
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS logical_date;
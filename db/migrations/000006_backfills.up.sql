@@ -0,0 +1,23 @@
+-- 000006_backfills.up.sql
+-- Adds backfills and links workflow runs to the schedule interval they cover.
+
+ALTER TABLE workflow_runs ADD COLUMN logical_date TIMESTAMPTZ;
+ALTER TABLE workflow_runs ADD COLUMN backfill_id  UUID;
+
+-- backfills: replays of a workflow's schedule over a past date range.
+CREATE TABLE backfills (
+    id                UUID        NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+    workflow_id       UUID        NOT NULL REFERENCES workflows (id) ON DELETE CASCADE,
+    start_at          TIMESTAMPTZ NOT NULL,
+    end_at            TIMESTAMPTZ NOT NULL,
+    max_active_runs   INTEGER     NOT NULL DEFAULT 1,
+    status            TEXT        NOT NULL DEFAULT 'running',
+    total             INTEGER     NOT NULL,
+    created           INTEGER     NOT NULL DEFAULT 0,
+    last_logical_date TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at       TIMESTAMPTZ
+);
+
+CREATE INDEX idx_backfills_status ON backfills (status);
+CREATE INDEX idx_workflow_runs_backfill_id ON workflow_runs (backfill_id);
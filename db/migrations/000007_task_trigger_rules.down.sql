@@ -0,0 +1,4 @@
+-- 000007_task_trigger_rules.down.sql
+-- Rolls back the task trigger rule migration.
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS trigger_rule;
@@ -0,0 +1,4 @@
+-- 000007_task_trigger_rules.up.sql
+-- Lets tasks declare the trigger rule applied to their upstream states.
+
+ALTER TABLE tasks ADD COLUMN trigger_rule TEXT NOT NULL DEFAULT 'all_success';
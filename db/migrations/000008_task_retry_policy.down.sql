@@ -0,0 +1,6 @@
+-- 000008_task_retry_policy.down.sql
+-- Rolls back the per-task retry policy migration.
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS retry_jitter;
+ALTER TABLE tasks DROP COLUMN IF EXISTS retry_max_delay_seconds;
+ALTER TABLE tasks DROP COLUMN IF EXISTS retry_multiplier;
@@ -0,0 +1,6 @@
+-- 000008_task_retry_policy.up.sql
+-- Stores the per-task retry backoff alongside retry_count and retry_delay_seconds.
+
+ALTER TABLE tasks ADD COLUMN retry_multiplier        DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN retry_max_delay_seconds INTEGER          NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN retry_jitter            BOOLEAN          NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+-- 000009_shared_queue_state.down.sql
+-- Rolls back the shared queue state migration.
+
+DROP TABLE IF EXISTS worker_nodes;
+DROP TABLE IF EXISTS queue_tasks;
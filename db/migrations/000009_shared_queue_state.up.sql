@@ -0,0 +1,38 @@
+-- 000009_shared_queue_state.up.sql
+-- Execution state shared by the scheduler and worker processes.
+
+-- queue_tasks: dispatched units of work and their execution status.
+CREATE TABLE queue_tasks (
+    id              TEXT        NOT NULL PRIMARY KEY,
+    name            TEXT        NOT NULL,
+    payload         BYTEA,
+    status          TEXT        NOT NULL,
+    priority        INT         NOT NULL,
+    max_retries     INT         NOT NULL DEFAULT 0,
+    retry_count     INT         NOT NULL DEFAULT 0,
+    scheduled_at    TIMESTAMPTZ NOT NULL,
+    started_at      TIMESTAMPTZ,
+    finished_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    error           TEXT        NOT NULL DEFAULT '',
+    type            TEXT        NOT NULL DEFAULT '',
+    pool            TEXT        NOT NULL DEFAULT '',
+    concurrency_key TEXT        NOT NULL DEFAULT '',
+    workflow_id     TEXT        NOT NULL DEFAULT '',
+    retry           JSONB,
+    deferral        JSONB
+);
+
+CREATE INDEX idx_queue_tasks_status ON queue_tasks (status);
+
+-- worker_nodes: worker processes registered with the queue.
+CREATE TABLE worker_nodes (
+    id            TEXT        NOT NULL PRIMARY KEY,
+    address       TEXT        NOT NULL,
+    status        TEXT        NOT NULL,
+    concurrency   INT         NOT NULL DEFAULT 1,
+    active_tasks  INT         NOT NULL DEFAULT 0,
+    last_heart_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    registered_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+-- 000010_workflow_run_stats.down.sql
+-- Rolls back the workflow run statistics index.
+
+DROP INDEX IF EXISTS idx_workflow_runs_workflow_id_started_at;
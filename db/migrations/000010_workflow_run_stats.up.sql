@@ -0,0 +1,4 @@
+-- 000010_workflow_run_stats.up.sql
+-- Adds a composite index for per-workflow run duration statistics.
+
+CREATE INDEX idx_workflow_runs_workflow_id_started_at ON workflow_runs (workflow_id, started_at);
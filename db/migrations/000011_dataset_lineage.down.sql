@@ -0,0 +1,6 @@
+-- 000011_dataset_lineage.down.sql
+-- Rolls back the dataset lineage migration.
+
+DROP TABLE IF EXISTS lineage_edges;
+ALTER TABLE tasks DROP COLUMN IF EXISTS outputs;
+ALTER TABLE tasks DROP COLUMN IF EXISTS inputs;
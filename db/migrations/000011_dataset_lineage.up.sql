@@ -0,0 +1,20 @@
+-- 000011_dataset_lineage.up.sql
+-- Adds task input/output datasets and the per-run lineage edges they produce.
+
+ALTER TABLE tasks ADD COLUMN inputs  JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE tasks ADD COLUMN outputs JSONB NOT NULL DEFAULT '[]';
+
+-- lineage_edges: append-only record of the datasets each task run read or wrote.
+CREATE TABLE lineage_edges (
+    id              UUID        NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+    dataset         TEXT        NOT NULL,
+    direction       TEXT        NOT NULL CHECK (direction IN ('input', 'output')),
+    workflow_id     UUID        NOT NULL REFERENCES workflows (id) ON DELETE CASCADE,
+    workflow_run_id UUID        NOT NULL REFERENCES workflow_runs (id) ON DELETE CASCADE,
+    task_id         UUID        NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    task_run_id     UUID        NOT NULL REFERENCES task_runs (id) ON DELETE CASCADE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_lineage_edges_dataset     ON lineage_edges (dataset, direction, created_at);
+CREATE INDEX idx_lineage_edges_task_run_id ON lineage_edges (task_run_id);
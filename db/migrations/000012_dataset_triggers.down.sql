@@ -0,0 +1,5 @@
+-- 000012_dataset_triggers.down.sql
+-- Rolls back the dataset trigger migration.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS dataset_policy;
+ALTER TABLE workflows DROP COLUMN IF EXISTS trigger_datasets;
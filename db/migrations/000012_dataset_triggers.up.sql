@@ -0,0 +1,5 @@
+-- 000012_dataset_triggers.up.sql
+-- Lets workflows run when the datasets they depend on are updated.
+
+ALTER TABLE workflows ADD COLUMN trigger_datasets JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE workflows ADD COLUMN dataset_policy   TEXT  NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- 000013_calendars.down.sql
+-- Rolls back the calendars migration.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS calendar_id;
+DROP TABLE IF EXISTS calendars;
@@ -0,0 +1,13 @@
+-- 000013_calendars.up.sql
+-- Adds exclusion calendars that skip scheduled runs on holidays.
+
+-- calendars: named sets of excluded days, optionally backed by an iCal feed.
+CREATE TABLE calendars (
+    id             UUID        NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+    name           TEXT        NOT NULL,
+    excluded_dates JSONB       NOT NULL DEFAULT '[]',
+    ical_url       TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE workflows ADD COLUMN calendar_id UUID REFERENCES calendars (id) ON DELETE SET NULL;
@@ -0,0 +1,5 @@
+-- 000014_cross_workflow_triggers.down.sql
+-- Rolls back the cross-workflow trigger migration.
+
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS triggered_by_run_id;
+ALTER TABLE workflows     DROP COLUMN IF EXISTS trigger_on_success;
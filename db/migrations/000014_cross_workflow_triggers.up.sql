@@ -0,0 +1,7 @@
+-- 000014_cross_workflow_triggers.up.sql
+-- Lets a workflow start other workflows and records which run started a run.
+
+ALTER TABLE workflows     ADD COLUMN trigger_on_success  JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE workflow_runs ADD COLUMN triggered_by_run_id UUID REFERENCES workflow_runs (id) ON DELETE SET NULL;
+
+CREATE INDEX idx_workflow_runs_triggered_by_run_id ON workflow_runs (triggered_by_run_id);
@@ -0,0 +1,6 @@
+-- 000015_templating.down.sql
+-- Rolls back the templating migration.
+
+ALTER TABLE queue_tasks   DROP COLUMN IF EXISTS env;
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS params;
+ALTER TABLE tasks         DROP COLUMN IF EXISTS env;
@@ -0,0 +1,6 @@
+-- 000015_templating.up.sql
+-- Adds task environment variables and per-run template parameters.
+
+ALTER TABLE tasks         ADD COLUMN env    JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE workflow_runs ADD COLUMN params JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE queue_tasks   ADD COLUMN env    JSONB NOT NULL DEFAULT '{}';
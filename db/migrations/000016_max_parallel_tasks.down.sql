@@ -0,0 +1,4 @@
+-- 000016_max_parallel_tasks.down.sql
+-- Rolls back the max parallel tasks migration.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS max_parallel_tasks;
@@ -0,0 +1,4 @@
+-- 000016_max_parallel_tasks.up.sql
+-- Adds a per-workflow cap on the tasks of a run dispatched at once.
+
+ALTER TABLE workflows ADD COLUMN max_parallel_tasks INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+-- 000017_worker_placement.down.sql
+-- Rolls back the worker placement migration.
+
+DROP INDEX IF EXISTS idx_worker_nodes_queues;
+DROP INDEX IF EXISTS idx_worker_nodes_labels;
+
+ALTER TABLE worker_nodes DROP COLUMN IF EXISTS queues;
+ALTER TABLE worker_nodes DROP COLUMN IF EXISTS labels;
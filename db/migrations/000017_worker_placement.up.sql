@@ -0,0 +1,8 @@
+-- 000017_worker_placement.up.sql
+-- Adds worker labels and queues, and indexes them for filtered placement.
+
+ALTER TABLE worker_nodes ADD COLUMN labels JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE worker_nodes ADD COLUMN queues JSONB NOT NULL DEFAULT '[]';
+
+CREATE INDEX idx_worker_nodes_labels ON worker_nodes USING GIN (labels);
+CREATE INDEX idx_worker_nodes_queues ON worker_nodes USING GIN (queues);
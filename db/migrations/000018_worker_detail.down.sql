@@ -0,0 +1,8 @@
+-- 000018_worker_detail.down.sql
+-- Rolls back the worker detail migration.
+
+DROP TABLE IF EXISTS worker_heartbeats;
+
+DROP INDEX IF EXISTS idx_queue_tasks_worker_id_status;
+
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS worker_id;
@@ -0,0 +1,17 @@
+-- 000018_worker_detail.up.sql
+-- Records which worker runs each queue task and keeps a heartbeat history.
+
+ALTER TABLE queue_tasks ADD COLUMN worker_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_queue_tasks_worker_id_status ON queue_tasks (worker_id, status);
+
+-- worker_heartbeats: recent heartbeats of each worker, pruned on insert.
+CREATE TABLE worker_heartbeats (
+    id           UUID        NOT NULL DEFAULT uuid_generate_v4() PRIMARY KEY,
+    worker_id    TEXT        NOT NULL,
+    at           TIMESTAMPTZ NOT NULL,
+    status       TEXT        NOT NULL,
+    active_tasks INT         NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_worker_heartbeats_worker_id_at ON worker_heartbeats (worker_id, at DESC);
@@ -0,0 +1,4 @@
+-- 000019_dispatch_freeze.down.sql
+-- Drops the dispatch freeze switch.
+
+DROP TABLE IF EXISTS dispatch_freeze;
@@ -0,0 +1,11 @@
+-- 000019_dispatch_freeze.up.sql
+-- Adds the cluster-wide switch that stops workers from taking queued tasks.
+
+-- dispatch_freeze: at most one row (id = 1); no row means not frozen.
+CREATE TABLE dispatch_freeze (
+    id         INT         NOT NULL PRIMARY KEY CHECK (id = 1),
+    frozen     BOOLEAN     NOT NULL DEFAULT FALSE,
+    reason     TEXT        NOT NULL DEFAULT '',
+    changed_by TEXT        NOT NULL DEFAULT '',
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,5 @@
+-- 000020_workflow_retention.down.sql
+-- Rolls back the workflow retention migration.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS retain_days;
+ALTER TABLE workflows DROP COLUMN IF EXISTS retain_runs;
@@ -0,0 +1,5 @@
+-- 000020_workflow_retention.up.sql
+-- Adds per-workflow limits on the finished run history kept.
+
+ALTER TABLE workflows ADD COLUMN retain_runs INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE workflows ADD COLUMN retain_days INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- 000021_secrets.down.sql
+-- Drops the secrets store.
+
+DROP TABLE IF EXISTS secrets;
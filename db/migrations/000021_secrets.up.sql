@@ -0,0 +1,8 @@
+-- 000021_secrets.up.sql
+-- Adds the store task environment secret:// references resolve against.
+
+CREATE TABLE secrets (
+    name       TEXT        NOT NULL PRIMARY KEY,
+    value      TEXT        NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,5 @@
+-- 000022_run_labels.down.sql
+-- Rolls back the run labels migration.
+
+DROP INDEX IF EXISTS idx_workflow_runs_labels;
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS labels;
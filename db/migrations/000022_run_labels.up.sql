@@ -0,0 +1,6 @@
+-- 000022_run_labels.up.sql
+-- Adds labels to workflow runs for filtering run listings.
+
+ALTER TABLE workflow_runs ADD COLUMN labels JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_workflow_runs_labels ON workflow_runs USING GIN (labels);
@@ -0,0 +1,5 @@
+-- 000023_worker_groups.down.sql
+-- Drops the worker group columns.
+
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS worker_group;
+ALTER TABLE workflows DROP COLUMN IF EXISTS worker_group;
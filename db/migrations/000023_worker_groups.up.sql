@@ -0,0 +1,6 @@
+-- 000023_worker_groups.up.sql
+-- Adds the worker group a workflow's tasks are dedicated to, and records it
+-- on queue tasks so retries and resumed tasks stay in their group.
+
+ALTER TABLE workflows ADD COLUMN worker_group TEXT NOT NULL DEFAULT '';
+ALTER TABLE queue_tasks ADD COLUMN worker_group TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- 000024_task_attempts.down.sql
+-- Drops the task attempt history.
+
+DROP TABLE IF EXISTS task_attempts;
@@ -0,0 +1,14 @@
+-- 000024_task_attempts.up.sql
+-- Keeps every execution of a queue task, which retries otherwise overwrite.
+
+CREATE TABLE task_attempts (
+    task_id     TEXT        NOT NULL,
+    number      INT         NOT NULL,
+    status      TEXT        NOT NULL,
+    worker_id   TEXT        NOT NULL DEFAULT '',
+    started_at  TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    error       TEXT        NOT NULL DEFAULT '',
+    logs        TEXT        NOT NULL DEFAULT '',
+    PRIMARY KEY (task_id, number)
+);
@@ -0,0 +1,4 @@
+-- 000025_trigger_events.down.sql
+-- Drops the workflow event subscriptions.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS trigger_events;
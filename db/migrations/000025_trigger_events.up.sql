@@ -0,0 +1,5 @@
+-- 000025_trigger_events.up.sql
+-- Adds the external events a workflow subscribes to; posting one of them to
+-- POST /events/{name} starts a run of the workflow.
+
+ALTER TABLE workflows ADD COLUMN trigger_events JSONB NOT NULL DEFAULT '[]';
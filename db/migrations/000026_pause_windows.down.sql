@@ -0,0 +1,4 @@
+-- 000026_pause_windows.down.sql
+-- Drops the workflow pause windows.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS pause_windows;
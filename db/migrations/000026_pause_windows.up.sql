@@ -0,0 +1,5 @@
+-- 000026_pause_windows.up.sql
+-- Adds recurring pause windows, in which a workflow's scheduled fires are
+-- skipped or delayed until the window closes.
+
+ALTER TABLE workflows ADD COLUMN pause_windows JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,5 @@
+-- 000027_task_hooks.down.sql
+-- Drops task hooks.
+
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS hooks;
+ALTER TABLE tasks DROP COLUMN IF EXISTS hooks;
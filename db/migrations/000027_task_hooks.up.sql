@@ -0,0 +1,7 @@
+-- 000027_task_hooks.up.sql
+-- Adds task hooks: HTTP calls, follow-up tasks and notifications a worker
+-- runs after a task succeeds, fails or is retried. Queue tasks carry a copy
+-- so the worker executing them has it.
+
+ALTER TABLE tasks ADD COLUMN hooks JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE queue_tasks ADD COLUMN hooks JSONB NOT NULL DEFAULT '[]';
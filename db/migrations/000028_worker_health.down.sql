@@ -0,0 +1,5 @@
+-- 000028_worker_health.down.sql
+-- Drops the worker health columns.
+
+ALTER TABLE worker_nodes DROP COLUMN IF EXISTS avg_latency_ms;
+ALTER TABLE worker_nodes DROP COLUMN IF EXISTS failure_rate;
@@ -0,0 +1,6 @@
+-- 000028_worker_health.up.sql
+-- Records each worker node's recent failure rate and mean attempt latency,
+-- so placement can rank failing workers last.
+
+ALTER TABLE worker_nodes ADD COLUMN failure_rate DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE worker_nodes ADD COLUMN avg_latency_ms BIGINT NOT NULL DEFAULT 0;
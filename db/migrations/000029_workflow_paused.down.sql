@@ -0,0 +1,6 @@
+-- 000029_workflow_paused.down.sql
+-- Drops the workflow paused flag.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS paused_at;
+ALTER TABLE workflows DROP COLUMN IF EXISTS paused_by;
+ALTER TABLE workflows DROP COLUMN IF EXISTS paused;
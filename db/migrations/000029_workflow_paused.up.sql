@@ -0,0 +1,7 @@
+-- 000029_workflow_paused.up.sql
+-- Adds the paused flag that stops a workflow's cron schedule from starting
+-- runs without deactivating the workflow, and who set it when.
+
+ALTER TABLE workflows ADD COLUMN paused BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE workflows ADD COLUMN paused_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE workflows ADD COLUMN paused_at TIMESTAMPTZ;
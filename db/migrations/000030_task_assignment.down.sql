@@ -0,0 +1,7 @@
+-- 000030_task_assignment.down.sql
+-- Drops the task dispatch assignment.
+
+DROP INDEX IF EXISTS idx_task_runs_assigned_worker_id;
+ALTER TABLE task_runs DROP COLUMN IF EXISTS dispatched_at;
+ALTER TABLE task_runs DROP COLUMN IF EXISTS assigned_worker_id;
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS dispatched_at;
@@ -0,0 +1,9 @@
+-- 000030_task_assignment.up.sql
+-- Records which worker each queue task and task run was dispatched to, and
+-- when, so recovery and the worker detail endpoint can tell who owns a task.
+
+ALTER TABLE queue_tasks ADD COLUMN dispatched_at TIMESTAMPTZ;
+ALTER TABLE task_runs ADD COLUMN assigned_worker_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE task_runs ADD COLUMN dispatched_at TIMESTAMPTZ;
+
+CREATE INDEX idx_task_runs_assigned_worker_id ON task_runs (assigned_worker_id);
@@ -0,0 +1,4 @@
+-- 000031_queue_task_timeout.down.sql
+-- Drops the queue task timeout.
+
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS timeout_seconds;
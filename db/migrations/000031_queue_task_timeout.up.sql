@@ -0,0 +1,5 @@
+-- 000031_queue_task_timeout.up.sql
+-- Carries each task's timeout onto its queue task, where workers enforce it
+-- and the SQS queue hides a received task for that long.
+
+ALTER TABLE queue_tasks ADD COLUMN timeout_seconds INT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- 000032_run_progress.down.sql
+-- Drops the workflow run progress counts.
+
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS tasks_total;
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS tasks_completed;
@@ -0,0 +1,6 @@
+-- 000032_run_progress.up.sql
+-- Adds the per-run task counts the orchestrator keeps current, so run
+-- listings can show progress without reading every task run.
+
+ALTER TABLE workflow_runs ADD COLUMN tasks_completed INT NOT NULL DEFAULT 0;
+ALTER TABLE workflow_runs ADD COLUMN tasks_total INT NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- 000033_queue_task_payload_ref.down.sql
+-- Drops the queue task payload reference.
+
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS payload_ref;
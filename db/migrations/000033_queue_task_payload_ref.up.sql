@@ -0,0 +1,5 @@
+-- 000033_queue_task_payload_ref.up.sql
+-- Records where an offloaded payload is stored, so a queue task whose
+-- payload went to the payload store keeps a way back to it.
+
+ALTER TABLE queue_tasks ADD COLUMN payload_ref TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- 000034_queue_task_idempotency_key.down.sql
+-- Drops the queue task idempotency key.
+
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS idempotency_key;
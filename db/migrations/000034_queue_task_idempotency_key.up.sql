@@ -0,0 +1,5 @@
+-- 000034_queue_task_idempotency_key.up.sql
+-- Keeps the idempotency key a task was submitted with, so a duplicate
+-- submission can be traced back to the task that ran.
+
+ALTER TABLE queue_tasks ADD COLUMN idempotency_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- 000035_queue_task_routing_key.down.sql
+-- Drops the queue task routing key.
+
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS routing_key;
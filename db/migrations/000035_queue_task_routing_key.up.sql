@@ -0,0 +1,5 @@
+-- 000035_queue_task_routing_key.up.sql
+-- Keeps the routing key that pins a task to one worker, so it is routed
+-- the same way when it is dispatched again.
+
+ALTER TABLE queue_tasks ADD COLUMN routing_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- 000036_workflow_run_timeout.down.sql
+-- Drops the workflow run timeout columns.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS run_timeout_policy;
+ALTER TABLE workflows DROP COLUMN IF EXISTS run_timeout_seconds;
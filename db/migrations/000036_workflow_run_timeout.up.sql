@@ -0,0 +1,6 @@
+-- 000036_workflow_run_timeout.up.sql
+-- Adds the per-workflow run timeout and the policy the orchestrator applies
+-- to runs that overrun it.
+
+ALTER TABLE workflows ADD COLUMN run_timeout_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE workflows ADD COLUMN run_timeout_policy TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- 000037_workflow_catchup.down.sql
+-- Drops the workflow catchup policy.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS catchup;
@@ -0,0 +1,5 @@
+-- 000037_workflow_catchup.up.sql
+-- Adds the policy deciding which schedule fires missed while the scheduler
+-- was down are run when it starts again.
+
+ALTER TABLE workflows ADD COLUMN catchup TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- 000038_worker_deactivation.down.sql
+-- Drops the worker node deactivation timestamp.
+
+ALTER TABLE worker_nodes DROP COLUMN IF EXISTS deactivated_at;
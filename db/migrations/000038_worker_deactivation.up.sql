@@ -0,0 +1,6 @@
+-- 000038_worker_deactivation.up.sql
+-- Records when a worker node was deactivated. Deactivated workers keep their
+-- row, with status 'deactivated', so the tasks and attempts naming them
+-- still resolve.
+
+ALTER TABLE worker_nodes ADD COLUMN deactivated_at TIMESTAMPTZ;
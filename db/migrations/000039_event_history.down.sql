@@ -0,0 +1,4 @@
+-- 000039_event_history.down.sql
+-- Drops the event history.
+
+DROP TABLE IF EXISTS event_history;
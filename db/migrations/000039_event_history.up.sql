@@ -0,0 +1,14 @@
+-- 000039_event_history.up.sql
+-- Keeps the events published to WebSocket clients, so incident reviews can
+-- replay what the system reported and when. seq orders the history and is
+-- its pagination cursor; rows older than the retention period are pruned.
+
+CREATE TABLE event_history (
+    seq     BIGSERIAL   PRIMARY KEY,
+    type    TEXT        NOT NULL,
+    payload JSONB       NOT NULL DEFAULT 'null',
+    at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_event_history_at ON event_history (at);
+CREATE INDEX idx_event_history_type_seq ON event_history (type, seq);
@@ -0,0 +1,5 @@
+-- 000040_workflow_max_active_runs.down.sql
+-- Drops the workflow active run cap columns.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS overlap_policy;
+ALTER TABLE workflows DROP COLUMN IF EXISTS max_active_runs;
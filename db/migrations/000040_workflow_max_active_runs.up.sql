@@ -0,0 +1,6 @@
+-- 000040_workflow_max_active_runs.up.sql
+-- Adds the per-workflow cap on active runs and the policy applied to runs
+-- beyond it.
+
+ALTER TABLE workflows ADD COLUMN max_active_runs INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE workflows ADD COLUMN overlap_policy TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- 000041_task_profile.down.sql
+-- Drops the task isolation profile columns.
+
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS profile;
+ALTER TABLE tasks DROP COLUMN IF EXISTS profile;
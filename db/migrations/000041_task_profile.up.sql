@@ -0,0 +1,6 @@
+-- 000041_task_profile.up.sql
+-- Adds the isolation profile a task runs under, on task definitions and on
+-- the queued tasks that execute them.
+
+ALTER TABLE tasks ADD COLUMN profile TEXT NOT NULL DEFAULT '';
+ALTER TABLE queue_tasks ADD COLUMN profile TEXT NOT NULL DEFAULT '';
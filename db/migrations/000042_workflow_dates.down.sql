@@ -0,0 +1,5 @@
+-- 000042_workflow_dates.down.sql
+-- Drops the workflow schedule date columns.
+
+ALTER TABLE workflows DROP COLUMN IF EXISTS end_date;
+ALTER TABLE workflows DROP COLUMN IF EXISTS start_date;
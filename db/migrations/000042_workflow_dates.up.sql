@@ -0,0 +1,5 @@
+-- 000042_workflow_dates.up.sql
+-- Adds the dates bounding the fires of a workflow's cron schedule.
+
+ALTER TABLE workflows ADD COLUMN start_date TIMESTAMPTZ;
+ALTER TABLE workflows ADD COLUMN end_date TIMESTAMPTZ;
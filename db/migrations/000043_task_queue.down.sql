@@ -0,0 +1,5 @@
+-- 000043_task_queue.down.sql
+-- Drops the task named queue columns.
+
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS queue_name;
+ALTER TABLE tasks DROP COLUMN IF EXISTS queue_name;
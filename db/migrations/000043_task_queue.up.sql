@@ -0,0 +1,6 @@
+-- 000043_task_queue.up.sql
+-- Adds the named queue a task waits on, on task definitions and on the
+-- queued tasks that execute them.
+
+ALTER TABLE tasks ADD COLUMN queue_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE queue_tasks ADD COLUMN queue_name TEXT NOT NULL DEFAULT '';
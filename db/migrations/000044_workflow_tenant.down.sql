@@ -0,0 +1,5 @@
+-- 000044_workflow_tenant.down.sql
+-- Drops the workflow tenant column and the task run dispatch index.
+
+DROP INDEX IF EXISTS idx_task_runs_dispatched_at;
+ALTER TABLE workflows DROP COLUMN IF EXISTS tenant;
@@ -0,0 +1,6 @@
+-- 000044_workflow_tenant.up.sql
+-- Adds the tenant a workflow's execution time is charged to, and an index
+-- for the daily usage report over task run dispatch times.
+
+ALTER TABLE workflows ADD COLUMN tenant TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_task_runs_dispatched_at ON task_runs (dispatched_at);
@@ -0,0 +1,5 @@
+-- 000045_priority.down.sql
+-- Drops the workflow and task priority columns.
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS priority;
+ALTER TABLE workflows DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,6 @@
+-- 000045_priority.up.sql
+-- Adds the queue priority of workflows, inherited by their tasks, and the
+-- per-task override of it.
+
+ALTER TABLE workflows ADD COLUMN priority INT NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN priority INT NOT NULL DEFAULT 0;
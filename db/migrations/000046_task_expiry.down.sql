@@ -0,0 +1,5 @@
+-- 000046_task_expiry.down.sql
+-- Drops the queued task expiry and the task TTL.
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS ttl_seconds;
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS expires_at;
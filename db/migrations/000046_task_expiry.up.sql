@@ -0,0 +1,6 @@
+-- 000046_task_expiry.up.sql
+-- Adds the time a queued task expires if not dispatched, and the TTL
+-- workflow tasks set it from.
+
+ALTER TABLE queue_tasks ADD COLUMN expires_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN ttl_seconds INT NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+-- 000047_idempotency_keys.down.sql
+-- Drops the task run queue task, the workflow run idempotency key and the
+-- unique index on queue task idempotency keys.
+
+ALTER TABLE task_runs DROP COLUMN IF EXISTS queue_task_id;
+DROP INDEX IF EXISTS idx_workflow_runs_idempotency_key;
+ALTER TABLE workflow_runs DROP COLUMN IF EXISTS idempotency_key;
+DROP INDEX IF EXISTS idx_queue_tasks_idempotency_key;
@@ -0,0 +1,30 @@
+-- 000047_idempotency_keys.up.sql
+-- Makes a queue task idempotency key unique among the tasks holding one,
+-- so schedulers sharing the database reject a repeated key, and keeps the
+-- key a client triggered a workflow run with, unique per workflow, along
+-- with the queue task a task run follows when its own submission was a
+-- duplicate. Keys 000034 let tasks repeat are cleared from all but the
+-- latest task holding them first.
+
+UPDATE queue_tasks SET idempotency_key = ''
+ WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (
+                   PARTITION BY idempotency_key ORDER BY created_at DESC, id DESC) AS n
+          FROM queue_tasks
+         WHERE idempotency_key <> ''
+    ) ranked
+     WHERE n > 1
+ );
+
+CREATE UNIQUE INDEX idx_queue_tasks_idempotency_key
+    ON queue_tasks (idempotency_key)
+    WHERE idempotency_key <> '';
+
+ALTER TABLE workflow_runs ADD COLUMN idempotency_key TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX idx_workflow_runs_idempotency_key
+    ON workflow_runs (workflow_id, idempotency_key)
+    WHERE idempotency_key <> '';
+
+ALTER TABLE task_runs ADD COLUMN queue_task_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+-- 000048_queue_task_dedup_key.down.sql
+-- Drops the queue task dedup key and run.
+
+DROP INDEX IF EXISTS idx_queue_tasks_dedup_key;
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS run_id;
+ALTER TABLE queue_tasks DROP COLUMN IF EXISTS dedup_key;
@@ -0,0 +1,11 @@
+-- 000048_queue_task_dedup_key.up.sql
+-- Keeps the content key a queue task is deduplicated by, unique among the
+-- tasks holding one, and the workflow run the task belongs to, which
+-- scopes that key.
+
+ALTER TABLE queue_tasks ADD COLUMN dedup_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE queue_tasks ADD COLUMN run_id TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX idx_queue_tasks_dedup_key
+    ON queue_tasks (dedup_key)
+    WHERE dedup_key <> '';
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Attempt is the record of one execution of a task by a worker. Retries
+// update the Task in place, so its attempts are what is left of the earlier
+// executions.
+type Attempt struct {
+	TaskID     string
+	Number     int        // 1 for the first execution, RetryCount+1 in general
+	Status     TaskStatus // TaskStatusSucceeded or TaskStatusFailed
+	WorkerID   string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Error      string
+	Logs       string // output the handler wrote, truncated to its tail
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// DispatchFreeze is the cluster-wide switch that stops workers from taking
+// tasks off the queue. While Frozen, tasks are still accepted and queued;
+// they wait there until dispatch is unfrozen.
+type DispatchFreeze struct {
+	Frozen bool
+	Reason string
+	// By identifies who last changed the switch, and At when.
+	By string
+	At time.Time
+}
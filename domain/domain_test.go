@@ -1,6 +1,7 @@
 package domain_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -94,6 +95,7 @@ func TestTask_IsTerminal(t *testing.T) {
 		{domain.TaskStatusRetrying, false},
 		{domain.TaskStatusSucceeded, true},
 		{domain.TaskStatusFailed, true},
+		{domain.TaskStatusExpired, true},
 	}
 	for _, tc := range cases {
 		task := validTask()
@@ -104,6 +106,57 @@ func TestTask_IsTerminal(t *testing.T) {
 	}
 }
 
+func TestTask_Expired(t *testing.T) {
+	now := time.Now()
+	task := validTask()
+	if task.Expired(now) {
+		t.Error("a task without ExpiresAt must never expire")
+	}
+	at := now.Add(time.Minute)
+	task.ExpiresAt = &at
+	if task.Expired(now) {
+		t.Error("expired before ExpiresAt")
+	}
+	if !task.Expired(at) {
+		t.Error("not expired at ExpiresAt")
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := &domain.RetryPolicy{InitialDelay: time.Second, Multiplier: 3, MaxDelay: 5 * time.Second}
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, 3 * time.Second},
+		{2, 5 * time.Second}, // 9 s capped at MaxDelay
+	}
+	for _, tc := range cases {
+		if got := p.Delay(tc.attempt); got != tc.want {
+			t.Errorf("Delay(%d): got %v, want %v", tc.attempt, got, tc.want)
+		}
+	}
+}
+
+func TestRetryPolicy_DelayJitter(t *testing.T) {
+	p := &domain.RetryPolicy{InitialDelay: time.Second, Jitter: true}
+	for i := 0; i < 50; i++ {
+		d := p.Delay(1) // 2 s before jitter
+		if d < time.Second || d > 2*time.Second {
+			t.Fatalf("jittered delay %v outside [1s, 2s]", d)
+		}
+	}
+}
+
+func TestTask_Validate_InvalidRetryPolicy(t *testing.T) {
+	task := validTask()
+	task.Retry = &domain.RetryPolicy{Multiplier: 0.5}
+	if err := task.Validate(); err == nil {
+		t.Fatal("expected error for multiplier below 1, got nil")
+	}
+}
+
 // ── Worker tests ──────────────────────────────────────────────────────────────
 
 func validWorker() *domain.Worker {
@@ -198,6 +251,93 @@ func TestWorker_IsAlive(t *testing.T) {
 	}
 }
 
+func TestWorker_Matches(t *testing.T) {
+	w := validWorker()
+	w.Status = domain.WorkerStatusBusy
+	w.Concurrency = 4
+	w.ActiveTasks = 1
+	w.Labels = map[string]string{"gpu": "a100", "zone": "eu"}
+	w.Queues = []string{"etl"}
+
+	cases := []struct {
+		name   string
+		filter domain.WorkerFilter
+		want   bool
+	}{
+		{"empty filter", domain.WorkerFilter{}, true},
+		{"label subset", domain.WorkerFilter{Labels: map[string]string{"gpu": "a100"}}, true},
+		{"label mismatch", domain.WorkerFilter{Labels: map[string]string{"gpu": "t4"}}, false},
+		{"missing label", domain.WorkerFilter{Labels: map[string]string{"arch": "arm"}}, false},
+		{"enough slots", domain.WorkerFilter{MinFreeSlots: 3}, true},
+		{"too few slots", domain.WorkerFilter{MinFreeSlots: 4}, false},
+		{"served queue", domain.WorkerFilter{Queue: "etl"}, true},
+		{"other queue", domain.WorkerFilter{Queue: "ml"}, false},
+	}
+	for _, c := range cases {
+		if got := w.Matches(c.filter); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	w.Queues = nil
+	if !w.Matches(domain.WorkerFilter{Queue: "ml"}) {
+		t.Error("expected a worker without queues to serve every queue")
+	}
+}
+
+func TestSortByLoad(t *testing.T) {
+	mk := func(id string, active, concurrency int) *domain.Worker {
+		return &domain.Worker{ID: id, ActiveTasks: active, Concurrency: concurrency}
+	}
+	ws := []*domain.Worker{mk("a", 3, 4), mk("c", 0, 2), mk("b", 1, 4), mk("d", 0, 8)}
+	domain.SortByLoad(ws)
+	var got []string
+	for _, w := range ws {
+		got = append(got, w.ID)
+	}
+	if want := "c d b a"; strings.Join(got, " ") != want {
+		t.Errorf("order: got %v, want %s", got, want)
+	}
+
+	ws = []*domain.Worker{mk("a", 3, 4), mk("b", 0, 4)}
+	ws[1].FailureRate = domain.UnhealthyFailureRate
+	domain.SortByLoad(ws)
+	if ws[0].ID != "a" {
+		t.Errorf("unhealthy worker not ranked last: got %s first", ws[0].ID)
+	}
+}
+
+// ── PollConfig tests ──────────────────────────────────────────────────────────
+
+func TestPollConfig_IdleDelay(t *testing.T) {
+	c := domain.PollConfig{Interval: time.Second, MaxIdleBackoff: 5 * time.Second}
+	want := []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for empty, w := range want {
+		if got := c.IdleDelay(empty); got != w {
+			t.Errorf("IdleDelay(%d): got %s, want %s", empty, got, w)
+		}
+	}
+	flat := domain.PollConfig{Interval: time.Second}
+	if got := flat.IdleDelay(10); got != time.Second {
+		t.Errorf("without MaxIdleBackoff: got %s, want 1s", got)
+	}
+	if got := (domain.PollConfig{}).IdleDelay(3); got != 0 {
+		t.Errorf("zero config: got %s, want 0", got)
+	}
+}
+
+func TestPollConfig_Validate(t *testing.T) {
+	if err := (domain.PollConfig{LongPoll: -time.Second}).Validate(); err == nil {
+		t.Error("expected error for negative LongPoll")
+	}
+	if err := (domain.PollConfig{Interval: 2 * time.Second, MaxIdleBackoff: time.Second}).Validate(); err == nil {
+		t.Error("expected error for MaxIdleBackoff below Interval")
+	}
+	if err := (domain.PollConfig{LongPoll: 5 * time.Second, Interval: time.Second, MaxIdleBackoff: time.Minute}).Validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+}
+
 // ── Sentinel error tests ──────────────────────────────────────────────────────
 
 func TestSentinelErrors_NotNil(t *testing.T) {
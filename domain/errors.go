@@ -7,6 +7,14 @@ var (
 	ErrTaskNotFound   = errors.New("task not found")
 	ErrWorkerNotFound = errors.New("worker not found")
 	ErrQueueEmpty     = errors.New("queue is empty")
+	ErrDraining       = errors.New("draining: not accepting tasks")
 	ErrTaskInvalid    = errors.New("task is invalid")
 	ErrWorkerInvalid  = errors.New("worker is invalid")
+	ErrSecretNotFound = errors.New("secret not found")
+
+	ErrPayloadNotFound = errors.New("payload not found")
+	ErrRateLimited     = errors.New("task submission rate limited")
+	ErrDuplicateTask   = errors.New("duplicate task submission")
+
+	ErrWorkerDeactivated = errors.New("worker is deactivated")
 )
@@ -0,0 +1,54 @@
+package domain
+
+// DefaultGroup is the worker group of tasks and workers that name none.
+const DefaultGroup = ""
+
+// LabelWorkerGroup is the worker label recording the group the worker
+// belongs to, so WorkerFilter.Labels can select a group's workers.
+const LabelWorkerGroup = "worker_group"
+
+// MaxGroupNameLen bounds the length of a worker group name.
+const MaxGroupNameLen = 64
+
+// ValidGroupName reports whether name may name a worker group: 1 to
+// MaxGroupNameLen ASCII letters, digits, '-', '_' or '.'. The restriction
+// keeps group names usable in queue keys and URLs.
+func ValidGroupName(name string) bool {
+	if name == "" || len(name) > MaxGroupNameLen {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Group returns the worker group w belongs to, or DefaultGroup.
+func (w *Worker) Group() string {
+	return w.Labels[LabelWorkerGroup]
+}
+
+// NamedQueuePrefix starts the names of the queues that hold the tasks
+// routed by their Queue field.
+const NamedQueuePrefix = "queue."
+
+// NamedQueue returns the name of the queue holding the tasks whose Queue
+// is name. It is opened like a worker group's queue.
+func NamedQueue(name string) string {
+	return NamedQueuePrefix + name
+}
+
+// StickyQueuePrefix starts the names of the queues that hold the tasks
+// pinned to one worker by their RoutingKey.
+const StickyQueuePrefix = "sticky."
+
+// StickyQueue returns the name of the queue holding the tasks pinned to
+// the worker with workerID. It is opened like a worker group's queue.
+func StickyQueue(workerID string) string {
+	return StickyQueuePrefix + workerID
+}
@@ -0,0 +1,13 @@
+package domain
+
+// Hook is a side effect a worker performs after a task transition, so
+// callbacks need not be modelled as separate DAG nodes. The API's task hook
+// definitions document the events and kinds.
+type Hook struct {
+	On       string // "on_success", "on_failure" or "on_retry"
+	Kind     string // "http", "enqueue" or "notify"
+	URL      string // http: receives a POST of the task's outcome
+	Command  string // enqueue: payload of the command task queued
+	Notifier string // notify: name of the worker's notifier
+	Message  string // notify: text sent; empty describes the transition
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // TaskRepository defines the persistence operations for Tasks.
 type TaskRepository interface {
@@ -15,18 +18,78 @@ type TaskRepository interface {
 	Delete(ctx context.Context, id string) error
 }
 
+// IdempotencyStore is a TaskRepository that keeps each IdempotencyKey, and
+// each DedupKey, on at most one task, so a key is checked against every
+// Scheduler sharing the store rather than one process's memory.
+type IdempotencyStore interface {
+	TaskRepository
+	// ClaimKey saves task, which has an IdempotencyKey, a DedupKey or
+	// both, as the holder of its keys, taking each over from a task
+	// created by since. If a task with another ID created after since
+	// holds either key, nothing is saved and ClaimKey returns that task
+	// and ErrDuplicateTask. Save never writes either key.
+	ClaimKey(ctx context.Context, task *Task, since time.Time) (*Task, error)
+}
+
 // WorkerRepository defines the persistence operations for Workers.
 type WorkerRepository interface {
 	// Save creates or updates a worker registration.
 	Save(ctx context.Context, worker *Worker) error
 	// FindByID returns the worker with the given ID or ErrWorkerNotFound.
 	FindByID(ctx context.Context, id string) (*Worker, error)
-	// FindAvailable returns all workers that currently have capacity.
-	FindAvailable(ctx context.Context) ([]*Worker, error)
+	// FindAvailable returns the workers that currently have capacity and
+	// match filter, least loaded first.
+	FindAvailable(ctx context.Context, filter WorkerFilter) ([]*Worker, error)
 	// Delete removes the worker record.
 	Delete(ctx context.Context, id string) error
 }
 
+// HeartbeatRepository keeps a bounded history of worker heartbeats.
+type HeartbeatRepository interface {
+	// Record appends hb to its worker's history, discarding entries that
+	// fall outside the repository's retention.
+	Record(ctx context.Context, hb Heartbeat) error
+	// ListRecent returns up to limit of the worker's latest heartbeats,
+	// newest first.
+	ListRecent(ctx context.Context, workerID string, limit int) ([]Heartbeat, error)
+}
+
+// AttemptRepository keeps the attempts of each task.
+type AttemptRepository interface {
+	// Record stores a finished attempt.
+	Record(ctx context.Context, a *Attempt) error
+	// ListByTaskID returns the task's attempts ordered by Number; a task
+	// without any returns an empty slice.
+	ListByTaskID(ctx context.Context, taskID string) ([]*Attempt, error)
+}
+
+// FreezeRepository stores the DispatchFreeze shared by every worker.
+type FreezeRepository interface {
+	// Get returns the current switch; a never-saved switch is not frozen.
+	Get(ctx context.Context) (*DispatchFreeze, error)
+	// Save replaces the switch.
+	Save(ctx context.Context, f *DispatchFreeze) error
+}
+
+// SecretStore holds the values task Env entries reference with
+// SecretRefPrefix.
+type SecretStore interface {
+	// Get returns the current value of the named secret, or
+	// ErrSecretNotFound.
+	Get(ctx context.Context, name string) (string, error)
+	// Put creates the named secret or replaces its value.
+	Put(ctx context.Context, name, value string) error
+}
+
+// PayloadStore holds task payloads too large to travel through the Queue,
+// such as in object storage.
+type PayloadStore interface {
+	// Put stores data under key, replacing anything already there.
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns the data stored under key, or ErrPayloadNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
 // Queue defines the operations for the distributed task queue.
 type Queue interface {
 	// Enqueue pushes a task onto the queue.
@@ -38,6 +101,29 @@ type Queue interface {
 	Len(ctx context.Context) (int, error)
 }
 
+// AckQueue is a Queue that hands a dequeued task out again unless it is
+// acknowledged in time, so a task whose worker dies is not lost. Workers
+// call Ack once they are done with the attempt, whatever its outcome.
+type AckQueue interface {
+	Queue
+	// Ack confirms that task, as returned by Dequeue, needs no redelivery.
+	Ack(ctx context.Context, task *Task) error
+}
+
+// RetryQueue holds tasks waiting out their retry backoff apart from the
+// Queue, so a waiting retry takes no worker slot and a burst of failures
+// does not flood the Queue the moment its backoffs end.
+type RetryQueue interface {
+	// Schedule holds task until at.
+	Schedule(ctx context.Context, task *Task, at time.Time) error
+	// Release moves up to limit tasks due at or before now onto the Queue,
+	// earliest first, and returns how many it moved. A limit of zero or
+	// less moves every due task.
+	Release(ctx context.Context, now time.Time, limit int) (int, error)
+	// Len returns the number of tasks waiting.
+	Len(ctx context.Context) (int, error)
+}
+
 // Scheduler defines the high-level scheduling operations.
 type Scheduler interface {
 	// Submit accepts a new task and enqueues it for execution.
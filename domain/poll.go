@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// PollConfig tunes how a consumer polls a queue for tasks, trading pickup
+// latency for load on the broker. The zero value keeps each consumer's
+// default behaviour.
+type PollConfig struct {
+	LongPoll       time.Duration // how long one poll waits for a task; 0 uses the consumer's default
+	Interval       time.Duration // pause after a poll that found nothing; 0 polls again at once
+	MaxIdleBackoff time.Duration // the pause doubles per empty poll up to this; 0 keeps it at Interval
+}
+
+// Validate checks that the durations are not negative and that
+// MaxIdleBackoff, when set, is at least Interval.
+func (c PollConfig) Validate() error {
+	if c.LongPoll < 0 || c.Interval < 0 || c.MaxIdleBackoff < 0 {
+		return errors.New("poll durations must not be negative")
+	}
+	if c.MaxIdleBackoff > 0 && c.MaxIdleBackoff < c.Interval {
+		return errors.New("poll MaxIdleBackoff must be at least Interval")
+	}
+	return nil
+}
+
+// IdleDelay returns the pause after the given number of consecutive empty
+// polls (1 for the first): Interval doubled per further empty poll, capped
+// at MaxIdleBackoff.
+func (c PollConfig) IdleDelay(empty int) time.Duration {
+	if c.Interval <= 0 || empty <= 0 {
+		return 0
+	}
+	d := c.Interval
+	for i := 1; i < empty && d < c.MaxIdleBackoff; i++ {
+		d *= 2
+	}
+	if c.MaxIdleBackoff > 0 && d > c.MaxIdleBackoff {
+		d = c.MaxIdleBackoff
+	}
+	return d
+}
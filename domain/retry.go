@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// DefaultRetryMultiplier is the growth factor used when a RetryPolicy leaves
+// Multiplier unset.
+const DefaultRetryMultiplier = 2.0
+
+// RetryPolicy controls the delay between retries of a single task. The
+// number of retries is still bounded by Task.MaxRetries.
+type RetryPolicy struct {
+	InitialDelay time.Duration // delay before the first retry
+	Multiplier   float64       // growth per retry; 0 means DefaultRetryMultiplier
+	MaxDelay     time.Duration // upper bound on any delay; 0 means unbounded
+	Jitter       bool          // randomise each delay within [d/2, d]
+}
+
+// Validate checks that the policy's fields are within range.
+func (p *RetryPolicy) Validate() error {
+	if p.InitialDelay < 0 || p.MaxDelay < 0 {
+		return errors.New("retry policy delays must not be negative")
+	}
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		return errors.New("retry policy Multiplier must be at least 1")
+	}
+	return nil
+}
+
+// Delay returns the wait before retry number attempt (0-indexed):
+// InitialDelay × Multiplier^attempt, capped at MaxDelay.
+func (p *RetryPolicy) Delay(attempt int) time.Duration {
+	mult := p.Multiplier
+	if mult == 0 {
+		mult = DefaultRetryMultiplier
+	}
+	d := float64(p.InitialDelay) * math.Pow(mult, float64(attempt))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if d > math.MaxInt64 {
+		d = math.MaxInt64
+	}
+	delay := time.Duration(d)
+	if p.Jitter && delay > 1 {
+		half := delay / 2
+		delay = half + time.Duration(rand.Int63n(int64(delay-half)+1))
+	}
+	return delay
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "strings"
+
+// SecretRefPrefix marks a task Env value as a reference to a secret. The
+// worker replaces "secret://name" with the current value of secret name
+// just before running the task; the stored task keeps the reference.
+const SecretRefPrefix = "secret://"
+
+// SecretRef returns the name of the secret an Env value refers to, and
+// whether it is a reference at all.
+func SecretRef(v string) (name string, ok bool) {
+	name, ok = strings.CutPrefix(v, SecretRefPrefix)
+	return name, ok && name != ""
+}
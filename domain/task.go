@@ -17,6 +17,8 @@ const (
 	TaskStatusSucceeded TaskStatus = "succeeded"
 	TaskStatusFailed    TaskStatus = "failed"
 	TaskStatusRetrying  TaskStatus = "retrying"
+	TaskStatusDeferred  TaskStatus = "deferred" // waiting on an external operation without a worker slot
+	TaskStatusExpired   TaskStatus = "expired"  // not dispatched before its ExpiresAt, so never run
 )
 
 // Priority controls the order in which tasks are dequeued.
@@ -43,6 +45,47 @@ type Task struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	Error       string
+
+	Type           string        // selects the worker handler; empty uses the default
+	Pool           string        // resource pool the task occupies a slot in, if any
+	ConcurrencyKey string        // at most one task per key is dispatched at a time
+	Retry          *RetryPolicy  // per-task retry delays; nil uses the worker's backoff
+	WorkflowID     string        // owning workflow, if any; used to group circuit breakers
+	Group          string        // worker group whose workers run the task; empty is the default group
+	Queue          string        // named queue the task waits on instead of its group's, e.g. "gpu"; empty uses the group's
+	Deferral       *Deferral     // external operation the task is (or was) waiting on
+	Hooks          []Hook        // side effects run after the task succeeds, fails or is retried
+	Timeout        time.Duration // how long one attempt may run; 0 means no limit
+	IdempotencyKey string        // client-chosen key; a repeat within the Scheduler's window is rejected
+	DedupKey       string        // content key set by the Scheduler under content dedup; a repeat within its window is rejected
+	RunID          string        // workflow run the task belongs to, if any; scopes its content key
+	RoutingKey     string        // tasks sharing a key are pinned to one worker of the group
+	Profile        string        // isolation profile the worker runs the task under; empty uses its default
+	ExpiresAt      *time.Time    // the task expires instead of running if not dispatched by then; nil never expires
+
+	// Env holds environment variables for the task's process.
+	Env map[string]string
+	// WorkerID is the worker the task was last dispatched to, or routed to
+	// by the scheduler while it waits on that worker's queue, and
+	// DispatchedAt when that worker took it off the queue.
+	WorkerID     string
+	DispatchedAt *time.Time
+	// PayloadRef is the key of the task's Payload in a PayloadStore when
+	// the payload was too large to travel through the queue; Payload is
+	// empty while it is set.
+	PayloadRef string
+}
+
+// Deferral records the external operation a deferred task is waiting on.
+// Once the operation finishes Done is set and the task is queued again so its
+// handler can complete using Result or Error.
+type Deferral struct {
+	Token        string        // handle of the external operation, e.g. a job ID
+	PollInterval time.Duration // how often to poll; 0 means wait for a callback
+	NextPollAt   time.Time
+	Done         bool
+	Result       []byte
+	Error        string
 }
 
 // Validate checks that a Task has the minimum required fields.
@@ -59,6 +102,15 @@ func (t *Task) Validate() error {
 	if t.MaxRetries < 0 {
 		return errors.New("task MaxRetries must not be negative")
 	}
+	if t.Group != DefaultGroup && !ValidGroupName(t.Group) {
+		return errors.New("task Group is not a valid worker group name")
+	}
+	if t.Queue != "" && !ValidGroupName(t.Queue) {
+		return errors.New("task Queue is not a valid queue name")
+	}
+	if t.Retry != nil {
+		return t.Retry.Validate()
+	}
 	return nil
 }
 
@@ -69,5 +121,10 @@ func (t *Task) CanRetry() bool {
 
 // IsTerminal reports whether the task has reached a final state.
 func (t *Task) IsTerminal() bool {
-	return t.Status == TaskStatusSucceeded || t.Status == TaskStatusFailed
+	return t.Status == TaskStatusSucceeded || t.Status == TaskStatusFailed || t.Status == TaskStatusExpired
+}
+
+// Expired reports whether the task's ExpiresAt has passed at now.
+func (t *Task) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
 }
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"sort"
 	"time"
 )
 
@@ -13,8 +14,27 @@ const (
 	WorkerStatusBusy    WorkerStatus = "busy"
 	WorkerStatusDrained WorkerStatus = "drained"
 	WorkerStatusOffline WorkerStatus = "offline"
+	// WorkerStatusQuarantined marks a worker that stopped taking tasks for
+	// a while because too many of its recent attempts failed.
+	WorkerStatusQuarantined WorkerStatus = "quarantined"
+	// WorkerStatusDeactivated marks a worker taken out of service for
+	// good whose record is kept, so the tasks and attempts naming it still
+	// resolve. It cannot register again until restored.
+	WorkerStatusDeactivated WorkerStatus = "deactivated"
 )
 
+// DefaultHeartbeatInterval is how often a worker records a heartbeat, and
+// DefaultHeartbeatTimeout how long without one before it is treated as
+// gone: three missed heartbeats.
+const (
+	DefaultHeartbeatInterval = 15 * time.Second
+	DefaultHeartbeatTimeout  = 3 * DefaultHeartbeatInterval
+)
+
+// UnhealthyFailureRate is the recent failure rate from which SortByLoad,
+// and so FindAvailable, ranks a worker after every healthier one.
+const UnhealthyFailureRate = 0.5
+
 // Worker represents a node that executes tasks.
 type Worker struct {
 	ID          string
@@ -24,6 +44,44 @@ type Worker struct {
 	ActiveTasks int
 	LastHeartAt time.Time
 	RegisteredAt time.Time
+
+	// Labels are free-form placement attributes, e.g. "gpu": "a100".
+	Labels map[string]string
+	// Queues names the queues the worker serves; empty serves every queue.
+	Queues []string
+
+	// FailureRate is the share of the worker's recent attempts that failed,
+	// and AvgLatency their mean duration.
+	FailureRate float64
+	AvgLatency  time.Duration
+
+	// DeactivatedAt is when the worker was deactivated, while it is.
+	DeactivatedAt *time.Time
+}
+
+// Heartbeat is one entry of a worker's heartbeat history.
+type Heartbeat struct {
+	WorkerID    string
+	At          time.Time
+	Status      WorkerStatus
+	ActiveTasks int
+}
+
+// WorkerFilter narrows WorkerRepository.FindAvailable. Zero fields match
+// every worker.
+type WorkerFilter struct {
+	// Labels must all be present on the worker with equal values.
+	Labels map[string]string
+	// MinFreeSlots is the least number of free slots the worker must have.
+	MinFreeSlots int
+	// Queue must be one of the worker's Queues, unless it serves every queue.
+	Queue string
+	// IgnoreCapacity also matches idle or busy workers without a free
+	// slot, for placement that must not move as load changes.
+	IgnoreCapacity bool
+	// HeartbeatSince, if set, leaves out workers whose last heartbeat is
+	// older, so stale workers are not picked before they are reaped.
+	HeartbeatSince time.Time
 }
 
 // Validate checks that a Worker has the minimum required fields.
@@ -47,7 +105,75 @@ func (w *Worker) HasCapacity() bool {
 }
 
 // IsAlive reports whether a recent heartbeat has been received within the given
-// timeout window.
+// timeout window, normally DefaultHeartbeatTimeout or the timeout configured
+// in its place.
 func (w *Worker) IsAlive(timeout time.Duration) bool {
 	return time.Since(w.LastHeartAt) <= timeout
 }
+
+// FreeSlots returns how many more tasks the worker can take: 0 unless it is
+// idle or busy.
+func (w *Worker) FreeSlots() int {
+	if w.Status != WorkerStatusIdle && w.Status != WorkerStatusBusy {
+		return 0
+	}
+	if free := w.Concurrency - w.ActiveTasks; free > 0 {
+		return free
+	}
+	return 0
+}
+
+// Load returns the fraction of the worker's slots in use.
+func (w *Worker) Load() float64 {
+	return float64(w.ActiveTasks) / float64(max(w.Concurrency, 1))
+}
+
+// Matches reports whether the worker has capacity, unless f ignores it, and
+// satisfies f.
+func (w *Worker) Matches(f WorkerFilter) bool {
+	if f.IgnoreCapacity {
+		if w.Status != WorkerStatusIdle && w.Status != WorkerStatusBusy {
+			return false
+		}
+	} else if !w.HasCapacity() || (f.MinFreeSlots > 0 && w.FreeSlots() < f.MinFreeSlots) {
+		return false
+	}
+	if !f.HeartbeatSince.IsZero() && w.LastHeartAt.Before(f.HeartbeatSince) {
+		return false
+	}
+	for k, v := range f.Labels {
+		if got, ok := w.Labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	if f.Queue == "" || len(w.Queues) == 0 {
+		return true
+	}
+	for _, q := range w.Queues {
+		if q == f.Queue {
+			return true
+		}
+	}
+	return false
+}
+
+// Unhealthy reports whether the worker's recent failure rate has reached
+// UnhealthyFailureRate.
+func (w *Worker) Unhealthy() bool {
+	return w.FailureRate >= UnhealthyFailureRate
+}
+
+// SortByLoad orders workers least loaded first, breaking ties by ID.
+// Unhealthy workers come after all the others.
+func SortByLoad(workers []*Worker) {
+	sort.Slice(workers, func(i, j int) bool {
+		if ui, uj := workers[i].Unhealthy(), workers[j].Unhealthy(); ui != uj {
+			return uj
+		}
+		li, lj := workers[i].Load(), workers[j].Load()
+		if li != lj {
+			return li < lj
+		}
+		return workers[i].ID < workers[j].ID
+	})
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/service"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// HeaderRequestID carries the ID of a request. A caller-supplied value is
+// kept; otherwise RequestID generates one. Either way it is echoed on the
+// response and in error bodies.
+const HeaderRequestID = "X-Request-ID"
+
+// ctxRequestIDKey is the gin context key under which RequestID stores the
+// request's ID.
+const ctxRequestIDKey = "request_id"
+
+// maxRequestIDLen bounds a caller-supplied request ID; longer ones are
+// replaced.
+const maxRequestIDLen = 128
+
+// Error codes of failures the handlers detect themselves, and of errors no
+// more specific code is known for.
+const (
+	CodeInvalidRequest  = "invalid_request"
+	CodeUnauthenticated = "unauthenticated"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeInternal        = "internal"
+)
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError describes a failed request. Code is stable and meant for clients
+// to branch on; Message is for humans and may change. Details carries
+// code-specific data, such as the offending field of a cron expression.
+type APIError struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+
+	status int
+}
+
+// Error implements error, so handlers can pass an APIError to writeError.
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// errorMappings assigns the HTTP status and code of each error the service
+// layer returns. The first entry err matches with errors.Is wins; anything
+// else is reported as a 500 CodeInternal.
+var errorMappings = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{repository.ErrNotFound, http.StatusNotFound, CodeNotFound},
+
+	{service.ErrInvalidWorkflow, http.StatusUnprocessableEntity, "invalid_workflow"},
+	{service.ErrInvalidTasks, http.StatusUnprocessableEntity, "invalid_tasks"},
+	{service.ErrInvalidSchedule, http.StatusUnprocessableEntity, "invalid_schedule"},
+	{service.ErrNoSchedule, http.StatusUnprocessableEntity, "no_schedule"},
+	{service.ErrInvalidLabels, http.StatusUnprocessableEntity, "invalid_labels"},
+	{service.ErrInvalidRunFilter, http.StatusUnprocessableEntity, "invalid_run_filter"},
+	{service.ErrInvalidRunCalendar, http.StatusUnprocessableEntity, "invalid_run_calendar"},
+	{service.ErrInvalidUsageRange, http.StatusUnprocessableEntity, "invalid_usage_range"},
+	{service.ErrInvalidStatsRange, http.StatusUnprocessableEntity, "invalid_stats_range"},
+	{service.ErrInvalidBackfillRange, http.StatusUnprocessableEntity, "invalid_backfill_range"},
+	{service.ErrInvalidLineageQuery, http.StatusUnprocessableEntity, "invalid_lineage_query"},
+	{service.ErrInvalidCalendar, http.StatusUnprocessableEntity, "invalid_calendar"},
+	{service.ErrInvalidPurge, http.StatusUnprocessableEntity, "invalid_purge"},
+	{service.ErrInvalidLogOffset, http.StatusUnprocessableEntity, "invalid_log_offset"},
+	{service.ErrInvalidConcurrency, http.StatusUnprocessableEntity, "invalid_concurrency"},
+	{service.ErrInvalidSecret, http.StatusUnprocessableEntity, "invalid_secret"},
+	{service.ErrInvalidSimulation, http.StatusUnprocessableEntity, "invalid_simulation"},
+	{service.ErrInvalidEvent, http.StatusUnprocessableEntity, "invalid_event"},
+	{service.ErrInvalidExport, http.StatusUnprocessableEntity, "invalid_export"},
+	{service.ErrInvalidDefinitions, http.StatusUnprocessableEntity, "invalid_definitions"},
+	{service.ErrInvalidTaskTest, http.StatusUnprocessableEntity, "invalid_task_test"},
+	{service.ErrInvalidEventQuery, http.StatusUnprocessableEntity, "invalid_event_query"},
+	{service.ErrInvalidRebalance, http.StatusUnprocessableEntity, "invalid_rebalance"},
+
+	{service.ErrBackfillNotRunning, http.StatusConflict, "backfill_not_running"},
+	{service.ErrNotAwaitingApproval, http.StatusConflict, "not_awaiting_approval"},
+	{service.ErrTaskRunActive, http.StatusConflict, "task_run_active"},
+	{service.ErrDefinitionsDrifted, http.StatusConflict, "definitions_drifted"},
+	{service.ErrWorkerInService, http.StatusConflict, "worker_in_service"},
+	{service.ErrWorkerNotDeactivated, http.StatusConflict, "worker_not_deactivated"},
+	{service.ErrMaxActiveRuns, http.StatusConflict, "max_active_runs"},
+
+	{service.ErrTasksUnavailable, http.StatusNotImplemented, "tasks_unavailable"},
+	{service.ErrApprovalsUnavailable, http.StatusNotImplemented, "approvals_unavailable"},
+	{service.ErrBackfillsUnavailable, http.StatusNotImplemented, "backfills_unavailable"},
+	{service.ErrStatsUnavailable, http.StatusNotImplemented, "stats_unavailable"},
+	{service.ErrLineageUnavailable, http.StatusNotImplemented, "lineage_unavailable"},
+	{service.ErrCalendarsUnavailable, http.StatusNotImplemented, "calendars_unavailable"},
+	{service.ErrRetentionUnavailable, http.StatusNotImplemented, "retention_unavailable"},
+	{service.ErrWorkerNodesUnavailable, http.StatusNotImplemented, "worker_nodes_unavailable"},
+	{service.ErrWorkerControlUnavailable, http.StatusNotImplemented, "worker_control_unavailable"},
+	{service.ErrFreezeUnavailable, http.StatusNotImplemented, "dispatch_freeze_unavailable"},
+	{service.ErrSecretsUnavailable, http.StatusNotImplemented, "secrets_unavailable"},
+	{service.ErrRateLimitsUnavailable, http.StatusNotImplemented, "rate_limits_unavailable"},
+	{service.ErrTaskTestingUnavailable, http.StatusNotImplemented, "task_testing_unavailable"},
+	{service.ErrEventHistoryUnavailable, http.StatusNotImplemented, "event_history_unavailable"},
+	{service.ErrQueuesUnavailable, http.StatusNotImplemented, "queues_unavailable"},
+}
+
+// toAPIError returns the APIError err is reported as.
+func toAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	out := &APIError{Code: CodeInternal, Message: err.Error(), status: http.StatusInternalServerError}
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			out.Code, out.status = m.code, m.status
+			break
+		}
+	}
+	var cronErr *scheduler.CronError
+	if errors.As(err, &cronErr) && cronErr.Field != "" {
+		out.Details = gin.H{"field": cronErr.Field, "position": cronErr.Pos}
+	}
+	return out
+}
+
+// writeError writes the ErrorResponse for err.
+func writeError(c *gin.Context, err error) {
+	apiErr := *toAPIError(err)
+	apiErr.RequestID = requestID(c)
+	c.JSON(apiErr.status, ErrorResponse{Error: apiErr})
+}
+
+// badRequest writes a 400 CodeInvalidRequest response, for requests the
+// handler rejects before reaching the service.
+func badRequest(c *gin.Context, message string) {
+	writeError(c, &APIError{Code: CodeInvalidRequest, Message: message, status: http.StatusBadRequest})
+}
+
+// notFound reports a repository.ErrNotFound err as the resource what being
+// missing; other errors are returned unchanged.
+func notFound(what string, err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return &APIError{Code: CodeNotFound, Message: what + " not found", status: http.StatusNotFound}
+	}
+	return err
+}
+
+// RequestID returns middleware that assigns every request an ID, taken
+// from HeaderRequestID when the caller sent a usable one, and echoes it on
+// the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if id == "" || len(id) > maxRequestIDLen {
+			id = uuid.NewString()
+		}
+		c.Set(ctxRequestIDKey, id)
+		c.Header(HeaderRequestID, id)
+		c.Next()
+	}
+}
+
+// requestID returns the ID RequestID assigned to the request, or "" when
+// the middleware is not installed.
+func requestID(c *gin.Context) string {
+	return c.GetString(ctxRequestIDKey)
+}
@@ -4,15 +4,21 @@
 package handler
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sauravritesh63/GoLang-Project-/internal/api/service"
 	ws "github.com/sauravritesh63/GoLang-Project-/internal/api/websocket"
 	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
 	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
 )
 
@@ -28,14 +34,67 @@ func New(svc *service.Service, hub *ws.Hub) *Handler {
 	return &Handler{svc: svc, hub: hub}
 }
 
+// broadcast sends e to WebSocket clients. With an event bus configured it is
+// published on the bus instead, so clients of every API replica receive it
+// through the hub's relay.
+func (h *Handler) broadcast(ctx context.Context, e ws.Event) {
+	if bus := h.svc.Events(); bus != nil {
+		if err := bus.Publish(ctx, events.Event{Type: events.Type(e.Type), Payload: e.Payload}); err == nil {
+			return
+		}
+	}
+	h.hub.Broadcast(ctx, e)
+}
+
 // RegisterRoutes mounts all API routes onto the supplied Gin engine.
 func (h *Handler) RegisterRoutes(r *gin.Engine) {
+	r.Use(h.limitRate(), h.rejectWrites())
 	r.POST("/workflows", h.createWorkflow)
 	r.GET("/workflows", h.listWorkflows)
 	r.POST("/workflows/:id/trigger", h.triggerWorkflow)
+	r.POST("/workflows/:id/pause", h.pauseWorkflow)
+	r.POST("/workflows/:id/resume", h.resumeWorkflow)
+	r.PUT("/workflows/:id/run-timeout", h.setRunTimeout)
+	r.PUT("/workflows/:id/priority", h.setWorkflowPriority)
+	r.POST("/events/:name", h.fireEvent)
+	r.GET("/events/history", h.eventHistory)
+	r.GET("/workflows/:id/next-runs", h.nextRuns)
+	r.GET("/workflows/:id/stats", h.workflowStats)
+	r.POST("/workflows/:id/simulate", h.simulateWorkflow)
+	r.GET("/workflows/:id/runs", h.listRunsByWorkflow)
+	r.POST("/workflows/:id/backfill", h.createBackfill)
+	r.GET("/backfills/:id", h.getBackfill)
+	r.POST("/backfills/:id/cancel", h.cancelBackfill)
 	r.GET("/workflow-runs", h.listWorkflowRuns)
+	r.GET("/workflow-runs/:id/tasks/:taskId/attempts", h.taskAttempts)
+	r.DELETE("/workflow-runs", requireRole(RoleAdmin), h.purgeWorkflowRuns)
+	r.GET("/export/runs", h.exportRuns)
+	r.GET("/workflow-definitions", h.exportDefinitions)
+	r.POST("/workflow-definitions/plan", h.planDefinitions)
+	r.POST("/workflow-definitions/apply", requireRole(RoleAdmin), h.applyDefinitions)
+	r.GET("/lineage", h.lineage)
+	r.POST("/calendars", h.createCalendar)
+	r.GET("/calendars/:id", h.getCalendar)
+	r.GET("/calendar", h.runCalendar)
+	r.GET("/usage", h.usageReport)
 	r.GET("/task-runs", h.listTaskRuns)
+	r.POST("/task-runs/:id/approval", requireRole(RoleApprover), h.decideApproval)
+	r.GET("/task-runs/:id/approvals", h.listApprovals)
+	r.GET("/task-runs/:id/logs", h.taskRunLogs)
+	r.POST("/task-runs/:id/clear", h.clearTaskRun)
+	r.POST("/tasks/:id/test", h.testTask)
 	r.GET("/workers", h.listWorkers)
+	r.GET("/workers/:id", h.getWorker)
+	r.POST("/workers/:id/drain", requireRole(RoleAdmin), h.drainWorker)
+	r.PUT("/workers/:id/concurrency", requireRole(RoleAdmin), h.setWorkerConcurrency)
+	r.DELETE("/workers/:id", requireRole(RoleAdmin), h.deleteWorker)
+	r.POST("/workers/:id/restore", requireRole(RoleAdmin), h.restoreWorker)
+	r.GET("/admin/dispatch", h.dispatchState)
+	r.POST("/admin/dispatch/freeze", requireRole(RoleAdmin), h.freezeDispatch)
+	r.POST("/admin/dispatch/unfreeze", requireRole(RoleAdmin), h.unfreezeDispatch)
+	r.POST("/admin/queues/rebalance", requireRole(RoleAdmin), h.rebalanceQueue)
+	r.PUT("/admin/secrets/:name", requireRole(RoleAdmin), h.putSecret)
+	r.GET("/admin/api-keys/usage", requireRole(RoleAdmin), h.apiKeyUsage)
 	r.GET("/ws/updates", h.serveWS)
 	r.GET("/healthz", h.healthz)
 }
@@ -44,12 +103,12 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 func (h *Handler) createWorkflow(c *gin.Context) {
 	var in service.CreateWorkflowInput
 	if err := c.ShouldBindJSON(&in); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, err.Error())
 		return
 	}
 	wf, err := h.svc.CreateWorkflow(c.Request.Context(), in)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, wf)
@@ -57,12 +116,14 @@ func (h *Handler) createWorkflow(c *gin.Context) {
 
 // listWorkflows handles GET /workflows with optional ?offset=&limit= pagination.
 func (h *Handler) listWorkflows(c *gin.Context) {
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-
+	params := newParams(c)
+	offset, limit := params.page()
+	if !params.valid() {
+		return
+	}
 	wfs, err := h.svc.ListWorkflows(c.Request.Context(), offset, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, wfs)
@@ -70,72 +131,827 @@ func (h *Handler) listWorkflows(c *gin.Context) {
 
 // triggerWorkflow handles POST /workflows/{id}/trigger.
 func (h *Handler) triggerWorkflow(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workflow id"})
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	// The body is optional; an empty one triggers a run without params.
+	var in service.TriggerInput
+	if err := c.ShouldBindJSON(&in); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(c, err.Error())
 		return
 	}
-	run, err := h.svc.TriggerWorkflow(c.Request.Context(), id)
+	in.By = currentUser(c)
+	run, err := h.svc.TriggerWorkflow(c.Request.Context(), id, in)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "workflow not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, notFound("workflow", err))
 		return
 	}
 	// Broadcast the new workflow run event to connected WebSocket clients.
-	h.hub.Broadcast(c.Request.Context(), ws.Event{
+	h.broadcast(c.Request.Context(), ws.Event{
 		Type:    ws.EventWorkflowStatus,
 		Payload: run,
 	})
 	c.JSON(http.StatusCreated, run)
 }
 
-// listWorkflowRuns handles GET /workflow-runs with optional ?status= filter.
+// pauseWorkflow handles POST /workflows/{id}/pause.
+func (h *Handler) pauseWorkflow(c *gin.Context) {
+	h.setPaused(c, func(ctx context.Context, id uuid.UUID) (*domain.Workflow, error) {
+		return h.svc.PauseWorkflow(ctx, id, currentUser(c))
+	})
+}
+
+// resumeWorkflow handles POST /workflows/{id}/resume.
+func (h *Handler) resumeWorkflow(c *gin.Context) {
+	h.setPaused(c, h.svc.ResumeWorkflow)
+}
+
+func (h *Handler) setPaused(c *gin.Context, set func(context.Context, uuid.UUID) (*domain.Workflow, error)) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	wf, err := set(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, notFound("workflow", err))
+		return
+	}
+	c.JSON(http.StatusOK, wf)
+}
+
+// setRunTimeout handles PUT /workflows/{id}/run-timeout.
+func (h *Handler) setRunTimeout(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	var in service.RunTimeoutInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	wf, err := h.svc.SetRunTimeout(c.Request.Context(), id, in)
+	if err != nil {
+		writeError(c, notFound("workflow", err))
+		return
+	}
+	c.JSON(http.StatusOK, wf)
+}
+
+// setWorkflowPriority handles PUT /workflows/{id}/priority.
+func (h *Handler) setWorkflowPriority(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	var in service.PriorityInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	wf, err := h.svc.SetWorkflowPriority(c.Request.Context(), id, in)
+	if err != nil {
+		writeError(c, notFound("workflow", err))
+		return
+	}
+	c.JSON(http.StatusOK, wf)
+}
+
+// fireEvent handles POST /events/{name}. The optional body is a JSON
+// object whose fields become the params of the runs the event starts.
+func (h *Handler) fireEvent(c *gin.Context) {
+	var payload map[string]any
+	if err := c.ShouldBindJSON(&payload); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(c, "event payload must be a JSON object: "+err.Error())
+		return
+	}
+	res, err := h.svc.FireEvent(c.Request.Context(), c.Param("name"), service.EventInput{
+		Payload: payload,
+		By:      currentUser(c),
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	for _, run := range res.Runs {
+		h.broadcast(c.Request.Context(), ws.Event{Type: ws.EventWorkflowStatus, Payload: run})
+	}
+	c.JSON(http.StatusAccepted, res)
+}
+
+// nextRuns handles GET /workflows/{id}/next-runs with optional ?count=
+// (default 5).
+func (h *Handler) nextRuns(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	count := params.intRange("count", 5, 1, service.MaxNextRuns)
+	if !params.valid() {
+		return
+	}
+	res, err := h.svc.NextRuns(c.Request.Context(), id, count)
+	if err != nil {
+		writeError(c, notFound("workflow", err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// workflowStats handles GET /workflows/{id}/stats with optional ?window= and
+// ?bucket= durations (e.g. "168h", "1h").
+func (h *Handler) workflowStats(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	var window, bucket time.Duration
+	var err error
+	if v := c.Query("window"); v != "" {
+		if window, err = time.ParseDuration(v); err != nil {
+			badRequest(c, "invalid window")
+			return
+		}
+	}
+	if v := c.Query("bucket"); v != "" {
+		if bucket, err = time.ParseDuration(v); err != nil {
+			badRequest(c, "invalid bucket")
+			return
+		}
+	}
+	res, err := h.svc.WorkflowStats(c.Request.Context(), id, window, bucket)
+	if err != nil {
+		writeError(c, notFound("workflow", err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// simulateWorkflow handles POST /workflows/{id}/simulate. The body is
+// optional; without one every duration comes from the task's history.
+func (h *Handler) simulateWorkflow(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	var in service.SimulateInput
+	if err := c.ShouldBindJSON(&in); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(c, err.Error())
+		return
+	}
+	res, err := h.svc.SimulateWorkflow(c.Request.Context(), id, in)
+	if err != nil {
+		writeError(c, notFound("workflow", err))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// createBackfill handles POST /workflows/{id}/backfill.
+func (h *Handler) createBackfill(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	var in service.BackfillInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	p, err := h.svc.CreateBackfill(c.Request.Context(), id, in)
+	if err != nil {
+		writeError(c, notFound("workflow", err))
+		return
+	}
+	c.JSON(http.StatusCreated, p)
+}
+
+// getBackfill handles GET /backfills/{id}.
+func (h *Handler) getBackfill(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	p, err := h.svc.GetBackfill(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, notFound("backfill", err))
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// cancelBackfill handles POST /backfills/{id}/cancel.
+func (h *Handler) cancelBackfill(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	p, err := h.svc.CancelBackfill(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, notFound("backfill", err))
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// eventHistory handles GET /events/history with optional ?cursor= (the
+// next_cursor of the previous page), ?limit=, repeatable ?type= and
+// ?since=&until= (RFC 3339) filters.
+func (h *Handler) eventHistory(c *gin.Context) {
+	params := newParams(c)
+	q := service.EventHistoryQuery{
+		Cursor: c.Query("cursor"),
+		Types:  c.QueryArray("type"),
+		Limit:  params.intRange("limit", service.DefaultEventHistoryLimit, 1, service.MaxEventHistoryLimit),
+	}
+	if !params.valid() {
+		return
+	}
+	for param, dst := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := c.Query(param); v != "" {
+			var err error
+			if *dst, err = time.Parse(time.RFC3339, v); err != nil {
+				badRequest(c, "invalid "+param+": must be RFC 3339")
+				return
+			}
+		}
+	}
+	page, err := h.svc.EventHistory(c.Request.Context(), q)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ExportErrorTrailer is the HTTP trailer GET /export/runs sets when the
+// export fails after the response has started.
+const ExportErrorTrailer = "X-Export-Error"
+
+// exportRuns handles GET /export/runs?from=&to= (RFC 3339, bounding
+// started_at) with optional ?table=runs|task_runs and ?format=csv|parquet,
+// streaming the history as a file download.
+func (h *Handler) exportRuns(c *gin.Context) {
+	in := service.ExportInput{Table: c.Query("table"), Format: c.Query("format")}
+	for param, dst := range map[string]*time.Time{"from": &in.From, "to": &in.To} {
+		if v := c.Query(param); v != "" {
+			var err error
+			if *dst, err = time.Parse(time.RFC3339, v); err != nil {
+				badRequest(c, "invalid "+param+": must be RFC 3339")
+				return
+			}
+		}
+	}
+	exp, err := h.svc.ExportHistory(in)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("Content-Type", exp.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exp.Filename()))
+	c.Header("Trailer", ExportErrorTrailer)
+	c.Status(http.StatusOK)
+	if err := exp.Stream(c.Request.Context(), c.Writer); err != nil {
+		// The status is already sent, so a failure part way through is
+		// reported in a trailer: a client must treat a download carrying
+		// it as incomplete.
+		c.Writer.Header().Set(ExportErrorTrailer, err.Error())
+		_ = c.Error(err)
+	}
+}
+
+// exportDefinitions handles GET /workflow-definitions.
+func (h *Handler) exportDefinitions(c *gin.Context) {
+	defs, err := h.svc.ExportDefinitions(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, defs)
+}
+
+// planDefinitions handles POST /workflow-definitions/plan. The body is a
+// bundle from GET /workflow-definitions, typically of another deployment.
+func (h *Handler) planDefinitions(c *gin.Context) {
+	var defs service.Definitions
+	if err := c.ShouldBindJSON(&defs); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	plan, err := h.svc.PlanDefinitions(c.Request.Context(), &defs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// applyDefinitions handles POST /workflow-definitions/apply with optional
+// ?fingerprint= of the reviewed plan. The caller must hold RoleAdmin.
+func (h *Handler) applyDefinitions(c *gin.Context) {
+	var defs service.Definitions
+	if err := c.ShouldBindJSON(&defs); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	plan, err := h.svc.ApplyDefinitions(c.Request.Context(), &defs, c.Query("fingerprint"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// lineage handles GET /lineage?dataset=<uri> with optional ?direction=
+// (downstream|upstream), ?since= (RFC 3339) and ?depth=.
+func (h *Handler) lineage(c *gin.Context) {
+	params := newParams(c)
+	depth := params.intRange("depth", 0, 0, service.MaxLineageDepth)
+	if !params.valid() {
+		return
+	}
+	var since time.Time
+	if v := c.Query("since"); v != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			badRequest(c, "invalid since")
+			return
+		}
+	}
+	g, err := h.svc.Lineage(c.Request.Context(), c.Query("dataset"), c.Query("direction"), since, depth)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+// createCalendar handles POST /calendars.
+func (h *Handler) createCalendar(c *gin.Context) {
+	var in service.CreateCalendarInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	cal, err := h.svc.CreateCalendar(c.Request.Context(), in)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, cal)
+}
+
+// getCalendar handles GET /calendars/{id}.
+func (h *Handler) getCalendar(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	cal, err := h.svc.GetCalendar(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, notFound("calendar", err))
+		return
+	}
+	c.JSON(http.StatusOK, cal)
+}
+
+// runCalendar handles GET /calendar?from=&to=, both YYYY-MM-DD days and
+// inclusive.
+func (h *Handler) runCalendar(c *gin.Context) {
+	var from, to time.Time
+	for param, dst := range map[string]*time.Time{"from": &from, "to": &to} {
+		v, err := time.Parse(domain.CalendarDateLayout, c.Query(param))
+		if err != nil {
+			badRequest(c, "invalid "+param+": must be YYYY-MM-DD")
+			return
+		}
+		*dst = v
+	}
+	cal, err := h.svc.RunCalendar(c.Request.Context(), from, to)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cal)
+}
+
+// usageReport handles GET /usage?from=&to=&tenant=, from and to being
+// YYYY-MM-DD days, inclusive.
+func (h *Handler) usageReport(c *gin.Context) {
+	var from, to time.Time
+	for param, dst := range map[string]*time.Time{"from": &from, "to": &to} {
+		v, err := time.Parse(domain.CalendarDateLayout, c.Query(param))
+		if err != nil {
+			badRequest(c, "invalid "+param+": must be YYYY-MM-DD")
+			return
+		}
+		*dst = v
+	}
+	report, err := h.svc.UsageReport(c.Request.Context(), from, to, c.Query("tenant"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// listRunsByWorkflow handles GET /workflows/{id}/runs with optional ?status=,
+// ?from= and ?to= (RFC 3339, bounding started_at) and repeatable
+// ?label=key=value filters and ?offset=&limit= pagination.
+func (h *Handler) listRunsByWorkflow(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	filter := repository.WorkflowRunFilter{Status: params.status("status")}
+	filter.Offset, filter.Limit = params.page()
+	if !params.valid() {
+		return
+	}
+	var err error
+	if filter.Labels, err = labelSelector(c); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	for param, dst := range map[string]*time.Time{"from": &filter.StartedFrom, "to": &filter.StartedTo} {
+		if v := c.Query(param); v != "" {
+			if *dst, err = time.Parse(time.RFC3339, v); err != nil {
+				badRequest(c, "invalid "+param+": must be RFC 3339")
+				return
+			}
+		}
+	}
+	runs, err := h.svc.ListRunsByWorkflow(c.Request.Context(), id, filter)
+	if err != nil {
+		writeError(c, notFound("workflow", err))
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+// listWorkflowRuns handles GET /workflow-runs with optional ?status= and
+// repeatable ?label=key=value filters.
 func (h *Handler) listWorkflowRuns(c *gin.Context) {
-	status := domain.Status(c.Query("status"))
-	runs, err := h.svc.ListWorkflowRuns(c.Request.Context(), status)
+	params := newParams(c)
+	status := params.status("status")
+	if !params.valid() {
+		return
+	}
+	labels, err := labelSelector(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		badRequest(c, err.Error())
+		return
+	}
+	runs, err := h.svc.ListWorkflowRuns(c.Request.Context(), status, labels)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, runs)
 }
 
+// purgeWorkflowRuns handles DELETE /workflow-runs?before= (RFC 3339) with
+// optional ?status= and ?dry_run=true.
+func (h *Handler) purgeWorkflowRuns(c *gin.Context) {
+	params := newParams(c)
+	status := params.status("status")
+	dryRun := params.bool("dry_run", false)
+	if !params.valid() {
+		return
+	}
+	before, err := time.Parse(time.RFC3339, c.Query("before"))
+	if err != nil {
+		badRequest(c, "invalid before: must be RFC 3339")
+		return
+	}
+	res, err := h.svc.PurgeRuns(c.Request.Context(), before, status, dryRun)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// labelSelector parses the repeatable ?label=key=value query parameter.
+func labelSelector(c *gin.Context) (map[string]string, error) {
+	params := c.QueryArray("label")
+	if len(params) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(params))
+	for _, p := range params {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid label %q: must be key=value", p)
+		}
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+func hasRole(header, role string) bool {
+	for _, r := range strings.Split(header, ",") {
+		r = strings.TrimSpace(r)
+		if r == role || r == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
 // listTaskRuns handles GET /task-runs with optional ?status= filter.
 func (h *Handler) listTaskRuns(c *gin.Context) {
-	status := domain.Status(c.Query("status"))
+	params := newParams(c)
+	status := params.status("status")
+	if !params.valid() {
+		return
+	}
 	trs, err := h.svc.ListTaskRuns(c.Request.Context(), status)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, trs)
+}
+
+// decideApproval handles POST /task-runs/{id}/approval. The caller must hold
+// the approver role; their identity is recorded on the audit entry.
+func (h *Handler) decideApproval(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	var in service.ApprovalInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	a, tr, err := h.svc.DecideApproval(c.Request.Context(), id, currentUser(c), in)
+	if err != nil {
+		writeError(c, notFound("task run", err))
+		return
+	}
+	h.broadcast(c.Request.Context(), ws.Event{
+		Type:    ws.EventTaskStatus,
+		Payload: *tr,
+	})
+	c.JSON(http.StatusCreated, a)
+}
+
+// listApprovals handles GET /task-runs/{id}/approvals.
+func (h *Handler) listApprovals(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	list, err := h.svc.ListApprovals(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, notFound("task run", err))
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// taskRunLogs handles GET /task-runs/:id/logs with optional ?offset= (bytes
+// already read) and ?follow=true to wait for output past the offset.
+func (h *Handler) taskRunLogs(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	offset := params.intRange("offset", 0, 0, math.MaxInt)
+	follow := params.bool("follow", false)
+	if !params.valid() {
+		return
+	}
+	chunk, err := h.svc.TaskRunLogs(c.Request.Context(), id, offset, follow)
+	if err != nil {
+		writeError(c, notFound("task run", err))
+		return
+	}
+	c.JSON(http.StatusOK, chunk)
+}
+
+// taskAttempts handles GET /workflow-runs/{id}/tasks/{taskId}/attempts.
+func (h *Handler) taskAttempts(c *gin.Context) {
+	params := newParams(c)
+	runID := params.uuid("id")
+	taskID := params.uuid("taskId")
+	if !params.valid() {
+		return
+	}
+	attempts, err := h.svc.TaskAttempts(c.Request.Context(), runID, taskID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, attempts)
+}
+
+// clearTaskRun handles POST /task-runs/:id/clear with optional
+// ?downstream=true to clear the tasks depending on it as well.
+func (h *Handler) clearTaskRun(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	downstream := params.bool("downstream", false)
+	if !params.valid() {
+		return
+	}
+	trs, err := h.svc.ClearTaskRun(c.Request.Context(), id, downstream)
+	if err != nil {
+		writeError(c, notFound("task run", err))
 		return
 	}
 	c.JSON(http.StatusOK, trs)
 }
 
+// testTask handles POST /tasks/{id}/test. It answers 200 with the outcome,
+// or 202 if the execution had not finished within the wait.
+func (h *Handler) testTask(c *gin.Context) {
+	params := newParams(c)
+	id := params.uuid("id")
+	if !params.valid() {
+		return
+	}
+	// The body is optional; an empty one runs the task as defined.
+	var in service.TestTaskInput
+	if err := c.ShouldBindJSON(&in); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(c, err.Error())
+		return
+	}
+	res, err := h.svc.TestTask(c.Request.Context(), id, in)
+	if err != nil {
+		writeError(c, notFound("task", err))
+		return
+	}
+	status := http.StatusOK
+	if !res.Complete {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, res)
+}
+
 // listWorkers handles GET /workers.
 func (h *Handler) listWorkers(c *gin.Context) {
 	workers, err := h.svc.ListWorkers(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, workers)
 }
 
+// getWorker handles GET /workers/:id.
+func (h *Handler) getWorker(c *gin.Context) {
+	d, err := h.svc.GetWorker(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, notFound("worker", err))
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// drainWorker handles POST /workers/:id/drain.
+func (h *Handler) drainWorker(c *gin.Context) {
+	cmd, err := h.svc.DrainWorker(c.Request.Context(), c.Param("id"), currentUser(c))
+	if err != nil {
+		writeError(c, notFound("worker", err))
+		return
+	}
+	c.JSON(http.StatusAccepted, cmd)
+}
+
+// setWorkerConcurrency handles PUT /workers/:id/concurrency with a
+// {"concurrency": n} body.
+func (h *Handler) setWorkerConcurrency(c *gin.Context) {
+	var body struct {
+		Concurrency int `json:"concurrency"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	cmd, err := h.svc.SetWorkerConcurrency(c.Request.Context(), c.Param("id"), currentUser(c), body.Concurrency)
+	if err != nil {
+		writeError(c, notFound("worker", err))
+		return
+	}
+	c.JSON(http.StatusAccepted, cmd)
+}
+
+// deleteWorker handles DELETE /workers/:id, which deactivates the worker,
+// or deletes its record with ?purge=true.
+func (h *Handler) deleteWorker(c *gin.Context) {
+	params := newParams(c)
+	purge := params.bool("purge", false)
+	if !params.valid() {
+		return
+	}
+	if err := h.svc.DeleteWorker(c.Request.Context(), c.Param("id"), purge); err != nil {
+		writeError(c, notFound("worker", err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// restoreWorker handles POST /workers/:id/restore.
+func (h *Handler) restoreWorker(c *gin.Context) {
+	d, err := h.svc.RestoreWorker(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, notFound("worker", err))
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// rebalanceQueue handles POST /admin/queues/rebalance with a
+// service.RebalanceInput body.
+func (h *Handler) rebalanceQueue(c *gin.Context) {
+	var in service.RebalanceInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	res, err := h.svc.RebalanceQueue(c.Request.Context(), in)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// putSecret handles PUT /admin/secrets/:name with a {"value": "..."} body.
+// The value is never returned.
+func (h *Handler) putSecret(c *gin.Context) {
+	var body struct {
+		Value string `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	err := h.svc.PutSecret(c.Request.Context(), c.Param("name"), body.Value, currentUser(c))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// dispatchState handles GET /admin/dispatch.
+func (h *Handler) dispatchState(c *gin.Context) {
+	st, err := h.svc.DispatchState(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+// freezeDispatch handles POST /admin/dispatch/freeze with an optional
+// {"reason": "..."} body.
+func (h *Handler) freezeDispatch(c *gin.Context) {
+	var in service.FreezeInput
+	if err := c.ShouldBindJSON(&in); err != nil && !errors.Is(err, io.EOF) {
+		badRequest(c, err.Error())
+		return
+	}
+	st, err := h.svc.FreezeDispatch(c.Request.Context(), currentUser(c), in)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+// unfreezeDispatch handles POST /admin/dispatch/unfreeze.
+func (h *Handler) unfreezeDispatch(c *gin.Context) {
+	st, err := h.svc.UnfreezeDispatch(c.Request.Context(), currentUser(c))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
 // serveWS upgrades the connection to WebSocket and streams real-time events.
 func (h *Handler) serveWS(c *gin.Context) {
 	h.hub.ServeWS(c.Writer, c.Request)
 }
 
 // healthz handles GET /healthz.
-// It returns a JSON object with the overall service status. Additional
-// component checks (DB, Redis, etc.) can be injected here when concrete
-// infrastructure clients are wired in.
+// It returns a JSON object with the overall service status, "degraded"
+// while a backpressure alert fires. Degraded is still served with 200 so
+// load balancers keep routing to the API. Additional component checks (DB,
+// Redis, etc.) can be injected here when concrete infrastructure clients
+// are wired in.
 func (h *Handler) healthz(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "ok",
-		"service": "task-scheduler-api",
-	})
+	c.JSON(http.StatusOK, h.svc.Health())
 }
@@ -6,16 +6,22 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
 	"github.com/sauravritesh63/GoLang-Project-/internal/api/handler"
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/ratelimit"
 	"github.com/sauravritesh63/GoLang-Project-/internal/api/service"
 	ws "github.com/sauravritesh63/GoLang-Project-/internal/api/websocket"
 	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
 	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
 )
 
 func init() {
@@ -28,12 +34,22 @@ func newTestRouter() (*gin.Engine, *mock.WorkflowRepo, *mock.WorkflowRunRepo, *m
 	wrRepo := mock.NewWorkflowRunRepo()
 	trRepo := mock.NewTaskRunRepo()
 	wkRepo := mock.NewWorkerRepo()
+	tasks := mock.NewTaskRepo()
 
-	svc := service.New(wfRepo, wrRepo, trRepo, wkRepo)
+	svc := service.New(wfRepo, wrRepo, trRepo, wkRepo,
+		service.WithApprovals(mock.NewApprovalRepo(trRepo)),
+		service.WithBackfills(mock.NewBackfillRepo()),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)),
+		service.WithStats(mock.NewStatsRepo(wrRepo, trRepo, tasks)),
+		service.WithLineage(mock.NewLineageRepo()),
+		service.WithCalendars(mock.NewCalendarRepo()),
+		service.WithRetention(mock.NewRetentionRepo(wrRepo, trRepo)),
+	)
 	hub := ws.NewHub()
 	h := handler.New(svc, hub)
 
 	r := gin.New()
+	r.Use(handler.RequestID())
 	h.RegisterRoutes(r)
 	return r, wfRepo, wrRepo, trRepo, wkRepo
 }
@@ -64,6 +80,25 @@ func TestCreateWorkflow_Success(t *testing.T) {
 	}
 }
 
+// TestCreateWorkflow_CyclicTasks verifies POST /workflows returns 422 when
+// the supplied tasks depend on each other in a cycle.
+func TestCreateWorkflow_CyclicTasks(t *testing.T) {
+	r, wfRepo, _, _, _ := newTestRouter()
+
+	body := `{"name":"wf","tasks":[{"name":"a","depends_on":["b"]},{"name":"b","depends_on":["a"]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/workflows", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	if all, _ := wfRepo.List(context.Background()); len(all) != 0 {
+		t.Errorf("expected no workflow to be created, got %d", len(all))
+	}
+}
+
 // TestCreateWorkflow_MissingName verifies POST /workflows returns 400 when
 // the required 'name' field is absent.
 func TestCreateWorkflow_MissingName(t *testing.T) {
@@ -80,6 +115,93 @@ func TestCreateWorkflow_MissingName(t *testing.T) {
 	}
 }
 
+// TestCreateWorkflow_InvalidSchedule verifies POST /workflows rejects an
+// unparsable cron expression or timezone with 422, pointing at the faulty
+// cron field, and stores nothing.
+func TestCreateWorkflow_InvalidSchedule(t *testing.T) {
+	r, wfRepo, _, _, _ := newTestRouter()
+
+	for _, tc := range []struct {
+		body     string
+		field    string
+		position int
+	}{
+		{`{"name":"wf","schedule_cron":"banana"}`, "", 0},
+		{`{"name":"wf","schedule_cron":"0 9 * * 9"}`, "day of week", 9},
+		{`{"name":"wf","schedule_cron":"0 9 * * *","timezone":"Nowhere/City"}`, "", 0},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/workflows", bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d: %s", tc.body, w.Code, w.Body.String())
+			continue
+		}
+		var resp struct {
+			Error struct {
+				Code    string `json:"code"`
+				Details struct {
+					Field    string `json:"field"`
+					Position int    `json:"position"`
+				} `json:"details"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Error.Code != "invalid_schedule" {
+			t.Errorf("%s: code %q, want invalid_schedule", tc.body, resp.Error.Code)
+		}
+		if d := resp.Error.Details; d.Field != tc.field || d.Position != tc.position {
+			t.Errorf("%s: field %q at %d, want %q at %d", tc.body, d.Field, d.Position, tc.field, tc.position)
+		}
+	}
+	if wfs, _ := wfRepo.List(context.Background()); len(wfs) != 0 {
+		t.Errorf("stored %d workflows, want none", len(wfs))
+	}
+}
+
+// TestErrorEnvelope verifies error responses carry a machine-readable code
+// and the request's ID, whether the caller supplied it or not.
+func TestErrorEnvelope(t *testing.T) {
+	r, _, _, _, _ := newTestRouter()
+
+	for _, tc := range []struct {
+		req    *http.Request
+		status int
+		code   string
+	}{
+		{httptest.NewRequest(http.MethodPost, "/workflows/"+uuid.NewString()+"/trigger", nil), http.StatusNotFound, handler.CodeNotFound},
+		{httptest.NewRequest(http.MethodGet, "/workflows/not-a-uuid/stats", nil), http.StatusUnprocessableEntity, handler.CodeInvalidParams},
+		{httptest.NewRequest(http.MethodPost, "/admin/dispatch/freeze", nil), http.StatusUnauthorized, handler.CodeUnauthenticated},
+		{httptest.NewRequest(http.MethodGet, "/admin/dispatch", nil), http.StatusNotImplemented, "dispatch_freeze_unavailable"},
+		{httptest.NewRequest(http.MethodPost, "/tasks/"+uuid.NewString()+"/test", nil), http.StatusNotImplemented, "task_testing_unavailable"},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, tc.req)
+		var resp handler.ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != tc.status || resp.Error.Code != tc.code || resp.Error.Message == "" {
+			t.Errorf("%s %s: got %d %+v, want %d with code %s", tc.req.Method, tc.req.URL, w.Code, resp.Error, tc.status, tc.code)
+		}
+		if id := w.Header().Get(handler.HeaderRequestID); id == "" || resp.Error.RequestID != id {
+			t.Errorf("%s %s: request_id %q, header %q", tc.req.Method, tc.req.URL, resp.Error.RequestID, id)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/workflows/"+uuid.NewString()+"/runs", nil)
+	req.Header.Set(handler.HeaderRequestID, "req-42")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp handler.ErrorResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Error.RequestID != "req-42" || resp.Error.Message != "workflow not found" {
+		t.Errorf("got %+v, want the caller's request ID and a workflow not found message", resp.Error)
+	}
+}
+
 // TestListWorkflows_Empty verifies GET /workflows returns an empty JSON array
 // when no workflows exist.
 func TestListWorkflows_Empty(t *testing.T) {
@@ -157,8 +279,142 @@ func TestTriggerWorkflow_Success(t *testing.T) {
 	}
 }
 
+// TestTriggerWorkflow_WithParams verifies that params in the request body are
+// stored on the run.
+func TestTriggerWorkflow_WithParams(t *testing.T) {
+	r, wfRepo, _, _, _ := newTestRouter()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(context.Background(), wf)
+
+	body := bytes.NewBufferString(`{"params":{"table":"orders"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/workflows/"+wf.ID.String()+"/trigger", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var run domain.WorkflowRun
+	if err := json.NewDecoder(w.Body).Decode(&run); err != nil {
+		t.Fatal(err)
+	}
+	if run.Params["table"] != "orders" {
+		t.Errorf("expected params to be stored, got %v", run.Params)
+	}
+}
+
+// TestPauseResumeWorkflow verifies POST /workflows/{id}/pause records the
+// caller and POST /workflows/{id}/resume clears the pause.
+func TestPauseResumeWorkflow(t *testing.T) {
+	r, wfRepo, _, _, _ := newTestRouter()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", IsActive: true, CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(context.Background(), wf)
+
+	post := func(path string) (*httptest.ResponseRecorder, domain.Workflow) {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set(handler.HeaderUser, "alice")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var got domain.Workflow
+		_ = json.Unmarshal(w.Body.Bytes(), &got)
+		return w, got
+	}
+	w, got := post("/workflows/" + wf.ID.String() + "/pause")
+	if w.Code != http.StatusOK || !got.Paused || got.PausedBy != "alice" {
+		t.Fatalf("pause: got %d %s", w.Code, w.Body.String())
+	}
+	w, got = post("/workflows/" + wf.ID.String() + "/resume")
+	if w.Code != http.StatusOK || got.Paused || got.PausedBy != "" {
+		t.Fatalf("resume: got %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := post("/workflows/" + uuid.NewString() + "/pause"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown workflow: expected 404, got %d", w.Code)
+	}
+	if w, _ := post("/workflows/not-a-uuid/resume"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid id: expected 422, got %d", w.Code)
+	}
+}
+
 // TestTriggerWorkflow_NotFound verifies that triggering a non-existent workflow
 // returns 404.
+// TestRunLabels verifies labels given at trigger time, and the caller as
+// triggered_by, can be used to filter both run listings.
+func TestRunLabels(t *testing.T) {
+	r, wfRepo, _, _, _ := newTestRouter()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(context.Background(), wf)
+
+	for _, user := range []string{"alice", "bob"} {
+		req := httptest.NewRequest(http.MethodPost, "/workflows/"+wf.ID.String()+"/trigger",
+			bytes.NewBufferString(`{"labels": {"env": "prod"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(handler.HeaderUser, user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("trigger: expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	list := func(path string) (int, []domain.WorkflowRun) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var runs []domain.WorkflowRun
+		_ = json.NewDecoder(w.Body).Decode(&runs)
+		return w.Code, runs
+	}
+	for _, path := range []string{
+		"/workflow-runs?label=triggered_by=alice&label=env=prod",
+		"/workflows/" + wf.ID.String() + "/runs?label=triggered_by=alice&label=env=prod",
+	} {
+		code, runs := list(path)
+		if code != http.StatusOK || len(runs) != 1 || runs[0].Labels[domain.LabelTriggeredBy] != "alice" {
+			t.Errorf("%s: got %d with %d runs, want alice's run", path, code, len(runs))
+		}
+	}
+	if _, runs := list("/workflow-runs?label=env=dev"); len(runs) != 0 {
+		t.Errorf("env=dev: got %d runs, want 0", len(runs))
+	}
+	if code, _ := list("/workflow-runs?label=env"); code != http.StatusBadRequest {
+		t.Errorf("malformed label: expected 400, got %d", code)
+	}
+}
+
+func TestFireEvent(t *testing.T) {
+	r, wfRepo, _, _, _ := newTestRouter()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "ingest", IsActive: true, TriggerEvents: []string{"orders.exported"}, CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(context.Background(), wf)
+
+	post := func(name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/events/"+name, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	w := post("orders.exported", `{"date": "2026-10-16"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var res service.EventResult
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Runs) != 1 || res.Runs[0].WorkflowID != wf.ID || res.Runs[0].Params["date"] != "2026-10-16" {
+		t.Errorf("result = %+v, want a run of ingest with the payload as params", res)
+	}
+	if w := post("orders.exported", ""); w.Code != http.StatusAccepted {
+		t.Errorf("empty body: expected 202, got %d", w.Code)
+	}
+	if w := post("orders.exported", `["not", "an", "object"]`); w.Code != http.StatusBadRequest {
+		t.Errorf("array body: expected 400, got %d", w.Code)
+	}
+	if w := post("bad%20name", ""); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid name: expected 422, got %d", w.Code)
+	}
+}
+
 func TestTriggerWorkflow_NotFound(t *testing.T) {
 	r, _, _, _, _ := newTestRouter()
 
@@ -179,8 +435,232 @@ func TestTriggerWorkflow_InvalidID(t *testing.T) {
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Fatalf("expected 400, got %d", w.Code)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+}
+
+// TestNextRuns_Timezone verifies GET /workflows/{id}/next-runs returns the
+// requested number of fire times in the workflow's timezone.
+func TestNextRuns_Timezone(t *testing.T) {
+	r, wfRepo, _, _, _ := newTestRouter()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 9 * * *", Timezone: "Asia/Kolkata"}
+	_ = wfRepo.Create(context.Background(), wf)
+
+	req := httptest.NewRequest(http.MethodGet, "/workflows/"+wf.ID.String()+"/next-runs?count=3", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var res service.NextRunsResult
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.NextRuns) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(res.NextRuns))
+	}
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+	for _, ts := range res.NextRuns {
+		if local := ts.In(loc); local.Hour() != 9 || local.Minute() != 0 {
+			t.Errorf("expected 09:00 IST, got %s", local)
+		}
+	}
+}
+
+// TestNextRuns_NoSchedule verifies that previewing an unscheduled workflow
+// returns 422.
+func TestNextRuns_NoSchedule(t *testing.T) {
+	r, wfRepo, _, _, _ := newTestRouter()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf"}
+	_ = wfRepo.Create(context.Background(), wf)
+
+	req := httptest.NewRequest(http.MethodGet, "/workflows/"+wf.ID.String()+"/next-runs", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+}
+
+// TestWorkflowStats verifies GET /workflows/{id}/stats summarises finished
+// runs and rejects windows spanning too many buckets.
+func TestWorkflowStats(t *testing.T) {
+	r, wfRepo, wrRepo, _, _ := newTestRouter()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf"}
+	_ = wfRepo.Create(context.Background(), wf)
+	for _, d := range []time.Duration{10 * time.Second, 30 * time.Second} {
+		started := time.Now().UTC().Add(-time.Hour)
+		finished := started.Add(d)
+		_ = wrRepo.Create(context.Background(), &domain.WorkflowRun{
+			ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusSuccess, StartedAt: started, FinishedAt: &finished,
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/workflows/"+wf.ID.String()+"/stats?window=24h&bucket=1h", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var res service.WorkflowStats
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Runs.Runs != 2 || res.Runs.P50 != 20 || res.BucketSeconds != 3600 {
+		t.Errorf("unexpected stats: %+v", res)
+	}
+	if len(res.Trend) == 0 {
+		t.Error("expected at least one trend bucket")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/workflows/"+wf.ID.String()+"/stats?window=8760h&bucket=1m", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for too many buckets, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/workflows/"+uuid.New().String()+"/stats", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown workflow, got %d", w.Code)
+	}
+}
+
+// TestLineage_RequiresDataset verifies GET /lineage returns an empty graph for
+// an unknown dataset and 422 when no dataset is given.
+func TestLineage_RequiresDataset(t *testing.T) {
+	r, _, _, _, _ := newTestRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/lineage?dataset=s3://lake/orders&direction=upstream", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var g domain.LineageGraph
+	if err := json.NewDecoder(w.Body).Decode(&g); err != nil {
+		t.Fatal(err)
+	}
+	if g.Dataset != "s3://lake/orders" || len(g.Edges) != 0 {
+		t.Errorf("unexpected graph: %+v", g)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/lineage", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d", w.Code)
+	}
+}
+
+// TestCalendar_CreateAndAttach verifies POST /calendars validates dates, and
+// that the created calendar can be attached to a new workflow.
+func TestCalendar_CreateAndAttach(t *testing.T) {
+	r, _, _, _, _ := newTestRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/calendars", bytes.NewBufferString(`{"name":"holidays","excluded_dates":["25/12/2026"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a malformed date, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/calendars", bytes.NewBufferString(`{"name":"holidays","excluded_dates":["2026-12-25"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var cal domain.Calendar
+	if err := json.NewDecoder(w.Body).Decode(&cal); err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"name":"wf","schedule_cron":"@daily","calendar_id":"` + cal.ID.String() + `"}`
+	req = httptest.NewRequest(http.MethodPost, "/workflows", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body = `{"name":"wf","schedule_cron":"@daily","calendar_id":"` + uuid.New().String() + `"}`
+	req = httptest.NewRequest(http.MethodPost, "/workflows", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for an unknown calendar, got %d", w.Code)
+	}
+}
+
+// TestBackfill_CreateAndCancel verifies POST /workflows/{id}/backfill plans
+// the range without creating runs itself and that the backfill can then be
+// cancelled.
+func TestBackfill_CreateAndCancel(t *testing.T) {
+	r, wfRepo, wrRepo, _, _ := newTestRouter()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "@daily"}
+	_ = wfRepo.Create(context.Background(), wf)
+
+	body := `{"start":"2024-01-01T00:00:00Z","end":"2024-01-05T00:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/workflows/"+wf.ID.String()+"/backfill", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var p service.BackfillProgress
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Total != 5 || p.Created != 0 || p.Active != 0 {
+		t.Errorf("expected total=5 created=0 active=0, got %d/%d/%d", p.Total, p.Created, p.Active)
+	}
+	// Runs are left to the scheduler's Backfiller.
+	if runs, _ := wrRepo.ListByWorkflowID(context.Background(), wf.ID); len(runs) != 0 {
+		t.Fatalf("expected no runs yet, got %d", len(runs))
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/backfills/"+p.ID.String()+"/cancel", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cancel: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/backfills/"+p.ID.String()+"/cancel", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("second cancel: expected 409, got %d", w.Code)
+	}
+}
+
+// TestBackfill_ReversedRange verifies that end before start returns 422.
+func TestBackfill_ReversedRange(t *testing.T) {
+	r, wfRepo, _, _, _ := newTestRouter()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "@daily"}
+	_ = wfRepo.Create(context.Background(), wf)
+
+	body := `{"start":"2024-01-05T00:00:00Z","end":"2024-01-01T00:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/workflows/"+wf.ID.String()+"/backfill", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
 	}
 }
 
@@ -198,6 +678,48 @@ func TestListWorkflowRuns_Empty(t *testing.T) {
 	}
 }
 
+// TestListRunsByWorkflow verifies GET /workflows/{id}/runs returns only that
+// workflow's runs, filtered and paginated.
+func TestListRunsByWorkflow(t *testing.T) {
+	r, wfRepo, wrRepo, _, _ := newTestRouter()
+	ctx := context.Background()
+
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(ctx, wf)
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, status := range []domain.Status{domain.StatusSuccess, domain.StatusFailed, domain.StatusSuccess} {
+		_ = wrRepo.Create(ctx, &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: status,
+			StartedAt: base.Add(time.Duration(i) * 24 * time.Hour)})
+	}
+	_ = wrRepo.Create(ctx, &domain.WorkflowRun{ID: uuid.New(), WorkflowID: uuid.New(), Status: domain.StatusSuccess, StartedAt: base})
+
+	url := "/workflows/" + wf.ID.String() + "/runs?status=success&from=2024-03-01T00:00:00Z&to=2024-03-10T00:00:00Z&limit=1"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var runs []domain.WorkflowRun
+	if err := json.NewDecoder(w.Body).Decode(&runs); err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || !runs[0].StartedAt.Equal(base.Add(48*time.Hour)) {
+		t.Errorf("expected the newest successful run, got %+v", runs)
+	}
+
+	for url, want := range map[string]int{
+		"/workflows/" + uuid.NewString() + "/runs":                                                 http.StatusNotFound,
+		"/workflows/" + wf.ID.String() + "/runs?from=yesterday":                                    http.StatusBadRequest,
+		"/workflows/" + wf.ID.String() + "/runs?from=2024-03-02T00:00:00Z&to=2024-03-01T00:00:00Z": http.StatusUnprocessableEntity,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", url, want, w.Code)
+		}
+	}
+}
+
 // TestListTaskRuns_Empty verifies GET /task-runs returns an empty JSON array.
 func TestListTaskRuns_Empty(t *testing.T) {
 	r, _, _, _, _ := newTestRouter()
@@ -250,25 +772,1176 @@ func TestListWorkers_Active(t *testing.T) {
 	}
 }
 
-// TestHealthz verifies GET /healthz returns 200 with status "ok".
-func TestHealthz(t *testing.T) {
+// TestGetWorker verifies GET /workers/:id returns the worker node with only
+// its own running tasks and its heartbeats newest first.
+func TestGetWorker(t *testing.T) {
+	nodes, tasks, beats := scheduler.NewMemWorkerRepo(), scheduler.NewMemTaskRepo(), scheduler.NewMemHeartbeatRepo()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithWorkerNodes(nodes, tasks, beats))
+	r := gin.New()
+	handler.New(svc, ws.NewHub()).RegisterRoutes(r)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	_ = nodes.Save(ctx, &qdomain.Worker{ID: "w1", Status: qdomain.WorkerStatusBusy, Concurrency: 2, ActiveTasks: 1})
+	_ = tasks.Save(ctx, &qdomain.Task{ID: "t1", Name: "extract", Status: qdomain.TaskStatusRunning, WorkerID: "w1", RetryCount: 1, StartedAt: &now})
+	_ = tasks.Save(ctx, &qdomain.Task{ID: "t2", Name: "other", Status: qdomain.TaskStatusRunning, WorkerID: "w2"})
+	_ = tasks.Save(ctx, &qdomain.Task{ID: "t3", Name: "done", Status: qdomain.TaskStatusSucceeded, WorkerID: "w1"})
+	for i := range 3 {
+		_ = beats.Record(ctx, qdomain.Heartbeat{WorkerID: "w1", At: now.Add(time.Duration(i) * time.Second), ActiveTasks: i})
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/workers/w1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var d service.WorkerDetail
+	if err := json.NewDecoder(w.Body).Decode(&d); err != nil {
+		t.Fatal(err)
+	}
+	if d.ID != "w1" || d.ActiveTasks != 1 {
+		t.Errorf("worker = %+v", d)
+	}
+	if len(d.RunningTasks) != 1 || d.RunningTasks[0].ID != "t1" || d.RunningTasks[0].Attempt != 2 {
+		t.Errorf("running tasks = %+v, want only t1 on attempt 2", d.RunningTasks)
+	}
+	if len(d.Heartbeats) != 3 || d.Heartbeats[0].ActiveTasks != 2 {
+		t.Errorf("heartbeats = %+v, want 3 newest first", d.Heartbeats)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/workers/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown worker: expected 404, got %d", w.Code)
+	}
+}
+
+// TestGetWorker_Unavailable verifies GET /workers/:id returns 501 when no
+// worker node stores are configured.
+func TestGetWorker_Unavailable(t *testing.T) {
 	r, _, _, _, _ := newTestRouter()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/workers/w1", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("expected 501, got %d", w.Code)
+	}
+}
+
+// TestTaskRunLogs_Offset verifies GET /task-runs/:id/logs returns the log
+// from the offset and the offset to continue from.
+func TestTaskAttempts(t *testing.T) {
+	r, _, wrRepo, trRepo, _ := newTestRouter()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	wr := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: uuid.New(), Status: domain.StatusFailed, StartedAt: now, FinishedAt: &now}
+	_ = wrRepo.Create(ctx, wr)
+	tr := &domain.TaskRun{ID: uuid.New(), WorkflowRunID: wr.ID, TaskID: uuid.New(), Status: domain.StatusFailed, Attempt: 1, StartedAt: now, FinishedAt: &now, Logs: "boom\n"}
+	_ = trRepo.Create(ctx, tr)
+	path := func(run, task string) string { return "/workflow-runs/" + run + "/tasks/" + task + "/attempts" }
 
-	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
 	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path(wr.ID.String(), tr.TaskID.String()), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var attempts []service.TaskAttempt
+	if err := json.NewDecoder(w.Body).Decode(&attempts); err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 1 || attempts[0].Number != 1 || attempts[0].TaskRunID != tr.ID || attempts[0].Logs != "boom\n" {
+		t.Errorf("attempts = %+v, want the task run as attempt 1", attempts)
+	}
+
+	for name, tc := range map[string]struct {
+		path string
+		code int
+	}{
+		"bad run id":   {path("nope", tr.TaskID.String()), http.StatusUnprocessableEntity},
+		"bad task id":  {path(wr.ID.String(), "nope"), http.StatusUnprocessableEntity},
+		"unknown run":  {path(uuid.NewString(), tr.TaskID.String()), http.StatusNotFound},
+		"unknown task": {path(wr.ID.String(), uuid.NewString()), http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.code {
+			t.Errorf("%s: expected %d, got %d", name, tc.code, w.Code)
+		}
+	}
+}
+
+func TestTaskRunLogs_Offset(t *testing.T) {
+	r, _, _, trRepo, _ := newTestRouter()
+	tr := &domain.TaskRun{ID: uuid.New(), Status: domain.StatusRunning, Logs: "hello\nworld\n"}
+	_ = trRepo.Create(context.Background(), tr)
 
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/task-runs/"+tr.ID.String()+"/logs?offset=6", nil))
 	if w.Code != http.StatusOK {
-		t.Fatalf("expected 200, got %d", w.Code)
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
 	}
-	var body map[string]string
-	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+	var chunk service.LogChunk
+	if err := json.NewDecoder(w.Body).Decode(&chunk); err != nil {
 		t.Fatal(err)
 	}
-	if body["status"] != "ok" {
-		t.Errorf("expected status 'ok', got %q", body["status"])
+	if chunk.Data != "world\n" || chunk.Offset != 12 || chunk.Complete {
+		t.Errorf("chunk = %+v, want world from offset 6 up to 12", chunk)
 	}
-	if body["service"] != "task-scheduler-api" {
-		t.Errorf("expected service 'task-scheduler-api', got %q", body["service"])
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/task-runs/"+tr.ID.String()+"/logs?offset=13", nil))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("offset past the end: expected 422, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/task-runs/"+uuid.NewString()+"/logs", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown task run: expected 404, got %d", w.Code)
+	}
+}
+
+// TestTaskRunLogs_Follow verifies ?follow=true waits for output past the
+// offset instead of returning an empty chunk straight away.
+func TestTaskRunLogs_Follow(t *testing.T) {
+	r, _, _, trRepo, _ := newTestRouter()
+	ctx := context.Background()
+	tr := &domain.TaskRun{ID: uuid.New(), Status: domain.StatusRunning, Logs: "start\n"}
+	_ = trRepo.Create(ctx, tr)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = trRepo.AppendLogs(ctx, tr.ID, "more\n")
+	}()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/task-runs/"+tr.ID.String()+"/logs?offset=6&follow=true", nil))
+	var chunk service.LogChunk
+	if err := json.NewDecoder(w.Body).Decode(&chunk); err != nil {
+		t.Fatal(err)
+	}
+	if chunk.Data != "more\n" || chunk.Offset != 11 {
+		t.Errorf("chunk = %+v, want the appended line", chunk)
+	}
+
+	// A finished task run returns at once even though nothing is new.
+	_ = trRepo.UpdateStatus(ctx, tr.ID, domain.StatusSuccess, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/task-runs/"+tr.ID.String()+"/logs?offset=11&follow=true", nil))
+	chunk = service.LogChunk{}
+	if err := json.NewDecoder(w.Body).Decode(&chunk); err != nil {
+		t.Fatal(err)
+	}
+	if chunk.Data != "" || !chunk.Complete {
+		t.Errorf("chunk = %+v, want empty and complete", chunk)
+	}
+}
+
+// TestClearTaskRun verifies POST /task-runs/:id/clear adds a pending attempt
+// for a finished task run and refuses one that is still running.
+func TestClearTaskRun(t *testing.T) {
+	r, _, wrRepo, trRepo, _ := newTestRouter()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	run := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: uuid.New(), Status: domain.StatusFailed, StartedAt: now, FinishedAt: &now}
+	_ = wrRepo.Create(ctx, run)
+	failed := &domain.TaskRun{ID: uuid.New(), WorkflowRunID: run.ID, TaskID: uuid.New(), Status: domain.StatusFailed, Attempt: 1, StartedAt: now}
+	running := &domain.TaskRun{ID: uuid.New(), WorkflowRunID: run.ID, TaskID: uuid.New(), Status: domain.StatusRunning, Attempt: 1, StartedAt: now}
+	_ = trRepo.Create(ctx, failed)
+	_ = trRepo.Create(ctx, running)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/task-runs/"+failed.ID.String()+"/clear", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var cleared []domain.TaskRun
+	if err := json.NewDecoder(w.Body).Decode(&cleared); err != nil {
+		t.Fatal(err)
+	}
+	if len(cleared) != 1 || cleared[0].Attempt != 2 || cleared[0].Status != domain.StatusPending {
+		t.Errorf("cleared = %+v, want one pending attempt 2", cleared)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/task-runs/"+running.ID.String()+"/clear", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("running task run: expected 409, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/task-runs/"+failed.ID.String()+"/clear?downstream=maybe", nil))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("malformed downstream: expected 422, got %d", w.Code)
+	}
+}
+
+// TestSimulateWorkflow verifies POST /workflows/{id}/simulate returns the
+// predicted timeline and rejects estimates for unknown tasks.
+func TestSimulateWorkflow(t *testing.T) {
+	r, _, _, _, _ := newTestRouter()
+	body := `{"name":"etl","tasks":[{"name":"extract","command":"e.sh"},{"name":"load","command":"l.sh","depends_on":["extract"]}]}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/workflows", bytes.NewBufferString(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var wf domain.Workflow
+	if err := json.NewDecoder(w.Body).Decode(&wf); err != nil {
+		t.Fatal(err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/workflows/"+wf.ID.String()+"/simulate",
+		bytes.NewBufferString(`{"estimates":{"extract":30,"load":15}}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("simulate: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var sim service.Simulation
+	if err := json.NewDecoder(w.Body).Decode(&sim); err != nil {
+		t.Fatal(err)
+	}
+	if sim.DurationSeconds != 45 || len(sim.CriticalPath) != 2 || sim.CriticalPath[0] != "extract" {
+		t.Errorf("simulation = %+v, want 45s through extract and load", sim)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/workflows/"+wf.ID.String()+"/simulate",
+		bytes.NewBufferString(`{"estimates":{"deploy":5}}`)))
+	var resp handler.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusUnprocessableEntity || resp.Error.Code != "invalid_simulation" {
+		t.Errorf("unknown task: got %d %q, want 422 invalid_simulation", w.Code, resp.Error.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/workflows/"+uuid.NewString()+"/simulate", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown workflow: expected 404, got %d", w.Code)
+	}
+}
+
+// TestRunCalendar verifies GET /calendar buckets runs per workflow and day
+// and validates its range.
+func TestRunCalendar(t *testing.T) {
+	r, wfRepo, wrRepo, _, _ := newTestRouter()
+	ctx := context.Background()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "etl", CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(ctx, wf)
+	day := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	_ = wrRepo.Create(ctx, &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusSuccess, StartedAt: day.Add(3 * time.Hour)})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/calendar?from=2025-06-01&to=2025-06-07", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var cal service.RunCalendar
+	if err := json.NewDecoder(w.Body).Decode(&cal); err != nil {
+		t.Fatal(err)
+	}
+	if len(cal.Workflows) != 1 || len(cal.Workflows[0].Days) != 1 ||
+		cal.Workflows[0].Days[0] != (service.CalendarDay{Date: "2025-06-02", Succeeded: 1}) {
+		t.Errorf("calendar = %+v, want one success on 2025-06-02", cal)
+	}
+
+	for query, want := range map[string]int{
+		"/calendar?from=2025-06-01":               http.StatusBadRequest,
+		"/calendar?from=2025-06-01&to=06/07/2025": http.StatusBadRequest,
+		"/calendar?from=2025-06-07&to=2025-06-01": http.StatusUnprocessableEntity,
+	} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, query, nil))
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", query, want, w.Code)
+		}
+	}
+}
+
+// TestUsageReport verifies GET /usage totals task execution time per tenant
+// and validates its range.
+func TestUsageReport(t *testing.T) {
+	r, wfRepo, wrRepo, trRepo, _ := newTestRouter()
+	ctx := context.Background()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "etl", Tenant: "data", CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(ctx, wf)
+	run := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusSuccess, StartedAt: time.Date(2025, 6, 2, 3, 0, 0, 0, time.UTC)}
+	_ = wrRepo.Create(ctx, run)
+	finished := run.StartedAt.Add(90 * time.Second)
+	_ = trRepo.Create(ctx, &domain.TaskRun{ID: uuid.New(), WorkflowRunID: run.ID, TaskID: uuid.New(), Status: domain.StatusSuccess,
+		StartedAt: run.StartedAt, DispatchedAt: &run.StartedAt, FinishedAt: &finished})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/usage?from=2025-06-01&to=2025-06-07&tenant=data", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report service.UsageReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Tenants) != 1 || report.Tenants[0].Tenant != "data" || report.Tenants[0].ExecutionSeconds != 90 {
+		t.Errorf("report = %+v, want 90s for tenant data", report)
+	}
+
+	for query, want := range map[string]int{
+		"/usage?from=2025-06-01":               http.StatusBadRequest,
+		"/usage?from=2025-06-07&to=2025-06-01": http.StatusUnprocessableEntity,
+	} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, query, nil))
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", query, want, w.Code)
+		}
+	}
+}
+
+// TestExportRuns verifies GET /export/runs downloads the runs started in the
+// range as an attachment in the requested format.
+func TestExportRuns(t *testing.T) {
+	r, wfRepo, wrRepo, _, _ := newTestRouter()
+	ctx := context.Background()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "etl", CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(ctx, wf)
+	day := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	run := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusRunning, StartedAt: day.Add(3 * time.Hour),
+		Labels: map[string]string{"env": "prod"}}
+	_ = wrRepo.Create(ctx, run)
+	_ = wrRepo.Create(ctx, &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusSuccess, StartedAt: day.AddDate(0, 0, -1)})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export/runs?from=2025-06-02T00:00:00Z&to=2025-06-03T00:00:00Z", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="runs_2025-06-02_2025-06-03.csv"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	want := "workflow_id,workflow_name,run_id,status,started_at,finished_at,duration_seconds,labels\n" +
+		wf.ID.String() + ",etl," + run.ID.String() + `,running,2025-06-02T03:00:00Z,,,"{""env"":""prod""}"` + "\n"
+	if w.Body.String() != want {
+		t.Errorf("body:\n%s\nwant\n%s", w.Body.String(), want)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export/runs?format=parquet&table=task_runs&from=2025-06-02T00:00:00Z&to=2025-06-03T00:00:00Z", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/vnd.apache.parquet" ||
+		!strings.HasPrefix(w.Body.String(), "PAR1") || !strings.HasSuffix(w.Body.String(), "PAR1") {
+		t.Errorf("parquet export: got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	for query, want := range map[string]int{
+		"/export/runs?from=2025-06-02&to=2025-06-03":                                 http.StatusBadRequest,
+		"/export/runs?from=2025-06-02T00:00:00Z":                                     http.StatusUnprocessableEntity,
+		"/export/runs?format=xlsx&from=2025-06-02T00:00:00Z&to=2025-06-03T00:00:00Z": http.StatusUnprocessableEntity,
+	} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, query, nil))
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", query, want, w.Code)
+		}
+	}
+}
+
+// TestWorkflowDefinitions verifies a bundle exported by GET
+// /workflow-definitions can be planned into another deployment and applied
+// there by an admin, with the fingerprint of the reviewed plan.
+func TestWorkflowDefinitions(t *testing.T) {
+	staging, _, _, _, _ := newTestRouter()
+	prod, _, _, _, _ := newTestRouter()
+	w := httptest.NewRecorder()
+	staging.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/workflows",
+		strings.NewReader(`{"name":"etl","schedule_cron":"0 6 * * *","tasks":[{"name":"extract","command":"extract.sh"}]}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	staging.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/workflow-definitions", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: expected 200, got %d", w.Code)
+	}
+	bundle := w.Body.String()
+
+	w = httptest.NewRecorder()
+	prod.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/workflow-definitions/plan", strings.NewReader(bundle)))
+	var plan service.SyncPlan
+	if err := json.NewDecoder(w.Body).Decode(&plan); err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Workflows) != 1 || plan.Workflows[0].Action != service.SyncCreate || plan.Fingerprint == "" {
+		t.Fatalf("plan: got %+v, want etl created", plan)
+	}
+
+	apply := func(fingerprint string, roles string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/workflow-definitions/apply?fingerprint="+fingerprint, strings.NewReader(bundle))
+		req.Header.Set(handler.HeaderUser, "release")
+		req.Header.Set(handler.HeaderRoles, roles)
+		w := httptest.NewRecorder()
+		prod.ServeHTTP(w, req)
+		return w
+	}
+	if w := apply(plan.Fingerprint, "viewer"); w.Code != http.StatusForbidden {
+		t.Errorf("non-admin apply: expected 403, got %d", w.Code)
+	}
+	if w := apply("0000", handler.RoleAdmin); w.Code != http.StatusConflict {
+		t.Errorf("drifted apply: expected 409, got %d", w.Code)
+	}
+	if w := apply(plan.Fingerprint, handler.RoleAdmin); w.Code != http.StatusOK {
+		t.Fatalf("apply: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	prod.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/workflow-definitions/plan", strings.NewReader(`{"version":9}`)))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("unknown version: expected 422, got %d", w.Code)
+	}
+}
+
+// TestPurgeWorkflowRuns verifies DELETE /workflow-runs reports what it would
+// delete on a dry run, then deletes only finished runs before the cutoff.
+func TestPurgeWorkflowRuns(t *testing.T) {
+	r, _, wrRepo, trRepo, _ := newTestRouter()
+	ctx := context.Background()
+	old := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	wfID := uuid.New()
+	var oldRun uuid.UUID
+	for _, run := range []struct {
+		status  domain.Status
+		started time.Time
+	}{
+		{domain.StatusSuccess, old},
+		{domain.StatusRunning, old},
+		{domain.StatusFailed, time.Now()},
+	} {
+		wr := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wfID, Status: run.status, StartedAt: run.started}
+		_ = wrRepo.Create(ctx, wr)
+		_ = trRepo.Create(ctx, &domain.TaskRun{ID: uuid.New(), WorkflowRunID: wr.ID, TaskID: uuid.New(), Status: run.status, StartedAt: run.started})
+		if run.status == domain.StatusSuccess {
+			oldRun = wr.ID
+		}
+	}
+
+	purge := func(query, roles string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/workflow-runs?"+query, nil)
+		req.Header.Set(handler.HeaderUser, "ops")
+		req.Header.Set(handler.HeaderRoles, roles)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := purge("before=2025-06-01T00:00:00Z", "viewer"); w.Code != http.StatusForbidden {
+		t.Errorf("non-admin: expected 403, got %d", w.Code)
+	}
+	if w := purge("before=yesterday", "admin"); w.Code != http.StatusBadRequest {
+		t.Errorf("bad cutoff: expected 400, got %d", w.Code)
+	}
+	if w := purge("before=2025-06-01T00:00:00Z&status=running", "admin"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("running status: expected 422, got %d", w.Code)
+	}
+
+	w := purge("before=2025-06-01T00:00:00Z&dry_run=true", "admin")
+	if w.Code != http.StatusOK {
+		t.Fatalf("dry run: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var res service.PurgeResult
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.DryRun || res.WorkflowRuns != 1 || res.TaskRuns != 1 {
+		t.Errorf("dry run = %+v, want 1 run and 1 task run", res)
+	}
+	if _, err := wrRepo.GetByID(ctx, oldRun); err != nil {
+		t.Fatalf("dry run deleted the run: %v", err)
+	}
+
+	if w = purge("before=2025-06-01T00:00:00Z", "admin"); w.Code != http.StatusOK {
+		t.Fatalf("purge: expected 200, got %d", w.Code)
+	}
+	if _, err := wrRepo.GetByID(ctx, oldRun); err == nil {
+		t.Error("purged run still exists")
+	}
+	if runs, _ := wrRepo.ListByWorkflowID(ctx, wfID); len(runs) != 2 {
+		t.Errorf("%d runs left, want the running and the recent one", len(runs))
+	}
+}
+
+// TestDrainWorker verifies POST /workers/:id/drain publishes a drain command
+// for that worker on the event bus.
+func TestDrainWorker(t *testing.T) {
+	nodes := scheduler.NewMemWorkerRepo()
+	bus := events.NewMemBus()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithWorkerNodes(nodes, scheduler.NewMemTaskRepo(), scheduler.NewMemHeartbeatRepo()),
+		service.WithEvents(bus))
+	r := gin.New()
+	handler.New(svc, ws.NewHub()).RegisterRoutes(r)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_ = nodes.Save(ctx, &qdomain.Worker{ID: "w1", Status: qdomain.WorkerStatusIdle})
+	sub, _ := bus.Subscribe(ctx)
+
+	drain := func(id string) int {
+		req := httptest.NewRequest(http.MethodPost, "/workers/"+id+"/drain", nil)
+		req.Header.Set(handler.HeaderUser, "ops")
+		req.Header.Set(handler.HeaderRoles, "admin")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := drain("w1"); code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", code)
+	}
+	select {
+	case e := <-sub:
+		var cmd events.Command
+		if err := e.DecodePayload(&cmd); err != nil {
+			t.Fatal(err)
+		}
+		if e.Type != events.WorkerCommand || cmd.WorkerID != "w1" || cmd.Action != events.CommandDrain || cmd.By != "ops" {
+			t.Errorf("published %s %+v, want a drain command for w1 by ops", e.Type, cmd)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no command published")
+	}
+	if code := drain("missing"); code != http.StatusNotFound {
+		t.Errorf("unknown worker: expected 404, got %d", code)
+	}
+}
+
+// TestSetWorkerConcurrency verifies PUT /workers/:id/concurrency publishes a
+// set_concurrency command and rejects limits below 1.
+func TestSetWorkerConcurrency(t *testing.T) {
+	nodes := scheduler.NewMemWorkerRepo()
+	bus := events.NewMemBus()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithWorkerNodes(nodes, scheduler.NewMemTaskRepo(), scheduler.NewMemHeartbeatRepo()),
+		service.WithEvents(bus))
+	r := gin.New()
+	handler.New(svc, ws.NewHub()).RegisterRoutes(r)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_ = nodes.Save(ctx, &qdomain.Worker{ID: "w1", Status: qdomain.WorkerStatusIdle})
+	sub, _ := bus.Subscribe(ctx)
+
+	put := func(id, roles, body string) int {
+		req := httptest.NewRequest(http.MethodPut, "/workers/"+id+"/concurrency", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(handler.HeaderUser, "ops")
+		req.Header.Set(handler.HeaderRoles, roles)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := put("w1", "viewer", `{"concurrency": 4}`); code != http.StatusForbidden {
+		t.Errorf("non-admin: expected 403, got %d", code)
+	}
+	if code := put("w1", "admin", `{"concurrency": 4}`); code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", code)
+	}
+	select {
+	case e := <-sub:
+		var cmd events.Command
+		if err := e.DecodePayload(&cmd); err != nil {
+			t.Fatal(err)
+		}
+		if cmd.WorkerID != "w1" || cmd.Action != events.CommandSetConcurrency || cmd.Concurrency != 4 {
+			t.Errorf("published %+v, want set_concurrency 4 for w1", cmd)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no command published")
+	}
+	if code := put("w1", "admin", `{"concurrency": 0}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("zero concurrency: expected 422, got %d", code)
+	}
+	if code := put("missing", "admin", `{"concurrency": 2}`); code != http.StatusNotFound {
+		t.Errorf("unknown worker: expected 404, got %d", code)
+	}
+}
+
+// TestDeleteAndRestoreWorker verifies DELETE /workers/:id deactivates a
+// drained worker but refuses one in service, POST /workers/:id/restore
+// brings it back offline, and ?purge=true deletes the record.
+func TestDeleteAndRestoreWorker(t *testing.T) {
+	nodes := scheduler.NewMemWorkerRepo()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithWorkerNodes(nodes, scheduler.NewMemTaskRepo(), scheduler.NewMemHeartbeatRepo()))
+	r := gin.New()
+	handler.New(svc, ws.NewHub()).RegisterRoutes(r)
+	ctx := context.Background()
+	_ = nodes.Save(ctx, &qdomain.Worker{ID: "busy", Status: qdomain.WorkerStatusBusy})
+	_ = nodes.Save(ctx, &qdomain.Worker{ID: "w1", Status: qdomain.WorkerStatusDrained})
+
+	call := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(handler.HeaderUser, "ops")
+		req.Header.Set(handler.HeaderRoles, "admin")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := call(http.MethodDelete, "/workers/busy"); w.Code != http.StatusConflict {
+		t.Errorf("worker in service: expected 409, got %d", w.Code)
+	}
+	if w := call(http.MethodDelete, "/workers/w1"); w.Code != http.StatusNoContent {
+		t.Fatalf("deactivate: expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if wrk, _ := nodes.FindByID(ctx, "w1"); wrk.Status != qdomain.WorkerStatusDeactivated || wrk.DeactivatedAt == nil {
+		t.Errorf("after deactivate: %+v, want the record kept as deactivated", wrk)
+	}
+
+	w := call(http.MethodPost, "/workers/w1/restore")
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var d service.WorkerDetail
+	if err := json.NewDecoder(w.Body).Decode(&d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Status != qdomain.WorkerStatusOffline || d.DeactivatedAt != nil {
+		t.Errorf("restored worker = %+v, want offline", d)
+	}
+	if w := call(http.MethodPost, "/workers/w1/restore"); w.Code != http.StatusConflict {
+		t.Errorf("restore again: expected 409, got %d", w.Code)
+	}
+
+	if w := call(http.MethodDelete, "/workers/w1?purge=true"); w.Code != http.StatusNoContent {
+		t.Fatalf("purge: expected 204, got %d", w.Code)
+	}
+	if w := call(http.MethodDelete, "/workers/w1"); w.Code != http.StatusNotFound {
+		t.Errorf("purged worker: expected 404, got %d", w.Code)
+	}
+}
+
+// TestPutSecret verifies PUT /admin/secrets/:name stores the value and
+// announces the rotation without publishing the value.
+func TestPutSecret(t *testing.T) {
+	secrets := scheduler.NewMemSecretStore()
+	bus := events.NewMemBus()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithSecrets(secrets), service.WithEvents(bus))
+	r := gin.New()
+	handler.New(svc, ws.NewHub()).RegisterRoutes(r)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub, _ := bus.Subscribe(ctx)
+
+	put := func(roles, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/admin/secrets/db-password", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(handler.HeaderUser, "ops")
+		req.Header.Set(handler.HeaderRoles, roles)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := put("viewer", `{"value": "hunter2"}`); w.Code != http.StatusForbidden {
+		t.Errorf("non-admin: expected 403, got %d", w.Code)
+	}
+	if w := put("admin", `{"value": ""}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("empty value: expected 422, got %d", w.Code)
+	}
+	w := put("admin", `{"value": "hunter2"}`)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "hunter2") {
+		t.Error("response contains the secret value")
+	}
+	if v, _ := secrets.Get(ctx, "db-password"); v != "hunter2" {
+		t.Errorf("stored %q, want hunter2", v)
+	}
+	select {
+	case e := <-sub:
+		raw, _ := json.Marshal(e.Payload)
+		if e.Type != events.SecretRotated || !strings.Contains(string(raw), "db-password") || strings.Contains(string(raw), "hunter2") {
+			t.Errorf("published %s %s, want a rotation of db-password without its value", e.Type, raw)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no rotation published")
+	}
+}
+
+// TestDispatchFreeze verifies admins can freeze and unfreeze dispatch and
+// that the state is reported by GET /admin/dispatch.
+func TestDispatchFreeze(t *testing.T) {
+	freeze := scheduler.NewMemFreezeRepo()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithDispatchFreeze(freeze))
+	r := gin.New()
+	handler.New(svc, ws.NewHub()).RegisterRoutes(r)
+
+	post := func(path, roles, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(handler.HeaderUser, "ops")
+		req.Header.Set(handler.HeaderRoles, roles)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("/admin/dispatch/freeze", "approver", ""); w.Code != http.StatusForbidden {
+		t.Fatalf("non-admin freeze: expected 403, got %d", w.Code)
+	}
+	if w := post("/admin/dispatch/freeze", "admin", `{"reason":"warehouse down"}`); w.Code != http.StatusOK {
+		t.Fatalf("freeze: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if f, _ := freeze.Get(context.Background()); !f.Frozen || f.Reason != "warehouse down" || f.By != "ops" {
+		t.Errorf("stored switch = %+v", f)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/dispatch", nil))
+	var st service.DispatchState
+	if err := json.NewDecoder(w.Body).Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	if !st.Frozen || st.ChangedBy != "ops" || st.ChangedAt == nil {
+		t.Errorf("state = %+v, want frozen by ops", st)
+	}
+
+	if w := post("/admin/dispatch/unfreeze", "admin", ""); w.Code != http.StatusOK {
+		t.Fatalf("unfreeze: expected 200, got %d", w.Code)
+	}
+	if f, _ := freeze.Get(context.Background()); f.Frozen {
+		t.Error("switch still frozen after unfreeze")
+	}
+}
+
+func TestRebalanceQueue(t *testing.T) {
+	queues := scheduler.NewGroupQueues(scheduler.NewMemQueue(), nil)
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithQueues(queues))
+	r := gin.New()
+	handler.New(svc, ws.NewHub()).RegisterRoutes(r)
+	_ = queues.Enqueue(context.Background(), &qdomain.Task{ID: "t1", Name: "n", Priority: qdomain.PriorityNormal})
+
+	post := func(roles, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/queues/rebalance", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(handler.HeaderUser, "ops")
+		req.Header.Set(handler.HeaderRoles, roles)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("approver", `{"to":"gpu"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("non-admin: expected 403, got %d", w.Code)
+	}
+	if w := post("admin", `{"priority":11}`); w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "invalid_rebalance") {
+		t.Fatalf("bad priority: expected 422 invalid_rebalance, got %d: %s", w.Code, w.Body.String())
+	}
+	w := post("admin", `{"to":"gpu"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("rebalance: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var res service.RebalanceResult
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Moved != 1 || res.To != "gpu" || len(res.TaskIDs) != 1 || res.TaskIDs[0] != "t1" {
+		t.Errorf("result = %+v, want t1 moved to gpu", res)
+	}
+	q, _ := queues.Queue("gpu")
+	if n, _ := q.Len(context.Background()); n != 1 {
+		t.Errorf("gpu queue holds %d tasks, want 1", n)
+	}
+}
+
+// seedAwaitingApproval stores a task run parked on an approval gate.
+func seedAwaitingApproval(t *testing.T, trRepo *mock.TaskRunRepo) *domain.TaskRun {
+	t.Helper()
+	tr := &domain.TaskRun{
+		ID:            uuid.New(),
+		WorkflowRunID: uuid.New(),
+		TaskID:        uuid.New(),
+		Status:        domain.StatusAwaitingApproval,
+		Attempt:       1,
+		StartedAt:     time.Now().UTC(),
+	}
+	if err := trRepo.Create(context.Background(), tr); err != nil {
+		t.Fatal(err)
+	}
+	return tr
+}
+
+// TestDecideApproval_Approved verifies an approver can release a parked task
+// run and that the decision is recorded in the audit trail.
+func TestDecideApproval_Approved(t *testing.T) {
+	r, _, _, trRepo, _ := newTestRouter()
+	tr := seedAwaitingApproval(t, trRepo)
+
+	body := `{"decision":"approved","comment":"ship it"}`
+	req := httptest.NewRequest(http.MethodPost, "/task-runs/"+tr.ID.String()+"/approval", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handler.HeaderUser, "alice")
+	req.Header.Set(handler.HeaderRoles, "viewer, approver")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	got, _ := trRepo.GetByID(context.Background(), tr.ID)
+	if got.Status != domain.StatusSuccess {
+		t.Errorf("expected task run status success, got %q", got.Status)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/task-runs/"+tr.ID.String()+"/approvals", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var audit []domain.Approval
+	if err := json.NewDecoder(w.Body).Decode(&audit); err != nil {
+		t.Fatal(err)
+	}
+	if len(audit) != 1 || audit[0].Approver != "alice" {
+		t.Errorf("expected one audit entry by alice, got %+v", audit)
+	}
+}
+
+// TestDecideApproval_Forbidden verifies callers without the approver role
+// are rejected.
+func TestDecideApproval_Forbidden(t *testing.T) {
+	r, _, _, trRepo, _ := newTestRouter()
+	tr := seedAwaitingApproval(t, trRepo)
+
+	body := `{"decision":"approved"}`
+	req := httptest.NewRequest(http.MethodPost, "/task-runs/"+tr.ID.String()+"/approval", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handler.HeaderUser, "mallory")
+	req.Header.Set(handler.HeaderRoles, "viewer")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+// TestDecideApproval_NotAwaiting verifies a decision on a task run that is
+// not parked returns 409.
+func TestDecideApproval_NotAwaiting(t *testing.T) {
+	r, _, _, trRepo, _ := newTestRouter()
+	tr := seedAwaitingApproval(t, trRepo)
+	_ = trRepo.UpdateStatus(context.Background(), tr.ID, domain.StatusRunning, nil)
+
+	body := `{"decision":"rejected"}`
+	req := httptest.NewRequest(http.MethodPost, "/task-runs/"+tr.ID.String()+"/approval", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handler.HeaderUser, "alice")
+	req.Header.Set(handler.HeaderRoles, "admin")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+}
+
+// TestDecideApproval_ConcurrentDecisions verifies only one of two racing
+// decisions is taken and audited; the other gets 409.
+func TestDecideApproval_ConcurrentDecisions(t *testing.T) {
+	r, _, _, trRepo, _ := newTestRouter()
+	tr := seedAwaitingApproval(t, trRepo)
+
+	codes := make(chan int, 2)
+	var wg sync.WaitGroup
+	for _, decision := range []string{"approved", "rejected"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/task-runs/"+tr.ID.String()+"/approval",
+				bytes.NewBufferString(`{"decision":"`+decision+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(handler.HeaderUser, "alice")
+			req.Header.Set(handler.HeaderRoles, "admin")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			codes <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	got := map[int]int{}
+	for c := range codes {
+		got[c]++
+	}
+	if got[http.StatusCreated] != 1 || got[http.StatusConflict] != 1 {
+		t.Errorf("status codes: got %v, want one 201 and one 409", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/task-runs/"+tr.ID.String()+"/approvals", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var list []domain.Approval
+	_ = json.NewDecoder(w.Body).Decode(&list)
+	if len(list) != 1 {
+		t.Errorf("approvals: got %d, want 1", len(list))
+	}
+}
+
+// TestHealthz verifies GET /healthz returns 200 with status "ok".
+func TestHealthz(t *testing.T) {
+	r, _, _, _, _ := newTestRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["status"] != "ok" {
+		t.Errorf("expected status 'ok', got %q", body["status"])
+	}
+	if body["service"] != "task-scheduler-api" {
+		t.Errorf("expected service 'task-scheduler-api', got %q", body["service"])
+	}
+}
+
+// TestHealthz_DegradedWhileAlertFires verifies GET /healthz reports the
+// backpressure alerts the scheduler publishes on the event bus.
+func TestHealthz_DegradedWhileAlertFires(t *testing.T) {
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo())
+	r := gin.New()
+	handler.New(svc, ws.NewHub()).RegisterRoutes(r)
+
+	health := func() service.Health {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		var h service.Health
+		if err := json.NewDecoder(w.Body).Decode(&h); err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	svc.Alerts().Record(events.AlertUpdate{Name: "queue_depth", Firing: true, Value: 120, Threshold: 100})
+	if h := health(); h.Status != "degraded" || !h.Degraded || len(h.Alerts) != 1 || h.Alerts[0].Name != "queue_depth" {
+		t.Errorf("firing: got %+v, want degraded with the queue_depth alert", h)
+	}
+	svc.Alerts().Record(events.AlertUpdate{Name: "queue_depth"})
+	if h := health(); h.Status != "ok" || h.Degraded {
+		t.Errorf("resolved: got %+v, want ok", h)
+	}
+}
+
+// TestRateLimitPerAPIKey verifies requests over an API key's trigger limit
+// get 429 with Retry-After, other keys are unaffected, and admins can read
+// each key's usage.
+func TestRateLimitPerAPIKey(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	limiter := ratelimit.New(map[string]ratelimit.Limit{"noisy": {TriggersPerMinute: 1}})
+	svc := service.New(wfRepo, mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithRateLimiter(limiter))
+	r := gin.New()
+	handler.New(svc, ws.NewHub()).RegisterRoutes(r)
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(context.Background(), wf)
+
+	trigger := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/workflows/"+wf.ID.String()+"/trigger", nil)
+		req.Header.Set(handler.HeaderAPIKey, key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := trigger("noisy"); w.Code != http.StatusCreated {
+		t.Fatalf("first trigger: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	w := trigger("noisy")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("second trigger: got %d (Retry-After %q), want 429 after 60s", w.Code, w.Header().Get("Retry-After"))
+	}
+	var resp handler.ErrorResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Error.Code != handler.CodeRateLimited {
+		t.Errorf("code: got %q, want %q", resp.Error.Code, handler.CodeRateLimited)
+	}
+	if w := trigger("other"); w.Code != http.StatusCreated {
+		t.Errorf("other key: expected 201, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/api-keys/usage", nil)
+	req.Header.Set(handler.HeaderUser, "ops")
+	req.Header.Set(handler.HeaderRoles, handler.RoleAdmin)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var usage []ratelimit.Usage
+	if err := json.NewDecoder(w.Body).Decode(&usage); err != nil {
+		t.Fatal(err)
+	}
+	byKey := map[string]ratelimit.Usage{}
+	for _, u := range usage {
+		byKey[u.KeyID] = u
+	}
+	if u := byKey[ratelimit.KeyID("noisy")]; u.Triggers != 1 || u.Throttled != 1 {
+		t.Errorf("noisy usage: got %+v, want 1 trigger and 1 throttled", u)
+	}
+	if u := byKey[ratelimit.KeyID("other")]; u.Triggers != 1 || u.Throttled != 0 {
+		t.Errorf("other usage: got %+v, want 1 trigger", u)
+	}
+}
+
+// TestReadOnlyRejectsWrites verifies a read-only API serves reads and the
+// routes that only compute an answer, and refuses everything else.
+func TestReadOnlyRejectsWrites(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	svc := service.New(wfRepo, mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithReadOnly())
+	r := gin.New()
+	handler.New(svc, ws.NewHub()).RegisterRoutes(r)
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(context.Background(), wf)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	for _, path := range []string{"/workflows", "/workflow-runs", "/healthz"} {
+		if w := do(http.MethodGet, path, ""); w.Code != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", path, w.Code)
+		}
+	}
+	// Without task definitions configured the simulation itself fails, but
+	// it gets past the read-only check.
+	if w := do(http.MethodPost, "/workflows/"+wf.ID.String()+"/simulate", "{}"); w.Code == http.StatusForbidden {
+		t.Errorf("simulate: refused as a write: %s", w.Body.String())
+	}
+	for _, req := range []struct{ method, path string }{
+		{http.MethodPost, "/workflows"},
+		{http.MethodPost, "/workflows/" + wf.ID.String() + "/trigger"},
+		{http.MethodPut, "/workflows/" + wf.ID.String() + "/run-timeout"},
+		{http.MethodPut, "/workflows/" + wf.ID.String() + "/priority"},
+		{http.MethodDelete, "/workflow-runs"},
+	} {
+		w := do(req.method, req.path, `{"name":"new"}`)
+		var resp handler.ErrorResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		if w.Code != http.StatusForbidden || resp.Error.Code != handler.CodeReadOnly {
+			t.Errorf("%s %s: got %d %q, want 403 %q", req.method, req.path, w.Code, resp.Error.Code, handler.CodeReadOnly)
+		}
+	}
+	if list, _ := wfRepo.List(context.Background()); len(list) != 1 {
+		t.Errorf("workflows: got %d, want the one created before", len(list))
+	}
+
+	var health service.Health
+	_ = json.NewDecoder(do(http.MethodGet, "/healthz", "").Body).Decode(&health)
+	if !health.ReadOnly {
+		t.Error("healthz: read_only not reported")
+	}
+}
+
+// TestEventHistory verifies GET /events/history pages through the events
+// published on a recording bus, oldest first, filtered by type.
+func TestEventHistory(t *testing.T) {
+	history := mock.NewEventRepo()
+	bus, err := events.OpenHistory(events.NewMemBus(), history, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithEvents(bus), service.WithEventHistory(history))
+	r := gin.New()
+	handler.New(svc, ws.NewHub()).RegisterRoutes(r)
+	ctx := context.Background()
+	_ = bus.Publish(ctx, events.Event{Type: events.TaskStatus, Payload: events.TaskUpdate{TaskID: "t1"}})
+	_ = bus.Publish(ctx, events.Event{Type: events.Alert, Payload: events.AlertUpdate{Name: "queue_depth"}})
+	_ = bus.Publish(ctx, events.Event{Type: events.TaskStatus, Payload: events.TaskUpdate{TaskID: "t2"}})
+
+	get := func(query string) (*httptest.ResponseRecorder, service.EventHistoryPage) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events/history?"+query, nil))
+		var page service.EventHistoryPage
+		if w.Code == http.StatusOK {
+			_ = json.NewDecoder(w.Body).Decode(&page)
+		}
+		return w, page
+	}
+	var seen []string
+	cursor := ""
+	for pages := 0; pages < 3; pages++ {
+		w, page := get("type=task_status&limit=1&cursor=" + cursor)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		for _, e := range page.Events {
+			var u events.TaskUpdate
+			_ = json.Unmarshal(e.Payload, &u)
+			seen = append(seen, u.TaskID)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if strings.Join(seen, ",") != "t1,t2" {
+		t.Errorf("paged task events = %v, want [t1 t2]", seen)
+	}
+
+	if _, page := get(""); len(page.Events) != 3 || page.NextCursor != "" {
+		t.Errorf("unfiltered page = %d events, next %q; want all 3 and no cursor", len(page.Events), page.NextCursor)
+	}
+	if w, _ := get("cursor=abc"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("malformed cursor: expected 422, got %d", w.Code)
+	}
+	if w, _ := get("limit=5000"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("limit over the maximum: expected 422, got %d", w.Code)
+	}
+}
+
+// TestParamValidation verifies malformed enum, UUID and integer parameters
+// are rejected with 422 and one field error each, rather than ignored.
+func TestParamValidation(t *testing.T) {
+	r, wfRepo, _, _, _ := newTestRouter()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", IsActive: true, CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(context.Background(), wf)
+
+	for path, fields := range map[string][]string{
+		"/workflow-runs?status=bananas":                             {"status"},
+		"/task-runs?status=bananas":                                 {"status"},
+		"/workflows?limit=0":                                        {"limit"},
+		"/workflows?offset=-1&limit=5000":                           {"offset", "limit"},
+		"/workflows/" + wf.ID.String() + "/runs?status=x&limit=ten": {"status", "limit"},
+		"/workflows/nope/runs?status=bananas":                       {"id", "status"},
+		"/workflows/" + wf.ID.String() + "/next-runs?count=1000":    {"count"},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d", path, w.Code)
+			continue
+		}
+		var body struct {
+			Error struct {
+				Code    string `json:"code"`
+				Details struct {
+					Fields []handler.FieldError `json:"fields"`
+				} `json:"details"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(w.Body).Decode(&body)
+		var got []string
+		for _, f := range body.Error.Details.Fields {
+			got = append(got, f.Field)
+		}
+		if body.Error.Code != handler.CodeInvalidParams || strings.Join(got, ",") != strings.Join(fields, ",") {
+			t.Errorf("%s: got %s %v, want %s %v", path, body.Error.Code, got, handler.CodeInvalidParams, fields)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/workflow-runs?status=failed", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("valid status: expected 200, got %d", w.Code)
 	}
 }
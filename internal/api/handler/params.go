@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// CodeInvalidParams is the code of the 422 response for path or query
+// parameters that do not hold a valid value; its details list each one as
+// a FieldError.
+const CodeInvalidParams = "invalid_params"
+
+// DefaultPageLimit and MaxPageLimit are the default and largest ?limit= of
+// the offset-paginated listings.
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 1000
+)
+
+// FieldError describes one invalid request parameter. In is "path" or
+// "query".
+type FieldError struct {
+	Field   string `json:"field"`
+	In      string `json:"in"`
+	Message string `json:"message"`
+}
+
+// requestParams reads and validates the path and query parameters of a request,
+// collecting a FieldError for each invalid one instead of failing on the
+// first, so a client learns about every bad parameter at once. Accessors
+// return the zero value, or the default, for an invalid parameter;
+// handlers call valid before using any of them.
+type requestParams struct {
+	c    *gin.Context
+	errs []FieldError
+}
+
+func newParams(c *gin.Context) *requestParams {
+	return &requestParams{c: c}
+}
+
+func (p *requestParams) fail(field, in, format string, args ...any) {
+	p.errs = append(p.errs, FieldError{Field: field, In: in, Message: fmt.Sprintf(format, args...)})
+}
+
+// uuid returns the path parameter name as a UUID.
+func (p *requestParams) uuid(name string) uuid.UUID {
+	id, err := uuid.Parse(p.c.Param(name))
+	if err != nil {
+		p.fail(name, "path", "must be a UUID")
+	}
+	return id
+}
+
+// status returns the query parameter name as a run status, or "" if it is
+// absent.
+func (p *requestParams) status(name string) domain.Status {
+	s := domain.Status(p.c.Query(name))
+	if !s.Valid() {
+		p.fail(name, "query", "must be one of %s", strings.Join(statusNames(), ", "))
+		return ""
+	}
+	return s
+}
+
+// intRange returns the query parameter name as an integer in [min, max],
+// or def if it is absent.
+func (p *requestParams) intRange(name string, def, min, max int) int {
+	v, ok := p.c.GetQuery(name)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min || n > max {
+		p.fail(name, "query", "must be an integer between %d and %d", min, max)
+		return def
+	}
+	return n
+}
+
+// bool returns the query parameter name as a boolean, or def if it is
+// absent.
+func (p *requestParams) bool(name string, def bool) bool {
+	v, ok := p.c.GetQuery(name)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		p.fail(name, "query", "must be true or false")
+		return def
+	}
+	return b
+}
+
+// page returns the ?offset= and ?limit= of an offset-paginated listing.
+func (p *requestParams) page() (offset, limit int) {
+	return p.intRange("offset", 0, 0, math.MaxInt), p.intRange("limit", DefaultPageLimit, 1, MaxPageLimit)
+}
+
+// valid reports whether every parameter read so far was valid. If not, it
+// writes the 422 CodeInvalidParams response listing them.
+func (p *requestParams) valid() bool {
+	if len(p.errs) == 0 {
+		return true
+	}
+	fields := make([]string, len(p.errs))
+	for i, e := range p.errs {
+		fields[i] = e.Field + ": " + e.Message
+	}
+	writeError(p.c, &APIError{
+		Code:    CodeInvalidParams,
+		Message: "invalid parameters: " + strings.Join(fields, "; "),
+		Details: gin.H{"fields": p.errs},
+		status:  http.StatusUnprocessableEntity,
+	})
+	return false
+}
+
+func statusNames() []string {
+	names := make([]string, len(domain.Statuses))
+	for i, s := range domain.Statuses {
+		names[i] = string(s)
+	}
+	return names
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/ratelimit"
+)
+
+// HeaderAPIKey carries the caller's API key. Requests without one are
+// neither limited nor accounted.
+const HeaderAPIKey = "X-API-Key"
+
+// CodeRateLimited is the error code of a request refused because its API
+// key is over one of its limits.
+const CodeRateLimited = "rate_limited"
+
+// triggerRoutes are the routes that count against an API key's trigger
+// limit as well as its request limit.
+var triggerRoutes = map[string]bool{
+	"/workflows/:id/trigger": true,
+	"/events/:name":          true,
+}
+
+// limitRate returns middleware that admits each request through the
+// service's rate limiter, answering 429 with a Retry-After header when the
+// caller's API key is over its limit. It does nothing without a limiter.
+func (h *Handler) limitRate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := h.svc.RateLimiter()
+		key := c.GetHeader(HeaderAPIKey)
+		if l == nil || key == "" {
+			c.Next()
+			return
+		}
+		kind := ratelimit.Request
+		if triggerRoutes[c.FullPath()] {
+			kind = ratelimit.Trigger
+		}
+		if ok, wait := l.Allow(key, kind); !ok {
+			secs := int(math.Ceil(wait.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(secs))
+			writeError(c, &APIError{
+				Code:    CodeRateLimited,
+				Message: "rate limit exceeded for API key",
+				Details: gin.H{"retry_after_seconds": secs},
+				status:  http.StatusTooManyRequests,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// apiKeyUsage handles GET /admin/api-keys/usage.
+func (h *Handler) apiKeyUsage(c *gin.Context) {
+	usage, err := h.svc.APIKeyUsage()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Identity headers are expected to be set by the authenticating reverse proxy
+// in front of the API; the API itself performs no authentication.
+const (
+	// HeaderUser carries the authenticated principal's name.
+	HeaderUser = "X-User"
+	// HeaderRoles carries the principal's roles as a comma-separated list.
+	HeaderRoles = "X-User-Roles"
+)
+
+// Roles recognised by requireRole. RoleAdmin satisfies every role check.
+const (
+	RoleAdmin    = "admin"
+	RoleApprover = "approver"
+)
+
+// ctxUserKey is the gin context key under which requireRole stores the caller.
+const ctxUserKey = "user"
+
+// requireRole returns middleware that rejects requests without an identity
+// (401) or without the given role (403). The caller's name is made available
+// to downstream handlers via currentUser.
+func requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := strings.TrimSpace(c.GetHeader(HeaderUser))
+		if user == "" {
+			writeError(c, &APIError{Code: CodeUnauthenticated, Message: "missing " + HeaderUser + " header", status: http.StatusUnauthorized})
+			c.Abort()
+			return
+		}
+		if !hasRole(c.GetHeader(HeaderRoles), role) {
+			writeError(c, &APIError{Code: CodeForbidden, Message: "role " + role + " required", status: http.StatusForbidden})
+			c.Abort()
+			return
+		}
+		c.Set(ctxUserKey, user)
+		c.Next()
+	}
+}
+
+// currentUser returns the principal stored by requireRole. On routes open
+// to anonymous callers it falls back to HeaderUser, which may be empty.
+func currentUser(c *gin.Context) string {
+	if user := c.GetString(ctxUserKey); user != "" {
+		return user
+	}
+	return strings.TrimSpace(c.GetHeader(HeaderUser))
+}
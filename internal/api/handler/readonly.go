@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CodeReadOnly is the error code of a request refused because the API is
+// serving a read-only copy of the data.
+const CodeReadOnly = "read_only"
+
+// readOnlyRoutes are the routes that only read despite their method: they
+// compute an answer from the request body without storing anything.
+var readOnlyRoutes = map[string]bool{
+	"/workflows/:id/simulate":    true,
+	"/workflow-definitions/plan": true,
+}
+
+// rejectWrites returns middleware that, when the service is read-only,
+// answers 403 to every request other than a GET, HEAD or OPTIONS, bar the
+// readOnlyRoutes. It does nothing otherwise.
+func (h *Handler) rejectWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		// Unknown routes are left to answer 404.
+		if !h.svc.ReadOnly() || c.FullPath() == "" || readOnlyRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+		writeError(c, &APIError{Code: CodeReadOnly, Message: "the API is read-only", status: http.StatusForbidden})
+		c.Abort()
+	}
+}
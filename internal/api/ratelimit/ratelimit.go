@@ -0,0 +1,241 @@
+// Package ratelimit throttles API callers per API key and accounts for what
+// each key has used, so one misbehaving integration can be slowed down
+// without limiting everyone else.
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+)
+
+// DefaultKey names the Limit applied to keys without one of their own in
+// ParseLimits input.
+const DefaultKey = "*"
+
+// MaxKeys bounds the number of keys tracked individually. Further keys
+// share one OverflowKeyID entry, so callers inventing keys cannot grow the
+// limiter without bound.
+const MaxKeys = 10000
+
+// OverflowKeyID is the KeyID usage beyond MaxKeys is accounted under.
+const OverflowKeyID = "overflow"
+
+// Kind is the kind of request being admitted.
+type Kind int
+
+const (
+	// Request is any API request.
+	Request Kind = iota
+	// Trigger is a request that starts a workflow run. It counts against
+	// both the request and the trigger limit.
+	Trigger
+)
+
+// Limit caps one key's rates. Zero fields do not limit.
+type Limit struct {
+	// RequestsPerMinute caps all requests.
+	RequestsPerMinute int `json:"requests_per_minute"`
+	// TriggersPerMinute caps the requests that start workflow runs.
+	TriggersPerMinute int `json:"triggers_per_minute"`
+}
+
+// Usage is what one key has used since the limiter started.
+type Usage struct {
+	KeyID     string    `json:"key_id"`
+	Limit     Limit     `json:"limit"`
+	Requests  int64     `json:"requests"`
+	Triggers  int64     `json:"triggers"`
+	Throttled int64     `json:"throttled"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Limiter admits requests per API key with a token bucket per key and
+// limit: a bucket holds up to a minute's allowance and refills at the
+// per-minute rate, so short bursts are allowed. It is safe for concurrent
+// use. State lives in memory, so every API replica limits on its own.
+type Limiter struct {
+	clock  clock.Clock
+	limits map[string]Limit
+	def    Limit
+
+	mu   sync.Mutex
+	keys map[string]*keyState // by KeyID
+}
+
+type keyState struct {
+	usage    Usage
+	requests bucket
+	triggers bucket
+}
+
+// Option is a functional option for configuring a Limiter.
+type Option func(*Limiter)
+
+// WithClock sets the clock buckets refill by. It defaults to clock.Real.
+func WithClock(c clock.Clock) Option {
+	return func(l *Limiter) { l.clock = c }
+}
+
+// New returns a Limiter applying limits, keyed by API key; the DefaultKey
+// entry, if any, applies to every other key.
+func New(limits map[string]Limit, opts ...Option) *Limiter {
+	l := &Limiter{
+		clock:  clock.Real,
+		limits: make(map[string]Limit, len(limits)),
+		def:    limits[DefaultKey],
+		keys:   make(map[string]*keyState),
+	}
+	for key, lim := range limits {
+		if key != DefaultKey {
+			l.limits[KeyID(key)] = lim
+		}
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// KeyID identifies key in usage reports without revealing it: the first 12
+// hex digits of its SHA-256.
+func KeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// Allow records a request of kind made with key and reports whether it is
+// within key's limits. When it is not, retryAfter is how long until it
+// would be.
+func (l *Limiter) Allow(key string, kind Kind) (ok bool, retryAfter time.Duration) {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	st := l.state(KeyID(key))
+	st.usage.LastSeen = now
+	lim := st.usage.Limit
+	if ok, retryAfter = st.requests.peek(lim.RequestsPerMinute, now); ok && kind == Trigger {
+		ok, retryAfter = st.triggers.peek(lim.TriggersPerMinute, now)
+	}
+	if !ok {
+		st.usage.Throttled++
+		return false, retryAfter
+	}
+	st.requests.take(lim.RequestsPerMinute)
+	st.usage.Requests++
+	if kind == Trigger {
+		st.triggers.take(lim.TriggersPerMinute)
+		st.usage.Triggers++
+	}
+	return true, 0
+}
+
+// Usage returns the usage of every key seen so far, ordered by KeyID.
+func (l *Limiter) Usage() []Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]Usage, 0, len(l.keys))
+	for _, st := range l.keys {
+		out = append(out, st.usage)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].KeyID < out[j].KeyID })
+	return out
+}
+
+// state returns the state of id, creating it on first use. l.mu is held.
+func (l *Limiter) state(id string) *keyState {
+	if st, ok := l.keys[id]; ok {
+		return st
+	}
+	lim, ok := l.limits[id]
+	if !ok {
+		lim = l.def
+	}
+	if len(l.keys) >= MaxKeys {
+		if _, own := l.limits[id]; !own {
+			id, lim = OverflowKeyID, l.def
+			if st, ok := l.keys[id]; ok {
+				return st
+			}
+		}
+	}
+	st := &keyState{usage: Usage{KeyID: id, Limit: lim}}
+	l.keys[id] = st
+	return st
+}
+
+// bucket is a token bucket holding up to perMinute tokens that refills at
+// perMinute tokens a minute. A new bucket starts full.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	started bool
+}
+
+// peek refills b up to now and reports whether a token is available, or
+// how long until one is. A perMinute of zero or less never limits.
+func (b *bucket) peek(perMinute int, now time.Time) (bool, time.Duration) {
+	if perMinute <= 0 {
+		return true, 0
+	}
+	capacity := float64(perMinute)
+	if !b.started {
+		b.tokens, b.updated, b.started = capacity, now, true
+	}
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed.Minutes()*capacity)
+		b.updated = now
+	}
+	if b.tokens >= 1 {
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / capacity * float64(time.Minute))
+	return false, wait
+}
+
+// take removes a token peek reported available.
+func (b *bucket) take(perMinute int) {
+	if perMinute > 0 {
+		b.tokens--
+	}
+}
+
+// ParseLimits parses a comma-separated list of key=requests[/triggers]
+// entries, both per minute, e.g. "ci-token=120/10,*=600". The key "*"
+// (DefaultKey) sets the limit of every unlisted key. An empty string
+// returns no limits.
+func ParseLimits(s string) (map[string]Limit, error) {
+	out := make(map[string]Limit)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, rates, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("rate limit %q: must be key=requests[/triggers]", entry)
+		}
+		reqs, trigs, hasTrigs := strings.Cut(rates, "/")
+		var lim Limit
+		var err error
+		if lim.RequestsPerMinute, err = strconv.Atoi(reqs); err != nil || lim.RequestsPerMinute < 0 {
+			return nil, fmt.Errorf("rate limit %q: invalid requests per minute %q", entry, reqs)
+		}
+		if hasTrigs {
+			if lim.TriggersPerMinute, err = strconv.Atoi(trigs); err != nil || lim.TriggersPerMinute < 0 {
+				return nil, fmt.Errorf("rate limit %q: invalid triggers per minute %q", entry, trigs)
+			}
+		}
+		out[key] = lim
+	}
+	return out, nil
+}
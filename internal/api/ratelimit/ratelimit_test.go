@@ -0,0 +1,91 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/ratelimit"
+)
+
+func TestLimiter_ThrottlesPerKey(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := ratelimit.New(map[string]ratelimit.Limit{
+		"noisy": {RequestsPerMinute: 2},
+	}, ratelimit.WithClock(clk))
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("noisy", ratelimit.Request); !ok {
+			t.Fatalf("request %d refused within the burst", i)
+		}
+	}
+	ok, wait := l.Allow("noisy", ratelimit.Request)
+	if ok || wait != 30*time.Second {
+		t.Fatalf("third request: got ok=%v wait=%v, want refused for 30s", ok, wait)
+	}
+	for i := 0; i < 10; i++ {
+		if ok, _ := l.Allow("quiet", ratelimit.Request); !ok {
+			t.Fatal("a key without a limit was throttled")
+		}
+	}
+
+	clk.Advance(30 * time.Second)
+	if ok, _ := l.Allow("noisy", ratelimit.Request); !ok {
+		t.Error("request refused after the bucket refilled")
+	}
+}
+
+func TestLimiter_TriggerLimitAndUsage(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := ratelimit.New(map[string]ratelimit.Limit{
+		ratelimit.DefaultKey: {RequestsPerMinute: 100, TriggersPerMinute: 1},
+	}, ratelimit.WithClock(clk))
+
+	if ok, _ := l.Allow("ci", ratelimit.Trigger); !ok {
+		t.Fatal("first trigger refused")
+	}
+	if ok, _ := l.Allow("ci", ratelimit.Trigger); ok {
+		t.Fatal("second trigger within a minute admitted")
+	}
+	if ok, _ := l.Allow("ci", ratelimit.Request); !ok {
+		t.Fatal("plain request refused while only the trigger limit is exhausted")
+	}
+
+	usage := l.Usage()
+	if len(usage) != 1 {
+		t.Fatalf("usage: got %d keys, want 1", len(usage))
+	}
+	want := ratelimit.Usage{
+		KeyID:     ratelimit.KeyID("ci"),
+		Limit:     ratelimit.Limit{RequestsPerMinute: 100, TriggersPerMinute: 1},
+		Requests:  2,
+		Triggers:  1,
+		Throttled: 1,
+		LastSeen:  clk.Now(),
+	}
+	if usage[0] != want {
+		t.Errorf("usage: got %+v, want %+v", usage[0], want)
+	}
+	if usage[0].KeyID == "ci" {
+		t.Error("usage reveals the API key")
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	got, err := ratelimit.ParseLimits(" ci-token=120/10, *=600 ")
+	if err != nil {
+		t.Fatalf("ParseLimits: %v", err)
+	}
+	if got["ci-token"] != (ratelimit.Limit{RequestsPerMinute: 120, TriggersPerMinute: 10}) ||
+		got[ratelimit.DefaultKey] != (ratelimit.Limit{RequestsPerMinute: 600}) || len(got) != 2 {
+		t.Errorf("got %+v", got)
+	}
+	if got, err := ratelimit.ParseLimits(""); err != nil || len(got) != 0 {
+		t.Errorf("empty: got %v, %v", got, err)
+	}
+	for _, bad := range []string{"ci-token", "=5", "ci=fast", "ci=5/-1"} {
+		if _, err := ratelimit.ParseLimits(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
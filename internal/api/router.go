@@ -3,6 +3,9 @@
 package api
 
 import (
+	"context"
+	"log"
+
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sauravritesh63/GoLang-Project-/internal/api/handler"
@@ -19,17 +22,49 @@ func NewRouter(
 	workflowRuns repository.WorkflowRunRepository,
 	taskRuns repository.TaskRunRepository,
 	workers repository.WorkerRepository,
+	opts ...service.Option,
 ) *gin.Engine {
-	svc := service.New(workflows, workflowRuns, taskRuns, workers)
+	r, _ := newRouter(workflows, workflowRuns, taskRuns, workers, opts...)
+	return r
+}
+
+// newRouter builds the router of NewRouter, also returning the WebSocket
+// hub its clients connect to.
+func newRouter(
+	workflows repository.WorkflowRepository,
+	workflowRuns repository.WorkflowRunRepository,
+	taskRuns repository.TaskRunRepository,
+	workers repository.WorkerRepository,
+	opts ...service.Option,
+) (*gin.Engine, *ws.Hub) {
+	svc := service.New(workflows, workflowRuns, taskRuns, workers, opts...)
 	hub := ws.NewHub()
 	h := handler.New(svc, hub)
 
+	// Relay run, task and worker events published by the scheduler and
+	// workers to WebSocket clients for the lifetime of the process, and
+	// track the scheduler's backpressure alerts for /healthz.
+	if bus := svc.Events(); bus != nil {
+		sub, err := bus.Subscribe(context.Background())
+		if err != nil {
+			log.Printf("event bus: subscribe: %v", err)
+		} else {
+			go hub.Relay(sub)
+		}
+		alerts, err := bus.Subscribe(context.Background())
+		if err != nil {
+			log.Printf("event bus: subscribe: %v", err)
+		} else {
+			go svc.Alerts().Watch(alerts)
+		}
+	}
+
 	r := gin.New()
-	r.Use(gin.Recovery())
+	r.Use(gin.Recovery(), handler.RequestID())
 	h.RegisterRoutes(r)
 
 	// Expose Prometheus metrics at /metrics.
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
-	return r
+	return r, hub
 }
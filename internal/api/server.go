@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/service"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// Defaults of ServerConfig's zero fields.
+const (
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultIdleTimeout       = 2 * time.Minute
+	DefaultShutdownTimeout   = 25 * time.Second
+)
+
+// ServerConfig configures the API's HTTP server.
+type ServerConfig struct {
+	// Addr is the address to listen on, e.g. ":8080".
+	Addr string
+	// ReadHeaderTimeout and ReadTimeout bound reading a request's headers
+	// and the whole request; IdleTimeout bounds how long a keep-alive
+	// connection waits for the next one.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	IdleTimeout       time.Duration
+	// WriteTimeout bounds writing a response. It is off by default, as
+	// exports and following logs can legitimately take longer than any
+	// fixed limit.
+	WriteTimeout time.Duration
+	// ShutdownTimeout bounds how long Run waits for in-flight requests once
+	// its context is done.
+	ShutdownTimeout time.Duration
+	// TLSCertFile and TLSKeyFile, when both set, serve HTTPS.
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// Server is the API's HTTP server. Unlike serving the router directly, it
+// shuts down gracefully: in-flight requests finish and WebSocket clients
+// receive a close frame before the process exits.
+type Server struct {
+	srv             *http.Server
+	cfg             ServerConfig
+	shutdownTimeout time.Duration
+}
+
+// NewServer builds the router as NewRouter does and a Server for it.
+func NewServer(
+	cfg ServerConfig,
+	workflows repository.WorkflowRepository,
+	workflowRuns repository.WorkflowRunRepository,
+	taskRuns repository.TaskRunRepository,
+	workers repository.WorkerRepository,
+	opts ...service.Option,
+) (*Server, error) {
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, errors.New("api: TLS needs both a certificate and a key file")
+	}
+	r, hub := newRouter(workflows, workflowRuns, taskRuns, workers, opts...)
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: orDefault(cfg.ReadHeaderTimeout, DefaultReadHeaderTimeout),
+		ReadTimeout:       orDefault(cfg.ReadTimeout, DefaultReadTimeout),
+		IdleTimeout:       orDefault(cfg.IdleTimeout, DefaultIdleTimeout),
+		WriteTimeout:      cfg.WriteTimeout,
+	}
+	srv.RegisterOnShutdown(hub.Close)
+	return &Server{srv: srv, cfg: cfg, shutdownTimeout: orDefault(cfg.ShutdownTimeout, DefaultShutdownTimeout)}, nil
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+	return d
+}
+
+// Handler returns the router the server serves.
+func (s *Server) Handler() http.Handler { return s.srv.Handler }
+
+// Run listens on the configured address and serves until ctx is done; see
+// Serve.
+func (s *Server) Run(ctx context.Context) error {
+	addr := s.srv.Addr
+	switch {
+	case addr != "":
+	case s.cfg.TLSCertFile != "":
+		addr = ":https"
+	default:
+		addr = ":http"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves connections on ln until ctx is done, then stops accepting
+// connections, closes WebSocket clients and waits up to ShutdownTimeout for
+// in-flight requests. It returns nil after a clean shutdown.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	errc := make(chan error, 1)
+	go func() {
+		if s.cfg.TLSCertFile != "" {
+			errc <- s.srv.ServeTLS(ln, s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+		} else {
+			errc <- s.srv.Serve(ln)
+		}
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	sctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := s.srv.Shutdown(sctx); err != nil {
+		_ = s.srv.Close()
+		return fmt.Errorf("api: shutdown: %w", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
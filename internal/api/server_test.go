@@ -0,0 +1,88 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sauravritesh63/GoLang-Project-/internal/api"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+)
+
+func newServer(t *testing.T) (*api.Server, string, context.CancelFunc, <-chan error) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	srv, err := api.NewServer(api.ServerConfig{ShutdownTimeout: 5 * time.Second},
+		mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx, ln) }()
+	return srv, ln.Addr().String(), cancel, done
+}
+
+// TestServer_DrainsInFlightRequests verifies a request in progress when the
+// server is told to stop still completes, and the WebSocket clients get a
+// going-away close frame.
+func TestServer_DrainsInFlightRequests(t *testing.T) {
+	srv, addr, stop, done := newServer(t)
+	started := make(chan struct{})
+	srv.Handler().(*gin.Engine).GET("/slow", func(c *gin.Context) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/ws/updates", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	stop()
+
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request: got %q, want done", got)
+	}
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("WebSocket: got %v, want a going-away close", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+	if _, err := http.Get("http://" + addr + "/healthz"); err == nil {
+		t.Error("server still accepts requests after shutdown")
+	}
+}
+
+func TestNewServer_RejectsHalfTLSConfig(t *testing.T) {
+	_, err := api.NewServer(api.ServerConfig{TLSCertFile: "cert.pem"},
+		mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo())
+	if err == nil || !strings.Contains(err.Error(), "TLS") {
+		t.Errorf("got %v, want a TLS configuration error", err)
+	}
+}
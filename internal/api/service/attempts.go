@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// WithAttempts sets the repository workers started with
+// worker.WithAttemptLog record their attempts in. Without it, TaskAttempts
+// reports a single attempt per task run, as retries overwrite it.
+func WithAttempts(r qdomain.AttemptRepository) Option {
+	return func(s *Service) { s.attempts = r }
+}
+
+// TaskAttempt is one execution of a task within a workflow run.
+type TaskAttempt struct {
+	// Number counts the task's attempts in the run from 1, across worker
+	// retries and clears of its task runs.
+	Number     int           `json:"number"`
+	TaskRunID  uuid.UUID     `json:"task_run_id"`
+	Status     domain.Status `json:"status"`
+	WorkerID   string        `json:"worker_id,omitempty"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Logs       string        `json:"logs"`
+}
+
+// TaskAttempts returns the attempts of task taskID in workflow run runID,
+// first to last. A task run the workers recorded no attempts for, such as
+// one still running its first attempt or one from before WithAttempts, is
+// reported as a single attempt. An unknown run, or a task not in the run's
+// workflow, returns repository.ErrNotFound.
+func (s *Service) TaskAttempts(ctx context.Context, runID, taskID uuid.UUID) ([]TaskAttempt, error) {
+	run, err := s.workflowRuns.GetByID(ctx, runID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: workflow run %s", repository.ErrNotFound, runID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.taskRuns.ListByWorkflowRunID(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	var trs []*domain.TaskRun
+	for _, tr := range all {
+		if tr.TaskID == taskID {
+			trs = append(trs, tr)
+		}
+	}
+	if len(trs) == 0 && s.tasks != nil {
+		// Tell a task that has not started yet from one the run never has.
+		t, err := s.tasks.GetByID(ctx, taskID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		if t == nil || t.WorkflowID != run.WorkflowID {
+			return nil, fmt.Errorf("%w: task %s in workflow run %s", repository.ErrNotFound, taskID, runID)
+		}
+	}
+	sort.Slice(trs, func(i, j int) bool { return trs[i].Attempt < trs[j].Attempt })
+
+	out := []TaskAttempt{}
+	for _, tr := range trs {
+		attempts, err := s.taskRunAttempts(ctx, tr)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range attempts {
+			a.Number = len(out) + 1
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// taskRunAttempts returns the attempts the workers recorded for tr, followed
+// by the one in progress, if any.
+func (s *Service) taskRunAttempts(ctx context.Context, tr *domain.TaskRun) ([]TaskAttempt, error) {
+	var recorded []*qdomain.Attempt
+	if s.attempts != nil {
+		var err error
+		if recorded, err = s.attempts.ListByTaskID(ctx, tr.ID.String()); err != nil {
+			return nil, fmt.Errorf("list attempts of task run %s: %w", tr.ID, err)
+		}
+	}
+	if len(recorded) == 0 {
+		return []TaskAttempt{{
+			TaskRunID:  tr.ID,
+			Status:     tr.Status,
+			StartedAt:  tr.StartedAt,
+			FinishedAt: tr.FinishedAt,
+			Logs:       tr.Logs,
+		}}, nil
+	}
+	out := make([]TaskAttempt, 0, len(recorded)+1)
+	for _, a := range recorded {
+		finished := a.FinishedAt
+		ta := TaskAttempt{
+			TaskRunID:  tr.ID,
+			Status:     domain.StatusSuccess,
+			WorkerID:   a.WorkerID,
+			StartedAt:  a.StartedAt,
+			FinishedAt: &finished,
+			Error:      a.Error,
+			Logs:       a.Logs,
+		}
+		if a.Status != qdomain.TaskStatusSucceeded {
+			ta.Status = domain.StatusFailed
+		}
+		out = append(out, ta)
+	}
+	if tr.Status.IsTerminal() || s.queueTasks == nil {
+		return out, nil
+	}
+	// The worker records an attempt once it finishes, so a retry in
+	// progress only shows on the queue task.
+	qt, err := s.queueTasks.FindByID(ctx, tr.ID.String())
+	if errors.Is(err, qdomain.ErrTaskNotFound) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get queue task %s: %w", tr.ID, err)
+	}
+	if qt.Status == qdomain.TaskStatusRunning && qt.StartedAt != nil && qt.RetryCount+1 > recorded[len(recorded)-1].Number {
+		out = append(out, TaskAttempt{
+			TaskRunID: tr.ID,
+			Status:    domain.StatusRunning,
+			WorkerID:  qt.WorkerID,
+			StartedAt: *qt.StartedAt,
+		})
+	}
+	return out, nil
+}
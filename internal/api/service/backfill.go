@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// MaxBackfillIntervals bounds the number of runs a single backfill may create.
+const MaxBackfillIntervals = 10000
+
+// Errors returned by the backfill use cases.
+var (
+	// ErrBackfillsUnavailable is returned when no BackfillRepository is configured.
+	ErrBackfillsUnavailable = errors.New("backfills are not configured")
+	// ErrInvalidBackfillRange is returned when the requested range is empty,
+	// reversed, or holds more than MaxBackfillIntervals intervals.
+	ErrInvalidBackfillRange = errors.New("invalid backfill range")
+	// ErrBackfillNotRunning is returned when cancelling a finished backfill.
+	ErrBackfillNotRunning = errors.New("backfill is not running")
+)
+
+// BackfillInput carries the date range and parallelism of a new backfill.
+type BackfillInput struct {
+	Start time.Time `json:"start" binding:"required"`
+	End   time.Time `json:"end"   binding:"required"`
+	// MaxActiveRuns caps how many backfill runs may be unfinished at once.
+	// Defaults to 1, which runs the intervals strictly one after another.
+	MaxActiveRuns int `json:"max_active_runs"`
+}
+
+// BackfillProgress is a backfill together with the state of the runs it has
+// created so far.
+type BackfillProgress struct {
+	*domain.Backfill
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Active    int `json:"active"`
+}
+
+// CreateBackfill plans one run per schedule interval of the workflow in
+// [in.Start, in.End]. The runs themselves are created by
+// scheduler.Backfiller, on its next pass and then as earlier ones finish, so
+// only one process ever advances a backfill.
+func (s *Service) CreateBackfill(ctx context.Context, workflowID uuid.UUID, in BackfillInput) (*BackfillProgress, error) {
+	if s.backfills == nil {
+		return nil, ErrBackfillsUnavailable
+	}
+	wf, err := s.workflows.GetByID(ctx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	if wf.ScheduleCron == "" {
+		return nil, ErrNoSchedule
+	}
+	if in.End.Before(in.Start) {
+		return nil, fmt.Errorf("%w: end is before start", ErrInvalidBackfillRange)
+	}
+	times, ok, err := scheduler.ScheduleTimes(wf, in.Start, in.End, MaxBackfillIntervals)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: more than %d intervals", ErrInvalidBackfillRange, MaxBackfillIntervals)
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("%w: no schedule intervals in range", ErrInvalidBackfillRange)
+	}
+	maxActive := in.MaxActiveRuns
+	if maxActive < 1 {
+		maxActive = 1
+	}
+
+	b := &domain.Backfill{
+		ID:            uuid.New(),
+		WorkflowID:    workflowID,
+		Start:         in.Start.UTC(),
+		End:           in.End.UTC(),
+		MaxActiveRuns: maxActive,
+		Status:        domain.BackfillStatusRunning,
+		Total:         len(times),
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := s.backfills.Create(ctx, b); err != nil {
+		return nil, err
+	}
+	return s.backfillProgress(ctx, b)
+}
+
+// GetBackfill returns a backfill and the state of its runs.
+func (s *Service) GetBackfill(ctx context.Context, id uuid.UUID) (*BackfillProgress, error) {
+	if s.backfills == nil {
+		return nil, ErrBackfillsUnavailable
+	}
+	b, err := s.backfills.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.backfillProgress(ctx, b)
+}
+
+// CancelBackfill stops a running backfill from creating further runs. Runs
+// that were already created are left to finish.
+func (s *Service) CancelBackfill(ctx context.Context, id uuid.UUID) (*BackfillProgress, error) {
+	if s.backfills == nil {
+		return nil, ErrBackfillsUnavailable
+	}
+	b, err := s.backfills.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != domain.BackfillStatusRunning {
+		return nil, ErrBackfillNotRunning
+	}
+	now := time.Now().UTC()
+	err = s.backfills.Transition(ctx, b.ID, domain.BackfillStatusRunning, domain.BackfillStatusCancelled, now)
+	if errors.Is(err, repository.ErrConflict) {
+		// It completed or was cancelled since it was read.
+		return nil, ErrBackfillNotRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.Status = domain.BackfillStatusCancelled
+	b.FinishedAt = &now
+	return s.backfillProgress(ctx, b)
+}
+
+// backfillProgress tallies the runs created by b.
+func (s *Service) backfillProgress(ctx context.Context, b *domain.Backfill) (*BackfillProgress, error) {
+	runs, err := s.workflowRuns.ListByWorkflowID(ctx, b.WorkflowID)
+	if err != nil {
+		return nil, err
+	}
+	p := &BackfillProgress{Backfill: b}
+	for _, r := range runs {
+		if r.BackfillID == nil || *r.BackfillID != b.ID {
+			continue
+		}
+		switch r.Status {
+		case domain.StatusSuccess:
+			p.Succeeded++
+		case domain.StatusFailed:
+			p.Failed++
+		default:
+			p.Active++
+		}
+	}
+	return p, nil
+}
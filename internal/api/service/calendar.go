@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// Errors returned by the calendar use cases.
+var (
+	// ErrCalendarsUnavailable is returned when no CalendarRepository is configured.
+	ErrCalendarsUnavailable = errors.New("calendars are not configured")
+	// ErrInvalidCalendar is returned for malformed dates or iCal URLs.
+	ErrInvalidCalendar = errors.New("invalid calendar")
+)
+
+// CreateCalendarInput carries the fields of a new exclusion calendar.
+type CreateCalendarInput struct {
+	Name string `json:"name" binding:"required"`
+	// ExcludedDates are days in YYYY-MM-DD form.
+	ExcludedDates []string `json:"excluded_dates"`
+	// ICalURL is an optional http(s) iCalendar feed of further excluded days.
+	ICalURL string `json:"ical_url"`
+}
+
+// CreateCalendar validates and stores a new exclusion calendar.
+func (s *Service) CreateCalendar(ctx context.Context, in CreateCalendarInput) (*domain.Calendar, error) {
+	if s.calendars == nil {
+		return nil, ErrCalendarsUnavailable
+	}
+	for _, d := range in.ExcludedDates {
+		if _, err := time.Parse(domain.CalendarDateLayout, d); err != nil {
+			return nil, fmt.Errorf("%w: excluded date %q is not YYYY-MM-DD", ErrInvalidCalendar, d)
+		}
+	}
+	if in.ICalURL != "" {
+		u, err := url.Parse(in.ICalURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%w: ical_url must be an http(s) URL", ErrInvalidCalendar)
+		}
+	}
+	cal := &domain.Calendar{
+		ID:            uuid.New(),
+		Name:          in.Name,
+		ExcludedDates: in.ExcludedDates,
+		ICalURL:       in.ICalURL,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if cal.ExcludedDates == nil {
+		cal.ExcludedDates = []string{}
+	}
+	if err := s.calendars.Create(ctx, cal); err != nil {
+		return nil, err
+	}
+	return cal, nil
+}
+
+// GetCalendar returns the calendar with the given ID.
+func (s *Service) GetCalendar(ctx context.Context, id uuid.UUID) (*domain.Calendar, error) {
+	if s.calendars == nil {
+		return nil, ErrCalendarsUnavailable
+	}
+	return s.calendars.GetByID(ctx, id)
+}
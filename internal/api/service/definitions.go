@@ -0,0 +1,704 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// Errors returned when replicating workflow definitions.
+var (
+	// ErrInvalidDefinitions is returned for a bundle of an unknown version,
+	// or with a workflow that has no name or appears twice.
+	ErrInvalidDefinitions = errors.New("invalid workflow definitions")
+	// ErrDefinitionsDrifted is returned by ApplyDefinitions when the
+	// workflows here changed since the plan the caller reviewed.
+	ErrDefinitionsDrifted = errors.New("workflows changed since the plan")
+)
+
+// DefinitionsVersion is the version of the bundle format ExportDefinitions
+// writes and ApplyDefinitions reads.
+const DefinitionsVersion = 1
+
+// Definitions is a portable bundle of workflow definitions, without run
+// history, for replicating workflows between deployments.
+type Definitions struct {
+	Version    int                  `json:"version"`
+	ExportedAt time.Time            `json:"exported_at"`
+	Workflows  []WorkflowDefinition `json:"workflows"`
+}
+
+// WorkflowDefinition is a workflow as it travels between deployments. IDs
+// differ from one deployment to the next, so references go by name:
+// Calendar names the exclusion calendar, TriggerOnSuccess the workflows to
+// start, and the command of a trigger_workflow task its workflow.
+type WorkflowDefinition struct {
+	Name             string               `json:"name"`
+	Description      string               `json:"description,omitempty"`
+	ScheduleCron     string               `json:"schedule_cron,omitempty"`
+	Timezone         string               `json:"timezone,omitempty"`
+	StartDate        *time.Time           `json:"start_date,omitempty"`
+	EndDate          *time.Time           `json:"end_date,omitempty"`
+	IsActive         bool                 `json:"is_active"`
+	Tasks            []TaskInput          `json:"tasks,omitempty"`
+	TriggerDatasets  []string             `json:"trigger_datasets,omitempty"`
+	DatasetPolicy    domain.DatasetPolicy `json:"dataset_policy,omitempty"`
+	TriggerEvents    []string             `json:"trigger_events,omitempty"`
+	Calendar         string               `json:"calendar,omitempty"`
+	PauseWindows     []domain.PauseWindow `json:"pause_windows,omitempty"`
+	Catchup          domain.CatchupPolicy `json:"catchup,omitempty"`
+	TriggerOnSuccess []string             `json:"trigger_on_success,omitempty"`
+	MaxParallelTasks int                  `json:"max_parallel_tasks,omitempty"`
+	RetainRuns       int                  `json:"retain_runs,omitempty"`
+	RetainDays       int                  `json:"retain_days,omitempty"`
+	WorkerGroup      string               `json:"worker_group,omitempty"`
+	Tenant           string               `json:"tenant,omitempty"`
+	Priority         int                  `json:"priority,omitempty"`
+
+	RunTimeoutSeconds int                     `json:"run_timeout_seconds,omitempty"`
+	RunTimeoutPolicy  domain.RunTimeoutPolicy `json:"run_timeout_policy,omitempty"`
+	MaxActiveRuns     int                     `json:"max_active_runs,omitempty"`
+	OverlapPolicy     domain.OverlapPolicy    `json:"overlap_policy,omitempty"`
+}
+
+// SyncAction is what applying a bundle does to one workflow.
+type SyncAction string
+
+const (
+	SyncCreate    SyncAction = "create"
+	SyncUpdate    SyncAction = "update"
+	SyncUnchanged SyncAction = "unchanged"
+	// SyncConflict workflows are left alone; Reason says why.
+	SyncConflict SyncAction = "conflict"
+)
+
+// FieldChange is one difference between a workflow here and its
+// definition, e.g. field "schedule_cron" or "tasks.load.command". A task
+// only one side has is reported as a whole, with a null From or To.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// WorkflowSync is the plan for one workflow of a bundle.
+type WorkflowSync struct {
+	Name       string        `json:"name"`
+	Action     SyncAction    `json:"action"`
+	WorkflowID *uuid.UUID    `json:"workflow_id,omitempty"`
+	Changes    []FieldChange `json:"changes,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+}
+
+// SyncPlan reports how a bundle differs from the workflows here.
+type SyncPlan struct {
+	Workflows []WorkflowSync `json:"workflows"`
+	// Unmanaged lists the workflows here that the bundle does not mention;
+	// they are never changed or deleted.
+	Unmanaged []string `json:"unmanaged"`
+	// Fingerprint identifies the plan. Passing it to ApplyDefinitions makes
+	// the apply fail if the workflows here drifted after it was made.
+	Fingerprint string `json:"fingerprint"`
+	Applied     bool   `json:"applied"`
+}
+
+// ExportDefinitions returns the definitions of every workflow, sorted by
+// name.
+func (s *Service) ExportDefinitions(ctx context.Context) (*Definitions, error) {
+	all, err := s.workflows.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uuid.UUID]string, len(all))
+	for _, wf := range all {
+		names[wf.ID] = wf.Name
+	}
+	out := &Definitions{Version: DefinitionsVersion, ExportedAt: time.Now().UTC(), Workflows: []WorkflowDefinition{}}
+	for _, wf := range all {
+		def, err := s.definition(ctx, wf, names)
+		if err != nil {
+			return nil, err
+		}
+		out.Workflows = append(out.Workflows, def)
+	}
+	sort.Slice(out.Workflows, func(i, j int) bool { return out.Workflows[i].Name < out.Workflows[j].Name })
+	return out, nil
+}
+
+// definition converts wf to its portable form; names maps workflow IDs to
+// names. References to deleted calendars and workflows are dropped.
+func (s *Service) definition(ctx context.Context, wf *domain.Workflow, names map[uuid.UUID]string) (WorkflowDefinition, error) {
+	def := WorkflowDefinition{
+		Name:             wf.Name,
+		Description:      wf.Description,
+		ScheduleCron:     wf.ScheduleCron,
+		Timezone:         wf.Timezone,
+		StartDate:        wf.StartDate,
+		EndDate:          wf.EndDate,
+		IsActive:         wf.IsActive,
+		TriggerDatasets:  wf.TriggerDatasets,
+		DatasetPolicy:    wf.DatasetPolicy,
+		TriggerEvents:    wf.TriggerEvents,
+		PauseWindows:     wf.PauseWindows,
+		Catchup:          wf.Catchup,
+		MaxParallelTasks: wf.MaxParallelTasks,
+		RetainRuns:       wf.RetainRuns,
+		RetainDays:       wf.RetainDays,
+		WorkerGroup:      wf.WorkerGroup,
+		Tenant:           wf.Tenant,
+		Priority:         wf.Priority,
+
+		RunTimeoutSeconds: wf.RunTimeoutSeconds,
+		RunTimeoutPolicy:  wf.RunTimeoutPolicy,
+		MaxActiveRuns:     wf.MaxActiveRuns,
+		OverlapPolicy:     wf.OverlapPolicy,
+	}
+	if wf.CalendarID != nil && s.calendars != nil {
+		cal, err := s.calendars.GetByID(ctx, *wf.CalendarID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return def, err
+		}
+		if cal != nil {
+			def.Calendar = cal.Name
+		}
+	}
+	for _, id := range wf.TriggerOnSuccess {
+		if name, ok := names[id]; ok {
+			def.TriggerOnSuccess = append(def.TriggerOnSuccess, name)
+		}
+	}
+	if s.tasks == nil || s.deps == nil {
+		return def, nil
+	}
+	tasks, err := s.tasks.ListByWorkflowID(ctx, wf.ID)
+	if err != nil {
+		return def, err
+	}
+	deps, err := s.deps.ListByWorkflowID(ctx, wf.ID)
+	if err != nil {
+		return def, err
+	}
+	taskNames := make(map[uuid.UUID]string, len(tasks))
+	for _, t := range tasks {
+		taskNames[t.ID] = t.Name
+	}
+	upstream := map[uuid.UUID][]string{}
+	for _, d := range deps {
+		upstream[d.TaskID] = append(upstream[d.TaskID], taskNames[d.DependsOnTaskID])
+	}
+	for _, t := range tasks {
+		ti := TaskInput{
+			Name:                 t.Name,
+			Command:              t.Command,
+			Type:                 t.Type,
+			RetryCount:           t.RetryCount,
+			RetryDelaySeconds:    t.RetryDelaySeconds,
+			RetryMultiplier:      t.RetryMultiplier,
+			RetryMaxDelaySeconds: t.RetryMaxDelaySeconds,
+			RetryJitter:          t.RetryJitter,
+			TimeoutSeconds:       t.TimeoutSeconds,
+			Pool:                 t.Pool,
+			ConcurrencyKey:       t.ConcurrencyKey,
+			TriggerRule:          t.TriggerRule,
+			DependsOn:            upstream[t.ID],
+			Inputs:               t.Inputs,
+			Outputs:              t.Outputs,
+			Env:                  t.Env,
+			Hooks:                t.Hooks,
+			Profile:              t.Profile,
+			Queue:                t.Queue,
+			Priority:             t.Priority,
+			TTLSeconds:           t.TTLSeconds,
+		}
+		if t.Type == domain.TaskTypeTriggerWorkflow {
+			if id, err := uuid.Parse(t.Command); err == nil && names[id] != "" {
+				ti.Command = names[id]
+			}
+		}
+		def.Tasks = append(def.Tasks, normalizeTask(ti))
+	}
+	sort.Slice(def.Tasks, func(i, j int) bool { return def.Tasks[i].Name < def.Tasks[j].Name })
+	return def, nil
+}
+
+// normalizeTask fills in the defaults buildTasks applies and sorts
+// DependsOn, so equal tasks compare equal.
+func normalizeTask(ti TaskInput) TaskInput {
+	if ti.Type == "" {
+		ti.Type = domain.TaskTypeCommand
+	}
+	if ti.TriggerRule == "" {
+		ti.TriggerRule = domain.TriggerAllSuccess
+	}
+	ti.DependsOn = slices.Sorted(slices.Values(ti.DependsOn))
+	return ti
+}
+
+// syncItem is a workflow of a bundle with what is known of it here.
+type syncItem struct {
+	*WorkflowSync
+	def     WorkflowDefinition
+	current *domain.Workflow
+}
+
+// PlanDefinitions compares the bundle with the workflows here, matched by
+// name, and reports what ApplyDefinitions would do without changing
+// anything.
+func (s *Service) PlanDefinitions(ctx context.Context, defs *Definitions) (*SyncPlan, error) {
+	plan, _, _, err := s.planDefinitions(ctx, defs)
+	return plan, err
+}
+
+// ApplyDefinitions creates the bundle's new workflows and updates the
+// changed ones as PlanDefinitions reports, leaving conflicts and unmanaged
+// workflows alone. Tasks are matched by name: new ones are added and
+// changed ones updated in place, keeping their run history; a definition
+// that drops a task or dependency is a conflict. With a non-empty
+// fingerprint, the apply fails with ErrDefinitionsDrifted unless the plan
+// still has that fingerprint.
+func (s *Service) ApplyDefinitions(ctx context.Context, defs *Definitions, fingerprint string) (*SyncPlan, error) {
+	plan, items, ids, err := s.planDefinitions(ctx, defs)
+	if err != nil {
+		return nil, err
+	}
+	if fingerprint != "" && fingerprint != plan.Fingerprint {
+		return nil, fmt.Errorf("%w: plan %s is now %s", ErrDefinitionsDrifted, fingerprint, plan.Fingerprint)
+	}
+	now := time.Now().UTC()
+	// Create the new workflows first, so every workflow can refer to every
+	// other one by ID.
+	for _, it := range items {
+		if it.Action != SyncCreate {
+			continue
+		}
+		it.current = &domain.Workflow{ID: uuid.New(), Name: it.def.Name, CreatedAt: now}
+		if err := s.workflows.Create(ctx, it.current); err != nil {
+			return nil, err
+		}
+		ids[it.def.Name] = it.current.ID
+		it.WorkflowID = &it.current.ID
+	}
+	for _, it := range items {
+		if it.Action != SyncCreate && it.Action != SyncUpdate {
+			continue
+		}
+		wf, tasks, err := s.resolveDefinition(ctx, it.def, ids, it.current)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.workflows.Update(ctx, wf); err != nil {
+			return nil, err
+		}
+		if err := s.syncTasks(ctx, wf.ID, tasks, now); err != nil {
+			return nil, err
+		}
+	}
+	for i, it := range items {
+		plan.Workflows[i] = *it.WorkflowSync
+		switch it.Action {
+		case SyncCreate:
+			s.workflowChanged(ctx, it.current.ID, WorkflowCreated)
+		case SyncUpdate:
+			s.workflowChanged(ctx, it.current.ID, WorkflowUpdated)
+		}
+	}
+	plan.Applied = true
+	return plan, nil
+}
+
+// planDefinitions validates defs and plans applying it. It also returns the
+// plan's items and the IDs of the workflows here by name, leaving out
+// names several workflows share.
+func (s *Service) planDefinitions(ctx context.Context, defs *Definitions) (*SyncPlan, []*syncItem, map[string]uuid.UUID, error) {
+	if defs == nil || defs.Version != DefinitionsVersion {
+		return nil, nil, nil, fmt.Errorf("%w: version must be %d", ErrInvalidDefinitions, DefinitionsVersion)
+	}
+	all, err := s.workflows.List(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	byName := map[string][]*domain.Workflow{}
+	names := make(map[uuid.UUID]string, len(all))
+	for _, wf := range all {
+		byName[wf.Name] = append(byName[wf.Name], wf)
+		names[wf.ID] = wf.Name
+	}
+	ids := map[string]uuid.UUID{}
+	for name, wfs := range byName {
+		if len(wfs) == 1 {
+			ids[name] = wfs[0].ID
+		}
+	}
+	inBundle := map[string]bool{}
+	for _, def := range defs.Workflows {
+		if def.Name == "" {
+			return nil, nil, nil, fmt.Errorf("%w: workflow without a name", ErrInvalidDefinitions)
+		}
+		if inBundle[def.Name] {
+			return nil, nil, nil, fmt.Errorf("%w: workflow %q appears twice", ErrInvalidDefinitions, def.Name)
+		}
+		inBundle[def.Name] = true
+	}
+
+	plan := &SyncPlan{Workflows: []WorkflowSync{}, Unmanaged: []string{}}
+	items := make([]*syncItem, 0, len(defs.Workflows))
+	for _, def := range defs.Workflows {
+		def.Tasks = slices.Clone(def.Tasks)
+		for i := range def.Tasks {
+			def.Tasks[i] = normalizeTask(def.Tasks[i])
+		}
+		it := &syncItem{WorkflowSync: &WorkflowSync{Name: def.Name}, def: def}
+		items = append(items, it)
+		if matches := byName[def.Name]; len(matches) > 1 {
+			it.Action, it.Reason = SyncConflict, fmt.Sprintf("%d workflows here are named %q", len(matches), def.Name)
+			continue
+		} else if len(matches) == 1 {
+			it.current = matches[0]
+			it.WorkflowID = &it.current.ID
+		}
+		if reason, err := s.missingReference(ctx, def, ids, inBundle); err != nil {
+			return nil, nil, nil, err
+		} else if reason != "" {
+			it.Action, it.Reason = SyncConflict, reason
+			continue
+		}
+		// Validate the definition as it would be stored, with placeholders
+		// for workflows the bundle has yet to create.
+		placeholders := maps.Clone(ids)
+		for name := range inBundle {
+			if _, ok := placeholders[name]; !ok {
+				placeholders[name] = uuid.Nil
+			}
+		}
+		wf, tasks, err := s.resolveDefinition(ctx, def, placeholders, it.current)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		wf.TriggerOnSuccess = slices.DeleteFunc(wf.TriggerOnSuccess, func(id uuid.UUID) bool { return id == uuid.Nil })
+		if err := s.validateWorkflow(ctx, wf); err != nil {
+			return nil, nil, nil, fmt.Errorf("workflow %q: %w", def.Name, err)
+		}
+		if len(tasks) > 0 {
+			if s.tasks == nil || s.deps == nil {
+				return nil, nil, nil, ErrTasksUnavailable
+			}
+			if _, _, err := buildTasks(wf.ID, tasks, time.Now()); err != nil {
+				return nil, nil, nil, fmt.Errorf("workflow %q: %w", def.Name, err)
+			}
+		}
+
+		if it.current == nil {
+			it.Action = SyncCreate
+			continue
+		}
+		current, err := s.definition(ctx, it.current, names)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		it.Changes = diffDefinitions(current, def)
+		switch reason := removedStructure(current, def); {
+		case reason != "":
+			it.Action, it.Reason = SyncConflict, reason
+		case len(it.Changes) > 0:
+			it.Action = SyncUpdate
+		default:
+			it.Action = SyncUnchanged
+		}
+	}
+	blockConflictDependents(items, ids)
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	for _, it := range items {
+		plan.Workflows = append(plan.Workflows, *it.WorkflowSync)
+	}
+	for name := range byName {
+		if !inBundle[name] {
+			plan.Unmanaged = append(plan.Unmanaged, name)
+		}
+	}
+	sort.Strings(plan.Unmanaged)
+	b, _ := json.Marshal(plan.Workflows)
+	sum := sha256.Sum256(b)
+	plan.Fingerprint = hex.EncodeToString(sum[:16])
+	return plan, items, ids, nil
+}
+
+// missingReference returns why def cannot be applied here when a calendar
+// or workflow it refers to exists neither here nor in the bundle.
+func (s *Service) missingReference(ctx context.Context, def WorkflowDefinition, ids map[string]uuid.UUID, inBundle map[string]bool) (string, error) {
+	if def.Calendar != "" {
+		if s.calendars == nil {
+			return "", ErrCalendarsUnavailable
+		}
+		_, err := s.calendars.GetByName(ctx, def.Calendar)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Sprintf("calendar %q does not exist here", def.Calendar), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	for _, name := range workflowReferences(def) {
+		if _, ok := ids[name]; !ok && !inBundle[name] {
+			return fmt.Sprintf("triggered workflow %q is neither here nor in the bundle", name), nil
+		}
+	}
+	return "", nil
+}
+
+// workflowReferences returns the names of the workflows def triggers, on
+// success or from trigger_workflow tasks.
+func workflowReferences(def WorkflowDefinition) []string {
+	refs := slices.Clone(def.TriggerOnSuccess)
+	for _, t := range def.Tasks {
+		if t.Type != domain.TaskTypeTriggerWorkflow {
+			continue
+		}
+		if _, err := uuid.Parse(t.Command); err != nil {
+			refs = append(refs, t.Command)
+		}
+	}
+	return refs
+}
+
+// blockConflictDependents turns into conflicts the items triggering a
+// workflow that only the bundle has, when that workflow is itself a
+// conflict and so will not be created.
+func blockConflictDependents(items []*syncItem, ids map[string]uuid.UUID) {
+	byName := make(map[string]*syncItem, len(items))
+	for _, it := range items {
+		byName[it.Name] = it
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, it := range items {
+			if it.Action == SyncConflict {
+				continue
+			}
+			for _, name := range workflowReferences(it.def) {
+				if _, ok := ids[name]; !ok && byName[name].Action == SyncConflict {
+					it.Action, it.Reason, it.Changes = SyncConflict, fmt.Sprintf("triggered workflow %q is in conflict", name), nil
+					changed = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// resolveDefinition returns def as a workflow, updating current when
+// non-nil, and its task inputs, with names resolved to IDs through ids.
+func (s *Service) resolveDefinition(ctx context.Context, def WorkflowDefinition, ids map[string]uuid.UUID, current *domain.Workflow) (*domain.Workflow, []TaskInput, error) {
+	wf := &domain.Workflow{ID: uuid.New(), CreatedAt: time.Now().UTC()}
+	if current != nil {
+		cp := *current
+		wf = &cp
+	}
+	wf.Name = def.Name
+	wf.Description = def.Description
+	wf.ScheduleCron = def.ScheduleCron
+	wf.Timezone = def.Timezone
+	wf.StartDate = def.StartDate
+	wf.EndDate = def.EndDate
+	wf.IsActive = def.IsActive
+	wf.TriggerDatasets = def.TriggerDatasets
+	wf.DatasetPolicy = def.DatasetPolicy
+	wf.TriggerEvents = def.TriggerEvents
+	wf.PauseWindows = def.PauseWindows
+	wf.Catchup = def.Catchup
+	wf.MaxParallelTasks = def.MaxParallelTasks
+	wf.RetainRuns = def.RetainRuns
+	wf.RetainDays = def.RetainDays
+	wf.WorkerGroup = def.WorkerGroup
+	wf.Tenant = def.Tenant
+	wf.Priority = def.Priority
+	wf.RunTimeoutSeconds = def.RunTimeoutSeconds
+	wf.RunTimeoutPolicy = def.RunTimeoutPolicy
+	wf.MaxActiveRuns = def.MaxActiveRuns
+	wf.OverlapPolicy = def.OverlapPolicy
+	wf.CalendarID = nil
+	if def.Calendar != "" {
+		if s.calendars == nil {
+			return nil, nil, ErrCalendarsUnavailable
+		}
+		cal, err := s.calendars.GetByName(ctx, def.Calendar)
+		if err != nil {
+			return nil, nil, fmt.Errorf("calendar %q: %w", def.Calendar, err)
+		}
+		wf.CalendarID = &cal.ID
+	}
+	wf.TriggerOnSuccess = nil
+	for _, name := range def.TriggerOnSuccess {
+		wf.TriggerOnSuccess = append(wf.TriggerOnSuccess, ids[name])
+	}
+	tasks := slices.Clone(def.Tasks)
+	for i, t := range tasks {
+		if t.Type != domain.TaskTypeTriggerWorkflow {
+			continue
+		}
+		if _, err := uuid.Parse(t.Command); err != nil {
+			tasks[i].Command = ids[t.Command].String()
+		}
+	}
+	return wf, tasks, nil
+}
+
+// syncTasks makes the tasks of workflow wfID match in, matching them by
+// name: missing tasks and dependencies are created and changed tasks
+// updated. planDefinitions has ruled out removals.
+func (s *Service) syncTasks(ctx context.Context, wfID uuid.UUID, in []TaskInput, now time.Time) error {
+	if len(in) == 0 {
+		return nil
+	}
+	built, deps, err := buildTasks(wfID, in, now)
+	if err != nil {
+		return err
+	}
+	existing, err := s.tasks.ListByWorkflowID(ctx, wfID)
+	if err != nil {
+		return err
+	}
+	existingDeps, err := s.deps.ListByWorkflowID(ctx, wfID)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]*domain.Task, len(existing))
+	for _, t := range existing {
+		byName[t.Name] = t
+	}
+	// ids maps the IDs buildTasks chose to the IDs the tasks end up with.
+	ids := make(map[uuid.UUID]uuid.UUID, len(built))
+	for _, t := range built {
+		old, ok := byName[t.Name]
+		if !ok {
+			ids[t.ID] = t.ID
+			if err := s.tasks.Create(ctx, t); err != nil {
+				return err
+			}
+			continue
+		}
+		ids[t.ID] = old.ID
+		t.ID, t.CreatedAt = old.ID, old.CreatedAt
+		if !reflect.DeepEqual(t, old) {
+			if err := s.tasks.Update(ctx, t); err != nil {
+				return err
+			}
+		}
+	}
+	have := map[[2]uuid.UUID]bool{}
+	for _, d := range existingDeps {
+		have[[2]uuid.UUID{d.TaskID, d.DependsOnTaskID}] = true
+	}
+	for _, d := range deps {
+		d.TaskID, d.DependsOnTaskID = ids[d.TaskID], ids[d.DependsOnTaskID]
+		if have[[2]uuid.UUID{d.TaskID, d.DependsOnTaskID}] {
+			continue
+		}
+		if err := s.deps.Create(ctx, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removedStructure returns why applying to over from would need to delete
+// a task or dependency, which ApplyDefinitions does not do.
+func removedStructure(from, to WorkflowDefinition) string {
+	target := make(map[string]TaskInput, len(to.Tasks))
+	for _, t := range to.Tasks {
+		target[t.Name] = t
+	}
+	for _, t := range from.Tasks {
+		nt, ok := target[t.Name]
+		if !ok {
+			return fmt.Sprintf("task %q is not in the definition; remove it here first", t.Name)
+		}
+		for _, up := range t.DependsOn {
+			if !slices.Contains(nt.DependsOn, up) {
+				return fmt.Sprintf("task %q no longer depends on %q; removing dependencies is not supported", t.Name, up)
+			}
+		}
+	}
+	return ""
+}
+
+// diffDefinitions lists the fields that differ between from and to, sorted
+// by name. Empty values (null, "", 0, false, [] and {}) are all equal.
+func diffDefinitions(from, to WorkflowDefinition) []FieldChange {
+	a, b := fieldsOf(from), fieldsOf(to)
+	delete(a, "tasks")
+	delete(b, "tasks")
+	changes := diffFields("", a, b)
+
+	tasks := map[string][2]*TaskInput{}
+	for i := range from.Tasks {
+		tasks[from.Tasks[i].Name] = [2]*TaskInput{&from.Tasks[i], nil}
+	}
+	for i := range to.Tasks {
+		pair := tasks[to.Tasks[i].Name]
+		pair[1] = &to.Tasks[i]
+		tasks[to.Tasks[i].Name] = pair
+	}
+	for _, name := range slices.Sorted(maps.Keys(tasks)) {
+		pair, field := tasks[name], "tasks."+name
+		switch {
+		case pair[0] == nil:
+			changes = append(changes, FieldChange{Field: field, To: pair[1]})
+		case pair[1] == nil:
+			changes = append(changes, FieldChange{Field: field, From: pair[0]})
+		default:
+			changes = append(changes, diffFields(field+".", fieldsOf(*pair[0]), fieldsOf(*pair[1]))...)
+		}
+	}
+	return changes
+}
+
+func diffFields(prefix string, a, b map[string]any) []FieldChange {
+	keys := maps.Clone(a)
+	maps.Copy(keys, b)
+	var out []FieldChange
+	for _, k := range slices.Sorted(maps.Keys(keys)) {
+		if isEmpty(a[k]) && isEmpty(b[k]) || reflect.DeepEqual(a[k], b[k]) {
+			continue
+		}
+		out = append(out, FieldChange{Field: prefix + k, From: a[k], To: b[k]})
+	}
+	return out
+}
+
+// fieldsOf returns v's JSON fields.
+func fieldsOf(v any) map[string]any {
+	var m map[string]any
+	b, _ := json.Marshal(v)
+	_ = json.Unmarshal(b, &m)
+	return m
+}
+
+func isEmpty(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// DefaultEventHistoryLimit and MaxEventHistoryLimit bound the number of
+// events one EventHistory page holds.
+const (
+	DefaultEventHistoryLimit = 100
+	MaxEventHistoryLimit     = 1000
+)
+
+// Errors returned by EventHistory.
+var (
+	// ErrEventHistoryUnavailable is returned when no EventRepository is
+	// configured.
+	ErrEventHistoryUnavailable = errors.New("event history is not configured")
+	// ErrInvalidEventQuery is returned for a malformed cursor, a limit out
+	// of range or a time range that ends before it starts.
+	ErrInvalidEventQuery = errors.New("invalid event history query")
+)
+
+// WithEventHistory sets the repository the events published on the bus are
+// recorded to; the binaries wrap their bus with events.OpenHistory to fill
+// it. Without it, EventHistory returns ErrEventHistoryUnavailable.
+func WithEventHistory(r repository.EventRepository) Option {
+	return func(s *Service) { s.eventHistory = r }
+}
+
+// EventHistoryQuery selects a page of the event history. Cursor is the
+// NextCursor of the previous page, empty for the first. Types, Since and
+// Until, if set, narrow the events; Limit is DefaultEventHistoryLimit if
+// zero.
+type EventHistoryQuery struct {
+	Cursor string
+	Types  []string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+// EventHistoryPage is one page of the event history, oldest event first.
+// NextCursor is set if more events match the query.
+type EventHistoryPage struct {
+	Events     []*domain.EventRecord `json:"events"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// EventHistory returns the recorded events matching q, in the order they
+// were published.
+func (s *Service) EventHistory(ctx context.Context, q EventHistoryQuery) (*EventHistoryPage, error) {
+	if s.eventHistory == nil {
+		return nil, ErrEventHistoryUnavailable
+	}
+	f := repository.EventFilter{Types: q.Types, Since: q.Since, Until: q.Until, Limit: q.Limit}
+	if f.Limit == 0 {
+		f.Limit = DefaultEventHistoryLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxEventHistoryLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidEventQuery, MaxEventHistoryLimit)
+	}
+	if q.Cursor != "" {
+		seq, err := strconv.ParseInt(q.Cursor, 10, 64)
+		if err != nil || seq < 0 {
+			return nil, fmt.Errorf("%w: malformed cursor %q", ErrInvalidEventQuery, q.Cursor)
+		}
+		f.AfterSeq = seq
+	}
+	if !f.Since.IsZero() && !f.Until.IsZero() && !f.Until.After(f.Since) {
+		return nil, fmt.Errorf("%w: until must be after since", ErrInvalidEventQuery)
+	}
+
+	// One event past the page tells whether there is a next one.
+	limit := f.Limit
+	f.Limit++
+	evs, err := s.eventHistory.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	page := &EventHistoryPage{Events: evs}
+	if len(evs) > limit {
+		page.Events = evs[:limit]
+		page.NextCursor = strconv.FormatInt(page.Events[limit-1].Seq, 10)
+	}
+	if page.Events == nil {
+		page.Events = []*domain.EventRecord{}
+	}
+	return page, nil
+}
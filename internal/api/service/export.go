@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/export"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// ErrInvalidExport is returned when an export names an unknown table or
+// format, or its date range is missing or empty.
+var ErrInvalidExport = errors.New("invalid export")
+
+// Tables of run history ExportHistory can export.
+const (
+	// ExportRuns has one row per workflow run.
+	ExportRuns = "runs"
+	// ExportTaskRuns has one row per task run of those workflow runs.
+	ExportTaskRuns = "task_runs"
+)
+
+// exportPageSize is how many workflow runs an export reads at a time.
+const exportPageSize = 500
+
+// ExportInput selects the history to export: the workflow runs started in
+// [From, To), as Table in Format.
+type ExportInput struct {
+	Table  string
+	Format string
+	From   time.Time
+	To     time.Time
+}
+
+// HistoryExport is a validated export, streamed by Stream.
+type HistoryExport struct {
+	svc *Service
+	in  ExportInput
+}
+
+// ExportHistory validates in and returns the export it describes. Table
+// defaults to ExportRuns and Format to export.CSV.
+func (s *Service) ExportHistory(in ExportInput) (*HistoryExport, error) {
+	if in.Table == "" {
+		in.Table = ExportRuns
+	}
+	if in.Format == "" {
+		in.Format = export.CSV
+	}
+	if in.Table != ExportRuns && in.Table != ExportTaskRuns {
+		return nil, fmt.Errorf("%w: unknown table %q", ErrInvalidExport, in.Table)
+	}
+	if !export.ValidFormat(in.Format) {
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidExport, in.Format)
+	}
+	if in.From.IsZero() || in.To.IsZero() {
+		return nil, fmt.Errorf("%w: from and to are required", ErrInvalidExport)
+	}
+	if !in.From.Before(in.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidExport)
+	}
+	return &HistoryExport{svc: s, in: in}, nil
+}
+
+// ContentType returns the media type of the exported file.
+func (e *HistoryExport) ContentType() string { return export.ContentType(e.in.Format) }
+
+// Filename returns a name for the exported file, e.g.
+// runs_2024-03-01_2024-04-01.csv.
+func (e *HistoryExport) Filename() string {
+	return fmt.Sprintf("%s_%s_%s.%s", e.in.Table,
+		e.in.From.UTC().Format(time.DateOnly), e.in.To.UTC().Format(time.DateOnly), e.in.Format)
+}
+
+var (
+	runColumns = []export.Column{
+		{Name: "workflow_id", Type: export.String},
+		{Name: "workflow_name", Type: export.String},
+		{Name: "run_id", Type: export.String},
+		{Name: "status", Type: export.String},
+		{Name: "started_at", Type: export.Time},
+		{Name: "finished_at", Type: export.Time},
+		{Name: "duration_seconds", Type: export.Float},
+		{Name: "labels", Type: export.String},
+	}
+	taskRunColumns = []export.Column{
+		{Name: "workflow_id", Type: export.String},
+		{Name: "workflow_name", Type: export.String},
+		{Name: "run_id", Type: export.String},
+		{Name: "task_run_id", Type: export.String},
+		{Name: "task_id", Type: export.String},
+		{Name: "task_name", Type: export.String},
+		{Name: "status", Type: export.String},
+		{Name: "attempt", Type: export.Int},
+		{Name: "started_at", Type: export.Time},
+		{Name: "finished_at", Type: export.Time},
+		{Name: "duration_seconds", Type: export.Float},
+	}
+)
+
+// Stream writes the export to w, reading the runs of one workflow at a time
+// so memory use does not grow with the range. Unfinished runs and task runs
+// have no finished_at or duration_seconds. Labels are a JSON object.
+func (e *HistoryExport) Stream(ctx context.Context, w io.Writer) error {
+	s := e.svc
+	cols := runColumns
+	if e.in.Table == ExportTaskRuns {
+		cols = taskRunColumns
+	}
+	out, err := export.NewWriter(e.in.Format, w, cols)
+	if err != nil {
+		return err
+	}
+	workflows, err := s.workflows.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, wf := range workflows {
+		var taskNames map[string]string
+		if e.in.Table == ExportTaskRuns {
+			if taskNames, err = s.taskNames(ctx, wf); err != nil {
+				return err
+			}
+		}
+		filter := repository.WorkflowRunFilter{StartedFrom: e.in.From, StartedTo: e.in.To, Limit: exportPageSize}
+		for {
+			runs, err := s.workflowRuns.FindByWorkflowID(ctx, wf.ID, filter)
+			if err != nil {
+				return fmt.Errorf("list runs of workflow %s: %w", wf.ID, err)
+			}
+			for _, run := range runs {
+				if e.in.Table == ExportRuns {
+					err = out.Write(runRow(wf, run))
+				} else {
+					err = s.writeTaskRunRows(ctx, out, wf, run, taskNames)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if len(runs) < exportPageSize {
+				break
+			}
+			filter.Offset += len(runs)
+		}
+	}
+	return out.Close()
+}
+
+func runRow(wf *domain.Workflow, run *domain.WorkflowRun) []any {
+	var labels any
+	if len(run.Labels) > 0 {
+		b, _ := json.Marshal(run.Labels)
+		labels = string(b)
+	}
+	finished, duration := finishedAndDuration(run.StartedAt, run.FinishedAt)
+	return []any{wf.ID.String(), wf.Name, run.ID.String(), string(run.Status),
+		run.StartedAt, finished, duration, labels}
+}
+
+func (s *Service) writeTaskRunRows(ctx context.Context, out export.Writer, wf *domain.Workflow, run *domain.WorkflowRun, taskNames map[string]string) error {
+	trs, err := s.taskRuns.ListByWorkflowRunID(ctx, run.ID)
+	if err != nil {
+		return fmt.Errorf("list task runs of workflow run %s: %w", run.ID, err)
+	}
+	for _, tr := range trs {
+		var name any
+		if n, ok := taskNames[tr.TaskID.String()]; ok {
+			name = n
+		}
+		finished, duration := finishedAndDuration(tr.StartedAt, tr.FinishedAt)
+		row := []any{wf.ID.String(), wf.Name, run.ID.String(), tr.ID.String(), tr.TaskID.String(), name,
+			string(tr.Status), int64(tr.Attempt), tr.StartedAt, finished, duration}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taskNames maps the IDs of wf's tasks to their names; it is empty without
+// WithTasks.
+func (s *Service) taskNames(ctx context.Context, wf *domain.Workflow) (map[string]string, error) {
+	names := map[string]string{}
+	if s.tasks == nil {
+		return names, nil
+	}
+	tasks, err := s.tasks.ListByWorkflowID(ctx, wf.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list tasks of workflow %s: %w", wf.ID, err)
+	}
+	for _, t := range tasks {
+		names[t.ID.String()] = t.Name
+	}
+	return names, nil
+}
+
+// finishedAndDuration returns the export values of a run's finish time and
+// duration in seconds, nil while it is unfinished.
+func finishedAndDuration(started time.Time, finished *time.Time) (any, any) {
+	if finished == nil {
+		return nil, nil
+	}
+	return *finished, finished.Sub(started).Seconds()
+}
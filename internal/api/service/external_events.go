@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// ErrInvalidEvent is returned by FireEvent for an event name that no
+// workflow could subscribe to.
+var ErrInvalidEvent = errors.New("invalid event")
+
+// EventInput carries an external event posted to FireEvent. The handler
+// fills it from the request body and the caller's identity.
+type EventInput struct {
+	// Payload becomes the Params of every run the event starts. String
+	// values are passed as they are; other values as their JSON encoding.
+	Payload map[string]any
+	// By is the caller, recorded as the triggered_by label.
+	By string
+}
+
+// EventResult lists the runs an external event started, one per active
+// workflow subscribed to it that accepted a run, ordered by workflow name.
+type EventResult struct {
+	Event string                `json:"event"`
+	Runs  []*domain.WorkflowRun `json:"runs"`
+}
+
+// FireEvent starts a run of every active workflow whose TriggerEvents
+// include name, so upstream systems can start pipelines without knowing
+// their IDs. The runs carry the payload as Params and the event's name as
+// their domain.LabelEvent label. An event no workflow subscribes to starts
+// nothing and is not an error; neither is a workflow that rejects the run
+// because of its MaxActiveRuns.
+func (s *Service) FireEvent(ctx context.Context, name string, in EventInput) (*EventResult, error) {
+	if !domain.ValidEventName(name) {
+		return nil, fmt.Errorf("%w: name must be 1 to %d letters, digits, '-', '_', '.' or ':'", ErrInvalidEvent, domain.MaxEventNameLen)
+	}
+	params, err := eventParams(in.Payload)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.workflows.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var subscribed []*domain.Workflow
+	for _, wf := range active {
+		for _, ev := range wf.TriggerEvents {
+			if ev == name {
+				subscribed = append(subscribed, wf)
+				break
+			}
+		}
+	}
+	sort.Slice(subscribed, func(i, j int) bool { return subscribed[i].Name < subscribed[j].Name })
+
+	out := &EventResult{Event: name, Runs: []*domain.WorkflowRun{}}
+	for _, wf := range subscribed {
+		run, err := s.TriggerWorkflow(ctx, wf.ID, TriggerInput{
+			Params: params,
+			Labels: map[string]string{domain.LabelEvent: name},
+			By:     in.By,
+		})
+		if errors.Is(err, ErrMaxActiveRuns) {
+			continue // the workflow rejects runs beyond its limit
+		}
+		if err != nil {
+			return nil, fmt.Errorf("trigger workflow %s: %w", wf.Name, err)
+		}
+		out.Runs = append(out.Runs, run)
+	}
+	return out, nil
+}
+
+// eventParams flattens an event payload into run params.
+func eventParams(payload map[string]any) (map[string]string, error) {
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	params := make(map[string]string, len(payload))
+	for k, v := range payload {
+		if k == "" {
+			return nil, fmt.Errorf("%w: payload keys must not be empty", ErrInvalidEvent)
+		}
+		if str, ok := v.(string); ok {
+			params[k] = str
+			continue
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: payload %q: %v", ErrInvalidEvent, k, err)
+		}
+		params[k] = string(b)
+	}
+	return params, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// Directions a lineage graph can be traced in.
+const (
+	// LineageDownstream follows a dataset to the task runs that read it and
+	// on to the datasets they wrote.
+	LineageDownstream = "downstream"
+	// LineageUpstream follows a dataset to the task runs that wrote it and
+	// back to the datasets they read.
+	LineageUpstream = "upstream"
+)
+
+// MaxLineageDepth bounds how many task-run hops a lineage query follows.
+const MaxLineageDepth = 10
+
+// Errors returned by the lineage use case.
+var (
+	// ErrLineageUnavailable is returned when no LineageRepository is configured.
+	ErrLineageUnavailable = errors.New("lineage is not configured")
+	// ErrInvalidLineageQuery is returned for an empty dataset or an unknown
+	// direction.
+	ErrInvalidLineageQuery = errors.New("invalid lineage query")
+)
+
+// Lineage traces the recorded lineage of dataset in the given direction
+// (LineageDownstream when empty), following at most depth task-run hops.
+// Only edges recorded at or after since are followed, so passing the time a
+// table was corrupted lists exactly the runs that may have propagated it.
+// depth is clamped to [1, MaxLineageDepth]; 0 means MaxLineageDepth.
+func (s *Service) Lineage(ctx context.Context, dataset, direction string, since time.Time, depth int) (*domain.LineageGraph, error) {
+	if s.lineage == nil {
+		return nil, ErrLineageUnavailable
+	}
+	if dataset == "" {
+		return nil, fmt.Errorf("%w: dataset is required", ErrInvalidLineageQuery)
+	}
+	// from is the direction of the edges that lead away from a dataset,
+	// to the direction of the edges that lead on from the task run.
+	var from, to domain.LineageDirection
+	switch direction {
+	case "", LineageDownstream:
+		from, to = domain.LineageInput, domain.LineageOutput
+	case LineageUpstream:
+		from, to = domain.LineageOutput, domain.LineageInput
+	default:
+		return nil, fmt.Errorf("%w: unknown direction %q", ErrInvalidLineageQuery, direction)
+	}
+	if depth <= 0 || depth > MaxLineageDepth {
+		depth = MaxLineageDepth
+	}
+
+	g := &domain.LineageGraph{Dataset: dataset, Datasets: []string{dataset}, Workflows: []uuid.UUID{}, Edges: []domain.LineageEdge{}}
+	seenDatasets := map[string]bool{dataset: true}
+	seenEdges := make(map[uuid.UUID]bool)
+	seenWorkflows := make(map[uuid.UUID]bool)
+	add := func(e *domain.LineageEdge) {
+		if seenEdges[e.ID] {
+			return
+		}
+		seenEdges[e.ID] = true
+		g.Edges = append(g.Edges, *e)
+		if !seenWorkflows[e.WorkflowID] {
+			seenWorkflows[e.WorkflowID] = true
+			g.Workflows = append(g.Workflows, e.WorkflowID)
+		}
+	}
+
+	frontier := []string{dataset}
+	for hop := 0; hop < depth && len(frontier) > 0; hop++ {
+		var next []string
+		for _, ds := range frontier {
+			edges, err := s.lineage.ListByDataset(ctx, ds, from, since)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range edges {
+				add(e)
+				onward, err := s.lineage.ListByTaskRunID(ctx, e.TaskRunID)
+				if err != nil {
+					return nil, err
+				}
+				for _, o := range onward {
+					if o.Direction != to {
+						continue
+					}
+					add(o)
+					if !seenDatasets[o.Dataset] {
+						seenDatasets[o.Dataset] = true
+						g.Datasets = append(g.Datasets, o.Dataset)
+						next = append(next, o.Dataset)
+					}
+				}
+			}
+		}
+		frontier = next
+	}
+	sort.SliceStable(g.Edges, func(i, j int) bool { return g.Edges[i].CreatedAt.Before(g.Edges[j].CreatedAt) })
+	return g, nil
+}
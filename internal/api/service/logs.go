@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// MaxLogFollow bounds how long a following TaskRunLogs call waits for new
+// output before returning an empty chunk.
+const MaxLogFollow = 30 * time.Second
+
+// logPollInterval is how often a following TaskRunLogs call re-reads the
+// task run.
+const logPollInterval = 200 * time.Millisecond
+
+// ErrInvalidLogOffset is returned for a negative offset or one beyond the
+// end of the log.
+var ErrInvalidLogOffset = errors.New("invalid log offset")
+
+// LogChunk is the part of a task run's log from a byte offset onwards.
+type LogChunk struct {
+	TaskRunID uuid.UUID `json:"task_run_id"`
+	// Data is the log from the requested offset to the end of the log.
+	Data string `json:"data"`
+	// Offset is the offset to pass on the next call to continue tailing.
+	Offset int `json:"offset"`
+	// Complete reports that the task run has finished, so the log will
+	// not grow further.
+	Complete bool `json:"complete"`
+}
+
+// TaskRunLogs returns the task run's log from the byte offset onwards.
+// With follow, and while the task run is still running, it waits up to
+// MaxLogFollow (or until ctx is done) for output past offset before
+// returning; an empty Data then means nothing new was written.
+func (s *Service) TaskRunLogs(ctx context.Context, id uuid.UUID, offset int, follow bool) (*LogChunk, error) {
+	tr, err := s.taskRuns.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 || offset > len(tr.Logs) {
+		return nil, fmt.Errorf("%w: %d is outside the log of %d bytes", ErrInvalidLogOffset, offset, len(tr.Logs))
+	}
+	if follow {
+		tr, err = s.waitForLogs(ctx, tr, offset)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &LogChunk{
+		TaskRunID: tr.ID,
+		Data:      tr.Logs[offset:],
+		Offset:    len(tr.Logs),
+		Complete:  tr.Status.IsTerminal(),
+	}, nil
+}
+
+// waitForLogs polls the task run until its log grows past offset, it
+// finishes, MaxLogFollow elapses or ctx is done, and returns its latest
+// state.
+func (s *Service) waitForLogs(ctx context.Context, tr *domain.TaskRun, offset int) (*domain.TaskRun, error) {
+	deadline := time.NewTimer(MaxLogFollow)
+	defer deadline.Stop()
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+	for len(tr.Logs) == offset && !tr.Status.IsTerminal() {
+		select {
+		case <-ctx.Done():
+			return tr, nil
+		case <-deadline.C:
+			return tr, nil
+		case <-ticker.C:
+		}
+		latest, err := s.taskRuns.GetByID(ctx, tr.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return tr, nil
+			}
+			return nil, err
+		}
+		tr = latest
+	}
+	return tr, nil
+}
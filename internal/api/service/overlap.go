@@ -0,0 +1,23 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// ErrMaxActiveRuns is returned by TriggerWorkflow when the workflow already
+// has MaxActiveRuns runs pending or running and rejects runs beyond them.
+var ErrMaxActiveRuns = errors.New("workflow has reached its maximum active runs")
+
+// validateOverlap checks the MaxActiveRuns and OverlapPolicy of wf.
+func validateOverlap(wf *domain.Workflow) error {
+	if wf.MaxActiveRuns < 0 {
+		return fmt.Errorf("%w: max_active_runs must not be negative", ErrInvalidWorkflow)
+	}
+	if !wf.OverlapPolicy.Valid() {
+		return fmt.Errorf("%w: unknown overlap policy %q", ErrInvalidWorkflow, wf.OverlapPolicy)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// PriorityInput sets the queue priority a workflow's tasks inherit. A
+// Priority of 0 restores normal priority.
+type PriorityInput struct {
+	Priority int `json:"priority"`
+}
+
+// SetWorkflowPriority changes the priority of workflow id, which every one
+// of its tasks without a priority of its own is queued with from then on,
+// so a whole pipeline is escalated without editing its tasks. Tasks
+// already queued keep the priority they were queued with.
+func (s *Service) SetWorkflowPriority(ctx context.Context, id uuid.UUID, in PriorityInput) (*domain.Workflow, error) {
+	if err := validatePriority(in.Priority); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+	wf, err := s.workflows.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	wf.Priority = in.Priority
+	if err := s.workflows.Update(ctx, wf); err != nil {
+		return nil, err
+	}
+	s.workflowChanged(ctx, wf.ID, WorkflowUpdated)
+	return wf, nil
+}
+
+// validatePriority checks a workflow or task priority: 0, for the default,
+// or a queue priority.
+func validatePriority(p int) error {
+	if p != 0 && (p < int(qdomain.PriorityLow) || p > int(qdomain.PriorityHigh)) {
+		return fmt.Errorf("priority must be between %d and %d", qdomain.PriorityLow, qdomain.PriorityHigh)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/ratelimit"
+)
+
+// ErrRateLimitsUnavailable is returned when no rate limiter is configured.
+var ErrRateLimitsUnavailable = errors.New("rate limits are not configured")
+
+// WithRateLimiter sets the limiter API requests are admitted through, per
+// API key. Without it requests are not limited and APIKeyUsage returns
+// ErrRateLimitsUnavailable.
+func WithRateLimiter(l *ratelimit.Limiter) Option {
+	return func(s *Service) { s.limiter = l }
+}
+
+// RateLimiter returns the limiter configured with WithRateLimiter, or nil.
+func (s *Service) RateLimiter() *ratelimit.Limiter {
+	return s.limiter
+}
+
+// APIKeyUsage returns the requests, triggers and throttled requests of
+// every API key seen by this API process.
+func (s *Service) APIKeyUsage() ([]ratelimit.Usage, error) {
+	if s.limiter == nil {
+		return nil, ErrRateLimitsUnavailable
+	}
+	return s.limiter.Usage(), nil
+}
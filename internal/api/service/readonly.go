@@ -0,0 +1,13 @@
+package service
+
+// WithReadOnly marks the service as serving a read-only copy of the data,
+// such as a replica or a snapshot: the handlers refuse every request that
+// would change it, and Health reports the mode.
+func WithReadOnly() Option {
+	return func(s *Service) { s.readOnly = true }
+}
+
+// ReadOnly reports whether the service was configured with WithReadOnly.
+func (s *Service) ReadOnly() bool {
+	return s.readOnly
+}
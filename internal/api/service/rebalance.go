@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// rebalanceWait bounds how long RebalanceQueue waits for a task the source
+// queue reported waiting, in case a worker took it first.
+const rebalanceWait = 2 * time.Second
+
+// Errors returned by RebalanceQueue.
+var (
+	// ErrQueuesUnavailable is returned when no queues are configured.
+	ErrQueuesUnavailable = errors.New("queue rebalancing is not configured")
+	// ErrInvalidRebalance is returned for an unknown or invalid queue name,
+	// a priority out of range, a negative age or limit, or a request that
+	// would change nothing.
+	ErrInvalidRebalance = errors.New("invalid queue rebalance")
+)
+
+// WithQueues enables RebalanceQueue on q, the queues of every worker group
+// as the scheduler and workers open them. Without it, RebalanceQueue
+// returns ErrQueuesUnavailable.
+func WithQueues(q *scheduler.GroupQueues) Option {
+	return func(s *Service) { s.queues = q }
+}
+
+// RebalanceInput selects queued tasks and says what to do with them. From
+// is the worker group whose queue is searched, the default group if empty.
+// To, if set, is the group the tasks move to, "" naming the default group;
+// Priority, if set, replaces their priority. At least one of them must be
+// set. WorkflowID and MinAgeSeconds, if set, only select the tasks of that
+// workflow or queued at least that long ago; Limit, if set, stops after
+// that many tasks.
+type RebalanceInput struct {
+	From          string  `json:"from"`
+	To            *string `json:"to"`
+	Priority      *int    `json:"priority"`
+	WorkflowID    string  `json:"workflow_id"`
+	MinAgeSeconds int     `json:"min_age_seconds"`
+	Limit         int     `json:"limit"`
+	DryRun        bool    `json:"dry_run"`
+}
+
+// RebalanceResult reports what RebalanceQueue did: Scanned tasks were taken
+// off the source queue and Moved of them matched, their IDs in TaskIDs.
+// With DryRun nothing was changed and Moved counts the matching tasks.
+type RebalanceResult struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Priority *int     `json:"priority,omitempty"`
+	Scanned  int      `json:"scanned"`
+	Moved    int      `json:"moved"`
+	TaskIDs  []string `json:"task_ids"`
+	DryRun   bool     `json:"dry_run"`
+}
+
+// RebalanceQueue moves the tasks waiting in one worker group's queue that
+// match in to another group's queue, or changes their priority, so an
+// operator can shift a backlog onto idle capacity. It makes one pass over
+// the tasks waiting when it starts: each is taken off the queue, and put
+// back at its tail unless it matches. A matching task is enqueued on the
+// destination with its Group and Priority updated, and its record in the
+// execution store is updated to match. Tasks are served first in, first
+// out, so a task whose priority changes on its own queue also moves to the
+// tail; the new priority orders it wherever the scheduler orders tasks,
+// such as when it is retried. A task that cannot be enqueued is put back
+// on its source queue and the error is returned with what was moved so far.
+func (s *Service) RebalanceQueue(ctx context.Context, in RebalanceInput) (*RebalanceResult, error) {
+	if s.queues == nil {
+		return nil, ErrQueuesUnavailable
+	}
+	if err := validateRebalance(in); err != nil {
+		return nil, err
+	}
+	res := &RebalanceResult{From: in.From, To: in.From, Priority: in.Priority, DryRun: in.DryRun, TaskIDs: []string{}}
+	if in.To != nil {
+		res.To = *in.To
+	}
+	src, err := s.queues.Queue(res.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from: %v", ErrInvalidRebalance, err)
+	}
+	dst, err := s.queues.Queue(res.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: to: %v", ErrInvalidRebalance, err)
+	}
+	waiting, err := src.Len(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rebalance: length of queue %q: %w", res.From, err)
+	}
+
+	cutoff := time.Now().UTC().Add(-time.Duration(in.MinAgeSeconds) * time.Second)
+	for ; res.Scanned < waiting; res.Scanned++ {
+		wctx, cancel := context.WithTimeout(ctx, rebalanceWait)
+		task, err := src.Dequeue(wctx)
+		cancel()
+		if errors.Is(err, qdomain.ErrQueueEmpty) {
+			break // taken by workers meanwhile
+		}
+		if err != nil {
+			return res, fmt.Errorf("rebalance: dequeue from %q: %w", res.From, err)
+		}
+		match := (in.Limit == 0 || res.Moved < in.Limit) &&
+			(in.WorkflowID == "" || task.WorkflowID == in.WorkflowID) &&
+			(in.MinAgeSeconds == 0 || !task.CreatedAt.After(cutoff))
+		to, out := src, task
+		if match && !in.DryRun {
+			moved := *task
+			if in.To != nil {
+				moved.Group = res.To
+				moved.WorkerID = "" // no longer waiting on a worker's own queue
+			}
+			if in.Priority != nil {
+				moved.Priority = qdomain.Priority(*in.Priority)
+			}
+			to, out = dst, &moved
+		}
+		if err := to.Enqueue(ctx, out); err != nil {
+			if to == src {
+				return res, fmt.Errorf("rebalance: task %s is lost: put back on %q: %w", task.ID, res.From, err)
+			}
+			if rerr := src.Enqueue(context.WithoutCancel(ctx), task); rerr != nil {
+				return res, fmt.Errorf("rebalance: task %s is lost: enqueue on %q: %v; putting it back: %w", task.ID, res.To, err, rerr)
+			}
+			return res, fmt.Errorf("rebalance: enqueue task %s on %q: %w", task.ID, res.To, err)
+		}
+		if ack, ok := src.(qdomain.AckQueue); ok {
+			if err := ack.Ack(ctx, task); err != nil {
+				return res, fmt.Errorf("rebalance: ack task %s on %q: %w", task.ID, res.From, err)
+			}
+		}
+		if !match {
+			continue
+		}
+		res.Moved++
+		res.TaskIDs = append(res.TaskIDs, task.ID)
+		if !in.DryRun {
+			s.saveRebalanced(ctx, out)
+		}
+	}
+	return res, nil
+}
+
+// saveRebalanced updates the record of a moved task, if the execution store
+// has one; test executions and tasks submitted straight to the queue may
+// not.
+func (s *Service) saveRebalanced(ctx context.Context, task *qdomain.Task) {
+	if s.queueTasks == nil {
+		return
+	}
+	rec, err := s.queueTasks.FindByID(ctx, task.ID)
+	if err != nil {
+		return
+	}
+	rec.Group, rec.Priority, rec.WorkerID = task.Group, task.Priority, task.WorkerID
+	rec.UpdatedAt = time.Now().UTC()
+	_ = s.queueTasks.Save(ctx, rec)
+}
+
+func validateRebalance(in RebalanceInput) error {
+	if in.To == nil && in.Priority == nil {
+		return fmt.Errorf("%w: set to, priority or both", ErrInvalidRebalance)
+	}
+	if in.To != nil && strings.HasPrefix(*in.To, qdomain.StickyQueuePrefix) {
+		return fmt.Errorf("%w: to: tasks are only pinned to a worker by the scheduler", ErrInvalidRebalance)
+	}
+	if in.To != nil && *in.To == in.From && in.Priority == nil {
+		return fmt.Errorf("%w: from and to are the same queue", ErrInvalidRebalance)
+	}
+	if p := in.Priority; p != nil && (*p < int(qdomain.PriorityLow) || *p > int(qdomain.PriorityHigh)) {
+		return fmt.Errorf("%w: priority must be between %d and %d", ErrInvalidRebalance, qdomain.PriorityLow, qdomain.PriorityHigh)
+	}
+	if in.MinAgeSeconds < 0 {
+		return fmt.Errorf("%w: min_age_seconds must not be negative", ErrInvalidRebalance)
+	}
+	if in.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidRebalance)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// ErrTaskRunActive is returned when a task run is cleared while the task,
+// or one of the downstream tasks cleared with it, has not finished yet.
+var ErrTaskRunActive = errors.New("task run has not finished")
+
+// ClearTaskRun resets the task of task run id to pending within its
+// workflow run so the orchestrator dispatches it again. With downstream set,
+// every task that depends on it and has already run is cleared too.
+//
+// Earlier attempts are kept: each cleared task gets a new pending task run
+// whose Attempt follows its latest one, and the orchestrator starts that
+// run in place of creating a new one. A finished workflow run is set back
+// to running. It returns the new task runs, the given task's first.
+func (s *Service) ClearTaskRun(ctx context.Context, id uuid.UUID, downstream bool) ([]*domain.TaskRun, error) {
+	if s.tasks == nil || s.deps == nil {
+		return nil, ErrTasksUnavailable
+	}
+	tr, err := s.taskRuns.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	run, err := s.workflowRuns.GetByID(ctx, tr.WorkflowRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	clear := []uuid.UUID{tr.TaskID}
+	if downstream {
+		tasks, err := s.tasks.ListByWorkflowID(ctx, run.WorkflowID)
+		if err != nil {
+			return nil, err
+		}
+		deps, err := s.deps.ListByWorkflowID(ctx, run.WorkflowID)
+		if err != nil {
+			return nil, err
+		}
+		dag, err := domain.NewDAG(tasks, deps)
+		if err != nil {
+			return nil, err
+		}
+		clear = append(clear, dag.Descendants(tr.TaskID)...)
+	}
+
+	trs, err := s.taskRuns.ListByWorkflowRunID(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[uuid.UUID]*domain.TaskRun, len(trs))
+	for _, t := range trs {
+		if prev := latest[t.TaskID]; prev == nil || t.Attempt >= prev.Attempt {
+			latest[t.TaskID] = t
+		}
+	}
+
+	// Check every task before creating anything, so a rejected clear
+	// leaves the run untouched.
+	var next []*domain.TaskRun
+	now := time.Now().UTC()
+	for i, taskID := range clear {
+		prev := latest[taskID]
+		if prev == nil {
+			// A downstream task that never started runs on its own
+			// once the cleared tasks settle.
+			continue
+		}
+		if prev.Status == domain.StatusPending && i > 0 {
+			continue
+		}
+		if !prev.Status.IsTerminal() {
+			return nil, fmt.Errorf("%w: task run %s is %s", ErrTaskRunActive, prev.ID, prev.Status)
+		}
+		next = append(next, &domain.TaskRun{
+			ID:            uuid.New(),
+			WorkflowRunID: run.ID,
+			TaskID:        taskID,
+			Status:        domain.StatusPending,
+			Attempt:       prev.Attempt + 1,
+			StartedAt:     now,
+		})
+	}
+	for _, t := range next {
+		if err := s.taskRuns.Create(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+	if run.Status.IsTerminal() {
+		if err := s.workflowRuns.UpdateStatus(ctx, run.ID, domain.StatusRunning, nil); err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
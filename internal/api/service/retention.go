@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// Errors returned by the run history use cases.
+var (
+	// ErrRetentionUnavailable is returned when no RetentionRepository is
+	// configured.
+	ErrRetentionUnavailable = errors.New("run retention is not configured")
+	// ErrInvalidPurge is returned when a purge has no cutoff or targets runs
+	// that have not finished.
+	ErrInvalidPurge = errors.New("invalid run purge")
+)
+
+// WithRetention sets the repository that deletes old run history. Without
+// it, PurgeRuns returns ErrRetentionUnavailable.
+func WithRetention(r repository.RetentionRepository) Option {
+	return func(s *Service) { s.retention = r }
+}
+
+// PurgeResult reports the outcome of PurgeRuns.
+type PurgeResult struct {
+	DryRun bool `json:"dry_run"`
+	domain.PurgeCounts
+}
+
+// PurgeRuns deletes the finished workflow runs started before before, with
+// their task runs. A non-empty status limits the purge to runs in that
+// status, which must be terminal. With dryRun set nothing is deleted and the
+// result counts what would have been.
+func (s *Service) PurgeRuns(ctx context.Context, before time.Time, status domain.Status, dryRun bool) (*PurgeResult, error) {
+	if s.retention == nil {
+		return nil, ErrRetentionUnavailable
+	}
+	if before.IsZero() {
+		return nil, fmt.Errorf("%w: a cutoff time is required", ErrInvalidPurge)
+	}
+	// Pending and running runs are never purged: the scheduler still owns them.
+	filter := repository.RunPurgeFilter{Before: before, Statuses: domain.TerminalStatuses()}
+	if status != "" {
+		if !status.IsTerminal() {
+			return nil, fmt.Errorf("%w: runs in status %q have not finished", ErrInvalidPurge, status)
+		}
+		filter.Statuses = []domain.Status{status}
+	}
+	counts, err := s.retention.PurgeRuns(ctx, filter, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("purge runs: %w", err)
+	}
+	return &PurgeResult{DryRun: dryRun, PurgeCounts: counts}, nil
+}
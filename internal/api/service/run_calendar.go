@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// MaxRunCalendarDays bounds the range RunCalendar covers.
+const MaxRunCalendarDays = 366
+
+// ErrInvalidRunCalendar is returned when a run calendar's range is empty or
+// longer than MaxRunCalendarDays.
+var ErrInvalidRunCalendar = errors.New("invalid run calendar range")
+
+// RunCalendar is the per-day activity of every workflow over a range of
+// days, for a heatmap-style overview.
+type RunCalendar struct {
+	// From and To are the first and last day covered, YYYY-MM-DD in UTC.
+	From      string             `json:"from"`
+	To        string             `json:"to"`
+	Workflows []WorkflowCalendar `json:"workflows"`
+}
+
+// WorkflowCalendar is one workflow's row of a RunCalendar. Days lists only
+// the days with activity, oldest first.
+type WorkflowCalendar struct {
+	WorkflowID uuid.UUID     `json:"workflow_id"`
+	Name       string        `json:"name"`
+	Days       []CalendarDay `json:"days"`
+}
+
+// CalendarDay counts one workflow's runs on one UTC day. Scheduled counts
+// cron fire times still ahead; Running counts pending and running runs.
+type CalendarDay struct {
+	Date      string `json:"date"`
+	Scheduled int    `json:"scheduled"`
+	Running   int    `json:"running"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+}
+
+// RunCalendar buckets the runs of every workflow started between the days
+// from and to, inclusive, by UTC day, and adds the fire times the schedules
+// of active workflows still have ahead in that range.
+func (s *Service) RunCalendar(ctx context.Context, from, to time.Time) (*RunCalendar, error) {
+	start := truncateDay(from)
+	end := truncateDay(to).AddDate(0, 0, 1)
+	if !start.Before(end) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidRunCalendar)
+	}
+	if end.Sub(start) > MaxRunCalendarDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: at most %d days", ErrInvalidRunCalendar, MaxRunCalendarDays)
+	}
+	wfs, err := s.workflows.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	out := &RunCalendar{
+		From:      start.Format(domain.CalendarDateLayout),
+		To:        end.AddDate(0, 0, -1).Format(domain.CalendarDateLayout),
+		Workflows: make([]WorkflowCalendar, 0, len(wfs)),
+	}
+	for _, wf := range wfs {
+		days := make(map[string]*CalendarDay)
+		day := func(t time.Time) *CalendarDay {
+			date := t.UTC().Format(domain.CalendarDateLayout)
+			if days[date] == nil {
+				days[date] = &CalendarDay{Date: date}
+			}
+			return days[date]
+		}
+
+		runs, err := s.workflowRuns.FindByWorkflowID(ctx, wf.ID, repository.WorkflowRunFilter{StartedFrom: start, StartedTo: end})
+		if err != nil {
+			return nil, err
+		}
+		for _, run := range runs {
+			d := day(run.StartedAt)
+			switch run.Status {
+			case domain.StatusSuccess:
+				d.Succeeded++
+			case domain.StatusFailed:
+				d.Failed++
+			default:
+				d.Running++
+			}
+		}
+
+		if wf.IsActive && !wf.Paused && wf.ScheduleCron != "" {
+			// A schedule that no longer parses simply has nothing ahead.
+			if sched, err := scheduler.WorkflowSchedule(wf); err == nil {
+				for t := sched.Next(maxTime(start, now).Add(-time.Nanosecond)); !t.IsZero() && t.Before(end); t = sched.Next(t) {
+					day(t).Scheduled++
+				}
+			}
+		}
+
+		row := WorkflowCalendar{WorkflowID: wf.ID, Name: wf.Name, Days: make([]CalendarDay, 0, len(days))}
+		for _, d := range days {
+			row.Days = append(row.Days, *d)
+		}
+		sort.Slice(row.Days, func(i, j int) bool { return row.Days[i].Date < row.Days[j].Date })
+		out.Workflows = append(out.Workflows, row)
+	}
+	return out, nil
+}
+
+// truncateDay returns the start of t's day in UTC.
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
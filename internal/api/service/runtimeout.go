@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// RunTimeoutInput sets how long runs of a workflow may take and what
+// happens to one that takes longer. A RunTimeoutSeconds of 0 removes the
+// limit.
+type RunTimeoutInput struct {
+	RunTimeoutSeconds int                     `json:"run_timeout_seconds"`
+	RunTimeoutPolicy  domain.RunTimeoutPolicy `json:"run_timeout_policy"`
+}
+
+// SetRunTimeout changes the run timeout settings of workflow id. Runs
+// already in progress are held to the new settings from the orchestrator's
+// next pass.
+func (s *Service) SetRunTimeout(ctx context.Context, id uuid.UUID, in RunTimeoutInput) (*domain.Workflow, error) {
+	wf, err := s.workflows.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	wf.RunTimeoutSeconds, wf.RunTimeoutPolicy = in.RunTimeoutSeconds, in.RunTimeoutPolicy
+	if err := validateRunTimeout(wf); err != nil {
+		return nil, err
+	}
+	if err := s.workflows.Update(ctx, wf); err != nil {
+		return nil, err
+	}
+	s.workflowChanged(ctx, wf.ID, WorkflowUpdated)
+	return wf, nil
+}
+
+// validateRunTimeout checks wf's RunTimeoutSeconds and RunTimeoutPolicy.
+func validateRunTimeout(wf *domain.Workflow) error {
+	if wf.RunTimeoutSeconds < 0 {
+		return fmt.Errorf("%w: run_timeout_seconds must not be negative", ErrInvalidWorkflow)
+	}
+	if !wf.RunTimeoutPolicy.Valid() {
+		return fmt.Errorf("%w: unknown run timeout policy %q", ErrInvalidWorkflow, wf.RunTimeoutPolicy)
+	}
+	return nil
+}
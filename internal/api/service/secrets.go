@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+)
+
+// Errors returned by the secret use cases.
+var (
+	// ErrSecretsUnavailable is returned when no SecretStore is configured.
+	ErrSecretsUnavailable = errors.New("secrets are not configured")
+	// ErrInvalidSecret is returned for a secret without a name or value.
+	ErrInvalidSecret = errors.New("invalid secret")
+)
+
+// WithSecrets enables PutSecret on store, the store workers started with
+// worker.WithSecrets resolve task env references against. Without it,
+// PutSecret returns ErrSecretsUnavailable.
+func WithSecrets(store qdomain.SecretStore) Option {
+	return func(s *Service) { s.secrets = store }
+}
+
+// PutSecret creates the named secret or rotates its value. When an event
+// bus is configured the rotation is announced on it, so workers stop using
+// the value they cached; the value itself is never published.
+func (s *Service) PutSecret(ctx context.Context, name, value, by string) error {
+	if s.secrets == nil {
+		return ErrSecretsUnavailable
+	}
+	if name == "" || value == "" {
+		return fmt.Errorf("%w: name and value are required", ErrInvalidSecret)
+	}
+	if err := s.secrets.Put(ctx, name, value); err != nil {
+		return fmt.Errorf("store secret: %w", err)
+	}
+	if s.events == nil {
+		return nil
+	}
+	e := events.Event{Type: events.SecretRotated, Payload: events.Secret{Name: name, By: by, At: time.Now().UTC()}}
+	if err := s.events.Publish(ctx, e); err != nil {
+		return fmt.Errorf("publish secret rotation: %w", err)
+	}
+	return nil
+}
@@ -4,11 +4,17 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/ratelimit"
 	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
 	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
 )
 
 // Service holds all repository dependencies and exposes use-case methods
@@ -18,6 +24,86 @@ type Service struct {
 	workflowRuns repository.WorkflowRunRepository
 	taskRuns     repository.TaskRunRepository
 	workers      repository.WorkerRepository
+	approvals    repository.ApprovalRepository
+	backfills    repository.BackfillRepository
+	tasks        repository.TaskRepository
+	deps         repository.TaskDependencyRepository
+	events       events.Bus
+	stats        repository.StatsRepository
+	lineage      repository.LineageRepository
+	calendars    repository.CalendarRepository
+	retention    repository.RetentionRepository
+	eventHistory repository.EventRepository
+	limiter      *ratelimit.Limiter
+	alerts       *events.AlertBoard
+	readOnly     bool
+	usageRates   map[string]float64
+
+	// workerNodes, queueTasks and heartbeats read the execution side:
+	// the workers that run dispatched tasks.
+	workerNodes qdomain.WorkerRepository
+	queueTasks  qdomain.TaskRepository
+	heartbeats  qdomain.HeartbeatRepository
+	attempts    qdomain.AttemptRepository
+	freeze      qdomain.FreezeRepository
+	secrets     qdomain.SecretStore
+
+	// testQueue is the queue of the worker group testGroup, which runs
+	// TestTask executions.
+	testQueue qdomain.Queue
+	testGroup string
+
+	// queues holds the queue of every worker group, for RebalanceQueue.
+	queues *scheduler.GroupQueues
+}
+
+// Option is a functional option for configuring a Service.
+type Option func(*Service)
+
+// WithApprovals sets the repository used to record approval decisions.
+// Without it, approval endpoints return ErrApprovalsUnavailable.
+func WithApprovals(r repository.ApprovalRepository) Option {
+	return func(s *Service) { s.approvals = r }
+}
+
+// WithBackfills sets the repository used to track backfills. Without it,
+// backfill endpoints return ErrBackfillsUnavailable.
+func WithBackfills(r repository.BackfillRepository) Option {
+	return func(s *Service) { s.backfills = r }
+}
+
+// WithTasks sets the repositories that store the task definitions supplied
+// to CreateWorkflow. Without it, creating a workflow with tasks returns
+// ErrTasksUnavailable.
+func WithTasks(tasks repository.TaskRepository, deps repository.TaskDependencyRepository) Option {
+	return func(s *Service) {
+		s.tasks = tasks
+		s.deps = deps
+	}
+}
+
+// WithStats sets the repository that computes run duration statistics.
+// Without it, WorkflowStats returns ErrStatsUnavailable.
+func WithStats(r repository.StatsRepository) Option {
+	return func(s *Service) { s.stats = r }
+}
+
+// WithLineage sets the repository lineage queries read from. Without it,
+// Lineage returns ErrLineageUnavailable.
+func WithLineage(r repository.LineageRepository) Option {
+	return func(s *Service) { s.lineage = r }
+}
+
+// WithCalendars sets the repository that stores exclusion calendars.
+// Without it, calendar endpoints return ErrCalendarsUnavailable.
+func WithCalendars(r repository.CalendarRepository) Option {
+	return func(s *Service) { s.calendars = r }
+}
+
+// WithEvents sets the bus that scheduler and worker state changes arrive on.
+// The router relays its events to WebSocket clients.
+func WithEvents(b events.Bus) Option {
+	return func(s *Service) { s.events = b }
 }
 
 // New creates a Service with the supplied repository implementations.
@@ -26,40 +112,327 @@ func New(
 	workflowRuns repository.WorkflowRunRepository,
 	taskRuns repository.TaskRunRepository,
 	workers repository.WorkerRepository,
+	opts ...Option,
 ) *Service {
-	return &Service{
+	s := &Service{
 		workflows:    workflows,
 		workflowRuns: workflowRuns,
 		taskRuns:     taskRuns,
 		workers:      workers,
+		alerts:       events.NewAlertBoard(),
 	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
 }
 
+// Events returns the bus configured with WithEvents, or nil.
+func (s *Service) Events() events.Bus {
+	return s.events
+}
+
+// Actions of the events.WorkflowChanged events workflowChanged publishes.
+const (
+	WorkflowCreated = "created"
+	WorkflowUpdated = "updated"
+	WorkflowPaused  = "paused"
+	WorkflowResumed = "resumed"
+)
+
+// workflowChanged announces on the bus, if any, that workflow id was
+// created or changed, so running cron triggers reload their schedules. A
+// lost announcement only delays the change until the triggers' next
+// periodic reload, so publishing errors are ignored.
+func (s *Service) workflowChanged(ctx context.Context, id uuid.UUID, action string) {
+	if s.events == nil {
+		return
+	}
+	_ = s.events.Publish(ctx, events.Event{Type: events.WorkflowChanged, Payload: events.WorkflowChange{
+		WorkflowID: id.String(),
+		Action:     action,
+		At:         time.Now().UTC(),
+	}})
+}
+
+// Alerts returns the board of backpressure alerts the scheduler has raised;
+// the router feeds it from the event bus.
+func (s *Service) Alerts() *events.AlertBoard {
+	return s.alerts
+}
+
+// Health is the state reported by GET /healthz. Degraded is set while any
+// backpressure alert fires; ReadOnly when the API refuses writes.
+type Health struct {
+	Status   string               `json:"status"`
+	Service  string               `json:"service"`
+	Degraded bool                 `json:"degraded,omitempty"`
+	ReadOnly bool                 `json:"read_only,omitempty"`
+	Alerts   []events.AlertUpdate `json:"alerts,omitempty"`
+}
+
+// Health returns the API's health, degraded while the scheduler reports
+// backpressure.
+func (s *Service) Health() Health {
+	h := Health{Status: "ok", Service: "task-scheduler-api", ReadOnly: s.readOnly, Alerts: s.alerts.Firing()}
+	if len(h.Alerts) > 0 {
+		h.Status, h.Degraded = "degraded", true
+	}
+	return h
+}
+
+// Errors returned by the approval use cases.
+var (
+	// ErrApprovalsUnavailable is returned when no ApprovalRepository is configured.
+	ErrApprovalsUnavailable = errors.New("approvals are not configured")
+	// ErrNotAwaitingApproval is returned when a decision is submitted for a
+	// task run that is not parked on an approval gate.
+	ErrNotAwaitingApproval = errors.New("task run is not awaiting approval")
+)
+
+// ErrInvalidWorkflow is returned when CreateWorkflow is given inconsistent
+// workflow-level settings.
+var ErrInvalidWorkflow = errors.New("invalid workflow")
+
 // CreateWorkflowInput carries the fields supplied by the caller when creating
 // a new workflow. ID and CreatedAt are generated here.
 type CreateWorkflowInput struct {
 	Name         string `json:"name"         binding:"required"`
 	Description  string `json:"description"`
 	ScheduleCron string `json:"schedule_cron"`
+	Timezone     string `json:"timezone"`
 	IsActive     bool   `json:"is_active"`
+	// StartDate and EndDate bound when ScheduleCron fires; optional.
+	StartDate *time.Time `json:"start_date"`
+	EndDate   *time.Time `json:"end_date"`
+
+	// Tasks are created along with the workflow; optional.
+	Tasks []TaskInput `json:"tasks"`
+
+	// TriggerDatasets and DatasetPolicy make the workflow run when other
+	// workflows update these datasets; optional.
+	TriggerDatasets []string             `json:"trigger_datasets"`
+	DatasetPolicy   domain.DatasetPolicy `json:"dataset_policy"`
+	// TriggerEvents subscribes the workflow to external events posted to
+	// FireEvent; optional.
+	TriggerEvents []string `json:"trigger_events"`
+
+	// CalendarID attaches an exclusion calendar; optional.
+	CalendarID *uuid.UUID `json:"calendar_id"`
+	// PauseWindows are recurring blackouts for the schedule; optional.
+	PauseWindows []domain.PauseWindow `json:"pause_windows"`
+	// Catchup decides which fires missed while the scheduler was down are
+	// run; empty runs none.
+	Catchup domain.CatchupPolicy `json:"catchup"`
+	// TriggerOnSuccess lists existing workflows to start after each
+	// successful run; optional.
+	TriggerOnSuccess []uuid.UUID `json:"trigger_on_success"`
+	// MaxParallelTasks caps the tasks of a run dispatched at once; 0 means
+	// no limit.
+	MaxParallelTasks int `json:"max_parallel_tasks"`
+	// RetainRuns and RetainDays limit the finished run history the retention
+	// job keeps; 0 keeps everything.
+	RetainRuns int `json:"retain_runs"`
+	RetainDays int `json:"retain_days"`
+	// WorkerGroup dedicates the workflow's tasks to a worker group; empty
+	// uses the default group.
+	WorkerGroup string `json:"worker_group"`
+	// Tenant is charged for the workflow's execution time in the usage
+	// report.
+	Tenant string `json:"tenant"`
+	// Priority is the queue priority, 1 to 10, of the workflow's tasks
+	// that set none; 0 means normal priority.
+	Priority int `json:"priority"`
+	// RunTimeoutSeconds bounds how long a run may take and
+	// RunTimeoutPolicy what happens to one that overruns; 0 means no
+	// limit.
+	RunTimeoutSeconds int                     `json:"run_timeout_seconds"`
+	RunTimeoutPolicy  domain.RunTimeoutPolicy `json:"run_timeout_policy"`
+	// MaxActiveRuns caps the runs pending or running at once and
+	// OverlapPolicy decides what happens to those beyond it; 0 means no
+	// limit.
+	MaxActiveRuns int                  `json:"max_active_runs"`
+	OverlapPolicy domain.OverlapPolicy `json:"overlap_policy"`
 }
 
-// CreateWorkflow persists a new workflow and returns the stored entity.
+// CreateWorkflow persists a new workflow, together with its tasks and their
+// dependencies, and returns the stored workflow.
 func (s *Service) CreateWorkflow(ctx context.Context, in CreateWorkflowInput) (*domain.Workflow, error) {
+	now := time.Now().UTC()
 	wf := &domain.Workflow{
 		ID:           uuid.New(),
 		Name:         in.Name,
 		Description:  in.Description,
 		ScheduleCron: in.ScheduleCron,
+		Timezone:     in.Timezone,
+		StartDate:    in.StartDate,
+		EndDate:      in.EndDate,
 		IsActive:     in.IsActive,
-		CreatedAt:    time.Now().UTC(),
+		CreatedAt:    now,
+
+		TriggerDatasets: in.TriggerDatasets,
+		DatasetPolicy:   in.DatasetPolicy,
+		TriggerEvents:   in.TriggerEvents,
+		CalendarID:      in.CalendarID,
+		PauseWindows:    in.PauseWindows,
+		Catchup:         in.Catchup,
+
+		TriggerOnSuccess: in.TriggerOnSuccess,
+		MaxParallelTasks: in.MaxParallelTasks,
+		RetainRuns:       in.RetainRuns,
+		RetainDays:       in.RetainDays,
+		WorkerGroup:      in.WorkerGroup,
+		Tenant:           in.Tenant,
+		Priority:         in.Priority,
+
+		RunTimeoutSeconds: in.RunTimeoutSeconds,
+		RunTimeoutPolicy:  in.RunTimeoutPolicy,
+		MaxActiveRuns:     in.MaxActiveRuns,
+		OverlapPolicy:     in.OverlapPolicy,
+	}
+	if err := s.validateWorkflow(ctx, wf); err != nil {
+		return nil, err
+	}
+	var (
+		tasks []*domain.Task
+		deps  []*domain.TaskDependency
+	)
+	if len(in.Tasks) > 0 {
+		if s.tasks == nil || s.deps == nil {
+			return nil, ErrTasksUnavailable
+		}
+		var err error
+		if tasks, deps, err = buildTasks(wf.ID, in.Tasks, now); err != nil {
+			return nil, err
+		}
 	}
 	if err := s.workflows.Create(ctx, wf); err != nil {
 		return nil, err
 	}
+	for _, t := range tasks {
+		if err := s.tasks.Create(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+	for _, d := range deps {
+		if err := s.deps.Create(ctx, d); err != nil {
+			return nil, err
+		}
+	}
+	s.workflowChanged(ctx, wf.ID, WorkflowCreated)
 	return wf, nil
 }
 
+// validateWorkflow checks the settings of wf, whether new or updated, and
+// that the calendar and workflows it refers to exist.
+func (s *Service) validateWorkflow(ctx context.Context, wf *domain.Workflow) error {
+	if wf.MaxParallelTasks < 0 {
+		return fmt.Errorf("%w: max_parallel_tasks must not be negative", ErrInvalidWorkflow)
+	}
+	if wf.RetainRuns < 0 || wf.RetainDays < 0 {
+		return fmt.Errorf("%w: retain_runs and retain_days must not be negative", ErrInvalidWorkflow)
+	}
+	if wf.WorkerGroup != qdomain.DefaultGroup && !qdomain.ValidGroupName(wf.WorkerGroup) {
+		return fmt.Errorf("%w: worker_group must be 1 to %d letters, digits, '-', '_' or '.'", ErrInvalidWorkflow, qdomain.MaxGroupNameLen)
+	}
+	if err := validatePriority(wf.Priority); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+	if err := validateRunTimeout(wf); err != nil {
+		return err
+	}
+	if err := validateOverlap(wf); err != nil {
+		return err
+	}
+	if err := validateSchedule(wf); err != nil {
+		return err
+	}
+	if err := validatePauseWindows(wf); err != nil {
+		return err
+	}
+	if !wf.Catchup.Valid() {
+		return fmt.Errorf("%w: unknown catchup policy %q", ErrInvalidWorkflow, wf.Catchup)
+	}
+	if !wf.DatasetPolicy.Valid() {
+		return fmt.Errorf("%w: unknown dataset policy %q", ErrInvalidWorkflow, wf.DatasetPolicy)
+	}
+	for _, ds := range wf.TriggerDatasets {
+		if ds == "" {
+			return fmt.Errorf("%w: trigger dataset must not be empty", ErrInvalidWorkflow)
+		}
+	}
+	for _, ev := range wf.TriggerEvents {
+		if !domain.ValidEventName(ev) {
+			return fmt.Errorf("%w: trigger event %q must be 1 to %d letters, digits, '-', '_', '.' or ':'", ErrInvalidWorkflow, ev, domain.MaxEventNameLen)
+		}
+	}
+	if wf.CalendarID != nil {
+		if s.calendars == nil {
+			return ErrCalendarsUnavailable
+		}
+		_, err := s.calendars.GetByID(ctx, *wf.CalendarID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: calendar %s does not exist", ErrInvalidWorkflow, *wf.CalendarID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, target := range wf.TriggerOnSuccess {
+		_, err := s.workflows.GetByID(ctx, target)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: triggered workflow %s does not exist", ErrInvalidWorkflow, target)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSchedule checks wf's Timezone and ScheduleCron with the parser
+// CronTrigger uses, and that its dates are in order, so a workflow that
+// could never fire is not stored.
+func validateSchedule(wf *domain.Workflow) error {
+	if wf.Timezone != "" {
+		if _, err := time.LoadLocation(wf.Timezone); err != nil {
+			return fmt.Errorf("%w: invalid timezone %q", ErrInvalidSchedule, wf.Timezone)
+		}
+	}
+	if wf.StartDate != nil && wf.EndDate != nil && wf.EndDate.Before(*wf.StartDate) {
+		return fmt.Errorf("%w: end_date is before start_date", ErrInvalidSchedule)
+	}
+	if wf.ScheduleCron == "" {
+		return nil
+	}
+	if _, err := scheduler.WorkflowSchedule(wf); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+	return nil
+}
+
+// validatePauseWindows checks that wf's pause windows have distinct names,
+// a valid start and a duration up to domain.MaxPauseWindow.
+func validatePauseWindows(wf *domain.Workflow) error {
+	seen := make(map[string]bool, len(wf.PauseWindows))
+	for _, w := range wf.PauseWindows {
+		if w.Name == "" || seen[w.Name] {
+			return fmt.Errorf("%w: pause windows need distinct, non-empty names", ErrInvalidWorkflow)
+		}
+		seen[w.Name] = true
+		if w.DurationMinutes <= 0 || w.Duration() > domain.MaxPauseWindow {
+			return fmt.Errorf("%w: pause window %q: duration_minutes must be between 1 and %d", ErrInvalidWorkflow, w.Name, int(domain.MaxPauseWindow.Minutes()))
+		}
+		if !w.Action.Valid() {
+			return fmt.Errorf("%w: pause window %q: unknown action %q", ErrInvalidWorkflow, w.Name, w.Action)
+		}
+		if _, err := scheduler.PauseSchedule(wf, w); err != nil {
+			return fmt.Errorf("%w: pause window %q: %w", ErrInvalidSchedule, w.Name, err)
+		}
+	}
+	return nil
+}
+
 // ListWorkflows returns all workflows. Pagination (offset/limit) is applied
 // in-process because the repository List method returns all records.
 func (s *Service) ListWorkflows(ctx context.Context, offset, limit int) ([]*domain.Workflow, error) {
@@ -70,43 +443,188 @@ func (s *Service) ListWorkflows(ctx context.Context, offset, limit int) ([]*doma
 	return paginate(all, offset, limit), nil
 }
 
-// TriggerWorkflow creates a new WorkflowRun for the given workflow ID.
-func (s *Service) TriggerWorkflow(ctx context.Context, workflowID uuid.UUID) (*domain.WorkflowRun, error) {
-	// Verify the workflow exists.
-	if _, err := s.workflows.GetByID(ctx, workflowID); err != nil {
+// ErrNoSchedule is returned when a schedule preview is requested for a
+// workflow without a ScheduleCron.
+var ErrNoSchedule = errors.New("workflow has no schedule")
+
+// ErrInvalidSchedule is returned when a workflow's ScheduleCron or Timezone
+// cannot be parsed, or its EndDate is before its StartDate.
+var ErrInvalidSchedule = errors.New("invalid workflow schedule")
+
+// MaxNextRuns bounds the number of fire times NextRuns will compute.
+const MaxNextRuns = 100
+
+// NextRunsResult lists upcoming fire times of a workflow's schedule.
+type NextRunsResult struct {
+	WorkflowID   uuid.UUID   `json:"workflow_id"`
+	ScheduleCron string      `json:"schedule_cron"`
+	Timezone     string      `json:"timezone"`
+	NextRuns     []time.Time `json:"next_runs"`
+}
+
+// NextRuns computes the next count fire times of the workflow's schedule,
+// using the same parser CronTrigger uses. count is clamped to [1, MaxNextRuns].
+func (s *Service) NextRuns(ctx context.Context, workflowID uuid.UUID, count int) (*NextRunsResult, error) {
+	wf, err := s.workflows.GetByID(ctx, workflowID)
+	if err != nil {
 		return nil, err
 	}
+	if wf.ScheduleCron == "" {
+		return nil, ErrNoSchedule
+	}
+	if count < 1 {
+		count = 1
+	}
+	if count > MaxNextRuns {
+		count = MaxNextRuns
+	}
+	runs, err := scheduler.NextRuns(wf, time.Now(), count)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	tz := wf.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	return &NextRunsResult{
+		WorkflowID:   wf.ID,
+		ScheduleCron: wf.ScheduleCron,
+		Timezone:     tz,
+		NextRuns:     runs,
+	}, nil
+}
+
+// ErrInvalidLabels is returned when a run label has an empty key.
+var ErrInvalidLabels = errors.New("invalid run labels")
+
+// TriggerInput carries the optional fields of a manual workflow run.
+type TriggerInput struct {
+	// Params are exposed to task templates as {{ .params.name }}.
+	Params map[string]string `json:"params"`
+	// Labels tag the run for filtering the run listings.
+	Labels map[string]string `json:"labels"`
+	// IdempotencyKey, if set, identifies the run: triggering the workflow
+	// again with the same key returns the run it created instead of a new
+	// one, so a client can safely retry a trigger. Its tasks are also
+	// queued with keys derived from it.
+	IdempotencyKey string `json:"idempotency_key"`
+	// By is the caller, recorded as the triggered_by label. It is set by
+	// the handler from the caller's identity, never from the body.
+	By string `json:"-"`
+}
+
+// TriggerWorkflow creates a new WorkflowRun for the given workflow ID. A
+// workflow with MaxActiveRuns runs already pending or running gets a run
+// that waits its turn, a skipped run or ErrMaxActiveRuns, as its
+// OverlapPolicy says. A trigger with the IdempotencyKey of an existing run
+// of the workflow returns that run.
+func (s *Service) TriggerWorkflow(ctx context.Context, workflowID uuid.UUID, in TriggerInput) (*domain.WorkflowRun, error) {
+	labels := make(map[string]string, len(in.Labels)+1)
+	for k, v := range in.Labels {
+		if k == "" {
+			return nil, fmt.Errorf("%w: label keys must not be empty", ErrInvalidLabels)
+		}
+		labels[k] = v
+	}
+	if in.By != "" {
+		labels[domain.LabelTriggeredBy] = in.By
+	}
+	wf, err := s.workflows.GetByID(ctx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	if in.IdempotencyKey != "" {
+		run, err := s.workflowRuns.FindByIdempotencyKey(ctx, workflowID, in.IdempotencyKey)
+		if !errors.Is(err, repository.ErrNotFound) {
+			return run, err
+		}
+	}
 	run := &domain.WorkflowRun{
-		ID:         uuid.New(),
-		WorkflowID: workflowID,
-		Status:     domain.StatusPending,
-		StartedAt:  time.Now().UTC(),
+		ID:             uuid.New(),
+		WorkflowID:     workflowID,
+		Status:         domain.StatusPending,
+		StartedAt:      time.Now().UTC(),
+		Params:         in.Params,
+		Labels:         labels,
+		IdempotencyKey: in.IdempotencyKey,
 	}
-	if err := s.workflowRuns.Create(ctx, run); err != nil {
+	switch p, err := scheduler.Overlapping(ctx, s.workflowRuns, wf); {
+	case err != nil:
+		return nil, err
+	case p == domain.OverlapReject:
+		return nil, fmt.Errorf("%w: %d of %s", ErrMaxActiveRuns, wf.MaxActiveRuns, wf.Name)
+	case p == domain.OverlapSkip:
+		labels[domain.LabelMaxActiveRuns] = "true"
+		run.Status, run.FinishedAt = domain.StatusSkipped, &run.StartedAt
+	}
+	if len(labels) == 0 {
+		run.Labels = nil
+	}
+	if err := s.workflowRuns.Create(ctx, run); errors.Is(err, repository.ErrConflict) {
+		// A concurrent trigger with the same key created the run first.
+		return s.workflowRuns.FindByIdempotencyKey(ctx, workflowID, in.IdempotencyKey)
+	} else if err != nil {
 		return nil, err
 	}
 	return run, nil
 }
 
-// ListWorkflowRuns returns all workflow runs, optionally filtered by status.
-func (s *Service) ListWorkflowRuns(ctx context.Context, status domain.Status) ([]*domain.WorkflowRun, error) {
-	if status != "" {
-		return s.workflowRuns.ListByStatus(ctx, status)
+// ErrInvalidRunFilter is returned when a run listing's date range is empty.
+var ErrInvalidRunFilter = errors.New("invalid workflow run filter")
+
+// ListRunsByWorkflow returns one page of workflowID's runs matching filter,
+// newest first. The workflow must exist.
+func (s *Service) ListRunsByWorkflow(ctx context.Context, workflowID uuid.UUID, filter repository.WorkflowRunFilter) ([]*domain.WorkflowRun, error) {
+	if !filter.StartedFrom.IsZero() && !filter.StartedTo.IsZero() && !filter.StartedFrom.Before(filter.StartedTo) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidRunFilter)
 	}
-	// No status filter — collect runs for all workflows.
-	wfs, err := s.workflows.List(ctx)
+	if _, err := s.workflows.GetByID(ctx, workflowID); err != nil {
+		return nil, err
+	}
+	filter.Offset = max(filter.Offset, 0)
+	runs, err := s.workflowRuns.FindByWorkflowID(ctx, workflowID, filter)
 	if err != nil {
 		return nil, err
 	}
+	if runs == nil {
+		runs = []*domain.WorkflowRun{}
+	}
+	return runs, nil
+}
+
+// ListWorkflowRuns returns all workflow runs, optionally filtered by status
+// and by labels every returned run must carry.
+func (s *Service) ListWorkflowRuns(ctx context.Context, status domain.Status, labels map[string]string) ([]*domain.WorkflowRun, error) {
 	var runs []*domain.WorkflowRun
-	for _, wf := range wfs {
-		r, err := s.workflowRuns.ListByWorkflowID(ctx, wf.ID)
+	if status != "" {
+		var err error
+		if runs, err = s.workflowRuns.ListByStatus(ctx, status); err != nil {
+			return nil, err
+		}
+	} else {
+		// No status filter — collect runs for all workflows.
+		wfs, err := s.workflows.List(ctx)
 		if err != nil {
 			return nil, err
 		}
-		runs = append(runs, r...)
+		for _, wf := range wfs {
+			r, err := s.workflowRuns.ListByWorkflowID(ctx, wf.ID)
+			if err != nil {
+				return nil, err
+			}
+			runs = append(runs, r...)
+		}
 	}
-	return runs, nil
+	if len(labels) == 0 {
+		return runs, nil
+	}
+	matched := runs[:0]
+	for _, wr := range runs {
+		if wr.HasLabels(labels) {
+			matched = append(matched, wr)
+		}
+	}
+	return matched, nil
 }
 
 // ListTaskRuns returns all task runs, optionally filtered by status.
@@ -141,6 +659,62 @@ func (s *Service) ListWorkers(ctx context.Context) ([]*domain.Worker, error) {
 	return s.workers.ListActive(ctx)
 }
 
+// ApprovalInput carries a reviewer's decision on an approval task run.
+type ApprovalInput struct {
+	Decision domain.ApprovalDecision `json:"decision" binding:"required,oneof=approved rejected"`
+	Comment  string                  `json:"comment"`
+}
+
+// DecideApproval records approver's decision on a task run parked in
+// StatusAwaitingApproval and completes the task run accordingly: approved
+// runs succeed, rejected runs fail. Every decision is kept as an audit record.
+// Of concurrent decisions only the first is taken; the others return
+// ErrNotAwaitingApproval. It returns the decision and the task run it settled.
+func (s *Service) DecideApproval(ctx context.Context, taskRunID uuid.UUID, approver string, in ApprovalInput) (*domain.Approval, *domain.TaskRun, error) {
+	if s.approvals == nil {
+		return nil, nil, ErrApprovalsUnavailable
+	}
+	tr, err := s.taskRuns.GetByID(ctx, taskRunID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tr.Status != domain.StatusAwaitingApproval {
+		return nil, nil, ErrNotAwaitingApproval
+	}
+	now := time.Now().UTC()
+	a := &domain.Approval{
+		ID:        uuid.New(),
+		TaskRunID: taskRunID,
+		Decision:  in.Decision,
+		Approver:  approver,
+		Comment:   in.Comment,
+		CreatedAt: now,
+	}
+	status := domain.StatusSuccess
+	if in.Decision == domain.ApprovalRejected {
+		status = domain.StatusFailed
+	}
+	if err := s.approvals.Decide(ctx, a, status); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, nil, ErrNotAwaitingApproval
+		}
+		return nil, nil, err
+	}
+	tr.Status, tr.FinishedAt = status, &now
+	return a, tr, nil
+}
+
+// ListApprovals returns the decision audit trail for a task run.
+func (s *Service) ListApprovals(ctx context.Context, taskRunID uuid.UUID) ([]*domain.Approval, error) {
+	if s.approvals == nil {
+		return nil, ErrApprovalsUnavailable
+	}
+	if _, err := s.taskRuns.GetByID(ctx, taskRunID); err != nil {
+		return nil, err
+	}
+	return s.approvals.ListByTaskRunID(ctx, taskRunID)
+}
+
 // paginate applies offset/limit slicing to a slice; non-positive limit means
 // return all remaining items. A negative offset is treated as zero.
 func paginate[T any](items []T, offset, limit int) []T {
@@ -1,15 +1,23 @@
 package service_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
 	"github.com/sauravritesh63/GoLang-Project-/internal/api/service"
 	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
 	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
 	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
 )
 
 var ctx = context.Background()
@@ -78,6 +86,226 @@ func TestCreateWorkflow_UniqueIDs(t *testing.T) {
 	}
 }
 
+func TestCreateWorkflow_WithTasks(t *testing.T) {
+	tasks := mock.NewTaskRepo()
+	deps := mock.NewTaskDependencyRepo(tasks)
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithTasks(tasks, deps))
+
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{
+		Name: "etl",
+		Tasks: []service.TaskInput{
+			{Name: "extract", Command: "extract.sh"},
+			{Name: "load", Command: "load.sh", DependsOn: []string{"extract"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	list, _ := tasks.ListByWorkflowID(ctx, wf.ID)
+	if len(list) != 2 {
+		t.Fatalf("tasks: got %d, want 2", len(list))
+	}
+	if list[0].Type != domain.TaskTypeCommand || list[0].TriggerRule != domain.TriggerAllSuccess {
+		t.Errorf("defaults not applied: %+v", list[0])
+	}
+	edges, _ := deps.ListByWorkflowID(ctx, wf.ID)
+	if len(edges) != 1 {
+		t.Fatalf("dependencies: got %d, want 1", len(edges))
+	}
+}
+
+func TestCreateWorkflow_InvalidTasks(t *testing.T) {
+	tasks := mock.NewTaskRepo()
+	wfRepo := mock.NewWorkflowRepo()
+	svc := service.New(wfRepo, mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)))
+
+	for name, in := range map[string][]service.TaskInput{
+		"duplicate":    {{Name: "a"}, {Name: "a"}},
+		"unknown dep":  {{Name: "a", DependsOn: []string{"b"}}},
+		"cycle":        {{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"a"}}},
+		"bad rule":     {{Name: "a", TriggerRule: "sometimes"}},
+		"missing name": {{Command: "x"}},
+		"bad trigger":  {{Name: "a", Type: domain.TaskTypeTriggerWorkflow, Command: "not-a-uuid"}},
+		"bad template": {{Name: "a", Command: "etl {{ .ds"}},
+		"bad env":      {{Name: "a", Env: map[string]string{"DS": "{{ .ds"}}},
+		"bad hook":     {{Name: "a", Hooks: []domain.TaskHook{{On: domain.HookOnSuccess, Kind: domain.HookHTTP, URL: "ftp://x"}}}},
+		"hook event":   {{Name: "a", Hooks: []domain.TaskHook{{On: "on_start", Kind: domain.HookEnqueue, Command: "x"}}}},
+		"bad priority": {{Name: "a", Priority: 11}},
+		"retry count":  {{Name: "a", RetryCount: -1}},
+		"multiplier":   {{Name: "a", RetryCount: 2, RetryMultiplier: 0.5}},
+		"max delay":    {{Name: "a", RetryCount: 2, RetryMaxDelaySeconds: -5}},
+		"approval hook": {{Name: "a", Type: domain.TaskTypeApproval,
+			Hooks: []domain.TaskHook{{On: domain.HookOnSuccess, Kind: domain.HookNotify, Notifier: "ops"}}}},
+	} {
+		_, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", Tasks: in})
+		if !errors.Is(err, service.ErrInvalidTasks) {
+			t.Errorf("%s: expected ErrInvalidTasks, got %v", name, err)
+		}
+	}
+	if all, _ := wfRepo.List(ctx); len(all) != 0 {
+		t.Errorf("invalid input must not create a workflow, got %d", len(all))
+	}
+}
+
+func TestCreateWorkflow_TasksUnavailable(t *testing.T) {
+	_, err := newService().CreateWorkflow(ctx, service.CreateWorkflowInput{
+		Name: "wf", Tasks: []service.TaskInput{{Name: "a"}},
+	})
+	if !errors.Is(err, service.ErrTasksUnavailable) {
+		t.Errorf("expected ErrTasksUnavailable, got %v", err)
+	}
+}
+
+func TestCreateWorkflow_TriggerDatasets(t *testing.T) {
+	svc := newService()
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{
+		Name: "wf", TriggerDatasets: []string{"orders"}, DatasetPolicy: domain.DatasetPolicyAny,
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if len(wf.TriggerDatasets) != 1 || wf.DatasetPolicy != domain.DatasetPolicyAny {
+		t.Errorf("unexpected trigger settings: %v %q", wf.TriggerDatasets, wf.DatasetPolicy)
+	}
+
+	_, err = svc.CreateWorkflow(ctx, service.CreateWorkflowInput{
+		Name: "wf", TriggerDatasets: []string{"orders"}, DatasetPolicy: "most",
+	})
+	if !errors.Is(err, service.ErrInvalidWorkflow) {
+		t.Errorf("expected ErrInvalidWorkflow, got %v", err)
+	}
+}
+
+func TestCreateWorkflow_Catchup(t *testing.T) {
+	svc := newService()
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", ScheduleCron: "@hourly", Catchup: domain.CatchupAll})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if wf.Catchup != domain.CatchupAll {
+		t.Errorf("Catchup = %q, want %q", wf.Catchup, domain.CatchupAll)
+	}
+	_, err = svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", ScheduleCron: "@hourly", Catchup: "some"})
+	if !errors.Is(err, service.ErrInvalidWorkflow) {
+		t.Errorf("expected ErrInvalidWorkflow, got %v", err)
+	}
+}
+
+func TestCreateWorkflow_TriggerOnSuccess(t *testing.T) {
+	svc, wfRepo, _, _, _ := newServiceWithRepos()
+	downstream := &domain.Workflow{ID: uuid.New(), Name: "downstream"}
+	_ = wfRepo.Create(ctx, downstream)
+
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "upstream", TriggerOnSuccess: []uuid.UUID{downstream.ID}})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if len(wf.TriggerOnSuccess) != 1 || wf.TriggerOnSuccess[0] != downstream.ID {
+		t.Errorf("TriggerOnSuccess: got %v", wf.TriggerOnSuccess)
+	}
+
+	_, err = svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "upstream", TriggerOnSuccess: []uuid.UUID{uuid.New()}})
+	if !errors.Is(err, service.ErrInvalidWorkflow) {
+		t.Errorf("unknown target: expected ErrInvalidWorkflow, got %v", err)
+	}
+}
+
+func TestCreateWorkflow_MaxParallelTasks(t *testing.T) {
+	svc := newService()
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", MaxParallelTasks: 4})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if wf.MaxParallelTasks != 4 {
+		t.Errorf("MaxParallelTasks: got %d, want 4", wf.MaxParallelTasks)
+	}
+
+	_, err = svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", MaxParallelTasks: -1})
+	if !errors.Is(err, service.ErrInvalidWorkflow) {
+		t.Errorf("expected ErrInvalidWorkflow, got %v", err)
+	}
+}
+
+func TestCreateWorkflow_Retention(t *testing.T) {
+	svc := newService()
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", RetainRuns: 50, RetainDays: 7})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if wf.RetainRuns != 50 || wf.RetainDays != 7 {
+		t.Errorf("retention: got %d runs / %d days, want 50 / 7", wf.RetainRuns, wf.RetainDays)
+	}
+
+	_, err = svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", RetainDays: -1})
+	if !errors.Is(err, service.ErrInvalidWorkflow) {
+		t.Errorf("expected ErrInvalidWorkflow, got %v", err)
+	}
+}
+
+func TestCreateWorkflow_WorkerGroup(t *testing.T) {
+	svc := newService()
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", WorkerGroup: "team-ml"})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if wf.WorkerGroup != "team-ml" {
+		t.Errorf("WorkerGroup: got %q, want team-ml", wf.WorkerGroup)
+	}
+
+	_, err = svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", WorkerGroup: "team ml"})
+	if !errors.Is(err, service.ErrInvalidWorkflow) {
+		t.Errorf("expected ErrInvalidWorkflow, got %v", err)
+	}
+}
+
+func TestCreateWorkflow_PauseWindows(t *testing.T) {
+	svc := newService()
+	maint := domain.PauseWindow{Name: "maintenance", Start: "0 0 * * 0", DurationMinutes: 240, Action: domain.PauseDelay}
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", Timezone: "Europe/Berlin", PauseWindows: []domain.PauseWindow{maint}})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if len(wf.PauseWindows) != 1 || wf.PauseWindows[0] != maint {
+		t.Errorf("PauseWindows: got %+v, want [%+v]", wf.PauseWindows, maint)
+	}
+
+	for name, tc := range map[string]struct {
+		windows []domain.PauseWindow
+		want    error
+	}{
+		"duplicate": {[]domain.PauseWindow{maint, maint}, service.ErrInvalidWorkflow},
+		"unnamed":   {[]domain.PauseWindow{{Start: "@daily", DurationMinutes: 60}}, service.ErrInvalidWorkflow},
+		"duration":  {[]domain.PauseWindow{{Name: "w", Start: "@daily", DurationMinutes: 8 * 24 * 60}}, service.ErrInvalidWorkflow},
+		"action":    {[]domain.PauseWindow{{Name: "w", Start: "@daily", DurationMinutes: 60, Action: "drop"}}, service.ErrInvalidWorkflow},
+		"start":     {[]domain.PauseWindow{{Name: "w", Start: "0 25 * * *", DurationMinutes: 60}}, service.ErrInvalidSchedule},
+	} {
+		_, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", PauseWindows: tc.windows})
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", name, err, tc.want)
+		}
+	}
+}
+
+func TestCreateWorkflow_StartAndEndDates(t *testing.T) {
+	svc := newService()
+	start := time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 10)
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", ScheduleCron: "@hourly", StartDate: &start, EndDate: &end})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if wf.StartDate == nil || !wf.StartDate.Equal(start) || wf.EndDate == nil || !wf.EndDate.Equal(end) {
+		t.Errorf("dates: got %v – %v, want %s – %s", wf.StartDate, wf.EndDate, start, end)
+	}
+
+	_, err = svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "backwards", ScheduleCron: "@hourly", StartDate: &end, EndDate: &start})
+	if !errors.Is(err, service.ErrInvalidSchedule) {
+		t.Errorf("end before start: got %v, want ErrInvalidSchedule", err)
+	}
+}
+
 // ── ListWorkflows ─────────────────────────────────────────────────────────────
 
 func TestListWorkflows_Empty(t *testing.T) {
@@ -154,7 +382,7 @@ func TestTriggerWorkflow_Success(t *testing.T) {
 	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", CreatedAt: time.Now().UTC()}
 	_ = wfRepo.Create(ctx, wf)
 
-	run, err := svc.TriggerWorkflow(ctx, wf.ID)
+	run, err := svc.TriggerWorkflow(ctx, wf.ID, service.TriggerInput{})
 	if err != nil {
 		t.Fatalf("TriggerWorkflow: %v", err)
 	}
@@ -172,9 +400,31 @@ func TestTriggerWorkflow_Success(t *testing.T) {
 	}
 }
 
+func TestTriggerWorkflow_IdempotencyKeyReturnsExistingRun(t *testing.T) {
+	svc, wfRepo, _, _, _ := newServiceWithRepos()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(ctx, wf)
+
+	run, err := svc.TriggerWorkflow(ctx, wf.ID, service.TriggerInput{IdempotencyKey: "nightly-42"})
+	if err != nil {
+		t.Fatalf("TriggerWorkflow: %v", err)
+	}
+	if run.IdempotencyKey != "nightly-42" {
+		t.Errorf("IdempotencyKey: got %q, want nightly-42", run.IdempotencyKey)
+	}
+	again, err := svc.TriggerWorkflow(ctx, wf.ID, service.TriggerInput{IdempotencyKey: "nightly-42"})
+	if err != nil || again.ID != run.ID {
+		t.Errorf("retried trigger: got %v, %v; want run %s", again, err, run.ID)
+	}
+	other, err := svc.TriggerWorkflow(ctx, wf.ID, service.TriggerInput{IdempotencyKey: "nightly-43"})
+	if err != nil || other.ID == run.ID {
+		t.Errorf("trigger with another key: got %v, %v; want a new run", other, err)
+	}
+}
+
 func TestTriggerWorkflow_NotFound(t *testing.T) {
 	svc := newService()
-	_, err := svc.TriggerWorkflow(ctx, uuid.New())
+	_, err := svc.TriggerWorkflow(ctx, uuid.New(), service.TriggerInput{})
 	if err == nil {
 		t.Fatal("expected error for non-existent workflow, got nil")
 	}
@@ -183,16 +433,243 @@ func TestTriggerWorkflow_NotFound(t *testing.T) {
 	}
 }
 
+func TestTriggerWorkflow_Labels(t *testing.T) {
+	svc, wfRepo, _, _, _ := newServiceWithRepos()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(ctx, wf)
+
+	// The caller's identity wins over a triggered_by label in the body.
+	run, err := svc.TriggerWorkflow(ctx, wf.ID, service.TriggerInput{
+		Labels: map[string]string{"env": "prod", domain.LabelTriggeredBy: "mallory"},
+		By:     "alice",
+	})
+	if err != nil {
+		t.Fatalf("TriggerWorkflow: %v", err)
+	}
+	if run.Labels["env"] != "prod" || run.Labels[domain.LabelTriggeredBy] != "alice" {
+		t.Errorf("Labels: got %v, want env=prod and triggered_by=alice", run.Labels)
+	}
+
+	_, err = svc.TriggerWorkflow(ctx, wf.ID, service.TriggerInput{Labels: map[string]string{"": "x"}})
+	if !errors.Is(err, service.ErrInvalidLabels) {
+		t.Errorf("empty key: expected ErrInvalidLabels, got %v", err)
+	}
+}
+
+func TestTriggerWorkflow_MaxActiveRuns(t *testing.T) {
+	svc, wfRepo, _, _, _ := newServiceWithRepos()
+	for _, policy := range []domain.OverlapPolicy{domain.OverlapQueue, domain.OverlapSkip, domain.OverlapReject} {
+		wf := &domain.Workflow{ID: uuid.New(), Name: string(policy), MaxActiveRuns: 1, OverlapPolicy: policy}
+		_ = wfRepo.Create(ctx, wf)
+		if run, err := svc.TriggerWorkflow(ctx, wf.ID, service.TriggerInput{}); err != nil || run.Status != domain.StatusPending {
+			t.Fatalf("%s: first run: got %+v, %v; want pending", policy, run, err)
+		}
+		run, err := svc.TriggerWorkflow(ctx, wf.ID, service.TriggerInput{})
+		switch policy {
+		case domain.OverlapQueue:
+			if err != nil || run.Status != domain.StatusPending {
+				t.Errorf("queue: got %+v, %v; want a pending run waiting its turn", run, err)
+			}
+		case domain.OverlapSkip:
+			if err != nil || run.Status != domain.StatusSkipped || run.Labels[domain.LabelMaxActiveRuns] != "true" {
+				t.Errorf("skip: got %+v, %v; want a skipped run labelled max_active_runs", run, err)
+			}
+		case domain.OverlapReject:
+			if !errors.Is(err, service.ErrMaxActiveRuns) {
+				t.Errorf("reject: got %+v, %v; want ErrMaxActiveRuns", run, err)
+			}
+		}
+	}
+
+	_, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "bad", MaxActiveRuns: 1, OverlapPolicy: "wait"})
+	if !errors.Is(err, service.ErrInvalidWorkflow) {
+		t.Errorf("unknown policy: got %v, want ErrInvalidWorkflow", err)
+	}
+}
+
+// ── PauseWorkflow ─────────────────────────────────────────────────────────────
+
+func TestPauseWorkflow(t *testing.T) {
+	svc, wfRepo, _, _, _ := newServiceWithRepos()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", IsActive: true, CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(ctx, wf)
+
+	paused, err := svc.PauseWorkflow(ctx, wf.ID, "alice")
+	if err != nil {
+		t.Fatalf("PauseWorkflow: %v", err)
+	}
+	if !paused.Paused || paused.PausedBy != "alice" || paused.PausedAt == nil || !paused.IsActive {
+		t.Errorf("paused workflow: got %+v, want paused by alice and still active", paused)
+	}
+	// Pausing again keeps the original pause.
+	again, _ := svc.PauseWorkflow(ctx, wf.ID, "bob")
+	if again.PausedBy != "alice" {
+		t.Errorf("second pause: PausedBy got %q, want alice", again.PausedBy)
+	}
+
+	resumed, err := svc.ResumeWorkflow(ctx, wf.ID)
+	if err != nil {
+		t.Fatalf("ResumeWorkflow: %v", err)
+	}
+	if stored, _ := wfRepo.GetByID(ctx, wf.ID); stored.Paused || stored.PausedBy != "" || stored.PausedAt != nil || resumed.Paused {
+		t.Errorf("resumed workflow: got %+v, want the pause cleared", stored)
+	}
+
+	if _, err := svc.PauseWorkflow(ctx, uuid.New(), "alice"); !isErrNotFound(err) {
+		t.Errorf("unknown workflow: expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestWorkflowChangedEvents verifies creating, pausing and resuming a
+// workflow each announce the change on the bus, for cron triggers to
+// reload.
+func TestWorkflowChangedEvents(t *testing.T) {
+	bus := events.NewMemBus()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithEvents(bus))
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sub, _ := bus.Subscribe(sctx)
+
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", ScheduleCron: "0 * * * *"})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	_, _ = svc.PauseWorkflow(ctx, wf.ID, "alice")
+	_, _ = svc.ResumeWorkflow(ctx, wf.ID)
+
+	for _, want := range []string{service.WorkflowCreated, service.WorkflowPaused, service.WorkflowResumed} {
+		select {
+		case e := <-sub:
+			var c events.WorkflowChange
+			if e.Type != events.WorkflowChanged || e.DecodePayload(&c) != nil || c.WorkflowID != wf.ID.String() || c.Action != want {
+				t.Errorf("got %s %+v, want workflow_changed %s", e.Type, e.Payload, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %s event", want)
+		}
+	}
+}
+
+// ── SetRunTimeout ─────────────────────────────────────────────────────────────
+
+func TestSetRunTimeout(t *testing.T) {
+	svc, wfRepo, _, _, _ := newServiceWithRepos()
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", RunTimeoutSeconds: 3600})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+
+	got, err := svc.SetRunTimeout(ctx, wf.ID, service.RunTimeoutInput{RunTimeoutSeconds: 600, RunTimeoutPolicy: domain.RunTimeoutAlert})
+	if err != nil {
+		t.Fatalf("SetRunTimeout: %v", err)
+	}
+	if stored, _ := wfRepo.GetByID(ctx, wf.ID); stored.RunTimeoutSeconds != 600 || stored.RunTimeoutPolicy != domain.RunTimeoutAlert || got.RunTimeoutSeconds != 600 {
+		t.Errorf("stored workflow: got %d %q, want 600 alert", stored.RunTimeoutSeconds, stored.RunTimeoutPolicy)
+	}
+
+	for name, in := range map[string]service.RunTimeoutInput{
+		"negative": {RunTimeoutSeconds: -1},
+		"policy":   {RunTimeoutSeconds: 60, RunTimeoutPolicy: "retry"},
+	} {
+		if _, err := svc.SetRunTimeout(ctx, wf.ID, in); !errors.Is(err, service.ErrInvalidWorkflow) {
+			t.Errorf("%s: expected ErrInvalidWorkflow, got %v", name, err)
+		}
+	}
+	if _, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf2", RunTimeoutPolicy: "ignore"}); !errors.Is(err, service.ErrInvalidWorkflow) {
+		t.Errorf("create with unknown policy: expected ErrInvalidWorkflow, got %v", err)
+	}
+	if _, err := svc.SetRunTimeout(ctx, uuid.New(), service.RunTimeoutInput{}); !isErrNotFound(err) {
+		t.Errorf("unknown workflow: expected ErrNotFound, got %v", err)
+	}
+}
+
+// ── SetWorkflowPriority ───────────────────────────────────────────────────────
+
+func TestSetWorkflowPriority(t *testing.T) {
+	svc, wfRepo, _, _, _ := newServiceWithRepos()
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf", Priority: 3})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+
+	if _, err := svc.SetWorkflowPriority(ctx, wf.ID, service.PriorityInput{Priority: 10}); err != nil {
+		t.Fatalf("SetWorkflowPriority: %v", err)
+	}
+	if stored, _ := wfRepo.GetByID(ctx, wf.ID); stored.Priority != 10 {
+		t.Errorf("stored priority: got %d, want 10", stored.Priority)
+	}
+	for _, p := range []int{-1, 11} {
+		if _, err := svc.SetWorkflowPriority(ctx, wf.ID, service.PriorityInput{Priority: p}); !errors.Is(err, service.ErrInvalidWorkflow) {
+			t.Errorf("priority %d: expected ErrInvalidWorkflow, got %v", p, err)
+		}
+	}
+	if _, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "wf2", Priority: 12}); !errors.Is(err, service.ErrInvalidWorkflow) {
+		t.Errorf("create with priority 12: expected ErrInvalidWorkflow, got %v", err)
+	}
+	if _, err := svc.SetWorkflowPriority(ctx, uuid.New(), service.PriorityInput{}); !isErrNotFound(err) {
+		t.Errorf("unknown workflow: expected ErrNotFound, got %v", err)
+	}
+}
+
 // isErrNotFound checks whether err is the repository.ErrNotFound sentinel.
 func isErrNotFound(err error) bool {
 	return err == repository.ErrNotFound
 }
 
+// ── FireEvent ─────────────────────────────────────────────────────────────────
+
+func TestFireEvent_TriggersSubscribedWorkflows(t *testing.T) {
+	svc := newService()
+	create := func(name string, active bool, events ...string) *domain.Workflow {
+		wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: name, IsActive: active, TriggerEvents: events})
+		if err != nil {
+			t.Fatalf("CreateWorkflow %s: %v", name, err)
+		}
+		return wf
+	}
+	ingest := create("ingest", true, "s3:object-created")
+	audit := create("audit", true, "user.deleted", "s3:object-created")
+	create("paused", false, "s3:object-created")
+	create("other", true, "user.deleted")
+
+	res, err := svc.FireEvent(ctx, "s3:object-created", service.EventInput{
+		Payload: map[string]any{"key": "orders/2026-10-16.csv", "size": 1024, "tags": []string{"raw"}},
+		By:      "uploader",
+	})
+	if err != nil {
+		t.Fatalf("FireEvent: %v", err)
+	}
+	if len(res.Runs) != 2 || res.Runs[0].WorkflowID != audit.ID || res.Runs[1].WorkflowID != ingest.ID {
+		t.Fatalf("runs: got %+v, want one each of audit and ingest", res.Runs)
+	}
+	run := res.Runs[1]
+	want := map[string]string{"key": "orders/2026-10-16.csv", "size": "1024", "tags": `["raw"]`}
+	for k, v := range want {
+		if run.Params[k] != v {
+			t.Errorf("param %s: got %q, want %q", k, run.Params[k], v)
+		}
+	}
+	if run.Labels[domain.LabelEvent] != "s3:object-created" || run.Labels[domain.LabelTriggeredBy] != "uploader" {
+		t.Errorf("labels: got %v", run.Labels)
+	}
+
+	if res, err := svc.FireEvent(ctx, "nobody.listens", service.EventInput{}); err != nil || len(res.Runs) != 0 {
+		t.Errorf("unsubscribed event: got %+v, %v; want no runs", res, err)
+	}
+	if _, err := svc.FireEvent(ctx, "no spaces", service.EventInput{}); !errors.Is(err, service.ErrInvalidEvent) {
+		t.Errorf("invalid name: got %v, want ErrInvalidEvent", err)
+	}
+	if _, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "bad", TriggerEvents: []string{"a/b"}}); !errors.Is(err, service.ErrInvalidWorkflow) {
+		t.Errorf("invalid trigger event: got %v, want ErrInvalidWorkflow", err)
+	}
+}
+
 // ── ListWorkflowRuns ──────────────────────────────────────────────────────────
 
 func TestListWorkflowRuns_Empty(t *testing.T) {
 	svc := newService()
-	runs, err := svc.ListWorkflowRuns(ctx, "")
+	runs, err := svc.ListWorkflowRuns(ctx, "", nil)
 	if err != nil {
 		t.Fatalf("ListWorkflowRuns: %v", err)
 	}
@@ -214,7 +691,7 @@ func TestListWorkflowRuns_ByStatus(t *testing.T) {
 	_ = wrRepo.Create(ctx, pending)
 	_ = wrRepo.Create(ctx, running)
 
-	runs, err := svc.ListWorkflowRuns(ctx, domain.StatusPending)
+	runs, err := svc.ListWorkflowRuns(ctx, domain.StatusPending, nil)
 	if err != nil {
 		t.Fatalf("ListWorkflowRuns: %v", err)
 	}
@@ -233,7 +710,7 @@ func TestListWorkflowRuns_All(t *testing.T) {
 	_ = wrRepo.Create(ctx, &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusPending, StartedAt: time.Now().UTC()})
 	_ = wrRepo.Create(ctx, &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusRunning, StartedAt: time.Now().UTC()})
 
-	runs, err := svc.ListWorkflowRuns(ctx, "")
+	runs, err := svc.ListWorkflowRuns(ctx, "", nil)
 	if err != nil {
 		t.Fatalf("ListWorkflowRuns: %v", err)
 	}
@@ -242,6 +719,31 @@ func TestListWorkflowRuns_All(t *testing.T) {
 	}
 }
 
+func TestListWorkflowRuns_ByLabels(t *testing.T) {
+	svc, wfRepo, wrRepo, _, _ := newServiceWithRepos()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "wf", CreatedAt: time.Now().UTC()}
+	_ = wfRepo.Create(ctx, wf)
+	for _, labels := range []map[string]string{
+		{domain.LabelBackfill: "true", "env": "prod"},
+		{domain.LabelBackfill: "true", "env": "dev"},
+		nil,
+	} {
+		_ = wrRepo.Create(ctx, &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusPending, StartedAt: time.Now().UTC(), Labels: labels})
+	}
+
+	runs, err := svc.ListWorkflowRuns(ctx, "", map[string]string{domain.LabelBackfill: "true"})
+	if err != nil {
+		t.Fatalf("ListWorkflowRuns: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Errorf("backfill=true: expected 2 runs, got %d", len(runs))
+	}
+	runs, _ = svc.ListWorkflowRuns(ctx, domain.StatusPending, map[string]string{domain.LabelBackfill: "true", "env": "prod"})
+	if len(runs) != 1 || runs[0].Labels["env"] != "prod" {
+		t.Errorf("backfill=true,env=prod: got %d runs, want the prod one", len(runs))
+	}
+}
+
 // ── ListTaskRuns ──────────────────────────────────────────────────────────────
 
 func TestListTaskRuns_Empty(t *testing.T) {
@@ -298,6 +800,773 @@ func TestListTaskRuns_All(t *testing.T) {
 	}
 }
 
+// ── ClearTaskRun ──────────────────────────────────────────────────────────────
+
+func TestClearTaskRun_Downstream(t *testing.T) {
+	tasks := mock.NewTaskRepo()
+	wrRepo, trRepo := mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo()
+	svc := service.New(mock.NewWorkflowRepo(), wrRepo, trRepo, mock.NewWorkerRepo(),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)))
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{
+		Name: "etl",
+		Tasks: []service.TaskInput{
+			{Name: "extract", Command: "extract.sh"},
+			{Name: "transform", Command: "transform.sh", DependsOn: []string{"extract"}},
+			{Name: "load", Command: "load.sh", DependsOn: []string{"transform"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	byName := map[string]uuid.UUID{}
+	list, _ := tasks.ListByWorkflowID(ctx, wf.ID)
+	for _, task := range list {
+		byName[task.Name] = task.ID
+	}
+	now := time.Now().UTC()
+	wr := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusFailed, StartedAt: now, FinishedAt: &now}
+	_ = wrRepo.Create(ctx, wr)
+	first := &domain.TaskRun{ID: uuid.New(), WorkflowRunID: wr.ID, TaskID: byName["extract"], Status: domain.StatusFailed, Attempt: 1, StartedAt: now}
+	_ = trRepo.Create(ctx, first)
+	_ = trRepo.Create(ctx, &domain.TaskRun{ID: uuid.New(), WorkflowRunID: wr.ID, TaskID: byName["extract"], Status: domain.StatusFailed, Attempt: 2, StartedAt: now})
+	_ = trRepo.Create(ctx, &domain.TaskRun{ID: uuid.New(), WorkflowRunID: wr.ID, TaskID: byName["transform"], Status: domain.StatusSkipped, Attempt: 1, StartedAt: now})
+
+	cleared, err := svc.ClearTaskRun(ctx, first.ID, true)
+	if err != nil {
+		t.Fatalf("ClearTaskRun: %v", err)
+	}
+	// load never ran, so only extract and transform get a new attempt.
+	if len(cleared) != 2 {
+		t.Fatalf("cleared: got %d task runs, want 2", len(cleared))
+	}
+	if cleared[0].TaskID != byName["extract"] || cleared[0].Attempt != 3 || cleared[0].Status != domain.StatusPending {
+		t.Errorf("extract: got %+v, want pending attempt 3", cleared[0])
+	}
+	if cleared[1].TaskID != byName["transform"] || cleared[1].Attempt != 2 {
+		t.Errorf("transform: got %+v, want attempt 2", cleared[1])
+	}
+	if all, _ := trRepo.ListByWorkflowRunID(ctx, wr.ID); len(all) != 5 {
+		t.Errorf("task runs: got %d, want earlier attempts kept (5)", len(all))
+	}
+	got, _ := wrRepo.GetByID(ctx, wr.ID)
+	if got.Status != domain.StatusRunning || got.FinishedAt != nil {
+		t.Errorf("run: got %q (finished %v), want running", got.Status, got.FinishedAt)
+	}
+}
+
+func TestClearTaskRun_RejectsUnfinishedTask(t *testing.T) {
+	tasks := mock.NewTaskRepo()
+	wrRepo, trRepo := mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo()
+	svc := service.New(mock.NewWorkflowRepo(), wrRepo, trRepo, mock.NewWorkerRepo(),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)))
+	wr := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: uuid.New(), Status: domain.StatusRunning, StartedAt: time.Now().UTC()}
+	_ = wrRepo.Create(ctx, wr)
+	tr := &domain.TaskRun{ID: uuid.New(), WorkflowRunID: wr.ID, TaskID: uuid.New(), Status: domain.StatusRunning, Attempt: 1, StartedAt: time.Now().UTC()}
+	_ = trRepo.Create(ctx, tr)
+
+	if _, err := svc.ClearTaskRun(ctx, tr.ID, false); !errors.Is(err, service.ErrTaskRunActive) {
+		t.Fatalf("got %v, want ErrTaskRunActive", err)
+	}
+	if _, err := svc.ClearTaskRun(ctx, uuid.New(), false); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("unknown task run: got %v, want ErrNotFound", err)
+	}
+}
+
+// ── SimulateWorkflow ──────────────────────────────────────────────────────────
+
+func TestSimulateWorkflow_TimelineAndCriticalPath(t *testing.T) {
+	tasks := mock.NewTaskRepo()
+	trRepo := mock.NewTaskRunRepo()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), trRepo, mock.NewWorkerRepo(),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)))
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{
+		Name: "etl",
+		Tasks: []service.TaskInput{
+			{Name: "extract", Command: "extract.sh"},
+			{Name: "fast", Command: "fast.sh", DependsOn: []string{"extract"}},
+			{Name: "slow", Command: "slow.sh", DependsOn: []string{"extract"}},
+			{Name: "load", Command: "load.sh", DependsOn: []string{"fast", "slow"}},
+			{Name: "alert", Command: "page.sh", DependsOn: []string{"load"}, TriggerRule: domain.TriggerOneFailed},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	byName := map[string]uuid.UUID{}
+	list, _ := tasks.ListByWorkflowID(ctx, wf.ID)
+	for _, task := range list {
+		byName[task.Name] = task.ID
+	}
+	// extract took 10s, 20s and 30s; a failed attempt does not count.
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, secs := range []int{10, 30, 20} {
+		started := base.Add(time.Duration(i) * time.Hour)
+		finished := started.Add(time.Duration(secs) * time.Second)
+		_ = trRepo.Create(ctx, &domain.TaskRun{ID: uuid.New(), TaskID: byName["extract"], Status: domain.StatusSuccess, StartedAt: started, FinishedAt: &finished})
+	}
+	failedAt := base.Add(time.Hour)
+	_ = trRepo.Create(ctx, &domain.TaskRun{ID: uuid.New(), TaskID: byName["extract"], Status: domain.StatusFailed, StartedAt: base, FinishedAt: &failedAt})
+
+	start := time.Date(2026, 2, 1, 6, 0, 0, 0, time.UTC)
+	sim, err := svc.SimulateWorkflow(ctx, wf.ID, service.SimulateInput{
+		Start:          start,
+		Estimates:      map[string]float64{"fast": 5, "slow": 60},
+		DefaultSeconds: 1,
+	})
+	if err != nil {
+		t.Fatalf("SimulateWorkflow: %v", err)
+	}
+	// extract 0–20, fast 20–25, slow 20–80, load 80–81; alert is skipped.
+	if sim.DurationSeconds != 81 || !sim.End.Equal(start.Add(81*time.Second)) {
+		t.Errorf("duration: got %vs ending %v, want 81s", sim.DurationSeconds, sim.End)
+	}
+	want := []string{"extract", "slow", "load"}
+	if len(sim.CriticalPath) != len(want) {
+		t.Fatalf("critical path: got %v, want %v", sim.CriticalPath, want)
+	}
+	for i := range want {
+		if sim.CriticalPath[i] != want[i] {
+			t.Fatalf("critical path: got %v, want %v", sim.CriticalPath, want)
+		}
+	}
+	got := map[string]service.SimulatedTask{}
+	for _, st := range sim.Tasks {
+		got[st.Name] = st
+	}
+	if e := got["extract"]; e.Source != service.DurationHistory || e.Samples != 3 || e.DurationSeconds != 20 || !e.Critical {
+		t.Errorf("extract: got %+v, want critical, median of 3 runs (20s)", e)
+	}
+	if f := got["fast"]; f.Source != service.DurationEstimate || f.StartOffset != 20 || f.Critical {
+		t.Errorf("fast: got %+v, want estimate starting at 20s, not critical", f)
+	}
+	if l := got["load"]; l.Source != service.DurationDefault || l.StartOffset != 80 {
+		t.Errorf("load: got %+v, want default duration starting at 80s", l)
+	}
+	if a := got["alert"]; !a.Skipped || a.Start != nil {
+		t.Errorf("alert: got %+v, want skipped", a)
+	}
+	if last := sim.Tasks[len(sim.Tasks)-1]; last.Name != "alert" {
+		t.Errorf("skipped tasks should come last, got %q", last.Name)
+	}
+}
+
+func TestSimulateWorkflow_MaxParallelTasks(t *testing.T) {
+	tasks := mock.NewTaskRepo()
+	wfRepo := mock.NewWorkflowRepo()
+	svc := service.New(wfRepo, mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)))
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{
+		Name:             "fan-out",
+		MaxParallelTasks: 1,
+		Tasks: []service.TaskInput{
+			{Name: "a", Command: "a.sh"},
+			{Name: "b", Command: "b.sh"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	sim, err := svc.SimulateWorkflow(ctx, wf.ID, service.SimulateInput{Estimates: map[string]float64{"a": 10, "b": 10}})
+	if err != nil {
+		t.Fatalf("SimulateWorkflow: %v", err)
+	}
+	// One slot: the second task waits for the first.
+	if sim.DurationSeconds != 20 || len(sim.CriticalPath) != 2 {
+		t.Errorf("got %vs via %v, want 20s through both tasks", sim.DurationSeconds, sim.CriticalPath)
+	}
+}
+
+func TestSimulateWorkflow_Errors(t *testing.T) {
+	if _, err := newService().SimulateWorkflow(ctx, uuid.New(), service.SimulateInput{}); !errors.Is(err, service.ErrTasksUnavailable) {
+		t.Errorf("without tasks: got %v, want ErrTasksUnavailable", err)
+	}
+	tasks := mock.NewTaskRepo()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)))
+	if _, err := svc.SimulateWorkflow(ctx, uuid.New(), service.SimulateInput{}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("unknown workflow: got %v, want ErrNotFound", err)
+	}
+	wf, _ := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "x", Tasks: []service.TaskInput{{Name: "a", Command: "a.sh"}}})
+	for name, in := range map[string]service.SimulateInput{
+		"unknown task":      {Estimates: map[string]float64{"nope": 1}},
+		"negative estimate": {Estimates: map[string]float64{"a": -1}},
+		"negative default":  {DefaultSeconds: -1},
+	} {
+		if _, err := svc.SimulateWorkflow(ctx, wf.ID, in); !errors.Is(err, service.ErrInvalidSimulation) {
+			t.Errorf("%s: got %v, want ErrInvalidSimulation", name, err)
+		}
+	}
+}
+
+// ── RunCalendar ───────────────────────────────────────────────────────────────
+
+func TestRunCalendar_BucketsRunsAndSchedules(t *testing.T) {
+	svc, wfRepo, wrRepo, _, _ := newServiceWithRepos()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := today.AddDate(0, 0, -1)
+	etl := &domain.Workflow{ID: uuid.New(), Name: "etl", CreatedAt: today}
+	nightly := &domain.Workflow{ID: uuid.New(), Name: "nightly", ScheduleCron: "0 6 * * *", IsActive: true, CreatedAt: today}
+	_ = wfRepo.Create(ctx, etl)
+	_ = wfRepo.Create(ctx, nightly)
+	for _, run := range []struct {
+		started time.Time
+		status  domain.Status
+	}{
+		{yesterday.Add(time.Hour), domain.StatusSuccess},
+		{yesterday.Add(2 * time.Hour), domain.StatusFailed},
+		{today.Add(time.Minute), domain.StatusRunning},
+		{today.AddDate(0, 0, -10), domain.StatusSuccess}, // outside the range
+	} {
+		_ = wrRepo.Create(ctx, &domain.WorkflowRun{ID: uuid.New(), WorkflowID: etl.ID, Status: run.status, StartedAt: run.started})
+	}
+
+	cal, err := svc.RunCalendar(ctx, yesterday, today.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatalf("RunCalendar: %v", err)
+	}
+	if len(cal.Workflows) != 2 {
+		t.Fatalf("workflows: got %d, want 2", len(cal.Workflows))
+	}
+	for _, row := range cal.Workflows {
+		switch row.WorkflowID {
+		case etl.ID:
+			want := []service.CalendarDay{
+				{Date: yesterday.Format(domain.CalendarDateLayout), Succeeded: 1, Failed: 1},
+				{Date: today.Format(domain.CalendarDateLayout), Running: 1},
+			}
+			if len(row.Days) != len(want) || row.Days[0] != want[0] || row.Days[1] != want[1] {
+				t.Errorf("etl: got %+v, want %+v", row.Days, want)
+			}
+		case nightly.ID:
+			// Three days ahead always fire; today's 06:00 may have passed.
+			scheduled := 0
+			for _, d := range row.Days {
+				scheduled += d.Scheduled
+			}
+			if scheduled < 3 || scheduled > 4 {
+				t.Errorf("nightly: got %d scheduled runs, want 3 or 4", scheduled)
+			}
+		}
+	}
+}
+
+func TestRunCalendar_InvalidRange(t *testing.T) {
+	svc := newService()
+	now := time.Now()
+	if _, err := svc.RunCalendar(ctx, now, now.AddDate(0, 0, -1)); !errors.Is(err, service.ErrInvalidRunCalendar) {
+		t.Errorf("reversed range: got %v, want ErrInvalidRunCalendar", err)
+	}
+	if _, err := svc.RunCalendar(ctx, now, now.AddDate(0, 0, service.MaxRunCalendarDays)); !errors.Is(err, service.ErrInvalidRunCalendar) {
+		t.Errorf("too long: got %v, want ErrInvalidRunCalendar", err)
+	}
+}
+
+// ── UsageReport ───────────────────────────────────────────────────────────────
+
+func TestUsageReport_TotalsPerTenantWorkflowAndClass(t *testing.T) {
+	wfRepo, wrRepo, trRepo, tasks := mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewTaskRepo()
+	svc := service.New(wfRepo, wrRepo, trRepo, mock.NewWorkerRepo(),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)),
+		service.WithStats(mock.NewStatsRepo(wrRepo, trRepo, tasks)),
+		service.WithUsageRates(map[string]float64{"gpu": 2, "*": 0.5}))
+
+	etl := &domain.Workflow{ID: uuid.New(), Name: "etl", Tenant: "data"}
+	train := &domain.Workflow{ID: uuid.New(), Name: "train", Tenant: "ml"}
+	_ = wfRepo.Create(ctx, etl)
+	_ = wfRepo.Create(ctx, train)
+	extract := &domain.Task{ID: uuid.New(), WorkflowID: etl.ID, Name: "extract", Type: domain.TaskTypeCommand}
+	fit := &domain.Task{ID: uuid.New(), WorkflowID: train.ID, Name: "fit", Type: domain.TaskTypeCommand, Queue: "gpu"}
+	_ = tasks.Create(ctx, extract)
+	_ = tasks.Create(ctx, fit)
+
+	day := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	// execute saves a task run of task dispatched at the given hour of the
+	// day that ran for secs seconds, or is still running if secs is zero.
+	execute := func(wf *domain.Workflow, task *domain.Task, hour, secs int) {
+		run := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusRunning, StartedAt: day}
+		_ = wrRepo.Create(ctx, run)
+		dispatched := day.Add(time.Duration(hour) * time.Hour)
+		tr := &domain.TaskRun{ID: uuid.New(), WorkflowRunID: run.ID, TaskID: task.ID, Status: domain.StatusRunning, StartedAt: dispatched, DispatchedAt: &dispatched}
+		if secs > 0 {
+			finished := dispatched.Add(time.Duration(secs) * time.Second)
+			tr.Status, tr.FinishedAt = domain.StatusSuccess, &finished
+		}
+		_ = trRepo.Create(ctx, tr)
+	}
+	execute(etl, extract, 1, 60)
+	execute(etl, extract, 2, 30)
+	execute(etl, extract, 26, 10) // the next day
+	execute(etl, extract, 3, 0)   // unfinished
+	execute(train, fit, 4, 100)
+	execute(train, fit, -2, 100) // the day before the range
+
+	report, err := svc.UsageReport(ctx, day, day.AddDate(0, 0, 1), "")
+	if err != nil {
+		t.Fatalf("UsageReport: %v", err)
+	}
+	if report.From != "2025-06-02" || report.To != "2025-06-03" || len(report.Tenants) != 2 {
+		t.Fatalf("report = %+v, want the data and ml tenants from 2025-06-02 to 2025-06-03", report)
+	}
+	data, ml := report.Tenants[0], report.Tenants[1]
+	if data.Tenant != "data" || data.TaskRuns != 3 || data.ExecutionSeconds != 100 || data.Cost == nil || *data.Cost != 50 {
+		t.Errorf("data tenant = %+v, want 3 task runs, 100s costing 50", data)
+	}
+	if len(data.Workflows) != 1 || len(data.Workflows[0].Days) != 2 ||
+		data.Workflows[0].Days[0].Date != "2025-06-02" || data.Workflows[0].Days[0].ExecutionSeconds != 90 ||
+		data.Workflows[0].Days[0].ResourceClass != service.DefaultResourceClass {
+		t.Errorf("etl usage = %+v, want 90s of default on 2025-06-02 and 10s the next day", data.Workflows)
+	}
+	if ml.Tenant != "ml" || ml.TaskRuns != 1 || ml.Workflows[0].Days[0].ResourceClass != "gpu" || ml.Cost == nil || *ml.Cost != 200 {
+		t.Errorf("ml tenant = %+v, want one gpu task run costing 200", ml)
+	}
+
+	report, err = svc.UsageReport(ctx, day, day, "ml")
+	if err != nil {
+		t.Fatalf("UsageReport for ml: %v", err)
+	}
+	if len(report.Tenants) != 1 || report.Tenants[0].Tenant != "ml" {
+		t.Errorf("tenant filter: got %+v, want ml only", report.Tenants)
+	}
+}
+
+func TestUsageReport_InvalidRangeAndUnconfigured(t *testing.T) {
+	wrRepo, trRepo, tasks := mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewTaskRepo()
+	svc := service.New(mock.NewWorkflowRepo(), wrRepo, trRepo, mock.NewWorkerRepo(),
+		service.WithStats(mock.NewStatsRepo(wrRepo, trRepo, tasks)))
+	now := time.Now()
+	if _, err := svc.UsageReport(ctx, now, now.AddDate(0, 0, -1), ""); !errors.Is(err, service.ErrInvalidUsageRange) {
+		t.Errorf("reversed range: got %v, want ErrInvalidUsageRange", err)
+	}
+	if _, err := svc.UsageReport(ctx, now, now.AddDate(0, 0, service.MaxUsageReportDays), ""); !errors.Is(err, service.ErrInvalidUsageRange) {
+		t.Errorf("too long: got %v, want ErrInvalidUsageRange", err)
+	}
+	if _, err := newService().UsageReport(ctx, now, now, ""); !errors.Is(err, service.ErrStatsUnavailable) {
+		t.Errorf("unconfigured: got %v, want ErrStatsUnavailable", err)
+	}
+}
+
+func TestParseUsageRates(t *testing.T) {
+	rates, err := service.ParseUsageRates("gpu=0.002, *=0.0001")
+	if err != nil || len(rates) != 2 || rates["gpu"] != 0.002 || rates["*"] != 0.0001 {
+		t.Errorf("got %v, %v; want gpu and * rates", rates, err)
+	}
+	for _, bad := range []string{"gpu", "=1", "gpu=cheap", "gpu=-1"} {
+		if _, err := service.ParseUsageRates(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+// ── TaskAttempts ──────────────────────────────────────────────────────────────
+
+func TestTaskAttempts_AcrossRetriesAndClears(t *testing.T) {
+	wrRepo, trRepo := mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo()
+	attempts, queueTasks := scheduler.NewMemAttemptRepo(), scheduler.NewMemTaskRepo()
+	svc := service.New(mock.NewWorkflowRepo(), wrRepo, trRepo, mock.NewWorkerRepo(),
+		service.WithAttempts(attempts),
+		service.WithWorkerNodes(scheduler.NewMemWorkerRepo(), queueTasks, scheduler.NewMemHeartbeatRepo()))
+	now := time.Now().UTC()
+	wr := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: uuid.New(), Status: domain.StatusRunning, StartedAt: now}
+	_ = wrRepo.Create(ctx, wr)
+	taskID := uuid.New()
+	// The first task run failed twice on the worker and was cleared; the
+	// second failed once and is running its retry.
+	first := &domain.TaskRun{ID: uuid.New(), WorkflowRunID: wr.ID, TaskID: taskID, Status: domain.StatusFailed, Attempt: 1, StartedAt: now}
+	second := &domain.TaskRun{ID: uuid.New(), WorkflowRunID: wr.ID, TaskID: taskID, Status: domain.StatusRunning, Attempt: 2, StartedAt: now}
+	_ = trRepo.Create(ctx, second)
+	_ = trRepo.Create(ctx, first)
+	_ = trRepo.Create(ctx, &domain.TaskRun{ID: uuid.New(), WorkflowRunID: wr.ID, TaskID: uuid.New(), Status: domain.StatusSuccess, Attempt: 1, StartedAt: now})
+	for n, err := range []string{"timeout", "oom"} {
+		_ = attempts.Record(ctx, &qdomain.Attempt{TaskID: first.ID.String(), Number: n + 1, Status: qdomain.TaskStatusFailed,
+			WorkerID: "w1", StartedAt: now, FinishedAt: now.Add(time.Second), Error: err, Logs: err + "\n"})
+	}
+	_ = attempts.Record(ctx, &qdomain.Attempt{TaskID: second.ID.String(), Number: 1, Status: qdomain.TaskStatusFailed,
+		WorkerID: "w2", StartedAt: now, FinishedAt: now, Error: "flaky"})
+	started := now.Add(time.Minute)
+	_ = queueTasks.Save(ctx, &qdomain.Task{ID: second.ID.String(), Name: "a", Status: qdomain.TaskStatusRunning,
+		RetryCount: 1, WorkerID: "w3", StartedAt: &started})
+
+	got, err := svc.TaskAttempts(ctx, wr.ID, taskID)
+	if err != nil {
+		t.Fatalf("TaskAttempts: %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("attempts: got %d, want 4", len(got))
+	}
+	want := []struct {
+		run    uuid.UUID
+		status domain.Status
+		worker string
+		err    string
+	}{
+		{first.ID, domain.StatusFailed, "w1", "timeout"},
+		{first.ID, domain.StatusFailed, "w1", "oom"},
+		{second.ID, domain.StatusFailed, "w2", "flaky"},
+		{second.ID, domain.StatusRunning, "w3", ""},
+	}
+	for i, w := range want {
+		a := got[i]
+		if a.Number != i+1 || a.TaskRunID != w.run || a.Status != w.status || a.WorkerID != w.worker || a.Error != w.err {
+			t.Errorf("attempt %d: got %+v, want %+v", i+1, a, w)
+		}
+	}
+	if got[1].Logs != "oom\n" || got[3].FinishedAt != nil || !got[3].StartedAt.Equal(started) {
+		t.Errorf("attempts 2 and 4: got %+v and %+v", got[1], got[3])
+	}
+}
+
+func TestTaskAttempts_UnrecordedTaskRun(t *testing.T) {
+	svc, _, wrRepo, trRepo, _ := newServiceWithRepos()
+	now := time.Now().UTC()
+	wr := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: uuid.New(), Status: domain.StatusSuccess, StartedAt: now, FinishedAt: &now}
+	_ = wrRepo.Create(ctx, wr)
+	tr := &domain.TaskRun{ID: uuid.New(), WorkflowRunID: wr.ID, TaskID: uuid.New(), Status: domain.StatusSuccess, Attempt: 1, StartedAt: now, FinishedAt: &now, Logs: "done"}
+	_ = trRepo.Create(ctx, tr)
+
+	got, err := svc.TaskAttempts(ctx, wr.ID, tr.TaskID)
+	if err != nil {
+		t.Fatalf("TaskAttempts: %v", err)
+	}
+	if len(got) != 1 || got[0].Number != 1 || got[0].Status != domain.StatusSuccess || got[0].Logs != "done" {
+		t.Errorf("got %+v, want the task run as attempt 1", got)
+	}
+}
+
+func TestTaskAttempts_NotFound(t *testing.T) {
+	tasks := mock.NewTaskRepo()
+	wrRepo := mock.NewWorkflowRunRepo()
+	svc := service.New(mock.NewWorkflowRepo(), wrRepo, mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)))
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "etl", Tasks: []service.TaskInput{{Name: "a", Command: "a"}}})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	list, _ := tasks.ListByWorkflowID(ctx, wf.ID)
+	wr := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusRunning, StartedAt: time.Now().UTC()}
+	_ = wrRepo.Create(ctx, wr)
+
+	if got, err := svc.TaskAttempts(ctx, wr.ID, list[0].ID); err != nil || len(got) != 0 {
+		t.Errorf("task not started yet: got %v, %v; want no attempts", got, err)
+	}
+	if _, err := svc.TaskAttempts(ctx, wr.ID, uuid.New()); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("task outside the workflow: got %v, want ErrNotFound", err)
+	}
+	if _, err := svc.TaskAttempts(ctx, uuid.New(), list[0].ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("unknown run: got %v, want ErrNotFound", err)
+	}
+}
+
+// ── ExportHistory ─────────────────────────────────────────────────────────────
+
+func TestExportHistory_TaskRunsCSV(t *testing.T) {
+	tasks := mock.NewTaskRepo()
+	wrRepo, trRepo := mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo()
+	svc := service.New(mock.NewWorkflowRepo(), wrRepo, trRepo, mock.NewWorkerRepo(),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)))
+	wf, err := svc.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "etl", Tasks: []service.TaskInput{{Name: "extract", Command: "x"}}})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	list, _ := tasks.ListByWorkflowID(ctx, wf.ID)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	finished := from.Add(90 * time.Second)
+	in := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusSuccess, StartedAt: from, FinishedAt: &finished}
+	_ = wrRepo.Create(ctx, in)
+	_ = wrRepo.Create(ctx, &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusSuccess, StartedAt: from.AddDate(0, 0, 1)})
+	tr := &domain.TaskRun{ID: uuid.New(), WorkflowRunID: in.ID, TaskID: list[0].ID, Status: domain.StatusSuccess, Attempt: 2, StartedAt: from, FinishedAt: &finished}
+	_ = trRepo.Create(ctx, tr)
+
+	exp, err := svc.ExportHistory(service.ExportInput{Table: service.ExportTaskRuns, From: from, To: from.AddDate(0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ExportHistory: %v", err)
+	}
+	if exp.Filename() != "task_runs_2024-03-01_2024-03-02.csv" {
+		t.Errorf("Filename: got %q", exp.Filename())
+	}
+	var buf bytes.Buffer
+	if err := exp.Stream(ctx, &buf); err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	want := "workflow_id,workflow_name,run_id,task_run_id,task_id,task_name,status,attempt,started_at,finished_at,duration_seconds\n" +
+		strings.Join([]string{wf.ID.String(), "etl", in.ID.String(), tr.ID.String(), list[0].ID.String(), "extract",
+			"success", "2", "2024-03-01T00:00:00Z", "2024-03-01T00:01:30Z", "90"}, ",") + "\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestExportHistory_Invalid(t *testing.T) {
+	svc := newService()
+	now := time.Now()
+	for name, in := range map[string]service.ExportInput{
+		"no range":       {},
+		"empty range":    {From: now, To: now},
+		"unknown table":  {Table: "workers", From: now, To: now.Add(time.Hour)},
+		"unknown format": {Format: "xlsx", From: now, To: now.Add(time.Hour)},
+	} {
+		if _, err := svc.ExportHistory(in); !errors.Is(err, service.ErrInvalidExport) {
+			t.Errorf("%s: got %v, want ErrInvalidExport", name, err)
+		}
+	}
+}
+
+// ── Workflow definitions ──────────────────────────────────────────────────────
+
+// newDeployment returns a Service storing tasks and calendars, with the
+// calendar "holidays".
+func newDeployment(t *testing.T) *service.Service {
+	t.Helper()
+	tasks := mock.NewTaskRepo()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)), service.WithCalendars(mock.NewCalendarRepo()))
+	if _, err := svc.CreateCalendar(ctx, service.CreateCalendarInput{Name: "holidays", ExcludedDates: []string{"2025-12-25"}}); err != nil {
+		t.Fatalf("CreateCalendar: %v", err)
+	}
+	return svc
+}
+
+func TestDefinitions_Promote(t *testing.T) {
+	staging, prod := newDeployment(t), newDeployment(t)
+	report, err := staging.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "report", Tasks: []service.TaskInput{{Name: "render", Command: "render.sh"}}})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	_, err = staging.CreateWorkflow(ctx, service.CreateWorkflowInput{
+		Name: "etl", ScheduleCron: "0 6 * * *", IsActive: true, TriggerOnSuccess: []uuid.UUID{report.ID},
+		Tasks: []service.TaskInput{
+			{Name: "extract", Command: "extract.sh"},
+			{Name: "load", Command: "load.sh", DependsOn: []string{"extract"}},
+			{Name: "notify", Type: domain.TaskTypeTriggerWorkflow, Command: report.ID.String(), DependsOn: []string{"load"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+
+	defs, err := staging.ExportDefinitions(ctx)
+	if err != nil {
+		t.Fatalf("ExportDefinitions: %v", err)
+	}
+	etl := defs.Workflows[0]
+	if etl.Name != "etl" || len(etl.TriggerOnSuccess) != 1 || etl.TriggerOnSuccess[0] != "report" || etl.Tasks[2].Command != "report" || etl.Tasks[2].Name != "notify" {
+		t.Fatalf("etl definition: got %+v, want references by name", etl)
+	}
+
+	plan, err := prod.PlanDefinitions(ctx, defs)
+	if err != nil {
+		t.Fatalf("PlanDefinitions: %v", err)
+	}
+	if len(plan.Workflows) != 2 || plan.Workflows[0].Action != service.SyncCreate || plan.Workflows[1].Action != service.SyncCreate {
+		t.Fatalf("plan: got %+v, want two creates", plan.Workflows)
+	}
+	if listed, _ := prod.ListWorkflows(ctx, 0, 0); len(listed) != 0 {
+		t.Fatalf("PlanDefinitions created %d workflows", len(listed))
+	}
+	if _, err := prod.ApplyDefinitions(ctx, defs, plan.Fingerprint); err != nil {
+		t.Fatalf("ApplyDefinitions: %v", err)
+	}
+
+	got, _ := prod.ExportDefinitions(ctx)
+	got.ExportedAt = defs.ExportedAt
+	if !reflect.DeepEqual(got, defs) {
+		t.Errorf("prod definitions differ from staging:\n got %+v\nwant %+v", got, defs)
+	}
+	plan, _ = prod.PlanDefinitions(ctx, defs)
+	for _, w := range plan.Workflows {
+		if w.Action != service.SyncUnchanged {
+			t.Errorf("%s after apply: got %s %+v, want unchanged", w.Name, w.Action, w.Changes)
+		}
+	}
+}
+
+func TestDefinitions_DriftAndConflicts(t *testing.T) {
+	prod := newDeployment(t)
+	if _, err := prod.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "etl", ScheduleCron: "0 5 * * *", Tasks: []service.TaskInput{
+		{Name: "extract", Command: "extract.sh"},
+		{Name: "legacy", Command: "legacy.sh"},
+	}}); err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	_, _ = prod.CreateWorkflow(ctx, service.CreateWorkflowInput{Name: "adhoc"})
+	defs := &service.Definitions{Version: service.DefinitionsVersion, Workflows: []service.WorkflowDefinition{{
+		Name: "etl", ScheduleCron: "0 6 * * *", Calendar: "holidays", Tasks: []service.TaskInput{
+			{Name: "extract", Command: "extract.sh --full"},
+			{Name: "legacy", Command: "legacy.sh"},
+			{Name: "load", Command: "load.sh", DependsOn: []string{"extract"}},
+		},
+	}}}
+
+	plan, err := prod.PlanDefinitions(ctx, defs)
+	if err != nil {
+		t.Fatalf("PlanDefinitions: %v", err)
+	}
+	etl := plan.Workflows[0]
+	var fields []string
+	for _, c := range etl.Changes {
+		fields = append(fields, c.Field)
+	}
+	want := []string{"calendar", "schedule_cron", "tasks.extract.command", "tasks.load"}
+	if etl.Action != service.SyncUpdate || !reflect.DeepEqual(fields, want) {
+		t.Fatalf("etl: got %s %v, want update of %v", etl.Action, fields, want)
+	}
+	if !reflect.DeepEqual(plan.Unmanaged, []string{"adhoc"}) {
+		t.Errorf("unmanaged: got %v, want [adhoc]", plan.Unmanaged)
+	}
+
+	// The workflow changes after the plan was reviewed.
+	stale := plan.Fingerprint
+	defs.Workflows[0].Description = "nightly load"
+	if _, err := prod.ApplyDefinitions(ctx, defs, stale); !errors.Is(err, service.ErrDefinitionsDrifted) {
+		t.Fatalf("stale fingerprint: got %v, want ErrDefinitionsDrifted", err)
+	}
+	if _, err := prod.ApplyDefinitions(ctx, defs, ""); err != nil {
+		t.Fatalf("ApplyDefinitions: %v", err)
+	}
+	plan, _ = prod.PlanDefinitions(ctx, defs)
+	if plan.Workflows[0].Action != service.SyncUnchanged {
+		t.Errorf("after apply: got %s %+v, want unchanged", plan.Workflows[0].Action, plan.Workflows[0].Changes)
+	}
+
+	// Dropping a task would delete its history, so it is left to a human.
+	defs.Workflows[0].Tasks = defs.Workflows[0].Tasks[:1]
+	plan, _ = prod.PlanDefinitions(ctx, defs)
+	if plan.Workflows[0].Action != service.SyncConflict || plan.Workflows[0].Reason == "" {
+		t.Errorf("dropped tasks: got %+v, want a conflict", plan.Workflows[0])
+	}
+
+	for name, bad := range map[string]*service.Definitions{
+		"version":   {Version: 2},
+		"duplicate": {Version: 1, Workflows: []service.WorkflowDefinition{{Name: "a"}, {Name: "a"}}},
+	} {
+		if _, err := prod.PlanDefinitions(ctx, bad); !errors.Is(err, service.ErrInvalidDefinitions) {
+			t.Errorf("%s: got %v, want ErrInvalidDefinitions", name, err)
+		}
+	}
+}
+
+// ── TestTask ──────────────────────────────────────────────────────────────────
+
+func TestTestTask_RunsOnTestQueue(t *testing.T) {
+	wfRepo, tasks := mock.NewWorkflowRepo(), mock.NewTaskRepo()
+	attempts, queueTasks := scheduler.NewMemAttemptRepo(), scheduler.NewMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	svc := service.New(wfRepo, mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithTasks(tasks, mock.NewTaskDependencyRepo(tasks)),
+		service.WithAttempts(attempts),
+		service.WithWorkerNodes(scheduler.NewMemWorkerRepo(), queueTasks, scheduler.NewMemHeartbeatRepo()),
+		service.WithTaskTesting(q, "task-test"))
+
+	if _, err := svc.TestTask(ctx, uuid.New(), service.TestTaskInput{}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("unknown task: got %v, want ErrNotFound", err)
+	}
+	wf := &domain.Workflow{ID: uuid.New(), Name: "etl"}
+	_ = wfRepo.Create(ctx, wf)
+	task := &domain.Task{ID: uuid.New(), WorkflowID: wf.ID, Name: "load", Type: domain.TaskTypeCommand,
+		Command: "load.sh {{.params.table}}", RetryCount: 3}
+	_ = tasks.Create(ctx, task)
+	if _, err := svc.TestTask(ctx, task.ID, service.TestTaskInput{}); !errors.Is(err, service.ErrInvalidTaskTest) {
+		t.Errorf("missing param: got %v, want ErrInvalidTaskTest", err)
+	}
+
+	// Stand in for a worker of the test group.
+	go func() {
+		qt, err := q.Dequeue(ctx)
+		if err != nil {
+			return
+		}
+		now := time.Now()
+		qt.Status, qt.WorkerID, qt.StartedAt, qt.FinishedAt = qdomain.TaskStatusSucceeded, "tester", &now, &now
+		_ = attempts.Record(ctx, &qdomain.Attempt{TaskID: qt.ID, Number: 1, Status: qt.Status, Logs: "ran " + string(qt.Payload) + "\n"})
+		_ = queueTasks.Save(ctx, qt)
+	}()
+	res, err := svc.TestTask(ctx, task.ID, service.TestTaskInput{Params: map[string]string{"table": "orders"}, WaitSeconds: 5})
+	if err != nil {
+		t.Fatalf("TestTask: %v", err)
+	}
+	if !res.Complete || res.Status != qdomain.TaskStatusSucceeded || res.WorkerID != "tester" {
+		t.Errorf("result: got %+v, want succeeded on tester", res)
+	}
+	if res.Command != "load.sh orders" || res.Logs != "ran load.sh orders\n" {
+		t.Errorf("result: got command %q, logs %q", res.Command, res.Logs)
+	}
+	qt, _ := queueTasks.FindByID(ctx, res.ID)
+	if qt.Group != "task-test" || qt.MaxRetries != 0 || qt.Env["TASK_NAME"] != "load" {
+		t.Errorf("queued test: got group %q, %d retries, env %v", qt.Group, qt.MaxRetries, qt.Env)
+	}
+
+	// Nobody takes the next one: the call gives up waiting.
+	res, err = svc.TestTask(ctx, task.ID, service.TestTaskInput{Command: "echo hi", WaitSeconds: 1})
+	if err != nil || res.Complete || res.Status != qdomain.TaskStatusQueued || res.Command != "echo hi" {
+		t.Errorf("unanswered test: got %+v, %v; want it still queued", res, err)
+	}
+}
+
+// ── RebalanceQueue ────────────────────────────────────────────────────────────
+
+func TestRebalanceQueue(t *testing.T) {
+	queues := scheduler.NewGroupQueues(scheduler.NewMemQueue(), nil)
+	queueTasks := scheduler.NewMemTaskRepo()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithWorkerNodes(scheduler.NewMemWorkerRepo(), queueTasks, scheduler.NewMemHeartbeatRepo()),
+		service.WithQueues(queues))
+
+	old := time.Now().UTC().Add(-time.Hour)
+	for i, wf := range []string{"etl", "ml", "etl", "etl"} {
+		task := &qdomain.Task{ID: fmt.Sprintf("t%d", i), Name: "n", WorkflowID: wf, Priority: qdomain.PriorityNormal, CreatedAt: old}
+		if i == 3 {
+			task.CreatedAt = time.Now().UTC()
+		}
+		_ = queueTasks.Save(ctx, task)
+		_ = queues.Enqueue(ctx, task)
+	}
+	gpu := "gpu"
+	in := service.RebalanceInput{To: &gpu, WorkflowID: "etl", MinAgeSeconds: 600, DryRun: true}
+	res, err := svc.RebalanceQueue(ctx, in)
+	if err != nil || res.Scanned != 4 || !reflect.DeepEqual(res.TaskIDs, []string{"t0", "t2"}) {
+		t.Fatalf("dry run: got %+v, %v; want t0 and t2 of 4", res, err)
+	}
+	if n, _ := queues.Len(ctx); n != 4 {
+		t.Fatalf("dry run: %d tasks queued, want 4 left alone", n)
+	}
+
+	in.DryRun, in.Priority = false, new(int)
+	*in.Priority = int(qdomain.PriorityHigh)
+	if res, err = svc.RebalanceQueue(ctx, in); err != nil || res.Moved != 2 {
+		t.Fatalf("rebalance: got %+v, %v; want 2 moved", res, err)
+	}
+	q, _ := queues.Queue("gpu")
+	for _, want := range []string{"t0", "t2"} {
+		task, _ := q.Dequeue(ctx)
+		if task.ID != want || task.Group != "gpu" || task.Priority != qdomain.PriorityHigh {
+			t.Errorf("gpu queue: got %s in %q at %d, want %s in gpu at high priority", task.ID, task.Group, task.Priority, want)
+		}
+		if rec, _ := queueTasks.FindByID(ctx, want); rec.Group != "gpu" || rec.Priority != qdomain.PriorityHigh {
+			t.Errorf("record of %s: got group %q, priority %d", want, rec.Group, rec.Priority)
+		}
+	}
+	for _, want := range []string{"t1", "t3"} {
+		if task, _ := queues.Dequeue(ctx); task.ID != want {
+			t.Errorf("default queue: got %s, want %s kept in order", task.ID, want)
+		}
+	}
+
+	sticky := qdomain.StickyQueue("w1")
+	for name, bad := range map[string]service.RebalanceInput{
+		"nothing to do": {},
+		"same queue":    {From: "gpu", To: &gpu},
+		"priority":      {Priority: new(int)},
+		"sticky":        {To: &sticky},
+		"bad group":     {From: "no spaces", To: &gpu},
+	} {
+		if _, err := svc.RebalanceQueue(ctx, bad); !errors.Is(err, service.ErrInvalidRebalance) {
+			t.Errorf("%s: got %v, want ErrInvalidRebalance", name, err)
+		}
+	}
+	if _, err := newService().RebalanceQueue(ctx, in); !errors.Is(err, service.ErrQueuesUnavailable) {
+		t.Errorf("unconfigured: got %v, want ErrQueuesUnavailable", err)
+	}
+}
+
 // ── ListWorkers ───────────────────────────────────────────────────────────────
 
 func TestListWorkers_Empty(t *testing.T) {
@@ -329,3 +1598,58 @@ func TestListWorkers_OnlyActive(t *testing.T) {
 		t.Errorf("unexpected worker returned: got %v, want %v", workers[0].ID, active.ID)
 	}
 }
+
+// ── Lineage ───────────────────────────────────────────────────────────────────
+
+func TestLineage_TracesDownstreamAndUpstream(t *testing.T) {
+	lineage := mock.NewLineageRepo()
+	svc := service.New(mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewWorkerRepo(),
+		service.WithLineage(lineage))
+
+	// raw → (ingest) → clean → (report) → dashboard, with ingest and report
+	// in different workflows.
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	ingestWF, reportWF := uuid.New(), uuid.New()
+	edge := func(wf, taskRun uuid.UUID, ds string, dir domain.LineageDirection, at time.Time) {
+		_ = lineage.Create(ctx, &domain.LineageEdge{
+			ID: uuid.New(), Dataset: ds, Direction: dir, WorkflowID: wf,
+			WorkflowRunID: uuid.New(), TaskID: uuid.New(), TaskRunID: taskRun, CreatedAt: at,
+		})
+	}
+	ingest, report := uuid.New(), uuid.New()
+	edge(ingestWF, ingest, "raw", domain.LineageInput, base)
+	edge(ingestWF, ingest, "clean", domain.LineageOutput, base.Add(time.Minute))
+	edge(reportWF, report, "clean", domain.LineageInput, base.Add(time.Hour))
+	edge(reportWF, report, "dashboard", domain.LineageOutput, base.Add(2*time.Hour))
+
+	g, err := svc.Lineage(ctx, "raw", "", time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("Lineage: %v", err)
+	}
+	if len(g.Datasets) != 3 || g.Datasets[2] != "dashboard" || len(g.Workflows) != 2 || len(g.Edges) != 4 {
+		t.Errorf("downstream: got datasets %v, workflows %v, %d edges", g.Datasets, g.Workflows, len(g.Edges))
+	}
+
+	g, _ = svc.Lineage(ctx, "raw", service.LineageDownstream, time.Time{}, 1)
+	if len(g.Datasets) != 2 {
+		t.Errorf("depth 1: got datasets %v, want [raw clean]", g.Datasets)
+	}
+
+	g, _ = svc.Lineage(ctx, "dashboard", service.LineageUpstream, time.Time{}, 0)
+	if len(g.Datasets) != 3 || g.Datasets[2] != "raw" {
+		t.Errorf("upstream: got datasets %v", g.Datasets)
+	}
+
+	// Reads of "clean" before the cut-off are not followed.
+	g, _ = svc.Lineage(ctx, "clean", "", base.Add(3*time.Hour), 0)
+	if len(g.Edges) != 0 {
+		t.Errorf("since: got %d edges, want 0", len(g.Edges))
+	}
+
+	if _, err := svc.Lineage(ctx, "raw", "sideways", time.Time{}, 0); !errors.Is(err, service.ErrInvalidLineageQuery) {
+		t.Errorf("direction: got %v, want ErrInvalidLineageQuery", err)
+	}
+	if _, err := newService().Lineage(ctx, "raw", "", time.Time{}, 0); !errors.Is(err, service.ErrLineageUnavailable) {
+		t.Errorf("unconfigured: got %v, want ErrLineageUnavailable", err)
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// MaxSimulationSamples is how many of a task's latest successful runs
+// SimulateWorkflow derives its duration from.
+const MaxSimulationSamples = 20
+
+// ErrInvalidSimulation is returned when a simulation's estimates name
+// unknown tasks or are negative.
+var ErrInvalidSimulation = errors.New("invalid simulation")
+
+// Where the duration of a SimulatedTask comes from.
+const (
+	DurationEstimate = "estimate"
+	DurationHistory  = "history"
+	DurationDefault  = "default"
+)
+
+// SimulateInput tunes a workflow simulation.
+type SimulateInput struct {
+	// Start is when the simulated run starts; zero means now.
+	Start time.Time `json:"start"`
+	// Estimates gives the duration in seconds of tasks by name, overriding
+	// their history.
+	Estimates map[string]float64 `json:"estimates"`
+	// DefaultSeconds is the duration of tasks with neither an estimate nor
+	// a successful run to learn from.
+	DefaultSeconds float64 `json:"default_seconds"`
+}
+
+// Simulation is the predicted timeline of a workflow run.
+type Simulation struct {
+	WorkflowID      uuid.UUID `json:"workflow_id"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	// Tasks are ordered by predicted start; skipped tasks come last.
+	Tasks []SimulatedTask `json:"tasks"`
+	// CriticalPath names the chain of tasks, first to last, whose
+	// durations add up to the run's; shortening any other task does not
+	// finish the run sooner.
+	CriticalPath []string `json:"critical_path"`
+}
+
+// SimulatedTask is one task's place in a Simulation. Offsets are seconds
+// from the simulation's start. A task whose trigger rule cannot be met when
+// every task succeeds is Skipped and has no times.
+type SimulatedTask struct {
+	TaskID          uuid.UUID  `json:"task_id"`
+	Name            string     `json:"name"`
+	Start           *time.Time `json:"start,omitempty"`
+	End             *time.Time `json:"end,omitempty"`
+	StartOffset     float64    `json:"start_offset_seconds"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	// Source is DurationEstimate, DurationHistory or DurationDefault;
+	// Samples counts the runs a DurationHistory duration comes from.
+	Source   string `json:"source"`
+	Samples  int    `json:"samples,omitempty"`
+	Critical bool   `json:"critical"`
+	Skipped  bool   `json:"skipped,omitempty"`
+}
+
+// SimulateWorkflow predicts the timeline of a run of the workflow without
+// executing anything. Every task is assumed to succeed and to take the
+// median duration of its latest successful runs, unless in.Estimates gives
+// one. Tasks start as soon as their trigger rules allow and, when the
+// workflow sets MaxParallelTasks, a slot is free.
+func (s *Service) SimulateWorkflow(ctx context.Context, workflowID uuid.UUID, in SimulateInput) (*Simulation, error) {
+	if s.tasks == nil || s.deps == nil {
+		return nil, ErrTasksUnavailable
+	}
+	if in.DefaultSeconds < 0 {
+		return nil, fmt.Errorf("%w: default_seconds must not be negative", ErrInvalidSimulation)
+	}
+	wf, err := s.workflows.GetByID(ctx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.tasks.ListByWorkflowID(ctx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := s.deps.ListByWorkflowID(ctx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	dag, err := domain.NewDAG(tasks, deps)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		byName[t.Name] = true
+	}
+	for name, secs := range in.Estimates {
+		if !byName[name] {
+			return nil, fmt.Errorf("%w: no task named %q", ErrInvalidSimulation, name)
+		}
+		if secs < 0 {
+			return nil, fmt.Errorf("%w: estimate of %q must not be negative", ErrInvalidSimulation, name)
+		}
+	}
+
+	sims := make(map[uuid.UUID]*SimulatedTask, len(tasks))
+	for _, t := range tasks {
+		st := &SimulatedTask{TaskID: t.ID, Name: t.Name, Source: DurationDefault, DurationSeconds: in.DefaultSeconds}
+		if secs, ok := in.Estimates[t.Name]; ok {
+			st.Source, st.DurationSeconds = DurationEstimate, secs
+		} else {
+			secs, n, err := s.historicalDuration(ctx, t.ID)
+			if err != nil {
+				return nil, err
+			}
+			if n > 0 {
+				st.Source, st.DurationSeconds, st.Samples = DurationHistory, secs, n
+			}
+		}
+		sims[t.ID] = st
+	}
+
+	start := in.Start
+	if start.IsZero() {
+		start = time.Now().UTC()
+	}
+	order, via := simulate(dag, sims, wf.MaxParallelTasks)
+
+	out := &Simulation{WorkflowID: workflowID, Start: start, End: start, Tasks: make([]SimulatedTask, 0, len(tasks))}
+	var last *SimulatedTask
+	for _, id := range order {
+		st := sims[id]
+		if last == nil || finishOffset(st) > finishOffset(last) {
+			last = st
+		}
+	}
+	if last != nil {
+		out.DurationSeconds = finishOffset(last)
+		out.End = start.Add(seconds(out.DurationSeconds))
+		var path []string
+		for id := last.TaskID; id != uuid.Nil; id = via[id] {
+			sims[id].Critical = true
+			path = append(path, sims[id].Name)
+		}
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+		out.CriticalPath = path
+	}
+	for _, id := range order {
+		st := sims[id]
+		begin, end := start.Add(seconds(st.StartOffset)), start.Add(seconds(finishOffset(st)))
+		st.Start, st.End = &begin, &end
+		out.Tasks = append(out.Tasks, *st)
+	}
+	var skipped []SimulatedTask
+	for _, st := range sims {
+		if st.Skipped {
+			skipped = append(skipped, *st)
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].Name < skipped[j].Name })
+	out.Tasks = append(out.Tasks, skipped...)
+	return out, nil
+}
+
+// simulate walks dag as the orchestrator would if every task succeeded,
+// setting StartOffset on started tasks and Skipped on the rest. It returns
+// the started tasks in start order and, for each, the task whose finish let
+// it start: the upstream it waited for last, or the task whose slot it took
+// when limit caps the tasks running at once.
+func simulate(dag *domain.DAG, sims map[uuid.UUID]*SimulatedTask, limit int) ([]uuid.UUID, map[uuid.UUID]uuid.UUID) {
+	states := make(map[uuid.UUID]domain.Status, len(sims))
+	via := make(map[uuid.UUID]uuid.UUID, len(sims))
+	var (
+		order    []uuid.UUID
+		running  []uuid.UUID
+		now      float64
+		finished uuid.UUID
+	)
+	for {
+		ready, skipped := dag.Evaluate(states)
+		for _, id := range skipped {
+			states[id] = domain.StatusSkipped
+			sims[id].Skipped = true
+		}
+		for _, id := range ready {
+			if limit > 0 && len(running) >= limit {
+				break
+			}
+			states[id] = domain.StatusRunning
+			sims[id].StartOffset = now
+			via[id] = finished
+			order = append(order, id)
+			running = append(running, id)
+		}
+		if len(running) == 0 {
+			return order, via
+		}
+		// Finish the running task that ends first; ties go to the one
+		// started first, keeping the result deterministic.
+		next := 0
+		for i, id := range running {
+			if finishOffset(sims[id]) < finishOffset(sims[running[next]]) {
+				next = i
+			}
+		}
+		finished = running[next]
+		running = append(running[:next], running[next+1:]...)
+		now = finishOffset(sims[finished])
+		states[finished] = domain.StatusSuccess
+	}
+}
+
+func finishOffset(st *SimulatedTask) float64 {
+	return st.StartOffset + st.DurationSeconds
+}
+
+// historicalDuration returns the median duration in seconds of the latest
+// MaxSimulationSamples successful runs of task id, and how many there were.
+func (s *Service) historicalDuration(ctx context.Context, id uuid.UUID) (float64, int, error) {
+	runs, err := s.taskRuns.ListByTaskID(ctx, id)
+	if err != nil {
+		return 0, 0, err
+	}
+	var done []*domain.TaskRun
+	for _, tr := range runs {
+		if tr.Status == domain.StatusSuccess && tr.FinishedAt != nil {
+			done = append(done, tr)
+		}
+	}
+	sort.Slice(done, func(i, j int) bool { return done[i].StartedAt.After(done[j].StartedAt) })
+	if len(done) > MaxSimulationSamples {
+		done = done[:MaxSimulationSamples]
+	}
+	if len(done) == 0 {
+		return 0, 0, nil
+	}
+	durations := make([]float64, len(done))
+	for i, tr := range done {
+		durations[i] = tr.FinishedAt.Sub(tr.StartedAt).Seconds()
+	}
+	sort.Float64s(durations)
+	mid := len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[mid-1] + durations[mid]) / 2, len(durations), nil
+	}
+	return durations[mid], len(durations), nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// Defaults and limits for WorkflowStats.
+const (
+	// DefaultStatsWindow is how far back statistics look when no window is given.
+	DefaultStatsWindow = 30 * 24 * time.Hour
+	// DefaultStatsBucket is the width of a trend bucket when none is given.
+	DefaultStatsBucket = 24 * time.Hour
+	// MaxStatsBuckets bounds the number of trend buckets a window may span.
+	MaxStatsBuckets = 1000
+)
+
+// Errors returned by the statistics use case.
+var (
+	// ErrStatsUnavailable is returned when no StatsRepository is configured.
+	ErrStatsUnavailable = errors.New("statistics are not configured")
+	// ErrInvalidStatsRange is returned when the window or bucket is not
+	// positive, or the window spans more than MaxStatsBuckets buckets.
+	ErrInvalidStatsRange = errors.New("invalid statistics range")
+)
+
+// WorkflowStats is the duration profile of a workflow's recent runs.
+type WorkflowStats struct {
+	WorkflowID uuid.UUID `json:"workflow_id"`
+	Since      time.Time `json:"since"`
+	// BucketSeconds is the width of each Trend bucket.
+	BucketSeconds int64                      `json:"bucket_seconds"`
+	Runs          domain.DurationPercentiles `json:"runs"`
+	Tasks         []domain.TaskDurationStat  `json:"tasks"`
+	Trend         []domain.DurationBucket    `json:"trend"`
+}
+
+// WorkflowStats aggregates the durations of the workflow's finished runs that
+// started within the last window, grouping the trend into buckets of the
+// given width. Zero values select DefaultStatsWindow and DefaultStatsBucket.
+func (s *Service) WorkflowStats(ctx context.Context, workflowID uuid.UUID, window, bucket time.Duration) (*WorkflowStats, error) {
+	if s.stats == nil {
+		return nil, ErrStatsUnavailable
+	}
+	if window == 0 {
+		window = DefaultStatsWindow
+	}
+	if bucket == 0 {
+		bucket = DefaultStatsBucket
+	}
+	if window < 0 || bucket < time.Second {
+		return nil, fmt.Errorf("%w: window must be positive and bucket at least 1s", ErrInvalidStatsRange)
+	}
+	if window/bucket > MaxStatsBuckets {
+		return nil, fmt.Errorf("%w: more than %d buckets", ErrInvalidStatsRange, MaxStatsBuckets)
+	}
+	if _, err := s.workflows.GetByID(ctx, workflowID); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().UTC().Add(-window)
+	runs, err := s.stats.RunDurationPercentiles(ctx, workflowID, since)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.stats.TaskDurationAverages(ctx, workflowID, since)
+	if err != nil {
+		return nil, err
+	}
+	trend, err := s.stats.RunDurationTrend(ctx, workflowID, since, bucket.Truncate(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	return &WorkflowStats{
+		WorkflowID:    workflowID,
+		Since:         since,
+		BucketSeconds: int64(bucket / time.Second),
+		Runs:          *runs,
+		Tasks:         tasks,
+		Trend:         trend,
+	}, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// Errors returned when a workflow is created with tasks.
+var (
+	// ErrTasksUnavailable is returned when tasks are supplied but no
+	// TaskRepository is configured.
+	ErrTasksUnavailable = errors.New("task definitions are not configured")
+	// ErrInvalidTasks is returned when the supplied tasks do not form a
+	// valid graph.
+	ErrInvalidTasks = errors.New("invalid workflow tasks")
+)
+
+// TaskInput describes one task of a workflow created through
+// CreateWorkflow. DependsOn names other tasks of the same workflow.
+type TaskInput struct {
+	Name                 string             `json:"name"`
+	Command              string             `json:"command"`
+	Type                 domain.TaskType    `json:"type"`
+	RetryCount           int                `json:"retry_count"`
+	RetryDelaySeconds    int                `json:"retry_delay_seconds"`
+	RetryMultiplier      float64            `json:"retry_multiplier"`
+	RetryMaxDelaySeconds int                `json:"retry_max_delay_seconds"`
+	RetryJitter          bool               `json:"retry_jitter"`
+	TimeoutSeconds       int                `json:"timeout_seconds"`
+	Pool                 string             `json:"pool"`
+	ConcurrencyKey       string             `json:"concurrency_key"`
+	TriggerRule          domain.TriggerRule `json:"trigger_rule"`
+	DependsOn            []string           `json:"depends_on"`
+	Inputs               []string           `json:"inputs"`
+	Outputs              []string           `json:"outputs"`
+	Env                  map[string]string  `json:"env"`
+	Hooks                []domain.TaskHook  `json:"hooks"`
+	Profile              string             `json:"profile"`
+	Queue                string             `json:"queue"`
+	Priority             int                `json:"priority"`
+	TTLSeconds           int                `json:"ttl_seconds"`
+}
+
+// buildTasks converts the task inputs of workflow wfID into tasks and the
+// dependencies between them, rejecting duplicate names, unknown upstream
+// names, unknown trigger rules, malformed templates and cycles with
+// ErrInvalidTasks.
+func buildTasks(wfID uuid.UUID, in []TaskInput, now time.Time) ([]*domain.Task, []*domain.TaskDependency, error) {
+	tasks := make([]*domain.Task, 0, len(in))
+	byName := make(map[string]uuid.UUID, len(in))
+	for _, ti := range in {
+		if ti.Name == "" {
+			return nil, nil, fmt.Errorf("%w: task name must not be empty", ErrInvalidTasks)
+		}
+		if _, dup := byName[ti.Name]; dup {
+			return nil, nil, fmt.Errorf("%w: duplicate task name %q", ErrInvalidTasks, ti.Name)
+		}
+		if !ti.TriggerRule.Valid() {
+			return nil, nil, fmt.Errorf("%w: task %q: unknown trigger rule %q", ErrInvalidTasks, ti.Name, ti.TriggerRule)
+		}
+		t := &domain.Task{
+			ID:                   uuid.New(),
+			WorkflowID:           wfID,
+			Name:                 ti.Name,
+			Command:              ti.Command,
+			RetryCount:           ti.RetryCount,
+			RetryDelaySeconds:    ti.RetryDelaySeconds,
+			TimeoutSeconds:       ti.TimeoutSeconds,
+			CreatedAt:            now,
+			Type:                 ti.Type,
+			Pool:                 ti.Pool,
+			ConcurrencyKey:       ti.ConcurrencyKey,
+			TriggerRule:          ti.TriggerRule,
+			RetryMultiplier:      ti.RetryMultiplier,
+			RetryMaxDelaySeconds: ti.RetryMaxDelaySeconds,
+			RetryJitter:          ti.RetryJitter,
+			Inputs:               ti.Inputs,
+			Outputs:              ti.Outputs,
+			Env:                  ti.Env,
+			Hooks:                ti.Hooks,
+			Profile:              ti.Profile,
+			Queue:                ti.Queue,
+			Priority:             ti.Priority,
+			TTLSeconds:           ti.TTLSeconds,
+		}
+		if t.Type == "" {
+			t.Type = domain.TaskTypeCommand
+		}
+		if t.Queue != "" && !qdomain.ValidGroupName(t.Queue) {
+			return nil, nil, fmt.Errorf("%w: task %q: invalid queue name %q", ErrInvalidTasks, ti.Name, t.Queue)
+		}
+		if t.RetryCount < 0 {
+			return nil, nil, fmt.Errorf("%w: task %q: retry_count must not be negative", ErrInvalidTasks, ti.Name)
+		}
+		if err := scheduler.TaskRetryPolicy(t).Validate(); err != nil {
+			return nil, nil, fmt.Errorf("%w: task %q: %v", ErrInvalidTasks, ti.Name, err)
+		}
+		if t.TTLSeconds < 0 {
+			return nil, nil, fmt.Errorf("%w: task %q: ttl_seconds must not be negative", ErrInvalidTasks, ti.Name)
+		}
+		if err := validatePriority(t.Priority); err != nil {
+			return nil, nil, fmt.Errorf("%w: task %q: %v", ErrInvalidTasks, ti.Name, err)
+		}
+		if len(t.Hooks) > 0 && (t.Type == domain.TaskTypeApproval || t.Type == domain.TaskTypeTriggerWorkflow) {
+			return nil, nil, fmt.Errorf("%w: task %q: hooks are run by workers, which do not run %s tasks", ErrInvalidTasks, ti.Name, t.Type)
+		}
+		for _, h := range t.Hooks {
+			if err := h.Validate(); err != nil {
+				return nil, nil, fmt.Errorf("%w: task %q: %v", ErrInvalidTasks, ti.Name, err)
+			}
+		}
+		if t.Type == domain.TaskTypeTriggerWorkflow {
+			if _, err := uuid.Parse(t.Command); err != nil {
+				return nil, nil, fmt.Errorf("%w: task %q: command must be the ID of the workflow to trigger", ErrInvalidTasks, ti.Name)
+			}
+		}
+		if err := t.ValidateTemplates(); err != nil {
+			return nil, nil, fmt.Errorf("%w: task %q: %v", ErrInvalidTasks, ti.Name, err)
+		}
+		if t.TriggerRule == "" {
+			t.TriggerRule = domain.TriggerAllSuccess
+		}
+		byName[ti.Name] = t.ID
+		tasks = append(tasks, t)
+	}
+
+	var deps []*domain.TaskDependency
+	for i, ti := range in {
+		for _, up := range ti.DependsOn {
+			upID, ok := byName[up]
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: task %q depends on unknown task %q", ErrInvalidTasks, ti.Name, up)
+			}
+			deps = append(deps, &domain.TaskDependency{
+				ID:              uuid.New(),
+				TaskID:          tasks[i].ID,
+				DependsOnTaskID: upID,
+			})
+		}
+	}
+	if _, err := domain.NewDAG(tasks, deps); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidTasks, err)
+	}
+	return tasks, deps, nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// DefaultTestWorkerGroup is the worker group cmd/api queues test executions
+// on unless TASK_TEST_GROUP names another.
+const DefaultTestWorkerGroup = "task-test"
+
+// DefaultTaskTestWait and MaxTaskTestWait bound how long TestTask waits
+// for the test execution to finish.
+const (
+	DefaultTaskTestWait = time.Minute
+	MaxTaskTestWait     = 5 * time.Minute
+)
+
+// Errors returned by TestTask.
+var (
+	// ErrTaskTestingUnavailable is returned when no test queue is
+	// configured.
+	ErrTaskTestingUnavailable = errors.New("task testing is not configured")
+	// ErrInvalidTaskTest is returned for a task type workers do not run,
+	// a wait out of range or a command that does not render.
+	ErrInvalidTaskTest = errors.New("invalid task test")
+)
+
+// WithTaskTesting enables TestTask, which queues test executions on q, the
+// queue of the worker group set aside to run them, so they never wait
+// behind production work or run on its workers. Without it, TestTask
+// returns ErrTaskTestingUnavailable. It also needs WithTasks and
+// WithWorkerNodes.
+func WithTaskTesting(q qdomain.Queue, group string) Option {
+	return func(s *Service) { s.testQueue, s.testGroup = q, group }
+}
+
+// TestTaskInput carries the parameters of a test execution. Params stand in
+// for the run's params in the task's templates; Command, if set, replaces
+// the task's command. WaitSeconds bounds how long the call waits for the
+// result, DefaultTaskTestWait if zero.
+type TestTaskInput struct {
+	Params      map[string]string `json:"params"`
+	Command     string            `json:"command"`
+	WaitSeconds int               `json:"wait_seconds"`
+}
+
+// TaskTestResult is the outcome of a test execution. Complete is false if
+// it had not finished when TestTask stopped waiting, in which case it runs
+// on without anyone to report to.
+type TaskTestResult struct {
+	ID         string             `json:"id"`
+	TaskID     uuid.UUID          `json:"task_id"`
+	Command    string             `json:"command"`
+	Status     qdomain.TaskStatus `json:"status"`
+	Complete   bool               `json:"complete"`
+	WorkerID   string             `json:"worker_id,omitempty"`
+	StartedAt  *time.Time         `json:"started_at,omitempty"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	Logs       string             `json:"logs"`
+}
+
+// TestTask executes task id once, outside any workflow run, on the test
+// worker group and waits for the outcome. The command and env templates
+// are rendered as for a run with in.Params and today's logical date. The
+// execution is not retried and runs no hooks. An unknown task returns
+// repository.ErrNotFound.
+func (s *Service) TestTask(ctx context.Context, id uuid.UUID, in TestTaskInput) (*TaskTestResult, error) {
+	if s.testQueue == nil {
+		return nil, ErrTaskTestingUnavailable
+	}
+	if s.tasks == nil {
+		return nil, ErrTasksUnavailable
+	}
+	if s.queueTasks == nil {
+		return nil, ErrWorkerNodesUnavailable
+	}
+	wait := DefaultTaskTestWait
+	if in.WaitSeconds != 0 {
+		wait = time.Duration(in.WaitSeconds) * time.Second
+	}
+	if wait <= 0 || wait > MaxTaskTestWait {
+		return nil, fmt.Errorf("%w: wait_seconds must be between 1 and %d", ErrInvalidTaskTest, int(MaxTaskTestWait/time.Second))
+	}
+	t, err := s.tasks.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Type == domain.TaskTypeApproval || t.Type == domain.TaskTypeTriggerWorkflow {
+		return nil, fmt.Errorf("%w: %s tasks are not run by workers", ErrInvalidTaskTest, t.Type)
+	}
+	if in.Command != "" {
+		t.Command = in.Command
+		if err := t.ValidateTemplates(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTaskTest, err)
+		}
+	}
+	wfName := ""
+	if wf, err := s.workflows.GetByID(ctx, t.WorkflowID); err == nil {
+		wfName = wf.Name
+	}
+
+	now := time.Now().UTC()
+	run := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: t.WorkflowID, StartedAt: now, Params: in.Params}
+	rendered, err := t.Render(domain.ExecutionContext{Run: run, WorkflowName: wfName, TaskName: t.Name, Attempt: 1})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTaskTest, err)
+	}
+	qt := scheduler.QueueTask("test-"+run.ID.String(), rendered, now)
+	qt.MaxRetries, qt.Retry, qt.Hooks = 0, nil, nil
+	qt.Pool, qt.ConcurrencyKey, qt.Group = "", "", s.testGroup
+	qt.Status, qt.CreatedAt, qt.UpdatedAt = qdomain.TaskStatusQueued, now, now
+	if err := s.queueTasks.Save(ctx, qt); err != nil {
+		return nil, err
+	}
+	// The worker that dequeues qt owns it, so only a copy is polled.
+	queued := *qt
+	if err := s.testQueue.Enqueue(ctx, qt); err != nil {
+		return nil, fmt.Errorf("queue test of task %s: %w", t.Name, err)
+	}
+
+	if qt, err = s.waitForTest(ctx, &queued, wait); err != nil {
+		return nil, err
+	}
+	res := &TaskTestResult{
+		ID:         qt.ID,
+		TaskID:     t.ID,
+		Command:    rendered.Command,
+		Status:     qt.Status,
+		Complete:   qt.IsTerminal(),
+		WorkerID:   qt.WorkerID,
+		StartedAt:  qt.StartedAt,
+		FinishedAt: qt.FinishedAt,
+		Error:      qt.Error,
+	}
+	if s.attempts != nil && res.Complete {
+		attempts, err := s.attempts.ListByTaskID(ctx, qt.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(attempts) > 0 {
+			res.Logs = attempts[len(attempts)-1].Logs
+		}
+	}
+	return res, nil
+}
+
+// waitForTest polls the test execution qt, a copy of the queued task,
+// until it finishes, wait elapses or ctx is done, and returns its latest
+// state. Each poll reads a fresh copy from the repository.
+func (s *Service) waitForTest(ctx context.Context, qt *qdomain.Task, wait time.Duration) (*qdomain.Task, error) {
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+	for !qt.IsTerminal() {
+		select {
+		case <-ctx.Done():
+			return qt, nil
+		case <-deadline.C:
+			return qt, nil
+		case <-ticker.C:
+		}
+		latest, err := s.queueTasks.FindByID(ctx, qt.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return qt, nil
+			}
+			return nil, err
+		}
+		qt = latest
+	}
+	return qt, nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// MaxUsageReportDays bounds the range UsageReport covers.
+const MaxUsageReportDays = 366
+
+// DefaultResourceClass is the resource class reported for tasks without a
+// Queue, which run on their workflow's worker group.
+const DefaultResourceClass = "default"
+
+// ErrInvalidUsageRange is returned when a usage report's range is empty or
+// longer than MaxUsageReportDays.
+var ErrInvalidUsageRange = errors.New("invalid usage report range")
+
+// WithUsageRates sets the price of one second of task execution per
+// resource class, so UsageReport adds a cost to its totals. The rate under
+// "*" prices the classes not listed; without one they cost nothing.
+func WithUsageRates(rates map[string]float64) Option {
+	return func(s *Service) { s.usageRates = rates }
+}
+
+// ParseUsageRates parses per-second usage rates in the form
+// "gpu=0.002,*=0.0001": comma-separated class=rate pairs. An empty string
+// means no rates.
+func ParseUsageRates(s string) (map[string]float64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	rates := make(map[string]float64)
+	for _, entry := range strings.Split(s, ",") {
+		class, v, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || class == "" {
+			return nil, fmt.Errorf("invalid usage rate %q: want class=rate", entry)
+		}
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid usage rate %q: rate must be a non-negative number", entry)
+		}
+		rates[class] = rate
+	}
+	return rates, nil
+}
+
+// UsageReport is the task execution time spent over a range of days, per
+// tenant and workflow, for chargeback.
+type UsageReport struct {
+	// From and To are the first and last day covered, YYYY-MM-DD in UTC.
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Tenants []TenantUsage `json:"tenants"`
+}
+
+// TenantUsage totals the usage of the workflows of one tenant; the empty
+// tenant holds the workflows without one. Cost is set only when usage
+// rates are configured.
+type TenantUsage struct {
+	Tenant           string          `json:"tenant"`
+	TaskRuns         int             `json:"task_runs"`
+	ExecutionSeconds float64         `json:"execution_seconds"`
+	Cost             *float64        `json:"cost,omitempty"`
+	Workflows        []WorkflowUsage `json:"workflows"`
+}
+
+// WorkflowUsage totals the usage of one workflow. Days lists it per day and
+// resource class, oldest first.
+type WorkflowUsage struct {
+	WorkflowID       uuid.UUID  `json:"workflow_id"`
+	Name             string     `json:"name"`
+	TaskRuns         int        `json:"task_runs"`
+	ExecutionSeconds float64    `json:"execution_seconds"`
+	Cost             *float64   `json:"cost,omitempty"`
+	Days             []UsageDay `json:"days"`
+}
+
+// UsageDay is the usage of one workflow on one resource class during one
+// UTC day.
+type UsageDay struct {
+	Date             string   `json:"date"`
+	ResourceClass    string   `json:"resource_class"`
+	TaskRuns         int      `json:"task_runs"`
+	ExecutionSeconds float64  `json:"execution_seconds"`
+	Cost             *float64 `json:"cost,omitempty"`
+}
+
+// UsageReport totals the execution time of the task runs dispatched to a
+// worker between the days from and to, inclusive, that have finished, by
+// tenant, workflow, UTC day and resource class: the Queue of the task, or
+// DefaultResourceClass. A non-empty tenant limits the report to the
+// workflows of that tenant. Usage of deleted workflows is left out.
+func (s *Service) UsageReport(ctx context.Context, from, to time.Time, tenant string) (*UsageReport, error) {
+	if s.stats == nil {
+		return nil, ErrStatsUnavailable
+	}
+	start := truncateDay(from)
+	end := truncateDay(to).AddDate(0, 0, 1)
+	if !start.Before(end) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidUsageRange)
+	}
+	if end.Sub(start) > MaxUsageReportDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: at most %d days", ErrInvalidUsageRange, MaxUsageReportDays)
+	}
+	usage, err := s.stats.DailyUsage(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	wfs, err := s.workflows.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*domain.Workflow, len(wfs))
+	for _, wf := range wfs {
+		byID[wf.ID] = wf
+	}
+
+	tenants := make(map[string]*TenantUsage)
+	workflows := make(map[uuid.UUID]*WorkflowUsage)
+	for _, u := range usage {
+		wf := byID[u.WorkflowID]
+		if wf == nil || (tenant != "" && wf.Tenant != tenant) {
+			continue
+		}
+		tu := tenants[wf.Tenant]
+		if tu == nil {
+			tu = &TenantUsage{Tenant: wf.Tenant}
+			tenants[wf.Tenant] = tu
+		}
+		wu := workflows[wf.ID]
+		if wu == nil {
+			wu = &WorkflowUsage{WorkflowID: wf.ID, Name: wf.Name}
+			workflows[wf.ID] = wu
+		}
+		class := u.ResourceClass
+		if class == "" {
+			class = DefaultResourceClass
+		}
+		day := UsageDay{Date: u.Date, ResourceClass: class, TaskRuns: u.TaskRuns, ExecutionSeconds: u.ExecutionSeconds}
+		if rate, ok := s.usageRate(class); ok {
+			cost := rate * u.ExecutionSeconds
+			day.Cost = &cost
+			wu.Cost = addCost(wu.Cost, cost)
+			tu.Cost = addCost(tu.Cost, cost)
+		}
+		wu.Days = append(wu.Days, day)
+		wu.TaskRuns += u.TaskRuns
+		wu.ExecutionSeconds += u.ExecutionSeconds
+		tu.TaskRuns += u.TaskRuns
+		tu.ExecutionSeconds += u.ExecutionSeconds
+	}
+	for id, wu := range workflows {
+		tu := tenants[byID[id].Tenant]
+		tu.Workflows = append(tu.Workflows, *wu)
+	}
+
+	out := &UsageReport{
+		From:    start.Format(domain.CalendarDateLayout),
+		To:      end.AddDate(0, 0, -1).Format(domain.CalendarDateLayout),
+		Tenants: make([]TenantUsage, 0, len(tenants)),
+	}
+	for _, tu := range tenants {
+		sort.Slice(tu.Workflows, func(i, j int) bool { return tu.Workflows[i].Name < tu.Workflows[j].Name })
+		out.Tenants = append(out.Tenants, *tu)
+	}
+	sort.Slice(out.Tenants, func(i, j int) bool { return out.Tenants[i].Tenant < out.Tenants[j].Tenant })
+	return out, nil
+}
+
+// usageRate returns the per-second rate of the resource class, if usage
+// rates are configured and price it.
+func (s *Service) usageRate(class string) (float64, bool) {
+	if rate, ok := s.usageRates[class]; ok {
+		return rate, true
+	}
+	rate, ok := s.usageRates["*"]
+	return rate, ok
+}
+
+func addCost(total *float64, cost float64) *float64 {
+	sum := cost
+	if total != nil {
+		sum += *total
+	}
+	return &sum
+}
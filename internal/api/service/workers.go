@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// WorkerHeartbeatHistory is how many recent heartbeats GetWorker returns.
+const WorkerHeartbeatHistory = 20
+
+// Errors returned by the worker node use cases.
+var (
+	// ErrWorkerNodesUnavailable is returned when no worker node stores are
+	// configured.
+	ErrWorkerNodesUnavailable = errors.New("worker nodes are not configured")
+	// ErrWorkerControlUnavailable is returned by DrainWorker and
+	// SetWorkerConcurrency when no event bus is configured to carry the
+	// command.
+	ErrWorkerControlUnavailable = errors.New("worker control channel is not configured")
+	// ErrInvalidConcurrency is returned by SetWorkerConcurrency for a limit
+	// below 1.
+	ErrInvalidConcurrency = errors.New("invalid worker concurrency")
+	// ErrWorkerInService is returned by DeleteWorker for a worker that is
+	// idle, busy or quarantined: it has to be drained or gone offline
+	// first.
+	ErrWorkerInService = errors.New("worker is in service")
+	// ErrWorkerNotDeactivated is returned by RestoreWorker for a worker
+	// that is not deactivated.
+	ErrWorkerNotDeactivated = errors.New("worker is not deactivated")
+)
+
+// WithWorkerNodes enables GetWorker, which reads the workers that execute
+// queue tasks, the tasks themselves and the workers' heartbeat history.
+// Without it, GetWorker returns ErrWorkerNodesUnavailable.
+func WithWorkerNodes(nodes qdomain.WorkerRepository, tasks qdomain.TaskRepository, heartbeats qdomain.HeartbeatRepository) Option {
+	return func(s *Service) {
+		s.workerNodes = nodes
+		s.queueTasks = tasks
+		s.heartbeats = heartbeats
+	}
+}
+
+// WorkerDetail is a worker node together with the task runs it is
+// executing and its most recent heartbeats.
+type WorkerDetail struct {
+	ID           string               `json:"id"`
+	Address      string               `json:"address"`
+	Status       qdomain.WorkerStatus `json:"status"`
+	Concurrency  int                  `json:"concurrency"`
+	ActiveTasks  int                  `json:"active_tasks"`
+	FailureRate  float64              `json:"failure_rate"`
+	AvgLatencyMS int64                `json:"avg_latency_ms"`
+	Labels       map[string]string    `json:"labels"`
+	Queues       []string             `json:"queues"`
+	LastHeartAt  time.Time            `json:"last_heart_at"`
+	RegisteredAt time.Time            `json:"registered_at"`
+	RunningTasks []WorkerTask         `json:"running_tasks"`
+	Heartbeats   []WorkerHeartbeat    `json:"heartbeats"`
+	// DeactivatedAt is set while the worker is deactivated.
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+}
+
+// WorkerTask is a task run executing on a worker. ID is the task run ID.
+type WorkerTask struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Type         string     `json:"type"`
+	WorkflowID   string     `json:"workflow_id,omitempty"`
+	DispatchedAt *time.Time `json:"dispatched_at,omitempty"`
+	StartedAt    *time.Time `json:"started_at"`
+	Attempt      int        `json:"attempt"`
+}
+
+// WorkerHeartbeat is one entry of WorkerDetail.Heartbeats.
+type WorkerHeartbeat struct {
+	At          time.Time            `json:"at"`
+	Status      qdomain.WorkerStatus `json:"status"`
+	ActiveTasks int                  `json:"active_tasks"`
+}
+
+// GetWorker returns the worker node with the given ID, the tasks currently
+// running on it and its latest WorkerHeartbeatHistory heartbeats, newest
+// first. An unknown ID returns repository.ErrNotFound.
+func (s *Service) GetWorker(ctx context.Context, id string) (*WorkerDetail, error) {
+	if s.workerNodes == nil {
+		return nil, ErrWorkerNodesUnavailable
+	}
+	w, err := s.workerNodes.FindByID(ctx, id)
+	if errors.Is(err, qdomain.ErrWorkerNotFound) {
+		return nil, fmt.Errorf("%w: worker %s", repository.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	running, err := s.queueTasks.FindByStatus(ctx, qdomain.TaskStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("list running tasks: %w", err)
+	}
+	beats, err := s.heartbeats.ListRecent(ctx, id, WorkerHeartbeatHistory)
+	if err != nil {
+		return nil, fmt.Errorf("list heartbeats: %w", err)
+	}
+
+	d := &WorkerDetail{
+		ID:           w.ID,
+		Address:      w.Address,
+		Status:       w.Status,
+		Concurrency:  w.Concurrency,
+		ActiveTasks:  w.ActiveTasks,
+		FailureRate:  w.FailureRate,
+		AvgLatencyMS: w.AvgLatency.Milliseconds(),
+		Labels:       w.Labels,
+		Queues:       w.Queues,
+		LastHeartAt:  w.LastHeartAt,
+		RegisteredAt: w.RegisteredAt,
+		RunningTasks: []WorkerTask{},
+		Heartbeats:   make([]WorkerHeartbeat, len(beats)),
+
+		DeactivatedAt: w.DeactivatedAt,
+	}
+	for _, t := range running {
+		if t.WorkerID != id {
+			continue
+		}
+		d.RunningTasks = append(d.RunningTasks, WorkerTask{
+			ID:           t.ID,
+			Name:         t.Name,
+			Type:         t.Type,
+			WorkflowID:   t.WorkflowID,
+			DispatchedAt: t.DispatchedAt,
+			StartedAt:    t.StartedAt,
+			Attempt:      t.RetryCount + 1,
+		})
+	}
+	for i, hb := range beats {
+		d.Heartbeats[i] = WorkerHeartbeat{At: hb.At, Status: hb.Status, ActiveTasks: hb.ActiveTasks}
+	}
+	return d, nil
+}
+
+// DeleteWorker takes the worker with the given ID out of service. By
+// default its record is kept, deactivated, so the task runs and attempts
+// naming it still resolve and the worker cannot register again until
+// RestoreWorker; deactivating it again changes nothing. With purge, the
+// record is deleted instead, leaving whatever names it dangling. Either way
+// a worker still in service, idle, busy or quarantined, returns
+// ErrWorkerInService: drain it first. An unknown ID returns
+// repository.ErrNotFound.
+func (s *Service) DeleteWorker(ctx context.Context, id string, purge bool) error {
+	w, err := s.workerNode(ctx, id)
+	if err != nil {
+		return err
+	}
+	switch w.Status {
+	case qdomain.WorkerStatusIdle, qdomain.WorkerStatusBusy, qdomain.WorkerStatusQuarantined:
+		return fmt.Errorf("%w: worker %s is %s", ErrWorkerInService, id, w.Status)
+	}
+	if purge {
+		return s.workerNodes.Delete(ctx, id)
+	}
+	if w.Status == qdomain.WorkerStatusDeactivated {
+		return nil
+	}
+	now := time.Now().UTC()
+	w.Status, w.DeactivatedAt = qdomain.WorkerStatusDeactivated, &now
+	return s.workerNodes.Save(ctx, w)
+}
+
+// RestoreWorker brings the deactivated worker with the given ID back as
+// offline, so it takes tasks again once its process registers. A worker
+// that is not deactivated returns ErrWorkerNotDeactivated; an unknown ID
+// returns repository.ErrNotFound.
+func (s *Service) RestoreWorker(ctx context.Context, id string) (*WorkerDetail, error) {
+	w, err := s.workerNode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if w.Status != qdomain.WorkerStatusDeactivated {
+		return nil, fmt.Errorf("%w: worker %s is %s", ErrWorkerNotDeactivated, id, w.Status)
+	}
+	w.Status, w.DeactivatedAt = qdomain.WorkerStatusOffline, nil
+	if err := s.workerNodes.Save(ctx, w); err != nil {
+		return nil, err
+	}
+	return s.GetWorker(ctx, id)
+}
+
+// workerNode returns the worker node with the given ID, translating an
+// unknown ID to repository.ErrNotFound.
+func (s *Service) workerNode(ctx context.Context, id string) (*qdomain.Worker, error) {
+	if s.workerNodes == nil {
+		return nil, ErrWorkerNodesUnavailable
+	}
+	w, err := s.workerNodes.FindByID(ctx, id)
+	if errors.Is(err, qdomain.ErrWorkerNotFound) {
+		return nil, fmt.Errorf("%w: worker %s", repository.ErrNotFound, id)
+	}
+	return w, err
+}
+
+// DrainWorker sends the worker a drain command over the event bus. A worker
+// started with worker.WithControl then stops taking tasks, finishes the tasks
+// it is running, marks itself drained and exits. An unknown ID returns
+// repository.ErrNotFound.
+func (s *Service) DrainWorker(ctx context.Context, id, by string) (*events.Command, error) {
+	return s.sendWorkerCommand(ctx, &events.Command{WorkerID: id, Action: events.CommandDrain, By: by})
+}
+
+// SetWorkerConcurrency sends the worker a command to run up to n tasks at
+// once. The worker scales without restarting: tasks already running finish
+// even when n is lower than their number.
+func (s *Service) SetWorkerConcurrency(ctx context.Context, id, by string, n int) (*events.Command, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("%w: %d is below 1", ErrInvalidConcurrency, n)
+	}
+	return s.sendWorkerCommand(ctx, &events.Command{WorkerID: id, Action: events.CommandSetConcurrency, Concurrency: n, By: by})
+}
+
+// sendWorkerCommand publishes cmd after checking its worker exists.
+func (s *Service) sendWorkerCommand(ctx context.Context, cmd *events.Command) (*events.Command, error) {
+	if s.workerNodes == nil {
+		return nil, ErrWorkerNodesUnavailable
+	}
+	if s.events == nil {
+		return nil, ErrWorkerControlUnavailable
+	}
+	if _, err := s.workerNodes.FindByID(ctx, cmd.WorkerID); err != nil {
+		if errors.Is(err, qdomain.ErrWorkerNotFound) {
+			return nil, fmt.Errorf("%w: worker %s", repository.ErrNotFound, cmd.WorkerID)
+		}
+		return nil, err
+	}
+	cmd.At = time.Now().UTC()
+	if err := s.events.Publish(ctx, events.Event{Type: events.WorkerCommand, Payload: cmd}); err != nil {
+		return nil, fmt.Errorf("publish %s command: %w", cmd.Action, err)
+	}
+	return cmd, nil
+}
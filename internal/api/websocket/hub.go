@@ -8,8 +8,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
 )
 
 // EventType labels the kind of real-time event being broadcast.
@@ -22,6 +24,8 @@ const (
 	EventWorkflowStatus EventType = "workflow_status"
 	// EventWorkerHeartbeat is emitted when a worker sends a heartbeat.
 	EventWorkerHeartbeat EventType = "worker_heartbeat"
+	// EventAlert is emitted when a backpressure alert fires or resolves.
+	EventAlert EventType = "alert"
 )
 
 // Event is the JSON envelope sent to every connected WebSocket client.
@@ -40,6 +44,12 @@ var upgrader = websocket.Upgrader{
 type Hub struct {
 	mu      sync.RWMutex
 	clients map[*websocket.Conn]struct{}
+	closed  bool
+
+	// writeMu serialises Broadcast calls: a connection supports only one
+	// concurrent writer, and events now arrive from the bus as well as from
+	// request handlers.
+	writeMu sync.Mutex
 }
 
 // NewHub creates an empty Hub.
@@ -50,11 +60,21 @@ func NewHub() *Hub {
 // ServeWS upgrades an HTTP connection to WebSocket, registers the client, and
 // blocks until the connection is closed.
 func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	closed := h.closed
+	h.mu.RUnlock()
+	if closed {
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return
 	}
-	h.register(conn)
+	if !h.register(conn) {
+		_ = conn.Close()
+		return
+	}
 	defer h.unregister(conn)
 
 	// Keep the connection alive; drain incoming messages (we only push, never pull).
@@ -79,6 +99,8 @@ func (h *Hub) Broadcast(ctx context.Context, event Event) {
 	}
 	h.mu.RUnlock()
 
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
 	for _, c := range clients {
 		select {
 		case <-ctx.Done():
@@ -91,10 +113,42 @@ func (h *Hub) Broadcast(ctx context.Context, event Event) {
 	}
 }
 
-func (h *Hub) register(c *websocket.Conn) {
+// Relay broadcasts every event received from ch, typically a subscription to
+// the event bus the scheduler and workers publish to, until ch is closed.
+func (h *Hub) Relay(ch <-chan events.Event) {
+	for e := range ch {
+		h.Broadcast(context.Background(), Event{Type: EventType(e.Type), Payload: e.Payload})
+	}
+}
+
+// Close tells every client the server is going away and disconnects it,
+// and refuses connections from then on. http.Server.Shutdown does not
+// close upgraded connections, so servers register it with
+// RegisterOnShutdown.
+func (h *Hub) Close() {
 	h.mu.Lock()
-	h.clients[c] = struct{}{}
+	h.closed = true
+	clients := h.clients
+	h.clients = make(map[*websocket.Conn]struct{})
 	h.mu.Unlock()
+
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	deadline := time.Now().Add(time.Second)
+	for c := range clients {
+		_ = c.WriteControl(websocket.CloseMessage, msg, deadline)
+		_ = c.Close()
+	}
+}
+
+// register adds c to the clients, unless the hub is closed.
+func (h *Hub) register(c *websocket.Conn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.clients[c] = struct{}{}
+	return true
 }
 
 func (h *Hub) unregister(c *websocket.Conn) {
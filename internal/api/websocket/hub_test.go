@@ -6,9 +6,11 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/websocket"
 	ws "github.com/sauravritesh63/GoLang-Project-/internal/api/websocket"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
 )
 
 // TestNewHub_NotNil ensures NewHub returns a non-nil Hub.
@@ -106,3 +108,48 @@ func TestEventTypeConstants(t *testing.T) {
 		}
 	}
 }
+
+// TestRelay_ForwardsBusEvents verifies that events published on the bus reach
+// connected WebSocket clients once the hub relays the subscription.
+func TestRelay_ForwardsBusEvents(t *testing.T) {
+	hub := ws.NewHub()
+	conn, cleanup := dialHub(t, hub)
+	defer cleanup()
+
+	bus := events.NewMemBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub, _ := bus.Subscribe(ctx)
+	go hub.Relay(sub)
+
+	// The client registers asynchronously, so keep publishing until it
+	// receives something.
+	go func() {
+		for ctx.Err() == nil {
+			_ = bus.Publish(ctx, events.Event{Type: events.WorkflowStatus, Payload: map[string]string{"id": "run-1"}})
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if !strings.Contains(string(msg), `"workflow_status"`) || !strings.Contains(string(msg), "run-1") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
+// TestClose_RefusesNewClients verifies a closed hub turns away new
+// connections instead of registering them.
+func TestClose_RefusesNewClients(t *testing.T) {
+	hub := ws.NewHub()
+	hub.Close()
+	srv := httptest.NewServer(http.HandlerFunc(hub.ServeWS))
+	defer srv.Close()
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("dial after Close: got %v, want 503", err)
+	}
+}
@@ -0,0 +1,145 @@
+// Package backend constructs the stores the API, scheduler and worker
+// binaries share. Pointing every binary at the same DATABASE_URL and
+// QUEUE_URL lets a workflow triggered through the API be dispatched by the
+// scheduler and executed by a worker.
+package backend
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	pgRepo "github.com/sauravritesh63/GoLang-Project-/internal/repository/postgres"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+	pgdriver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Stores groups every repository used by the binaries.
+type Stores struct {
+	Workflows    repository.WorkflowRepository
+	Tasks        repository.TaskRepository
+	TaskDeps     repository.TaskDependencyRepository
+	WorkflowRuns repository.WorkflowRunRepository
+	TaskRuns     repository.TaskRunRepository
+	Workers      repository.WorkerRepository
+	Approvals    repository.ApprovalRepository
+	Backfills    repository.BackfillRepository
+	Stats        repository.StatsRepository
+	Lineage      repository.LineageRepository
+	Calendars    repository.CalendarRepository
+	Retention    repository.RetentionRepository
+	// Events keeps the history of events published on the bus.
+	Events repository.EventRepository
+
+	// QueueTasks and QueueWorkers hold execution state of dispatched tasks
+	// and the workers running them; Heartbeats keeps the workers' recent
+	// heartbeats and Attempts the earlier executions of retried tasks.
+	QueueTasks   qdomain.TaskRepository
+	QueueWorkers qdomain.WorkerRepository
+	Heartbeats   qdomain.HeartbeatRepository
+	Attempts     qdomain.AttemptRepository
+	// Freeze is the switch that stops every worker taking queued tasks.
+	Freeze qdomain.FreezeRepository
+	// Secrets holds the values task env secret:// references resolve to.
+	Secrets qdomain.SecretStore
+
+	// Shared reports whether the stores are visible to other processes.
+	Shared bool
+}
+
+// OpenStores connects to the PostgreSQL database at databaseURL. An empty
+// URL returns in-memory stores private to the calling process.
+func OpenStores(databaseURL string) (*Stores, error) {
+	if databaseURL == "" {
+		tasks := mock.NewTaskRepo()
+		workflowRuns, taskRuns := mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo()
+		return &Stores{
+			Workflows:    mock.NewWorkflowRepo(),
+			Tasks:        tasks,
+			TaskDeps:     mock.NewTaskDependencyRepo(tasks),
+			WorkflowRuns: workflowRuns,
+			TaskRuns:     taskRuns,
+			Workers:      mock.NewWorkerRepo(),
+			Approvals:    mock.NewApprovalRepo(taskRuns),
+			Backfills:    mock.NewBackfillRepo(),
+			Stats:        mock.NewStatsRepo(workflowRuns, taskRuns, tasks),
+			Lineage:      mock.NewLineageRepo(),
+			Calendars:    mock.NewCalendarRepo(),
+			Retention:    mock.NewRetentionRepo(workflowRuns, taskRuns),
+			Events:       mock.NewEventRepo(),
+			QueueTasks:   scheduler.NewMemTaskRepo(),
+			QueueWorkers: scheduler.NewMemWorkerRepo(),
+			Heartbeats:   scheduler.NewMemHeartbeatRepo(),
+			Attempts:     scheduler.NewMemAttemptRepo(),
+			Freeze:       scheduler.NewMemFreezeRepo(),
+			Secrets:      scheduler.NewMemSecretStore(),
+		}, nil
+	}
+
+	db, err := gorm.Open(pgdriver.Open(databaseURL), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("connect to postgres: %w", err)
+	}
+	return postgresStores(db), nil
+}
+
+// OpenReadOnlyStores is OpenStores for a process that must not change the
+// data, such as an API serving run history from a replica or a snapshot:
+// every PostgreSQL transaction it starts is read-only, so the database
+// refuses a write even if one slips through.
+func OpenReadOnlyStores(databaseURL string) (*Stores, error) {
+	if databaseURL == "" {
+		return OpenStores("")
+	}
+	dsn, err := readOnlyDSN(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return OpenStores(dsn)
+}
+
+// readOnlyDSN adds default_transaction_read_only=on, which the driver sends
+// as a session parameter, to databaseURL, in URL or key=value form.
+func readOnlyDSN(databaseURL string) (string, error) {
+	if !strings.Contains(databaseURL, "://") {
+		return databaseURL + " default_transaction_read_only=on", nil
+	}
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "", fmt.Errorf("parse database URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("default_transaction_read_only", "on")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// postgresStores returns the stores backed by db.
+func postgresStores(db *gorm.DB) *Stores {
+	return &Stores{
+		Workflows:    pgRepo.NewWorkflowRepo(db),
+		Tasks:        pgRepo.NewTaskRepo(db),
+		TaskDeps:     pgRepo.NewTaskDependencyRepo(db),
+		WorkflowRuns: pgRepo.NewWorkflowRunRepo(db),
+		TaskRuns:     pgRepo.NewTaskRunRepo(db),
+		Workers:      pgRepo.NewWorkerRepo(db),
+		Approvals:    pgRepo.NewApprovalRepo(db),
+		Backfills:    pgRepo.NewBackfillRepo(db),
+		Stats:        pgRepo.NewStatsRepo(db),
+		Lineage:      pgRepo.NewLineageRepo(db),
+		Calendars:    pgRepo.NewCalendarRepo(db),
+		Retention:    pgRepo.NewRetentionRepo(db),
+		Events:       pgRepo.NewEventRepo(db),
+		QueueTasks:   pgRepo.NewQueueTaskRepo(db),
+		QueueWorkers: pgRepo.NewWorkerNodeRepo(db),
+		Heartbeats:   pgRepo.NewHeartbeatRepo(db),
+		Attempts:     pgRepo.NewAttemptRepo(db),
+		Freeze:       pgRepo.NewFreezeRepo(db),
+		Secrets:      pgRepo.NewSecretStore(db),
+		Shared:       true,
+	}
+}
@@ -0,0 +1,28 @@
+package backend_test
+
+import (
+	"testing"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/backend"
+)
+
+func TestOpenStores_InMemory(t *testing.T) {
+	s, err := backend.OpenStores("")
+	if err != nil {
+		t.Fatalf("OpenStores: %v", err)
+	}
+	if s.Shared {
+		t.Error("in-memory stores must not report Shared")
+	}
+	for name, r := range map[string]any{
+		"Workflows": s.Workflows, "Tasks": s.Tasks, "TaskDeps": s.TaskDeps,
+		"WorkflowRuns": s.WorkflowRuns, "TaskRuns": s.TaskRuns, "Workers": s.Workers,
+		"Approvals": s.Approvals, "Backfills": s.Backfills, "Retention": s.Retention,
+		"QueueTasks": s.QueueTasks, "QueueWorkers": s.QueueWorkers,
+		"Heartbeats": s.Heartbeats, "Freeze": s.Freeze, "Secrets": s.Secrets,
+	} {
+		if r == nil {
+			t.Errorf("%s is nil", name)
+		}
+	}
+}
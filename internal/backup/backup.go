@@ -0,0 +1,243 @@
+// Package backup copies a deployment's workflow definitions, and
+// optionally its recent run history, into a portable archive and restores
+// them into another deployment, e.g. a fresh one in a disaster recovery
+// drill. Records keep their IDs, so links between them survive the trip.
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// Version is the archive format Snapshot writes and Restore reads.
+const Version = 1
+
+// ErrConflict is returned by Restore when the target already holds a
+// workflow of the archive.
+var ErrConflict = errors.New("backup: target already holds archived workflows")
+
+// Archive is a snapshot of a deployment.
+type Archive struct {
+	Version   int                `json:"version"`
+	CreatedAt time.Time          `json:"created_at"`
+	Calendars []*domain.Calendar `json:"calendars"`
+	Workflows []*Workflow        `json:"workflows"`
+}
+
+// Workflow is one archived workflow with its task graph and, if the
+// snapshot included them, its finished runs.
+type Workflow struct {
+	Workflow     *domain.Workflow         `json:"workflow"`
+	Tasks        []*domain.Task           `json:"tasks"`
+	Dependencies []*domain.TaskDependency `json:"dependencies"`
+	Runs         []*Run                   `json:"runs,omitempty"`
+}
+
+// Run is one archived workflow run with its task runs.
+type Run struct {
+	Run      *domain.WorkflowRun `json:"run"`
+	TaskRuns []*domain.TaskRun   `json:"task_runs"`
+}
+
+// Stores are the repositories Snapshot reads and Restore writes.
+type Stores struct {
+	Workflows    repository.WorkflowRepository
+	Tasks        repository.TaskRepository
+	TaskDeps     repository.TaskDependencyRepository
+	Calendars    repository.CalendarRepository
+	WorkflowRuns repository.WorkflowRunRepository
+	TaskRuns     repository.TaskRunRepository
+}
+
+// Options selects what Snapshot archives besides definitions. Runs that
+// finished at or after RunsSince are included; the zero time includes
+// none. Runs still in progress are never included, as no other deployment
+// could carry them on.
+type Options struct {
+	RunsSince time.Time
+}
+
+// Snapshot archives every workflow in s with its tasks, dependencies and
+// calendar, and the runs opt selects.
+func Snapshot(ctx context.Context, s Stores, opt Options) (*Archive, error) {
+	wfs, err := s.Workflows.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("backup: list workflows: %w", err)
+	}
+	a := &Archive{Version: Version, CreatedAt: time.Now().UTC(), Calendars: []*domain.Calendar{}, Workflows: make([]*Workflow, 0, len(wfs))}
+	calendars := make(map[uuid.UUID]bool)
+	for _, wf := range wfs {
+		aw := &Workflow{Workflow: wf}
+		if aw.Tasks, err = s.Tasks.ListByWorkflowID(ctx, wf.ID); err != nil {
+			return nil, fmt.Errorf("backup: workflow %s: list tasks: %w", wf.Name, err)
+		}
+		if aw.Dependencies, err = s.TaskDeps.ListByWorkflowID(ctx, wf.ID); err != nil {
+			return nil, fmt.Errorf("backup: workflow %s: list dependencies: %w", wf.Name, err)
+		}
+		if id := wf.CalendarID; id != nil && !calendars[*id] && s.Calendars != nil {
+			cal, err := s.Calendars.GetByID(ctx, *id)
+			if err != nil {
+				return nil, fmt.Errorf("backup: workflow %s: calendar %s: %w", wf.Name, *id, err)
+			}
+			calendars[*id] = true
+			a.Calendars = append(a.Calendars, cal)
+		}
+		if !opt.RunsSince.IsZero() {
+			if aw.Runs, err = snapshotRuns(ctx, s, wf, opt.RunsSince); err != nil {
+				return nil, err
+			}
+		}
+		a.Workflows = append(a.Workflows, aw)
+	}
+	return a, nil
+}
+
+// snapshotRuns returns the runs of wf that finished at or after since.
+func snapshotRuns(ctx context.Context, s Stores, wf *domain.Workflow, since time.Time) ([]*Run, error) {
+	wrs, err := s.WorkflowRuns.ListByWorkflowID(ctx, wf.ID)
+	if err != nil {
+		return nil, fmt.Errorf("backup: workflow %s: list runs: %w", wf.Name, err)
+	}
+	var runs []*Run
+	for _, wr := range wrs {
+		if !wr.Status.IsTerminal() || wr.FinishedAt == nil || wr.FinishedAt.Before(since) {
+			continue
+		}
+		trs, err := s.TaskRuns.ListByWorkflowRunID(ctx, wr.ID)
+		if err != nil {
+			return nil, fmt.Errorf("backup: run %s: list task runs: %w", wr.ID, err)
+		}
+		runs = append(runs, &Run{Run: wr, TaskRuns: trs})
+	}
+	return runs, nil
+}
+
+// Result counts the records Restore created.
+type Result struct {
+	Calendars    int `json:"calendars"`
+	Workflows    int `json:"workflows"`
+	Tasks        int `json:"tasks"`
+	Dependencies int `json:"dependencies"`
+	Runs         int `json:"runs"`
+	TaskRuns     int `json:"task_runs"`
+}
+
+// Restore creates the records of a in s. It refuses, with ErrConflict and
+// before writing anything, if s already holds any of a's workflows.
+// Calendars s already holds are left as they are. Runs are restored oldest
+// first, and a run started by a run that was not archived loses that link.
+// Restore is not atomic: if it fails part way, the Result tells how far it
+// got.
+func Restore(ctx context.Context, s Stores, a *Archive) (*Result, error) {
+	if a.Version != Version {
+		return nil, fmt.Errorf("backup: unsupported archive version %d", a.Version)
+	}
+	for _, aw := range a.Workflows {
+		_, err := s.Workflows.GetByID(ctx, aw.Workflow.ID)
+		if err == nil {
+			return nil, fmt.Errorf("%w: workflow %s (%s)", ErrConflict, aw.Workflow.Name, aw.Workflow.ID)
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("backup: look up workflow %s: %w", aw.Workflow.Name, err)
+		}
+	}
+
+	res := &Result{}
+	for _, cal := range a.Calendars {
+		if s.Calendars == nil {
+			return res, errors.New("backup: archive holds calendars but the target has no calendar store")
+		}
+		if _, err := s.Calendars.GetByID(ctx, cal.ID); err == nil {
+			continue
+		}
+		if err := s.Calendars.Create(ctx, cal); err != nil {
+			return res, fmt.Errorf("backup: create calendar %s: %w", cal.Name, err)
+		}
+		res.Calendars++
+	}
+	var runs []*Run
+	for _, aw := range a.Workflows {
+		if err := restoreWorkflow(ctx, s, aw, res); err != nil {
+			return res, err
+		}
+		runs = append(runs, aw.Runs...)
+	}
+
+	// Runs may be started by runs of other workflows, so they go in once
+	// every workflow is in, oldest first.
+	slices.SortStableFunc(runs, func(a, b *Run) int { return a.Run.StartedAt.Compare(b.Run.StartedAt) })
+	archived := make(map[uuid.UUID]bool, len(runs))
+	for _, r := range runs {
+		archived[r.Run.ID] = true
+	}
+	for _, r := range runs {
+		if id := r.Run.TriggeredByRunID; id != nil && !archived[*id] {
+			r.Run.TriggeredByRunID = nil
+		}
+		if err := s.WorkflowRuns.Create(ctx, r.Run); err != nil {
+			return res, fmt.Errorf("backup: create run %s: %w", r.Run.ID, err)
+		}
+		res.Runs++
+		for _, tr := range r.TaskRuns {
+			if err := s.TaskRuns.Create(ctx, tr); err != nil {
+				return res, fmt.Errorf("backup: create task run %s: %w", tr.ID, err)
+			}
+			res.TaskRuns++
+		}
+	}
+	return res, nil
+}
+
+// restoreWorkflow creates aw's workflow, tasks and dependencies in s.
+func restoreWorkflow(ctx context.Context, s Stores, aw *Workflow, res *Result) error {
+	if err := s.Workflows.Create(ctx, aw.Workflow); err != nil {
+		return fmt.Errorf("backup: create workflow %s: %w", aw.Workflow.Name, err)
+	}
+	res.Workflows++
+	for _, t := range aw.Tasks {
+		if err := s.Tasks.Create(ctx, t); err != nil {
+			return fmt.Errorf("backup: workflow %s: create task %s: %w", aw.Workflow.Name, t.Name, err)
+		}
+		res.Tasks++
+	}
+	for _, d := range aw.Dependencies {
+		if err := s.TaskDeps.Create(ctx, d); err != nil {
+			return fmt.Errorf("backup: workflow %s: create dependency %s: %w", aw.Workflow.Name, d.ID, err)
+		}
+		res.Dependencies++
+	}
+	return nil
+}
+
+// Write encodes a to w as gzip-compressed JSON.
+func Write(w io.Writer, a *Archive) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(a); err != nil {
+		return fmt.Errorf("backup: encode archive: %w", err)
+	}
+	return zw.Close()
+}
+
+// Read decodes an archive written by Write.
+func Read(r io.Reader) (*Archive, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("backup: read archive: %w", err)
+	}
+	defer zr.Close()
+	var a Archive
+	if err := json.NewDecoder(zr).Decode(&a); err != nil {
+		return nil, fmt.Errorf("backup: decode archive: %w", err)
+	}
+	return &a, nil
+}
@@ -0,0 +1,144 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/backup"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+)
+
+func newStores() backup.Stores {
+	tasks := mock.NewTaskRepo()
+	return backup.Stores{
+		Workflows:    mock.NewWorkflowRepo(),
+		Tasks:        tasks,
+		TaskDeps:     mock.NewTaskDependencyRepo(tasks),
+		Calendars:    mock.NewCalendarRepo(),
+		WorkflowRuns: mock.NewWorkflowRunRepo(),
+		TaskRuns:     mock.NewTaskRunRepo(),
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	src := newStores()
+
+	cal := &domain.Calendar{ID: uuid.New(), Name: "holidays", ExcludedDates: []string{"2026-12-25"}}
+	wf := &domain.Workflow{ID: uuid.New(), Name: "etl", ScheduleCron: "0 * * * *", IsActive: true, CalendarID: &cal.ID}
+	extract := &domain.Task{ID: uuid.New(), WorkflowID: wf.ID, Name: "extract", Command: "echo extract"}
+	load := &domain.Task{ID: uuid.New(), WorkflowID: wf.ID, Name: "load", Command: "echo load"}
+	dep := &domain.TaskDependency{ID: uuid.New(), TaskID: load.ID, DependsOnTaskID: extract.ID}
+
+	// old finished before the window, parent is recent, child was started
+	// by parent and running is still in progress.
+	finished := func(ago time.Duration) *time.Time { at := now.Add(-ago); return &at }
+	old := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusSuccess, StartedAt: now.Add(-49 * time.Hour), FinishedAt: finished(48 * time.Hour)}
+	parent := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusSuccess, StartedAt: now.Add(-3 * time.Hour), FinishedAt: finished(2 * time.Hour), TriggeredByRunID: &old.ID}
+	child := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusFailed, StartedAt: now.Add(-2 * time.Hour), FinishedAt: finished(time.Hour), TriggeredByRunID: &parent.ID}
+	running := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusRunning, StartedAt: now}
+	tr := &domain.TaskRun{ID: uuid.New(), WorkflowRunID: child.ID, TaskID: extract.ID, Status: domain.StatusFailed, Attempt: 1, StartedAt: child.StartedAt, Logs: "boom"}
+
+	must(t, src.Calendars.Create(ctx, cal))
+	must(t, src.Workflows.Create(ctx, wf))
+	must(t, src.Tasks.Create(ctx, extract))
+	must(t, src.Tasks.Create(ctx, load))
+	must(t, src.TaskDeps.Create(ctx, dep))
+	for _, wr := range []*domain.WorkflowRun{old, parent, child, running} {
+		must(t, src.WorkflowRuns.Create(ctx, wr))
+	}
+	must(t, src.TaskRuns.Create(ctx, tr))
+
+	a, err := backup.Snapshot(ctx, src, backup.Options{RunsSince: now.Add(-24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	var buf bytes.Buffer
+	must(t, backup.Write(&buf, a))
+	a, err = backup.Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	dst := newStores()
+	res, err := backup.Restore(ctx, dst, a)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	want := backup.Result{Calendars: 1, Workflows: 1, Tasks: 2, Dependencies: 1, Runs: 2, TaskRuns: 1}
+	if *res != want {
+		t.Errorf("Restore = %+v, want %+v", *res, want)
+	}
+
+	got, err := dst.Workflows.GetByID(ctx, wf.ID)
+	if err != nil || got.Name != "etl" || got.CalendarID == nil || *got.CalendarID != cal.ID {
+		t.Errorf("restored workflow = %+v, %v", got, err)
+	}
+	if c, err := dst.Calendars.GetByID(ctx, cal.ID); err != nil || len(c.ExcludedDates) != 1 {
+		t.Errorf("restored calendar = %+v, %v", c, err)
+	}
+	deps, _ := dst.TaskDeps.ListByWorkflowID(ctx, wf.ID)
+	if len(deps) != 1 || deps[0].TaskID != load.ID || deps[0].DependsOnTaskID != extract.ID {
+		t.Errorf("restored dependencies = %+v", deps)
+	}
+	runs, _ := dst.WorkflowRuns.ListByWorkflowID(ctx, wf.ID)
+	if len(runs) != 2 {
+		t.Fatalf("restored %d runs, want parent and child", len(runs))
+	}
+	for _, wr := range runs {
+		switch wr.ID {
+		case parent.ID:
+			if wr.TriggeredByRunID != nil {
+				t.Errorf("parent still triggered by unarchived run %s", *wr.TriggeredByRunID)
+			}
+		case child.ID:
+			if wr.TriggeredByRunID == nil || *wr.TriggeredByRunID != parent.ID {
+				t.Errorf("child triggered by %v, want parent", wr.TriggeredByRunID)
+			}
+		default:
+			t.Errorf("restored unexpected run %s (%s)", wr.ID, wr.Status)
+		}
+	}
+	trs, _ := dst.TaskRuns.ListByWorkflowRunID(ctx, child.ID)
+	if len(trs) != 1 || trs[0].Logs != "boom" {
+		t.Errorf("restored task runs = %+v", trs)
+	}
+
+	// A second restore into the same target is refused before writing.
+	if _, err := backup.Restore(ctx, dst, a); !errors.Is(err, backup.ErrConflict) {
+		t.Errorf("second Restore = %v, want ErrConflict", err)
+	}
+}
+
+func TestSnapshot_DefinitionsOnly(t *testing.T) {
+	ctx := context.Background()
+	src := newStores()
+	wf := &domain.Workflow{ID: uuid.New(), Name: "etl", IsActive: true}
+	must(t, src.Workflows.Create(ctx, wf))
+	must(t, src.WorkflowRuns.Create(ctx, &domain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: domain.StatusSuccess, StartedAt: time.Now().UTC()}))
+
+	a, err := backup.Snapshot(ctx, src, backup.Options{})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if len(a.Workflows) != 1 || len(a.Workflows[0].Runs) != 0 || len(a.Calendars) != 0 {
+		t.Errorf("Snapshot = %d workflows, %d calendars, runs %v; want definitions only", len(a.Workflows), len(a.Calendars), a.Workflows[0].Runs)
+	}
+	a.Version = backup.Version + 1
+	if _, err := backup.Restore(ctx, newStores(), a); err == nil {
+		t.Error("Restore of an unknown archive version succeeded")
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CalendarDateLayout is the layout of Calendar.ExcludedDates entries.
+const CalendarDateLayout = "2006-01-02"
+
+// Calendar lists days on which the scheduled runs of the workflows attached
+// to it are skipped, e.g. public holidays or maintenance windows.
+type Calendar struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	// ExcludedDates are calendar days in CalendarDateLayout, interpreted in
+	// each workflow's own timezone.
+	ExcludedDates []string `json:"excluded_dates"`
+	// ICalURL optionally names an iCalendar feed whose events are excluded
+	// in addition to ExcludedDates.
+	ICalURL   string    `json:"ical_url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Excludes reports whether day (in CalendarDateLayout) is one of the
+// calendar's ExcludedDates.
+func (c *Calendar) Excludes(day string) bool {
+	for _, d := range c.ExcludedDates {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
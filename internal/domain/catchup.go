@@ -0,0 +1,25 @@
+package domain
+
+// CatchupPolicy decides which of the fires a workflow's schedule missed
+// while no scheduler was running are run when one starts again.
+type CatchupPolicy string
+
+const (
+	// CatchupNone drops missed fires. It is the default.
+	CatchupNone CatchupPolicy = "none"
+	// CatchupLatest runs the most recent missed fire only, for workflows
+	// whose runs each process everything up to their logical date.
+	CatchupLatest CatchupPolicy = "latest"
+	// CatchupAll runs every missed fire, oldest first, for workflows whose
+	// runs each process their own interval.
+	CatchupAll CatchupPolicy = "all"
+)
+
+// Valid reports whether p is empty or one of the known policies.
+func (p CatchupPolicy) Valid() bool {
+	switch p {
+	case "", CatchupNone, CatchupLatest, CatchupAll:
+		return true
+	}
+	return false
+}
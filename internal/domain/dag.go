@@ -0,0 +1,245 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// TriggerRule decides whether a task runs once its upstream tasks have
+// settled. A task whose rule can no longer be met is marked StatusSkipped,
+// which its own downstream tasks then see as an upstream state.
+type TriggerRule string
+
+const (
+	// TriggerAllSuccess runs when every upstream succeeded. It is the default.
+	TriggerAllSuccess TriggerRule = "all_success"
+	// TriggerAllFailed runs when every upstream failed.
+	TriggerAllFailed TriggerRule = "all_failed"
+	// TriggerAllDone runs once every upstream is terminal, whatever the outcome.
+	TriggerAllDone TriggerRule = "all_done"
+	// TriggerOneSuccess runs as soon as any upstream succeeded.
+	TriggerOneSuccess TriggerRule = "one_success"
+	// TriggerOneFailed runs as soon as any upstream failed.
+	TriggerOneFailed TriggerRule = "one_failed"
+	// TriggerNoneFailed runs when no upstream failed; skipped upstreams are
+	// accepted, which makes it the rule for joins after a branch.
+	TriggerNoneFailed TriggerRule = "none_failed"
+)
+
+// Valid reports whether r is empty or one of the known trigger rules.
+func (r TriggerRule) Valid() bool {
+	switch r {
+	case "", TriggerAllSuccess, TriggerAllFailed, TriggerAllDone,
+		TriggerOneSuccess, TriggerOneFailed, TriggerNoneFailed:
+		return true
+	}
+	return false
+}
+
+// DAG is the dependency graph of a workflow's tasks.
+type DAG struct {
+	order    []uuid.UUID
+	tasks    map[uuid.UUID]*Task
+	upstream map[uuid.UUID][]uuid.UUID
+}
+
+// NewDAG builds the graph for tasks linked by deps. It fails if a dependency
+// references an unknown task, a trigger rule is unknown, or the graph has a
+// cycle.
+func NewDAG(tasks []*Task, deps []*TaskDependency) (*DAG, error) {
+	d := &DAG{
+		tasks:    make(map[uuid.UUID]*Task, len(tasks)),
+		upstream: make(map[uuid.UUID][]uuid.UUID),
+	}
+	for _, t := range tasks {
+		if !t.TriggerRule.Valid() {
+			return nil, fmt.Errorf("task %s: unknown trigger rule %q", t.ID, t.TriggerRule)
+		}
+		d.tasks[t.ID] = t
+	}
+	for _, dep := range deps {
+		if _, ok := d.tasks[dep.TaskID]; !ok {
+			return nil, fmt.Errorf("dependency %s: unknown task %s", dep.ID, dep.TaskID)
+		}
+		if _, ok := d.tasks[dep.DependsOnTaskID]; !ok {
+			return nil, fmt.Errorf("dependency %s: unknown upstream task %s", dep.ID, dep.DependsOnTaskID)
+		}
+		d.upstream[dep.TaskID] = append(d.upstream[dep.TaskID], dep.DependsOnTaskID)
+	}
+
+	// Kahn's algorithm gives a topological order and detects cycles.
+	indegree := make(map[uuid.UUID]int, len(tasks))
+	downstream := make(map[uuid.UUID][]uuid.UUID)
+	for id, ups := range d.upstream {
+		indegree[id] = len(ups)
+		for _, up := range ups {
+			downstream[up] = append(downstream[up], id)
+		}
+	}
+	var queue []uuid.UUID
+	for _, t := range tasks {
+		if indegree[t.ID] == 0 {
+			queue = append(queue, t.ID)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		d.order = append(d.order, id)
+		for _, down := range downstream[id] {
+			indegree[down]--
+			if indegree[down] == 0 {
+				queue = append(queue, down)
+			}
+		}
+	}
+	if len(d.order) != len(tasks) {
+		return nil, fmt.Errorf("task dependencies contain a cycle")
+	}
+	return d, nil
+}
+
+// Upstream returns the IDs of the tasks id depends on.
+func (d *DAG) Upstream(id uuid.UUID) []uuid.UUID {
+	return d.upstream[id]
+}
+
+// Descendants returns the IDs of every task that depends on id, directly or
+// transitively, in topological order. id itself is not included.
+func (d *DAG) Descendants(id uuid.UUID) []uuid.UUID {
+	reached := map[uuid.UUID]bool{id: true}
+	var out []uuid.UUID
+	for _, t := range d.order {
+		for _, up := range d.upstream[t] {
+			if reached[up] {
+				reached[t] = true
+				out = append(out, t)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Evaluate applies trigger rules to the current task states of a run. Tasks
+// absent from states are treated as pending. It returns the pending tasks
+// that may start now and the tasks that must be marked StatusSkipped; skips
+// cascade, so a single call settles the whole graph. states is not modified.
+func (d *DAG) Evaluate(states map[uuid.UUID]Status) (ready, skipped []uuid.UUID) {
+	resolved := make(map[uuid.UUID]Status, len(states))
+	for id, s := range states {
+		resolved[id] = s
+	}
+	// Visiting in topological order means every upstream skip decided in
+	// this pass is already visible when its downstream tasks are evaluated.
+	for _, id := range d.order {
+		if s, ok := resolved[id]; ok && s != StatusPending {
+			continue
+		}
+		switch d.decide(id, resolved) {
+		case decisionRun:
+			ready = append(ready, id)
+		case decisionSkip:
+			resolved[id] = StatusSkipped
+			skipped = append(skipped, id)
+		}
+	}
+	return ready, skipped
+}
+
+// RunStatus derives a workflow run's status from its task states: running
+// while any task is unfinished, failed if any task failed, success otherwise.
+// Skipped tasks count as finished without affecting the outcome.
+func (d *DAG) RunStatus(states map[uuid.UUID]Status) Status {
+	failed := false
+	for id := range d.tasks {
+		s := states[id]
+		if !s.IsTerminal() {
+			return StatusRunning
+		}
+		if s == StatusFailed {
+			failed = true
+		}
+	}
+	if failed {
+		return StatusFailed
+	}
+	return StatusSuccess
+}
+
+type decision int
+
+const (
+	decisionWait decision = iota
+	decisionRun
+	decisionSkip
+)
+
+// decide evaluates the trigger rule of a pending task. A root task has no
+// upstream state for its rule to test, so it runs whatever the rule.
+func (d *DAG) decide(id uuid.UUID, states map[uuid.UUID]Status) decision {
+	ups := d.upstream[id]
+	if len(ups) == 0 {
+		return decisionRun
+	}
+	var success, failed, done int
+	for _, up := range ups {
+		switch states[up] {
+		case StatusSuccess:
+			success++
+			done++
+		case StatusFailed:
+			failed++
+			done++
+		case StatusSkipped:
+			done++
+		}
+	}
+	settled := done == len(ups)
+
+	switch d.tasks[id].TriggerRule {
+	case TriggerOneSuccess:
+		if success > 0 {
+			return decisionRun
+		}
+	case TriggerOneFailed:
+		if failed > 0 {
+			return decisionRun
+		}
+	case TriggerAllFailed:
+		if success > 0 || done-success-failed > 0 {
+			return decisionSkip
+		}
+		if settled {
+			return decisionRun
+		}
+		return decisionWait
+	case TriggerAllDone:
+		if settled {
+			return decisionRun
+		}
+		return decisionWait
+	case TriggerNoneFailed:
+		if failed > 0 {
+			return decisionSkip
+		}
+		if settled {
+			return decisionRun
+		}
+		return decisionWait
+	default: // TriggerAllSuccess
+		if done > success {
+			return decisionSkip
+		}
+		if settled {
+			return decisionRun
+		}
+		return decisionWait
+	}
+	// one_success / one_failed not yet met.
+	if settled {
+		return decisionSkip
+	}
+	return decisionWait
+}
@@ -0,0 +1,147 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// chain builds tasks a -> b -> c (b depends on a, c on b) with the given
+// trigger rules.
+func chain(rules ...domain.TriggerRule) ([]*domain.Task, []*domain.TaskDependency) {
+	var tasks []*domain.Task
+	var deps []*domain.TaskDependency
+	for i, r := range rules {
+		tasks = append(tasks, &domain.Task{ID: uuid.New(), TriggerRule: r})
+		if i > 0 {
+			deps = append(deps, &domain.TaskDependency{ID: uuid.New(), TaskID: tasks[i].ID, DependsOnTaskID: tasks[i-1].ID})
+		}
+	}
+	return tasks, deps
+}
+
+func TestNewDAG_RejectsCycle(t *testing.T) {
+	tasks, deps := chain("", "")
+	deps = append(deps, &domain.TaskDependency{ID: uuid.New(), TaskID: tasks[0].ID, DependsOnTaskID: tasks[1].ID})
+	if _, err := domain.NewDAG(tasks, deps); err == nil {
+		t.Fatal("expected error for cyclic dependencies, got nil")
+	}
+}
+
+func TestDAG_Evaluate_ReadyRoots(t *testing.T) {
+	tasks, deps := chain("", "")
+	d, err := domain.NewDAG(tasks, deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready, skipped := d.Evaluate(nil)
+	if len(ready) != 1 || ready[0] != tasks[0].ID || len(skipped) != 0 {
+		t.Errorf("expected only the root ready, got ready=%v skipped=%v", ready, skipped)
+	}
+}
+
+func TestDAG_Evaluate_RootsRunWhateverTheirRule(t *testing.T) {
+	var tasks []*domain.Task
+	for _, r := range []domain.TriggerRule{domain.TriggerOneSuccess, domain.TriggerOneFailed, domain.TriggerAllFailed, domain.TriggerNoneFailed} {
+		tasks = append(tasks, &domain.Task{ID: uuid.New(), TriggerRule: r})
+	}
+	d, err := domain.NewDAG(tasks, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready, skipped := d.Evaluate(nil)
+	if len(ready) != len(tasks) || len(skipped) != 0 {
+		t.Errorf("expected every root ready, got ready=%v skipped=%v", ready, skipped)
+	}
+}
+
+func TestDAG_Evaluate_FailureCascadesSkips(t *testing.T) {
+	tasks, deps := chain("", "", "")
+	d, _ := domain.NewDAG(tasks, deps)
+
+	states := map[uuid.UUID]domain.Status{tasks[0].ID: domain.StatusFailed}
+	ready, skipped := d.Evaluate(states)
+	if len(ready) != 0 {
+		t.Errorf("expected nothing ready, got %v", ready)
+	}
+	if len(skipped) != 2 {
+		t.Fatalf("expected both downstream tasks skipped, got %v", skipped)
+	}
+	for _, id := range skipped {
+		states[id] = domain.StatusSkipped
+	}
+	if got := d.RunStatus(states); got != domain.StatusFailed {
+		t.Errorf("RunStatus: got %q, want failed", got)
+	}
+}
+
+func TestDAG_Evaluate_AllDoneRunsAfterFailure(t *testing.T) {
+	tasks, deps := chain("", domain.TriggerAllDone)
+	d, _ := domain.NewDAG(tasks, deps)
+
+	ready, skipped := d.Evaluate(map[uuid.UUID]domain.Status{tasks[0].ID: domain.StatusFailed})
+	if len(ready) != 1 || ready[0] != tasks[1].ID || len(skipped) != 0 {
+		t.Errorf("expected cleanup task ready, got ready=%v skipped=%v", ready, skipped)
+	}
+}
+
+func TestDAG_Evaluate_BranchJoin(t *testing.T) {
+	// branch -> {left, right} -> join(none_failed); right is the branch not taken.
+	branch := &domain.Task{ID: uuid.New()}
+	left := &domain.Task{ID: uuid.New()}
+	right := &domain.Task{ID: uuid.New()}
+	join := &domain.Task{ID: uuid.New(), TriggerRule: domain.TriggerNoneFailed}
+	dep := func(task, up *domain.Task) *domain.TaskDependency {
+		return &domain.TaskDependency{ID: uuid.New(), TaskID: task.ID, DependsOnTaskID: up.ID}
+	}
+	d, err := domain.NewDAG(
+		[]*domain.Task{branch, left, right, join},
+		[]*domain.TaskDependency{dep(left, branch), dep(right, branch), dep(join, left), dep(join, right)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states := map[uuid.UUID]domain.Status{
+		branch.ID: domain.StatusSuccess,
+		left.ID:   domain.StatusSuccess,
+		right.ID:  domain.StatusSkipped,
+	}
+	ready, skipped := d.Evaluate(states)
+	if len(ready) != 1 || ready[0] != join.ID || len(skipped) != 0 {
+		t.Fatalf("expected join ready, got ready=%v skipped=%v", ready, skipped)
+	}
+	states[join.ID] = domain.StatusSuccess
+	if got := d.RunStatus(states); got != domain.StatusSuccess {
+		t.Errorf("RunStatus: got %q, want success", got)
+	}
+}
+
+func TestDAG_Descendants(t *testing.T) {
+	tasks, deps := chain("", "", "")
+	side := &domain.Task{ID: uuid.New()}
+	tasks = append(tasks, side)
+	deps = append(deps, &domain.TaskDependency{ID: uuid.New(), TaskID: side.ID, DependsOnTaskID: tasks[0].ID})
+	d, err := domain.NewDAG(tasks, deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Descendants(tasks[1].ID); len(got) != 1 || got[0] != tasks[2].ID {
+		t.Errorf("Descendants(b): got %v, want only c", got)
+	}
+	if got := d.Descendants(tasks[0].ID); len(got) != 3 {
+		t.Errorf("Descendants(a): got %v, want b, c and the side task", got)
+	}
+	if got := d.Descendants(tasks[2].ID); len(got) != 0 {
+		t.Errorf("Descendants(c): got %v, want none", got)
+	}
+}
+
+func TestDAG_RunStatus_RunningUntilSettled(t *testing.T) {
+	tasks, deps := chain("", "")
+	d, _ := domain.NewDAG(tasks, deps)
+	if got := d.RunStatus(map[uuid.UUID]domain.Status{tasks[0].ID: domain.StatusSuccess}); got != domain.StatusRunning {
+		t.Errorf("RunStatus: got %q, want running", got)
+	}
+}
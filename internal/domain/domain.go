@@ -2,6 +2,9 @@
 package domain
 
 import (
+	"fmt"
+	"math"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,8 +18,27 @@ const (
 	StatusRunning Status = "running"
 	StatusSuccess Status = "success"
 	StatusFailed  Status = "failed"
+	// StatusAwaitingApproval parks an approval task run until a human decides.
+	StatusAwaitingApproval Status = "awaiting_approval"
+	// StatusSkipped marks a task run that will never execute because its
+	// trigger rule can no longer be satisfied.
+	StatusSkipped Status = "skipped"
 )
 
+// Statuses lists every Status.
+var Statuses = []Status{StatusPending, StatusRunning, StatusSuccess, StatusFailed, StatusAwaitingApproval, StatusSkipped}
+
+// Valid reports whether s is one of Statuses or empty, which filters
+// treat as any status.
+func (s Status) Valid() bool {
+	return s == "" || slices.Contains(Statuses, s)
+}
+
+// IsTerminal reports whether s is a final state that will not change again.
+func (s Status) IsTerminal() bool {
+	return s == StatusSuccess || s == StatusFailed || s == StatusSkipped
+}
+
 // WorkerStatus represents the availability state of a worker node.
 type WorkerStatus string
 
@@ -25,6 +47,30 @@ const (
 	WorkerStatusInactive WorkerStatus = "inactive"
 )
 
+// TaskType selects how a task is executed.
+type TaskType string
+
+const (
+	// TaskTypeCommand runs Task.Command on a worker. It is the default.
+	TaskTypeCommand TaskType = "command"
+	// TaskTypeApproval runs nothing; the task run waits for a human decision.
+	TaskTypeApproval TaskType = "approval"
+	// TaskTypeSensor polls for an external condition (file, HTTP 200, time)
+	// described by Task.Command as a JSON sensor spec.
+	TaskTypeSensor TaskType = "sensor"
+	// TaskTypeTriggerWorkflow runs nothing on a worker; the orchestrator
+	// starts a run of the workflow whose ID is in Task.Command.
+	TaskTypeTriggerWorkflow TaskType = "trigger_workflow"
+)
+
+// ApprovalDecision is the outcome recorded against an approval task run.
+type ApprovalDecision string
+
+const (
+	ApprovalApproved ApprovalDecision = "approved"
+	ApprovalRejected ApprovalDecision = "rejected"
+)
+
 // Workflow is a named, schedulable collection of tasks.
 type Workflow struct {
 	ID           uuid.UUID `json:"id"`
@@ -33,6 +79,67 @@ type Workflow struct {
 	ScheduleCron string    `json:"schedule_cron"`
 	IsActive     bool      `json:"is_active"`
 	CreatedAt    time.Time `json:"created_at"`
+
+	// Timezone is the IANA zone ScheduleCron is evaluated in; empty means UTC.
+	Timezone string `json:"timezone,omitempty"`
+	// StartDate and EndDate, when set, bound the fires of ScheduleCron: it
+	// fires nothing before StartDate or after EndDate, so a seasonal or
+	// time-boxed workflow stops on its own.
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	// TriggerDatasets makes the workflow run when these datasets are written
+	// by other workflows' tasks, as decided by DatasetPolicy.
+	TriggerDatasets []string      `json:"trigger_datasets,omitempty"`
+	DatasetPolicy   DatasetPolicy `json:"dataset_policy,omitempty"`
+	// TriggerEvents makes the workflow run whenever one of these external
+	// events is posted, with the event's payload as the run's Params.
+	TriggerEvents []string `json:"trigger_events,omitempty"`
+	// CalendarID attaches a Calendar whose excluded days skip scheduled runs.
+	CalendarID *uuid.UUID `json:"calendar_id,omitempty"`
+	// PauseWindows are recurring blackouts in which scheduled fires are
+	// skipped or delayed.
+	PauseWindows []PauseWindow `json:"pause_windows,omitempty"`
+	// Catchup decides which fires missed while the scheduler was down are
+	// run when it starts; empty means CatchupNone.
+	Catchup CatchupPolicy `json:"catchup,omitempty"`
+	// TriggerOnSuccess lists workflows to start whenever a run of this
+	// workflow succeeds.
+	TriggerOnSuccess []uuid.UUID `json:"trigger_on_success,omitempty"`
+	// MaxParallelTasks caps how many tasks of one run are dispatched at the
+	// same time; 0 means no limit.
+	MaxParallelTasks int `json:"max_parallel_tasks,omitempty"`
+	// RetainRuns and RetainDays bound the workflow's finished run history:
+	// the retention job deletes runs beyond the newest RetainRuns and runs
+	// started more than RetainDays days ago. 0 keeps runs indefinitely.
+	RetainRuns int `json:"retain_runs,omitempty"`
+	RetainDays int `json:"retain_days,omitempty"`
+	// WorkerGroup names the worker group whose workers run the workflow's
+	// tasks, giving a team dedicated capacity; empty uses the default
+	// group.
+	WorkerGroup string `json:"worker_group,omitempty"`
+	// Tenant names the team or customer the workflow's execution time is
+	// charged to in the usage report; empty leaves it unattributed.
+	Tenant string `json:"tenant,omitempty"`
+	// Priority is the queue priority, 1 (lowest) to 10 (highest), of the
+	// workflow's tasks that do not set their own; 0 means normal priority.
+	Priority int `json:"priority,omitempty"`
+	// Paused stops the cron schedule from starting runs while leaving the
+	// workflow active: every fire is recorded as a skipped run instead.
+	// PausedBy and PausedAt tell who paused it and when.
+	Paused   bool       `json:"paused,omitempty"`
+	PausedBy string     `json:"paused_by,omitempty"`
+	PausedAt *time.Time `json:"paused_at,omitempty"`
+	// RunTimeoutSeconds bounds how long a run may take, counted from its
+	// StartedAt; 0 means no limit. RunTimeoutPolicy decides what the
+	// orchestrator does with a run that overruns it; empty means
+	// RunTimeoutFail.
+	RunTimeoutSeconds int              `json:"run_timeout_seconds,omitempty"`
+	RunTimeoutPolicy  RunTimeoutPolicy `json:"run_timeout_policy,omitempty"`
+	// MaxActiveRuns caps how many runs of the workflow may be pending or
+	// running at once; 0 means no limit. OverlapPolicy decides what
+	// happens to a run beyond it; empty means OverlapQueue.
+	MaxActiveRuns int           `json:"max_active_runs,omitempty"`
+	OverlapPolicy OverlapPolicy `json:"overlap_policy,omitempty"`
 }
 
 // Task is a single unit of work that belongs to a Workflow.
@@ -45,6 +152,63 @@ type Task struct {
 	RetryDelaySeconds int       `json:"retry_delay_seconds"`
 	TimeoutSeconds    int       `json:"timeout_seconds"`
 	CreatedAt         time.Time `json:"created_at"`
+
+	// Type selects how the task executes; empty means TaskTypeCommand.
+	Type TaskType `json:"type"`
+	// Pool names the resource pool the task occupies a slot in while running.
+	Pool string `json:"pool,omitempty"`
+	// ConcurrencyKey prevents tasks sharing the key from running at the same
+	// time. Use the task's own ID to serialise runs of a single task.
+	ConcurrencyKey string `json:"concurrency_key,omitempty"`
+	// TriggerRule decides, from its upstream states, whether the task runs
+	// or is skipped; empty means TriggerAllSuccess.
+	TriggerRule TriggerRule `json:"trigger_rule,omitempty"`
+	// RetryMultiplier grows RetryDelaySeconds after each retry; 0 means 2.
+	RetryMultiplier float64 `json:"retry_multiplier,omitempty"`
+	// RetryMaxDelaySeconds caps the delay between retries; 0 means no cap.
+	RetryMaxDelaySeconds int `json:"retry_max_delay_seconds,omitempty"`
+	// RetryJitter randomises each retry delay within [d/2, d].
+	RetryJitter bool `json:"retry_jitter,omitempty"`
+	// Inputs and Outputs name the datasets the task reads and writes, e.g.
+	// "postgres://warehouse/orders". Each run records them as lineage edges.
+	Inputs  []string `json:"inputs,omitempty"`
+	Outputs []string `json:"outputs,omitempty"`
+	// Env holds environment variables for the task's process. Command and
+	// Env values are rendered with the run's TemplateVars before dispatch.
+	Env map[string]string `json:"env,omitempty"`
+	// Hooks are side effects the worker runs after the task succeeds,
+	// fails or is retried.
+	Hooks []TaskHook `json:"hooks,omitempty"`
+	// Profile names the isolation profile (working directory, environment
+	// baseline, user, network policy) the worker runs the task under;
+	// empty uses the worker's default profile, if it has one.
+	Profile string `json:"profile,omitempty"`
+	// Queue names the queue the task waits on, so only the workers that
+	// serve it, e.g. those with a GPU, run the task; empty uses the queue
+	// of the workflow's worker group.
+	Queue string `json:"queue,omitempty"`
+	// Priority overrides the workflow's Priority for this task; 0 inherits
+	// it.
+	Priority int `json:"priority,omitempty"`
+	// TTLSeconds bounds how long a run of the task may wait to be
+	// dispatched before it expires, and fails, instead; 0 means no limit.
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+}
+
+// EffectivePriority returns the queue priority of t's runs: t's own
+// Priority, or else that of wf, the workflow it belongs to, which may be
+// nil. 0 leaves the queue's default.
+func (t *Task) EffectivePriority(wf *Workflow) int {
+	if t.Priority != 0 || wf == nil {
+		return t.Priority
+	}
+	return wf.Priority
+}
+
+// RequiresApproval reports whether runs of this task wait for a human decision
+// instead of being dispatched to a worker.
+func (t *Task) RequiresApproval() bool {
+	return t.Type == TaskTypeApproval
 }
 
 // TaskDependency records that a task must wait for another task to complete first.
@@ -61,6 +225,95 @@ type WorkflowRun struct {
 	Status     Status     `json:"status"`
 	StartedAt  time.Time  `json:"started_at"`
 	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	// LogicalDate is the schedule interval the run covers. Nil for runs
+	// triggered manually.
+	LogicalDate *time.Time `json:"logical_date,omitempty"`
+	// BackfillID links a run to the backfill that created it.
+	BackfillID *uuid.UUID `json:"backfill_id,omitempty"`
+	// TriggeredByRunID links a run to the run of another workflow that
+	// started it, through TriggerOnSuccess or a trigger_workflow task.
+	TriggeredByRunID *uuid.UUID `json:"triggered_by_run_id,omitempty"`
+	// Params are caller-supplied values available to task templates as
+	// {{ .params.name }}.
+	Params map[string]string `json:"params,omitempty"`
+	// Labels tag the run for filtering, e.g. backfill=true or
+	// triggered_by=alice. They are not visible to tasks.
+	Labels map[string]string `json:"labels,omitempty"`
+	// IdempotencyKey is the client-chosen key the run was triggered with.
+	// Each of its task runs is queued with a key derived from it, see
+	// WorkflowRun.TaskIdempotencyKey, so the tasks of a retried trigger are
+	// refused as duplicates rather than run twice.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// Progress counts the run's settled tasks, as of the orchestrator's
+	// latest pass.
+	Progress RunProgress `json:"progress"`
+}
+
+// RunProgress is how far a workflow run has got: Completed of its Total
+// tasks have succeeded, failed or been skipped.
+type RunProgress struct {
+	Completed int     `json:"completed"`
+	Total     int     `json:"total"`
+	Percent   float64 `json:"percent"`
+}
+
+// NewRunProgress returns the progress of a run with completed of total
+// tasks settled. Percent is rounded to one decimal place, and 0 for a run
+// without tasks.
+func NewRunProgress(completed, total int) RunProgress {
+	p := RunProgress{Completed: completed, Total: total}
+	if total > 0 {
+		p.Percent = math.Round(float64(completed)*1000/float64(total)) / 10
+	}
+	return p
+}
+
+// Labels set on workflow runs by the scheduler and the API.
+const (
+	// LabelBackfill is "true" on runs created by a backfill.
+	LabelBackfill = "backfill"
+	// LabelTriggeredBy names the caller that triggered a run manually.
+	LabelTriggeredBy = "triggered_by"
+	// LabelEvent names the external event that started a run.
+	LabelEvent = "event"
+	// LabelPauseWindow names the pause window that skipped or delayed a
+	// scheduled run.
+	LabelPauseWindow = "pause_window"
+	// LabelPaused is "true" on the skipped run of a scheduled fire that
+	// found its workflow paused; LabelPausedBy names who paused it.
+	LabelPaused   = "paused"
+	LabelPausedBy = "paused_by"
+	// LabelCatchup is "true" on the run of a fire missed while the
+	// scheduler was down, created when it started again.
+	LabelCatchup = "catchup"
+	// LabelMisfire is "true" on the skipped run of a fire the trigger woke
+	// up for too late under the skip misfire policy.
+	LabelMisfire = "misfire"
+	// LabelMaxActiveRuns is "true" on the skipped run of a trigger that
+	// found its workflow at MaxActiveRuns under the skip overlap policy.
+	LabelMaxActiveRuns = "max_active_runs"
+)
+
+// HasLabels reports whether wr carries every label in selector.
+func (wr *WorkflowRun) HasLabels(selector map[string]string) bool {
+	for k, v := range selector {
+		if got, ok := wr.Labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// TaskIdempotencyKey returns the idempotency key the given attempt of the
+// task named taskName is queued with, or "" if wr has no key. It is unique
+// per workflow, key, task and attempt, so clearing a task run still queues
+// its next attempt.
+func (wr *WorkflowRun) TaskIdempotencyKey(taskName string, attempt int) string {
+	if wr == nil || wr.IdempotencyKey == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%s/%d", wr.WorkflowID, wr.IdempotencyKey, taskName, attempt)
 }
 
 // TaskRun is a single execution attempt of a Task within a WorkflowRun.
@@ -73,6 +326,16 @@ type TaskRun struct {
 	StartedAt     time.Time  `json:"started_at"`
 	FinishedAt    *time.Time `json:"finished_at,omitempty"`
 	Logs          string     `json:"logs"`
+	// AssignedWorkerID is the worker the attempt was dispatched to, and
+	// DispatchedAt when it took the attempt off the queue; both are empty
+	// until a worker does.
+	AssignedWorkerID string     `json:"assigned_worker_id,omitempty"`
+	DispatchedAt     *time.Time `json:"dispatched_at,omitempty"`
+	// QueueTaskID is the queue task the attempt follows when its own
+	// submission was refused as a duplicate of another run's attempt, see
+	// WorkflowRun.TaskIdempotencyKey; empty means the queue task with the
+	// task run's own ID.
+	QueueTaskID string `json:"queue_task_id,omitempty"`
 }
 
 // Worker represents a node that picks up and executes tasks.
@@ -82,3 +345,42 @@ type Worker struct {
 	LastHeartbeat time.Time    `json:"last_heartbeat"`
 	Status        WorkerStatus `json:"status"`
 }
+
+// Approval is the audit record of a human decision on an approval task run.
+type Approval struct {
+	ID        uuid.UUID        `json:"id"`
+	TaskRunID uuid.UUID        `json:"task_run_id"`
+	Decision  ApprovalDecision `json:"decision"`
+	Approver  string           `json:"approver"`
+	Comment   string           `json:"comment"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// BackfillStatus represents the lifecycle state of a backfill.
+type BackfillStatus string
+
+const (
+	BackfillStatusRunning   BackfillStatus = "running"
+	BackfillStatusCompleted BackfillStatus = "completed"
+	BackfillStatusCancelled BackfillStatus = "cancelled"
+)
+
+// Backfill replays a workflow's schedule over a past date range, creating one
+// WorkflowRun per interval in chronological order while keeping at most
+// MaxActiveRuns of them unfinished at a time.
+type Backfill struct {
+	ID            uuid.UUID      `json:"id"`
+	WorkflowID    uuid.UUID      `json:"workflow_id"`
+	Start         time.Time      `json:"start"`
+	End           time.Time      `json:"end"`
+	MaxActiveRuns int            `json:"max_active_runs"`
+	Status        BackfillStatus `json:"status"`
+	// Total is the number of schedule intervals in [Start, End].
+	Total int `json:"total"`
+	// Created is the number of runs created so far.
+	Created int `json:"created"`
+	// Cursor is the logical date of the most recently created run.
+	Cursor     *time.Time `json:"cursor,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
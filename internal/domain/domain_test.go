@@ -368,3 +368,80 @@ if statusVal != "failed" {
 t.Errorf("status JSON value: got %q, want %q", statusVal, "failed")
 }
 }
+
+func TestTaskRender(t *testing.T) {
+	logical := time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)
+	run := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: uuid.New(), LogicalDate: &logical,
+		Params: map[string]string{"x": "42"}}
+	task := &domain.Task{Command: "run {{ .execution_date }} {{ .params.x }}", Env: map[string]string{"DS": "{{ .ds }}"}}
+	ec := domain.ExecutionContext{Run: run}
+
+	got, err := task.Render(ec)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if got.Command != "run 2024-03-01T06:00:00Z 42" {
+		t.Errorf("Command: got %q", got.Command)
+	}
+	if got.Env["DS"] != "2024-03-01" {
+		t.Errorf("Env DS: got %q", got.Env["DS"])
+	}
+	if task.Command != "run {{ .execution_date }} {{ .params.x }}" {
+		t.Error("Render modified the original task")
+	}
+
+	task.Command = "run {{ .params.y }}"
+	if _, err := task.Render(ec); err == nil {
+		t.Error("expected an error for a missing param")
+	}
+	task.Command = "run {{ .params.x"
+	if err := task.ValidateTemplates(); err == nil {
+		t.Error("expected ValidateTemplates to reject a malformed template")
+	}
+}
+
+func TestExecutionContextEnv(t *testing.T) {
+	started := time.Date(2024, 3, 2, 9, 30, 0, 0, time.UTC)
+	prev := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	run := &domain.WorkflowRun{ID: uuid.New(), WorkflowID: uuid.New(), StartedAt: started}
+	ec := domain.ExecutionContext{Run: run, WorkflowName: "etl", TaskName: "load", Attempt: 2, PrevSuccessDate: &prev}
+
+	env := ec.Env()
+	want := map[string]string{
+		domain.EnvRunID:           run.ID.String(),
+		domain.EnvWorkflowID:      run.WorkflowID.String(),
+		domain.EnvWorkflowName:    "etl",
+		domain.EnvTaskName:        "load",
+		domain.EnvAttempt:         "2",
+		domain.EnvLogicalDate:     "2024-03-02T09:30:00Z",
+		domain.EnvPrevSuccessDate: "2024-03-01T00:00:00Z",
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s: got %q, want %q", k, env[k], v)
+		}
+	}
+
+	task := &domain.Task{Command: "{{ .workflow_name }}.{{ .task_name }}#{{ .attempt }}", Env: map[string]string{domain.EnvTaskName: "override"}}
+	got, err := task.Render(ec)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if got.Command != "etl.load#2" {
+		t.Errorf("Command: got %q", got.Command)
+	}
+	if got.Env[domain.EnvTaskName] != "override" || got.Env[domain.EnvRunID] != run.ID.String() {
+		t.Errorf("Env: got %v, want task entries to override the context", got.Env)
+	}
+}
+
+func TestNewRunProgress(t *testing.T) {
+	for _, tc := range []struct {
+		completed, total int
+		want             float64
+	}{{0, 0, 0}, {0, 3, 0}, {1, 3, 33.3}, {2, 3, 66.7}, {3, 3, 100}} {
+		if got := domain.NewRunProgress(tc.completed, tc.total).Percent; got != tc.want {
+			t.Errorf("%d of %d: got %v%%, want %v%%", tc.completed, tc.total, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package domain
+
+// MaxEventNameLen bounds the name of an external event.
+const MaxEventNameLen = 128
+
+// ValidEventName reports whether name can name an external event a
+// workflow subscribes to: 1 to MaxEventNameLen letters, digits, '-', '_',
+// '.' or ':', so that names like "s3:object-created" fit in a URL path
+// segment unescaped.
+func ValidEventName(name string) bool {
+	if name == "" || len(name) > MaxEventNameLen {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventRecord is one event as the system reported it to WebSocket clients,
+// kept for the event history. Seq is assigned when the record is stored
+// and increases with every record, so it orders the history and serves as
+// its pagination cursor.
+type EventRecord struct {
+	Seq     int64           `json:"seq"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+	At      time.Time       `json:"at"`
+}
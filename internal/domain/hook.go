@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// HookEvent is the task transition a TaskHook runs after.
+type HookEvent string
+
+const (
+	// HookOnSuccess runs once the task has succeeded.
+	HookOnSuccess HookEvent = "on_success"
+	// HookOnFailure runs once the task has failed for good, after its
+	// last retry.
+	HookOnFailure HookEvent = "on_failure"
+	// HookOnRetry runs each time a failed attempt is retried.
+	HookOnRetry HookEvent = "on_retry"
+)
+
+// HookKind selects what a TaskHook does.
+type HookKind string
+
+const (
+	// HookHTTP POSTs the task's outcome as JSON to URL.
+	HookHTTP HookKind = "http"
+	// HookEnqueue queues a standalone command task running Command.
+	HookEnqueue HookKind = "enqueue"
+	// HookNotify sends Message through the worker's notifier named
+	// Notifier.
+	HookNotify HookKind = "notify"
+)
+
+// TaskHook is a side effect the worker performs after a task transition.
+// Hooks are best effort: a failing hook is logged and never changes the
+// task's outcome.
+type TaskHook struct {
+	On   HookEvent `json:"on"`
+	Kind HookKind  `json:"kind"`
+	// URL is where a HookHTTP hook posts to.
+	URL string `json:"url,omitempty"`
+	// Command is what the task of a HookEnqueue hook runs.
+	Command string `json:"command,omitempty"`
+	// Notifier and Message configure a HookNotify hook; an empty Message
+	// describes the transition.
+	Notifier string `json:"notifier,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Validate checks that h names a known event and has what its kind needs.
+func (h TaskHook) Validate() error {
+	switch h.On {
+	case HookOnSuccess, HookOnFailure, HookOnRetry:
+	default:
+		return fmt.Errorf("unknown hook event %q", h.On)
+	}
+	switch h.Kind {
+	case HookHTTP:
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("http hook needs an http(s) url")
+		}
+	case HookEnqueue:
+		if h.Command == "" {
+			return errors.New("enqueue hook needs a command")
+		}
+	case HookNotify:
+		if h.Notifier == "" {
+			return errors.New("notify hook needs a notifier")
+		}
+	default:
+		return fmt.Errorf("unknown hook kind %q", h.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LineageDirection tells whether a task run read or wrote a dataset.
+type LineageDirection string
+
+const (
+	// LineageInput records that a task run consumed a dataset.
+	LineageInput LineageDirection = "input"
+	// LineageOutput records that a task run produced a dataset.
+	LineageOutput LineageDirection = "output"
+)
+
+// LineageEdge links one task run to a dataset it read or wrote. Input edges
+// are recorded when the task run starts, output edges when it succeeds.
+type LineageEdge struct {
+	ID            uuid.UUID        `json:"id"`
+	Dataset       string           `json:"dataset"`
+	Direction     LineageDirection `json:"direction"`
+	WorkflowID    uuid.UUID        `json:"workflow_id"`
+	WorkflowRunID uuid.UUID        `json:"workflow_run_id"`
+	TaskID        uuid.UUID        `json:"task_id"`
+	TaskRunID     uuid.UUID        `json:"task_run_id"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+// LineageGraph is the part of the recorded lineage reachable from a dataset.
+// Datasets lists every dataset visited, including the root, and Workflows
+// every workflow whose runs appear on an edge.
+type LineageGraph struct {
+	Dataset   string        `json:"dataset"`
+	Datasets  []string      `json:"datasets"`
+	Workflows []uuid.UUID   `json:"workflows"`
+	Edges     []LineageEdge `json:"edges"`
+}
+
+// DatasetPolicy decides which updates of a workflow's TriggerDatasets start
+// a new run.
+type DatasetPolicy string
+
+const (
+	// DatasetPolicyAll waits until every trigger dataset has been updated
+	// since the workflow last ran. It is the default.
+	DatasetPolicyAll DatasetPolicy = "all"
+	// DatasetPolicyAny runs as soon as any trigger dataset is updated.
+	DatasetPolicyAny DatasetPolicy = "any"
+)
+
+// Valid reports whether p is empty or one of the known policies.
+func (p DatasetPolicy) Valid() bool {
+	switch p {
+	case "", DatasetPolicyAll, DatasetPolicyAny:
+		return true
+	}
+	return false
+}
+
+// Satisfied reports whether updated of total trigger datasets having been
+// updated is enough to start a run under p.
+func (p DatasetPolicy) Satisfied(updated, total int) bool {
+	if updated == 0 {
+		return false
+	}
+	if p == DatasetPolicyAny {
+		return true
+	}
+	return updated == total
+}
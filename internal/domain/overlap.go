@@ -0,0 +1,34 @@
+package domain
+
+// OverlapPolicy decides what happens to a new run of a workflow that
+// already has MaxActiveRuns runs pending or running.
+type OverlapPolicy string
+
+const (
+	// OverlapQueue creates the run as pending; it starts once fewer than
+	// MaxActiveRuns runs of the workflow are running, oldest first. It is
+	// the default.
+	OverlapQueue OverlapPolicy = "queue"
+	// OverlapSkip records the run as skipped instead of starting it.
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapReject creates no run: a manual trigger fails and a scheduled
+	// fire is dropped.
+	OverlapReject OverlapPolicy = "reject"
+)
+
+// Valid reports whether p is a known policy or empty.
+func (p OverlapPolicy) Valid() bool {
+	switch p {
+	case "", OverlapQueue, OverlapSkip, OverlapReject:
+		return true
+	}
+	return false
+}
+
+// Overlap returns the policy applied to wf's runs beyond MaxActiveRuns.
+func (wf *Workflow) Overlap() OverlapPolicy {
+	if wf == nil || wf.OverlapPolicy == "" {
+		return OverlapQueue
+	}
+	return wf.OverlapPolicy
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// MaxPauseWindow bounds PauseWindow.DurationMinutes.
+const MaxPauseWindow = 7 * 24 * time.Hour
+
+// PauseAction is what CronTrigger does with a fire inside a PauseWindow.
+type PauseAction string
+
+const (
+	// PauseSkip records the fire as a skipped run. It is the default.
+	PauseSkip PauseAction = "skip"
+	// PauseDelay starts the run when the window closes instead. Fires
+	// falling in the same window coalesce into that one run.
+	PauseDelay PauseAction = "delay"
+)
+
+// Valid reports whether a is empty or one of the known actions.
+func (a PauseAction) Valid() bool {
+	switch a {
+	case "", PauseSkip, PauseDelay:
+		return true
+	}
+	return false
+}
+
+// PauseWindow is a recurring blackout in which a workflow's schedule does
+// not start runs, e.g. "0 0 * * 0" for 240 minutes for a Sunday night
+// maintenance.
+type PauseWindow struct {
+	// Name identifies the window in the labels of the runs it affects.
+	Name string `json:"name"`
+	// Start is a cron expression, evaluated in the workflow's timezone, for
+	// when the window opens.
+	Start string `json:"start"`
+	// DurationMinutes is how long the window stays open.
+	DurationMinutes int `json:"duration_minutes"`
+	// Action defaults to PauseSkip.
+	Action PauseAction `json:"action,omitempty"`
+}
+
+// Duration returns how long the window stays open.
+func (w PauseWindow) Duration() time.Duration {
+	return time.Duration(w.DurationMinutes) * time.Minute
+}
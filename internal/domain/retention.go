@@ -0,0 +1,14 @@
+package domain
+
+// TerminalStatuses returns the statuses for which Status.IsTerminal holds:
+// the runs that may be deleted from history.
+func TerminalStatuses() []Status {
+	return []Status{StatusSuccess, StatusFailed, StatusSkipped}
+}
+
+// PurgeCounts reports how many records a purge of run history deleted, or
+// would delete on a dry run.
+type PurgeCounts struct {
+	WorkflowRuns int64 `json:"workflow_runs"`
+	TaskRuns     int64 `json:"task_runs"`
+}
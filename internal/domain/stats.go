@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DurationPercentiles summarises the durations, in seconds, of finished
+// workflow runs.
+type DurationPercentiles struct {
+	Runs int     `json:"runs"`
+	P50  float64 `json:"p50_seconds"`
+	P95  float64 `json:"p95_seconds"`
+	P99  float64 `json:"p99_seconds"`
+}
+
+// TaskDurationStat is the average duration of the finished runs of one task.
+type TaskDurationStat struct {
+	TaskID     uuid.UUID `json:"task_id"`
+	TaskName   string    `json:"task_name"`
+	Runs       int       `json:"runs"`
+	AvgSeconds float64   `json:"avg_seconds"`
+}
+
+// DurationBucket aggregates the finished workflow runs that started within
+// [Start, Start+bucket width).
+type DurationBucket struct {
+	Start      time.Time `json:"start"`
+	Runs       int       `json:"runs"`
+	Failed     int       `json:"failed"`
+	AvgSeconds float64   `json:"avg_seconds"`
+	P95Seconds float64   `json:"p95_seconds"`
+}
+
+// Percentile returns the p-th percentile (0 ≤ p ≤ 1) of sorted using linear
+// interpolation between closest ranks, matching PostgreSQL's
+// percentile_cont. It returns 0 for an empty slice.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+// Percentiles sorts durations in place and summarises them.
+func Percentiles(durations []float64) DurationPercentiles {
+	sort.Float64s(durations)
+	return DurationPercentiles{
+		Runs: len(durations),
+		P50:  Percentile(durations, 0.50),
+		P95:  Percentile(durations, 0.95),
+		P99:  Percentile(durations, 0.99),
+	}
+}
+
+// TaskUsage is the execution time the task runs of one workflow on one
+// resource class spent on workers during one UTC day: from when a worker
+// took each off the queue until it finished.
+type TaskUsage struct {
+	// Date is the UTC day the task runs were dispatched, YYYY-MM-DD.
+	Date       string    `json:"date"`
+	WorkflowID uuid.UUID `json:"workflow_id"`
+	// ResourceClass is the queue the tasks ran from; empty is the queue of
+	// the workflow's worker group.
+	ResourceClass    string  `json:"resource_class"`
+	TaskRuns         int     `json:"task_runs"`
+	ExecutionSeconds float64 `json:"execution_seconds"`
+}
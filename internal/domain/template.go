@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Environment variables set on every task from its ExecutionContext.
+const (
+	EnvRunID           = "WORKFLOW_RUN_ID"
+	EnvWorkflowID      = "WORKFLOW_ID"
+	EnvWorkflowName    = "WORKFLOW_NAME"
+	EnvTaskName        = "TASK_NAME"
+	EnvAttempt         = "TASK_ATTEMPT"
+	EnvLogicalDate     = "LOGICAL_DATE"
+	EnvPrevSuccessDate = "PREV_SUCCESS_DATE"
+)
+
+// ExecutionContext describes the run a task executes in. It is exposed to
+// the task both as template variables (Vars) and as environment variables
+// (Env).
+type ExecutionContext struct {
+	Run          *WorkflowRun
+	WorkflowName string
+	TaskName     string
+	Attempt      int
+	// PrevSuccessDate is the logical date of the workflow's latest
+	// successful run before Run, if any.
+	PrevSuccessDate *time.Time
+}
+
+// LogicalDate returns the run's LogicalDate, or its StartedAt for runs
+// without one, in UTC.
+func (ec ExecutionContext) LogicalDate() time.Time {
+	if ec.Run.LogicalDate != nil {
+		return ec.Run.LogicalDate.UTC()
+	}
+	return ec.Run.StartedAt.UTC()
+}
+
+// Vars returns the template variables of ec:
+//
+//	execution_date, logical_date  the run's logical date, RFC 3339 in UTC
+//	ds                            the logical date as YYYY-MM-DD
+//	prev_success_date             PrevSuccessDate, RFC 3339, or ""
+//	run_id, workflow_id           the run and workflow IDs
+//	workflow_name, task_name      the workflow and task names
+//	attempt                       the task run attempt, starting at 1
+//	params                        the run's Params; a missing key is an error
+func (ec ExecutionContext) Vars() map[string]any {
+	params := ec.Run.Params
+	if params == nil {
+		params = map[string]string{}
+	}
+	date := ec.LogicalDate().Format(time.RFC3339)
+	return map[string]any{
+		"execution_date":    date,
+		"logical_date":      date,
+		"ds":                ec.LogicalDate().Format(CalendarDateLayout),
+		"prev_success_date": ec.prevSuccessDate(),
+		"run_id":            ec.Run.ID.String(),
+		"workflow_id":       ec.Run.WorkflowID.String(),
+		"workflow_name":     ec.WorkflowName,
+		"task_name":         ec.TaskName,
+		"attempt":           ec.Attempt,
+		"params":            params,
+	}
+}
+
+// Env returns the environment variables of ec.
+func (ec ExecutionContext) Env() map[string]string {
+	return map[string]string{
+		EnvRunID:           ec.Run.ID.String(),
+		EnvWorkflowID:      ec.Run.WorkflowID.String(),
+		EnvWorkflowName:    ec.WorkflowName,
+		EnvTaskName:        ec.TaskName,
+		EnvAttempt:         strconv.Itoa(ec.Attempt),
+		EnvLogicalDate:     ec.LogicalDate().Format(time.RFC3339),
+		EnvPrevSuccessDate: ec.prevSuccessDate(),
+	}
+}
+
+func (ec ExecutionContext) prevSuccessDate() string {
+	if ec.PrevSuccessDate == nil {
+		return ""
+	}
+	return ec.PrevSuccessDate.UTC().Format(time.RFC3339)
+}
+
+// Render returns a copy of t with Command and the Env values rendered as Go
+// templates against ec.Vars(), and with ec.Env() added to Env. The task's
+// own Env entries take precedence. Strings without "{{" are left as is.
+func (t *Task) Render(ec ExecutionContext) (*Task, error) {
+	vars := ec.Vars()
+	out := *t
+	cmd, err := renderTemplate("command", t.Command, vars)
+	if err != nil {
+		return nil, err
+	}
+	out.Command = cmd
+	out.Env = ec.Env()
+	for k, v := range t.Env {
+		if out.Env[k], err = renderTemplate("env "+k, v, vars); err != nil {
+			return nil, err
+		}
+	}
+	return &out, nil
+}
+
+// ValidateTemplates reports a syntax error in t's Command or Env templates.
+func (t *Task) ValidateTemplates() error {
+	if _, err := parseTemplate("command", t.Command); err != nil {
+		return err
+	}
+	for k, v := range t.Env {
+		if _, err := parseTemplate("env "+k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+func renderTemplate(name, text string, vars map[string]any) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("render %s template: %w", name, err)
+	}
+	return b.String(), nil
+}
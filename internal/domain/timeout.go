@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// RunTimeoutPolicy decides what happens to a workflow run that is still
+// unfinished RunTimeoutSeconds after it started.
+type RunTimeoutPolicy string
+
+const (
+	// RunTimeoutFail fails the run at once: its running tasks are cancelled
+	// and failed, and the tasks yet to start are skipped. It is the default.
+	RunTimeoutFail RunTimeoutPolicy = "fail"
+	// RunTimeoutCancelDownstream skips the tasks yet to start but lets the
+	// running ones finish; the run then fails.
+	RunTimeoutCancelDownstream RunTimeoutPolicy = "cancel_downstream"
+	// RunTimeoutAlert leaves the run alone and raises an alert until it
+	// finishes.
+	RunTimeoutAlert RunTimeoutPolicy = "alert"
+)
+
+// Valid reports whether p is a known policy or empty.
+func (p RunTimeoutPolicy) Valid() bool {
+	switch p {
+	case "", RunTimeoutFail, RunTimeoutCancelDownstream, RunTimeoutAlert:
+		return true
+	}
+	return false
+}
+
+// RunDeadline returns when runs of wf started at start time out, and false
+// if wf sets no run timeout.
+func (wf *Workflow) RunDeadline(start time.Time) (time.Time, bool) {
+	if wf == nil || wf.RunTimeoutSeconds <= 0 {
+		return time.Time{}, false
+	}
+	return start.Add(time.Duration(wf.RunTimeoutSeconds) * time.Second), true
+}
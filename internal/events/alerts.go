@@ -0,0 +1,75 @@
+package events
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// AlertUpdate is the payload of Alert events. Value is the measurement that
+// crossed, or fell back under, Threshold.
+type AlertUpdate struct {
+	Name      string    `json:"name"`
+	Firing    bool      `json:"firing"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Message   string    `json:"message"`
+	At        time.Time `json:"at"`
+}
+
+// AlertBoard keeps the latest state of every alert it has seen, so a process
+// can report whether any is firing. It is safe for concurrent use.
+type AlertBoard struct {
+	mu     sync.Mutex
+	firing map[string]AlertUpdate
+}
+
+// NewAlertBoard returns an AlertBoard with no alert firing.
+func NewAlertBoard() *AlertBoard {
+	return &AlertBoard{firing: make(map[string]AlertUpdate)}
+}
+
+// Record applies a: a firing alert is kept until an update with the same
+// Name resolves it.
+func (b *AlertBoard) Record(a AlertUpdate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if a.Firing {
+		b.firing[a.Name] = a
+	} else {
+		delete(b.firing, a.Name)
+	}
+}
+
+// Watch records the Alert events received from ch until ch is closed.
+// Other events are ignored.
+func (b *AlertBoard) Watch(ch <-chan Event) {
+	for e := range ch {
+		if e.Type != Alert {
+			continue
+		}
+		var a AlertUpdate
+		if err := e.DecodePayload(&a); err == nil {
+			b.Record(a)
+		}
+	}
+}
+
+// Firing returns the alerts currently firing, sorted by name.
+func (b *AlertBoard) Firing() []AlertUpdate {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]AlertUpdate, 0, len(b.firing))
+	for _, a := range b.firing {
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Degraded reports whether any alert is firing.
+func (b *AlertBoard) Degraded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.firing) > 0
+}
@@ -0,0 +1,191 @@
+// Package events carries run, task and worker state changes from the
+// scheduler and worker processes to the API, which relays them to WebSocket
+// clients. Producers depend only on Publisher; Bus implementations decide
+// whether events stay in-process or cross process boundaries.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Type labels the kind of event. The values match the WebSocket event types
+// so events can be relayed to clients unchanged.
+type Type string
+
+const (
+	// TaskStatus is published when a task run or queue task changes state.
+	TaskStatus Type = "task_status"
+	// WorkflowStatus is published when a workflow run changes state.
+	WorkflowStatus Type = "workflow_status"
+	// WorkerHeartbeat is published every time a worker records a heartbeat.
+	WorkerHeartbeat Type = "worker_heartbeat"
+	// WorkerCommand is published by the API to instruct a worker process.
+	WorkerCommand Type = "worker_command"
+	// SecretRotated is published by the API when a secret's value changes,
+	// so workers drop the value they cached.
+	SecretRotated Type = "secret_rotated"
+	// WorkerQuarantined is published by a worker that stops taking tasks
+	// because too many of its recent attempts failed.
+	WorkerQuarantined Type = "worker_quarantined"
+	// Alert is published by the scheduler when a backpressure threshold is
+	// crossed, and again when the measurement falls back under it.
+	Alert Type = "alert"
+	// WorkflowChanged is published by the API after it creates or changes
+	// a workflow, so cron triggers reload their schedules.
+	WorkflowChanged Type = "workflow_changed"
+)
+
+// Actions a WorkerCommand event can carry.
+const (
+	// CommandDrain makes the worker stop taking tasks, finish the ones it
+	// is running and exit.
+	CommandDrain = "drain"
+	// CommandSetConcurrency changes how many tasks the worker runs at once
+	// to Command.Concurrency.
+	CommandSetConcurrency = "set_concurrency"
+)
+
+// Event is a single state change. Payload must be JSON-encodable.
+type Event struct {
+	Type    Type `json:"type"`
+	Payload any  `json:"payload"`
+}
+
+// TaskUpdate is the payload workers publish with TaskStatus events. TaskID is
+// the queue task ID, which equals the ID of the task run it executes.
+type TaskUpdate struct {
+	TaskID     string    `json:"task_id"`
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	WorkerID   string    `json:"worker_id"`
+	RetryCount int       `json:"retry_count"`
+	Error      string    `json:"error,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// Heartbeat is the payload of WorkerHeartbeat events.
+type Heartbeat struct {
+	WorkerID    string    `json:"worker_id"`
+	Status      string    `json:"status"`
+	ActiveTasks int       `json:"active_tasks"`
+	At          time.Time `json:"at"`
+}
+
+// Quarantine is the payload of WorkerQuarantined events.
+type Quarantine struct {
+	WorkerID    string  `json:"worker_id"`
+	FailureRate float64 `json:"failure_rate"`
+	// Attempts is how many recent attempts FailureRate covers, and
+	// AvgLatencyMS their mean duration in milliseconds.
+	Attempts     int       `json:"attempts"`
+	AvgLatencyMS int64     `json:"avg_latency_ms"`
+	Until        time.Time `json:"until"`
+	At           time.Time `json:"at"`
+}
+
+// Command is the payload of WorkerCommand events.
+type Command struct {
+	WorkerID string `json:"worker_id"`
+	Action   string `json:"action"`
+	// Concurrency is the new limit of a CommandSetConcurrency.
+	Concurrency int       `json:"concurrency,omitempty"`
+	By          string    `json:"by,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// Secret is the payload of SecretRotated events. It never carries the value.
+type Secret struct {
+	Name string    `json:"name"`
+	By   string    `json:"by,omitempty"`
+	At   time.Time `json:"at"`
+}
+
+// WorkflowChange is the payload of WorkflowChanged events. Action says
+// what happened, e.g. "created", "updated", "paused" or "resumed".
+type WorkflowChange struct {
+	WorkflowID string    `json:"workflow_id"`
+	Action     string    `json:"action"`
+	At         time.Time `json:"at"`
+}
+
+// DecodePayload decodes e.Payload into v. It accepts both the value
+// published in-process and the JSON a RedisBus delivers.
+func (e Event) DecodePayload(v any) error {
+	raw, ok := e.Payload.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(e.Payload); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// Publisher accepts events for delivery to subscribers.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Bus is a Publisher whose events can be consumed. Subscribe returns a
+// channel that receives every event published after the call; it is closed
+// once ctx is cancelled.
+type Bus interface {
+	Publisher
+	Subscribe(ctx context.Context) (<-chan Event, error)
+}
+
+// Discard is a Publisher that drops every event. Producers use it when no
+// bus is configured.
+var Discard Publisher = discard{}
+
+type discard struct{}
+
+func (discard) Publish(context.Context, Event) error { return nil }
+
+// subscriberBuffer is the number of events a subscriber may fall behind
+// before further events are dropped for it.
+const subscriberBuffer = 256
+
+// MemBus is an in-process Bus. Publish never blocks: a subscriber whose
+// buffer is full misses the event rather than stalling the producer.
+type MemBus struct {
+	mu   sync.RWMutex
+	subs map[chan Event]struct{}
+}
+
+// NewMemBus creates a MemBus with no subscribers.
+func NewMemBus() *MemBus {
+	return &MemBus{subs: make(map[chan Event]struct{})}
+}
+
+// Publish delivers e to every current subscriber.
+func (b *MemBus) Publish(_ context.Context, e Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a new subscriber until ctx is cancelled.
+func (b *MemBus) Subscribe(ctx context.Context) (<-chan Event, error) {
+	ch := make(chan Event, subscriberBuffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+		close(ch)
+	}()
+	return ch, nil
+}
@@ -0,0 +1,203 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+)
+
+func TestMemBus_FanOut(t *testing.T) {
+	bus := events.NewMemBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, _ := bus.Subscribe(ctx)
+	b, _ := bus.Subscribe(ctx)
+	_ = bus.Publish(ctx, events.Event{Type: events.WorkflowStatus, Payload: "run-1"})
+
+	for name, ch := range map[string]<-chan events.Event{"a": a, "b": b} {
+		select {
+		case e := <-ch:
+			if e.Type != events.WorkflowStatus || e.Payload != "run-1" {
+				t.Errorf("%s: got %+v", name, e)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s: no event received", name)
+		}
+	}
+}
+
+func TestMemBus_SubscriptionEndsWithContext(t *testing.T) {
+	bus := events.NewMemBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, _ := bus.Subscribe(ctx)
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("expected channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+	// Publishing after the subscriber left must not block or panic.
+	_ = bus.Publish(context.Background(), events.Event{Type: events.TaskStatus})
+}
+
+func TestMemBus_SlowSubscriberDoesNotBlock(t *testing.T) {
+	bus := events.NewMemBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, _ = bus.Subscribe(ctx) // never read
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			_ = bus.Publish(ctx, events.Event{Type: events.TaskStatus})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a slow subscriber")
+	}
+}
+
+// TestRedisBus_FanOut runs against the server in REDIS_URL and is skipped
+// when it is unset.
+func TestEvent_DecodePayload(t *testing.T) {
+	want := events.Command{WorkerID: "w1", Action: events.CommandDrain}
+	raw, _ := json.Marshal(want)
+	for name, e := range map[string]events.Event{
+		"in-process": {Type: events.WorkerCommand, Payload: want},
+		"json":       {Type: events.WorkerCommand, Payload: json.RawMessage(raw)},
+	} {
+		var got events.Command
+		if err := e.DecodePayload(&got); err != nil || got != want {
+			t.Errorf("%s: got %+v, %v; want %+v", name, got, err, want)
+		}
+	}
+}
+
+func TestAlertBoard_WatchTracksFiringAlerts(t *testing.T) {
+	bus := events.NewMemBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, _ := bus.Subscribe(ctx)
+	board := events.NewAlertBoard()
+	done := make(chan struct{})
+	go func() { board.Watch(sub); close(done) }()
+
+	for _, e := range []events.Event{
+		{Type: events.Alert, Payload: events.AlertUpdate{Name: "queue_depth", Firing: true}},
+		{Type: events.TaskStatus, Payload: events.TaskUpdate{TaskID: "t1"}},
+		{Type: events.Alert, Payload: events.AlertUpdate{Name: "failure_rate", Firing: true}},
+		{Type: events.Alert, Payload: events.AlertUpdate{Name: "queue_depth"}},
+	} {
+		_ = bus.Publish(ctx, e)
+	}
+	cancel()
+	<-done
+
+	firing := board.Firing()
+	if len(firing) != 1 || firing[0].Name != "failure_rate" || !board.Degraded() {
+		t.Errorf("firing: got %+v, want only failure_rate", firing)
+	}
+}
+
+func TestRedisBus_FanOut(t *testing.T) {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		t.Skip("REDIS_URL not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Two buses stand in for two processes sharing the channel.
+	pub, err := events.Open(url + "?channel=test:" + t.Name())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	sub, err := events.Open(url + "?channel=test:" + t.Name())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	ch, err := sub.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := pub.Publish(ctx, events.Event{Type: events.WorkerHeartbeat, Payload: events.Heartbeat{WorkerID: "w1"}}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	select {
+	case e := <-ch:
+		raw, _ := e.Payload.(json.RawMessage)
+		if e.Type != events.WorkerHeartbeat || !strings.Contains(string(raw), `"worker_id":"w1"`) {
+			t.Errorf("got %s %s", e.Type, raw)
+		}
+	case <-ctx.Done():
+		t.Fatal("no event received")
+	}
+}
+
+func TestHistory_RecordsAndPrunes(t *testing.T) {
+	ctx := context.Background()
+	rec := mock.NewEventRepo()
+	stale := &domain.EventRecord{Type: string(events.Alert), Payload: json.RawMessage(`{}`), At: time.Now().Add(-48 * time.Hour)}
+	_ = rec.Append(ctx, stale)
+
+	bus, err := events.OpenHistory(events.NewMemBus(), rec, "24h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, _ := bus.Subscribe(ctx)
+	update := events.TaskUpdate{TaskID: "t1", Status: "success"}
+	if err := bus.Publish(ctx, events.Event{Type: events.TaskStatus, Payload: update}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-sub:
+		if e.Type != events.TaskStatus {
+			t.Errorf("relayed %s, want %s", e.Type, events.TaskStatus)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not published on the wrapped bus")
+	}
+
+	got, _ := rec.List(ctx, repository.EventFilter{})
+	if len(got) != 1 {
+		t.Fatalf("history holds %d events, want the published one only", len(got))
+	}
+	var decoded events.TaskUpdate
+	if got[0].Type != string(events.TaskStatus) || json.Unmarshal(got[0].Payload, &decoded) != nil || decoded.TaskID != "t1" {
+		t.Errorf("recorded %+v", got[0])
+	}
+	if got[0].Seq <= stale.Seq {
+		t.Errorf("seq %d not after %d", got[0].Seq, stale.Seq)
+	}
+}
+
+func TestOpenHistory(t *testing.T) {
+	bus := events.NewMemBus()
+	if got, err := events.OpenHistory(bus, mock.NewEventRepo(), "0"); err != nil || got != events.Bus(bus) {
+		t.Errorf("retention 0: got %T, %v; want the bus unwrapped", got, err)
+	}
+	if got, err := events.OpenHistory(bus, mock.NewEventRepo(), ""); err != nil {
+		t.Errorf("default retention: %v", err)
+	} else if _, ok := got.(*events.History); !ok {
+		t.Errorf("default retention: got %T, want *events.History", got)
+	}
+	if _, err := events.OpenHistory(bus, mock.NewEventRepo(), "a week"); err == nil {
+		t.Error("invalid retention accepted")
+	}
+}
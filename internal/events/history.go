@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// DefaultHistoryRetention is how long OpenHistory keeps events when no
+// retention is given.
+const DefaultHistoryRetention = 7 * 24 * time.Hour
+
+// historyPruneInterval is how often a History deletes the events that have
+// outlived its retention.
+const historyPruneInterval = time.Hour
+
+// Recorder stores published events; repository.EventRepository is one.
+type Recorder interface {
+	Append(ctx context.Context, e *domain.EventRecord) error
+	DeleteBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+// History is a Bus that records every event published through it before
+// passing it on, so what WebSocket clients were told can be read back
+// later. Recording is best effort: an event the Recorder rejects is logged
+// and still published. Events older than the retention are deleted at most
+// once per historyPruneInterval, from the publishing goroutine.
+type History struct {
+	Bus
+	rec    Recorder
+	retain time.Duration
+
+	mu     sync.Mutex
+	pruned time.Time
+}
+
+// NewHistory returns a History recording the events published on bus to
+// rec and keeping them for retain.
+func NewHistory(bus Bus, rec Recorder, retain time.Duration) *History {
+	return &History{Bus: bus, rec: rec, retain: retain}
+}
+
+// OpenHistory wraps bus in a History recording to rec, keeping events for
+// the Go duration retention, or DefaultHistoryRetention if it is empty. A
+// retention of zero records nothing and returns bus itself, as does a nil
+// rec.
+func OpenHistory(bus Bus, rec Recorder, retention string) (Bus, error) {
+	retain := DefaultHistoryRetention
+	if retention != "" {
+		d, err := time.ParseDuration(retention)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("events: invalid history retention %q", retention)
+		}
+		retain = d
+	}
+	if retain == 0 || rec == nil {
+		return bus, nil
+	}
+	return NewHistory(bus, rec, retain), nil
+}
+
+// Publish records e, then publishes it on the wrapped bus.
+func (h *History) Publish(ctx context.Context, e Event) error {
+	now := time.Now().UTC()
+	if err := h.record(ctx, e, now); err != nil {
+		log.Printf("events: record %s event: %v", e.Type, err)
+	}
+	h.prune(ctx, now)
+	return h.Bus.Publish(ctx, e)
+}
+
+func (h *History) record(ctx context.Context, e Event, now time.Time) error {
+	payload, err := json.Marshal(e.Payload)
+	if err != nil {
+		return err
+	}
+	return h.rec.Append(ctx, &domain.EventRecord{Type: string(e.Type), Payload: payload, At: now})
+}
+
+// prune deletes the events older than the retention if it has not done so
+// within historyPruneInterval.
+func (h *History) prune(ctx context.Context, now time.Time) {
+	h.mu.Lock()
+	if now.Sub(h.pruned) < historyPruneInterval {
+		h.mu.Unlock()
+		return
+	}
+	h.pruned = now
+	h.mu.Unlock()
+	if _, err := h.rec.DeleteBefore(context.WithoutCancel(ctx), now.Add(-h.retain)); err != nil {
+		log.Printf("events: prune history: %v", err)
+	}
+}
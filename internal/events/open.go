@@ -0,0 +1,40 @@
+package events
+
+import (
+	"fmt"
+	neturl "net/url"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Open returns the bus described by url:
+//
+//	""                         in-process MemBus
+//	redis://host:port/db       RedisBus on DefaultRedisChannel
+//	rediss://...               RedisBus over TLS
+//
+// A "channel" query parameter on a Redis URL overrides the channel name. Only
+// a Redis bus connects producers and consumers in different processes.
+func Open(url string) (Bus, error) {
+	switch {
+	case url == "":
+		return NewMemBus(), nil
+	case strings.HasPrefix(url, "redis://"), strings.HasPrefix(url, "rediss://"):
+		u, err := neturl.Parse(url)
+		if err != nil {
+			return nil, fmt.Errorf("events: %w", err)
+		}
+		q := u.Query()
+		channel := q.Get("channel")
+		q.Del("channel")
+		u.RawQuery = q.Encode()
+		opts, err := redis.ParseURL(u.String())
+		if err != nil {
+			return nil, fmt.Errorf("events: %w", err)
+		}
+		return NewRedisBus(redis.NewClient(opts), channel), nil
+	default:
+		return nil, fmt.Errorf("events: unsupported URL %q", url)
+	}
+}
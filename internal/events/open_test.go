@@ -0,0 +1,38 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+)
+
+// Compile-time checks: both buses satisfy Bus.
+var (
+	_ events.Bus = (*events.MemBus)(nil)
+	_ events.Bus = (*events.RedisBus)(nil)
+)
+
+func TestOpen(t *testing.T) {
+	b, err := events.Open("")
+	if err != nil {
+		t.Fatalf("Open(\"\"): %v", err)
+	}
+	if _, ok := b.(*events.MemBus); !ok {
+		t.Errorf("Open(\"\") = %T, want *events.MemBus", b)
+	}
+
+	// Constructing the client does not dial, so no server is needed.
+	b, err = events.Open("redis://localhost:6379/0?channel=updates")
+	if err != nil {
+		t.Fatalf("Open(redis): %v", err)
+	}
+	rb, ok := b.(*events.RedisBus)
+	if !ok {
+		t.Fatalf("Open(redis) = %T, want *events.RedisBus", b)
+	}
+	_ = rb.Close()
+
+	if _, err := events.Open("nats://localhost:4222"); err == nil {
+		t.Error("expected error for unsupported scheme")
+	}
+}
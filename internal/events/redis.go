@@ -0,0 +1,87 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisChannel is the pub/sub channel RedisBus uses when none is given.
+const DefaultRedisChannel = "scheduler:events"
+
+// RedisBus is a Bus on a Redis pub/sub channel. Every subscriber in every
+// process receives every event published after it subscribed, so scheduler
+// and worker events reach the WebSocket clients of all API replicas.
+//
+// Redis pub/sub does not store messages: events published while a
+// subscriber is disconnected are lost to it.
+type RedisBus struct {
+	client  *redis.Client
+	channel string
+}
+
+// NewRedisBus creates a RedisBus on channel, or on DefaultRedisChannel when
+// channel is empty.
+func NewRedisBus(client *redis.Client, channel string) *RedisBus {
+	if channel == "" {
+		channel = DefaultRedisChannel
+	}
+	return &RedisBus{client: client, channel: channel}
+}
+
+// Publish encodes e as JSON and publishes it on the channel.
+func (b *RedisBus) Publish(ctx context.Context, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("redis bus: encode %s event: %w", e.Type, err)
+	}
+	return b.client.Publish(ctx, b.channel, data).Err()
+}
+
+// Subscribe subscribes to the channel until ctx is cancelled. Payloads of
+// received events are json.RawMessage values holding the published JSON.
+func (b *RedisBus) Subscribe(ctx context.Context) (<-chan Event, error) {
+	ps := b.client.Subscribe(ctx, b.channel)
+	// Wait for the subscription to be confirmed so no event published after
+	// Subscribe returns is missed.
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, fmt.Errorf("redis bus: subscribe: %w", err)
+	}
+
+	out := make(chan Event, subscriberBuffer)
+	go func() {
+		defer close(out)
+		defer ps.Close()
+		msgs := ps.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var wire struct {
+					Type    Type            `json:"type"`
+					Payload json.RawMessage `json:"payload"`
+				}
+				if err := json.Unmarshal([]byte(msg.Payload), &wire); err != nil {
+					continue
+				}
+				select {
+				case out <- Event{Type: wire.Type, Payload: wire.Payload}:
+				default:
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Close releases the underlying Redis connection pool.
+func (b *RedisBus) Close() error {
+	return b.client.Close()
+}
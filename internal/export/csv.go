@@ -0,0 +1,56 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvWriter writes a header row of column names followed by the rows.
+// Times are RFC 3339 in UTC and missing values are empty.
+type csvWriter struct {
+	w    *csv.Writer
+	cols []Column
+	rec  []string
+}
+
+func newCSVWriter(w io.Writer, cols []Column) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), cols: cols, rec: make([]string, len(cols))}
+	for i, c := range cols {
+		cw.rec[i] = c.Name
+	}
+	if err := cw.w.Write(cw.rec); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(row []any) error {
+	if len(row) != len(cw.cols) {
+		return fmt.Errorf("export: row has %d values, want %d", len(row), len(cw.cols))
+	}
+	for i, v := range row {
+		switch v := v.(type) {
+		case nil:
+			cw.rec[i] = ""
+		case string:
+			cw.rec[i] = v
+		case int64:
+			cw.rec[i] = strconv.FormatInt(v, 10)
+		case float64:
+			cw.rec[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case time.Time:
+			cw.rec[i] = v.UTC().Format(time.RFC3339Nano)
+		default:
+			return fmt.Errorf("export: column %s: unsupported value %T", cw.cols[i].Name, v)
+		}
+	}
+	return cw.w.Write(cw.rec)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
@@ -0,0 +1,64 @@
+// Package export encodes tables of run history in file formats data
+// warehouses load directly.
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+// Formats a Writer can encode.
+const (
+	CSV     = "csv"
+	Parquet = "parquet"
+)
+
+// Type is the type of a Column's values.
+type Type int
+
+// Column types and the Go type of their values.
+const (
+	String Type = iota // string
+	Int                // int64
+	Float              // float64
+	Time               // time.Time
+)
+
+// Column is one column of a table.
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Writer encodes the rows of a table. Each row holds one value per column,
+// of the Go type its Type names, or nil for a missing value. Close must be
+// called once every row is written.
+type Writer interface {
+	Write(row []any) error
+	Close() error
+}
+
+// NewWriter returns a Writer encoding a table of cols to w in format.
+func NewWriter(format string, w io.Writer, cols []Column) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w, cols)
+	case Parquet:
+		return newParquetWriter(w, cols), nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// ValidFormat reports whether NewWriter supports format.
+func ValidFormat(format string) bool {
+	return format == CSV || format == Parquet
+}
+
+// ContentType returns the media type of files in format.
+func ContentType(format string) string {
+	if format == Parquet {
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv; charset=utf-8"
+}
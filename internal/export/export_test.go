@@ -0,0 +1,134 @@
+package export_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/export"
+)
+
+var cols = []export.Column{
+	{Name: "name", Type: export.String},
+	{Name: "attempt", Type: export.Int},
+	{Name: "seconds", Type: export.Float},
+	{Name: "started_at", Type: export.Time},
+}
+
+var start = time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := export.NewWriter(export.CSV, &buf, cols)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	for _, row := range [][]any{
+		{"extract", int64(1), 1.5, start},
+		{"load, final", int64(2), nil, nil},
+	} {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	want := "name,attempt,seconds,started_at\n" +
+		"extract,1,1.5,2026-10-16T09:30:00Z\n" +
+		"\"load, final\",2,,\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+	if err := w.Write([]any{"short"}); err == nil {
+		t.Error("Write of a short row: want error")
+	}
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	if _, err := export.NewWriter("xlsx", &bytes.Buffer{}, cols); err == nil {
+		t.Error("want error for an unknown format")
+	}
+	if export.ValidFormat("xlsx") || !export.ValidFormat(export.Parquet) {
+		t.Error("ValidFormat: want only csv and parquet")
+	}
+}
+
+// TestParquetWriter reads the file back with parquet-go's reader, checking
+// what warehouses rely on: the schema in column order, one row group per
+// ParquetRowGroupSize rows, nulls and the values of every type.
+func TestParquetWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := export.NewWriter(export.Parquet, &buf, cols)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	rows := export.ParquetRowGroupSize + 3
+	for i := range rows {
+		row := []any{"task", int64(i), float64(i) / 2, start.Add(time.Duration(i) * time.Second)}
+		if i%3 == 1 {
+			row[0], row[3] = nil, nil
+		}
+		if err := w.Write(row); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Write([]any{int64(1), int64(1), 1.0, start}); err == nil {
+		t.Error("Write of a mistyped value: want error")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if file.NumRows() != int64(rows) {
+		t.Errorf("rows: got %d, want %d", file.NumRows(), rows)
+	}
+	fields := file.Schema().Fields()
+	if len(fields) != len(cols) {
+		t.Fatalf("schema: got %v", file.Schema())
+	}
+	for i, c := range cols {
+		if fields[i].Name() != c.Name || !fields[i].Optional() {
+			t.Errorf("schema column %d: got %s, want optional %s", i, fields[i].Name(), c.Name)
+		}
+	}
+	if lt := fields[3].Type().LogicalType(); lt == nil || lt.Timestamp == nil {
+		t.Errorf("started_at: got logical type %v, want a timestamp", lt)
+	}
+	groups := file.RowGroups()
+	if len(groups) != 2 || groups[1].NumRows() != 3 {
+		t.Fatalf("row groups: got %d, want 2 with 3 rows in the second", len(groups))
+	}
+
+	r := parquet.NewReader(file)
+	defer r.Close()
+	got := make([]parquet.Row, rows)
+	if n, err := r.ReadRows(got); n != rows || (err != nil && err != io.EOF) {
+		t.Fatalf("ReadRows: got %d rows, %v; want %d", n, err, rows)
+	}
+	// Rows 10000 to 10002: every third of them null.
+	for i, want := range []string{"<null>", "task", "task"} {
+		v := got[export.ParquetRowGroupSize+i][0]
+		name := "<null>"
+		if !v.IsNull() {
+			name = v.String()
+		}
+		if name != want {
+			t.Errorf("name of row %d: got %s, want %s", export.ParquetRowGroupSize+i, name, want)
+		}
+	}
+	if row := got[6]; row[0].String() != "task" || row[1].Int64() != 6 || row[2].Double() != 3 ||
+		row[3].Int64() != start.Add(6*time.Second).UnixMicro() {
+		t.Errorf("row 6: got %v", row)
+	}
+	if row := got[7]; !row[0].IsNull() || row[2].Double() != 3.5 || !row[3].IsNull() {
+		t.Errorf("row 7: got %v, want a null name and start", row)
+	}
+}
@@ -0,0 +1,125 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// ParquetRowGroupSize is how many rows the Parquet writer buffers before
+// writing them out as a row group, bounding its memory use.
+const ParquetRowGroupSize = 10000
+
+// parquetWriter writes a Parquet file with parquet-go, every column
+// optional and in the order of the table's columns, in row groups of
+// ParquetRowGroupSize rows.
+type parquetWriter struct {
+	w    *parquet.Writer
+	cols []Column
+	err  error
+}
+
+func newParquetWriter(w io.Writer, cols []Column) *parquetWriter {
+	return &parquetWriter{
+		w:    parquet.NewWriter(w, parquetSchema(cols), parquet.MaxRowsPerRowGroup(ParquetRowGroupSize)),
+		cols: cols,
+	}
+}
+
+func (pw *parquetWriter) Write(row []any) error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if len(row) != len(pw.cols) {
+		return fmt.Errorf("export: row has %d values, want %d", len(row), len(pw.cols))
+	}
+	values := make(parquet.Row, len(row))
+	for i, v := range row {
+		if v == nil {
+			values[i] = parquet.NullValue().Level(0, 0, i)
+			continue
+		}
+		if !matches(pw.cols[i].Type, v) {
+			return fmt.Errorf("export: column %s: unsupported value %T", pw.cols[i].Name, v)
+		}
+		values[i] = parquetValue(v).Level(0, 1, i)
+	}
+	if _, err := pw.w.WriteRows([]parquet.Row{values}); err != nil {
+		pw.err = fmt.Errorf("export: %w", err)
+	}
+	return pw.err
+}
+
+func (pw *parquetWriter) Close() error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if err := pw.w.Close(); err != nil {
+		pw.err = fmt.Errorf("export: %w", err)
+		return pw.err
+	}
+	pw.err = errors.New("export: writer is closed")
+	return nil
+}
+
+func matches(t Type, v any) bool {
+	switch v.(type) {
+	case string:
+		return t == String
+	case int64:
+		return t == Int
+	case float64:
+		return t == Float
+	case time.Time:
+		return t == Time
+	}
+	return false
+}
+
+// parquetValue converts v, of a column's Go type, to its Parquet value:
+// times become microseconds since the Unix epoch.
+func parquetValue(v any) parquet.Value {
+	switch v := v.(type) {
+	case string:
+		return parquet.ByteArrayValue([]byte(v))
+	case int64:
+		return parquet.Int64Value(v)
+	case float64:
+		return parquet.DoubleValue(v)
+	case time.Time:
+		return parquet.Int64Value(v.UnixMicro())
+	}
+	panic(fmt.Sprintf("export: unsupported value %T", v))
+}
+
+// parquetSchema returns the schema of a table of cols. parquet.Group sorts
+// its fields by name, so the schema is taken from a struct type instead,
+// whose fields keep the order of cols.
+func parquetSchema(cols []Column) *parquet.Schema {
+	fields := make([]reflect.StructField, len(cols))
+	for i, c := range cols {
+		tag := c.Name + ",optional"
+		var typ reflect.Type
+		switch c.Type {
+		case String:
+			typ = reflect.TypeFor[string]()
+		case Int:
+			typ = reflect.TypeFor[int64]()
+		case Float:
+			typ = reflect.TypeFor[float64]()
+		case Time:
+			typ = reflect.TypeFor[time.Time]()
+			tag += ",timestamp(microsecond)"
+		}
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("C%d", i),
+			Type: typ,
+			Tag:  reflect.StructTag(`parquet:"` + tag + `"`),
+		}
+	}
+	return parquet.SchemaOf(reflect.New(reflect.StructOf(fields)).Interface())
+}
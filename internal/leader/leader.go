@@ -0,0 +1,211 @@
+// Package leader provides the shared locks scheduler instances elect a
+// leader with: a lease in Redis or an advisory lock in PostgreSQL. Each
+// instance opens its own handle on the same lock; see scheduler.Elector.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	neturl "net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+	pgdriver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// DefaultLockName names the lock the scheduler instances of a deployment
+// share unless the URL passed to Open names another.
+const DefaultLockName = "scheduler:leader"
+
+// DefaultLeaseTTL is how long a RedisLock lease lasts without renewal, and
+// so about how long a dead leader goes unreplaced.
+const DefaultLeaseTTL = 15 * time.Second
+
+// Open returns a handle on the lock described by url:
+//
+//	""                               nil: no election, every instance leads
+//	redis://host:port/db             RedisLock on DefaultLockName
+//	rediss://...                     RedisLock over TLS
+//	postgres://... or postgresql://  PostgresLock on DefaultLockName
+//
+// A "name" query parameter overrides the lock name, and a "ttl" duration
+// on a Redis URL overrides DefaultLeaseTTL.
+func Open(url string) (scheduler.LeaderLock, error) {
+	if url == "" {
+		return nil, nil
+	}
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return nil, fmt.Errorf("leader: %w", err)
+	}
+	q := u.Query()
+	name := q.Get("name")
+	if name == "" {
+		name = DefaultLockName
+	}
+	q.Del("name")
+	switch u.Scheme {
+	case "redis", "rediss":
+		ttl := DefaultLeaseTTL
+		if v := q.Get("ttl"); v != "" {
+			if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 {
+				return nil, fmt.Errorf("leader: invalid ttl %q", v)
+			}
+		}
+		q.Del("ttl")
+		u.RawQuery = q.Encode()
+		opts, err := redis.ParseURL(u.String())
+		if err != nil {
+			return nil, fmt.Errorf("leader: %w", err)
+		}
+		return NewRedisLock(redis.NewClient(opts), name, ttl), nil
+	case "postgres", "postgresql":
+		u.RawQuery = q.Encode()
+		db, err := gorm.Open(pgdriver.Open(u.String()), &gorm.Config{})
+		if err != nil {
+			return nil, fmt.Errorf("leader: connect to postgres: %w", err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, fmt.Errorf("leader: %w", err)
+		}
+		return NewPostgresLock(sqlDB, name), nil
+	default:
+		return nil, fmt.Errorf("leader: unsupported URL %q", url)
+	}
+}
+
+// acquireScript renews the lease KEYS[1] for ARGV[1] if it holds it, or
+// takes it if it is free, for ARGV[2] milliseconds, and returns 1 if
+// ARGV[1] holds it afterwards.
+var acquireScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes KEYS[1] if ARGV[1] holds it.
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisLock is a lease on a Redis key, holding a token unique to the
+// handle. The holder renews it on every TryLock; a holder that dies or is
+// cut off from Redis loses it when the TTL runs out.
+type RedisLock struct {
+	client *redis.Client
+	key    string
+	ttl    time.Duration
+	token  string
+}
+
+// NewRedisLock creates a handle on the lease at key lasting ttl.
+func NewRedisLock(client *redis.Client, key string, ttl time.Duration) *RedisLock {
+	host, _ := os.Hostname()
+	return &RedisLock{client: client, key: key, ttl: ttl, token: host + "/" + uuid.NewString()}
+}
+
+// TryLock takes or renews the lease.
+func (l *RedisLock) TryLock(ctx context.Context) (bool, error) {
+	n, err := acquireScript.Run(ctx, l.client, []string{l.key}, l.token, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("redis lock %s: %w", l.key, err)
+	}
+	return n == 1, nil
+}
+
+// Unlock gives up the lease if this handle holds it.
+func (l *RedisLock) Unlock(ctx context.Context) error {
+	if err := releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err(); err != nil {
+		return fmt.Errorf("redis lock %s: %w", l.key, err)
+	}
+	return nil
+}
+
+// PostgresLock is a session advisory lock, held on a connection of its own
+// for as long as the handle holds the lock. PostgreSQL frees it as soon as
+// that connection closes, so a leader that dies gives it up with its
+// connection; one cut off from the database gives it up once the server
+// notices.
+type PostgresLock struct {
+	db  *sql.DB
+	key int64
+
+	mu   sync.Mutex
+	conn *sql.Conn // set while the lock is held
+}
+
+// NewPostgresLock creates a handle on the advisory lock named name.
+func NewPostgresLock(db *sql.DB, name string) *PostgresLock {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return &PostgresLock{db: db, key: int64(h.Sum64())}
+}
+
+// TryLock takes the lock, or checks the connection holding it is still up.
+func (l *PostgresLock) TryLock(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err != nil {
+			discard(l.conn)
+			l.conn = nil
+			return false, fmt.Errorf("postgres lock: connection lost: %w", err)
+		}
+		return true, nil
+	}
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("postgres lock: %w", err)
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&ok); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("postgres lock: %w", err)
+	}
+	if !ok {
+		conn.Close()
+		return false, nil
+	}
+	l.conn = conn
+	return true, nil
+}
+
+// Unlock releases the lock if this handle holds it.
+func (l *PostgresLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return nil
+	}
+	conn := l.conn
+	l.conn = nil
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+		discard(conn)
+		return fmt.Errorf("postgres lock: %w", err)
+	}
+	return conn.Close()
+}
+
+// discard closes conn for good rather than returning it to the pool, so
+// any advisory lock it may still hold is freed with it.
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+}
@@ -0,0 +1,36 @@
+package leader_test
+
+import (
+	"testing"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/leader"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// Compile-time checks: both locks satisfy scheduler.LeaderLock.
+var (
+	_ scheduler.LeaderLock = (*leader.RedisLock)(nil)
+	_ scheduler.LeaderLock = (*leader.PostgresLock)(nil)
+)
+
+func TestOpen(t *testing.T) {
+	l, err := leader.Open("")
+	if err != nil || l != nil {
+		t.Errorf("Open(\"\") = %v, %v; want no lock", l, err)
+	}
+
+	// Constructing the client does not dial, so no server is needed.
+	l, err = leader.Open("redis://localhost:6379/0?name=etl:leader&ttl=30s")
+	if err != nil {
+		t.Fatalf("Open(redis): %v", err)
+	}
+	if _, ok := l.(*leader.RedisLock); !ok {
+		t.Errorf("Open(redis) = %T, want *leader.RedisLock", l)
+	}
+
+	for _, url := range []string{"redis://localhost:6379/0?ttl=soon", "redis://localhost:6379/0?ttl=-1s", "etcd://localhost:2379"} {
+		if _, err := leader.Open(url); err == nil {
+			t.Errorf("Open(%q) succeeded, want an error", url)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package queue
+
+import (
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// AWSCredentials authenticate requests to AWS services.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // set for temporary credentials only
+}
+
+// AWSCredentialsFromEnv reads credentials from AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+func AWSCredentialsFromEnv() AWSCredentials {
+	return AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// provider returns the SDK credentials provider for c. Without an access
+// key requests go unsigned, as local emulators accept.
+func (c AWSCredentials) provider() aws.CredentialsProvider {
+	if c.AccessKeyID == "" {
+		return aws.AnonymousCredentials{}
+	}
+	return credentials.NewStaticCredentialsProvider(c.AccessKeyID, c.SecretAccessKey, c.SessionToken)
+}
@@ -0,0 +1,147 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// streamField is the stream entry field holding the JSON encoded task.
+const streamField = "task"
+
+// StreamKey returns the stream a RedisQueue on the list key mirrors its
+// tasks to with WithStream.
+func StreamKey(key string) string {
+	return key + ":stream"
+}
+
+// ConsumerGroup reads the tasks a RedisQueue mirrors with WithStream as one
+// Redis consumer group, e.g. for a shadow or analytics consumer. Every group
+// keeps its own offset and sees every task; the consumers of one group share
+// its tasks between them. Reading a stream never takes tasks off the queue,
+// so the queue's workers are unaffected.
+type ConsumerGroup struct {
+	client   *redis.Client
+	stream   string
+	group    string
+	consumer string
+	// replayed is set once the consumer's unacknowledged entries from an
+	// earlier run have been read again.
+	replayed bool
+}
+
+// Delivery is a task read by a ConsumerGroup. Ack it once processed, or it
+// is delivered to the consumer again after a restart.
+type Delivery struct {
+	ID   string
+	Task *domain.Task
+}
+
+// NewConsumerGroup joins consumer to group on stream, creating the group if
+// it does not exist. A new group starts after the latest entry, or with the
+// oldest one kept when fromStart is set; an existing group keeps its offset.
+func NewConsumerGroup(ctx context.Context, client *redis.Client, stream, group, consumer string, fromStart bool) (*ConsumerGroup, error) {
+	if group == "" || consumer == "" {
+		return nil, errors.New("consumer group: group and consumer names are required")
+	}
+	start := "$"
+	if fromStart {
+		start = "0"
+	}
+	err := client.XGroupCreateMkStream(ctx, stream, group, start).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, fmt.Errorf("consumer group %s: create: %w", group, err)
+	}
+	return &ConsumerGroup{client: client, stream: stream, group: group, consumer: consumer}, nil
+}
+
+// OpenConsumerGroup joins a consumer group on the stream of the Redis queue
+// url names, as NewConsumerGroup does. Close releases its connection.
+func OpenConsumerGroup(ctx context.Context, url, group, consumer string, fromStart bool) (*ConsumerGroup, error) {
+	client, key, _, err := openRedis(url)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		key = DefaultRedisKey
+	}
+	g, err := NewConsumerGroup(ctx, client, StreamKey(key), group, consumer, fromStart)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return g, nil
+}
+
+// Read blocks until the group has a task for this consumer or ctx is
+// cancelled, in which case domain.ErrQueueEmpty is returned. Entries read
+// but not acknowledged by this consumer before a restart come first.
+func (g *ConsumerGroup) Read(ctx context.Context) (*Delivery, error) {
+	for {
+		if ctx.Err() != nil {
+			return nil, domain.ErrQueueEmpty
+		}
+		args := &redis.XReadGroupArgs{Group: g.group, Consumer: g.consumer, Streams: []string{g.stream, ">"}, Count: 1, Block: pollTimeout}
+		if !g.replayed {
+			args.Streams[1], args.Block = "0", -1
+		}
+		res, err := g.client.XReadGroup(ctx, args).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			if ctx.Err() != nil {
+				return nil, domain.ErrQueueEmpty
+			}
+			return nil, fmt.Errorf("consumer group %s: %w", g.group, err)
+		}
+		if len(res) == 0 || len(res[0].Messages) == 0 {
+			g.replayed = true
+			continue
+		}
+		msg := res[0].Messages[0]
+		raw, _ := msg.Values[streamField].(string)
+		var task domain.Task
+		if err := json.Unmarshal([]byte(raw), &task); err != nil {
+			// Acknowledge the entry so it is not delivered again forever.
+			_ = g.Ack(ctx, msg.ID)
+			return nil, fmt.Errorf("consumer group %s: decode entry %s: %w", g.group, msg.ID, err)
+		}
+		return &Delivery{ID: msg.ID, Task: &task}, nil
+	}
+}
+
+// Ack marks the delivery with the given ID as processed by the group.
+func (g *ConsumerGroup) Ack(ctx context.Context, id string) error {
+	return g.client.XAck(ctx, g.stream, g.group, id).Err()
+}
+
+// GroupInfo is where a consumer group stands on its stream.
+type GroupInfo struct {
+	Name string `json:"name"`
+	// Pending counts entries delivered but not yet acknowledged.
+	Pending int64 `json:"pending"`
+	// Lag counts entries not yet delivered to the group.
+	Lag int64 `json:"lag"`
+}
+
+// Info returns where the group stands on its stream.
+func (g *ConsumerGroup) Info(ctx context.Context) (GroupInfo, error) {
+	groups, err := g.client.XInfoGroups(ctx, g.stream).Result()
+	if err != nil {
+		return GroupInfo{}, fmt.Errorf("consumer group %s: %w", g.group, err)
+	}
+	for _, info := range groups {
+		if info.Name == g.group {
+			return GroupInfo{Name: info.Name, Pending: info.Pending, Lag: info.Lag}, nil
+		}
+	}
+	return GroupInfo{}, fmt.Errorf("consumer group %s: not found on %s", g.group, g.stream)
+}
+
+// Close releases the group's Redis connection pool.
+func (g *ConsumerGroup) Close() error {
+	return g.client.Close()
+}
@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// migrateWait bounds how long Migrate waits for a task the source reported
+// waiting, in case another consumer took it first.
+const migrateWait = 2 * time.Second
+
+// Sink receives the tasks Migrate moves. Every domain.Queue is one.
+type Sink interface {
+	Enqueue(ctx context.Context, task *domain.Task) error
+}
+
+// Migrate drains src into dst in the order src serves its tasks and returns
+// how many it moved, stopping once src is empty. Tasks are republished
+// unchanged, so their priority, scheduled time, retries and group survive
+// the move. When dst refuses a task it is put back at the tail of src and
+// Migrate returns the error, so an interrupted migration loses nothing and
+// can be run again.
+func Migrate(ctx context.Context, dst Sink, src domain.Queue) (int, error) {
+	moved := 0
+	for {
+		n, err := src.Len(ctx)
+		if err != nil {
+			return moved, fmt.Errorf("queue migrate: source length: %w", err)
+		}
+		if n == 0 {
+			return moved, nil
+		}
+		wctx, cancel := context.WithTimeout(ctx, migrateWait)
+		task, err := src.Dequeue(wctx)
+		cancel()
+		if errors.Is(err, domain.ErrQueueEmpty) {
+			return moved, ctx.Err()
+		}
+		if err != nil {
+			return moved, fmt.Errorf("queue migrate: dequeue: %w", err)
+		}
+		if err := dst.Enqueue(ctx, task); err != nil {
+			if rerr := src.Enqueue(context.WithoutCancel(ctx), task); rerr != nil {
+				return moved, fmt.Errorf("queue migrate: task %s is lost: enqueue: %v; putting it back: %w", task.ID, err, rerr)
+			}
+			return moved, fmt.Errorf("queue migrate: enqueue task %s: %w", task.ID, err)
+		}
+		moved++
+	}
+}
+
+// SnapshotWriter is a Sink writing tasks to a queue snapshot: one JSON
+// encoded task per line, in the encoding RedisQueue stores them in.
+// Migrating an in-process MemQueue into one, e.g. on shutdown, keeps its
+// tasks for ReadSnapshot to queue again elsewhere.
+type SnapshotWriter struct {
+	enc *json.Encoder
+}
+
+// NewSnapshotWriter returns a SnapshotWriter writing to w.
+func NewSnapshotWriter(w io.Writer) *SnapshotWriter {
+	return &SnapshotWriter{enc: json.NewEncoder(w)}
+}
+
+// Enqueue appends task to the snapshot.
+func (s *SnapshotWriter) Enqueue(_ context.Context, task *domain.Task) error {
+	if err := s.enc.Encode(task); err != nil {
+		return fmt.Errorf("queue snapshot: encode task %s: %w", task.ID, err)
+	}
+	return nil
+}
+
+// ReadSnapshot returns a MemQueue holding the tasks of a snapshot written by
+// SnapshotWriter, in the order they were written.
+func ReadSnapshot(r io.Reader) (*scheduler.MemQueue, error) {
+	q := scheduler.NewMemQueue()
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 64<<20) // payloads may be large
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var task domain.Task
+		if err := json.Unmarshal(sc.Bytes(), &task); err != nil {
+			return nil, fmt.Errorf("queue snapshot: line %d: %w", line, err)
+		}
+		_ = q.Enqueue(context.Background(), &task)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("queue snapshot: %w", err)
+	}
+	return q, nil
+}
@@ -0,0 +1,90 @@
+package queue_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/queue"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+func migrateTasks() []*domain.Task {
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	return []*domain.Task{
+		{ID: "a", Name: "extract", Priority: domain.PriorityHigh, ScheduledAt: at, RetryCount: 1, Group: "gpu"},
+		{ID: "b", Name: "load", Priority: domain.PriorityLow, ScheduledAt: at.Add(time.Hour), Payload: []byte("{}")},
+	}
+}
+
+// TestMigrate_ThroughSnapshot drains a MemQueue into a snapshot and the
+// snapshot into another queue, keeping order, priority and scheduled times.
+func TestMigrate_ThroughSnapshot(t *testing.T) {
+	ctx := context.Background()
+	src := scheduler.NewMemQueue()
+	for _, task := range migrateTasks() {
+		_ = src.Enqueue(ctx, task)
+	}
+	var snap bytes.Buffer
+	if n, err := queue.Migrate(ctx, queue.NewSnapshotWriter(&snap), src); err != nil || n != 2 {
+		t.Fatalf("Migrate to snapshot: got %d, %v; want 2 tasks", n, err)
+	}
+	if n, _ := src.Len(ctx); n != 0 {
+		t.Errorf("source still holds %d tasks", n)
+	}
+
+	restored, err := queue.ReadSnapshot(&snap)
+	if err != nil {
+		t.Fatalf("ReadSnapshot: %v", err)
+	}
+	dst := scheduler.NewMemQueue()
+	if n, err := queue.Migrate(ctx, dst, restored); err != nil || n != 2 {
+		t.Fatalf("Migrate from snapshot: got %d, %v; want 2 tasks", n, err)
+	}
+	for _, want := range migrateTasks() {
+		got, err := dst.Dequeue(ctx)
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		if got.ID != want.ID || got.Priority != want.Priority || !got.ScheduledAt.Equal(want.ScheduledAt) ||
+			got.RetryCount != want.RetryCount || got.Group != want.Group || string(got.Payload) != string(want.Payload) {
+			t.Errorf("task %s: got %+v, want %+v", want.ID, got, want)
+		}
+	}
+}
+
+type failingSink struct{ after int }
+
+func (s *failingSink) Enqueue(context.Context, *domain.Task) error {
+	if s.after == 0 {
+		return errors.New("unavailable")
+	}
+	s.after--
+	return nil
+}
+
+// TestMigrate_KeepsRefusedTask verifies a task the destination refuses is
+// put back on the source.
+func TestMigrate_KeepsRefusedTask(t *testing.T) {
+	ctx := context.Background()
+	src := scheduler.NewMemQueue()
+	for _, task := range migrateTasks() {
+		_ = src.Enqueue(ctx, task)
+	}
+	n, err := queue.Migrate(ctx, &failingSink{after: 1}, src)
+	if err == nil || n != 1 {
+		t.Fatalf("Migrate: got %d, %v; want 1 task and an error", n, err)
+	}
+	if got, _ := src.Dequeue(ctx); got == nil || got.ID != "b" {
+		t.Errorf("source: got %+v, want task b back", got)
+	}
+}
+
+func TestReadSnapshot_RejectsGarbage(t *testing.T) {
+	if _, err := queue.ReadSnapshot(bytes.NewBufferString("{\"ID\":\"a\"}\nnot json\n")); err == nil {
+		t.Error("want error for a malformed line")
+	}
+}
@@ -0,0 +1,229 @@
+package queue
+
+import (
+	"fmt"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// Open returns the queue described by url:
+//
+//	""                         in-process scheduler.MemQueue
+//	journal:PATH               in-process MemQueue journaled to the file PATH
+//	redis://host:port/db       RedisQueue on DefaultRedisKey
+//	rediss://...               RedisQueue over TLS
+//	sqs://host/account/name    SQSQueue on https://host/account/name
+//	sqs+http://...             SQSQueue over plain HTTP, e.g. for a local emulator
+//
+// A "key" query parameter on a Redis URL overrides the list name, and a
+// "stream" parameter mirrors tasks for ConsumerGroups, keeping about that
+// many (see WithStream). The "long_poll", "poll_interval" and
+// "max_idle_backoff" parameters take durations such as 5s and tune how
+// Dequeue polls (see WithPolling).
+//
+// An SQS URL takes the same polling parameters, a "region" (by default
+// AWS_REGION, or the one in an sqs.<region>.amazonaws.com host) and a
+// "visibility" duration (see WithSQSVisibility). Credentials come from
+// the environment (see AWSCredentialsFromEnv).
+//
+// A journaled queue keeps its tasks across restarts of the process (see
+// scheduler.OpenMemQueue) but, like any MemQueue, is only reachable from it.
+func Open(url string) (domain.Queue, error) {
+	if url == "" {
+		return scheduler.NewMemQueue(), nil
+	}
+	if path, ok := strings.CutPrefix(url, journalPrefix); ok {
+		return scheduler.OpenMemQueue(path)
+	}
+	if isSQS(url) {
+		queueURL, region, opts, err := openSQS(url)
+		if err != nil {
+			return nil, err
+		}
+		return NewSQSQueue(queueURL, region, AWSCredentialsFromEnv(), opts...)
+	}
+	client, key, opts, err := openRedis(url)
+	if err != nil {
+		return nil, err
+	}
+	return NewRedisQueue(client, key, opts...), nil
+}
+
+// OpenGroups returns the queue described by url, as Open does, partitioned
+// by worker group. In process every group gets its own MemQueue; on Redis
+// the default group uses the list Open would and each named group the list
+// GroupKey names, over one shared connection pool. On SQS each named group
+// uses the queue SQSGroupURL names, which must exist. A journaled default
+// group keeps its journal at PATH and each named group at PATH.<group>.
+func OpenGroups(url string) (*scheduler.GroupQueues, error) {
+	if url == "" {
+		return scheduler.NewGroupQueues(scheduler.NewMemQueue(), nil), nil
+	}
+	if path, ok := strings.CutPrefix(url, journalPrefix); ok {
+		def, err := scheduler.OpenMemQueue(path)
+		if err != nil {
+			return nil, err
+		}
+		return scheduler.NewGroupQueues(def, func(group string) (domain.Queue, error) {
+			return scheduler.OpenMemQueue(path + "." + group)
+		}), nil
+	}
+	if isSQS(url) {
+		queueURL, region, opts, err := openSQS(url)
+		if err != nil {
+			return nil, err
+		}
+		creds := AWSCredentialsFromEnv()
+		def, err := NewSQSQueue(queueURL, region, creds, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return scheduler.NewGroupQueues(def, func(group string) (domain.Queue, error) {
+			return NewSQSQueue(SQSGroupURL(queueURL, group), region, creds, opts...)
+		}), nil
+	}
+	client, key, opts, err := openRedis(url)
+	if err != nil {
+		return nil, err
+	}
+	def := NewRedisQueue(client, key, opts...)
+	return scheduler.NewGroupQueues(def, func(group string) (domain.Queue, error) {
+		return NewRedisQueue(client, GroupKey(def.key, group), opts...), nil
+	}), nil
+}
+
+// GroupKey returns the Redis list holding the tasks of group for a queue
+// whose default group uses key.
+func GroupKey(key, group string) string {
+	if key == "" {
+		key = DefaultRedisKey
+	}
+	return key + ":group:" + group
+}
+
+// SQSGroupURL returns the URL of the SQS queue holding the tasks of group
+// for a queue whose default group uses queueURL. SQS queue names cannot
+// contain dots, so the group's dots become underscores.
+func SQSGroupURL(queueURL, group string) string {
+	return queueURL + "-group-" + strings.ReplaceAll(group, ".", "_")
+}
+
+// journalPrefix selects a journaled in-process queue.
+const journalPrefix = "journal:"
+
+func isSQS(url string) bool {
+	return strings.HasPrefix(url, "sqs://") || strings.HasPrefix(url, "sqs+http://")
+}
+
+// openSQS parses an SQS queue URL into the queue's HTTP URL, its region and
+// the queue options its other parameters select.
+func openSQS(url string) (string, string, []SQSOption, error) {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("queue: %w", err)
+	}
+	q := u.Query()
+	region := q.Get("region")
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if host := u.Hostname(); region == "" && strings.HasPrefix(host, "sqs.") && strings.HasSuffix(host, ".amazonaws.com") {
+		region = strings.TrimSuffix(strings.TrimPrefix(host, "sqs."), ".amazonaws.com")
+	}
+	if region == "" {
+		return "", "", nil, fmt.Errorf("queue: no region for %q: set the region parameter or AWS_REGION", url)
+	}
+	var opts []SQSOption
+	if v := q.Get("visibility"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return "", "", nil, fmt.Errorf("queue: visibility must be a positive duration, got %q", v)
+		}
+		opts = append(opts, WithSQSVisibility(d))
+	}
+	poll, err := pollConfig(q)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if poll != (domain.PollConfig{}) {
+		opts = append(opts, WithSQSPolling(poll))
+	}
+	u.Scheme = "https"
+	if strings.HasPrefix(url, "sqs+http://") {
+		u.Scheme = "http"
+	}
+	u.RawQuery = ""
+	return u.String(), region, opts, nil
+}
+
+// openRedis parses a Redis queue URL into a client, the list name given by
+// its "key" parameter, if any, and the queue options its other parameters
+// select.
+func openRedis(url string) (*redis.Client, string, []RedisOption, error) {
+	if !strings.HasPrefix(url, "redis://") && !strings.HasPrefix(url, "rediss://") {
+		return nil, "", nil, fmt.Errorf("queue: unsupported URL %q", url)
+	}
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("queue: %w", err)
+	}
+	q := u.Query()
+	key := q.Get("key")
+	var qopts []RedisOption
+	if v := q.Get("stream"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, "", nil, fmt.Errorf("queue: stream must be a positive entry count, got %q", v)
+		}
+		qopts = append(qopts, WithStream(n))
+	}
+	poll, err := pollConfig(q)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if poll != (domain.PollConfig{}) {
+		qopts = append(qopts, WithPolling(poll))
+	}
+	for _, p := range []string{"key", "stream", "long_poll", "poll_interval", "max_idle_backoff"} {
+		q.Del(p)
+	}
+	u.RawQuery = q.Encode()
+	opts, err := redis.ParseURL(u.String())
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("queue: %w", err)
+	}
+	return redis.NewClient(opts), key, qopts, nil
+}
+
+// pollConfig reads the polling parameters of a queue URL.
+func pollConfig(q neturl.Values) (domain.PollConfig, error) {
+	var c domain.PollConfig
+	for name, d := range map[string]*time.Duration{
+		"long_poll":        &c.LongPoll,
+		"poll_interval":    &c.Interval,
+		"max_idle_backoff": &c.MaxIdleBackoff,
+	} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		var err error
+		if *d, err = time.ParseDuration(v); err != nil {
+			return c, fmt.Errorf("queue: %s: %w", name, err)
+		}
+	}
+	if c.LongPoll != 0 && c.LongPoll < time.Second {
+		return c, fmt.Errorf("queue: long_poll must be at least 1s, got %s", c.LongPoll)
+	}
+	if err := c.Validate(); err != nil {
+		return c, fmt.Errorf("queue: %w", err)
+	}
+	return c, nil
+}
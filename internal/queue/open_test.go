@@ -0,0 +1,189 @@
+package queue_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/queue"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// Compile-time check: RedisQueue satisfies domain.Queue.
+var _ domain.Queue = (*queue.RedisQueue)(nil)
+
+func TestOpen_EmptyURLIsInMemory(t *testing.T) {
+	q, err := queue.Open("")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, ok := q.(*scheduler.MemQueue); !ok {
+		t.Errorf("Open(\"\") = %T, want *scheduler.MemQueue", q)
+	}
+}
+
+func TestOpenGroups_Journal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.journal")
+	groups, err := queue.OpenGroups("journal:" + path)
+	if err != nil {
+		t.Fatalf("OpenGroups: %v", err)
+	}
+	task := &domain.Task{ID: "t1", Name: "t1", Priority: domain.PriorityNormal, Group: "etl"}
+	if err := groups.Enqueue(context.Background(), task); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if _, err := os.Stat(path + ".etl"); err != nil {
+		t.Errorf("group etl has no journal of its own: %v", err)
+	}
+
+	// Reopening replays the group's journal.
+	q, err := queue.Open("journal:" + path + ".etl")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if n, _ := q.Len(context.Background()); n != 1 {
+		t.Errorf("reopened group queue holds %d tasks, want 1", n)
+	}
+}
+
+func TestOpen_RedisURL(t *testing.T) {
+	// Constructing the client does not dial, so no server is needed.
+	q, err := queue.Open("redis://localhost:6379/0?key=jobs")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	rq, ok := q.(*queue.RedisQueue)
+	if !ok {
+		t.Fatalf("Open = %T, want *queue.RedisQueue", q)
+	}
+	_ = rq.Close()
+}
+
+func TestOpen_RejectsUnknownScheme(t *testing.T) {
+	for _, url := range []string{"amqp://localhost", "localhost:6379", "redis://:bad:port/x"} {
+		if _, err := queue.Open(url); err == nil {
+			t.Errorf("Open(%q): expected error", url)
+		}
+	}
+}
+
+func TestOpenGroups_InMemory(t *testing.T) {
+	groups, err := queue.OpenGroups("")
+	if err != nil {
+		t.Fatalf("OpenGroups: %v", err)
+	}
+	a, err := groups.Queue("a")
+	if err != nil {
+		t.Fatalf("Queue: %v", err)
+	}
+	def, _ := groups.Queue("")
+	if a == def {
+		t.Error("named group shares the default group's queue")
+	}
+}
+
+func TestOpenGroups_RedisKeys(t *testing.T) {
+	groups, err := queue.OpenGroups("redis://localhost:6379/0?key=jobs")
+	if err != nil {
+		t.Fatalf("OpenGroups: %v", err)
+	}
+	def, _ := groups.Queue("")
+	etl, _ := groups.Queue("etl")
+	if got := def.(*queue.RedisQueue).Key(); got != "jobs" {
+		t.Errorf("default key: got %q, want jobs", got)
+	}
+	if got := etl.(*queue.RedisQueue).Key(); got != "jobs:group:etl" {
+		t.Errorf("group key: got %q, want jobs:group:etl", got)
+	}
+	_ = def.(*queue.RedisQueue).Close()
+}
+
+func TestOpen_RedisStream(t *testing.T) {
+	groups, err := queue.OpenGroups("redis://localhost:6379/0?key=jobs&stream=10000")
+	if err != nil {
+		t.Fatalf("OpenGroups: %v", err)
+	}
+	def, _ := groups.Queue("")
+	etl, _ := groups.Queue("etl")
+	if got := def.(*queue.RedisQueue).Stream(); got != "jobs:stream" {
+		t.Errorf("default stream: got %q, want jobs:stream", got)
+	}
+	if got := etl.(*queue.RedisQueue).Stream(); got != "jobs:group:etl:stream" {
+		t.Errorf("group stream: got %q, want jobs:group:etl:stream", got)
+	}
+	_ = def.(*queue.RedisQueue).Close()
+
+	q, _ := queue.Open("redis://localhost:6379/0")
+	if got := q.(*queue.RedisQueue).Stream(); got != "" {
+		t.Errorf("stream without the parameter: got %q, want none", got)
+	}
+	_ = q.(*queue.RedisQueue).Close()
+
+	for _, url := range []string{"redis://localhost:6379/0?stream=0", "redis://localhost:6379/0?stream=lots"} {
+		if _, err := queue.Open(url); err == nil {
+			t.Errorf("Open(%q): expected error", url)
+		}
+	}
+}
+
+func TestOpen_RedisPolling(t *testing.T) {
+	q, err := queue.Open("redis://localhost:6379/0?long_poll=5s&poll_interval=200ms&max_idle_backoff=2s")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	want := domain.PollConfig{LongPoll: 5 * time.Second, Interval: 200 * time.Millisecond, MaxIdleBackoff: 2 * time.Second}
+	if got := q.(*queue.RedisQueue).Polling(); got != want {
+		t.Errorf("polling: got %+v, want %+v", got, want)
+	}
+	_ = q.(*queue.RedisQueue).Close()
+
+	for _, url := range []string{
+		"redis://localhost:6379/0?long_poll=500ms",
+		"redis://localhost:6379/0?poll_interval=soon",
+		"redis://localhost:6379/0?poll_interval=2s&max_idle_backoff=1s",
+	} {
+		if _, err := queue.Open(url); err == nil {
+			t.Errorf("Open(%q): expected error", url)
+		}
+	}
+}
+
+func TestNewRedisRetryQueue_Key(t *testing.T) {
+	groups, err := queue.OpenGroups("redis://localhost:6379/0?key=jobs")
+	if err != nil {
+		t.Fatalf("OpenGroups: %v", err)
+	}
+	etl, _ := groups.Queue("etl")
+	if got := queue.NewRedisRetryQueue(etl.(*queue.RedisQueue)).Key(); got != "jobs:group:etl:retry" {
+		t.Errorf("retry key: got %q, want jobs:group:etl:retry", got)
+	}
+	_ = etl.(*queue.RedisQueue).Close()
+}
+
+func TestPriorityKey(t *testing.T) {
+	cases := map[domain.Priority]string{
+		domain.PriorityHigh:   "jobs:p10",
+		domain.PriorityLow:    "jobs:p1",
+		domain.PriorityNormal: "jobs",
+		0:                     "jobs",
+		11:                    "jobs",
+	}
+	for p, want := range cases {
+		if got := queue.PriorityKey("jobs", p); got != want {
+			t.Errorf("PriorityKey(jobs, %d): got %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestOpenConsumerGroup_Validates(t *testing.T) {
+	ctx := context.Background()
+	if _, err := queue.OpenConsumerGroup(ctx, "amqp://localhost", "analytics", "c1", false); err == nil {
+		t.Error("unsupported URL: expected error")
+	}
+	if _, err := queue.OpenConsumerGroup(ctx, "redis://localhost:6379/0", "", "c1", false); err == nil {
+		t.Error("missing group: expected error")
+	}
+}
@@ -0,0 +1,197 @@
+package queue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// DefaultOffloadThreshold is the payload size, in bytes, above which an
+// OffloadQueue moves a payload to its store. Payloads travel base64-encoded
+// in the JSON message, a third larger than they are, so this keeps a task
+// well inside SQS's 256 KiB message limit.
+const DefaultOffloadThreshold = 128 << 10
+
+// OffloadQueue is a Queue that moves task payloads larger than its
+// threshold to a PayloadStore on Enqueue, so the message carries only
+// their key in PayloadRef. Workers load the payload back with
+// worker.WithPayloadStore before the handler runs.
+//
+// Payloads are stored under the hash of their content, so a retried task,
+// which keeps its PayloadRef, is not stored again. Nothing is deleted;
+// expire old payloads with the store's own lifecycle rules.
+type OffloadQueue struct {
+	domain.Queue
+	store     domain.PayloadStore
+	threshold int
+}
+
+// NewOffloadQueue wraps q so payloads larger than threshold bytes go to
+// store. A threshold of zero or less uses DefaultOffloadThreshold.
+func NewOffloadQueue(q domain.Queue, store domain.PayloadStore, threshold int) *OffloadQueue {
+	if threshold <= 0 {
+		threshold = DefaultOffloadThreshold
+	}
+	return &OffloadQueue{Queue: q, store: store, threshold: threshold}
+}
+
+// Enqueue stores task's payload if it is too large and pushes a copy of
+// task carrying its PayloadRef instead. task itself is left unchanged.
+func (q *OffloadQueue) Enqueue(ctx context.Context, task *domain.Task) error {
+	if len(task.Payload) == 0 || (task.PayloadRef == "" && len(task.Payload) <= q.threshold) {
+		return q.Queue.Enqueue(ctx, task)
+	}
+	light := *task
+	if light.PayloadRef == "" {
+		light.PayloadRef = PayloadKey(task.Payload)
+		if err := q.store.Put(ctx, light.PayloadRef, task.Payload); err != nil {
+			return fmt.Errorf("offload payload of task %s: %w", task.ID, err)
+		}
+	}
+	light.Payload = nil
+	return q.Queue.Enqueue(ctx, &light)
+}
+
+// Ack passes through to a queue that takes acknowledgements.
+func (q *OffloadQueue) Ack(ctx context.Context, task *domain.Task) error {
+	if aq, ok := q.Queue.(domain.AckQueue); ok {
+		return aq.Ack(ctx, task)
+	}
+	return nil
+}
+
+// OffloadGroups wraps the queue of every group of g, as NewOffloadQueue
+// does.
+func OffloadGroups(g *scheduler.GroupQueues, store domain.PayloadStore, threshold int) (*scheduler.GroupQueues, error) {
+	def, err := g.Queue(domain.DefaultGroup)
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.NewGroupQueues(NewOffloadQueue(def, store, threshold), func(group string) (domain.Queue, error) {
+		q, err := g.Queue(group)
+		if err != nil {
+			return nil, err
+		}
+		return NewOffloadQueue(q, store, threshold), nil
+	}), nil
+}
+
+// PayloadKey returns the key an OffloadQueue stores payload under.
+func PayloadKey(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// OpenPayloadStore returns the payload store described by url:
+//
+//	file:///path                  FilePayloadStore in /path
+//	s3://bucket/prefix            S3PayloadStore on AWS
+//	s3+http://host:port/bucket    S3PayloadStore on an S3-compatible server
+//
+// An S3 URL takes a "region" parameter, by default AWS_REGION, and an
+// "endpoint" host for S3-compatible services on https. Credentials come
+// from the environment (see AWSCredentialsFromEnv).
+func OpenPayloadStore(url string) (domain.PayloadStore, error) {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return nil, fmt.Errorf("payload store: %w", err)
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("payload store: no directory in %q", url)
+		}
+		return NewFilePayloadStore(u.Path)
+	case "s3", "s3+http":
+		region := u.Query().Get("region")
+		if region == "" {
+			region = os.Getenv("AWS_REGION")
+		}
+		endpoint := "https://s3." + region + ".amazonaws.com"
+		if host := u.Query().Get("endpoint"); host != "" {
+			endpoint = "https://" + host
+		}
+		bucket, prefix := u.Host, u.Path
+		if u.Scheme == "s3+http" {
+			endpoint = "http://" + u.Host
+			bucket, prefix, _ = strings.Cut(strings.Trim(u.Path, "/"), "/")
+		}
+		return NewS3PayloadStore(endpoint, bucket, prefix, region, AWSCredentialsFromEnv())
+	default:
+		return nil, fmt.Errorf("payload store: unsupported URL %q", url)
+	}
+}
+
+// FilePayloadStore is a domain.PayloadStore keeping each payload in a file
+// of one directory, such as a volume every process mounts.
+type FilePayloadStore struct {
+	dir string
+}
+
+// NewFilePayloadStore creates a FilePayloadStore in dir, creating dir if
+// needed.
+func NewFilePayloadStore(dir string) (*FilePayloadStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("payload store: %w", err)
+	}
+	return &FilePayloadStore{dir: dir}, nil
+}
+
+// Put writes data to the file named key, replacing it atomically so a
+// concurrent Get never sees part of it.
+func (s *FilePayloadStore) Put(_ context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.dir, ".payload-*")
+	if err != nil {
+		return fmt.Errorf("payload store: %w", err)
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("payload store: %w", err)
+	}
+	return nil
+}
+
+// Get reads the file named key.
+func (s *FilePayloadStore) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, domain.ErrPayloadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("payload store: %w", err)
+	}
+	return data, nil
+}
+
+// path returns the file of key, refusing keys that would leave the
+// directory.
+func (s *FilePayloadStore) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("payload store: invalid key %q", key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
@@ -0,0 +1,146 @@
+package queue_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/queue"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+func TestOffloadQueue_MovesLargePayloads(t *testing.T) {
+	ctx := context.Background()
+	store := scheduler.NewMemPayloadStore()
+	mem := scheduler.NewMemQueue()
+	q := queue.NewOffloadQueue(mem, store, 16)
+
+	small := &domain.Task{ID: "small", Name: "a", Priority: domain.PriorityNormal, Payload: []byte("echo hi")}
+	large := &domain.Task{ID: "large", Name: "b", Priority: domain.PriorityNormal, Payload: bytes.Repeat([]byte("x"), 64)}
+	for _, task := range []*domain.Task{small, large} {
+		if err := q.Enqueue(ctx, task); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	if len(large.Payload) != 64 || large.PayloadRef != "" {
+		t.Errorf("Enqueue changed the caller's task: %+v", large)
+	}
+
+	got := map[string]*domain.Task{}
+	for i := 0; i < 2; i++ {
+		task, _ := q.Dequeue(ctx)
+		got[task.ID] = task
+	}
+	if s := got["small"]; string(s.Payload) != "echo hi" || s.PayloadRef != "" {
+		t.Errorf("small task: got payload %q, ref %q; want it inline", s.Payload, s.PayloadRef)
+	}
+	l := got["large"]
+	if len(l.Payload) != 0 || l.PayloadRef != queue.PayloadKey(large.Payload) {
+		t.Fatalf("large task: got %d payload bytes, ref %q; want only the ref", len(l.Payload), l.PayloadRef)
+	}
+	if data, err := store.Get(ctx, l.PayloadRef); err != nil || !bytes.Equal(data, large.Payload) {
+		t.Errorf("stored payload: got %d bytes, %v", len(data), err)
+	}
+
+	// A retry keeps its ref and is not stored again.
+	l.Payload = large.Payload
+	_ = q.Enqueue(ctx, l)
+	retry, _ := q.Dequeue(ctx)
+	if len(retry.Payload) != 0 || retry.PayloadRef != l.PayloadRef || store.Len() != 1 {
+		t.Errorf("retry: got %d payload bytes, ref %q, %d stored", len(retry.Payload), retry.PayloadRef, store.Len())
+	}
+}
+
+func TestFilePayloadStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := queue.OpenPayloadStore("file://" + t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenPayloadStore: %v", err)
+	}
+	if err := store.Put(ctx, "k1", []byte("payload")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if data, err := store.Get(ctx, "k1"); err != nil || string(data) != "payload" {
+		t.Errorf("Get: got %q, %v", data, err)
+	}
+	if _, err := store.Get(ctx, "missing"); err != domain.ErrPayloadNotFound {
+		t.Errorf("Get of a missing key: got %v, want ErrPayloadNotFound", err)
+	}
+	if err := store.Put(ctx, "../escape", nil); err == nil {
+		t.Error("Put outside the directory: expected error")
+	}
+}
+
+// fakeS3 serves object PUTs and GETs from memory.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte // path → body
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("X-Amz-Content-Sha256") == "" || !strings.Contains(r.Header.Get("Authorization"), "/s3/aws4_request") {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[r.URL.Path], _ = io.ReadAll(r.Body)
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+			return
+		}
+		_, _ = w.Write(data)
+	}
+}
+
+func TestS3PayloadStore(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	store, err := queue.OpenPayloadStore("s3+http://" + strings.TrimPrefix(srv.URL, "http://") + "/bucket/payloads?region=us-east-1")
+	if err != nil {
+		t.Fatalf("OpenPayloadStore: %v", err)
+	}
+	if err := store.Put(ctx, "k1", []byte("payload")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, ok := fake.objects["/bucket/payloads/k1"]; !ok {
+		t.Errorf("objects: got %v, want /bucket/payloads/k1", fake.objects)
+	}
+	if data, err := store.Get(ctx, "k1"); err != nil || string(data) != "payload" {
+		t.Errorf("Get: got %q, %v", data, err)
+	}
+	if _, err := store.Get(ctx, "missing"); err != domain.ErrPayloadNotFound {
+		t.Errorf("Get of a missing key: got %v, want ErrPayloadNotFound", err)
+	}
+
+	aws, err := queue.OpenPayloadStore("s3://bucket/payloads?region=eu-west-1")
+	if err != nil {
+		t.Fatalf("OpenPayloadStore: %v", err)
+	}
+	if got := aws.(*queue.S3PayloadStore).URL("k1"); got != "https://s3.eu-west-1.amazonaws.com/bucket/payloads/k1" {
+		t.Errorf("object URL: got %s", got)
+	}
+	for _, url := range []string{"s3:///payloads?region=eu-west-1", "gs://bucket", "file://"} {
+		if _, err := queue.OpenPayloadStore(url); err == nil {
+			t.Errorf("OpenPayloadStore(%q): expected error", url)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Package queue provides domain.Queue implementations that can be shared
+// between processes, and Open to pick one from a connection URL.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// DefaultRedisKey is the Redis list RedisQueue uses when none is given.
+const DefaultRedisKey = "scheduler:queue"
+
+// pollTimeout bounds each blocking BRPOP so Dequeue notices a cancelled
+// context promptly. WithPolling can lengthen it.
+const pollTimeout = time.Second
+
+// RedisQueue is a domain.Queue backed by Redis lists, one per task
+// priority (see PriorityKey). Tasks are stored as JSON, pushed on the left
+// and popped from the right of their priority's list, and BRPOP scans the
+// lists highest priority first, so tasks are served by priority, in FIFO
+// order within one, to any number of consumers.
+type RedisQueue struct {
+	client *redis.Client
+	key    string
+	// streamMaxLen, when positive, mirrors every enqueued task to the
+	// stream StreamKey names, trimmed to about that many entries.
+	streamMaxLen int64
+	poll         domain.PollConfig
+}
+
+// RedisOption is a functional option for configuring a RedisQueue.
+type RedisOption func(*RedisQueue)
+
+// WithStream also appends every enqueued task to a Redis stream, keeping
+// roughly the latest maxLen, for ConsumerGroups to read alongside the
+// queue's workers.
+func WithStream(maxLen int64) RedisOption {
+	return func(q *RedisQueue) { q.streamMaxLen = maxLen }
+}
+
+// WithPolling tunes how Dequeue polls Redis: each BRPOP blocks for
+// c.LongPoll instead of pollTimeout, and a BRPOP that finds the list empty
+// is followed by c.IdleDelay before the next. Redis blocks in whole
+// seconds, so LongPoll is rounded down to them, to at least one. Longer
+// waits cost less broker load and, with a pause, more pickup latency.
+func WithPolling(c domain.PollConfig) RedisOption {
+	return func(q *RedisQueue) { q.poll = c }
+}
+
+// NewRedisQueue creates a RedisQueue on the list named key, or on
+// DefaultRedisKey when key is empty.
+func NewRedisQueue(client *redis.Client, key string, opts ...RedisOption) *RedisQueue {
+	if key == "" {
+		key = DefaultRedisKey
+	}
+	q := &RedisQueue{client: client, key: key}
+	for _, o := range opts {
+		o(q)
+	}
+	return q
+}
+
+// PriorityKey returns the list a RedisQueue on the list key keeps tasks of
+// priority p on. Normal priority, and any priority out of range, uses key
+// itself, so a queue whose tasks all have the default priority is still
+// the single list it has always been.
+func PriorityKey(key string, p domain.Priority) string {
+	if p == domain.PriorityNormal || p < domain.PriorityLow || p > domain.PriorityHigh {
+		return key
+	}
+	return fmt.Sprintf("%s:p%d", key, p)
+}
+
+// keys returns every list of the queue, highest priority first.
+func (q *RedisQueue) keys() []string {
+	keys := make([]string, 0, domain.PriorityHigh-domain.PriorityLow+1)
+	for p := domain.PriorityHigh; p >= domain.PriorityLow; p-- {
+		keys = append(keys, PriorityKey(q.key, p))
+	}
+	return keys
+}
+
+// Enqueue appends task to the tail of its priority's list. With WithStream
+// the task is appended to the stream in the same transaction.
+func (q *RedisQueue) Enqueue(ctx context.Context, task *domain.Task) error {
+	b, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("redis queue: encode task %s: %w", task.ID, err)
+	}
+	list := PriorityKey(q.key, task.Priority)
+	if q.streamMaxLen <= 0 {
+		return q.client.LPush(ctx, list, b).Err()
+	}
+	_, err = q.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.LPush(ctx, list, b)
+		p.XAdd(ctx, &redis.XAddArgs{
+			Stream: StreamKey(q.key),
+			MaxLen: q.streamMaxLen,
+			Approx: true,
+			Values: []string{streamField, string(b)},
+		})
+		return nil
+	})
+	return err
+}
+
+// Dequeue removes and returns the head task of the highest priority with
+// any waiting. It blocks until a task is available or ctx is cancelled, in
+// which case domain.ErrQueueEmpty is returned.
+func (q *RedisQueue) Dequeue(ctx context.Context) (*domain.Task, error) {
+	block := pollTimeout
+	if q.poll.LongPoll >= time.Second {
+		block = q.poll.LongPoll
+	}
+	keys := q.keys()
+	for empty := 0; ; {
+		if ctx.Err() != nil {
+			return nil, domain.ErrQueueEmpty
+		}
+		res, err := q.client.BRPop(ctx, block, keys...).Result()
+		if errors.Is(err, redis.Nil) {
+			empty++
+			if d := q.poll.IdleDelay(empty); d > 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(d):
+				}
+			}
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, domain.ErrQueueEmpty
+			}
+			return nil, fmt.Errorf("redis queue: %w", err)
+		}
+		// BRPOP replies with the key followed by the popped value.
+		var task domain.Task
+		if err := json.Unmarshal([]byte(res[1]), &task); err != nil {
+			return nil, fmt.Errorf("redis queue: decode task: %w", err)
+		}
+		return &task, nil
+	}
+}
+
+// Len returns the number of tasks currently waiting in the queue, at any
+// priority.
+func (q *RedisQueue) Len(ctx context.Context) (int, error) {
+	keys := q.keys()
+	lens := make([]*redis.IntCmd, len(keys))
+	_, err := q.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, k := range keys {
+			lens[i] = p.LLen(ctx, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, l := range lens {
+		n += int(l.Val())
+	}
+	return n, nil
+}
+
+// Key returns the name of the Redis list backing the queue, which holds its
+// normal-priority tasks; PriorityKey names the others.
+func (q *RedisQueue) Key() string {
+	return q.key
+}
+
+// Stream returns the name of the stream the queue mirrors its tasks to, or
+// "" without WithStream.
+func (q *RedisQueue) Stream() string {
+	if q.streamMaxLen <= 0 {
+		return ""
+	}
+	return StreamKey(q.key)
+}
+
+// Polling returns the polling configuration set with WithPolling.
+func (q *RedisQueue) Polling() domain.PollConfig {
+	return q.poll
+}
+
+// Close releases the underlying Redis connection pool, which the queues of
+// OpenGroups share.
+func (q *RedisQueue) Close() error {
+	return q.client.Close()
+}
@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// RetryKey returns the sorted set holding the retries of a RedisQueue on
+// the list key.
+func RetryKey(key string) string {
+	return key + ":retry"
+}
+
+// releaseScript moves up to ARGV[2] members of the sorted set KEYS[1]
+// scored at or below ARGV[1] onto the queue on the list KEYS[2], lowest
+// score first, also appending them to the stream KEYS[3], under the field
+// ARGV[4], when ARGV[3] is positive. A negative ARGV[2] moves them all.
+// Each task goes to the list PriorityKey names for its Priority, given the
+// normal, low and high priorities as ARGV[5..7]. Running as one script
+// keeps two releasing workers from moving the same task.
+var releaseScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local normal, low, high = tonumber(ARGV[5]), tonumber(ARGV[6]), tonumber(ARGV[7])
+for _, task in ipairs(due) do
+	redis.call('ZREM', KEYS[1], task)
+	local p = tonumber(cjson.decode(task)['Priority']) or normal
+	local list = KEYS[2]
+	if p ~= normal and p >= low and p <= high then
+		list = KEYS[2] .. ':p' .. p
+	end
+	redis.call('LPUSH', list, task)
+	if tonumber(ARGV[3]) > 0 then
+		redis.call('XADD', KEYS[3], 'MAXLEN', '~', ARGV[3], '*', ARGV[4], task)
+	end
+end
+return #due
+`)
+
+// RedisRetryQueue is a domain.RetryQueue kept in a Redis sorted set scored
+// by due time, which releases onto the list of a RedisQueue. Every worker
+// of the queue can share it: retries outlive the worker that scheduled
+// them and any of the workers may release them.
+type RedisRetryQueue struct {
+	queue *RedisQueue
+	key   string
+}
+
+// NewRedisRetryQueue creates the RedisRetryQueue of q, on the sorted set
+// RetryKey names. Released tasks are mirrored to q's stream as Enqueue
+// would.
+func NewRedisRetryQueue(q *RedisQueue) *RedisRetryQueue {
+	return &RedisRetryQueue{queue: q, key: RetryKey(q.key)}
+}
+
+// Schedule holds task until at.
+func (r *RedisRetryQueue) Schedule(ctx context.Context, task *domain.Task, at time.Time) error {
+	b, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("redis retry queue: encode task %s: %w", task.ID, err)
+	}
+	return r.queue.client.ZAdd(ctx, r.key, redis.Z{Score: float64(at.UnixMilli()), Member: b}).Err()
+}
+
+// Release moves up to limit tasks due at or before now onto the queue,
+// earliest first.
+func (r *RedisRetryQueue) Release(ctx context.Context, now time.Time, limit int) (int, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	n, err := releaseScript.Run(ctx, r.queue.client,
+		[]string{r.key, r.queue.key, StreamKey(r.queue.key)},
+		now.UnixMilli(), limit, r.queue.streamMaxLen, streamField,
+		int(domain.PriorityNormal), int(domain.PriorityLow), int(domain.PriorityHigh),
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("redis retry queue: %w", err)
+	}
+	return n, nil
+}
+
+// Len returns the number of tasks waiting.
+func (r *RedisRetryQueue) Len(ctx context.Context) (int, error) {
+	n, err := r.queue.client.ZCard(ctx, r.key).Result()
+	return int(n), err
+}
+
+// Key returns the name of the sorted set backing the retry queue.
+func (r *RedisRetryQueue) Key() string {
+	return r.key
+}
@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	neturl "net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// S3PayloadStore is a domain.PayloadStore keeping each payload as an object
+// in an S3 bucket, through the AWS SDK's S3 client. Objects are addressed
+// path-style so S3-compatible servers such as MinIO work too.
+type S3PayloadStore struct {
+	endpoint string
+	bucket   string
+	prefix   string // prepended to keys, ending in a slash unless empty
+	client   *s3.Client
+}
+
+// NewS3PayloadStore creates an S3PayloadStore on bucket at endpoint, such
+// as https://s3.us-east-1.amazonaws.com, in region. Keys are stored under
+// prefix, if not empty.
+func NewS3PayloadStore(endpoint, bucket, prefix, region string, creds AWSCredentials) (*S3PayloadStore, error) {
+	if u, err := neturl.Parse(endpoint); err != nil || u.Host == "" {
+		return nil, fmt.Errorf("s3 payload store: invalid endpoint %q", endpoint)
+	}
+	if bucket == "" {
+		return nil, errors.New("s3 payload store: bucket must be set")
+	}
+	if region == "" {
+		return nil, errors.New("s3 payload store: region must be set")
+	}
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		prefix += "/"
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	return &S3PayloadStore{
+		endpoint: endpoint,
+		bucket:   bucket,
+		prefix:   prefix,
+		client: s3.New(s3.Options{
+			Region:       region,
+			BaseEndpoint: aws.String(endpoint),
+			Credentials:  creds.provider(),
+			UsePathStyle: true,
+		}),
+	}, nil
+}
+
+// Put uploads data as the object key.
+func (s *S3PayloadStore) Put(ctx context.Context, key string, data []byte) error {
+	if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+		Body:   bytes.NewReader(data),
+	}); err != nil {
+		return fmt.Errorf("s3 payload store: put %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get downloads the object key.
+func (s *S3PayloadStore) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+	})
+	if err != nil {
+		var missing *types.NoSuchKey
+		if errors.As(err, &missing) {
+			return nil, domain.ErrPayloadNotFound
+		}
+		return nil, fmt.Errorf("s3 payload store: get %s: %w", key, err)
+	}
+	defer out.Body.Close()
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("s3 payload store: get %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// URL returns the URL of the object key.
+func (s *S3PayloadStore) URL(key string) string {
+	return s.endpoint + "/" + s.bucket + "/" + s.prefix + neturl.PathEscape(key)
+}
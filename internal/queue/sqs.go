@@ -0,0 +1,234 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// DefaultSQSVisibility is how long a received task without a Timeout stays
+// hidden from other consumers before SQS hands it out again.
+const DefaultSQSVisibility = 30 * time.Minute
+
+// SQS limits: a receive waits at most 20 seconds and a message stays hidden
+// at most 12 hours.
+const (
+	sqsMaxWait       = 20 * time.Second
+	sqsMaxVisibility = 12 * time.Hour
+)
+
+// sqsVisibilitySlack is added to a task's Timeout when hiding it, leaving
+// the worker time to record the outcome and Ack before SQS redelivers.
+const sqsVisibilitySlack = 30 * time.Second
+
+// SQSQueue is a domain.AckQueue backed by an Amazon SQS queue, spoken to
+// through the AWS SDK's SQS client. Tasks are stored as JSON message
+// bodies. A received task stays hidden for its Timeout plus a little slack,
+// or for the queue's visibility when it has none; SQS hands it out again if
+// the worker has not acked it by then, e.g. because it died.
+//
+// The queue must already exist. SQS orders tasks only roughly and may
+// deliver one twice, so handlers should be idempotent.
+type SQSQueue struct {
+	url        string // the queue URL
+	client     *sqs.Client
+	http       *http.Client
+	visibility time.Duration
+	poll       domain.PollConfig
+
+	mu       sync.Mutex
+	receipts map[*domain.Task]string // receipt handles of dequeued tasks not yet acked
+}
+
+// SQSOption is a functional option for configuring an SQSQueue.
+type SQSOption func(*SQSQueue)
+
+// WithSQSVisibility sets how long a received task without a Timeout stays
+// hidden. The default is DefaultSQSVisibility.
+func WithSQSVisibility(d time.Duration) SQSOption {
+	return func(q *SQSQueue) { q.visibility = d }
+}
+
+// WithSQSPolling tunes how Dequeue polls SQS: each receive waits c.LongPoll,
+// in whole seconds up to 20 (the default), and a receive that finds nothing
+// is followed by c.IdleDelay before the next.
+func WithSQSPolling(c domain.PollConfig) SQSOption {
+	return func(q *SQSQueue) { q.poll = c }
+}
+
+// WithSQSHTTPClient sets the HTTP client requests are sent with. The
+// default is the AWS SDK's.
+func WithSQSHTTPClient(c *http.Client) SQSOption {
+	return func(q *SQSQueue) { q.http = c }
+}
+
+// NewSQSQueue creates an SQSQueue on the queue at queueURL, such as
+// https://sqs.us-east-1.amazonaws.com/123456789012/tasks, in region.
+func NewSQSQueue(queueURL, region string, creds AWSCredentials, opts ...SQSOption) (*SQSQueue, error) {
+	u, err := neturl.Parse(queueURL)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return nil, fmt.Errorf("sqs queue: invalid queue URL %q", queueURL)
+	}
+	if region == "" {
+		return nil, errors.New("sqs queue: region must be set")
+	}
+	q := &SQSQueue{
+		url:        queueURL,
+		visibility: DefaultSQSVisibility,
+		receipts:   make(map[*domain.Task]string),
+	}
+	for _, o := range opts {
+		o(q)
+	}
+	o := sqs.Options{
+		Region:       region,
+		BaseEndpoint: aws.String(u.Scheme + "://" + u.Host),
+		Credentials:  creds.provider(),
+	}
+	if q.http != nil {
+		o.HTTPClient = q.http
+	}
+	q.client = sqs.New(o)
+	return q, nil
+}
+
+// Enqueue sends task to the queue.
+func (q *SQSQueue) Enqueue(ctx context.Context, task *domain.Task) error {
+	b, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("sqs queue: encode task %s: %w", task.ID, err)
+	}
+	if _, err := q.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.url),
+		MessageBody: aws.String(string(b)),
+	}); err != nil {
+		return fmt.Errorf("sqs queue: %w", err)
+	}
+	return nil
+}
+
+// Dequeue receives the next task, hiding it for its Timeout plus slack. It
+// blocks until a task is available or ctx is cancelled, in which case
+// domain.ErrQueueEmpty is returned. Ack the task once done with it.
+func (q *SQSQueue) Dequeue(ctx context.Context) (*domain.Task, error) {
+	wait := sqsMaxWait
+	if q.poll.LongPoll >= time.Second && q.poll.LongPoll < sqsMaxWait {
+		wait = q.poll.LongPoll
+	}
+	for empty := 0; ; {
+		if ctx.Err() != nil {
+			return nil, domain.ErrQueueEmpty
+		}
+		out, err := q.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(q.url),
+			MaxNumberOfMessages: 1,
+			WaitTimeSeconds:     int32(wait / time.Second),
+			VisibilityTimeout:   visibilitySeconds(q.visibility),
+		})
+		if ctx.Err() != nil {
+			return nil, domain.ErrQueueEmpty
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sqs queue: %w", err)
+		}
+		if len(out.Messages) == 0 {
+			empty++
+			if d := q.poll.IdleDelay(empty); d > 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(d):
+				}
+			}
+			continue
+		}
+		m := out.Messages[0]
+		var task domain.Task
+		if err := json.Unmarshal([]byte(aws.ToString(m.Body)), &task); err != nil {
+			return nil, fmt.Errorf("sqs queue: decode task: %w", err)
+		}
+		if task.Timeout > 0 {
+			if _, err := q.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          aws.String(q.url),
+				ReceiptHandle:     m.ReceiptHandle,
+				VisibilityTimeout: visibilitySeconds(task.Timeout + sqsVisibilitySlack),
+			}); err != nil {
+				return nil, fmt.Errorf("sqs queue: %w", err)
+			}
+		}
+		q.mu.Lock()
+		q.receipts[&task] = aws.ToString(m.ReceiptHandle)
+		q.mu.Unlock()
+		return &task, nil
+	}
+}
+
+// Ack deletes task, as returned by Dequeue, from the queue. Tasks this
+// queue did not hand out, or already acked, are ignored.
+func (q *SQSQueue) Ack(ctx context.Context, task *domain.Task) error {
+	q.mu.Lock()
+	receipt, ok := q.receipts[task]
+	delete(q.receipts, task)
+	q.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	if _, err := q.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(q.url),
+		ReceiptHandle: aws.String(receipt),
+	}); err != nil {
+		return fmt.Errorf("sqs queue: %w", err)
+	}
+	return nil
+}
+
+// Len returns the approximate number of tasks waiting to be received.
+func (q *SQSQueue) Len(ctx context.Context) (int, error) {
+	out, err := q.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(q.url),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameApproximateNumberOfMessages},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("sqs queue: %w", err)
+	}
+	n, err := strconv.Atoi(out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)])
+	if err != nil {
+		return 0, fmt.Errorf("sqs queue: queue length: %w", err)
+	}
+	return n, nil
+}
+
+// URL returns the queue URL.
+func (q *SQSQueue) URL() string {
+	return q.url
+}
+
+// Visibility returns how long a received task without a Timeout stays
+// hidden.
+func (q *SQSQueue) Visibility() time.Duration {
+	return q.visibility
+}
+
+// visibilitySeconds converts d to the whole seconds SQS takes for a visibility
+// timeout, within its limits.
+func visibilitySeconds(d time.Duration) int32 {
+	if d > sqsMaxVisibility {
+		d = sqsMaxVisibility
+	}
+	if d < 0 {
+		d = 0
+	}
+	return int32(d / time.Second)
+}
@@ -0,0 +1,170 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/queue"
+)
+
+// fakeSQS serves the parts of the SQS JSON API SQSQueue uses, keeping its
+// messages in memory. Visibility timeouts are recorded, not enforced.
+type fakeSQS struct {
+	mu         sync.Mutex
+	next       int
+	messages   map[string]string // receipt handle → body
+	visible    []string          // receipt handles of visible messages, oldest first
+	visibility map[string]int    // receipt handle → latest visibility timeout
+	unsigned   int
+}
+
+func newFakeSQS() *fakeSQS {
+	return &fakeSQS{messages: make(map[string]string), visibility: make(map[string]int)}
+}
+
+func (f *fakeSQS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		f.unsigned++
+	}
+	var in struct {
+		MessageBody       string
+		ReceiptHandle     string
+		VisibilityTimeout int
+	}
+	_ = json.NewDecoder(r.Body).Decode(&in)
+	var out any = struct{}{}
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AmazonSQS.") {
+	case "SendMessage":
+		f.next++
+		receipt := "r" + strconv.Itoa(f.next)
+		f.messages[receipt] = in.MessageBody
+		f.visible = append(f.visible, receipt)
+	case "ReceiveMessage":
+		type message struct{ ReceiptHandle, Body string }
+		msgs := []message{}
+		if len(f.visible) > 0 {
+			receipt := f.visible[0]
+			f.visible = f.visible[1:]
+			f.visibility[receipt] = in.VisibilityTimeout
+			msgs = append(msgs, message{receipt, f.messages[receipt]})
+		}
+		out = map[string]any{"Messages": msgs}
+	case "ChangeMessageVisibility":
+		f.visibility[in.ReceiptHandle] = in.VisibilityTimeout
+	case "DeleteMessage":
+		delete(f.messages, in.ReceiptHandle)
+	case "GetQueueAttributes":
+		out = map[string]any{"Attributes": map[string]string{"ApproximateNumberOfMessages": strconv.Itoa(len(f.visible))}}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		out = map[string]string{"__type": "com.amazonaws.sqs#InvalidAction", "message": "unknown action"}
+	}
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func TestSQSQueue_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeSQS()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	q, err := queue.NewSQSQueue(srv.URL+"/000000000000/tasks", "us-east-1",
+		queue.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		queue.WithSQSVisibility(10*time.Minute))
+	if err != nil {
+		t.Fatalf("NewSQSQueue: %v", err)
+	}
+
+	plain := &domain.Task{ID: "plain", Name: "a", Priority: domain.PriorityNormal}
+	timed := &domain.Task{ID: "timed", Name: "b", Priority: domain.PriorityNormal, Timeout: 2 * time.Minute}
+	for _, task := range []*domain.Task{plain, timed} {
+		if err := q.Enqueue(ctx, task); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	if n, err := q.Len(ctx); err != nil || n != 2 {
+		t.Fatalf("Len: got %d, %v; want 2", n, err)
+	}
+
+	first, err := q.Dequeue(ctx)
+	if err != nil || first.ID != "plain" {
+		t.Fatalf("first Dequeue: got %+v, %v", first, err)
+	}
+	second, _ := q.Dequeue(ctx)
+	if second.ID != "timed" || second.Timeout != 2*time.Minute {
+		t.Fatalf("second Dequeue: got %+v", second)
+	}
+	// The queue's visibility for the plain task; the timed task's Timeout
+	// plus slack.
+	if got := fake.visibility["r1"]; got != 600 {
+		t.Errorf("plain task visibility: got %ds, want 600s", got)
+	}
+	if got := fake.visibility["r2"]; got != 150 {
+		t.Errorf("timed task visibility: got %ds, want 150s", got)
+	}
+
+	for _, task := range []*domain.Task{first, second} {
+		if err := q.Ack(ctx, task); err != nil {
+			t.Fatalf("Ack: %v", err)
+		}
+	}
+	if len(fake.messages) != 0 {
+		t.Errorf("messages left after Ack: %d", len(fake.messages))
+	}
+	if fake.unsigned != 0 {
+		t.Errorf("%d requests were not signed", fake.unsigned)
+	}
+}
+
+func TestSQSQueue_DequeueStopsOnCancel(t *testing.T) {
+	srv := httptest.NewServer(newFakeSQS())
+	defer srv.Close()
+	q, _ := queue.NewSQSQueue(srv.URL+"/000000000000/tasks", "us-east-1", queue.AWSCredentials{},
+		queue.WithSQSPolling(domain.PollConfig{Interval: time.Hour}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := q.Dequeue(ctx); err != domain.ErrQueueEmpty {
+		t.Errorf("Dequeue on an empty queue: got %v, want ErrQueueEmpty", err)
+	}
+}
+
+func TestOpen_SQS(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	q, err := queue.Open("sqs://sqs.eu-west-1.amazonaws.com/123456789012/tasks?visibility=1h")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	sq := q.(*queue.SQSQueue)
+	if sq.URL() != "https://sqs.eu-west-1.amazonaws.com/123456789012/tasks" || sq.Visibility() != time.Hour {
+		t.Errorf("got %s with visibility %s", sq.URL(), sq.Visibility())
+	}
+
+	groups, err := queue.OpenGroups("sqs+http://localhost:9324/000000000000/tasks?region=elasticmq")
+	if err != nil {
+		t.Fatalf("OpenGroups: %v", err)
+	}
+	etl, _ := groups.Queue("etl.daily")
+	if got := etl.(*queue.SQSQueue).URL(); got != "http://localhost:9324/000000000000/tasks-group-etl_daily" {
+		t.Errorf("group queue URL: got %s", got)
+	}
+
+	for _, url := range []string{
+		"sqs://localhost:9324/000000000000/tasks",
+		"sqs://sqs.eu-west-1.amazonaws.com/",
+		"sqs://sqs.eu-west-1.amazonaws.com/123456789012/tasks?visibility=never",
+	} {
+		if _, err := queue.Open(url); err == nil {
+			t.Errorf("Open(%q): expected error", url)
+		}
+	}
+}
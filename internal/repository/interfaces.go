@@ -5,6 +5,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -42,18 +44,68 @@ type TaskRepository interface {
 	ListByWorkflowID(ctx context.Context, workflowID uuid.UUID) ([]*domain.Task, error)
 }
 
+// TaskDependencyRepository defines operations on the edges between tasks.
+type TaskDependencyRepository interface {
+	// Create persists a new dependency. The caller is responsible for setting d.ID.
+	Create(ctx context.Context, d *domain.TaskDependency) error
+	// ListByWorkflowID returns every dependency between tasks of the given workflow.
+	ListByWorkflowID(ctx context.Context, workflowID uuid.UUID) ([]*domain.TaskDependency, error)
+}
+
 // WorkflowRunRepository defines CRUD and query operations for WorkflowRun entities.
 type WorkflowRunRepository interface {
-	// Create persists a new workflow run. The caller is responsible for setting wr.ID.
+	// Create persists a new workflow run. The caller is responsible for
+	// setting wr.ID. A run with an IdempotencyKey another run of its
+	// workflow already has is not created: Create returns ErrConflict.
 	Create(ctx context.Context, wr *domain.WorkflowRun) error
 	// GetByID returns the run with the given ID, or ErrNotFound.
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.WorkflowRun, error)
+	// FindByIdempotencyKey returns workflowID's run with the given
+	// IdempotencyKey, or ErrNotFound.
+	FindByIdempotencyKey(ctx context.Context, workflowID uuid.UUID, key string) (*domain.WorkflowRun, error)
 	// UpdateStatus atomically updates the status and optional finished timestamp.
 	UpdateStatus(ctx context.Context, id uuid.UUID, status domain.Status, finishedAt *time.Time) error
+	// UpdateProgress records the run's progress, or returns ErrNotFound.
+	UpdateProgress(ctx context.Context, id uuid.UUID, p domain.RunProgress) error
 	// ListByWorkflowID returns all runs for the given workflow, newest first.
 	ListByWorkflowID(ctx context.Context, workflowID uuid.UUID) ([]*domain.WorkflowRun, error)
 	// ListByStatus returns all runs with the given status, newest first.
 	ListByStatus(ctx context.Context, status domain.Status) ([]*domain.WorkflowRun, error)
+	// FindByWorkflowID returns one page of the given workflow's runs that
+	// match filter, newest first.
+	FindByWorkflowID(ctx context.Context, workflowID uuid.UUID, filter WorkflowRunFilter) ([]*domain.WorkflowRun, error)
+}
+
+// WorkflowRunFilter narrows WorkflowRunRepository.FindByWorkflowID. Zero
+// fields do not filter.
+type WorkflowRunFilter struct {
+	Status domain.Status
+	// StartedFrom and StartedTo bound StartedAt to [StartedFrom, StartedTo).
+	StartedFrom time.Time
+	StartedTo   time.Time
+	// Labels matches runs carrying every one of these labels.
+	Labels map[string]string
+	// Offset skips that many matching runs; Limit caps the page size.
+	Offset int
+	Limit  int
+}
+
+// Match reports whether wr satisfies the Status, StartedAt and Labels
+// conditions of f.
+func (f WorkflowRunFilter) Match(wr *domain.WorkflowRun) bool {
+	if f.Status != "" && wr.Status != f.Status {
+		return false
+	}
+	if !wr.HasLabels(f.Labels) {
+		return false
+	}
+	if !f.StartedFrom.IsZero() && wr.StartedAt.Before(f.StartedFrom) {
+		return false
+	}
+	if !f.StartedTo.IsZero() && !wr.StartedAt.Before(f.StartedTo) {
+		return false
+	}
+	return true
 }
 
 // TaskRunRepository defines CRUD and query operations for TaskRun entities.
@@ -70,6 +122,14 @@ type TaskRunRepository interface {
 	ListByTaskID(ctx context.Context, taskID uuid.UUID) ([]*domain.TaskRun, error)
 	// ListByStatus returns all task runs with the given status.
 	ListByStatus(ctx context.Context, status domain.Status) ([]*domain.TaskRun, error)
+	// AppendLogs appends chunk to the task run's Logs, or returns ErrNotFound.
+	AppendLogs(ctx context.Context, id uuid.UUID, chunk string) error
+	// UpdateAssignment records the worker the task run was dispatched to and
+	// when, or returns ErrNotFound.
+	UpdateAssignment(ctx context.Context, id uuid.UUID, workerID string, dispatchedAt time.Time) error
+	// UpdateQueueTask records the queue task the task run follows, or
+	// returns ErrNotFound.
+	UpdateQueueTask(ctx context.Context, id uuid.UUID, queueTaskID string) error
 }
 
 // WorkerRepository defines CRUD and query operations for Worker entities.
@@ -88,9 +148,158 @@ type WorkerRepository interface {
 	UpdateHeartbeat(ctx context.Context, id uuid.UUID, at time.Time) error
 }
 
+// ApprovalRepository stores the audit trail of decisions taken on approval
+// task runs. Records are append-only.
+type ApprovalRepository interface {
+	// Create persists a new approval record. The caller is responsible for setting a.ID.
+	Create(ctx context.Context, a *domain.Approval) error
+	// ListByTaskRunID returns all decisions recorded for the given task run,
+	// oldest first.
+	ListByTaskRunID(ctx context.Context, taskRunID uuid.UUID) ([]*domain.Approval, error)
+	// Decide records a and, in the same transaction, moves its task run
+	// from StatusAwaitingApproval to status, finished at a.CreatedAt. If the
+	// task run is no longer awaiting approval nothing is recorded and
+	// ErrConflict is returned.
+	Decide(ctx context.Context, a *domain.Approval, status domain.Status) error
+}
+
+// BackfillRepository defines CRUD and query operations for Backfill entities.
+type BackfillRepository interface {
+	// Create persists a new backfill. The caller is responsible for setting b.ID.
+	Create(ctx context.Context, b *domain.Backfill) error
+	// GetByID returns the backfill with the given ID, or ErrNotFound.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Backfill, error)
+	// UpdateProgress writes b's Created, Total and Cursor, provided the
+	// stored backfill still has status b.Status; otherwise nothing is
+	// written and ErrConflict is returned. The status itself is not written.
+	UpdateProgress(ctx context.Context, b *domain.Backfill) error
+	// Transition moves backfill id from status from to status to, finished
+	// at finishedAt. If it is no longer in status from nothing is written
+	// and ErrConflict is returned.
+	Transition(ctx context.Context, id uuid.UUID, from, to domain.BackfillStatus, finishedAt time.Time) error
+	// ListByStatus returns all backfills with the given status, oldest first.
+	ListByStatus(ctx context.Context, status domain.BackfillStatus) ([]*domain.Backfill, error)
+}
+
+// CalendarRepository persists exclusion calendars.
+type CalendarRepository interface {
+	Create(ctx context.Context, c *domain.Calendar) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Calendar, error)
+	// GetByName returns the oldest calendar with the given name, or
+	// ErrNotFound.
+	GetByName(ctx context.Context, name string) (*domain.Calendar, error)
+}
+
+// LineageRepository persists the datasets read and written by task runs.
+type LineageRepository interface {
+	Create(ctx context.Context, e *domain.LineageEdge) error
+	// ListByDataset returns the edges of the given direction touching dataset
+	// that were recorded at or after since, oldest first.
+	ListByDataset(ctx context.Context, dataset string, dir domain.LineageDirection, since time.Time) ([]*domain.LineageEdge, error)
+	ListByTaskRunID(ctx context.Context, taskRunID uuid.UUID) ([]*domain.LineageEdge, error)
+}
+
+// StatsRepository computes aggregate duration statistics over finished runs.
+// Only runs with a finished_at timestamp that started at or after since are
+// included.
+type StatsRepository interface {
+	// RunDurationPercentiles returns the p50/p95/p99 durations of the
+	// workflow's finished runs.
+	RunDurationPercentiles(ctx context.Context, workflowID uuid.UUID, since time.Time) (*domain.DurationPercentiles, error)
+	// TaskDurationAverages returns the average duration of each task of the
+	// workflow, ordered by task name. Tasks without finished runs are omitted.
+	TaskDurationAverages(ctx context.Context, workflowID uuid.UUID, since time.Time) ([]domain.TaskDurationStat, error)
+	// RunDurationTrend groups the workflow's finished runs into consecutive
+	// buckets of the given width, aligned to the Unix epoch, oldest first.
+	// Buckets without runs are omitted.
+	RunDurationTrend(ctx context.Context, workflowID uuid.UUID, since time.Time, bucket time.Duration) ([]domain.DurationBucket, error)
+	// DailyUsage totals the execution time of the finished task runs
+	// dispatched to a worker in [from, to), by UTC day, workflow and the
+	// task's Queue, ordered by day.
+	DailyUsage(ctx context.Context, from, to time.Time) ([]domain.TaskUsage, error)
+}
+
+// RetentionRepository deletes old run history.
+type RetentionRepository interface {
+	// PurgeRuns deletes the workflow runs matching filter together with
+	// their task runs. With dryRun set nothing is deleted and the counts
+	// report what would have been.
+	PurgeRuns(ctx context.Context, filter RunPurgeFilter, dryRun bool) (domain.PurgeCounts, error)
+}
+
+// RunPurgeFilter selects the workflow runs RetentionRepository.PurgeRuns
+// deletes: those whose status is one of Statuses that satisfy the other
+// non-zero conditions.
+type RunPurgeFilter struct {
+	Statuses []domain.Status
+	// WorkflowID limits the purge to the runs of one workflow.
+	WorkflowID uuid.UUID
+	// Before matches runs started before it.
+	Before time.Time
+	// KeepNewest spares the newest KeepNewest matching runs of each workflow.
+	KeepNewest int
+}
+
+// Match reports whether wr satisfies the Statuses, WorkflowID and Before
+// conditions of f. KeepNewest depends on the other runs and is applied by
+// the repository.
+func (f RunPurgeFilter) Match(wr *domain.WorkflowRun) bool {
+	if f.WorkflowID != uuid.Nil && wr.WorkflowID != f.WorkflowID {
+		return false
+	}
+	if !f.Before.IsZero() && !wr.StartedAt.Before(f.Before) {
+		return false
+	}
+	return slices.Contains(f.Statuses, wr.Status)
+}
+
+// EventRepository keeps the history of published events.
+type EventRepository interface {
+	// Append stores e, setting its Seq.
+	Append(ctx context.Context, e *domain.EventRecord) error
+	// List returns the events matching filter, oldest first.
+	List(ctx context.Context, filter EventFilter) ([]*domain.EventRecord, error)
+	// DeleteBefore deletes the events recorded before t and returns how
+	// many there were.
+	DeleteBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+// EventFilter selects the events EventRepository.List returns: those after
+// AfterSeq that satisfy the other non-zero conditions, at most Limit of
+// them.
+type EventFilter struct {
+	AfterSeq int64
+	// Types limits the events to those of one of the types.
+	Types []string
+	// Since and Until bound the events' At, Since inclusive and Until
+	// exclusive.
+	Since time.Time
+	Until time.Time
+	Limit int
+}
+
+// Match reports whether e satisfies the conditions of f other than Limit.
+func (f EventFilter) Match(e *domain.EventRecord) bool {
+	if e.Seq <= f.AfterSeq {
+		return false
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	if !f.Since.IsZero() && e.At.Before(f.Since) {
+		return false
+	}
+	return f.Until.IsZero() || e.At.Before(f.Until)
+}
+
 // ErrNotFound is returned when a requested record does not exist.
 var ErrNotFound = errNotFound("record not found")
 
+// ErrConflict is returned when a conditional update finds the record no
+// longer in the state it was expected in, e.g. because another request
+// changed it first.
+var ErrConflict = errors.New("record changed concurrently")
+
 type errNotFound string
 
 func (e errNotFound) Error() string { return string(e) }
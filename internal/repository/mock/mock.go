@@ -5,6 +5,7 @@ package mock
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -156,6 +157,45 @@ func (r *TaskRepo) ListByWorkflowID(_ context.Context, workflowID uuid.UUID) ([]
 	return out, nil
 }
 
+// ── TaskDependencyRepository ──────────────────────────────────────────────────
+
+// TaskDependencyRepo is an in-memory TaskDependencyRepository for testing.
+// It resolves a dependency's workflow through the TaskRepo it was built with.
+type TaskDependencyRepo struct {
+	mu    sync.RWMutex
+	tasks *TaskRepo
+	store []*domain.TaskDependency
+}
+
+// NewTaskDependencyRepo returns an empty in-memory TaskDependencyRepo whose
+// ListByWorkflowID looks tasks up in tasks.
+func NewTaskDependencyRepo(tasks *TaskRepo) *TaskDependencyRepo {
+	return &TaskDependencyRepo{tasks: tasks}
+}
+
+func (r *TaskDependencyRepo) Create(_ context.Context, d *domain.TaskDependency) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *d
+	r.store = append(r.store, &cp)
+	return nil
+}
+
+func (r *TaskDependencyRepo) ListByWorkflowID(ctx context.Context, workflowID uuid.UUID) ([]*domain.TaskDependency, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.TaskDependency
+	for _, d := range r.store {
+		t, err := r.tasks.GetByID(ctx, d.TaskID)
+		if err != nil || t.WorkflowID != workflowID {
+			continue
+		}
+		cp := *d
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
 // ── WorkflowRunRepository ─────────────────────────────────────────────────────
 
 // WorkflowRunRepo is an in-memory WorkflowRunRepository for testing.
@@ -172,6 +212,9 @@ func NewWorkflowRunRepo() *WorkflowRunRepo {
 func (r *WorkflowRunRepo) Create(_ context.Context, wr *domain.WorkflowRun) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if wr.IdempotencyKey != "" && r.findByKeyLocked(wr.WorkflowID, wr.IdempotencyKey) != nil {
+		return repository.ErrConflict
+	}
 	cp := *wr
 	r.store[wr.ID] = &cp
 	return nil
@@ -188,6 +231,26 @@ func (r *WorkflowRunRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.Work
 	return &cp, nil
 }
 
+func (r *WorkflowRunRepo) FindByIdempotencyKey(_ context.Context, workflowID uuid.UUID, key string) (*domain.WorkflowRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	wr := r.findByKeyLocked(workflowID, key)
+	if wr == nil {
+		return nil, repository.ErrNotFound
+	}
+	cp := *wr
+	return &cp, nil
+}
+
+func (r *WorkflowRunRepo) findByKeyLocked(workflowID uuid.UUID, key string) *domain.WorkflowRun {
+	for _, wr := range r.store {
+		if wr.WorkflowID == workflowID && key != "" && wr.IdempotencyKey == key {
+			return wr
+		}
+	}
+	return nil
+}
+
 func (r *WorkflowRunRepo) UpdateStatus(_ context.Context, id uuid.UUID, status domain.Status, finishedAt *time.Time) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -200,6 +263,17 @@ func (r *WorkflowRunRepo) UpdateStatus(_ context.Context, id uuid.UUID, status d
 	return nil
 }
 
+func (r *WorkflowRunRepo) UpdateProgress(_ context.Context, id uuid.UUID, p domain.RunProgress) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	wr, ok := r.store[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	wr.Progress = p
+	return nil
+}
+
 func (r *WorkflowRunRepo) ListByWorkflowID(_ context.Context, workflowID uuid.UUID) ([]*domain.WorkflowRun, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -213,6 +287,27 @@ func (r *WorkflowRunRepo) ListByWorkflowID(_ context.Context, workflowID uuid.UU
 	return out, nil
 }
 
+func (r *WorkflowRunRepo) FindByWorkflowID(_ context.Context, workflowID uuid.UUID, f repository.WorkflowRunFilter) ([]*domain.WorkflowRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.WorkflowRun
+	for _, wr := range r.store {
+		if wr.WorkflowID == workflowID && f.Match(wr) {
+			cp := *wr
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	if f.Offset >= len(out) {
+		return nil, nil
+	}
+	out = out[max(f.Offset, 0):]
+	if f.Limit > 0 && f.Limit < len(out) {
+		out = out[:f.Limit]
+	}
+	return out, nil
+}
+
 func (r *WorkflowRunRepo) ListByStatus(_ context.Context, status domain.Status) ([]*domain.WorkflowRun, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -309,6 +404,40 @@ func (r *TaskRunRepo) ListByStatus(_ context.Context, status domain.Status) ([]*
 	return out, nil
 }
 
+func (r *TaskRunRepo) AppendLogs(_ context.Context, id uuid.UUID, chunk string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tr, ok := r.store[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	tr.Logs += chunk
+	return nil
+}
+
+func (r *TaskRunRepo) UpdateAssignment(_ context.Context, id uuid.UUID, workerID string, dispatchedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tr, ok := r.store[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	tr.AssignedWorkerID = workerID
+	tr.DispatchedAt = &dispatchedAt
+	return nil
+}
+
+func (r *TaskRunRepo) UpdateQueueTask(_ context.Context, id uuid.UUID, queueTaskID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tr, ok := r.store[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	tr.QueueTaskID = queueTaskID
+	return nil
+}
+
 // ── WorkerRepository ──────────────────────────────────────────────────────────
 
 // WorkerRepo is an in-memory WorkerRepository for testing.
@@ -385,3 +514,507 @@ func (r *WorkerRepo) UpdateHeartbeat(_ context.Context, id uuid.UUID, at time.Ti
 	w.LastHeartbeat = at
 	return nil
 }
+
+// ── ApprovalRepository ────────────────────────────────────────────────────────
+
+// ApprovalRepo is an in-memory ApprovalRepository for testing. Decide
+// settles task runs in taskRuns.
+type ApprovalRepo struct {
+	mu       sync.RWMutex
+	store    []*domain.Approval
+	taskRuns *TaskRunRepo
+}
+
+// NewApprovalRepo returns an empty in-memory ApprovalRepo deciding the
+// task runs of taskRuns.
+func NewApprovalRepo(taskRuns *TaskRunRepo) *ApprovalRepo {
+	return &ApprovalRepo{taskRuns: taskRuns}
+}
+
+func (r *ApprovalRepo) Decide(_ context.Context, a *domain.Approval, status domain.Status) error {
+	r.taskRuns.mu.Lock()
+	defer r.taskRuns.mu.Unlock()
+	tr, ok := r.taskRuns.store[a.TaskRunID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if tr.Status != domain.StatusAwaitingApproval {
+		return repository.ErrConflict
+	}
+	at := a.CreatedAt
+	tr.Status, tr.FinishedAt = status, &at
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *a
+	r.store = append(r.store, &cp)
+	return nil
+}
+
+func (r *ApprovalRepo) Create(_ context.Context, a *domain.Approval) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *a
+	r.store = append(r.store, &cp)
+	return nil
+}
+
+func (r *ApprovalRepo) ListByTaskRunID(_ context.Context, taskRunID uuid.UUID) ([]*domain.Approval, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.Approval
+	for _, a := range r.store {
+		if a.TaskRunID == taskRunID {
+			cp := *a
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+// ── BackfillRepository ────────────────────────────────────────────────────────
+
+// BackfillRepo is an in-memory BackfillRepository for testing.
+type BackfillRepo struct {
+	mu    sync.RWMutex
+	store map[uuid.UUID]*domain.Backfill
+}
+
+// NewBackfillRepo returns an empty in-memory BackfillRepo.
+func NewBackfillRepo() *BackfillRepo {
+	return &BackfillRepo{store: make(map[uuid.UUID]*domain.Backfill)}
+}
+
+func (r *BackfillRepo) Create(_ context.Context, b *domain.Backfill) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *b
+	r.store[b.ID] = &cp
+	return nil
+}
+
+func (r *BackfillRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.Backfill, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.store[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *b
+	return &cp, nil
+}
+
+func (r *BackfillRepo) UpdateProgress(_ context.Context, b *domain.Backfill) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.store[b.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if stored.Status != b.Status {
+		return repository.ErrConflict
+	}
+	stored.Created = b.Created
+	stored.Total = b.Total
+	stored.Cursor = b.Cursor
+	return nil
+}
+
+func (r *BackfillRepo) Transition(_ context.Context, id uuid.UUID, from, to domain.BackfillStatus, finishedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.store[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if stored.Status != from {
+		return repository.ErrConflict
+	}
+	stored.Status = to
+	stored.FinishedAt = &finishedAt
+	return nil
+}
+
+func (r *BackfillRepo) ListByStatus(_ context.Context, status domain.BackfillStatus) ([]*domain.Backfill, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.Backfill
+	for _, b := range r.store {
+		if b.Status == status {
+			cp := *b
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+// ── CalendarRepository ────────────────────────────────────────────────────────
+
+// CalendarRepo is an in-memory CalendarRepository for testing.
+type CalendarRepo struct {
+	mu    sync.RWMutex
+	store map[uuid.UUID]*domain.Calendar
+}
+
+// NewCalendarRepo returns an empty in-memory CalendarRepo.
+func NewCalendarRepo() *CalendarRepo {
+	return &CalendarRepo{store: make(map[uuid.UUID]*domain.Calendar)}
+}
+
+func (r *CalendarRepo) Create(_ context.Context, c *domain.Calendar) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *c
+	r.store[c.ID] = &cp
+	return nil
+}
+
+func (r *CalendarRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.Calendar, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.store[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *c
+	return &cp, nil
+}
+
+func (r *CalendarRepo) GetByName(_ context.Context, name string) (*domain.Calendar, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var found *domain.Calendar
+	for _, c := range r.store {
+		if c.Name == name && (found == nil || c.CreatedAt.Before(found.CreatedAt)) {
+			found = c
+		}
+	}
+	if found == nil {
+		return nil, repository.ErrNotFound
+	}
+	cp := *found
+	return &cp, nil
+}
+
+// ── LineageRepository ─────────────────────────────────────────────────────────
+
+// LineageRepo is an in-memory LineageRepository for testing.
+type LineageRepo struct {
+	mu    sync.RWMutex
+	store []*domain.LineageEdge
+}
+
+// NewLineageRepo returns an empty in-memory LineageRepo.
+func NewLineageRepo() *LineageRepo {
+	return &LineageRepo{}
+}
+
+func (r *LineageRepo) Create(_ context.Context, e *domain.LineageEdge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *e
+	r.store = append(r.store, &cp)
+	return nil
+}
+
+func (r *LineageRepo) ListByDataset(_ context.Context, dataset string, dir domain.LineageDirection, since time.Time) ([]*domain.LineageEdge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.LineageEdge
+	for _, e := range r.store {
+		if e.Dataset == dataset && e.Direction == dir && !e.CreatedAt.Before(since) {
+			cp := *e
+			out = append(out, &cp)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *LineageRepo) ListByTaskRunID(_ context.Context, taskRunID uuid.UUID) ([]*domain.LineageEdge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.LineageEdge
+	for _, e := range r.store {
+		if e.TaskRunID == taskRunID {
+			cp := *e
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+// ── StatsRepository ───────────────────────────────────────────────────────────
+
+// StatsRepo is an in-memory StatsRepository for testing. It computes its
+// aggregates from the supplied run and task repositories on every call.
+type StatsRepo struct {
+	runs     *WorkflowRunRepo
+	taskRuns *TaskRunRepo
+	tasks    *TaskRepo
+}
+
+// NewStatsRepo returns a StatsRepo reading from the given repositories.
+func NewStatsRepo(runs *WorkflowRunRepo, taskRuns *TaskRunRepo, tasks *TaskRepo) *StatsRepo {
+	return &StatsRepo{runs: runs, taskRuns: taskRuns, tasks: tasks}
+}
+
+// finishedRuns returns the finished runs of workflowID started at or after since.
+func (r *StatsRepo) finishedRuns(workflowID uuid.UUID, since time.Time) []domain.WorkflowRun {
+	r.runs.mu.RLock()
+	defer r.runs.mu.RUnlock()
+	var out []domain.WorkflowRun
+	for _, wr := range r.runs.store {
+		if wr.WorkflowID == workflowID && wr.FinishedAt != nil && !wr.StartedAt.Before(since) {
+			out = append(out, *wr)
+		}
+	}
+	return out
+}
+
+func (r *StatsRepo) RunDurationPercentiles(_ context.Context, workflowID uuid.UUID, since time.Time) (*domain.DurationPercentiles, error) {
+	runs := r.finishedRuns(workflowID, since)
+	durations := make([]float64, len(runs))
+	for i, wr := range runs {
+		durations[i] = wr.FinishedAt.Sub(wr.StartedAt).Seconds()
+	}
+	p := domain.Percentiles(durations)
+	return &p, nil
+}
+
+func (r *StatsRepo) TaskDurationAverages(_ context.Context, workflowID uuid.UUID, since time.Time) ([]domain.TaskDurationStat, error) {
+	inRange := make(map[uuid.UUID]bool)
+	r.runs.mu.RLock()
+	for _, wr := range r.runs.store {
+		if wr.WorkflowID == workflowID && !wr.StartedAt.Before(since) {
+			inRange[wr.ID] = true
+		}
+	}
+	r.runs.mu.RUnlock()
+
+	totals := make(map[uuid.UUID]*domain.TaskDurationStat)
+	r.taskRuns.mu.RLock()
+	for _, tr := range r.taskRuns.store {
+		if !inRange[tr.WorkflowRunID] || tr.FinishedAt == nil || tr.Status == domain.StatusSkipped {
+			continue
+		}
+		s, ok := totals[tr.TaskID]
+		if !ok {
+			s = &domain.TaskDurationStat{TaskID: tr.TaskID}
+			totals[tr.TaskID] = s
+		}
+		s.Runs++
+		s.AvgSeconds += tr.FinishedAt.Sub(tr.StartedAt).Seconds()
+	}
+	r.taskRuns.mu.RUnlock()
+
+	r.tasks.mu.RLock()
+	defer r.tasks.mu.RUnlock()
+	out := make([]domain.TaskDurationStat, 0, len(totals))
+	for id, s := range totals {
+		if t, ok := r.tasks.store[id]; ok {
+			s.TaskName = t.Name
+		}
+		s.AvgSeconds /= float64(s.Runs)
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TaskName < out[j].TaskName })
+	return out, nil
+}
+
+func (r *StatsRepo) RunDurationTrend(_ context.Context, workflowID uuid.UUID, since time.Time, bucket time.Duration) ([]domain.DurationBucket, error) {
+	type acc struct {
+		failed    int
+		durations []float64
+	}
+	buckets := make(map[int64]*acc)
+	width := int64(bucket / time.Second)
+	for _, wr := range r.finishedRuns(workflowID, since) {
+		key := wr.StartedAt.Unix() / width * width
+		b, ok := buckets[key]
+		if !ok {
+			b = &acc{}
+			buckets[key] = b
+		}
+		if wr.Status == domain.StatusFailed {
+			b.failed++
+		}
+		b.durations = append(b.durations, wr.FinishedAt.Sub(wr.StartedAt).Seconds())
+	}
+	out := make([]domain.DurationBucket, 0, len(buckets))
+	for key, b := range buckets {
+		var sum float64
+		for _, d := range b.durations {
+			sum += d
+		}
+		p := domain.Percentiles(b.durations)
+		out = append(out, domain.DurationBucket{
+			Start:      time.Unix(key, 0).UTC(),
+			Runs:       p.Runs,
+			Failed:     b.failed,
+			AvgSeconds: sum / float64(p.Runs),
+			P95Seconds: p.P95,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out, nil
+}
+
+func (r *StatsRepo) DailyUsage(_ context.Context, from, to time.Time) ([]domain.TaskUsage, error) {
+	type key struct {
+		date  string
+		wfID  uuid.UUID
+		class string
+	}
+	r.runs.mu.RLock()
+	workflowOf := make(map[uuid.UUID]uuid.UUID, len(r.runs.store))
+	for _, wr := range r.runs.store {
+		workflowOf[wr.ID] = wr.WorkflowID
+	}
+	r.runs.mu.RUnlock()
+	r.tasks.mu.RLock()
+	queueOf := make(map[uuid.UUID]string, len(r.tasks.store))
+	for _, t := range r.tasks.store {
+		queueOf[t.ID] = t.Queue
+	}
+	r.tasks.mu.RUnlock()
+
+	totals := make(map[key]*domain.TaskUsage)
+	r.taskRuns.mu.RLock()
+	for _, tr := range r.taskRuns.store {
+		if tr.DispatchedAt == nil || tr.FinishedAt == nil || tr.DispatchedAt.Before(from) || !tr.DispatchedAt.Before(to) {
+			continue
+		}
+		k := key{tr.DispatchedAt.UTC().Format(domain.CalendarDateLayout), workflowOf[tr.WorkflowRunID], queueOf[tr.TaskID]}
+		u, ok := totals[k]
+		if !ok {
+			u = &domain.TaskUsage{Date: k.date, WorkflowID: k.wfID, ResourceClass: k.class}
+			totals[k] = u
+		}
+		u.TaskRuns++
+		u.ExecutionSeconds += tr.FinishedAt.Sub(*tr.DispatchedAt).Seconds()
+	}
+	r.taskRuns.mu.RUnlock()
+
+	out := make([]domain.TaskUsage, 0, len(totals))
+	for _, u := range totals {
+		out = append(out, *u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.WorkflowID != b.WorkflowID {
+			return a.WorkflowID.String() < b.WorkflowID.String()
+		}
+		return a.ResourceClass < b.ResourceClass
+	})
+	return out, nil
+}
+
+// ── RetentionRepository ───────────────────────────────────────────────────────
+
+// RetentionRepo is an in-memory RetentionRepository for testing. It deletes
+// from the supplied run and task run repositories.
+type RetentionRepo struct {
+	runs     *WorkflowRunRepo
+	taskRuns *TaskRunRepo
+}
+
+// NewRetentionRepo returns a RetentionRepo deleting from the given repositories.
+func NewRetentionRepo(runs *WorkflowRunRepo, taskRuns *TaskRunRepo) *RetentionRepo {
+	return &RetentionRepo{runs: runs, taskRuns: taskRuns}
+}
+
+func (r *RetentionRepo) PurgeRuns(_ context.Context, f repository.RunPurgeFilter, dryRun bool) (domain.PurgeCounts, error) {
+	r.runs.mu.Lock()
+	defer r.runs.mu.Unlock()
+	r.taskRuns.mu.Lock()
+	defer r.taskRuns.mu.Unlock()
+
+	byWorkflow := make(map[uuid.UUID][]*domain.WorkflowRun)
+	for _, wr := range r.runs.store {
+		if f.Match(wr) {
+			byWorkflow[wr.WorkflowID] = append(byWorkflow[wr.WorkflowID], wr)
+		}
+	}
+	var counts domain.PurgeCounts
+	purged := make(map[uuid.UUID]bool)
+	for _, runs := range byWorkflow {
+		sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+		for _, wr := range runs[min(f.KeepNewest, len(runs)):] {
+			purged[wr.ID] = true
+			counts.WorkflowRuns++
+		}
+	}
+	for id, tr := range r.taskRuns.store {
+		if purged[tr.WorkflowRunID] {
+			counts.TaskRuns++
+			if !dryRun {
+				delete(r.taskRuns.store, id)
+			}
+		}
+	}
+	if !dryRun {
+		for id := range purged {
+			delete(r.runs.store, id)
+		}
+	}
+	return counts, nil
+}
+
+// ── EventRepository ───────────────────────────────────────────────────────────
+
+// EventRepo is an in-memory EventRepository for testing.
+type EventRepo struct {
+	mu    sync.RWMutex
+	store []*domain.EventRecord
+	seq   int64
+}
+
+// NewEventRepo returns an empty in-memory EventRepo.
+func NewEventRepo() *EventRepo {
+	return &EventRepo{}
+}
+
+func (r *EventRepo) Append(_ context.Context, e *domain.EventRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	e.Seq = r.seq
+	cp := *e
+	r.store = append(r.store, &cp)
+	return nil
+}
+
+func (r *EventRepo) List(_ context.Context, f repository.EventFilter) ([]*domain.EventRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.EventRecord
+	for _, e := range r.store {
+		if f.Limit > 0 && len(out) == f.Limit {
+			break
+		}
+		if f.Match(e) {
+			cp := *e
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (r *EventRepo) DeleteBefore(_ context.Context, t time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.store[:0]
+	for _, e := range r.store {
+		if !e.At.Before(t) {
+			kept = append(kept, e)
+		}
+	}
+	n := int64(len(r.store) - len(kept))
+	clear(r.store[len(kept):])
+	r.store = kept
+	return n, nil
+}
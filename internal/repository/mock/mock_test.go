@@ -225,6 +225,30 @@ func TestTaskRepo_Delete(t *testing.T) {
 	}
 }
 
+// ── TaskDependencyRepo ────────────────────────────────────────────────────────
+
+func TestTaskDependencyRepo_ListByWorkflowID(t *testing.T) {
+	tasks := mock.NewTaskRepo()
+	r := mock.NewTaskDependencyRepo(tasks)
+	wfID := uuid.New()
+	a, b := newTask(wfID), newTask(wfID)
+	other := newTask(uuid.New())
+	_ = tasks.Create(ctx, a)
+	_ = tasks.Create(ctx, b)
+	_ = tasks.Create(ctx, other)
+
+	_ = r.Create(ctx, &domain.TaskDependency{ID: uuid.New(), TaskID: b.ID, DependsOnTaskID: a.ID})
+	_ = r.Create(ctx, &domain.TaskDependency{ID: uuid.New(), TaskID: other.ID, DependsOnTaskID: other.ID})
+
+	list, err := r.ListByWorkflowID(ctx, wfID)
+	if err != nil {
+		t.Fatalf("ListByWorkflowID: %v", err)
+	}
+	if len(list) != 1 || list[0].TaskID != b.ID || list[0].DependsOnTaskID != a.ID {
+		t.Errorf("ListByWorkflowID: got %+v", list)
+	}
+}
+
 // ── WorkflowRunRepo ───────────────────────────────────────────────────────────
 
 func TestWorkflowRunRepo_CreateAndGetByID(t *testing.T) {
@@ -285,6 +309,36 @@ func TestWorkflowRunRepo_ListByWorkflowID(t *testing.T) {
 	}
 }
 
+func TestWorkflowRunRepo_FindByWorkflowID(t *testing.T) {
+	r := mock.NewWorkflowRunRepo()
+	wfID := uuid.New()
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		run := newWorkflowRun(wfID)
+		run.StartedAt = base.Add(time.Duration(i) * time.Hour)
+		if i == 2 {
+			run.Status = domain.StatusFailed
+		}
+		_ = r.Create(ctx, run)
+	}
+	_ = r.Create(ctx, newWorkflowRun(uuid.New()))
+
+	page, err := r.FindByWorkflowID(ctx, wfID, repository.WorkflowRunFilter{
+		StartedFrom: base.Add(time.Hour), StartedTo: base.Add(4 * time.Hour), Offset: 1, Limit: 1,
+	})
+	if err != nil {
+		t.Fatalf("FindByWorkflowID: %v", err)
+	}
+	if len(page) != 1 || !page[0].StartedAt.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("page: got %v, want the second newest run in range", page)
+	}
+
+	failed, _ := r.FindByWorkflowID(ctx, wfID, repository.WorkflowRunFilter{Status: domain.StatusFailed})
+	if len(failed) != 1 {
+		t.Errorf("status filter: got %d runs, want 1", len(failed))
+	}
+}
+
 func TestWorkflowRunRepo_ListByStatus(t *testing.T) {
 	r := mock.NewWorkflowRunRepo()
 	_ = r.Create(ctx, newWorkflowRun(uuid.New())) // pending
@@ -380,6 +434,22 @@ func TestTaskRunRepo_ListByStatus(t *testing.T) {
 	}
 }
 
+func TestTaskRunRepo_AppendLogs(t *testing.T) {
+	r := mock.NewTaskRunRepo()
+	tr := newTaskRun(uuid.New(), uuid.New())
+	_ = r.Create(ctx, tr)
+
+	_ = r.AppendLogs(ctx, tr.ID, "line 1\n")
+	_ = r.AppendLogs(ctx, tr.ID, "line 2\n")
+	got, _ := r.GetByID(ctx, tr.ID)
+	if got.Logs != "line 1\nline 2\n" {
+		t.Errorf("Logs: got %q", got.Logs)
+	}
+	if err := r.AppendLogs(ctx, uuid.New(), "x"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("unknown id: got %v, want ErrNotFound", err)
+	}
+}
+
 // ── WorkerRepo ────────────────────────────────────────────────────────────────
 
 func TestWorkerRepo_CreateAndGetByID(t *testing.T) {
@@ -477,14 +547,208 @@ func TestWorkerRepo_UpdateHeartbeat_NotFound(t *testing.T) {
 	}
 }
 
+// ── ApprovalRepo ──────────────────────────────────────────────────────────────
+
+func TestApprovalRepo_CreateAndListByTaskRunID(t *testing.T) {
+	r := mock.NewApprovalRepo(mock.NewTaskRunRepo())
+	trID := uuid.New()
+	_ = r.Create(ctx, &domain.Approval{ID: uuid.New(), TaskRunID: trID, Decision: domain.ApprovalApproved, Approver: "alice"})
+	_ = r.Create(ctx, &domain.Approval{ID: uuid.New(), TaskRunID: uuid.New(), Decision: domain.ApprovalRejected, Approver: "bob"})
+
+	list, err := r.ListByTaskRunID(ctx, trID)
+	if err != nil {
+		t.Fatalf("ListByTaskRunID: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("ListByTaskRunID length: got %d, want 1", len(list))
+	}
+	if list[0].Approver != "alice" {
+		t.Errorf("Approver: got %q, want alice", list[0].Approver)
+	}
+}
+
+// ── BackfillRepo ──────────────────────────────────────────────────────────────
+
+func TestBackfillRepo_ConditionalUpdates(t *testing.T) {
+	r := mock.NewBackfillRepo()
+	b := &domain.Backfill{ID: uuid.New(), WorkflowID: uuid.New(), Status: domain.BackfillStatusRunning, Total: 3}
+	_ = r.Create(ctx, b)
+
+	b.Created = 1
+	if err := r.UpdateProgress(ctx, b); err != nil {
+		t.Fatalf("UpdateProgress: %v", err)
+	}
+	if err := r.Transition(ctx, b.ID, domain.BackfillStatusRunning, domain.BackfillStatusCancelled, time.Now()); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	// b is now stale: a write based on it must not revert the cancellation.
+	b.Created = 2
+	if err := r.UpdateProgress(ctx, b); err != repository.ErrConflict {
+		t.Errorf("stale UpdateProgress: got %v, want ErrConflict", err)
+	}
+	if err := r.Transition(ctx, b.ID, domain.BackfillStatusRunning, domain.BackfillStatusCompleted, time.Now()); err != repository.ErrConflict {
+		t.Errorf("second Transition: got %v, want ErrConflict", err)
+	}
+	got, _ := r.GetByID(ctx, b.ID)
+	if got.Status != domain.BackfillStatusCancelled || got.Created != 1 || got.FinishedAt == nil {
+		t.Errorf("stored: got status=%s created=%d, want cancelled/1 with finished_at", got.Status, got.Created)
+	}
+	running, _ := r.ListByStatus(ctx, domain.BackfillStatusRunning)
+	if len(running) != 0 {
+		t.Errorf("running: got %d, want 0", len(running))
+	}
+	if err := r.UpdateProgress(ctx, &domain.Backfill{ID: uuid.New()}); err != repository.ErrNotFound {
+		t.Errorf("UpdateProgress unknown: got %v, want ErrNotFound", err)
+	}
+}
+
+// ── LineageRepo ───────────────────────────────────────────────────────────────
+
+func TestLineageRepo_ListByDatasetAndTaskRun(t *testing.T) {
+	r := mock.NewLineageRepo()
+	trID := uuid.New()
+	now := time.Now().UTC()
+	_ = r.Create(ctx, &domain.LineageEdge{ID: uuid.New(), Dataset: "a", Direction: domain.LineageInput, TaskRunID: trID, CreatedAt: now})
+	_ = r.Create(ctx, &domain.LineageEdge{ID: uuid.New(), Dataset: "b", Direction: domain.LineageOutput, TaskRunID: trID, CreatedAt: now})
+	_ = r.Create(ctx, &domain.LineageEdge{ID: uuid.New(), Dataset: "a", Direction: domain.LineageInput, TaskRunID: uuid.New(), CreatedAt: now.Add(-time.Hour)})
+
+	if got, _ := r.ListByDataset(ctx, "a", domain.LineageInput, time.Time{}); len(got) != 2 || !got[0].CreatedAt.Before(got[1].CreatedAt) {
+		t.Errorf("ListByDataset: got %d edges, want 2 oldest first", len(got))
+	}
+	if got, _ := r.ListByDataset(ctx, "a", domain.LineageInput, now.Add(-time.Minute)); len(got) != 1 {
+		t.Errorf("ListByDataset since: got %d, want 1", len(got))
+	}
+	if got, _ := r.ListByDataset(ctx, "a", domain.LineageOutput, time.Time{}); len(got) != 0 {
+		t.Errorf("ListByDataset output: got %d, want 0", len(got))
+	}
+	if got, _ := r.ListByTaskRunID(ctx, trID); len(got) != 2 {
+		t.Errorf("ListByTaskRunID: got %d, want 2", len(got))
+	}
+}
+
+// ── StatsRepo ─────────────────────────────────────────────────────────────────
+
+func TestStatsRepo_Aggregates(t *testing.T) {
+	runs, taskRuns, tasks := mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo(), mock.NewTaskRepo()
+	r := mock.NewStatsRepo(runs, taskRuns, tasks)
+	wf := newWorkflow()
+	task := newTask(wf.ID)
+	_ = tasks.Create(ctx, task)
+
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Runs of 10s, 20s, 30s and 40s; the last fails on the next day.
+	for i := 1; i <= 4; i++ {
+		wr := newWorkflowRun(wf.ID)
+		wr.Status = domain.StatusSuccess
+		wr.StartedAt = day.Add(time.Duration(i) * time.Hour)
+		if i == 4 {
+			wr.Status = domain.StatusFailed
+			wr.StartedAt = day.Add(25 * time.Hour)
+		}
+		finished := wr.StartedAt.Add(time.Duration(i*10) * time.Second)
+		wr.FinishedAt = &finished
+		_ = runs.Create(ctx, wr)
+
+		tr := newTaskRun(wr.ID, task.ID)
+		trFinished := wr.StartedAt.Add(time.Duration(i) * time.Second)
+		tr.StartedAt, tr.FinishedAt = wr.StartedAt, &trFinished
+		_ = taskRuns.Create(ctx, tr)
+	}
+	// Unfinished runs are ignored.
+	_ = runs.Create(ctx, newWorkflowRun(wf.ID))
+
+	p, err := r.RunDurationPercentiles(ctx, wf.ID, day)
+	if err != nil {
+		t.Fatalf("RunDurationPercentiles: %v", err)
+	}
+	if p.Runs != 4 || p.P50 != 25 || p.P95 != 38.5 {
+		t.Errorf("percentiles: got %+v, want 4 runs, p50=25, p95=38.5", p)
+	}
+
+	avgs, _ := r.TaskDurationAverages(ctx, wf.ID, day)
+	if len(avgs) != 1 || avgs[0].TaskName != "extract" || avgs[0].Runs != 4 || avgs[0].AvgSeconds != 2.5 {
+		t.Errorf("task averages: got %+v", avgs)
+	}
+
+	trend, _ := r.RunDurationTrend(ctx, wf.ID, day, 24*time.Hour)
+	if len(trend) != 2 {
+		t.Fatalf("trend: got %d buckets, want 2", len(trend))
+	}
+	if !trend[0].Start.Equal(day) || trend[0].Runs != 3 || trend[0].AvgSeconds != 20 || trend[0].Failed != 0 {
+		t.Errorf("bucket 0: got %+v", trend[0])
+	}
+	if trend[1].Runs != 1 || trend[1].Failed != 1 || trend[1].P95Seconds != 40 {
+		t.Errorf("bucket 1: got %+v", trend[1])
+	}
+
+	// since excludes older runs.
+	p, _ = r.RunDurationPercentiles(ctx, wf.ID, day.Add(24*time.Hour))
+	if p.Runs != 1 {
+		t.Errorf("since: got %d runs, want 1", p.Runs)
+	}
+}
+
+func TestRetentionRepo_PurgeRuns(t *testing.T) {
+	runs, taskRuns := mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo()
+	r := mock.NewRetentionRepo(runs, taskRuns)
+	wf := newWorkflow()
+	task := newTask(wf.ID)
+
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// An old success, an old run still running and a recent success.
+	var ids []uuid.UUID
+	for i, status := range []domain.Status{domain.StatusSuccess, domain.StatusRunning, domain.StatusSuccess} {
+		wr := newWorkflowRun(wf.ID)
+		wr.Status = status
+		wr.StartedAt = day.Add(time.Duration(i) * 24 * time.Hour)
+		_ = runs.Create(ctx, wr)
+		_ = taskRuns.Create(ctx, newTaskRun(wr.ID, task.ID))
+		ids = append(ids, wr.ID)
+	}
+	filter := repository.RunPurgeFilter{Before: day.Add(48 * time.Hour), Statuses: []domain.Status{domain.StatusSuccess}}
+
+	counts, err := r.PurgeRuns(ctx, filter, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if counts != (domain.PurgeCounts{WorkflowRuns: 1, TaskRuns: 1}) {
+		t.Errorf("dry run counts = %+v, want 1 run and 1 task run", counts)
+	}
+	if _, err := runs.GetByID(ctx, ids[0]); err != nil {
+		t.Errorf("dry run deleted the run: %v", err)
+	}
+
+	if counts, _ = r.PurgeRuns(ctx, filter, false); counts.WorkflowRuns != 1 {
+		t.Errorf("purge deleted %d runs, want 1", counts.WorkflowRuns)
+	}
+	if _, err := runs.GetByID(ctx, ids[0]); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("purged run: got %v, want ErrNotFound", err)
+	}
+	if trs, _ := taskRuns.ListByWorkflowRunID(ctx, ids[0]); len(trs) != 0 {
+		t.Errorf("purged run kept %d task runs", len(trs))
+	}
+	for _, id := range ids[1:] {
+		if _, err := runs.GetByID(ctx, id); err != nil {
+			t.Errorf("run %s should be kept: %v", id, err)
+		}
+	}
+}
+
 // ── interface compliance ──────────────────────────────────────────────────────
 
 // These compile-time checks ensure each mock struct satisfies the corresponding
 // repository interface.
 var (
-	_ repository.WorkflowRepository    = (*mock.WorkflowRepo)(nil)
-	_ repository.TaskRepository        = (*mock.TaskRepo)(nil)
-	_ repository.WorkflowRunRepository = (*mock.WorkflowRunRepo)(nil)
-	_ repository.TaskRunRepository     = (*mock.TaskRunRepo)(nil)
-	_ repository.WorkerRepository      = (*mock.WorkerRepo)(nil)
+	_ repository.WorkflowRepository       = (*mock.WorkflowRepo)(nil)
+	_ repository.TaskRepository           = (*mock.TaskRepo)(nil)
+	_ repository.TaskDependencyRepository = (*mock.TaskDependencyRepo)(nil)
+	_ repository.WorkflowRunRepository    = (*mock.WorkflowRunRepo)(nil)
+	_ repository.TaskRunRepository        = (*mock.TaskRunRepo)(nil)
+	_ repository.WorkerRepository         = (*mock.WorkerRepo)(nil)
+	_ repository.ApprovalRepository       = (*mock.ApprovalRepo)(nil)
+	_ repository.BackfillRepository       = (*mock.BackfillRepo)(nil)
+	_ repository.CalendarRepository       = (*mock.CalendarRepo)(nil)
+	_ repository.LineageRepository        = (*mock.LineageRepo)(nil)
+	_ repository.StatsRepository          = (*mock.StatsRepo)(nil)
+	_ repository.RetentionRepository      = (*mock.RetentionRepo)(nil)
 )
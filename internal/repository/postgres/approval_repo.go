@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ApprovalRepo is a GORM-backed implementation of repository.ApprovalRepository.
+type ApprovalRepo struct {
+	db *gorm.DB
+}
+
+// NewApprovalRepo constructs an ApprovalRepo with the supplied *gorm.DB.
+func NewApprovalRepo(db *gorm.DB) *ApprovalRepo {
+	return &ApprovalRepo{db: db}
+}
+
+func (r *ApprovalRepo) Create(ctx context.Context, a *domain.Approval) error {
+	return r.db.WithContext(ctx).Create(approvalFromDomain(a)).Error
+}
+
+func (r *ApprovalRepo) Decide(ctx context.Context, a *domain.Approval, status domain.Status) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&taskRunModel{}).
+			Where("id = ? AND status = ?", a.TaskRunID.String(), string(domain.StatusAwaitingApproval)).
+			Updates(map[string]interface{}{"status": string(status), "finished_at": a.CreatedAt})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return repository.ErrConflict
+		}
+		return tx.Create(approvalFromDomain(a)).Error
+	})
+}
+
+func (r *ApprovalRepo) ListByTaskRunID(ctx context.Context, taskRunID uuid.UUID) ([]*domain.Approval, error) {
+	var models []approvalModel
+	if err := r.db.WithContext(ctx).
+		Where("task_run_id = ?", taskRunID.String()).
+		Order("created_at ASC").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*domain.Approval, len(models))
+	for i := range models {
+		a, err := models[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = a
+	}
+	return out, nil
+}
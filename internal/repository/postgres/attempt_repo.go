@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AttemptRepo is a GORM-backed implementation of domain.AttemptRepository.
+type AttemptRepo struct {
+	db *gorm.DB
+}
+
+// NewAttemptRepo constructs an AttemptRepo with the supplied *gorm.DB.
+func NewAttemptRepo(db *gorm.DB) *AttemptRepo {
+	return &AttemptRepo{db: db}
+}
+
+// Record inserts a, replacing an earlier attempt of the task with its
+// Number.
+func (r *AttemptRepo) Record(ctx context.Context, a *qdomain.Attempt) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "task_id"}, {Name: "number"}},
+			UpdateAll: true,
+		}).
+		Create(taskAttemptFromDomain(a)).Error
+}
+
+func (r *AttemptRepo) ListByTaskID(ctx context.Context, taskID string) ([]*qdomain.Attempt, error) {
+	var models []taskAttemptModel
+	if err := r.db.WithContext(ctx).
+		Where("task_id = ?", taskID).
+		Order("number").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*qdomain.Attempt, len(models))
+	for i := range models {
+		out[i] = models[i].toDomain()
+	}
+	return out, nil
+}
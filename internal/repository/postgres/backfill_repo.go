@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+	"gorm.io/gorm"
+)
+
+// BackfillRepo is a GORM-backed implementation of repository.BackfillRepository.
+type BackfillRepo struct {
+	db *gorm.DB
+}
+
+// NewBackfillRepo constructs a BackfillRepo with the supplied *gorm.DB.
+func NewBackfillRepo(db *gorm.DB) *BackfillRepo {
+	return &BackfillRepo{db: db}
+}
+
+func (r *BackfillRepo) Create(ctx context.Context, b *domain.Backfill) error {
+	return r.db.WithContext(ctx).Create(backfillFromDomain(b)).Error
+}
+
+func (r *BackfillRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Backfill, error) {
+	var m backfillModel
+	err := r.db.WithContext(ctx).First(&m, "id = ?", id.String()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.toDomain()
+}
+
+func (r *BackfillRepo) UpdateProgress(ctx context.Context, b *domain.Backfill) error {
+	result := r.db.WithContext(ctx).
+		Model(&backfillModel{}).
+		Where("id = ? AND status = ?", b.ID.String(), string(b.Status)).
+		// A map so a nil cursor is written too.
+		Updates(map[string]interface{}{
+			"created":           b.Created,
+			"total":             b.Total,
+			"last_logical_date": b.Cursor,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.missingOrConflict(ctx, b.ID)
+	}
+	return nil
+}
+
+func (r *BackfillRepo) Transition(ctx context.Context, id uuid.UUID, from, to domain.BackfillStatus, finishedAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&backfillModel{}).
+		Where("id = ? AND status = ?", id.String(), string(from)).
+		Updates(map[string]interface{}{
+			"status":      string(to),
+			"finished_at": finishedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.missingOrConflict(ctx, id)
+	}
+	return nil
+}
+
+// missingOrConflict explains a conditional update of backfill id that
+// matched no row: ErrNotFound if it does not exist, ErrConflict otherwise.
+func (r *BackfillRepo) missingOrConflict(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return repository.ErrConflict
+}
+
+func (r *BackfillRepo) ListByStatus(ctx context.Context, status domain.BackfillStatus) ([]*domain.Backfill, error) {
+	var models []backfillModel
+	if err := r.db.WithContext(ctx).
+		Where("status = ?", string(status)).
+		Order("created_at ASC").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*domain.Backfill, len(models))
+	for i := range models {
+		b, err := models[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = b
+	}
+	return out, nil
+}
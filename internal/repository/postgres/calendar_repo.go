@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+	"gorm.io/gorm"
+)
+
+// CalendarRepo is a GORM-backed implementation of repository.CalendarRepository.
+type CalendarRepo struct {
+	db *gorm.DB
+}
+
+// NewCalendarRepo constructs a CalendarRepo with the supplied *gorm.DB.
+func NewCalendarRepo(db *gorm.DB) *CalendarRepo {
+	return &CalendarRepo{db: db}
+}
+
+func (r *CalendarRepo) Create(ctx context.Context, c *domain.Calendar) error {
+	return r.db.WithContext(ctx).Create(calendarFromDomain(c)).Error
+}
+
+func (r *CalendarRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Calendar, error) {
+	var m calendarModel
+	err := r.db.WithContext(ctx).First(&m, "id = ?", id.String()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.toDomain()
+}
+
+func (r *CalendarRepo) GetByName(ctx context.Context, name string) (*domain.Calendar, error) {
+	var m calendarModel
+	err := r.db.WithContext(ctx).Order("created_at").First(&m, "name = ?", name).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.toDomain()
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+	"gorm.io/gorm"
+)
+
+// EventRepo is a GORM-backed implementation of repository.EventRepository.
+type EventRepo struct {
+	db *gorm.DB
+}
+
+// NewEventRepo constructs an EventRepo with the supplied *gorm.DB.
+func NewEventRepo(db *gorm.DB) *EventRepo {
+	return &EventRepo{db: db}
+}
+
+func (r *EventRepo) Append(ctx context.Context, e *domain.EventRecord) error {
+	m := eventFromDomain(e)
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
+		return err
+	}
+	e.Seq = m.Seq
+	return nil
+}
+
+func (r *EventRepo) List(ctx context.Context, f repository.EventFilter) ([]*domain.EventRecord, error) {
+	q := r.db.WithContext(ctx).Where("seq > ?", f.AfterSeq)
+	if len(f.Types) > 0 {
+		q = q.Where("type IN ?", f.Types)
+	}
+	if !f.Since.IsZero() {
+		q = q.Where("at >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		q = q.Where("at < ?", f.Until)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var models []eventModel
+	if err := q.Order("seq").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*domain.EventRecord, len(models))
+	for i := range models {
+		out[i] = models[i].toDomain()
+	}
+	return out, nil
+}
+
+func (r *EventRepo) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Where("at < ?", t).Delete(&eventModel{})
+	return res.RowsAffected, res.Error
+}
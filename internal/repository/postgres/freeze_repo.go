@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FreezeRepo is a GORM-backed implementation of domain.FreezeRepository,
+// keeping the switch in a single dispatch_freeze row.
+type FreezeRepo struct {
+	db *gorm.DB
+}
+
+// NewFreezeRepo constructs a FreezeRepo with the supplied *gorm.DB.
+func NewFreezeRepo(db *gorm.DB) *FreezeRepo {
+	return &FreezeRepo{db: db}
+}
+
+func (r *FreezeRepo) Get(ctx context.Context) (*qdomain.DispatchFreeze, error) {
+	var m dispatchFreezeModel
+	err := r.db.WithContext(ctx).First(&m, "id = ?", dispatchFreezeID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &qdomain.DispatchFreeze{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &qdomain.DispatchFreeze{Frozen: m.Frozen, Reason: m.Reason, By: m.By, At: m.At}, nil
+}
+
+func (r *FreezeRepo) Save(ctx context.Context, f *qdomain.DispatchFreeze) error {
+	m := &dispatchFreezeModel{ID: dispatchFreezeID, Frozen: f.Frozen, Reason: f.Reason, By: f.By, At: f.At}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(m).Error
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"gorm.io/gorm"
+)
+
+// HeartbeatRetention is how long HeartbeatRepo keeps a heartbeat.
+const HeartbeatRetention = 24 * time.Hour
+
+// HeartbeatRepo is a GORM-backed implementation of
+// domain.HeartbeatRepository.
+type HeartbeatRepo struct {
+	db *gorm.DB
+}
+
+// NewHeartbeatRepo constructs a HeartbeatRepo with the supplied *gorm.DB.
+func NewHeartbeatRepo(db *gorm.DB) *HeartbeatRepo {
+	return &HeartbeatRepo{db: db}
+}
+
+// Record inserts hb and prunes the worker's heartbeats older than
+// HeartbeatRetention in the same transaction.
+func (r *HeartbeatRepo) Record(ctx context.Context, hb qdomain.Heartbeat) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		m := &workerHeartbeatModel{
+			ID:          uuid.NewString(),
+			WorkerID:    hb.WorkerID,
+			At:          hb.At,
+			Status:      string(hb.Status),
+			ActiveTasks: hb.ActiveTasks,
+		}
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		return tx.Where("worker_id = ? AND at < ?", hb.WorkerID, hb.At.Add(-HeartbeatRetention)).
+			Delete(&workerHeartbeatModel{}).Error
+	})
+}
+
+func (r *HeartbeatRepo) ListRecent(ctx context.Context, workerID string, limit int) ([]qdomain.Heartbeat, error) {
+	var models []workerHeartbeatModel
+	if err := r.db.WithContext(ctx).
+		Where("worker_id = ?", workerID).
+		Order("at DESC").
+		Limit(limit).
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]qdomain.Heartbeat, len(models))
+	for i := range models {
+		out[i] = models[i].toDomain()
+	}
+	return out, nil
+}
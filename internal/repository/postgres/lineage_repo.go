@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"gorm.io/gorm"
+)
+
+// LineageRepo is a GORM-backed implementation of repository.LineageRepository.
+type LineageRepo struct {
+	db *gorm.DB
+}
+
+// NewLineageRepo constructs a LineageRepo with the supplied *gorm.DB.
+func NewLineageRepo(db *gorm.DB) *LineageRepo {
+	return &LineageRepo{db: db}
+}
+
+func (r *LineageRepo) Create(ctx context.Context, e *domain.LineageEdge) error {
+	return r.db.WithContext(ctx).Create(lineageEdgeFromDomain(e)).Error
+}
+
+func (r *LineageRepo) ListByDataset(ctx context.Context, dataset string, dir domain.LineageDirection, since time.Time) ([]*domain.LineageEdge, error) {
+	return r.list(r.db.WithContext(ctx).
+		Where("dataset = ? AND direction = ? AND created_at >= ?", dataset, string(dir), since))
+}
+
+func (r *LineageRepo) ListByTaskRunID(ctx context.Context, taskRunID uuid.UUID) ([]*domain.LineageEdge, error) {
+	return r.list(r.db.WithContext(ctx).Where("task_run_id = ?", taskRunID.String()))
+}
+
+func (r *LineageRepo) list(q *gorm.DB) ([]*domain.LineageEdge, error) {
+	var models []lineageEdgeModel
+	if err := q.Order("created_at ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*domain.LineageEdge, len(models))
+	for i := range models {
+		e, err := models[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = e
+	}
+	return out, nil
+}
@@ -5,6 +5,7 @@
 package postgres
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -15,12 +16,38 @@ import (
 // ── Workflow ──────────────────────────────────────────────────────────────────
 
 type workflowModel struct {
-	ID           string    `gorm:"type:uuid;primaryKey;column:id"`
-	Name         string    `gorm:"column:name;not null"`
-	Description  string    `gorm:"column:description;not null;default:''"`
-	ScheduleCron string    `gorm:"column:schedule_cron;not null;default:''"`
-	IsActive     bool      `gorm:"column:is_active;not null;default:true"`
-	CreatedAt    time.Time `gorm:"column:created_at;not null"`
+	ID           string     `gorm:"type:uuid;primaryKey;column:id"`
+	Name         string     `gorm:"column:name;not null"`
+	Description  string     `gorm:"column:description;not null;default:''"`
+	ScheduleCron string     `gorm:"column:schedule_cron;not null;default:''"`
+	IsActive     bool       `gorm:"column:is_active;not null;default:true"`
+	CreatedAt    time.Time  `gorm:"column:created_at;not null"`
+	Timezone     string     `gorm:"column:timezone;not null;default:''"`
+	StartDate    *time.Time `gorm:"column:start_date"`
+	EndDate      *time.Time `gorm:"column:end_date"`
+
+	TriggerDatasets  string  `gorm:"type:jsonb;column:trigger_datasets;not null;default:'[]'"`
+	DatasetPolicy    string  `gorm:"column:dataset_policy;not null;default:''"`
+	TriggerEvents    string  `gorm:"type:jsonb;column:trigger_events;not null;default:'[]'"`
+	CalendarID       *string `gorm:"type:uuid;column:calendar_id"`
+	PauseWindows     string  `gorm:"type:jsonb;column:pause_windows;not null;default:'[]'"`
+	Catchup          string  `gorm:"column:catchup;not null;default:''"`
+	TriggerOnSuccess string  `gorm:"type:jsonb;column:trigger_on_success;not null;default:'[]'"`
+	MaxParallelTasks int     `gorm:"column:max_parallel_tasks;not null;default:0"`
+	RetainRuns       int     `gorm:"column:retain_runs;not null;default:0"`
+	RetainDays       int     `gorm:"column:retain_days;not null;default:0"`
+	WorkerGroup      string  `gorm:"column:worker_group;not null;default:''"`
+	Tenant           string  `gorm:"column:tenant;not null;default:''"`
+	Priority         int     `gorm:"column:priority;not null;default:0"`
+
+	RunTimeoutSeconds int    `gorm:"column:run_timeout_seconds;not null;default:0"`
+	RunTimeoutPolicy  string `gorm:"column:run_timeout_policy;not null;default:''"`
+	MaxActiveRuns     int    `gorm:"column:max_active_runs;not null;default:0"`
+	OverlapPolicy     string `gorm:"column:overlap_policy;not null;default:''"`
+
+	Paused   bool       `gorm:"column:paused;not null;default:false"`
+	PausedBy string     `gorm:"column:paused_by;not null;default:''"`
+	PausedAt *time.Time `gorm:"column:paused_at"`
 }
 
 func (workflowModel) TableName() string { return "workflows" }
@@ -30,6 +57,30 @@ func (m *workflowModel) toDomain() (*domain.Workflow, error) {
 	if err != nil {
 		return nil, fmt.Errorf("workflow: invalid id %q: %w", m.ID, err)
 	}
+	var datasets []string
+	if err := decodeList(m.TriggerDatasets, &datasets); err != nil {
+		return nil, fmt.Errorf("workflow %s: invalid trigger_datasets: %w", m.ID, err)
+	}
+	var events []string
+	if err := decodeList(m.TriggerEvents, &events); err != nil {
+		return nil, fmt.Errorf("workflow %s: invalid trigger_events: %w", m.ID, err)
+	}
+	var pauses []domain.PauseWindow
+	if err := decodeList(m.PauseWindows, &pauses); err != nil {
+		return nil, fmt.Errorf("workflow %s: invalid pause_windows: %w", m.ID, err)
+	}
+	var triggers []uuid.UUID
+	if err := decodeList(m.TriggerOnSuccess, &triggers); err != nil {
+		return nil, fmt.Errorf("workflow %s: invalid trigger_on_success: %w", m.ID, err)
+	}
+	var calendarID *uuid.UUID
+	if m.CalendarID != nil {
+		id, err := uuid.Parse(*m.CalendarID)
+		if err != nil {
+			return nil, fmt.Errorf("workflow: invalid calendar_id %q: %w", *m.CalendarID, err)
+		}
+		calendarID = &id
+	}
 	return &domain.Workflow{
 		ID:           id,
 		Name:         m.Name,
@@ -37,10 +88,41 @@ func (m *workflowModel) toDomain() (*domain.Workflow, error) {
 		ScheduleCron: m.ScheduleCron,
 		IsActive:     m.IsActive,
 		CreatedAt:    m.CreatedAt,
+		Timezone:     m.Timezone,
+		StartDate:    m.StartDate,
+		EndDate:      m.EndDate,
+
+		TriggerDatasets: datasets,
+		DatasetPolicy:   domain.DatasetPolicy(m.DatasetPolicy),
+		TriggerEvents:   events,
+		CalendarID:      calendarID,
+		PauseWindows:    pauses,
+		Catchup:         domain.CatchupPolicy(m.Catchup),
+
+		TriggerOnSuccess: triggers,
+		MaxParallelTasks: m.MaxParallelTasks,
+		RetainRuns:       m.RetainRuns,
+		RetainDays:       m.RetainDays,
+		WorkerGroup:      m.WorkerGroup,
+		Tenant:           m.Tenant,
+		Priority:         m.Priority,
+		Paused:           m.Paused,
+		PausedBy:         m.PausedBy,
+		PausedAt:         m.PausedAt,
+
+		RunTimeoutSeconds: m.RunTimeoutSeconds,
+		RunTimeoutPolicy:  domain.RunTimeoutPolicy(m.RunTimeoutPolicy),
+		MaxActiveRuns:     m.MaxActiveRuns,
+		OverlapPolicy:     domain.OverlapPolicy(m.OverlapPolicy),
 	}, nil
 }
 
 func workflowFromDomain(wf *domain.Workflow) *workflowModel {
+	var calendarID *string
+	if wf.CalendarID != nil {
+		id := wf.CalendarID.String()
+		calendarID = &id
+	}
 	return &workflowModel{
 		ID:           wf.ID.String(),
 		Name:         wf.Name,
@@ -48,6 +130,32 @@ func workflowFromDomain(wf *domain.Workflow) *workflowModel {
 		ScheduleCron: wf.ScheduleCron,
 		IsActive:     wf.IsActive,
 		CreatedAt:    wf.CreatedAt,
+		Timezone:     wf.Timezone,
+		StartDate:    wf.StartDate,
+		EndDate:      wf.EndDate,
+
+		TriggerDatasets: encodeList(wf.TriggerDatasets),
+		DatasetPolicy:   string(wf.DatasetPolicy),
+		TriggerEvents:   encodeList(wf.TriggerEvents),
+		CalendarID:      calendarID,
+		PauseWindows:    encodeList(wf.PauseWindows),
+		Catchup:         string(wf.Catchup),
+
+		TriggerOnSuccess: encodeList(wf.TriggerOnSuccess),
+		MaxParallelTasks: wf.MaxParallelTasks,
+		RetainRuns:       wf.RetainRuns,
+		RetainDays:       wf.RetainDays,
+		WorkerGroup:      wf.WorkerGroup,
+		Tenant:           wf.Tenant,
+		Priority:         wf.Priority,
+		Paused:           wf.Paused,
+		PausedBy:         wf.PausedBy,
+		PausedAt:         wf.PausedAt,
+
+		RunTimeoutSeconds: wf.RunTimeoutSeconds,
+		RunTimeoutPolicy:  string(wf.RunTimeoutPolicy),
+		MaxActiveRuns:     wf.MaxActiveRuns,
+		OverlapPolicy:     string(wf.OverlapPolicy),
 	}
 }
 
@@ -58,10 +166,26 @@ type taskModel struct {
 	WorkflowID        string    `gorm:"type:uuid;column:workflow_id;not null"`
 	Name              string    `gorm:"column:name;not null"`
 	Command           string    `gorm:"column:command;not null;default:''"`
+	Type              string    `gorm:"column:type;not null;default:'command'"`
+	Pool              string    `gorm:"column:pool;not null;default:''"`
+	ConcurrencyKey    string    `gorm:"column:concurrency_key;not null;default:''"`
+	TriggerRule       string    `gorm:"column:trigger_rule;not null;default:'all_success'"`
 	RetryCount        int       `gorm:"column:retry_count;not null;default:0"`
 	RetryDelaySeconds int       `gorm:"column:retry_delay_seconds;not null;default:0"`
 	TimeoutSeconds    int       `gorm:"column:timeout_seconds;not null;default:0"`
 	CreatedAt         time.Time `gorm:"column:created_at;not null"`
+
+	RetryMultiplier      float64 `gorm:"column:retry_multiplier;not null;default:0"`
+	RetryMaxDelaySeconds int     `gorm:"column:retry_max_delay_seconds;not null;default:0"`
+	RetryJitter          bool    `gorm:"column:retry_jitter;not null;default:false"`
+	Inputs               string  `gorm:"type:jsonb;column:inputs;not null;default:'[]'"`
+	Outputs              string  `gorm:"type:jsonb;column:outputs;not null;default:'[]'"`
+	Env                  string  `gorm:"type:jsonb;column:env;not null;default:'{}'"`
+	Hooks                string  `gorm:"type:jsonb;column:hooks;not null;default:'[]'"`
+	Profile              string  `gorm:"column:profile;not null;default:''"`
+	Queue                string  `gorm:"column:queue_name;not null;default:''"`
+	Priority             int     `gorm:"column:priority;not null;default:0"`
+	TTLSeconds           int     `gorm:"column:ttl_seconds;not null;default:0"`
 }
 
 func (taskModel) TableName() string { return "tasks" }
@@ -75,15 +199,46 @@ func (m *taskModel) toDomain() (*domain.Task, error) {
 	if err != nil {
 		return nil, fmt.Errorf("task: invalid workflow_id %q: %w", m.WorkflowID, err)
 	}
+	var inputs, outputs []string
+	if err := decodeList(m.Inputs, &inputs); err != nil {
+		return nil, fmt.Errorf("task %s: invalid inputs: %w", m.ID, err)
+	}
+	if err := decodeList(m.Outputs, &outputs); err != nil {
+		return nil, fmt.Errorf("task %s: invalid outputs: %w", m.ID, err)
+	}
+	var env map[string]string
+	if err := decodeMap(m.Env, &env); err != nil {
+		return nil, fmt.Errorf("task %s: invalid env: %w", m.ID, err)
+	}
+	var hooks []domain.TaskHook
+	if err := decodeList(m.Hooks, &hooks); err != nil {
+		return nil, fmt.Errorf("task %s: invalid hooks: %w", m.ID, err)
+	}
 	return &domain.Task{
 		ID:                id,
 		WorkflowID:        wfID,
 		Name:              m.Name,
 		Command:           m.Command,
+		Type:              domain.TaskType(m.Type),
+		Pool:              m.Pool,
+		ConcurrencyKey:    m.ConcurrencyKey,
+		TriggerRule:       domain.TriggerRule(m.TriggerRule),
 		RetryCount:        m.RetryCount,
 		RetryDelaySeconds: m.RetryDelaySeconds,
 		TimeoutSeconds:    m.TimeoutSeconds,
 		CreatedAt:         m.CreatedAt,
+
+		RetryMultiplier:      m.RetryMultiplier,
+		RetryMaxDelaySeconds: m.RetryMaxDelaySeconds,
+		RetryJitter:          m.RetryJitter,
+		Inputs:               inputs,
+		Outputs:              outputs,
+		Env:                  env,
+		Hooks:                hooks,
+		Profile:              m.Profile,
+		Queue:                m.Queue,
+		Priority:             m.Priority,
+		TTLSeconds:           m.TTLSeconds,
 	}, nil
 }
 
@@ -93,10 +248,94 @@ func taskFromDomain(t *domain.Task) *taskModel {
 		WorkflowID:        t.WorkflowID.String(),
 		Name:              t.Name,
 		Command:           t.Command,
+		Type:              string(t.Type),
+		Pool:              t.Pool,
+		ConcurrencyKey:    t.ConcurrencyKey,
+		TriggerRule:       string(t.TriggerRule),
 		RetryCount:        t.RetryCount,
 		RetryDelaySeconds: t.RetryDelaySeconds,
 		TimeoutSeconds:    t.TimeoutSeconds,
 		CreatedAt:         t.CreatedAt,
+
+		RetryMultiplier:      t.RetryMultiplier,
+		RetryMaxDelaySeconds: t.RetryMaxDelaySeconds,
+		RetryJitter:          t.RetryJitter,
+		Inputs:               encodeList(t.Inputs),
+		Outputs:              encodeList(t.Outputs),
+		Env:                  encodeMap(t.Env),
+		Hooks:                encodeList(t.Hooks),
+		Profile:              t.Profile,
+		Queue:                t.Queue,
+		Priority:             t.Priority,
+		TTLSeconds:           t.TTLSeconds,
+	}
+}
+
+// encodeList encodes v for a jsonb array column; nil becomes "[]".
+func encodeList[T any](v []T) string {
+	if len(v) == 0 {
+		return "[]"
+	}
+	b, _ := json.Marshal(v) // the element types stored always marshal
+	return string(b)
+}
+
+// decodeList decodes a jsonb array column, leaving *v nil when empty.
+func decodeList[T any](col string, v *[]T) error {
+	if col == "" || col == "[]" {
+		return nil
+	}
+	return json.Unmarshal([]byte(col), v)
+}
+
+// encodeMap encodes v for a jsonb object column; nil becomes "{}".
+func encodeMap(v map[string]string) string {
+	if len(v) == 0 {
+		return "{}"
+	}
+	b, _ := json.Marshal(v) // string maps always marshal
+	return string(b)
+}
+
+// decodeMap decodes a jsonb object column, leaving *v nil when empty.
+func decodeMap(col string, v *map[string]string) error {
+	if col == "" || col == "{}" {
+		return nil
+	}
+	return json.Unmarshal([]byte(col), v)
+}
+
+// ── TaskDependency ────────────────────────────────────────────────────────────
+
+type taskDependencyModel struct {
+	ID              string `gorm:"type:uuid;primaryKey;column:id"`
+	TaskID          string `gorm:"type:uuid;column:task_id;not null"`
+	DependsOnTaskID string `gorm:"type:uuid;column:depends_on_task_id;not null"`
+}
+
+func (taskDependencyModel) TableName() string { return "task_dependencies" }
+
+func (m *taskDependencyModel) toDomain() (*domain.TaskDependency, error) {
+	id, err := uuid.Parse(m.ID)
+	if err != nil {
+		return nil, fmt.Errorf("task_dependency: invalid id %q: %w", m.ID, err)
+	}
+	taskID, err := uuid.Parse(m.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("task_dependency: invalid task_id %q: %w", m.TaskID, err)
+	}
+	upID, err := uuid.Parse(m.DependsOnTaskID)
+	if err != nil {
+		return nil, fmt.Errorf("task_dependency: invalid depends_on_task_id %q: %w", m.DependsOnTaskID, err)
+	}
+	return &domain.TaskDependency{ID: id, TaskID: taskID, DependsOnTaskID: upID}, nil
+}
+
+func taskDependencyFromDomain(d *domain.TaskDependency) *taskDependencyModel {
+	return &taskDependencyModel{
+		ID:              d.ID.String(),
+		TaskID:          d.TaskID.String(),
+		DependsOnTaskID: d.DependsOnTaskID.String(),
 	}
 }
 
@@ -108,6 +347,17 @@ type workflowRunModel struct {
 	Status     string     `gorm:"column:status;not null;default:'pending'"`
 	StartedAt  time.Time  `gorm:"column:started_at;not null"`
 	FinishedAt *time.Time `gorm:"column:finished_at"`
+
+	LogicalDate *time.Time `gorm:"column:logical_date"`
+	BackfillID  *string    `gorm:"type:uuid;column:backfill_id"`
+
+	TriggeredByRunID *string `gorm:"type:uuid;column:triggered_by_run_id"`
+	Params           string  `gorm:"type:jsonb;column:params;not null;default:'{}'"`
+	Labels           string  `gorm:"type:jsonb;column:labels;not null;default:'{}'"`
+	IdempotencyKey   string  `gorm:"column:idempotency_key;not null;default:''"`
+
+	TasksCompleted int `gorm:"column:tasks_completed;not null;default:0"`
+	TasksTotal     int `gorm:"column:tasks_total;not null;default:0"`
 }
 
 func (workflowRunModel) TableName() string { return "workflow_runs" }
@@ -121,36 +371,80 @@ func (m *workflowRunModel) toDomain() (*domain.WorkflowRun, error) {
 	if err != nil {
 		return nil, fmt.Errorf("workflow_run: invalid workflow_id %q: %w", m.WorkflowID, err)
 	}
-	return &domain.WorkflowRun{
-		ID:         id,
-		WorkflowID: wfID,
-		Status:     domain.Status(m.Status),
-		StartedAt:  m.StartedAt,
-		FinishedAt: m.FinishedAt,
-	}, nil
+	wr := &domain.WorkflowRun{
+		ID:          id,
+		WorkflowID:  wfID,
+		Status:      domain.Status(m.Status),
+		StartedAt:   m.StartedAt,
+		FinishedAt:  m.FinishedAt,
+		LogicalDate: m.LogicalDate,
+		Progress:    domain.NewRunProgress(m.TasksCompleted, m.TasksTotal),
+
+		IdempotencyKey: m.IdempotencyKey,
+	}
+	if m.BackfillID != nil {
+		bfID, err := uuid.Parse(*m.BackfillID)
+		if err != nil {
+			return nil, fmt.Errorf("workflow_run: invalid backfill_id %q: %w", *m.BackfillID, err)
+		}
+		wr.BackfillID = &bfID
+	}
+	if m.TriggeredByRunID != nil {
+		byID, err := uuid.Parse(*m.TriggeredByRunID)
+		if err != nil {
+			return nil, fmt.Errorf("workflow_run: invalid triggered_by_run_id %q: %w", *m.TriggeredByRunID, err)
+		}
+		wr.TriggeredByRunID = &byID
+	}
+	if err := decodeMap(m.Params, &wr.Params); err != nil {
+		return nil, fmt.Errorf("workflow_run %s: invalid params: %w", m.ID, err)
+	}
+	if err := decodeMap(m.Labels, &wr.Labels); err != nil {
+		return nil, fmt.Errorf("workflow_run %s: invalid labels: %w", m.ID, err)
+	}
+	return wr, nil
 }
 
 func workflowRunFromDomain(wr *domain.WorkflowRun) *workflowRunModel {
-	return &workflowRunModel{
-		ID:         wr.ID.String(),
-		WorkflowID: wr.WorkflowID.String(),
-		Status:     string(wr.Status),
-		StartedAt:  wr.StartedAt,
-		FinishedAt: wr.FinishedAt,
+	m := &workflowRunModel{
+		ID:          wr.ID.String(),
+		WorkflowID:  wr.WorkflowID.String(),
+		Status:      string(wr.Status),
+		StartedAt:   wr.StartedAt,
+		FinishedAt:  wr.FinishedAt,
+		LogicalDate: wr.LogicalDate,
+		Params:      encodeMap(wr.Params),
+		Labels:      encodeMap(wr.Labels),
+
+		IdempotencyKey: wr.IdempotencyKey,
+		TasksCompleted: wr.Progress.Completed,
+		TasksTotal:     wr.Progress.Total,
 	}
+	if wr.BackfillID != nil {
+		id := wr.BackfillID.String()
+		m.BackfillID = &id
+	}
+	if wr.TriggeredByRunID != nil {
+		id := wr.TriggeredByRunID.String()
+		m.TriggeredByRunID = &id
+	}
+	return m
 }
 
 // ── TaskRun ───────────────────────────────────────────────────────────────────
 
 type taskRunModel struct {
-	ID            string     `gorm:"type:uuid;primaryKey;column:id"`
-	WorkflowRunID string     `gorm:"type:uuid;column:workflow_run_id;not null"`
-	TaskID        string     `gorm:"type:uuid;column:task_id;not null"`
-	Status        string     `gorm:"column:status;not null;default:'pending'"`
-	Attempt       int        `gorm:"column:attempt;not null;default:1"`
-	StartedAt     time.Time  `gorm:"column:started_at;not null"`
-	FinishedAt    *time.Time `gorm:"column:finished_at"`
-	Logs          string     `gorm:"column:logs;not null;default:''"`
+	ID               string     `gorm:"type:uuid;primaryKey;column:id"`
+	WorkflowRunID    string     `gorm:"type:uuid;column:workflow_run_id;not null"`
+	TaskID           string     `gorm:"type:uuid;column:task_id;not null"`
+	Status           string     `gorm:"column:status;not null;default:'pending'"`
+	Attempt          int        `gorm:"column:attempt;not null;default:1"`
+	StartedAt        time.Time  `gorm:"column:started_at;not null"`
+	FinishedAt       *time.Time `gorm:"column:finished_at"`
+	Logs             string     `gorm:"column:logs;not null;default:''"`
+	AssignedWorkerID string     `gorm:"column:assigned_worker_id;not null;default:''"`
+	DispatchedAt     *time.Time `gorm:"column:dispatched_at"`
+	QueueTaskID      string     `gorm:"column:queue_task_id;not null;default:''"`
 }
 
 func (taskRunModel) TableName() string { return "task_runs" }
@@ -169,27 +463,33 @@ func (m *taskRunModel) toDomain() (*domain.TaskRun, error) {
 		return nil, fmt.Errorf("task_run: invalid task_id %q: %w", m.TaskID, err)
 	}
 	return &domain.TaskRun{
-		ID:            id,
-		WorkflowRunID: wrID,
-		TaskID:        tID,
-		Status:        domain.Status(m.Status),
-		Attempt:       m.Attempt,
-		StartedAt:     m.StartedAt,
-		FinishedAt:    m.FinishedAt,
-		Logs:          m.Logs,
+		ID:               id,
+		WorkflowRunID:    wrID,
+		TaskID:           tID,
+		Status:           domain.Status(m.Status),
+		Attempt:          m.Attempt,
+		StartedAt:        m.StartedAt,
+		FinishedAt:       m.FinishedAt,
+		Logs:             m.Logs,
+		AssignedWorkerID: m.AssignedWorkerID,
+		DispatchedAt:     m.DispatchedAt,
+		QueueTaskID:      m.QueueTaskID,
 	}, nil
 }
 
 func taskRunFromDomain(tr *domain.TaskRun) *taskRunModel {
 	return &taskRunModel{
-		ID:            tr.ID.String(),
-		WorkflowRunID: tr.WorkflowRunID.String(),
-		TaskID:        tr.TaskID.String(),
-		Status:        string(tr.Status),
-		Attempt:       tr.Attempt,
-		StartedAt:     tr.StartedAt,
-		FinishedAt:    tr.FinishedAt,
-		Logs:          tr.Logs,
+		ID:               tr.ID.String(),
+		WorkflowRunID:    tr.WorkflowRunID.String(),
+		TaskID:           tr.TaskID.String(),
+		Status:           string(tr.Status),
+		Attempt:          tr.Attempt,
+		StartedAt:        tr.StartedAt,
+		FinishedAt:       tr.FinishedAt,
+		Logs:             tr.Logs,
+		AssignedWorkerID: tr.AssignedWorkerID,
+		DispatchedAt:     tr.DispatchedAt,
+		QueueTaskID:      tr.QueueTaskID,
 	}
 }
 
@@ -226,3 +526,221 @@ func workerFromDomain(w *domain.Worker) *workerModel {
 	}
 }
 
+// ── Approval ──────────────────────────────────────────────────────────────────
+
+type approvalModel struct {
+	ID        string    `gorm:"type:uuid;primaryKey;column:id"`
+	TaskRunID string    `gorm:"type:uuid;column:task_run_id;not null"`
+	Decision  string    `gorm:"column:decision;not null"`
+	Approver  string    `gorm:"column:approver;not null"`
+	Comment   string    `gorm:"column:comment;not null;default:''"`
+	CreatedAt time.Time `gorm:"column:created_at;not null"`
+}
+
+func (approvalModel) TableName() string { return "approvals" }
+
+func (m *approvalModel) toDomain() (*domain.Approval, error) {
+	id, err := uuid.Parse(m.ID)
+	if err != nil {
+		return nil, fmt.Errorf("approval: invalid id %q: %w", m.ID, err)
+	}
+	trID, err := uuid.Parse(m.TaskRunID)
+	if err != nil {
+		return nil, fmt.Errorf("approval: invalid task_run_id %q: %w", m.TaskRunID, err)
+	}
+	return &domain.Approval{
+		ID:        id,
+		TaskRunID: trID,
+		Decision:  domain.ApprovalDecision(m.Decision),
+		Approver:  m.Approver,
+		Comment:   m.Comment,
+		CreatedAt: m.CreatedAt,
+	}, nil
+}
+
+func approvalFromDomain(a *domain.Approval) *approvalModel {
+	return &approvalModel{
+		ID:        a.ID.String(),
+		TaskRunID: a.TaskRunID.String(),
+		Decision:  string(a.Decision),
+		Approver:  a.Approver,
+		Comment:   a.Comment,
+		CreatedAt: a.CreatedAt,
+	}
+}
+
+// ── Backfill ──────────────────────────────────────────────────────────────────
+
+type backfillModel struct {
+	ID            string     `gorm:"type:uuid;primaryKey;column:id"`
+	WorkflowID    string     `gorm:"type:uuid;column:workflow_id;not null"`
+	StartAt       time.Time  `gorm:"column:start_at;not null"`
+	EndAt         time.Time  `gorm:"column:end_at;not null"`
+	MaxActiveRuns int        `gorm:"column:max_active_runs;not null;default:1"`
+	Status        string     `gorm:"column:status;not null;default:'running'"`
+	Total         int        `gorm:"column:total;not null"`
+	Created       int        `gorm:"column:created;not null;default:0"`
+	Cursor        *time.Time `gorm:"column:last_logical_date"`
+	CreatedAt     time.Time  `gorm:"column:created_at;not null"`
+	FinishedAt    *time.Time `gorm:"column:finished_at"`
+}
+
+func (backfillModel) TableName() string { return "backfills" }
+
+func (m *backfillModel) toDomain() (*domain.Backfill, error) {
+	id, err := uuid.Parse(m.ID)
+	if err != nil {
+		return nil, fmt.Errorf("backfill: invalid id %q: %w", m.ID, err)
+	}
+	wfID, err := uuid.Parse(m.WorkflowID)
+	if err != nil {
+		return nil, fmt.Errorf("backfill: invalid workflow_id %q: %w", m.WorkflowID, err)
+	}
+	return &domain.Backfill{
+		ID:            id,
+		WorkflowID:    wfID,
+		Start:         m.StartAt,
+		End:           m.EndAt,
+		MaxActiveRuns: m.MaxActiveRuns,
+		Status:        domain.BackfillStatus(m.Status),
+		Total:         m.Total,
+		Created:       m.Created,
+		Cursor:        m.Cursor,
+		CreatedAt:     m.CreatedAt,
+		FinishedAt:    m.FinishedAt,
+	}, nil
+}
+
+func backfillFromDomain(b *domain.Backfill) *backfillModel {
+	return &backfillModel{
+		ID:            b.ID.String(),
+		WorkflowID:    b.WorkflowID.String(),
+		StartAt:       b.Start,
+		EndAt:         b.End,
+		MaxActiveRuns: b.MaxActiveRuns,
+		Status:        string(b.Status),
+		Total:         b.Total,
+		Created:       b.Created,
+		Cursor:        b.Cursor,
+		CreatedAt:     b.CreatedAt,
+		FinishedAt:    b.FinishedAt,
+	}
+}
+
+// ── LineageEdge ───────────────────────────────────────────────────────────────
+
+type lineageEdgeModel struct {
+	ID            string    `gorm:"type:uuid;primaryKey;column:id"`
+	Dataset       string    `gorm:"column:dataset;not null"`
+	Direction     string    `gorm:"column:direction;not null"`
+	WorkflowID    string    `gorm:"type:uuid;column:workflow_id;not null"`
+	WorkflowRunID string    `gorm:"type:uuid;column:workflow_run_id;not null"`
+	TaskID        string    `gorm:"type:uuid;column:task_id;not null"`
+	TaskRunID     string    `gorm:"type:uuid;column:task_run_id;not null"`
+	CreatedAt     time.Time `gorm:"column:created_at;not null"`
+}
+
+func (lineageEdgeModel) TableName() string { return "lineage_edges" }
+
+func (m *lineageEdgeModel) toDomain() (*domain.LineageEdge, error) {
+	ids := make([]uuid.UUID, 5)
+	for i, col := range []struct{ name, value string }{
+		{"id", m.ID},
+		{"workflow_id", m.WorkflowID},
+		{"workflow_run_id", m.WorkflowRunID},
+		{"task_id", m.TaskID},
+		{"task_run_id", m.TaskRunID},
+	} {
+		id, err := uuid.Parse(col.value)
+		if err != nil {
+			return nil, fmt.Errorf("lineage_edge: invalid %s %q: %w", col.name, col.value, err)
+		}
+		ids[i] = id
+	}
+	return &domain.LineageEdge{
+		ID:            ids[0],
+		Dataset:       m.Dataset,
+		Direction:     domain.LineageDirection(m.Direction),
+		WorkflowID:    ids[1],
+		WorkflowRunID: ids[2],
+		TaskID:        ids[3],
+		TaskRunID:     ids[4],
+		CreatedAt:     m.CreatedAt,
+	}, nil
+}
+
+func lineageEdgeFromDomain(e *domain.LineageEdge) *lineageEdgeModel {
+	return &lineageEdgeModel{
+		ID:            e.ID.String(),
+		Dataset:       e.Dataset,
+		Direction:     string(e.Direction),
+		WorkflowID:    e.WorkflowID.String(),
+		WorkflowRunID: e.WorkflowRunID.String(),
+		TaskID:        e.TaskID.String(),
+		TaskRunID:     e.TaskRunID.String(),
+		CreatedAt:     e.CreatedAt,
+	}
+}
+
+// ── Calendar ──────────────────────────────────────────────────────────────────
+
+type calendarModel struct {
+	ID            string    `gorm:"type:uuid;primaryKey;column:id"`
+	Name          string    `gorm:"column:name;not null"`
+	ExcludedDates string    `gorm:"type:jsonb;column:excluded_dates;not null;default:'[]'"`
+	ICalURL       string    `gorm:"column:ical_url;not null;default:''"`
+	CreatedAt     time.Time `gorm:"column:created_at;not null"`
+}
+
+func (calendarModel) TableName() string { return "calendars" }
+
+func (m *calendarModel) toDomain() (*domain.Calendar, error) {
+	id, err := uuid.Parse(m.ID)
+	if err != nil {
+		return nil, fmt.Errorf("calendar: invalid id %q: %w", m.ID, err)
+	}
+	var dates []string
+	if err := decodeList(m.ExcludedDates, &dates); err != nil {
+		return nil, fmt.Errorf("calendar %s: invalid excluded_dates: %w", m.ID, err)
+	}
+	return &domain.Calendar{
+		ID:            id,
+		Name:          m.Name,
+		ExcludedDates: dates,
+		ICalURL:       m.ICalURL,
+		CreatedAt:     m.CreatedAt,
+	}, nil
+}
+
+func calendarFromDomain(c *domain.Calendar) *calendarModel {
+	return &calendarModel{
+		ID:            c.ID.String(),
+		Name:          c.Name,
+		ExcludedDates: encodeList(c.ExcludedDates),
+		ICalURL:       c.ICalURL,
+		CreatedAt:     c.CreatedAt,
+	}
+}
+
+// ── EventRecord ───────────────────────────────────────────────────────────────
+
+type eventModel struct {
+	Seq     int64     `gorm:"primaryKey;autoIncrement;column:seq"`
+	Type    string    `gorm:"column:type;not null"`
+	Payload string    `gorm:"type:jsonb;column:payload;not null;default:'null'"`
+	At      time.Time `gorm:"column:at;not null"`
+}
+
+func (eventModel) TableName() string { return "event_history" }
+
+func (m *eventModel) toDomain() *domain.EventRecord {
+	return &domain.EventRecord{Seq: m.Seq, Type: m.Type, Payload: json.RawMessage(m.Payload), At: m.At}
+}
+
+func eventFromDomain(e *domain.EventRecord) *eventModel {
+	payload := string(e.Payload)
+	if payload == "" {
+		payload = "null"
+	}
+	return &eventModel{Seq: e.Seq, Type: e.Type, Payload: payload, At: e.At}
+}
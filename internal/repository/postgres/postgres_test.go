@@ -1,6 +1,7 @@
 package postgres_test
 
 import (
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
 	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
 	"github.com/sauravritesh63/GoLang-Project-/internal/repository/postgres"
 )
@@ -8,9 +9,26 @@ import (
 // Compile-time checks that each postgres repo satisfies the corresponding
 // repository interface.
 var (
-	_ repository.WorkflowRepository    = (*postgres.WorkflowRepo)(nil)
-	_ repository.TaskRepository        = (*postgres.TaskRepo)(nil)
-	_ repository.WorkflowRunRepository = (*postgres.WorkflowRunRepo)(nil)
-	_ repository.TaskRunRepository     = (*postgres.TaskRunRepo)(nil)
-	_ repository.WorkerRepository      = (*postgres.WorkerRepo)(nil)
+	_ repository.WorkflowRepository       = (*postgres.WorkflowRepo)(nil)
+	_ repository.TaskRepository           = (*postgres.TaskRepo)(nil)
+	_ repository.TaskDependencyRepository = (*postgres.TaskDependencyRepo)(nil)
+	_ repository.WorkflowRunRepository    = (*postgres.WorkflowRunRepo)(nil)
+	_ repository.TaskRunRepository        = (*postgres.TaskRunRepo)(nil)
+	_ repository.WorkerRepository         = (*postgres.WorkerRepo)(nil)
+	_ repository.ApprovalRepository       = (*postgres.ApprovalRepo)(nil)
+	_ repository.BackfillRepository       = (*postgres.BackfillRepo)(nil)
+	_ repository.CalendarRepository       = (*postgres.CalendarRepo)(nil)
+	_ repository.LineageRepository        = (*postgres.LineageRepo)(nil)
+	_ repository.StatsRepository          = (*postgres.StatsRepo)(nil)
+	_ repository.RetentionRepository      = (*postgres.RetentionRepo)(nil)
+)
+
+// The queue-side repositories implement the top-level domain interfaces.
+var (
+	_ qdomain.TaskRepository      = (*postgres.QueueTaskRepo)(nil)
+	_ qdomain.IdempotencyStore    = (*postgres.QueueTaskRepo)(nil)
+	_ qdomain.WorkerRepository    = (*postgres.WorkerNodeRepo)(nil)
+	_ qdomain.HeartbeatRepository = (*postgres.HeartbeatRepo)(nil)
+	_ qdomain.FreezeRepository    = (*postgres.FreezeRepo)(nil)
+	_ qdomain.SecretStore         = (*postgres.SecretStore)(nil)
 )
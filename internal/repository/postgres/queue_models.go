@@ -0,0 +1,308 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// The models in this file back the queue-side repositories (domain.Task and
+// domain.Worker from the top-level domain package), which the scheduler and
+// worker processes share through the database.
+
+// ── QueueTask ─────────────────────────────────────────────────────────────────
+
+type queueTaskModel struct {
+	ID             string     `gorm:"primaryKey;column:id"`
+	Name           string     `gorm:"column:name;not null"`
+	Payload        []byte     `gorm:"column:payload"`
+	Status         string     `gorm:"column:status;not null"`
+	Priority       int        `gorm:"column:priority;not null"`
+	MaxRetries     int        `gorm:"column:max_retries;not null"`
+	RetryCount     int        `gorm:"column:retry_count;not null"`
+	ScheduledAt    time.Time  `gorm:"column:scheduled_at;not null"`
+	StartedAt      *time.Time `gorm:"column:started_at"`
+	FinishedAt     *time.Time `gorm:"column:finished_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at;not null"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at;not null"`
+	Error          string     `gorm:"column:error;not null"`
+	Type           string     `gorm:"column:type;not null"`
+	Pool           string     `gorm:"column:pool;not null"`
+	ConcurrencyKey string     `gorm:"column:concurrency_key;not null"`
+	WorkflowID     string     `gorm:"column:workflow_id;not null"`
+	Group          string     `gorm:"column:worker_group;not null;default:''"`
+	Retry          *string    `gorm:"type:jsonb;column:retry"`
+	Deferral       *string    `gorm:"type:jsonb;column:deferral"`
+	Env            string     `gorm:"type:jsonb;column:env;not null;default:'{}'"`
+	Hooks          string     `gorm:"type:jsonb;column:hooks;not null;default:'[]'"`
+	WorkerID       string     `gorm:"column:worker_id;not null;default:''"`
+	DispatchedAt   *time.Time `gorm:"column:dispatched_at"`
+	TimeoutSecs    int        `gorm:"column:timeout_seconds;not null;default:0"`
+	PayloadRef     string     `gorm:"column:payload_ref;not null;default:''"`
+	IdempotencyKey string     `gorm:"column:idempotency_key;not null;default:''"`
+	DedupKey       string     `gorm:"column:dedup_key;not null;default:''"`
+	RunID          string     `gorm:"column:run_id;not null;default:''"`
+	RoutingKey     string     `gorm:"column:routing_key;not null;default:''"`
+	Profile        string     `gorm:"column:profile;not null;default:''"`
+	Queue          string     `gorm:"column:queue_name;not null;default:''"`
+	ExpiresAt      *time.Time `gorm:"column:expires_at"`
+}
+
+func (queueTaskModel) TableName() string { return "queue_tasks" }
+
+func (m *queueTaskModel) toDomain() (*qdomain.Task, error) {
+	t := &qdomain.Task{
+		ID:             m.ID,
+		Name:           m.Name,
+		Payload:        m.Payload,
+		Status:         qdomain.TaskStatus(m.Status),
+		Priority:       qdomain.Priority(m.Priority),
+		MaxRetries:     m.MaxRetries,
+		RetryCount:     m.RetryCount,
+		ScheduledAt:    m.ScheduledAt,
+		StartedAt:      m.StartedAt,
+		FinishedAt:     m.FinishedAt,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+		Error:          m.Error,
+		Type:           m.Type,
+		Pool:           m.Pool,
+		ConcurrencyKey: m.ConcurrencyKey,
+		WorkflowID:     m.WorkflowID,
+		Group:          m.Group,
+		WorkerID:       m.WorkerID,
+		DispatchedAt:   m.DispatchedAt,
+		Timeout:        time.Duration(m.TimeoutSecs) * time.Second,
+		PayloadRef:     m.PayloadRef,
+		IdempotencyKey: m.IdempotencyKey,
+		DedupKey:       m.DedupKey,
+		RunID:          m.RunID,
+		RoutingKey:     m.RoutingKey,
+		Profile:        m.Profile,
+		Queue:          m.Queue,
+		ExpiresAt:      m.ExpiresAt,
+	}
+	if m.Retry != nil {
+		t.Retry = &qdomain.RetryPolicy{}
+		if err := json.Unmarshal([]byte(*m.Retry), t.Retry); err != nil {
+			return nil, fmt.Errorf("queue_task %s: invalid retry: %w", m.ID, err)
+		}
+	}
+	if m.Deferral != nil {
+		t.Deferral = &qdomain.Deferral{}
+		if err := json.Unmarshal([]byte(*m.Deferral), t.Deferral); err != nil {
+			return nil, fmt.Errorf("queue_task %s: invalid deferral: %w", m.ID, err)
+		}
+	}
+	if err := decodeMap(m.Env, &t.Env); err != nil {
+		return nil, fmt.Errorf("queue_task %s: invalid env: %w", m.ID, err)
+	}
+	if err := decodeList(m.Hooks, &t.Hooks); err != nil {
+		return nil, fmt.Errorf("queue_task %s: invalid hooks: %w", m.ID, err)
+	}
+	return t, nil
+}
+
+func queueTaskFromDomain(t *qdomain.Task) (*queueTaskModel, error) {
+	m := &queueTaskModel{
+		ID:             t.ID,
+		Name:           t.Name,
+		Payload:        t.Payload,
+		Status:         string(t.Status),
+		Priority:       int(t.Priority),
+		MaxRetries:     t.MaxRetries,
+		RetryCount:     t.RetryCount,
+		ScheduledAt:    t.ScheduledAt,
+		StartedAt:      t.StartedAt,
+		FinishedAt:     t.FinishedAt,
+		CreatedAt:      t.CreatedAt,
+		UpdatedAt:      t.UpdatedAt,
+		Error:          t.Error,
+		Type:           t.Type,
+		Pool:           t.Pool,
+		ConcurrencyKey: t.ConcurrencyKey,
+		WorkflowID:     t.WorkflowID,
+		Group:          t.Group,
+		Env:            encodeMap(t.Env),
+		Hooks:          encodeList(t.Hooks),
+		WorkerID:       t.WorkerID,
+		DispatchedAt:   t.DispatchedAt,
+		TimeoutSecs:    int(t.Timeout / time.Second),
+		PayloadRef:     t.PayloadRef,
+		IdempotencyKey: t.IdempotencyKey,
+		DedupKey:       t.DedupKey,
+		RunID:          t.RunID,
+		RoutingKey:     t.RoutingKey,
+		Profile:        t.Profile,
+		Queue:          t.Queue,
+		ExpiresAt:      t.ExpiresAt,
+	}
+	var err error
+	if m.Retry, err = jsonColumn(t.Retry, t.Retry == nil); err != nil {
+		return nil, fmt.Errorf("queue_task %s: encode retry: %w", t.ID, err)
+	}
+	if m.Deferral, err = jsonColumn(t.Deferral, t.Deferral == nil); err != nil {
+		return nil, fmt.Errorf("queue_task %s: encode deferral: %w", t.ID, err)
+	}
+	return m, nil
+}
+
+// jsonColumn encodes v for a nullable jsonb column; isNil maps to NULL.
+func jsonColumn(v any, isNil bool) (*string, error) {
+	if isNil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// ── WorkerNode ────────────────────────────────────────────────────────────────
+
+type workerNodeModel struct {
+	ID            string     `gorm:"primaryKey;column:id"`
+	Address       string     `gorm:"column:address;not null"`
+	Status        string     `gorm:"column:status;not null"`
+	Concurrency   int        `gorm:"column:concurrency;not null"`
+	ActiveTasks   int        `gorm:"column:active_tasks;not null"`
+	LastHeartAt   time.Time  `gorm:"column:last_heart_at;not null"`
+	RegisteredAt  time.Time  `gorm:"column:registered_at;not null"`
+	Labels        string     `gorm:"type:jsonb;column:labels;not null;default:'{}'"`
+	Queues        string     `gorm:"type:jsonb;column:queues;not null;default:'[]'"`
+	FailureRate   float64    `gorm:"column:failure_rate;not null;default:0"`
+	AvgLatencyMS  int64      `gorm:"column:avg_latency_ms;not null;default:0"`
+	DeactivatedAt *time.Time `gorm:"column:deactivated_at"`
+}
+
+func (workerNodeModel) TableName() string { return "worker_nodes" }
+
+func (m *workerNodeModel) toDomain() (*qdomain.Worker, error) {
+	w := &qdomain.Worker{
+		ID:            m.ID,
+		Address:       m.Address,
+		Status:        qdomain.WorkerStatus(m.Status),
+		Concurrency:   m.Concurrency,
+		ActiveTasks:   m.ActiveTasks,
+		LastHeartAt:   m.LastHeartAt,
+		RegisteredAt:  m.RegisteredAt,
+		FailureRate:   m.FailureRate,
+		AvgLatency:    time.Duration(m.AvgLatencyMS) * time.Millisecond,
+		DeactivatedAt: m.DeactivatedAt,
+	}
+	if err := decodeMap(m.Labels, &w.Labels); err != nil {
+		return nil, fmt.Errorf("worker_node %s: invalid labels: %w", m.ID, err)
+	}
+	if err := decodeList(m.Queues, &w.Queues); err != nil {
+		return nil, fmt.Errorf("worker_node %s: invalid queues: %w", m.ID, err)
+	}
+	return w, nil
+}
+
+func workerNodeFromDomain(w *qdomain.Worker) *workerNodeModel {
+	return &workerNodeModel{
+		ID:            w.ID,
+		Address:       w.Address,
+		Status:        string(w.Status),
+		Concurrency:   w.Concurrency,
+		ActiveTasks:   w.ActiveTasks,
+		LastHeartAt:   w.LastHeartAt,
+		RegisteredAt:  w.RegisteredAt,
+		Labels:        encodeMap(w.Labels),
+		Queues:        encodeList(w.Queues),
+		FailureRate:   w.FailureRate,
+		AvgLatencyMS:  w.AvgLatency.Milliseconds(),
+		DeactivatedAt: w.DeactivatedAt,
+	}
+}
+
+// ── WorkerHeartbeat ───────────────────────────────────────────────────────────
+
+type workerHeartbeatModel struct {
+	ID          string    `gorm:"type:uuid;primaryKey;column:id"`
+	WorkerID    string    `gorm:"column:worker_id;not null"`
+	At          time.Time `gorm:"column:at;not null"`
+	Status      string    `gorm:"column:status;not null"`
+	ActiveTasks int       `gorm:"column:active_tasks;not null"`
+}
+
+func (workerHeartbeatModel) TableName() string { return "worker_heartbeats" }
+
+func (m *workerHeartbeatModel) toDomain() qdomain.Heartbeat {
+	return qdomain.Heartbeat{
+		WorkerID:    m.WorkerID,
+		At:          m.At,
+		Status:      qdomain.WorkerStatus(m.Status),
+		ActiveTasks: m.ActiveTasks,
+	}
+}
+
+// ── TaskAttempt ───────────────────────────────────────────────────────────────
+
+type taskAttemptModel struct {
+	TaskID     string    `gorm:"primaryKey;column:task_id"`
+	Number     int       `gorm:"primaryKey;column:number"`
+	Status     string    `gorm:"column:status;not null"`
+	WorkerID   string    `gorm:"column:worker_id;not null"`
+	StartedAt  time.Time `gorm:"column:started_at;not null"`
+	FinishedAt time.Time `gorm:"column:finished_at;not null"`
+	Error      string    `gorm:"column:error;not null"`
+	Logs       string    `gorm:"column:logs;not null"`
+}
+
+func (taskAttemptModel) TableName() string { return "task_attempts" }
+
+func (m *taskAttemptModel) toDomain() *qdomain.Attempt {
+	return &qdomain.Attempt{
+		TaskID:     m.TaskID,
+		Number:     m.Number,
+		Status:     qdomain.TaskStatus(m.Status),
+		WorkerID:   m.WorkerID,
+		StartedAt:  m.StartedAt,
+		FinishedAt: m.FinishedAt,
+		Error:      m.Error,
+		Logs:       m.Logs,
+	}
+}
+
+func taskAttemptFromDomain(a *qdomain.Attempt) *taskAttemptModel {
+	return &taskAttemptModel{
+		TaskID:     a.TaskID,
+		Number:     a.Number,
+		Status:     string(a.Status),
+		WorkerID:   a.WorkerID,
+		StartedAt:  a.StartedAt,
+		FinishedAt: a.FinishedAt,
+		Error:      a.Error,
+		Logs:       a.Logs,
+	}
+}
+
+// ── DispatchFreeze ────────────────────────────────────────────────────────────
+
+// dispatchFreezeID is the primary key of the single dispatch_freeze row.
+const dispatchFreezeID = 1
+
+type dispatchFreezeModel struct {
+	ID     int       `gorm:"primaryKey;column:id"`
+	Frozen bool      `gorm:"column:frozen;not null"`
+	Reason string    `gorm:"column:reason;not null;default:''"`
+	By     string    `gorm:"column:changed_by;not null;default:''"`
+	At     time.Time `gorm:"column:changed_at;not null"`
+}
+
+func (dispatchFreezeModel) TableName() string { return "dispatch_freeze" }
+
+// ── Secret ────────────────────────────────────────────────────────────────────
+
+type secretModel struct {
+	Name      string    `gorm:"primaryKey;column:name"`
+	Value     string    `gorm:"column:value;not null"`
+	UpdatedAt time.Time `gorm:"column:updated_at;not null"`
+}
+
+func (secretModel) TableName() string { return "secrets" }
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// QueueTaskRepo is a GORM-backed implementation of domain.TaskRepository.
+// It holds the execution state of dispatched tasks so the scheduler and the
+// workers see the same task records.
+type QueueTaskRepo struct {
+	db *gorm.DB
+}
+
+// NewQueueTaskRepo constructs a QueueTaskRepo with the supplied *gorm.DB.
+func NewQueueTaskRepo(db *gorm.DB) *QueueTaskRepo {
+	return &QueueTaskRepo{db: db}
+}
+
+// Save creates t or updates every column of it but its idempotency and
+// dedup keys, which only ClaimKey writes: a created task holds no keys, so
+// reusing a key without an idempotency window never trips the unique
+// indexes.
+func (r *QueueTaskRepo) Save(ctx context.Context, t *qdomain.Task) error {
+	m, err := queueTaskFromDomain(t)
+	if err != nil {
+		return err
+	}
+	m.IdempotencyKey, m.DedupKey = "", ""
+	cols, err := queueTaskUpdateColumns()
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns(cols),
+		}).
+		Create(m).Error
+}
+
+// ClaimKey saves t as the holder of its idempotency and dedup keys; see
+// domain.IdempotencyStore. Claims of one key are serialised by a
+// transaction-scoped advisory lock on it, taken in a fixed order so two
+// claims cannot deadlock, and the unique indexes on the columns back them
+// up.
+func (r *QueueTaskRepo) ClaimKey(ctx context.Context, t *qdomain.Task, since time.Time) (*qdomain.Task, error) {
+	m, err := queueTaskFromDomain(t)
+	if err != nil {
+		return nil, err
+	}
+	var holder *qdomain.Task
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, k := range []struct{ column, key string }{
+			{"dedup_key", t.DedupKey},
+			{"idempotency_key", t.IdempotencyKey},
+		} {
+			if k.key == "" {
+				continue
+			}
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", k.column+"/"+k.key).Error; err != nil {
+				return err
+			}
+			var held queueTaskModel
+			err := tx.Where(k.column+" = ? AND id <> ?", k.key, t.ID).First(&held).Error
+			switch {
+			case err == nil && held.CreatedAt.After(since):
+				if holder, err = held.toDomain(); err != nil {
+					return err
+				}
+				return qdomain.ErrDuplicateTask
+			case err == nil:
+				// The claim has lapsed: take the key over.
+				if err := tx.Model(&queueTaskModel{}).Where("id = ?", held.ID).Update(k.column, "").Error; err != nil {
+					return err
+				}
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(m).Error
+	})
+	return holder, err
+}
+
+var (
+	updateColumnsOnce sync.Once
+	updateColumns     []string
+	updateColumnsErr  error
+)
+
+// queueTaskUpdateColumns returns the queue_tasks columns Save overwrites.
+func queueTaskUpdateColumns() ([]string, error) {
+	updateColumnsOnce.Do(func() {
+		s, err := schema.Parse(&queueTaskModel{}, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			updateColumnsErr = fmt.Errorf("queue task columns: %w", err)
+			return
+		}
+		for _, f := range s.Fields {
+			switch f.DBName {
+			case "", "id", "idempotency_key", "dedup_key":
+			default:
+				updateColumns = append(updateColumns, f.DBName)
+			}
+		}
+	})
+	return updateColumns, updateColumnsErr
+}
+
+func (r *QueueTaskRepo) FindByID(ctx context.Context, id string) (*qdomain.Task, error) {
+	var m queueTaskModel
+	err := r.db.WithContext(ctx).First(&m, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, qdomain.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.toDomain()
+}
+
+func (r *QueueTaskRepo) FindByStatus(ctx context.Context, status qdomain.TaskStatus) ([]*qdomain.Task, error) {
+	var models []queueTaskModel
+	if err := r.db.WithContext(ctx).
+		Where("status = ?", string(status)).
+		Order("priority DESC, scheduled_at ASC").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*qdomain.Task, len(models))
+	for i := range models {
+		t, err := models[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = t
+	}
+	return out, nil
+}
+
+func (r *QueueTaskRepo) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Delete(&queueTaskModel{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return qdomain.ErrTaskNotFound
+	}
+	return nil
+}
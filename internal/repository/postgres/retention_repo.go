@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+	"gorm.io/gorm"
+)
+
+// RetentionRepo is a GORM-backed implementation of
+// repository.RetentionRepository. Task runs, approvals and lineage edges of
+// a purged run are removed by the ON DELETE CASCADE foreign keys.
+type RetentionRepo struct {
+	db *gorm.DB
+}
+
+// NewRetentionRepo constructs a RetentionRepo with the supplied *gorm.DB.
+func NewRetentionRepo(db *gorm.DB) *RetentionRepo {
+	return &RetentionRepo{db: db}
+}
+
+func (r *RetentionRepo) PurgeRuns(ctx context.Context, f repository.RunPurgeFilter, dryRun bool) (domain.PurgeCounts, error) {
+	var counts domain.PurgeCounts
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// ids selects the IDs of the runs to purge; each call builds a fresh
+		// subquery.
+		ids := func() *gorm.DB {
+			matching := purgeScope(tx, f)
+			if f.KeepNewest <= 0 {
+				return matching.Select("id")
+			}
+			ranked := matching.Select("id, ROW_NUMBER() OVER (PARTITION BY workflow_id ORDER BY started_at DESC) AS rn")
+			return tx.Table("(?) AS ranked", ranked).Select("id").Where("rn > ?", f.KeepNewest)
+		}
+		if err := tx.Model(&workflowRunModel{}).Where("id IN (?)", ids()).Count(&counts.WorkflowRuns).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&taskRunModel{}).Where("workflow_run_id IN (?)", ids()).Count(&counts.TaskRuns).Error; err != nil {
+			return err
+		}
+		if dryRun || counts.WorkflowRuns == 0 {
+			return nil
+		}
+		return tx.Where("id IN (?)", ids()).Delete(&workflowRunModel{}).Error
+	})
+	return counts, err
+}
+
+// purgeScope selects the workflow runs matching the Statuses, WorkflowID and
+// Before conditions of f.
+func purgeScope(tx *gorm.DB, f repository.RunPurgeFilter) *gorm.DB {
+	statuses := make([]string, len(f.Statuses))
+	for i, s := range f.Statuses {
+		statuses[i] = string(s)
+	}
+	q := tx.Model(&workflowRunModel{}).Where("status IN ?", statuses)
+	if f.WorkflowID != uuid.Nil {
+		q = q.Where("workflow_id = ?", f.WorkflowID.String())
+	}
+	if !f.Before.IsZero() {
+		q = q.Where("started_at < ?", f.Before)
+	}
+	return q
+}
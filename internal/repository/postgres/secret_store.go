@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SecretStore is a GORM-backed implementation of domain.SecretStore.
+type SecretStore struct {
+	db *gorm.DB
+}
+
+// NewSecretStore constructs a SecretStore with the supplied *gorm.DB.
+func NewSecretStore(db *gorm.DB) *SecretStore {
+	return &SecretStore{db: db}
+}
+
+func (s *SecretStore) Get(ctx context.Context, name string) (string, error) {
+	var m secretModel
+	err := s.db.WithContext(ctx).First(&m, "name = ?", name).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", qdomain.ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return m.Value, nil
+}
+
+func (s *SecretStore) Put(ctx context.Context, name, value string) error {
+	m := &secretModel{Name: name, Value: value, UpdatedAt: time.Now().UTC()}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(m).Error
+}
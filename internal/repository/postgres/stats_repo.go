@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"gorm.io/gorm"
+)
+
+// runDuration is the duration of a finished run in seconds.
+const runDuration = "EXTRACT(EPOCH FROM finished_at - started_at)"
+
+// StatsRepo is a GORM-backed implementation of repository.StatsRepository.
+// Every aggregate is computed by PostgreSQL; no rows are loaded into memory.
+type StatsRepo struct {
+	db *gorm.DB
+}
+
+// NewStatsRepo constructs a StatsRepo with the supplied *gorm.DB.
+func NewStatsRepo(db *gorm.DB) *StatsRepo {
+	return &StatsRepo{db: db}
+}
+
+func (r *StatsRepo) RunDurationPercentiles(ctx context.Context, workflowID uuid.UUID, since time.Time) (*domain.DurationPercentiles, error) {
+	var row struct {
+		Runs int
+		P50  *float64
+		P95  *float64
+		P99  *float64
+	}
+	err := r.db.WithContext(ctx).
+		Table("workflow_runs").
+		Select(`COUNT(*) AS runs,
+			percentile_cont(0.50) WITHIN GROUP (ORDER BY `+runDuration+`) AS p50,
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY `+runDuration+`) AS p95,
+			percentile_cont(0.99) WITHIN GROUP (ORDER BY `+runDuration+`) AS p99`).
+		Where("workflow_id = ? AND finished_at IS NOT NULL AND started_at >= ?", workflowID.String(), since).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	return &domain.DurationPercentiles{
+		Runs: row.Runs,
+		P50:  deref(row.P50),
+		P95:  deref(row.P95),
+		P99:  deref(row.P99),
+	}, nil
+}
+
+func (r *StatsRepo) TaskDurationAverages(ctx context.Context, workflowID uuid.UUID, since time.Time) ([]domain.TaskDurationStat, error) {
+	var rows []struct {
+		TaskID     string
+		TaskName   string
+		Runs       int
+		AvgSeconds float64
+	}
+	err := r.db.WithContext(ctx).
+		Table("task_runs").
+		Select(`task_runs.task_id AS task_id, tasks.name AS task_name, COUNT(*) AS runs,
+			AVG(EXTRACT(EPOCH FROM task_runs.finished_at - task_runs.started_at)) AS avg_seconds`).
+		Joins("JOIN tasks ON tasks.id = task_runs.task_id").
+		Joins("JOIN workflow_runs ON workflow_runs.id = task_runs.workflow_run_id").
+		Where("workflow_runs.workflow_id = ? AND workflow_runs.started_at >= ?", workflowID.String(), since).
+		Where("task_runs.finished_at IS NOT NULL AND task_runs.status <> ?", string(domain.StatusSkipped)).
+		Group("task_runs.task_id, tasks.name").
+		Order("tasks.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.TaskDurationStat, len(rows))
+	for i, row := range rows {
+		id, err := uuid.Parse(row.TaskID)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = domain.TaskDurationStat{TaskID: id, TaskName: row.TaskName, Runs: row.Runs, AvgSeconds: row.AvgSeconds}
+	}
+	return out, nil
+}
+
+func (r *StatsRepo) RunDurationTrend(ctx context.Context, workflowID uuid.UUID, since time.Time, bucket time.Duration) ([]domain.DurationBucket, error) {
+	width := int64(bucket / time.Second)
+	var rows []struct {
+		Start      time.Time
+		Runs       int
+		Failed     int
+		AvgSeconds float64
+		P95Seconds float64
+	}
+	err := r.db.WithContext(ctx).
+		Table("workflow_runs").
+		Select(`to_timestamp(floor(EXTRACT(EPOCH FROM started_at) / ?) * ?) AS start,
+			COUNT(*) AS runs,
+			COUNT(*) FILTER (WHERE status = ?) AS failed,
+			AVG(`+runDuration+`) AS avg_seconds,
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY `+runDuration+`) AS p95_seconds`,
+			width, width, string(domain.StatusFailed)).
+		Where("workflow_id = ? AND finished_at IS NOT NULL AND started_at >= ?", workflowID.String(), since).
+		Group("1").
+		Order("1").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.DurationBucket, len(rows))
+	for i, row := range rows {
+		out[i] = domain.DurationBucket{
+			Start:      row.Start.UTC(),
+			Runs:       row.Runs,
+			Failed:     row.Failed,
+			AvgSeconds: row.AvgSeconds,
+			P95Seconds: row.P95Seconds,
+		}
+	}
+	return out, nil
+}
+
+func (r *StatsRepo) DailyUsage(ctx context.Context, from, to time.Time) ([]domain.TaskUsage, error) {
+	var rows []struct {
+		Date             string
+		WorkflowID       string
+		ResourceClass    string
+		TaskRuns         int
+		ExecutionSeconds float64
+	}
+	err := r.db.WithContext(ctx).
+		Table("task_runs").
+		Select(`to_char(task_runs.dispatched_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS date,
+			workflow_runs.workflow_id AS workflow_id, tasks.queue_name AS resource_class,
+			COUNT(*) AS task_runs,
+			SUM(EXTRACT(EPOCH FROM task_runs.finished_at - task_runs.dispatched_at)) AS execution_seconds`).
+		Joins("JOIN tasks ON tasks.id = task_runs.task_id").
+		Joins("JOIN workflow_runs ON workflow_runs.id = task_runs.workflow_run_id").
+		Where("task_runs.dispatched_at >= ? AND task_runs.dispatched_at < ? AND task_runs.finished_at IS NOT NULL", from, to).
+		Group("1, 2, 3").
+		Order("1, 2, 3").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.TaskUsage, len(rows))
+	for i, row := range rows {
+		id, err := uuid.Parse(row.WorkflowID)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = domain.TaskUsage{
+			Date:             row.Date,
+			WorkflowID:       id,
+			ResourceClass:    row.ResourceClass,
+			TaskRuns:         row.TaskRuns,
+			ExecutionSeconds: row.ExecutionSeconds,
+		}
+	}
+	return out, nil
+}
+
+// deref returns *f, or 0 when f is nil (an aggregate over no rows).
+func deref(f *float64) float64 {
+	if f == nil {
+		return 0
+	}
+	return *f
+}
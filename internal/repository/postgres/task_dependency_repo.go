@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"gorm.io/gorm"
+)
+
+// TaskDependencyRepo is a GORM-backed implementation of repository.TaskDependencyRepository.
+type TaskDependencyRepo struct {
+	db *gorm.DB
+}
+
+// NewTaskDependencyRepo constructs a TaskDependencyRepo with the supplied *gorm.DB.
+func NewTaskDependencyRepo(db *gorm.DB) *TaskDependencyRepo {
+	return &TaskDependencyRepo{db: db}
+}
+
+func (r *TaskDependencyRepo) Create(ctx context.Context, d *domain.TaskDependency) error {
+	return r.db.WithContext(ctx).Create(taskDependencyFromDomain(d)).Error
+}
+
+func (r *TaskDependencyRepo) ListByWorkflowID(ctx context.Context, workflowID uuid.UUID) ([]*domain.TaskDependency, error) {
+	var models []taskDependencyModel
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN tasks ON tasks.id = task_dependencies.task_id").
+		Where("tasks.workflow_id = ?", workflowID.String()).
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*domain.TaskDependency, len(models))
+	for i := range models {
+		d, err := models[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = d
+	}
+	return out, nil
+}
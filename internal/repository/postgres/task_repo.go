@@ -40,6 +40,9 @@ func (r *TaskRepo) Update(ctx context.Context, t *domain.Task) error {
 	result := r.db.WithContext(ctx).
 		Model(&taskModel{}).
 		Where("id = ?", t.ID.String()).
+		// Select every column so zero values, e.g. is_active=false, are
+		// written too; plain Updates skips them.
+		Select("*").Omit("ID", "CreatedAt").
 		Updates(taskFromDomain(t))
 	if result.Error != nil {
 		return result.Error
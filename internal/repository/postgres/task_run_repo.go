@@ -111,3 +111,48 @@ func (r *TaskRunRepo) ListByStatus(ctx context.Context, status domain.Status) ([
 	}
 	return out, nil
 }
+
+func (r *TaskRunRepo) AppendLogs(ctx context.Context, id uuid.UUID, chunk string) error {
+	result := r.db.WithContext(ctx).
+		Model(&taskRunModel{}).
+		Where("id = ?", id.String()).
+		Update("logs", gorm.Expr("logs || ?", chunk))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *TaskRunRepo) UpdateAssignment(ctx context.Context, id uuid.UUID, workerID string, dispatchedAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&taskRunModel{}).
+		Where("id = ?", id.String()).
+		Updates(map[string]interface{}{
+			"assigned_worker_id": workerID,
+			"dispatched_at":      dispatchedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *TaskRunRepo) UpdateQueueTask(ctx context.Context, id uuid.UUID, queueTaskID string) error {
+	result := r.db.WithContext(ctx).
+		Model(&taskRunModel{}).
+		Where("id = ?", id.String()).
+		Update("queue_task_id", queueTaskID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
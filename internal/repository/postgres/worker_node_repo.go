@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WorkerNodeRepo is a GORM-backed implementation of domain.WorkerRepository,
+// the registry of worker processes that pull from the queue.
+type WorkerNodeRepo struct {
+	db *gorm.DB
+}
+
+// NewWorkerNodeRepo constructs a WorkerNodeRepo with the supplied *gorm.DB.
+func NewWorkerNodeRepo(db *gorm.DB) *WorkerNodeRepo {
+	return &WorkerNodeRepo{db: db}
+}
+
+func (r *WorkerNodeRepo) Save(ctx context.Context, w *qdomain.Worker) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(workerNodeFromDomain(w)).Error
+}
+
+func (r *WorkerNodeRepo) FindByID(ctx context.Context, id string) (*qdomain.Worker, error) {
+	var m workerNodeModel
+	err := r.db.WithContext(ctx).First(&m, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, qdomain.ErrWorkerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.toDomain()
+}
+
+// FindAvailable filters and orders in a single query, so placement needs no
+// scan of the whole worker table. The order matches domain.SortByLoad.
+func (r *WorkerNodeRepo) FindAvailable(ctx context.Context, f qdomain.WorkerFilter) ([]*qdomain.Worker, error) {
+	q := r.db.WithContext(ctx)
+	if f.IgnoreCapacity {
+		q = q.Where("status IN ?", []string{string(qdomain.WorkerStatusIdle), string(qdomain.WorkerStatusBusy)})
+	} else {
+		q = q.Where("(status = ? OR (status = ? AND active_tasks < concurrency))",
+			string(qdomain.WorkerStatusIdle), string(qdomain.WorkerStatusBusy))
+	}
+	if f.MinFreeSlots > 0 && !f.IgnoreCapacity {
+		q = q.Where("concurrency - active_tasks >= ?", f.MinFreeSlots)
+	}
+	if !f.HeartbeatSince.IsZero() {
+		q = q.Where("last_heart_at >= ?", f.HeartbeatSince)
+	}
+	if len(f.Labels) > 0 {
+		q = q.Where("labels @> ?::jsonb", encodeMap(f.Labels))
+	}
+	if f.Queue != "" {
+		q = q.Where("(queues = '[]'::jsonb OR queues @> ?::jsonb)", encodeList([]string{f.Queue}))
+	}
+	var models []workerNodeModel
+	order := fmt.Sprintf("failure_rate >= %g, active_tasks::float8 / GREATEST(concurrency, 1), id", qdomain.UnhealthyFailureRate)
+	if err := q.Order(order).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*qdomain.Worker, len(models))
+	for i := range models {
+		w, err := models[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = w
+	}
+	return out, nil
+}
+
+func (r *WorkerNodeRepo) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Delete(&workerNodeModel{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return qdomain.ErrWorkerNotFound
+	}
+	return nil
+}
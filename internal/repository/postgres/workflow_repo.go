@@ -40,6 +40,9 @@ func (r *WorkflowRepo) Update(ctx context.Context, wf *domain.Workflow) error {
 	result := r.db.WithContext(ctx).
 		Model(&workflowModel{}).
 		Where("id = ?", wf.ID.String()).
+		// Select every column so zero values, e.g. is_active=false, are
+		// written too; plain Updates skips them.
+		Select("*").Omit("ID", "CreatedAt").
 		Updates(workflowFromDomain(wf))
 	if result.Error != nil {
 		return result.Error
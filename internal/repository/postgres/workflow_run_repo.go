@@ -21,8 +21,44 @@ func NewWorkflowRunRepo(db *gorm.DB) *WorkflowRunRepo {
 	return &WorkflowRunRepo{db: db}
 }
 
+// Create inserts wr. A run with an idempotency key is created under a
+// transaction-scoped advisory lock on its workflow and key, so of two
+// concurrent runs with the same key one gets ErrConflict; the unique index
+// on the key backs this up.
 func (r *WorkflowRunRepo) Create(ctx context.Context, wr *domain.WorkflowRun) error {
-	return r.db.WithContext(ctx).Create(workflowRunFromDomain(wr)).Error
+	if wr.IdempotencyKey == "" {
+		return r.db.WithContext(ctx).Create(workflowRunFromDomain(wr)).Error
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		lock := wr.WorkflowID.String() + "/" + wr.IdempotencyKey
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", lock).Error; err != nil {
+			return err
+		}
+		var n int64
+		if err := tx.Model(&workflowRunModel{}).
+			Where("workflow_id = ? AND idempotency_key = ?", wr.WorkflowID.String(), wr.IdempotencyKey).
+			Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return repository.ErrConflict
+		}
+		return tx.Create(workflowRunFromDomain(wr)).Error
+	})
+}
+
+func (r *WorkflowRunRepo) FindByIdempotencyKey(ctx context.Context, workflowID uuid.UUID, key string) (*domain.WorkflowRun, error) {
+	var m workflowRunModel
+	err := r.db.WithContext(ctx).
+		Where("workflow_id = ? AND idempotency_key = ? AND idempotency_key <> ''", workflowID.String(), key).
+		First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.toDomain()
 }
 
 func (r *WorkflowRunRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.WorkflowRun, error) {
@@ -55,6 +91,23 @@ func (r *WorkflowRunRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status
 	return nil
 }
 
+func (r *WorkflowRunRepo) UpdateProgress(ctx context.Context, id uuid.UUID, p domain.RunProgress) error {
+	result := r.db.WithContext(ctx).
+		Model(&workflowRunModel{}).
+		Where("id = ?", id.String()).
+		Updates(map[string]interface{}{
+			"tasks_completed": p.Completed,
+			"tasks_total":     p.Total,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
 func (r *WorkflowRunRepo) ListByWorkflowID(ctx context.Context, workflowID uuid.UUID) ([]*domain.WorkflowRun, error) {
 	var models []workflowRunModel
 	if err := r.db.WithContext(ctx).
@@ -74,6 +127,41 @@ func (r *WorkflowRunRepo) ListByWorkflowID(ctx context.Context, workflowID uuid.
 	return out, nil
 }
 
+func (r *WorkflowRunRepo) FindByWorkflowID(ctx context.Context, workflowID uuid.UUID, f repository.WorkflowRunFilter) ([]*domain.WorkflowRun, error) {
+	q := r.db.WithContext(ctx).Where("workflow_id = ?", workflowID.String())
+	if f.Status != "" {
+		q = q.Where("status = ?", string(f.Status))
+	}
+	if !f.StartedFrom.IsZero() {
+		q = q.Where("started_at >= ?", f.StartedFrom)
+	}
+	if !f.StartedTo.IsZero() {
+		q = q.Where("started_at < ?", f.StartedTo)
+	}
+	if len(f.Labels) > 0 {
+		q = q.Where("labels @> ?::jsonb", encodeMap(f.Labels))
+	}
+	if f.Offset > 0 {
+		q = q.Offset(f.Offset)
+	}
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var models []workflowRunModel
+	if err := q.Order("started_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	out := make([]*domain.WorkflowRun, len(models))
+	for i := range models {
+		wr, err := models[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = wr
+	}
+	return out, nil
+}
+
 func (r *WorkflowRunRepo) ListByStatus(ctx context.Context, status domain.Status) ([]*domain.WorkflowRun, error) {
 	var models []workflowRunModel
 	if err := r.db.WithContext(ctx).
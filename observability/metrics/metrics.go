@@ -11,13 +11,27 @@
 //	scheduler_workflow_successes_total  – total workflow run successes
 //	scheduler_worker_heartbeats_total   – total worker heartbeat ticks (labels: worker_id)
 //	scheduler_task_retries_total        – total task retry attempts   (labels: worker_id)
+//	scheduler_attempt_queue_wait_seconds – time a task attempt waited on the queue once due (labels: attempt, status)
+//	scheduler_attempt_duration_seconds  – task attempt execution duration (labels: attempt, status)
+//	scheduler_stale_runs_total          – stale workflow runs repaired by the run janitor (labels: action)
+//	scheduler_dispatch_paused           – 1 while the scheduler's dispatching is paused, else 0
 package metrics
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// MaxAttemptLabel is the attempt number from which attempts share one
+// label, "10+".
+const MaxAttemptLabel = 10
+
+// attemptBuckets span sub-second dispatches to hour-long waits and runs.
+var attemptBuckets = []float64{0.05, 0.25, 1, 5, 15, 60, 300, 900, 3600}
+
 // Collector groups all Prometheus metrics exposed by the scheduler system.
 type Collector struct {
 	WorkflowsTotal   *prometheus.CounterVec
@@ -27,6 +41,10 @@ type Collector struct {
 	WorkflowSuccesses prometheus.Counter
 	WorkerHeartbeats *prometheus.CounterVec
 	TaskRetries      *prometheus.CounterVec
+	AttemptQueueWait *prometheus.HistogramVec
+	AttemptDuration  *prometheus.HistogramVec
+	StaleRuns        *prometheus.CounterVec
+	DispatchPaused   prometheus.Gauge
 }
 
 // New registers and returns all scheduler Prometheus metrics using promauto so
@@ -68,5 +86,62 @@ func New() *Collector {
 			Name: "scheduler_task_retries_total",
 			Help: "Total number of task retry attempts.",
 		}, []string{"worker_id"}),
+
+		AttemptQueueWait: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "scheduler_attempt_queue_wait_seconds",
+			Help:    "Histogram of how long task attempts waited on the queue once due, in seconds.",
+			Buckets: attemptBuckets,
+		}, []string{"attempt", "status"}),
+
+		AttemptDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "scheduler_attempt_duration_seconds",
+			Help:    "Histogram of task attempt execution durations in seconds.",
+			Buckets: attemptBuckets,
+		}, []string{"attempt", "status"}),
+
+		StaleRuns: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "scheduler_stale_runs_total",
+			Help: "Total number of stale workflow runs repaired, by the action taken.",
+		}, []string{"action"}),
+
+		DispatchPaused: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "scheduler_dispatch_paused",
+			Help: "1 while the scheduler's dispatching is paused, else 0.",
+		}),
+	}
+}
+
+// ObserveAttempt records the queue wait and execution duration of attempt
+// number of a task, which ended in status. It lets a worker report to the
+// Collector through worker.WithAttemptObserver.
+func (c *Collector) ObserveAttempt(number int, status string, wait, run time.Duration) {
+	attempt := AttemptLabel(number)
+	c.AttemptQueueWait.WithLabelValues(attempt, status).Observe(wait.Seconds())
+	c.AttemptDuration.WithLabelValues(attempt, status).Observe(run.Seconds())
+}
+
+// ObserveStaleRun counts a stale workflow run repaired with action,
+// "resubmitted" or "failed". It lets the run janitor report to the Collector
+// through scheduler.WithStaleRunObserver.
+func (c *Collector) ObserveStaleRun(action string) {
+	c.StaleRuns.WithLabelValues(action).Inc()
+}
+
+// ObserveDispatchPaused sets the dispatch paused gauge. It lets a
+// scheduler report to the Collector through scheduler.WithPauseObserver.
+func (c *Collector) ObserveDispatchPaused(paused bool) {
+	if paused {
+		c.DispatchPaused.Set(1)
+	} else {
+		c.DispatchPaused.Set(0)
+	}
+}
+
+// AttemptLabel returns the attempt label for attempt number n, capping it
+// at MaxAttemptLabel so retries cannot grow the label set without bound.
+func AttemptLabel(n int) string {
+	if n >= MaxAttemptLabel {
+		return strconv.Itoa(MaxAttemptLabel) + "+"
 	}
+	return strconv.Itoa(max(n, 1))
 }
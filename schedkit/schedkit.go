@@ -0,0 +1,711 @@
+// Package schedkit embeds the workflow scheduler in a Go application. An
+// Engine runs the cron and dataset triggers, the backfiller, the retention
+// job, the orchestrator, the worker reaper, the dispatching scheduler and a
+// worker as goroutines of the calling process, on repositories and task
+// handlers the application supplies. Nothing listens on a port; workflows
+// are defined and triggered through the Engine's methods or its Service.
+//
+// The cmd binaries are built from the same Engine: the scheduler binary runs
+// one WithoutWorker, the worker binary one WithoutScheduler.
+package schedkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/chaos"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/api/service"
+	"github.com/sauravritesh63/GoLang-Project-/internal/backend"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/queue"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+	"github.com/sauravritesh63/GoLang-Project-/worker"
+)
+
+// The types below are defined in internal packages; the aliases let code
+// outside this module name them.
+type (
+	// Stores groups every repository the Engine reads and writes.
+	Stores = backend.Stores
+	// Queue carries dispatched tasks from the scheduler to the worker.
+	Queue = qdomain.Queue
+	// GroupQueues partitions the queue by worker group; see WithGroupQueues.
+	GroupQueues = scheduler.GroupQueues
+	// Bus carries state-change events and worker commands.
+	Bus = events.Bus
+	// Handler executes one task; see WithHandler.
+	Handler = worker.Handler
+	// Task is the unit of work a Handler executes. Payload holds the
+	// task's command and Type its task type.
+	Task = qdomain.Task
+	// Service exposes every use case of the HTTP API as plain methods.
+	Service = service.Service
+
+	Workflow      = domain.Workflow
+	WorkflowRun   = domain.WorkflowRun
+	TaskRun       = domain.TaskRun
+	Status        = domain.Status
+	WorkflowInput = service.CreateWorkflowInput
+	TaskInput     = service.TaskInput
+	TriggerInput  = service.TriggerInput
+	EventInput    = service.EventInput
+	EventResult   = service.EventResult
+)
+
+// Statuses of workflow runs and task runs.
+const (
+	StatusPending = domain.StatusPending
+	StatusRunning = domain.StatusRunning
+	StatusSuccess = domain.StatusSuccess
+	StatusFailed  = domain.StatusFailed
+	StatusSkipped = domain.StatusSkipped
+)
+
+// DefaultWorkerID is the ID the Engine's worker registers under unless
+// WithWorkerID sets another.
+const DefaultWorkerID = "schedkit-worker"
+
+var (
+	// ErrNoHandler is returned for tasks of a type no Handler is registered
+	// for, and for every command task when WithHandler is not used.
+	ErrNoHandler = errors.New("schedkit: no handler for task")
+	// ErrAlreadyRunning is returned when Run is called on a running Engine.
+	ErrAlreadyRunning = errors.New("schedkit: engine is already running")
+)
+
+// MemoryStores returns stores held in memory, private to the calling
+// process.
+func MemoryStores() *Stores {
+	s, _ := backend.OpenStores("")
+	return s
+}
+
+// OpenStores connects to the PostgreSQL database at databaseURL, the one the
+// API, scheduler and worker binaries share. An empty URL returns
+// MemoryStores.
+func OpenStores(databaseURL string) (*Stores, error) {
+	return backend.OpenStores(databaseURL)
+}
+
+// OpenQueue returns the queue at url: an in-process queue for "", Redis for
+// redis:// and rediss:// URLs.
+func OpenQueue(url string) (Queue, error) {
+	return queue.Open(url)
+}
+
+// OpenGroupQueues returns the queue at url, as OpenQueue does, partitioned
+// by worker group.
+func OpenGroupQueues(url string) (*GroupQueues, error) {
+	return queue.OpenGroups(url)
+}
+
+// OpenBus returns the event bus at url: an in-process bus for "", Redis
+// pub/sub for redis:// and rediss:// URLs.
+func OpenBus(url string) (Bus, error) {
+	return events.Open(url)
+}
+
+// Engine runs the scheduler and a worker in-process.
+type Engine struct {
+	stores *Stores
+	queue  Queue
+	groups *GroupQueues
+	bus    Bus
+
+	interval  time.Duration
+	noSched   bool
+	noWorker  bool
+	schedOpts []scheduler.Option
+	cronOpts  []scheduler.CronTriggerOption
+	bpTh      scheduler.Thresholds
+	sticky    time.Duration
+	assign    time.Duration
+	fair      map[string]int
+	hbTimeout time.Duration
+	orphan    scheduler.OrphanPolicy
+	staleAge  time.Duration
+	stale     scheduler.StalePolicy
+	staleOpts []scheduler.JanitorOption
+
+	workerID     string
+	workerGroup  string
+	workerQueues []string
+	concurrency  int
+	handler      Handler
+	handlers     map[string]Handler
+	workerOpts   []worker.Option
+	chaos        *chaos.Injector
+
+	svc       *service.Service
+	sched     *scheduler.Scheduler
+	cron      *scheduler.CronTrigger
+	elector   *scheduler.Elector
+	orch      *scheduler.Orchestrator
+	backfill  *scheduler.Backfiller
+	retention *scheduler.Retention
+	datasets  *scheduler.DatasetTrigger
+	bp        *scheduler.Backpressure
+	reaper    *scheduler.WorkerReaper
+	janitor   *scheduler.RunJanitor
+	worker    *worker.Worker
+	triggerer *worker.Triggerer
+	// err is a configuration error New could not return; Run returns it.
+	err error
+
+	running atomic.Bool
+}
+
+// Option is a functional option for configuring an Engine.
+type Option func(*Engine)
+
+// WithStores sets the repositories the Engine uses. The default is
+// MemoryStores.
+func WithStores(s *Stores) Option {
+	return func(e *Engine) { e.stores = s }
+}
+
+// WithQueue sets the queue between the scheduler and the worker. The default
+// is an in-process queue.
+func WithQueue(q Queue) Option {
+	return func(e *Engine) { e.queue = q }
+}
+
+// WithGroupQueues partitions the queue by worker group: the scheduler
+// enqueues each task on its group's queue and the worker dequeues from the
+// queue of its WithWorkerGroup. It replaces WithQueue. Without either
+// option the Engine uses in-process group queues.
+func WithGroupQueues(g *GroupQueues) Option {
+	return func(e *Engine) { e.groups = g }
+}
+
+// WithBus sets the bus state changes are published on and worker commands
+// arrive on. The default is an in-process bus.
+func WithBus(b Bus) Option {
+	return func(e *Engine) { e.bus = b }
+}
+
+// WithInterval sets how often the orchestrator advances workflow runs and
+// the scheduler dispatches held tasks. The defaults are
+// scheduler.DefaultOrchestrateInterval and one second.
+func WithInterval(d time.Duration) Option {
+	return func(e *Engine) { e.interval = d }
+}
+
+// WithSchedulerOptions configures the dispatching scheduler, e.g. with
+// scheduler.WithPools or scheduler.WithCircuitBreaker.
+func WithSchedulerOptions(opts ...scheduler.Option) Option {
+	return func(e *Engine) { e.schedOpts = append(e.schedOpts, opts...) }
+}
+
+// WithCronOptions configures the cron trigger, e.g. with
+// scheduler.WithJitter or scheduler.WithMisfirePolicy.
+func WithCronOptions(opts ...scheduler.CronTriggerOption) Option {
+	return func(e *Engine) { e.cronOpts = append(e.cronOpts, opts...) }
+}
+
+// WithLeaderElection runs the cron trigger, the scheduler, the orchestrator,
+// the backfiller, the dataset trigger, retention, the worker reaper and the
+// run janitor only while the Engine holds lock, so that of several Engines
+// sharing stores and a lock, such as replicas of the scheduler binary, only
+// one fires schedules and advances runs; another takes over if it stops or
+// dies. Backpressure and the worker run in every Engine. opts configure the
+// scheduler.Elector. Without it the Engine always runs all of them, so
+// Engines sharing stores must then be limited to one.
+func WithLeaderElection(lock scheduler.LeaderLock, opts ...scheduler.ElectorOption) Option {
+	return func(e *Engine) {
+		if lock != nil {
+			e.elector = scheduler.NewElector(lock, opts...)
+		}
+	}
+}
+
+// WithBackpressure raises alerts, published on the bus, while the queue
+// crosses th. Without it no thresholds are watched.
+func WithBackpressure(th scheduler.Thresholds) Option {
+	return func(e *Engine) { e.bpTh = th }
+}
+
+// WithStickyRouting pins tasks with a RoutingKey to one worker each, among
+// the workers that heartbeated within heartbeatTimeout: the scheduler
+// routes them to the worker's own queue, which the worker serves besides
+// its group's. It needs WithGroupQueues, where the per-worker queues live.
+func WithStickyRouting(heartbeatTimeout time.Duration) Option {
+	return func(e *Engine) { e.sticky = heartbeatTimeout }
+}
+
+// WithCapacityAssignment makes the scheduler assign each task to the worker
+// with the most free slots among those that heartbeated within
+// heartbeatTimeout, routing it to that worker's own queue. Like
+// WithStickyRouting, it needs WithGroupQueues.
+func WithCapacityAssignment(heartbeatTimeout time.Duration) Option {
+	return func(e *Engine) { e.assign = heartbeatTimeout }
+}
+
+// WithHeartbeatTimeout sets how long a worker may go without a heartbeat
+// before the scheduler's reaper marks it offline and stops routing tasks to
+// it. The default is qdomain.DefaultHeartbeatTimeout; it should span a few
+// of the workers' heartbeat intervals (worker.WithHeartbeatInterval).
+// WithStickyRouting and WithCapacityAssignment may set their own.
+func WithHeartbeatTimeout(d time.Duration) Option {
+	return func(e *Engine) { e.hbTimeout = d }
+}
+
+// WithOrphanPolicy sets what the scheduler's reaper does with tasks left
+// running by a worker that went longer than the heartbeat timeout without a
+// heartbeat. The default is scheduler.OrphanRetry; scheduler.OrphanIgnore
+// leaves them running.
+func WithOrphanPolicy(p scheduler.OrphanPolicy) Option {
+	return func(e *Engine) { e.orphan = p }
+}
+
+// WithStaleRunJanitor has the scheduler repair, with policy, workflow runs
+// left pending, or running with no task dispatched, for longer than after
+// (see scheduler.RunJanitor). It is off by default.
+func WithStaleRunJanitor(after time.Duration, policy scheduler.StalePolicy, opts ...scheduler.JanitorOption) Option {
+	return func(e *Engine) { e.staleAge, e.stale, e.staleOpts = after, policy, opts }
+}
+
+// WithFairShare makes the in-process queues share dequeues among workflows
+// by weighted round-robin, with the weight weights has for each workflow ID
+// and 1 for the others (see scheduler.FairQueue). It has no effect with
+// WithQueue or WithGroupQueues, whose queues are the caller's.
+func WithFairShare(weights map[string]int) Option {
+	return func(e *Engine) { e.fair = weights }
+}
+
+// WithoutScheduler leaves out the triggers, the orchestrator and the
+// dispatching scheduler, for a process that only executes tasks another
+// process dispatches through shared stores and queue.
+func WithoutScheduler() Option {
+	return func(e *Engine) { e.noSched = true }
+}
+
+// WithoutWorker leaves out the worker, for a process that only dispatches.
+func WithoutWorker() Option {
+	return func(e *Engine) { e.noWorker = true }
+}
+
+// WithWorkerID sets the ID the worker registers under. The default is
+// DefaultWorkerID; workers sharing stores need distinct IDs.
+func WithWorkerID(id string) Option {
+	return func(e *Engine) { e.workerID = id }
+}
+
+// WithWorkerGroup makes the worker serve the named worker group, running
+// only tasks of workflows dedicated to it. The default is the default
+// group, which runs the tasks of workflows that name none.
+func WithWorkerGroup(name string) Option {
+	return func(e *Engine) { e.workerGroup = name }
+}
+
+// WithWorkerQueues dedicates the worker to the named queues, e.g. "gpu":
+// it runs only the tasks whose Queue is one of names, instead of those of
+// its group's queue. It needs WithGroupQueues, where the named queues live.
+func WithWorkerQueues(names ...string) Option {
+	return func(e *Engine) { e.workerQueues = names }
+}
+
+// WithConcurrency sets how many tasks the worker executes at once. The
+// default is 1.
+func WithConcurrency(n int) Option {
+	return func(e *Engine) { e.concurrency = n }
+}
+
+// WithHandler sets the Handler of command tasks, and of tasks whose type has
+// no handler of its own. Without it such tasks fail with ErrNoHandler.
+func WithHandler(h Handler) Option {
+	return func(e *Engine) { e.handler = h }
+}
+
+// WithTaskHandler sets the Handler of tasks of type taskType, the Type of
+// their TaskInput. Sensor tasks are handled by worker.SensorHandler unless
+// replaced here.
+func WithTaskHandler(taskType string, h Handler) Option {
+	return func(e *Engine) { e.handlers[taskType] = h }
+}
+
+// WithWorkerOptions configures the worker further, e.g. with
+// worker.WithBackoff. They are applied after the Engine's own.
+func WithWorkerOptions(opts ...worker.Option) Option {
+	return func(e *Engine) { e.workerOpts = append(e.workerOpts, opts...) }
+}
+
+// WithChaos routes the queue, the queue task and worker stores and every
+// task handler through in, which injects the faults it is configured with.
+// Meant for staging; a nil in injects nothing.
+func WithChaos(in *chaos.Injector) Option {
+	return func(e *Engine) { e.chaos = in }
+}
+
+// New creates an Engine. Nothing runs until Run is called.
+func New(opts ...Option) *Engine {
+	e := &Engine{
+		workerID:    DefaultWorkerID,
+		concurrency: 1,
+		handler:     noHandler,
+		handlers:    make(map[string]Handler),
+	}
+	for _, o := range opts {
+		o(e)
+	}
+	if e.stores == nil {
+		e.stores = MemoryStores()
+	}
+	switch {
+	case e.groups != nil:
+		e.queue = e.groups
+	case e.queue == nil && e.fair != nil:
+		e.groups = scheduler.NewGroupQueues(scheduler.NewFairQueue(e.fair), func(string) (Queue, error) {
+			return scheduler.NewFairQueue(e.fair), nil
+		})
+		e.queue = e.groups
+	case e.queue == nil:
+		e.groups = scheduler.NewGroupQueues(scheduler.NewMemQueue(), nil)
+		e.queue = e.groups
+	}
+	if e.bus == nil {
+		e.bus = events.NewMemBus()
+	}
+	if e.chaos != nil {
+		faulty := *e.stores
+		faulty.QueueTasks = e.chaos.Tasks(faulty.QueueTasks)
+		faulty.QueueWorkers = e.chaos.Workers(faulty.QueueWorkers)
+		e.stores = &faulty
+		e.queue = e.chaos.Queue(e.queue)
+	}
+	s := e.stores
+
+	e.svc = service.New(s.Workflows, s.WorkflowRuns, s.TaskRuns, s.Workers,
+		service.WithApprovals(s.Approvals),
+		service.WithBackfills(s.Backfills),
+		service.WithStats(s.Stats),
+		service.WithLineage(s.Lineage),
+		service.WithCalendars(s.Calendars),
+		service.WithRetention(s.Retention),
+		service.WithTasks(s.Tasks, s.TaskDeps),
+		service.WithWorkerNodes(s.QueueWorkers, s.QueueTasks, s.Heartbeats),
+		service.WithAttempts(s.Attempts),
+		service.WithDispatchFreeze(s.Freeze),
+		service.WithSecrets(s.Secrets),
+		service.WithEvents(e.bus),
+	)
+
+	if !e.noSched {
+		schedOpts := e.schedOpts
+		orchOpts := []scheduler.OrchestratorOption{
+			scheduler.WithRunEvents(e.bus), scheduler.WithLineage(s.Lineage), scheduler.WithWorkflows(s.Workflows),
+		}
+		if e.interval > 0 {
+			schedOpts = append([]scheduler.Option{scheduler.WithDispatchInterval(e.interval)}, schedOpts...)
+			orchOpts = append(orchOpts, scheduler.WithOrchestrateInterval(e.interval))
+		}
+		if e.hbTimeout > 0 {
+			schedOpts = append(schedOpts, scheduler.WithHeartbeatTimeout(e.hbTimeout))
+		}
+		if e.sticky > 0 && e.groups != nil {
+			schedOpts = append(schedOpts, scheduler.WithStickyRouting(e.groups, e.sticky))
+		}
+		if e.assign > 0 && e.groups != nil {
+			schedOpts = append(schedOpts, scheduler.WithCapacityAssignment(e.groups, e.assign))
+		}
+		// Every status change the scheduler, the cron trigger and the
+		// orchestrator make is announced on the bus, as the worker's are.
+		schedOpts = append([]scheduler.Option{scheduler.WithTaskEvents(e.bus)}, schedOpts...)
+		e.sched = scheduler.New(s.QueueTasks, s.QueueWorkers, e.queue, schedOpts...)
+		// Days excluded by a workflow's calendar are recorded as skipped
+		// runs; workflow changes announced on the bus reload the schedules.
+		cronOpts := append([]scheduler.CronTriggerOption{
+			scheduler.WithCalendars(scheduler.NewCalendars(s.Calendars)),
+			scheduler.WithReloadEvents(e.bus),
+			scheduler.WithFireEvents(e.bus),
+		}, e.cronOpts...)
+		e.cron = scheduler.NewCronTrigger(s.Workflows, s.WorkflowRuns, cronOpts...)
+		e.backfill = scheduler.NewBackfiller(s.Backfills, s.Workflows, s.WorkflowRuns)
+		e.retention = scheduler.NewRetention(s.Workflows, s.Retention)
+		e.datasets = scheduler.NewDatasetTrigger(s.Workflows, s.WorkflowRuns, s.Lineage)
+		e.orch = scheduler.NewOrchestrator(s.Tasks, s.TaskDeps, s.WorkflowRuns, s.TaskRuns, e.sched, s.QueueTasks, orchOpts...)
+		e.reaper = scheduler.NewWorkerReaper(s.QueueWorkers, e.hbTimeout,
+			scheduler.WithOrphanedTasks(s.QueueTasks, e.queue, e.orphan))
+		if e.staleAge > 0 {
+			e.janitor = scheduler.NewRunJanitor(e.orch, e.staleAge, e.stale, e.staleOpts...)
+		}
+		if e.bpTh.Enabled() {
+			bpOpts := []scheduler.BackpressureOption{scheduler.WithAlertEvents(e.bus)}
+			if e.interval > 0 {
+				bpOpts = append(bpOpts, scheduler.WithBackpressureInterval(e.interval))
+			}
+			e.bp = scheduler.NewBackpressure(e.bpTh, e.queue, s.QueueTasks, bpOpts...)
+		}
+	}
+
+	if !e.noWorker {
+		handlers := map[string]Handler{
+			worker.TaskTypeSensor: worker.SensorHandler(nil, nil, worker.WithWorkflowRuns(s.WorkflowRuns)),
+		}
+		for typ, h := range e.handlers {
+			handlers[typ] = h
+		}
+		handler := e.handler
+		var workerOpts []worker.Option
+		for typ, h := range handlers {
+			if e.chaos != nil {
+				h = e.chaos.Handler(h)
+			}
+			workerOpts = append(workerOpts, worker.WithHandler(typ, h))
+		}
+		if e.chaos != nil {
+			handler = e.chaos.Handler(handler)
+		}
+		workerOpts = append(workerOpts,
+			worker.WithEvents(e.bus),
+			worker.WithHeartbeatLog(s.Heartbeats),
+			worker.WithAttemptLog(s.Attempts),
+			worker.WithDispatchFreeze(s.Freeze, time.Second),
+			worker.WithControl(e.bus),
+			worker.WithConcurrency(e.concurrency),
+			worker.WithSecrets(s.Secrets, time.Minute),
+		)
+		// The worker takes tasks off its group's queue, or its named queues,
+		// only and puts retries back on it; the triggerer routes resumed
+		// tasks by their Queue or Group.
+		intake := e.queue
+		if e.groups != nil {
+			var q Queue
+			var err error
+			if len(e.workerQueues) > 0 {
+				q, err = e.groups.Named(0, e.workerQueues...)
+			} else {
+				q, err = e.groups.Queue(e.workerGroup)
+			}
+			if err != nil {
+				e.err = fmt.Errorf("schedkit: %w", err)
+			} else if e.chaos != nil {
+				q = e.chaos.Queue(q)
+			}
+			intake = q
+		} else if e.workerGroup != qdomain.DefaultGroup {
+			e.err = fmt.Errorf("schedkit: worker group %q needs WithGroupQueues", e.workerGroup)
+		} else if len(e.workerQueues) > 0 {
+			e.err = fmt.Errorf("schedkit: named queues need WithGroupQueues")
+		}
+		workerOpts = append(workerOpts, worker.WithGroup(e.workerGroup))
+		if e.sticky > 0 || e.assign > 0 {
+			if e.groups == nil {
+				e.err = fmt.Errorf("schedkit: routing to workers needs WithGroupQueues")
+			} else if q, err := e.groups.Queue(qdomain.StickyQueue(e.workerID)); err != nil {
+				e.err = fmt.Errorf("schedkit: %w", err)
+			} else {
+				workerOpts = append(workerOpts, worker.WithStickyQueue(q, 0))
+			}
+		}
+		e.worker = worker.New(e.workerID, intake, s.QueueTasks, s.QueueWorkers, handler,
+			append(workerOpts, e.workerOpts...)...)
+		e.triggerer = worker.NewTriggerer(e.queue, s.QueueTasks, nil)
+	}
+	return e
+}
+
+// noHandler is the Handler of tasks nothing was registered for.
+func noHandler(_ context.Context, task *Task) error {
+	return fmt.Errorf("%w: task %s has type %q", ErrNoHandler, task.Name, task.Type)
+}
+
+// Run starts every component of the Engine and blocks until ctx is cancelled
+// or one of them fails, then waits for them to stop. Tasks in progress are
+// finished first. Cron schedules are loaded when Run starts, so schedules
+// of workflows created later take effect on the next Run.
+func (e *Engine) Run(ctx context.Context) error {
+	if e.err != nil {
+		return e.err
+	}
+	if !e.running.CompareAndSwap(false, true) {
+		return ErrAlreadyRunning
+	}
+	defer e.running.Store(false)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		runErr  error
+	)
+	spawn := func(name string, run func(context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := run(ctx); err != nil && ctx.Err() == nil {
+				errOnce.Do(func() { runErr = fmt.Errorf("schedkit: %s: %w", name, err) })
+				cancel()
+			}
+		}()
+	}
+
+	if e.sched != nil {
+		if e.elector != nil {
+			spawn("leader election", func(ctx context.Context) error {
+				return e.elector.Run(ctx, e.lead)
+			})
+		} else {
+			if err := e.cron.Start(ctx); err != nil {
+				return fmt.Errorf("schedkit: %w", err)
+			}
+			defer e.cron.Stop()
+			for _, l := range e.leaderLoops() {
+				spawn(l.name, l.run)
+			}
+		}
+		if e.bp != nil {
+			spawn("backpressure", e.bp.Run)
+		}
+	}
+	if e.worker != nil {
+		spawn("worker", e.worker.Run)
+		spawn("triggerer", e.triggerer.Run)
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+	return runErr
+}
+
+// loop is one of the Engine's long-running components.
+type loop struct {
+	name string
+	run  func(context.Context) error
+}
+
+// leaderLoops returns the components that act on the state the Engines
+// share, other than the cron trigger. Two Engines running them at once
+// would, for example, both start the same task of a run, so
+// WithLeaderElection runs them on the leader only.
+func (e *Engine) leaderLoops() []loop {
+	loops := []loop{
+		{"scheduler", e.sched.Run},
+		{"backfiller", e.backfill.Run},
+		{"retention", e.retention.Run},
+		{"dataset trigger", e.datasets.Run},
+		{"orchestrator", e.orch.Run},
+		{"worker reaper", e.reaper.Run},
+	}
+	if e.janitor != nil {
+		loops = append(loops, loop{"run janitor", e.janitor.Run})
+	}
+	return loops
+}
+
+// lead runs the cron trigger and the leaderLoops until ctx is cancelled,
+// for the elected leader. If one of the loops fails, the others are
+// stopped and its error returned, so the Engine steps down.
+func (e *Engine) lead(ctx context.Context) error {
+	if err := e.cron.Start(ctx); err != nil {
+		return err
+	}
+	defer e.cron.Stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	loops := e.leaderLoops()
+	errs := make(chan error, len(loops))
+	for _, l := range loops {
+		go func() {
+			err := l.run(ctx)
+			if err != nil && ctx.Err() == nil {
+				errs <- fmt.Errorf("%s: %w", l.name, err)
+				return
+			}
+			errs <- nil
+		}()
+	}
+	var first error
+	for range loops {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
+
+// Service returns the Service backed by the Engine's stores, for the use
+// cases the Engine has no method of its own for.
+func (e *Engine) Service() *Service {
+	return e.svc
+}
+
+// Stores returns the repositories the Engine uses.
+func (e *Engine) Stores() *Stores {
+	return e.stores
+}
+
+// Bus returns the bus the Engine publishes state changes on; subscribe to
+// it to follow runs as they progress.
+func (e *Engine) Bus() Bus {
+	return e.bus
+}
+
+// Scheduler returns the dispatching scheduler, or nil WithoutScheduler.
+func (e *Engine) Scheduler() *scheduler.Scheduler {
+	return e.sched
+}
+
+// CronTrigger returns the cron trigger, or nil WithoutScheduler.
+func (e *Engine) CronTrigger() *scheduler.CronTrigger {
+	return e.cron
+}
+
+// Leader reports whether the Engine runs the cron trigger and the other
+// components that must run in one Engine at a time, such as the scheduler
+// and the orchestrator: whether it is the elected leader
+// WithLeaderElection, or whether it has a scheduler at all otherwise.
+func (e *Engine) Leader() bool {
+	if e.elector != nil {
+		return e.elector.IsLeader()
+	}
+	return e.sched != nil
+}
+
+// Backpressure returns the backpressure monitor, or nil without
+// WithBackpressure or WithoutScheduler.
+func (e *Engine) Backpressure() *scheduler.Backpressure {
+	return e.bp
+}
+
+// Triggerer returns the triggerer that resumes deferred tasks, or nil
+// WithoutWorker. Its Complete reports an external operation finished.
+func (e *Engine) Triggerer() *worker.Triggerer {
+	return e.triggerer
+}
+
+// CreateWorkflow stores a workflow together with its tasks.
+func (e *Engine) CreateWorkflow(ctx context.Context, in WorkflowInput) (*Workflow, error) {
+	return e.svc.CreateWorkflow(ctx, in)
+}
+
+// Trigger creates a run of workflow id. The orchestrator starts it on its
+// next pass.
+func (e *Engine) Trigger(ctx context.Context, id uuid.UUID, in TriggerInput) (*WorkflowRun, error) {
+	return e.svc.TriggerWorkflow(ctx, id, in)
+}
+
+// FireEvent starts a run of every active workflow subscribed to the
+// external event name, with the payload as the runs' params.
+func (e *Engine) FireEvent(ctx context.Context, name string, in EventInput) (*EventResult, error) {
+	return e.svc.FireEvent(ctx, name, in)
+}
+
+// WorkflowRun returns the workflow run with the given ID.
+func (e *Engine) WorkflowRun(ctx context.Context, id uuid.UUID) (*WorkflowRun, error) {
+	return e.stores.WorkflowRuns.GetByID(ctx, id)
+}
+
+// TaskRuns returns the task runs of workflow run id.
+func (e *Engine) TaskRuns(ctx context.Context, id uuid.UUID) ([]*TaskRun, error) {
+	return e.stores.TaskRuns.ListByWorkflowRunID(ctx, id)
+}
@@ -0,0 +1,451 @@
+package schedkit_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/chaos"
+	"github.com/sauravritesh63/GoLang-Project-/schedkit"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// start runs e until the test ends.
+func start(t *testing.T, e *schedkit.Engine) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- e.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	})
+}
+
+// waitFinished polls run id until it reaches a terminal status.
+func waitFinished(t *testing.T, e *schedkit.Engine, id uuid.UUID) *schedkit.WorkflowRun {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		run, err := e.WorkflowRun(context.Background(), id)
+		if err != nil {
+			t.Fatalf("WorkflowRun: %v", err)
+		}
+		if run.Status.IsTerminal() {
+			return run
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("workflow run %s did not finish", id)
+	return nil
+}
+
+func TestEngine_RunsWorkflowInProcess(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		ran []string
+	)
+	e := schedkit.New(
+		schedkit.WithInterval(10*time.Millisecond),
+		schedkit.WithHandler(func(_ context.Context, task *schedkit.Task) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ran = append(ran, string(task.Payload))
+			return nil
+		}),
+		schedkit.WithTaskHandler("notify", func(_ context.Context, task *schedkit.Task) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ran = append(ran, "notify:"+string(task.Payload))
+			return nil
+		}),
+	)
+	start(t, e)
+
+	ctx := context.Background()
+	wf, err := e.CreateWorkflow(ctx, schedkit.WorkflowInput{
+		Name: "etl",
+		Tasks: []schedkit.TaskInput{
+			{Name: "extract", Command: "extract"},
+			{Name: "load", Command: "load", DependsOn: []string{"extract"}},
+			{Name: "tell", Command: "ops", Type: "notify", DependsOn: []string{"load"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	run, err := e.Trigger(ctx, wf.ID, schedkit.TriggerInput{})
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+
+	if got := waitFinished(t, e, run.ID); got.Status != schedkit.StatusSuccess {
+		t.Fatalf("run status: got %s, want success", got.Status)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"extract", "load", "notify:ops"}
+	if len(ran) != len(want) {
+		t.Fatalf("handled: got %v, want %v", ran, want)
+	}
+	for i := range want {
+		if ran[i] != want[i] {
+			t.Fatalf("handled: got %v, want %v", ran, want)
+		}
+	}
+	trs, err := e.TaskRuns(ctx, run.ID)
+	if err != nil {
+		t.Fatalf("TaskRuns: %v", err)
+	}
+	if len(trs) != 3 {
+		t.Errorf("task runs: got %d, want 3", len(trs))
+	}
+}
+
+func TestEngine_NoHandlerFailsRun(t *testing.T) {
+	e := schedkit.New(schedkit.WithInterval(10 * time.Millisecond))
+	start(t, e)
+
+	ctx := context.Background()
+	wf, err := e.CreateWorkflow(ctx, schedkit.WorkflowInput{
+		Name:  "unhandled",
+		Tasks: []schedkit.TaskInput{{Name: "a", Command: "true"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	run, err := e.Trigger(ctx, wf.ID, schedkit.TriggerInput{})
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	if got := waitFinished(t, e, run.ID); got.Status != schedkit.StatusFailed {
+		t.Errorf("run status: got %s, want failed", got.Status)
+	}
+}
+
+func TestEngine_ChaosFailsHandlers(t *testing.T) {
+	in := chaos.New(chaos.Config{HandlerFailureRate: 1})
+	e := schedkit.New(
+		schedkit.WithInterval(10*time.Millisecond),
+		schedkit.WithHandler(func(context.Context, *schedkit.Task) error { return nil }),
+		schedkit.WithChaos(in),
+	)
+	start(t, e)
+
+	ctx := context.Background()
+	wf, err := e.CreateWorkflow(ctx, schedkit.WorkflowInput{
+		Name:  "chaotic",
+		Tasks: []schedkit.TaskInput{{Name: "a", Command: "true"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	run, err := e.Trigger(ctx, wf.ID, schedkit.TriggerInput{})
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	if got := waitFinished(t, e, run.ID); got.Status != schedkit.StatusFailed {
+		t.Errorf("run status: got %s, want failed", got.Status)
+	}
+	if got := in.Stats().HandlerFailures; got != 1 {
+		t.Errorf("HandlerFailures: got %d, want 1", got)
+	}
+}
+
+func TestEngine_BackpressureAlerts(t *testing.T) {
+	e := schedkit.New(
+		schedkit.WithoutWorker(),
+		schedkit.WithInterval(10*time.Millisecond),
+		schedkit.WithBackpressure(scheduler.Thresholds{QueueDepth: 1}),
+	)
+	start(t, e)
+
+	ctx := context.Background()
+	wf, err := e.CreateWorkflow(ctx, schedkit.WorkflowInput{
+		Name:  "fan-out",
+		Tasks: []schedkit.TaskInput{{Name: "a", Command: "a"}, {Name: "b", Command: "b"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if _, err := e.Trigger(ctx, wf.ID, schedkit.TriggerInput{}); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !e.Backpressure().Alerts().Degraded() {
+		if time.Now().After(deadline) {
+			t.Fatal("queue_depth alert did not fire")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEngine_RunTwice(t *testing.T) {
+	e := schedkit.New(schedkit.WithoutWorker())
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() { errs <- e.Run(ctx) }()
+	}
+	var rejected int
+	for range 2 {
+		if err := <-errs; errors.Is(err, schedkit.ErrAlreadyRunning) {
+			rejected++
+		} else if err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	}
+	if rejected != 1 {
+		t.Errorf("concurrent Runs rejected: got %d, want 1", rejected)
+	}
+}
+
+func TestEngine_Roles(t *testing.T) {
+	if e := schedkit.New(schedkit.WithoutScheduler()); e.Scheduler() != nil || e.CronTrigger() != nil || e.Triggerer() == nil {
+		t.Error("WithoutScheduler: want a worker and no scheduler")
+	}
+	if e := schedkit.New(schedkit.WithoutWorker()); e.Scheduler() == nil || e.Triggerer() != nil {
+		t.Error("WithoutWorker: want a scheduler and no worker")
+	}
+}
+
+func TestEngine_LeaderElection(t *testing.T) {
+	lock := scheduler.NewMemLeaderLock()
+	stores := schedkit.MemoryStores()
+	newEngine := func() *schedkit.Engine {
+		return schedkit.New(schedkit.WithStores(stores), schedkit.WithoutWorker(),
+			schedkit.WithLeaderElection(lock.Handle(), scheduler.WithElectionInterval(10*time.Millisecond)))
+	}
+	first, second := newEngine(), newEngine()
+	start(t, first)
+	deadline := time.Now().Add(3 * time.Second)
+	for !first.Leader() {
+		if time.Now().After(deadline) {
+			t.Fatal("first engine was not elected")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- second.Run(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !first.Leader() || second.Leader() {
+		t.Errorf("leaders: first %v, second %v; want the first only", first.Leader(), second.Leader())
+	}
+}
+
+func TestEngine_OnlyLeaderAdvancesRuns(t *testing.T) {
+	lock := scheduler.NewMemLeaderLock()
+	election := func() schedkit.Option {
+		return schedkit.WithLeaderElection(lock.Handle(), scheduler.WithElectionInterval(10*time.Millisecond))
+	}
+	leader := schedkit.New(schedkit.WithoutWorker(), election(), schedkit.WithInterval(10*time.Millisecond))
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan error, 1)
+	go func() { leaderDone <- leader.Run(leaderCtx) }()
+	stopLeader := sync.OnceValue(func() error {
+		cancel()
+		return <-leaderDone
+	})
+	defer stopLeader()
+	deadline := time.Now().Add(3 * time.Second)
+	for !leader.Leader() {
+		if time.Now().After(deadline) {
+			t.Fatal("leader was not elected")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	follower := schedkit.New(election(), schedkit.WithInterval(10*time.Millisecond),
+		schedkit.WithHandler(func(context.Context, *schedkit.Task) error { return nil }))
+	start(t, follower)
+	ctx := context.Background()
+	wf, err := follower.CreateWorkflow(ctx, schedkit.WorkflowInput{
+		Name: "follow", Tasks: []schedkit.TaskInput{{Name: "a", Command: "noop"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	run, err := follower.Trigger(ctx, wf.ID, schedkit.TriggerInput{})
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	got, err := follower.WorkflowRun(ctx, run.ID)
+	if err != nil {
+		t.Fatalf("WorkflowRun: %v", err)
+	}
+	if got.Status.IsTerminal() {
+		t.Fatalf("run status before failover: got %s, want it not advanced by a follower", got.Status)
+	}
+
+	if err := stopLeader(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := waitFinished(t, follower, run.ID); got.Status != schedkit.StatusSuccess {
+		t.Fatalf("run status after failover: got %s, want success", got.Status)
+	}
+}
+
+func TestEngine_WorkerGroupsGetTheirOwnTasks(t *testing.T) {
+	stores := schedkit.MemoryStores()
+	groups, err := schedkit.OpenGroupQueues("")
+	if err != nil {
+		t.Fatalf("OpenGroupQueues: %v", err)
+	}
+	var (
+		mu  sync.Mutex
+		ran = map[string]string{}
+	)
+	recorder := func(worker string) schedkit.Handler {
+		return func(_ context.Context, task *schedkit.Task) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ran[string(task.Payload)] = worker
+			return nil
+		}
+	}
+	shared := []schedkit.Option{schedkit.WithStores(stores), schedkit.WithGroupQueues(groups)}
+	start(t, schedkit.New(append(shared, schedkit.WithoutWorker(), schedkit.WithInterval(10*time.Millisecond))...))
+	start(t, schedkit.New(append(shared, schedkit.WithoutScheduler(),
+		schedkit.WithWorkerID("default"), schedkit.WithHandler(recorder("default")))...))
+	heavy := schedkit.New(append(shared, schedkit.WithoutScheduler(), schedkit.WithWorkerGroup("heavy"),
+		schedkit.WithWorkerID("heavy"), schedkit.WithHandler(recorder("heavy")))...)
+	start(t, heavy)
+
+	ctx := context.Background()
+	var runs []uuid.UUID
+	for _, in := range []schedkit.WorkflowInput{
+		{Name: "light", Tasks: []schedkit.TaskInput{{Name: "a", Command: "light"}}},
+		{Name: "batch", WorkerGroup: "heavy", Tasks: []schedkit.TaskInput{{Name: "a", Command: "batch"}}},
+	} {
+		wf, err := heavy.CreateWorkflow(ctx, in)
+		if err != nil {
+			t.Fatalf("CreateWorkflow: %v", err)
+		}
+		run, err := heavy.Trigger(ctx, wf.ID, schedkit.TriggerInput{})
+		if err != nil {
+			t.Fatalf("Trigger: %v", err)
+		}
+		runs = append(runs, run.ID)
+	}
+	for _, id := range runs {
+		if got := waitFinished(t, heavy, id); got.Status != schedkit.StatusSuccess {
+			t.Fatalf("run status: got %s, want success", got.Status)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if ran["light"] != "default" || ran["batch"] != "heavy" {
+		t.Errorf("tasks ran on %v, want light on default and batch on heavy", ran)
+	}
+	w, err := stores.QueueWorkers.FindByID(ctx, "heavy")
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if w.Group() != "heavy" {
+		t.Errorf("worker group label: got %q, want heavy", w.Group())
+	}
+}
+
+func TestEngine_NamedQueuesRouteTasksToDedicatedWorkers(t *testing.T) {
+	stores := schedkit.MemoryStores()
+	groups, err := schedkit.OpenGroupQueues("")
+	if err != nil {
+		t.Fatalf("OpenGroupQueues: %v", err)
+	}
+	var (
+		mu  sync.Mutex
+		ran = map[string]string{}
+	)
+	recorder := func(worker string) schedkit.Handler {
+		return func(_ context.Context, task *schedkit.Task) error {
+			mu.Lock()
+			defer mu.Unlock()
+			ran[string(task.Payload)] = worker
+			return nil
+		}
+	}
+	shared := []schedkit.Option{schedkit.WithStores(stores), schedkit.WithGroupQueues(groups)}
+	start(t, schedkit.New(append(shared, schedkit.WithoutWorker(), schedkit.WithInterval(10*time.Millisecond))...))
+	start(t, schedkit.New(append(shared, schedkit.WithoutScheduler(),
+		schedkit.WithWorkerID("cpu"), schedkit.WithHandler(recorder("cpu")))...))
+	gpu := schedkit.New(append(shared, schedkit.WithoutScheduler(), schedkit.WithWorkerQueues("gpu"),
+		schedkit.WithWorkerID("gpu"), schedkit.WithHandler(recorder("gpu")))...)
+	start(t, gpu)
+
+	ctx := context.Background()
+	wf, err := gpu.CreateWorkflow(ctx, schedkit.WorkflowInput{Name: "train", Tasks: []schedkit.TaskInput{
+		{Name: "prepare", Command: "prepare"},
+		{Name: "fit", Command: "fit", Queue: "gpu", DependsOn: []string{"prepare"}},
+	}})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	run, err := gpu.Trigger(ctx, wf.ID, schedkit.TriggerInput{})
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	if got := waitFinished(t, gpu, run.ID); got.Status != schedkit.StatusSuccess {
+		t.Fatalf("run status: got %s, want success", got.Status)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if ran["prepare"] != "cpu" || ran["fit"] != "gpu" {
+		t.Errorf("tasks ran on %v, want prepare on cpu and fit on gpu", ran)
+	}
+}
+
+func TestEngine_InvalidWorkerGroup(t *testing.T) {
+	e := schedkit.New(schedkit.WithoutScheduler(), schedkit.WithWorkerGroup("no spaces"))
+	if err := e.Run(context.Background()); err == nil {
+		t.Error("Run: expected error for an invalid worker group")
+	}
+}
+
+func TestEngine_FireEvent(t *testing.T) {
+	ran := make(chan string, 1)
+	e := schedkit.New(
+		schedkit.WithInterval(10*time.Millisecond),
+		schedkit.WithHandler(func(_ context.Context, task *schedkit.Task) error {
+			ran <- string(task.Payload)
+			return nil
+		}),
+	)
+	start(t, e)
+
+	ctx := context.Background()
+	_, err := e.CreateWorkflow(ctx, schedkit.WorkflowInput{
+		Name:          "load",
+		IsActive:      true,
+		TriggerEvents: []string{"file.arrived"},
+		Tasks:         []schedkit.TaskInput{{Name: "a", Command: "load {{ .params.path }}"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	res, err := e.FireEvent(ctx, "file.arrived", schedkit.EventInput{Payload: map[string]any{"path": "/in/x.csv"}})
+	if err != nil {
+		t.Fatalf("FireEvent: %v", err)
+	}
+	if len(res.Runs) != 1 {
+		t.Fatalf("runs: got %d, want 1", len(res.Runs))
+	}
+	if got := waitFinished(t, e, res.Runs[0].ID); got.Status != schedkit.StatusSuccess {
+		t.Fatalf("run status: got %s, want success", got.Status)
+	}
+	if got := <-ran; got != "load /in/x.csv" {
+		t.Errorf("handled %q, want the rendered payload", got)
+	}
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// WithCapacityAssignment makes the scheduler place each task on a worker
+// itself rather than leave it on the shared queue of its group for any
+// worker to take. The task goes to the domain.StickyQueue of groups
+// belonging to the group's worker with the most free slots, among those
+// that heartbeated within heartbeatTimeout (the Scheduler's
+// WithHeartbeatTimeout if zero), counting the tasks already
+// waiting on that worker's queue as taken. Its WorkerID records the
+// assignment. A task no worker has a slot for stays on the shared queue.
+// Reconcile assigns again the tasks waiting for a worker that has gone.
+// Workers serve their own queue with worker.WithStickyQueue.
+//
+// With WithStickyRouting as well, tasks with a RoutingKey are pinned as
+// usual and only the others are assigned.
+func WithCapacityAssignment(groups *GroupQueues, heartbeatTimeout time.Duration) Option {
+	return func(s *Scheduler) {
+		s.workerQueues = groups
+		if heartbeatTimeout > 0 {
+			s.liveWithin = heartbeatTimeout
+		}
+		s.assign = true
+	}
+}
+
+// assignee returns the worker among workers with the most free slots left
+// once the tasks waiting on its queue are counted, or nil if none has one.
+// Ties go to the first, which FindAvailable ranks least loaded.
+func (s *Scheduler) assignee(ctx context.Context, workers []*domain.Worker) *domain.Worker {
+	var best *domain.Worker
+	bestFree := 0
+	for _, w := range workers {
+		free := w.FreeSlots()
+		if free <= bestFree {
+			continue
+		}
+		q, err := s.workerQueues.Queue(domain.StickyQueue(w.ID))
+		if err != nil {
+			continue
+		}
+		waiting, err := q.Len(ctx)
+		if err != nil {
+			continue
+		}
+		if free -= waiting; free > bestFree {
+			best, bestFree = w, free
+		}
+	}
+	return best
+}
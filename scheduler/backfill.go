@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// DefaultBackfillInterval is how often Backfiller.Run advances running
+// backfills.
+const DefaultBackfillInterval = 5 * time.Second
+
+// ScheduleTimes returns every fire time of wf's schedule in [start, end], in
+// chronological order. At most limit times are returned; ok is false when the
+// range holds more than limit intervals.
+func ScheduleTimes(wf *domain.Workflow, start, end time.Time, limit int) (times []time.Time, ok bool, err error) {
+	sched, err := WorkflowSchedule(wf)
+	if err != nil {
+		return nil, false, err
+	}
+	// Next is strictly-after, so step back to include a fire exactly at start.
+	t := start.Add(-time.Nanosecond)
+	for {
+		t = sched.Next(t)
+		if t.IsZero() || t.After(end) {
+			return times, true, nil
+		}
+		if len(times) == limit {
+			return times, false, nil
+		}
+		times = append(times, t)
+	}
+}
+
+// Backfiller creates the WorkflowRuns of running backfills. Each pass creates
+// runs in logical-date order until the backfill has MaxActiveRuns unfinished
+// runs, and marks it completed once every interval has run to a final state.
+type Backfiller struct {
+	backfills    repository.BackfillRepository
+	workflows    repository.WorkflowRepository
+	workflowRuns repository.WorkflowRunRepository
+}
+
+// NewBackfiller creates a Backfiller backed by the supplied repositories.
+func NewBackfiller(
+	backfills repository.BackfillRepository,
+	workflows repository.WorkflowRepository,
+	workflowRuns repository.WorkflowRunRepository,
+) *Backfiller {
+	return &Backfiller{
+		backfills:    backfills,
+		workflows:    workflows,
+		workflowRuns: workflowRuns,
+	}
+}
+
+// Run calls Reconcile every DefaultBackfillInterval until ctx is cancelled.
+func (bf *Backfiller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(DefaultBackfillInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := bf.Reconcile(ctx); err != nil {
+				log.Printf("Backfiller: reconcile: %v", err)
+			}
+		}
+	}
+}
+
+// Reconcile advances every running backfill once.
+func (bf *Backfiller) Reconcile(ctx context.Context) error {
+	running, err := bf.backfills.ListByStatus(ctx, domain.BackfillStatusRunning)
+	if err != nil {
+		return fmt.Errorf("list running backfills: %w", err)
+	}
+	for _, b := range running {
+		if err := bf.Advance(ctx, b); err != nil {
+			log.Printf("Backfiller: backfill %s: %v", b.ID, err)
+		}
+	}
+	return nil
+}
+
+// Advance creates as many of b's remaining runs as its MaxActiveRuns allows
+// and persists the updated progress. Backfills that are not running are left
+// untouched, including one cancelled while Advance was creating its runs:
+// the progress write is conditional on b still running, so it cannot revert
+// the cancellation.
+func (bf *Backfiller) Advance(ctx context.Context, b *domain.Backfill) error {
+	if b.Status != domain.BackfillStatusRunning {
+		return nil
+	}
+	wf, err := bf.workflows.GetByID(ctx, b.WorkflowID)
+	if err != nil {
+		return fmt.Errorf("load workflow: %w", err)
+	}
+	sched, err := WorkflowSchedule(wf)
+	if err != nil {
+		return fmt.Errorf("workflow schedule: %w", err)
+	}
+	active, err := bf.activeRuns(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	limit := b.MaxActiveRuns
+	if limit < 1 {
+		limit = 1
+	}
+	for b.Created < b.Total && active < limit {
+		from := b.Start.Add(-time.Nanosecond)
+		if b.Cursor != nil {
+			from = *b.Cursor
+		}
+		next := sched.Next(from)
+		if next.IsZero() || next.After(b.End) {
+			// The schedule changed since the backfill was planned; nothing
+			// is left in range.
+			b.Total = b.Created
+			break
+		}
+		backfillID := b.ID
+		run := &domain.WorkflowRun{
+			ID:          uuid.New(),
+			WorkflowID:  b.WorkflowID,
+			Status:      domain.StatusPending,
+			StartedAt:   time.Now().UTC(),
+			LogicalDate: &next,
+			BackfillID:  &backfillID,
+			Labels:      map[string]string{domain.LabelBackfill: "true"},
+		}
+		if err := bf.workflowRuns.Create(ctx, run); err != nil {
+			return fmt.Errorf("create run for %s: %w", next.Format(time.RFC3339), err)
+		}
+		b.Created++
+		b.Cursor = &next
+		active++
+	}
+
+	if err := bf.backfills.UpdateProgress(ctx, b); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil
+		}
+		return fmt.Errorf("save progress: %w", err)
+	}
+	if b.Created >= b.Total && active == 0 {
+		now := time.Now().UTC()
+		err := bf.backfills.Transition(ctx, b.ID, domain.BackfillStatusRunning, domain.BackfillStatusCompleted, now)
+		if err != nil && !errors.Is(err, repository.ErrConflict) {
+			return fmt.Errorf("complete: %w", err)
+		}
+		if err == nil {
+			b.Status = domain.BackfillStatusCompleted
+			b.FinishedAt = &now
+		}
+	}
+	return nil
+}
+
+// activeRuns counts b's runs that have not reached a final state.
+func (bf *Backfiller) activeRuns(ctx context.Context, b *domain.Backfill) (int, error) {
+	runs, err := bf.workflowRuns.ListByWorkflowID(ctx, b.WorkflowID)
+	if err != nil {
+		return 0, fmt.Errorf("list runs: %w", err)
+	}
+	n := 0
+	for _, r := range runs {
+		if r.BackfillID != nil && *r.BackfillID == b.ID && !r.Status.IsTerminal() {
+			n++
+		}
+	}
+	return n, nil
+}
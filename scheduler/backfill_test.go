@@ -0,0 +1,127 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+func TestScheduleTimes_InclusiveRange(t *testing.T) {
+	wf := &idomain.Workflow{ScheduleCron: "@daily"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	times, ok, err := scheduler.ScheduleTimes(wf, start, end, 10)
+	if err != nil || !ok {
+		t.Fatalf("ScheduleTimes: ok=%v err=%v", ok, err)
+	}
+	if len(times) != 3 {
+		t.Fatalf("len: got %d, want 3", len(times))
+	}
+	if !times[0].Equal(start) || !times[2].Equal(end) {
+		t.Errorf("bounds: got %s..%s", times[0], times[2])
+	}
+
+	if _, ok, _ := scheduler.ScheduleTimes(wf, start, end, 2); ok {
+		t.Error("expected ok=false when the range exceeds the limit")
+	}
+}
+
+func TestBackfiller_RespectsMaxActiveRunsAndOrder(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	bfRepo := mock.NewBackfillRepo()
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "@daily"}
+	_ = wfRepo.Create(ctx, wf)
+
+	b := &idomain.Backfill{
+		ID:            uuid.New(),
+		WorkflowID:    wf.ID,
+		Start:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:           time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+		MaxActiveRuns: 2,
+		Status:        idomain.BackfillStatusRunning,
+		Total:         3,
+	}
+	_ = bfRepo.Create(ctx, b)
+	bf := scheduler.NewBackfiller(bfRepo, wfRepo, runRepo)
+
+	if err := bf.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+	if len(runs) != 2 {
+		t.Fatalf("runs after first pass: got %d, want 2", len(runs))
+	}
+	if runs[0].Labels[idomain.LabelBackfill] != "true" {
+		t.Errorf("labels: got %v, want backfill=true", runs[0].Labels)
+	}
+
+	// Nothing finished yet, so a second pass must not exceed the cap.
+	_ = bf.Reconcile(ctx)
+	runs, _ = runRepo.ListByWorkflowID(ctx, wf.ID)
+	if len(runs) != 2 {
+		t.Fatalf("runs while at cap: got %d, want 2", len(runs))
+	}
+
+	for _, r := range runs {
+		_ = runRepo.UpdateStatus(ctx, r.ID, idomain.StatusSuccess, nil)
+	}
+	_ = bf.Reconcile(ctx)
+	runs, _ = runRepo.ListByWorkflowID(ctx, wf.ID)
+	if len(runs) != 3 {
+		t.Fatalf("runs after completion: got %d, want 3", len(runs))
+	}
+	var last time.Time
+	for _, r := range runs {
+		if r.LogicalDate.After(last) {
+			last = *r.LogicalDate
+		}
+	}
+	if !last.Equal(b.End) {
+		t.Errorf("last logical date: got %s, want %s", last, b.End)
+	}
+
+	for _, r := range runs {
+		_ = runRepo.UpdateStatus(ctx, r.ID, idomain.StatusSuccess, nil)
+	}
+	_ = bf.Reconcile(ctx)
+	got, _ := bfRepo.GetByID(ctx, b.ID)
+	if got.Status != idomain.BackfillStatusCompleted {
+		t.Errorf("Status: got %q, want %q", got.Status, idomain.BackfillStatusCompleted)
+	}
+}
+
+func TestBackfiller_AdvanceDoesNotRevertCancel(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	bfRepo := mock.NewBackfillRepo()
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "@daily"}
+	_ = wfRepo.Create(ctx, wf)
+	b := &idomain.Backfill{
+		ID:            uuid.New(),
+		WorkflowID:    wf.ID,
+		Start:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:           time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+		MaxActiveRuns: 1,
+		Status:        idomain.BackfillStatusRunning,
+		Total:         3,
+	}
+	_ = bfRepo.Create(ctx, b)
+
+	// The backfill is cancelled after the Backfiller read it.
+	stale := *b
+	_ = bfRepo.Transition(ctx, b.ID, idomain.BackfillStatusRunning, idomain.BackfillStatusCancelled, time.Now())
+
+	if err := scheduler.NewBackfiller(bfRepo, wfRepo, runRepo).Advance(ctx, &stale); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+	got, _ := bfRepo.GetByID(ctx, b.ID)
+	if got.Status != idomain.BackfillStatusCancelled {
+		t.Errorf("status: got %s, want cancelled", got.Status)
+	}
+}
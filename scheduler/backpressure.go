@@ -0,0 +1,306 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+)
+
+// DefaultBackpressureInterval is how often Backpressure.Run measures the
+// queue.
+const DefaultBackpressureInterval = 15 * time.Second
+
+// Defaults of the failure rate measurement.
+const (
+	DefaultFailureWindow   = 5 * time.Minute
+	DefaultFailureMinTasks = 10
+)
+
+// Names of the alerts Backpressure raises.
+const (
+	AlertQueueDepth    = "queue_depth"
+	AlertOldestTaskAge = "oldest_task_age"
+	AlertFailureRate   = "failure_rate"
+)
+
+// Thresholds configures Backpressure. A zero threshold disables its alert.
+type Thresholds struct {
+	// QueueDepth alerts when more tasks than this wait in the queue.
+	QueueDepth int `json:"queue_depth"`
+	// OldestTaskAge alerts when a task has been queued longer than this.
+	OldestTaskAge time.Duration `json:"oldest_task_age"`
+	// FailureRate alerts when more than this share of the tasks finished
+	// within FailureWindow failed, once at least FailureMinTasks finished.
+	FailureRate     float64       `json:"failure_rate"`
+	FailureWindow   time.Duration `json:"failure_window"`
+	FailureMinTasks int           `json:"failure_min_tasks"`
+}
+
+// Enabled reports whether t sets any threshold.
+func (t Thresholds) Enabled() bool {
+	return t.QueueDepth > 0 || t.OldestTaskAge > 0 || t.FailureRate > 0
+}
+
+// Measurements are the values Backpressure compares with its Thresholds.
+// OldestTaskAge is in seconds.
+type Measurements struct {
+	QueueDepth    int     `json:"queue_depth"`
+	OldestTaskAge float64 `json:"oldest_task_age_seconds"`
+	FailureRate   float64 `json:"failure_rate"`
+	Finished      int     `json:"finished"`
+}
+
+// Backpressure watches the queue and recently finished tasks and raises an
+// alert while a measurement is over its threshold. Each alert is published
+// as an events.Alert when it starts firing and when it resolves, and the
+// alerts currently firing are kept on Alerts for health checks.
+type Backpressure struct {
+	th       Thresholds
+	queue    domain.Queue
+	tasks    domain.TaskRepository
+	events   events.Publisher
+	clock    clock.Clock
+	interval time.Duration
+	alerts   *events.AlertBoard
+}
+
+// BackpressureOption is a functional option for configuring a Backpressure.
+type BackpressureOption func(*Backpressure)
+
+// WithAlertEvents publishes alerts to p, from where the API relays them to
+// WebSocket clients and tracks them for its /healthz.
+func WithAlertEvents(p events.Publisher) BackpressureOption {
+	return func(b *Backpressure) { b.events = p }
+}
+
+// WithBackpressureClock sets the clock task ages and the failure window are
+// measured against. The default is clock.Real.
+func WithBackpressureClock(c clock.Clock) BackpressureOption {
+	return func(b *Backpressure) { b.clock = c }
+}
+
+// WithBackpressureInterval sets how often Run measures. The default is
+// DefaultBackpressureInterval.
+func WithBackpressureInterval(d time.Duration) BackpressureOption {
+	return func(b *Backpressure) { b.interval = d }
+}
+
+// NewBackpressure creates a Backpressure applying th to queue and the queue
+// tasks in tasks.
+func NewBackpressure(th Thresholds, queue domain.Queue, tasks domain.TaskRepository, opts ...BackpressureOption) *Backpressure {
+	if th.FailureWindow <= 0 {
+		th.FailureWindow = DefaultFailureWindow
+	}
+	if th.FailureMinTasks <= 0 {
+		th.FailureMinTasks = DefaultFailureMinTasks
+	}
+	b := &Backpressure{
+		th:       th,
+		queue:    queue,
+		tasks:    tasks,
+		events:   events.Discard,
+		clock:    clock.Real,
+		interval: DefaultBackpressureInterval,
+		alerts:   events.NewAlertBoard(),
+	}
+	for _, o := range opts {
+		o(b)
+	}
+	return b
+}
+
+// Thresholds returns the thresholds b applies, with defaults filled in.
+func (b *Backpressure) Thresholds() Thresholds {
+	return b.th
+}
+
+// Alerts returns the board of alerts b has raised and not yet resolved.
+func (b *Backpressure) Alerts() *events.AlertBoard {
+	return b.alerts
+}
+
+// Run calls Check every interval until ctx is cancelled. It always returns
+// nil when the context expires.
+func (b *Backpressure) Run(ctx context.Context) error {
+	ticker := b.clock.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C():
+			if _, err := b.Check(ctx); err != nil {
+				log.Printf("Backpressure: %v", err)
+			}
+		}
+	}
+}
+
+// Check measures once, raises or resolves each alert whose state changed,
+// and returns the measurements.
+func (b *Backpressure) Check(ctx context.Context) (Measurements, error) {
+	m, err := b.measure(ctx)
+	if err != nil {
+		return m, err
+	}
+	now := b.clock.Now()
+	if b.th.QueueDepth > 0 {
+		b.update(ctx, AlertQueueDepth, float64(m.QueueDepth), float64(b.th.QueueDepth), now,
+			fmt.Sprintf("%d tasks queued, threshold %d", m.QueueDepth, b.th.QueueDepth))
+	}
+	if b.th.OldestTaskAge > 0 {
+		age := time.Duration(m.OldestTaskAge * float64(time.Second)).Truncate(time.Second)
+		b.update(ctx, AlertOldestTaskAge, m.OldestTaskAge, b.th.OldestTaskAge.Seconds(), now,
+			fmt.Sprintf("oldest queued task waiting %s, threshold %s", age, b.th.OldestTaskAge))
+	}
+	if b.th.FailureRate > 0 {
+		value := m.FailureRate
+		if m.Finished < b.th.FailureMinTasks {
+			value = 0 // too few tasks to judge
+		}
+		b.update(ctx, AlertFailureRate, value, b.th.FailureRate, now,
+			fmt.Sprintf("%.0f%% of %d tasks failed in the last %s, threshold %.0f%%",
+				m.FailureRate*100, m.Finished, b.th.FailureWindow, b.th.FailureRate*100))
+	}
+	return m, nil
+}
+
+// update publishes an alert when its state changes: firing while value is
+// over threshold, resolved once it is not.
+func (b *Backpressure) update(ctx context.Context, name string, value, threshold float64, now time.Time, msg string) {
+	firing := value > threshold
+	wasFiring := false
+	for _, a := range b.alerts.Firing() {
+		if a.Name == name {
+			wasFiring = true
+		}
+	}
+	if firing == wasFiring {
+		return
+	}
+	a := events.AlertUpdate{Name: name, Firing: firing, Value: value, Threshold: threshold, Message: msg, At: now}
+	b.alerts.Record(a)
+	if firing {
+		log.Printf("Backpressure: %s alert firing: %s", name, msg)
+	} else {
+		log.Printf("Backpressure: %s alert resolved", name)
+	}
+	if err := b.events.Publish(ctx, events.Event{Type: events.Alert, Payload: a}); err != nil {
+		log.Printf("Backpressure: publish %s alert: %v", name, err)
+	}
+}
+
+// measure gathers the values only the enabled thresholds need.
+func (b *Backpressure) measure(ctx context.Context) (Measurements, error) {
+	var m Measurements
+	now := b.clock.Now()
+	if b.th.QueueDepth > 0 {
+		n, err := b.queue.Len(ctx)
+		if err != nil {
+			return m, fmt.Errorf("queue length: %w", err)
+		}
+		m.QueueDepth = n
+	}
+	if b.th.OldestTaskAge > 0 {
+		queued, err := b.tasks.FindByStatus(ctx, domain.TaskStatusQueued)
+		if err != nil {
+			return m, fmt.Errorf("list queued tasks: %w", err)
+		}
+		for _, t := range queued {
+			if age := now.Sub(t.UpdatedAt).Seconds(); age > m.OldestTaskAge {
+				m.OldestTaskAge = age
+			}
+		}
+	}
+	if b.th.FailureRate > 0 {
+		since := now.Add(-b.th.FailureWindow)
+		var failed int
+		for _, status := range []domain.TaskStatus{domain.TaskStatusSucceeded, domain.TaskStatusFailed} {
+			tasks, err := b.tasks.FindByStatus(ctx, status)
+			if err != nil {
+				return m, fmt.Errorf("list %s tasks: %w", status, err)
+			}
+			for _, t := range tasks {
+				if t.FinishedAt == nil || t.FinishedAt.Before(since) {
+					continue
+				}
+				m.Finished++
+				if status == domain.TaskStatusFailed {
+					failed++
+				}
+			}
+		}
+		if m.Finished > 0 {
+			m.FailureRate = float64(failed) / float64(m.Finished)
+		}
+	}
+	return m, nil
+}
+
+// ParseThresholds parses a comma-separated list of key=value thresholds,
+// e.g. "queue_depth=1000,oldest_task_age=10m,failure_rate=0.2". Keys:
+//
+//	queue_depth         QueueDepth
+//	oldest_task_age     OldestTaskAge, a duration
+//	failure_rate        FailureRate, between 0 and 1
+//	failure_window      FailureWindow, a duration
+//	failure_min_tasks   FailureMinTasks
+//
+// An empty string returns zero Thresholds.
+func ParseThresholds(s string) (Thresholds, error) {
+	var th Thresholds
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return Thresholds{}, fmt.Errorf("threshold %q: must be key=value", entry)
+		}
+		var err error
+		switch key {
+		case "queue_depth":
+			th.QueueDepth, err = strconv.Atoi(value)
+			if err == nil && th.QueueDepth < 0 {
+				err = errors.New("must not be negative")
+			}
+		case "oldest_task_age":
+			th.OldestTaskAge, err = parseDuration(value)
+		case "failure_rate":
+			th.FailureRate, err = strconv.ParseFloat(value, 64)
+			if err == nil && (th.FailureRate < 0 || th.FailureRate > 1) {
+				err = errors.New("must be between 0 and 1")
+			}
+		case "failure_window":
+			th.FailureWindow, err = parseDuration(value)
+		case "failure_min_tasks":
+			th.FailureMinTasks, err = strconv.Atoi(value)
+			if err == nil && th.FailureMinTasks < 0 {
+				err = errors.New("must not be negative")
+			}
+		default:
+			return Thresholds{}, fmt.Errorf("threshold %q: unknown key %q", entry, key)
+		}
+		if err != nil {
+			return Thresholds{}, fmt.Errorf("threshold %q: %v", entry, err)
+		}
+	}
+	return th, nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		err = errors.New("must not be negative")
+	}
+	return d, err
+}
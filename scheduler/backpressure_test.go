@@ -0,0 +1,127 @@
+package scheduler_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+func TestBackpressure_RaisesAndResolvesAlerts(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	queue, tasks := scheduler.NewMemQueue(), scheduler.NewMemTaskRepo()
+	bus := events.NewMemBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub, _ := bus.Subscribe(ctx)
+
+	bp := scheduler.NewBackpressure(scheduler.Thresholds{QueueDepth: 2, OldestTaskAge: time.Minute},
+		queue, tasks, scheduler.WithAlertEvents(bus), scheduler.WithBackpressureClock(fake))
+	for _, id := range []string{"a", "b", "c"} {
+		task := &domain.Task{ID: id, Name: id, Status: domain.TaskStatusQueued, UpdatedAt: now}
+		_ = tasks.Save(ctx, task)
+		_ = queue.Enqueue(ctx, task)
+	}
+	fake.Advance(2 * time.Minute)
+
+	m, err := bp.Check(ctx)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if m.QueueDepth != 3 || m.OldestTaskAge != 120 {
+		t.Errorf("measurements: got %+v", m)
+	}
+	if !bp.Alerts().Degraded() || len(bp.Alerts().Firing()) != 2 {
+		t.Fatalf("firing: got %+v, want queue_depth and oldest_task_age", bp.Alerts().Firing())
+	}
+	// A second check over the thresholds publishes nothing new.
+	_, _ = bp.Check(ctx)
+
+	for range 3 {
+		_, _ = queue.Dequeue(ctx)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		_ = tasks.Save(ctx, &domain.Task{ID: id, Name: id, Status: domain.TaskStatusRunning})
+	}
+	if _, err := bp.Check(ctx); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if bp.Alerts().Degraded() {
+		t.Errorf("firing after recovery: %+v", bp.Alerts().Firing())
+	}
+
+	var got []events.AlertUpdate
+	for len(got) < 4 {
+		select {
+		case e := <-sub:
+			var a events.AlertUpdate
+			if e.Type != events.Alert || e.DecodePayload(&a) != nil {
+				t.Fatalf("unexpected event %+v", e)
+			}
+			got = append(got, a)
+		case <-time.After(time.Second):
+			t.Fatalf("got %d alert events, want 4", len(got))
+		}
+	}
+	select {
+	case e := <-sub:
+		t.Errorf("unexpected extra event %+v", e)
+	default:
+	}
+	if !got[0].Firing || !got[1].Firing || got[2].Firing || got[3].Firing {
+		t.Errorf("alert sequence: got %+v, want two firing then two resolved", got)
+	}
+}
+
+func TestBackpressure_FailureRate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tasks := scheduler.NewMemTaskRepo()
+	bp := scheduler.NewBackpressure(scheduler.Thresholds{FailureRate: 0.5, FailureMinTasks: 4},
+		scheduler.NewMemQueue(), tasks, scheduler.WithBackpressureClock(clock.NewFake(now)))
+
+	finish := func(id string, status domain.TaskStatus, ago time.Duration) {
+		at := now.Add(-ago)
+		_ = tasks.Save(ctx, &domain.Task{ID: id, Name: id, Status: status, FinishedAt: &at})
+	}
+	finish("f1", domain.TaskStatusFailed, time.Minute)
+	finish("f2", domain.TaskStatusFailed, time.Minute)
+	finish("f3", domain.TaskStatusFailed, time.Minute)
+	finish("old", domain.TaskStatusSucceeded, time.Hour) // outside the window
+
+	// Three failures out of three is too few tasks to judge.
+	if m, _ := bp.Check(ctx); m.Finished != 3 || bp.Alerts().Degraded() {
+		t.Fatalf("below FailureMinTasks: got %+v, firing %v", m, bp.Alerts().Firing())
+	}
+	finish("s1", domain.TaskStatusSucceeded, time.Minute)
+	m, _ := bp.Check(ctx)
+	if m.FailureRate != 0.75 || !bp.Alerts().Degraded() {
+		t.Errorf("got %+v, firing %v; want a 0.75 failure rate alert", m, bp.Alerts().Firing())
+	}
+}
+
+func TestParseThresholds(t *testing.T) {
+	th, err := scheduler.ParseThresholds("queue_depth=1000, oldest_task_age=10m,failure_rate=0.2,failure_window=15m,failure_min_tasks=20")
+	if err != nil {
+		t.Fatalf("ParseThresholds: %v", err)
+	}
+	want := scheduler.Thresholds{
+		QueueDepth: 1000, OldestTaskAge: 10 * time.Minute,
+		FailureRate: 0.2, FailureWindow: 15 * time.Minute, FailureMinTasks: 20,
+	}
+	if th != want || !th.Enabled() {
+		t.Errorf("got %+v, want %+v", th, want)
+	}
+	if th, err := scheduler.ParseThresholds(""); err != nil || th.Enabled() {
+		t.Errorf("empty: got %+v, %v; want disabled", th, err)
+	}
+	for _, bad := range []string{"queue_depth", "queue_depth=-1", "failure_rate=2", "oldest_task_age=soon", "bogus=1"} {
+		if _, err := scheduler.ParseThresholds(bad); err == nil {
+			t.Errorf("ParseThresholds(%q): want error", bad)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// DefaultBreakerThreshold is the number of consecutive failures that opens a
+// circuit when BreakerConfig.Threshold is zero.
+const DefaultBreakerThreshold = 10
+
+// BreakerConfig configures a CircuitBreaker.
+type BreakerConfig struct {
+	// Threshold is the number of consecutive terminal failures that opens a
+	// circuit. Zero means DefaultBreakerThreshold.
+	Threshold int
+	// Cooldown closes an open circuit automatically after this long. Zero
+	// keeps it open until Reset is called.
+	Cooldown time.Duration
+	// OnOpen is called, outside the breaker's lock, each time a circuit
+	// opens. The default logs the event.
+	OnOpen func(key string, failures int)
+}
+
+// BreakerState is a snapshot of one circuit.
+type BreakerState struct {
+	Key                 string     `json:"key"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Open                bool       `json:"open"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+// CircuitBreaker pauses dispatch for a task name or workflow once its runs
+// keep failing, so retries stop piling up against a dead dependency. Each
+// task is tracked under two circuits, "task:<Name>" and, when set,
+// "workflow:<WorkflowID>"; a task is held while either is open.
+// CircuitBreaker is safe for concurrent use.
+type CircuitBreaker struct {
+	cfg BreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	failures int
+	openedAt time.Time // zero while closed
+}
+
+// NewCircuitBreaker creates a CircuitBreaker with cfg.
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = DefaultBreakerThreshold
+	}
+	if cfg.OnOpen == nil {
+		cfg.OnOpen = func(key string, failures int) {
+			log.Printf("CircuitBreaker: %s opened after %d consecutive failures; dispatch paused", key, failures)
+		}
+	}
+	return &CircuitBreaker{cfg: cfg, now: time.Now, circuits: make(map[string]*circuit)}
+}
+
+// BreakerKeys returns the circuits task is tracked under.
+func BreakerKeys(task *domain.Task) []string {
+	keys := []string{"task:" + task.Name}
+	if task.WorkflowID != "" {
+		keys = append(keys, "workflow:"+task.WorkflowID)
+	}
+	return keys
+}
+
+// Allow reports whether task may be dispatched, i.e. none of its circuits is
+// open. Circuits whose cooldown has elapsed are closed first.
+func (b *CircuitBreaker) Allow(task *domain.Task) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, key := range BreakerKeys(task) {
+		c := b.circuits[key]
+		if c == nil || c.openedAt.IsZero() {
+			continue
+		}
+		if b.cfg.Cooldown > 0 && b.now().Sub(c.openedAt) >= b.cfg.Cooldown {
+			c.openedAt = time.Time{}
+			c.failures = 0
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// Record updates task's circuits with its terminal outcome. A success
+// resets the failure count; a failure that reaches the threshold opens the
+// circuit and fires OnOpen.
+func (b *CircuitBreaker) Record(task *domain.Task) {
+	var opened []string
+	var failures []int
+
+	b.mu.Lock()
+	for _, key := range BreakerKeys(task) {
+		c := b.circuits[key]
+		if c == nil {
+			c = &circuit{}
+			b.circuits[key] = c
+		}
+		switch task.Status {
+		case domain.TaskStatusSucceeded:
+			c.failures = 0
+		case domain.TaskStatusFailed:
+			c.failures++
+			if c.openedAt.IsZero() && c.failures >= b.cfg.Threshold {
+				c.openedAt = b.now()
+				opened = append(opened, key)
+				failures = append(failures, c.failures)
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	for i, key := range opened {
+		b.cfg.OnOpen(key, failures[i])
+	}
+}
+
+// Reset closes the circuit for key and clears its failure count. It reports
+// whether the circuit was open.
+func (b *CircuitBreaker) Reset(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[key]
+	if c == nil {
+		return false
+	}
+	wasOpen := !c.openedAt.IsZero()
+	delete(b.circuits, key)
+	return wasOpen
+}
+
+// States returns a snapshot of every circuit with at least one recorded
+// failure, sorted by key.
+func (b *CircuitBreaker) States() []BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]BreakerState, 0, len(b.circuits))
+	for key, c := range b.circuits {
+		if c.failures == 0 {
+			continue
+		}
+		st := BreakerState{Key: key, ConsecutiveFailures: c.failures, Open: !c.openedAt.IsZero()}
+		if st.Open {
+			at := c.openedAt
+			st.OpenedAt = &at
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
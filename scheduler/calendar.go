@@ -0,0 +1,227 @@
+package scheduler
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// DefaultICalRefresh is how long Calendars caches a fetched iCal feed.
+const DefaultICalRefresh = time.Hour
+
+// maxEventDays bounds how many days a single iCal event may exclude, so a
+// malformed feed cannot allocate without limit.
+const maxEventDays = 366
+
+// Calendars answers whether a calendar excludes a given day, merging its
+// ExcludedDates with the events of its iCal feed. Feeds are fetched lazily
+// and cached for DefaultICalRefresh; when a refresh fails the previous copy
+// keeps being used.
+type Calendars struct {
+	repo   repository.CalendarRepository
+	client *http.Client
+
+	mu    sync.Mutex
+	feeds map[string]icalFeed
+}
+
+type icalFeed struct {
+	days    map[string]bool
+	fetched time.Time
+}
+
+// NewCalendars creates a Calendars reading calendar definitions from repo.
+func NewCalendars(repo repository.CalendarRepository) *Calendars {
+	return &Calendars{
+		repo:   repo,
+		client: &http.Client{Timeout: 10 * time.Second},
+		feeds:  make(map[string]icalFeed),
+	}
+}
+
+// Excludes reports whether the calendar with the given ID excludes the day
+// t falls on in t's location.
+func (c *Calendars) Excludes(ctx context.Context, id uuid.UUID, t time.Time) (bool, error) {
+	cal, err := c.repo.GetByID(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("load calendar %s: %w", id, err)
+	}
+	day := t.Format(domain.CalendarDateLayout)
+	if cal.Excludes(day) {
+		return true, nil
+	}
+	if cal.ICalURL == "" {
+		return false, nil
+	}
+	days, err := c.feed(ctx, cal.ICalURL)
+	if err != nil {
+		return false, fmt.Errorf("calendar %s: %w", id, err)
+	}
+	return days[day], nil
+}
+
+// feed returns the excluded days of the iCal feed at url, fetching it when
+// the cached copy is missing or older than DefaultICalRefresh.
+func (c *Calendars) feed(ctx context.Context, url string) (map[string]bool, error) {
+	c.mu.Lock()
+	cached, ok := c.feeds[url]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetched) < DefaultICalRefresh {
+		return cached.days, nil
+	}
+
+	days, err := c.fetch(ctx, url)
+	if err != nil {
+		if ok {
+			return cached.days, nil
+		}
+		return nil, err
+	}
+	set := make(map[string]bool, len(days))
+	for _, d := range days {
+		set[d] = true
+	}
+	c.mu.Lock()
+	c.feeds[url] = icalFeed{days: set, fetched: time.Now()}
+	c.mu.Unlock()
+	return set, nil
+}
+
+func (c *Calendars) fetch(ctx context.Context, url string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch ical feed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch ical feed: %s", resp.Status)
+	}
+	return ParseICal(resp.Body)
+}
+
+// ParseICal returns the days, in domain.CalendarDateLayout, covered by the
+// VEVENTs of an iCalendar (RFC 5545) document. All-day events cover
+// [DTSTART, DTEND); timed events cover every day they touch in their own
+// timezone. Recurrence rules are not expanded.
+func ParseICal(r io.Reader) ([]string, error) {
+	var (
+		days       []string
+		inEvent    bool
+		start, end string
+		lines      []string
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		// Long lines are folded onto continuation lines starting with
+		// whitespace.
+		if n := len(lines); n > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[n-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		prop, params, _ := strings.Cut(name, ";")
+		switch strings.ToUpper(prop) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent, start, end = true, "", ""
+			}
+		case "DTSTART":
+			start = params + ":" + value
+		case "DTEND":
+			end = params + ":" + value
+		case "END":
+			if !inEvent || !strings.EqualFold(value, "VEVENT") {
+				continue
+			}
+			inEvent = false
+			if start == "" {
+				continue
+			}
+			eventDays, err := icalEventDays(start, end)
+			if err != nil {
+				return nil, err
+			}
+			days = append(days, eventDays...)
+		}
+	}
+	return days, nil
+}
+
+// icalEventDays expands one event's DTSTART/DTEND ("params:value") into days.
+func icalEventDays(start, end string) ([]string, error) {
+	from, allDay, err := icalTime(start)
+	if err != nil {
+		return nil, fmt.Errorf("ical DTSTART %q: %w", start, err)
+	}
+	to := from
+	if end != "" {
+		if to, _, err = icalTime(end); err != nil {
+			return nil, fmt.Errorf("ical DTEND %q: %w", end, err)
+		}
+	}
+	// DTEND is exclusive: an all-day event ending on the 26th, or a timed
+	// event ending at midnight, does not cover that day.
+	last := to
+	if to.After(from) && (allDay || to.Equal(truncateDay(to))) {
+		last = to.AddDate(0, 0, -1)
+	}
+	var days []string
+	for d := truncateDay(from); !d.After(last) && len(days) < maxEventDays; d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format(domain.CalendarDateLayout))
+	}
+	return days, nil
+}
+
+// icalTime parses a "params:value" date or date-time. Date-times in UTC end
+// in Z; others use the TZID parameter, or UTC when it is absent.
+func icalTime(s string) (t time.Time, allDay bool, err error) {
+	params, value, _ := strings.Cut(s, ":")
+	loc := time.UTC
+	for _, p := range strings.Split(params, ";") {
+		if k, v, ok := strings.Cut(p, "="); ok && strings.EqualFold(k, "TZID") {
+			if loc, err = time.LoadLocation(v); err != nil {
+				return time.Time{}, false, err
+			}
+		}
+	}
+	switch {
+	case len(value) == 8:
+		t, err = time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, err
+	default:
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+		return t, false, err
+	}
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,98 @@
+package scheduler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+const holidayFeed = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\nSUMMARY:Christmas\r\nDTSTART;VALUE=DATE:20241225\r\nDTEND;VALUE=DATE:20241227\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nSUMMARY:Maintenance\r\nDTSTART;TZID=Europe/Berlin:20240301T220000\r\n" +
+	" \r\nDTEND;TZID=Europe/Berlin:20240302T020000\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICal(t *testing.T) {
+	days, err := scheduler.ParseICal(strings.NewReader(holidayFeed))
+	if err != nil {
+		t.Fatalf("ParseICal: %v", err)
+	}
+	want := []string{"2024-12-25", "2024-12-26", "2024-03-01", "2024-03-02"}
+	if fmt.Sprint(days) != fmt.Sprint(want) {
+		t.Errorf("days: got %v, want %v", days, want)
+	}
+}
+
+func TestCalendars_MergesDatesAndFeed(t *testing.T) {
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches++
+		_, _ = w.Write([]byte(holidayFeed))
+	}))
+	defer srv.Close()
+
+	repo := mock.NewCalendarRepo()
+	cal := &idomain.Calendar{ID: uuid.New(), Name: "holidays", ExcludedDates: []string{"2024-01-01"}, ICalURL: srv.URL}
+	_ = repo.Create(ctx, cal)
+	cals := scheduler.NewCalendars(repo)
+
+	for day, want := range map[string]bool{"2024-01-01": true, "2024-12-26": true, "2024-12-27": false} {
+		at, _ := time.Parse("2006-01-02", day)
+		got, err := cals.Excludes(ctx, cal.ID, at.Add(9*time.Hour))
+		if err != nil {
+			t.Fatalf("Excludes(%s): %v", day, err)
+		}
+		if got != want {
+			t.Errorf("Excludes(%s): got %v, want %v", day, got, want)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("feed fetched %d times, want 1 (cached)", fetches)
+	}
+}
+
+func TestCronTrigger_RecordsExcludedDayAsSkipped(t *testing.T) {
+	wfRepo, runRepo, calRepo := mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewCalendarRepo()
+	cal := &idomain.Calendar{ID: uuid.New(), Name: "holidays", ExcludedDates: []string{"2024-01-02"}}
+	_ = calRepo.Create(ctx, cal)
+	// 01:30 in Kolkata is 20:00 UTC the previous day, so the fire at
+	// 2024-01-01T20:00Z falls on the excluded local day 2024-01-02.
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "30 1 * * *", IsActive: true,
+		Timezone: "Asia/Kolkata", CalendarID: &cal.ID}
+	_ = wfRepo.Create(ctx, wf)
+
+	fc := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc),
+		scheduler.WithCalendars(scheduler.NewCalendars(calRepo)))
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+
+	for _, day := range []int{1, 2} {
+		fc.BlockUntil(1)
+		fc.Set(time.Date(2024, 1, day, 20, 0, 0, 0, time.UTC))
+	}
+	fc.BlockUntil(1)
+
+	runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+	if len(runs) != 2 {
+		t.Fatalf("runs: got %d, want 2", len(runs))
+	}
+	statuses := map[int]idomain.Status{}
+	for _, r := range runs {
+		statuses[r.StartedAt.Day()] = r.Status
+	}
+	if statuses[1] != idomain.StatusSkipped || statuses[2] != idomain.StatusPending {
+		t.Errorf("statuses by day: got %v, want day 1 skipped, day 2 pending", statuses)
+	}
+}
@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// MaxCatchupRuns caps the runs CronTrigger creates for one workflow's
+// missed fires when it starts; the oldest fires beyond it are dropped.
+const MaxCatchupRuns = 1000
+
+// catchUp creates the runs of wf's fires missed while no trigger was
+// running, as its Catchup policy asks, and returns the fire a pause window
+// that is still open delays, if any, for the loop to retry. Fires are
+// missed from the latest logical date of the workflow's scheduled runs, or
+// its creation if it has none, up to the last one whose jittered time has
+// passed; fires a run already covers, such as a backfilled one, are left
+// alone.
+func (ct *CronTrigger) catchUp(ctx context.Context, wf *domain.Workflow, sched cron.Schedule, now time.Time) *delayedFire {
+	if wf.Catchup == "" || wf.Catchup == domain.CatchupNone {
+		return nil
+	}
+	runs, err := ct.workflowRuns.ListByWorkflowID(ctx, wf.ID)
+	if err != nil {
+		log.Printf("CronTrigger: workflow %s: catch up: list runs: %v", wf.ID, err)
+		return nil
+	}
+	from := wf.CreatedAt
+	covered := make(map[int64]bool, len(runs))
+	for _, r := range runs {
+		if r.LogicalDate == nil {
+			continue
+		}
+		covered[r.LogicalDate.Unix()] = true
+		if r.Labels[domain.LabelBackfill] != "true" && r.LogicalDate.After(from) {
+			from = *r.LogicalDate
+		}
+	}
+	if from.IsZero() {
+		return nil
+	}
+
+	var missed []time.Time
+	dropped := 0
+	last := now.Add(-ct.jitterFor(wf.ID))
+	for t := sched.Next(from); !t.IsZero() && !t.After(last); t = sched.Next(t) {
+		if covered[t.Unix()] {
+			continue
+		}
+		if len(missed) == MaxCatchupRuns {
+			missed, dropped = missed[1:], dropped+1
+		}
+		missed = append(missed, t)
+	}
+	if len(missed) == 0 {
+		return nil
+	}
+	if wf.Catchup == domain.CatchupLatest {
+		dropped += len(missed) - 1
+		missed = missed[len(missed)-1:]
+	}
+	log.Printf("CronTrigger: workflow %s: catching up %d missed fires from %s (%d dropped)",
+		wf.ID, len(missed), missed[0].UTC().Format(time.RFC3339), dropped)
+
+	for _, t := range missed {
+		d := ct.fire(ctx, wf.ID, t, now, &delayedFire{logical: t, catchup: true})
+		if d != nil && !d.until.After(now) {
+			d = ct.fire(ctx, wf.ID, d.until, now, d)
+		}
+		if d != nil {
+			// The window is still open; later missed fires fall in it too
+			// and coalesce into this one, as fires do while it runs.
+			return d
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// Keys of a ConcurrencyLimits. GlobalConcurrencyLimit caps every task; a
+// key made of WorkflowLimitPrefix and a workflow ID caps the tasks of that
+// workflow, and one made of TaskLimitPrefix and a task Name the tasks of
+// that name, whichever workflow they belong to.
+const (
+	GlobalConcurrencyLimit = "*"
+	WorkflowLimitPrefix    = "workflow:"
+	TaskLimitPrefix        = "task:"
+)
+
+// ConcurrencyLimits caps how many tasks run at once across the cluster, in
+// total and per workflow or task name, so that a burst of work cannot
+// overwhelm the systems the tasks call. Like a Pools slot, a task holds one
+// slot of every limit that applies to it from dispatch until it reaches a
+// terminal state, and a task that cannot get all of them is held back.
+// Keys with no limit are unlimited. ConcurrencyLimits is safe for
+// concurrent use.
+type ConcurrencyLimits struct {
+	mu    sync.Mutex
+	limit map[string]int
+	used  map[string]int
+}
+
+// NewConcurrencyLimits returns ConcurrencyLimits with the given number of
+// slots per key.
+func NewConcurrencyLimits(limits map[string]int) *ConcurrencyLimits {
+	l := &ConcurrencyLimits{limit: make(map[string]int, len(limits)), used: make(map[string]int)}
+	for key, n := range limits {
+		l.limit[key] = n
+	}
+	return l
+}
+
+// limitKeys returns the keys whose limits apply to task.
+func limitKeys(task *domain.Task) []string {
+	keys := []string{GlobalConcurrencyLimit, TaskLimitPrefix + task.Name}
+	if task.WorkflowID != "" {
+		keys = append(keys, WorkflowLimitPrefix+task.WorkflowID)
+	}
+	return keys
+}
+
+// TryAcquire takes a slot of every limit that applies to task and reports
+// whether it could; if any is full it takes none.
+func (l *ConcurrencyLimits) TryAcquire(task *domain.Task) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	keys := limitKeys(task)
+	for _, key := range keys {
+		if n, ok := l.limit[key]; ok && l.used[key] >= n {
+			return false
+		}
+	}
+	for _, key := range keys {
+		if _, ok := l.limit[key]; ok {
+			l.used[key]++
+		}
+	}
+	return true
+}
+
+// Release returns the slots TryAcquire took for task.
+func (l *ConcurrencyLimits) Release(task *domain.Task) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range limitKeys(task) {
+		if l.used[key] > 0 {
+			l.used[key]--
+		}
+	}
+}
+
+// Usage returns a snapshot of every configured limit.
+func (l *ConcurrencyLimits) Usage() map[string]PoolUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]PoolUsage, len(l.limit))
+	for key, n := range l.limit {
+		out[key] = PoolUsage{Size: n, Used: l.used[key]}
+	}
+	return out
+}
+
+// ParseConcurrencyLimits parses a concurrency limit specification such as
+// "*=50,task:load-warehouse=2,workflow:<id>=4", in the syntax of
+// ParsePools with keys as ConcurrencyLimits takes them.
+func ParseConcurrencyLimits(spec string) (map[string]int, error) {
+	limits, err := ParsePools(spec)
+	if err != nil {
+		return nil, err
+	}
+	for key := range limits {
+		name, ok := strings.CutPrefix(key, TaskLimitPrefix)
+		if !ok {
+			name, ok = strings.CutPrefix(key, WorkflowLimitPrefix)
+		}
+		if key != GlobalConcurrencyLimit && (!ok || name == "") {
+			return nil, fmt.Errorf("concurrency limit %q: key must be %q, %sNAME or %sID", key, GlobalConcurrencyLimit, TaskLimitPrefix, WorkflowLimitPrefix)
+		}
+	}
+	return limits, nil
+}
@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+)
+
+// DefaultCronReloadInterval is how often cmd/scheduler reloads schedules
+// unless CRON_RELOAD_INTERVAL says otherwise.
+const DefaultCronReloadInterval = time.Minute
+
+// WithReloadInterval makes the running trigger call Reload every d, so
+// workflows created or edited by any means, including straight in the
+// database, are scheduled within d. Zero, the default, never reloads on
+// its own.
+func WithReloadInterval(d time.Duration) CronTriggerOption {
+	return func(ct *CronTrigger) { ct.reloadEvery = d }
+}
+
+// WithReloadEvents makes the running trigger call Reload whenever an
+// events.WorkflowChanged event arrives on bus, as the API publishes after
+// every workflow mutation.
+func WithReloadEvents(bus events.Bus) CronTriggerOption {
+	return func(ct *CronTrigger) { ct.changes = bus }
+}
+
+// WithFireEvents publishes a WorkflowStatus event to p for every run the
+// trigger creates, pending or skipped, so feeds show a fire before the
+// orchestrator starts its run, and show skipped fires at all.
+func WithFireEvents(p events.Publisher) CronTriggerOption {
+	return func(ct *CronTrigger) { ct.events = p }
+}
+
+// Reload lists the active workflows again and brings the running trigger's
+// schedules in line: new workflows are scheduled from now, workflows no
+// longer active or scheduled are dropped, and a workflow whose schedule or
+// timezone changed fires next by its new schedule. Unchanged workflows
+// keep their next fire, and a fire delayed by a pause window still
+// happens when the window closes. Reload does not catch up fires; that
+// only happens on Start. It may be called whether or not the trigger is
+// running.
+func (ct *CronTrigger) Reload(ctx context.Context) error {
+	wfs, err := ct.workflows.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("cron trigger: reload: list active workflows: %w", err)
+	}
+	entries, scheduled := schedules(wfs)
+	ct.mu.Lock()
+	ct.entries, ct.scheduled = entries, scheduled
+	ct.mu.Unlock()
+	select {
+	case ct.reload <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// schedules parses the schedule of every workflow of wfs that has one,
+// logging and leaving out those that do not parse.
+func schedules(wfs []*domain.Workflow) (map[uuid.UUID]cron.Schedule, map[uuid.UUID]*domain.Workflow) {
+	entries := make(map[uuid.UUID]cron.Schedule, len(wfs))
+	scheduled := make(map[uuid.UUID]*domain.Workflow, len(wfs))
+	for _, wf := range wfs {
+		if wf.ScheduleCron == "" {
+			continue
+		}
+		sched, err := WorkflowSchedule(wf)
+		if err != nil {
+			log.Printf("CronTrigger: workflow %s: invalid schedule %q: %v", wf.ID, wf.ScheduleCron, err)
+			continue
+		}
+		entries[wf.ID] = sched
+		scheduled[wf.ID] = wf
+	}
+	return entries, scheduled
+}
+
+// sameSchedule reports whether a and b fire at the same times.
+func sameSchedule(a, b *domain.Workflow) bool {
+	return a != nil && b != nil && a.ScheduleCron == b.ScheduleCron && a.Timezone == b.Timezone &&
+		sameDate(a.StartDate, b.StartDate) && sameDate(a.EndDate, b.EndDate)
+}
+
+// sameDate reports whether a and b are both unset or the same instant.
+func sameDate(a, b *time.Time) bool {
+	return a == b || (a != nil && b != nil && a.Equal(*b))
+}
+
+// subscribeChanges subscribes to the bus set with WithReloadEvents until
+// ctx is done, returning nil if there is none or it fails.
+func (ct *CronTrigger) subscribeChanges(ctx context.Context) <-chan events.Event {
+	if ct.changes == nil {
+		return nil
+	}
+	sub, err := ct.changes.Subscribe(ctx)
+	if err != nil {
+		log.Printf("CronTrigger: subscribe to workflow changes: %v; relying on periodic reloads", err)
+		return nil
+	}
+	return sub
+}
@@ -0,0 +1,569 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// cronParser accepts standard five-field expressions plus descriptors such as
+// "@daily" and "@every 5m". A leading "CRON_TZ=<zone>" selects the timezone.
+var cronParser = cron.NewParser(
+	cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// cronFields names the fields of a five-field cron expression in order.
+var cronFields = [5]string{"minute", "hour", "day of month", "month", "day of week"}
+
+// CronError reports an invalid cron expression. When the fault lies in a
+// single field, Field names it and Pos is the 1-based column it starts at.
+type CronError struct {
+	Expr  string
+	Field string
+	Pos   int
+	Err   error
+}
+
+func (e *CronError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("invalid cron expression %q: %v", e.Expr, e.Err)
+	}
+	return fmt.Sprintf("invalid cron expression %q: %s at column %d: %v", e.Expr, e.Field, e.Pos, e.Err)
+}
+
+func (e *CronError) Unwrap() error { return e.Err }
+
+// ParseCron parses expr with the same parser CronTrigger uses to schedule
+// workflows, so callers can validate or preview a schedule ahead of time.
+// Parse failures are returned as a *CronError.
+func ParseCron(expr string) (cron.Schedule, error) {
+	sched, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, locateCronError(expr, err)
+	}
+	return sched, nil
+}
+
+// locateCronError wraps err, the parser's error for expr, in a CronError
+// pointing at the timezone prefix or the field responsible, when there is
+// exactly one.
+func locateCronError(expr string, err error) *CronError {
+	ce := &CronError{Expr: expr, Err: err}
+	body, start := expr, 0
+	if strings.HasPrefix(expr, "CRON_TZ=") || strings.HasPrefix(expr, "TZ=") {
+		eq := strings.IndexByte(expr, '=')
+		end := strings.IndexByte(expr, ' ')
+		if end < 0 {
+			end = len(expr)
+		}
+		if _, tzErr := time.LoadLocation(expr[eq+1 : end]); tzErr != nil {
+			ce.Field, ce.Pos = "timezone", eq+2
+			return ce
+		}
+		body, start = expr[end:], end
+	}
+
+	// Record where each whitespace-separated field starts.
+	var fields []string
+	var cols []int
+	for i := 0; i < len(body); {
+		if body[i] == ' ' || body[i] == '\t' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(body) && body[j] != ' ' && body[j] != '\t' {
+			j++
+		}
+		fields = append(fields, body[i:j])
+		cols = append(cols, start+i+1)
+		i = j
+	}
+	if len(fields) != len(cronFields) {
+		return ce
+	}
+	// Parse each field on its own, with every other field a wildcard.
+	var bad []int
+	for i, f := range fields {
+		probe := []string{"*", "*", "*", "*", "*"}
+		probe[i] = f
+		if _, err := cronParser.Parse(strings.Join(probe, " ")); err != nil {
+			bad = append(bad, i)
+		}
+	}
+	if len(bad) == 1 {
+		ce.Field, ce.Pos = cronFields[bad[0]], cols[bad[0]]
+	}
+	return ce
+}
+
+// WorkflowSchedule returns the schedule CronTrigger uses for wf: its
+// ScheduleCron evaluated in wf.Timezone (UTC when empty), firing from its
+// StartDate, if set, until its EndDate, if set, inclusive.
+func WorkflowSchedule(wf *domain.Workflow) (cron.Schedule, error) {
+	expr := wf.ScheduleCron
+	if wf.Timezone != "" && !strings.HasPrefix(expr, "CRON_TZ=") && !strings.HasPrefix(expr, "TZ=") {
+		if _, err := time.LoadLocation(wf.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", wf.Timezone, err)
+		}
+		expr = "CRON_TZ=" + wf.Timezone + " " + expr
+	}
+	sched, err := ParseCron(expr)
+	if err != nil || (wf.StartDate == nil && wf.EndDate == nil) {
+		return sched, err
+	}
+	return datedSchedule{Schedule: sched, start: wf.StartDate, end: wf.EndDate}, nil
+}
+
+// datedSchedule is a schedule that only fires between start and end, when
+// set. Past end its Next returns the zero time, as a schedule that will
+// never fire again does.
+type datedSchedule struct {
+	cron.Schedule
+	start, end *time.Time
+}
+
+// Next returns the first fire after t that falls within the dates.
+func (s datedSchedule) Next(t time.Time) time.Time {
+	if s.start != nil && t.Before(*s.start) {
+		t = s.start.Add(-time.Nanosecond) // a fire at start itself counts
+	}
+	next := s.Schedule.Next(t)
+	if s.end != nil && next.After(*s.end) {
+		return time.Time{}
+	}
+	return next
+}
+
+// NextRuns returns the next n fire times of wf's schedule strictly after
+// from, expressed in the workflow's timezone.
+func NextRuns(wf *domain.Workflow, from time.Time, n int) ([]time.Time, error) {
+	sched, err := WorkflowSchedule(wf)
+	if err != nil {
+		return nil, err
+	}
+	loc := time.UTC
+	if wf.Timezone != "" {
+		loc, _ = time.LoadLocation(wf.Timezone)
+	}
+	out := make([]time.Time, 0, n)
+	t := from
+	for i := 0; i < n; i++ {
+		t = sched.Next(t)
+		if t.IsZero() {
+			break
+		}
+		out = append(out, t.In(loc))
+	}
+	return out, nil
+}
+
+// CronTrigger creates a WorkflowRun every time an active workflow's
+// ScheduleCron expression fires. Workflows with an empty ScheduleCron are
+// ignored; workflows with an unparsable expression are logged and skipped.
+// While a workflow is Paused, its fires are recorded as skipped runs. On
+// Start, the fires missed while no trigger was running are run as each
+// workflow's Catchup policy asks. Reload picks up workflows created or
+// changed since.
+//
+// Fire times are computed from the trigger's clock, so tests can drive a
+// CronTrigger with clock.Fake instead of waiting for real schedules.
+type CronTrigger struct {
+	workflows    repository.WorkflowRepository
+	workflowRuns repository.WorkflowRunRepository
+	clock        clock.Clock
+	calendars    *Calendars
+	jitter       time.Duration
+	misfire      MisfirePolicy
+	misfireGrace time.Duration
+	reloadEvery  time.Duration
+	changes      events.Bus
+	events       events.Publisher
+	reload       chan struct{} // signals the loop that Reload swapped entries
+
+	mu        sync.Mutex
+	entries   map[uuid.UUID]cron.Schedule
+	scheduled map[uuid.UUID]*domain.Workflow // the workflows behind entries
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// CronTriggerOption is a functional option for configuring a CronTrigger.
+type CronTriggerOption func(*CronTrigger)
+
+// WithCronClock sets the clock schedules are evaluated against. The default
+// is clock.Real.
+func WithCronClock(c clock.Clock) CronTriggerOption {
+	return func(ct *CronTrigger) { ct.clock = c }
+}
+
+// WithCalendars makes the trigger consult each workflow's exclusion calendar:
+// a fire on an excluded day is recorded as a skipped run instead of a pending
+// one. If the calendar cannot be read the run is created as usual.
+func WithCalendars(c *Calendars) CronTriggerOption {
+	return func(ct *CronTrigger) { ct.calendars = c }
+}
+
+// NewCronTrigger creates a CronTrigger backed by the supplied repositories.
+func NewCronTrigger(
+	workflows repository.WorkflowRepository,
+	workflowRuns repository.WorkflowRunRepository,
+	opts ...CronTriggerOption,
+) *CronTrigger {
+	ct := &CronTrigger{
+		workflows:    workflows,
+		workflowRuns: workflowRuns,
+		clock:        clock.Real,
+		misfire:      MisfireFireNow,
+		misfireGrace: DefaultMisfireGrace,
+		events:       events.Discard,
+		entries:      make(map[uuid.UUID]cron.Schedule),
+		scheduled:    make(map[uuid.UUID]*domain.Workflow),
+		reload:       make(chan struct{}, 1),
+	}
+	for _, o := range opts {
+		o(ct)
+	}
+	return ct
+}
+
+// Start loads all active workflows, registers their schedules, creates the
+// runs of the fires each workflow's Catchup policy makes up for, and starts
+// the firing loop. Runs are created with ctx until Stop is called.
+func (ct *CronTrigger) Start(ctx context.Context) error {
+	wfs, err := ct.workflows.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("cron trigger: list active workflows: %w", err)
+	}
+
+	entries, scheduled := schedules(wfs)
+	ct.mu.Lock()
+	ct.entries, ct.scheduled = entries, scheduled
+	ct.mu.Unlock()
+
+	// Make up for the fires missed while no trigger ran before the loop
+	// takes over from now.
+	now := ct.clock.Now()
+	delayed := make(map[uuid.UUID]*delayedFire)
+	for _, wf := range wfs {
+		if sched, ok := entries[wf.ID]; ok {
+			if d := ct.catchUp(ctx, wf, sched, now); d != nil {
+				delayed[wf.ID] = d
+			}
+		}
+	}
+
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.stop = make(chan struct{})
+	ct.done = make(chan struct{})
+	go ct.loop(ctx, delayed, now, ct.stop, ct.done)
+	return nil
+}
+
+// Stop halts the firing loop and waits for any in-flight fire to complete.
+func (ct *CronTrigger) Stop() {
+	ct.mu.Lock()
+	stop, done := ct.stop, ct.done
+	ct.stop = nil
+	ct.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// Len returns the number of workflows currently scheduled.
+func (ct *CronTrigger) Len() int {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	return len(ct.entries)
+}
+
+// CronFire is the next time CronTrigger will fire a workflow, jitter
+// included.
+type CronFire struct {
+	WorkflowID   uuid.UUID `json:"workflow_id"`
+	Name         string    `json:"name"`
+	ScheduleCron string    `json:"schedule_cron"`
+	Timezone     string    `json:"timezone,omitempty"`
+	Next         time.Time `json:"next"`
+}
+
+// NextFires returns the next fire time of every scheduled workflow, soonest
+// first. Schedules that will never fire again are left out.
+func (ct *CronTrigger) NextFires() []CronFire {
+	now := ct.clock.Now()
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	out := make([]CronFire, 0, len(ct.entries))
+	for id, sched := range ct.entries {
+		jitter := ct.jitterFor(id)
+		next := sched.Next(now.Add(-jitter))
+		if next.IsZero() {
+			continue
+		}
+		next = next.Add(jitter)
+		wf := ct.scheduled[id]
+		out = append(out, CronFire{WorkflowID: id, Name: wf.Name, ScheduleCron: wf.ScheduleCron, Timezone: wf.Timezone, Next: next})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Next.Before(out[j].Next) })
+	return out
+}
+
+// loop sleeps until the earliest upcoming fire time after now, jitter
+// included, or the close of the pause window delaying a fire, fires every
+// workflow that is due, and repeats until stop is closed or ctx is
+// cancelled. In between it takes up the schedules Reload swaps in, and
+// calls Reload itself every reloadEvery and on every WorkflowChanged event.
+func (ct *CronTrigger) loop(ctx context.Context, delayed map[uuid.UUID]*delayedFire, now time.Time, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	changes := ct.subscribeChanges(ctx)
+	var reloadAt time.Time
+	if ct.reloadEvery > 0 {
+		reloadAt = now.Add(ct.reloadEvery)
+	}
+
+	ct.mu.Lock()
+	entries, scheduled := ct.entries, ct.scheduled
+	ct.mu.Unlock()
+
+	// next holds each workflow's scheduled fire time, or the close of the
+	// window delaying it; wakeAt adds the workflow's jitter to the former.
+	next := make(map[uuid.UUID]time.Time, len(entries))
+	for id, sched := range entries {
+		next[id] = sched.Next(now.Add(-ct.jitterFor(id)))
+		if d := delayed[id]; d != nil {
+			next[id] = d.until
+		}
+	}
+	wakeAt := func(id uuid.UUID) time.Time {
+		t := next[id]
+		if t.IsZero() || delayed[id] != nil {
+			return t
+		}
+		return t.Add(ct.jitterFor(id))
+	}
+	for {
+		earliest := reloadAt
+		for id := range next {
+			if t := wakeAt(id); !t.IsZero() && (earliest.IsZero() || t.Before(earliest)) {
+				earliest = t
+			}
+		}
+		var wake <-chan time.Time
+		if !earliest.IsZero() {
+			wake = ct.clock.After(earliest.Sub(ct.clock.Now()))
+		}
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case e, ok := <-changes:
+			if !ok {
+				changes = nil
+			} else if e.Type == events.WorkflowChanged {
+				ct.reloadLogged(ctx)
+			}
+			continue
+		case <-ct.reload:
+			ct.mu.Lock()
+			fresh, freshWfs := ct.entries, ct.scheduled
+			ct.mu.Unlock()
+			now = ct.clock.Now()
+			for id := range next {
+				if fresh[id] == nil {
+					delete(next, id)
+					delete(delayed, id)
+				}
+			}
+			for id, sched := range fresh {
+				if _, ok := next[id]; ok && (delayed[id] != nil || sameSchedule(scheduled[id], freshWfs[id])) {
+					continue
+				}
+				next[id] = sched.Next(now.Add(-ct.jitterFor(id)))
+			}
+			entries, scheduled = fresh, freshWfs
+			continue
+		case <-wake:
+		}
+
+		now = ct.clock.Now()
+		if !reloadAt.IsZero() && !reloadAt.After(now) {
+			reloadAt = now.Add(ct.reloadEvery)
+			ct.reloadLogged(ctx)
+		}
+		for id, t := range next {
+			due := wakeAt(id)
+			if due.IsZero() || due.After(now) {
+				continue
+			}
+			if ct.misfired(due, now) {
+				logical := t
+				if d := delayed[id]; d != nil {
+					logical = d.logical
+				}
+				ct.skipMisfire(ctx, id, logical, due, now)
+			} else if d := ct.fire(ctx, id, t, now, delayed[id]); d != nil {
+				delayed[id], next[id] = d, d.until
+				continue
+			}
+			delete(delayed, id)
+			next[id] = entries[id].Next(now.Add(-ct.jitterFor(id)))
+		}
+	}
+}
+
+// reloadLogged calls Reload, logging rather than returning its error: the
+// trigger keeps the schedules it has until a later reload succeeds.
+func (ct *CronTrigger) reloadLogged(ctx context.Context) {
+	if err := ct.Reload(ctx); err != nil {
+		log.Printf("CronTrigger: %v", err)
+	}
+}
+
+// delayedFire is a fire a pause window put off until it closes.
+type delayedFire struct {
+	logical time.Time // the schedule's fire time
+	window  string
+	until   time.Time
+	catchup bool // missed while no trigger ran; see catchUp
+}
+
+// fire creates a pending WorkflowRun for the workflow with the given ID, or
+// a skipped one when the workflow is paused, a pause window or its
+// calendar rules the fire out, or it has MaxActiveRuns runs active under
+// the skip overlap policy; under the reject policy it creates none. The workflow is reloaded on every fire, so a
+// pause set through any API replica applies from the next fire on. The
+// run's LogicalDate is the scheduled fire time, which other workflows'
+// external_run sensors match on; StartedAt is when it actually fired.
+//
+// due is when the fire was due: the scheduled time, or the close of the
+// pause window that delayed it, as recorded in delayed. When a delaying
+// window is open at due, fire creates no run and returns the fire to retry
+// once the window closes. delayed also carries the fires catchUp makes up
+// for, with no window.
+func (ct *CronTrigger) fire(ctx context.Context, workflowID uuid.UUID, due, at time.Time, delayed *delayedFire) *delayedFire {
+	logical := due.UTC()
+	run := &domain.WorkflowRun{
+		ID:          uuid.New(),
+		WorkflowID:  workflowID,
+		Status:      domain.StatusPending,
+		StartedAt:   at.UTC(),
+		LogicalDate: &logical,
+	}
+	catchup := delayed != nil && delayed.catchup
+	if delayed != nil {
+		logical = delayed.logical.UTC()
+		if delayed.window != "" {
+			run.Labels = map[string]string{domain.LabelPauseWindow: delayed.window}
+		}
+	}
+	wf := ct.workflow(ctx, workflowID)
+	if wf.Paused {
+		run.Labels = map[string]string{domain.LabelPaused: "true"}
+		if wf.PausedBy != "" {
+			run.Labels[domain.LabelPausedBy] = wf.PausedBy
+		}
+		run.Status, run.FinishedAt = domain.StatusSkipped, &run.StartedAt
+		log.Printf("CronTrigger: workflow %s: paused; run for %s skipped", workflowID, logical.Format(time.RFC3339))
+	} else if w, until := PausedAt(wf, due); w != nil {
+		run.Labels = map[string]string{domain.LabelPauseWindow: w.Name}
+		if w.Action == domain.PauseDelay {
+			log.Printf("CronTrigger: workflow %s: %s falls in pause window %q; run delayed until %s",
+				workflowID, logical.Format(time.RFC3339), w.Name, until.Format(time.RFC3339))
+			return &delayedFire{logical: logical, window: w.Name, until: until, catchup: catchup}
+		}
+		run.Status, run.FinishedAt = domain.StatusSkipped, &run.StartedAt
+		log.Printf("CronTrigger: workflow %s: %s falls in pause window %q; run skipped", workflowID, logical.Format(time.RFC3339), w.Name)
+	} else if ct.excluded(ctx, wf, logical) {
+		run.Status, run.FinishedAt = domain.StatusSkipped, &run.StartedAt
+		log.Printf("CronTrigger: workflow %s: %s is excluded by its calendar; run skipped", workflowID, logical.Format(time.RFC3339))
+	} else if p := ct.overlapping(ctx, wf); p == domain.OverlapReject {
+		log.Printf("CronTrigger: workflow %s: %d runs already active; run for %s dropped", workflowID, wf.MaxActiveRuns, logical.Format(time.RFC3339))
+		return nil
+	} else if p == domain.OverlapSkip {
+		run.Labels = map[string]string{domain.LabelMaxActiveRuns: "true"}
+		run.Status, run.FinishedAt = domain.StatusSkipped, &run.StartedAt
+		log.Printf("CronTrigger: workflow %s: %d runs already active; run for %s skipped", workflowID, wf.MaxActiveRuns, logical.Format(time.RFC3339))
+	}
+	if catchup {
+		if run.Labels == nil {
+			run.Labels = make(map[string]string, 1)
+		}
+		run.Labels[domain.LabelCatchup] = "true"
+	}
+	ct.createRun(ctx, run)
+	return nil
+}
+
+// createRun stores run and announces it, logging a failure.
+func (ct *CronTrigger) createRun(ctx context.Context, run *domain.WorkflowRun) {
+	if err := ct.workflowRuns.Create(ctx, run); err != nil {
+		log.Printf("CronTrigger: workflow %s: create run: %v", run.WorkflowID, err)
+		return
+	}
+	if err := ct.events.Publish(ctx, events.Event{Type: events.WorkflowStatus, Payload: *run}); err != nil {
+		log.Printf("CronTrigger: publish %s: %v", events.WorkflowStatus, err)
+	}
+}
+
+// overlapping returns the overlap policy the fire of wf falls under, as
+// Overlapping does; a fire whose active runs cannot be counted goes ahead.
+func (ct *CronTrigger) overlapping(ctx context.Context, wf *domain.Workflow) domain.OverlapPolicy {
+	p, err := Overlapping(ctx, ct.workflowRuns, wf)
+	if err != nil {
+		log.Printf("CronTrigger: workflow %s: count active runs: %v", wf.ID, err)
+	}
+	return p
+}
+
+// workflow returns the current version of the scheduled workflow with the
+// given ID, or the version it was scheduled with if that cannot be read.
+func (ct *CronTrigger) workflow(ctx context.Context, workflowID uuid.UUID) *domain.Workflow {
+	wf, err := ct.workflows.GetByID(ctx, workflowID)
+	if err == nil {
+		return wf
+	}
+	log.Printf("CronTrigger: workflow %s: reload: %v", workflowID, err)
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	if wf := ct.scheduled[workflowID]; wf != nil {
+		return wf
+	}
+	return &domain.Workflow{ID: workflowID}
+}
+
+// excluded reports whether wf's calendar excludes the day at falls on in
+// the workflow's timezone.
+func (ct *CronTrigger) excluded(ctx context.Context, wf *domain.Workflow, at time.Time) bool {
+	if ct.calendars == nil || wf.CalendarID == nil {
+		return false
+	}
+	if wf.Timezone != "" {
+		if loc, err := time.LoadLocation(wf.Timezone); err == nil {
+			at = at.In(loc)
+		}
+	}
+	skip, err := ct.calendars.Excludes(ctx, *wf.CalendarID, at)
+	if err != nil {
+		log.Printf("CronTrigger: workflow %s: %v", wf.ID, err)
+		return false
+	}
+	return skip
+}
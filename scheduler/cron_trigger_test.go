@@ -0,0 +1,551 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+func TestParseCron_Valid(t *testing.T) {
+	for _, expr := range []string{"0 * * * *", "@daily", "@every 5m", "CRON_TZ=Europe/Berlin 0 2 * * *"} {
+		if _, err := scheduler.ParseCron(expr); err != nil {
+			t.Errorf("ParseCron(%q): %v", expr, err)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	if _, err := scheduler.ParseCron("banana"); err == nil {
+		t.Fatal("expected error for invalid expression, got nil")
+	}
+}
+
+func TestParseCron_ErrorPosition(t *testing.T) {
+	for _, tc := range []struct {
+		expr  string
+		field string
+		pos   int
+	}{
+		{"banana", "", 0},
+		{"0 25 * * *", "hour", 3},
+		{"*/5  *  * foo *", "month", 11},
+		{"CRON_TZ=Mars/Base 0 2 * * *", "timezone", 9},
+		{"CRON_TZ=UTC 0 2 * * 8", "day of week", 21},
+	} {
+		_, err := scheduler.ParseCron(tc.expr)
+		var ce *scheduler.CronError
+		if !errors.As(err, &ce) {
+			t.Errorf("ParseCron(%q): got %v, want a *CronError", tc.expr, err)
+			continue
+		}
+		if ce.Field != tc.field || ce.Pos != tc.pos {
+			t.Errorf("ParseCron(%q): field %q at %d, want %q at %d", tc.expr, ce.Field, ce.Pos, tc.field, tc.pos)
+		}
+	}
+}
+
+func TestCronTrigger_SkipsUnscheduledAndInvalid(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	for _, expr := range []string{"", "banana", "0 * * * *"} {
+		_ = wfRepo.Create(ctx, &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: expr, IsActive: true})
+	}
+
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo)
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+	if got := ct.Len(); got != 1 {
+		t.Errorf("Len: got %d, want 1", got)
+	}
+}
+
+func TestCronTrigger_CreatesRunOnFire(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "@every 1s", IsActive: true}
+	_ = wfRepo.Create(ctx, wf)
+
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo)
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+		if len(runs) > 0 {
+			if runs[0].Status != idomain.StatusPending {
+				t.Errorf("Status: got %q, want %q", runs[0].Status, idomain.StatusPending)
+			}
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("expected CronTrigger to create a workflow run")
+}
+
+func TestCronTrigger_FiresOnFakeClock(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", IsActive: true}
+	_ = wfRepo.Create(ctx, wf)
+
+	start := time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)
+	fc := clock.NewFake(start)
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc))
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+
+	// Nothing fires before the top of the hour.
+	fc.BlockUntil(1)
+	fc.Advance(29 * time.Minute)
+	if runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID); len(runs) != 0 {
+		t.Fatalf("fired early: %d runs", len(runs))
+	}
+
+	// Each hour boundary creates exactly one run, stamped with the fire time.
+	for hour := 10; hour <= 11; hour++ {
+		fc.Set(time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC))
+		fc.BlockUntil(1) // the trigger is waiting for the next hour again
+		runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+		if want := hour - 9; len(runs) != want {
+			t.Fatalf("after %02d:00: got %d runs, want %d", hour, len(runs), want)
+		}
+	}
+	runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+	for _, r := range runs {
+		if r.StartedAt.Minute() != 0 || r.StartedAt.Second() != 0 {
+			t.Errorf("StartedAt not on the hour: %s", r.StartedAt)
+		}
+	}
+}
+
+func TestCronTrigger_PausedWorkflowSkipsFires(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", IsActive: true}
+	_ = wfRepo.Create(ctx, wf)
+
+	fc := clock.NewFake(time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC))
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc))
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+
+	// Another replica pauses the workflow after this trigger loaded it.
+	setPaused := func(paused bool) {
+		stored, _ := wfRepo.GetByID(ctx, wf.ID)
+		updated := *stored
+		updated.Paused, updated.PausedBy = paused, ""
+		if paused {
+			updated.PausedBy = "alice"
+		}
+		_ = wfRepo.Update(ctx, &updated)
+	}
+	setPaused(true)
+	fc.BlockUntil(1)
+	fc.Set(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	fc.BlockUntil(1)
+	setPaused(false)
+	fc.Set(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC))
+	fc.BlockUntil(1)
+
+	runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	byHour := map[int]*idomain.WorkflowRun{}
+	for _, r := range runs {
+		byHour[r.LogicalDate.Hour()] = r
+	}
+	if r := byHour[10]; r == nil || r.Status != idomain.StatusSkipped ||
+		r.Labels[idomain.LabelPaused] != "true" || r.Labels[idomain.LabelPausedBy] != "alice" {
+		t.Errorf("fire while paused: got %+v, want a skipped run labelled paused by alice", r)
+	}
+	if r := byHour[11]; r == nil || r.Status != idomain.StatusPending || len(r.Labels) != 0 {
+		t.Errorf("fire after resume: got %+v, want a plain pending run", r)
+	}
+}
+
+func TestCronTrigger_PublishesCreatedRuns(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", IsActive: true, Paused: true}
+	_ = wfRepo.Create(ctx, wf)
+	bus := events.NewMemBus()
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sub, _ := bus.Subscribe(sctx)
+
+	fc := clock.NewFake(time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC))
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc), scheduler.WithFireEvents(bus))
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+
+	// A fire while paused is announced as skipped, the next as pending.
+	fc.BlockUntil(1)
+	fc.Set(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	fc.BlockUntil(1)
+	resumed := *wf
+	resumed.Paused = false
+	_ = wfRepo.Update(ctx, &resumed)
+	fc.Set(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC))
+	fc.BlockUntil(1)
+
+	var got []idomain.Status
+	for len(sub) > 0 {
+		e := <-sub
+		run, ok := e.Payload.(idomain.WorkflowRun)
+		if e.Type != events.WorkflowStatus || !ok || run.WorkflowID != wf.ID {
+			t.Fatalf("unexpected event %s with %T", e.Type, e.Payload)
+		}
+		got = append(got, run.Status)
+	}
+	if want := []idomain.Status{idomain.StatusSkipped, idomain.StatusPending}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("announced runs: got %v, want %v", got, want)
+	}
+}
+
+func TestCronTrigger_MaxActiveRunsSkipsOrDropsFires(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", IsActive: true,
+		MaxActiveRuns: 1, OverlapPolicy: idomain.OverlapSkip}
+	_ = wfRepo.Create(ctx, wf)
+
+	fc := clock.NewFake(time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC))
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc))
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+
+	// The 10:00 run is still pending when 11:00 and 12:00 fire.
+	for hour := 10; hour <= 12; hour++ {
+		fc.BlockUntil(1)
+		if hour == 12 {
+			updated := *wf
+			updated.OverlapPolicy = idomain.OverlapReject
+			_ = wfRepo.Update(ctx, &updated)
+		}
+		fc.Set(time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC))
+	}
+	fc.BlockUntil(1)
+
+	runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2: the 12:00 fire is dropped", len(runs))
+	}
+	byHour := map[int]*idomain.WorkflowRun{}
+	for _, r := range runs {
+		byHour[r.LogicalDate.Hour()] = r
+	}
+	if r := byHour[10]; r == nil || r.Status != idomain.StatusPending {
+		t.Errorf("first fire: got %+v, want a pending run", r)
+	}
+	if r := byHour[11]; r == nil || r.Status != idomain.StatusSkipped || r.Labels[idomain.LabelMaxActiveRuns] != "true" {
+		t.Errorf("second fire: got %+v, want a skipped run labelled max_active_runs", r)
+	}
+}
+
+func TestNextRuns_UsesWorkflowTimezone(t *testing.T) {
+	wf := &idomain.Workflow{ScheduleCron: "30 2 * * *", Timezone: "America/New_York"}
+	from := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+	runs, err := scheduler.NextRuns(wf, from, 3)
+	if err != nil {
+		t.Fatalf("NextRuns: %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("len: got %d, want 3", len(runs))
+	}
+	for _, r := range runs {
+		if r.Location().String() != "America/New_York" {
+			t.Errorf("location: got %s, want America/New_York", r.Location())
+		}
+		if !r.After(from) {
+			t.Errorf("run %s not after %s", r, from)
+		}
+	}
+}
+
+func TestNextRuns_InvalidTimezone(t *testing.T) {
+	wf := &idomain.Workflow{ScheduleCron: "0 * * * *", Timezone: "Mars/Olympus"}
+	if _, err := scheduler.NextRuns(wf, time.Now(), 1); err == nil {
+		t.Fatal("expected error for unknown timezone, got nil")
+	}
+}
+
+func TestNextRuns_BoundedByStartAndEndDates(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	wf := &idomain.Workflow{ScheduleCron: "0 0 * * *", StartDate: &start, EndDate: &end}
+	runs, err := scheduler.NextRuns(wf, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 10)
+	if err != nil {
+		t.Fatalf("NextRuns: %v", err)
+	}
+	// Both dates are inclusive; nothing fires before start or after end.
+	want := []time.Time{start, start.AddDate(0, 0, 1), end}
+	if len(runs) != len(want) {
+		t.Fatalf("got %v, want %v", runs, want)
+	}
+	for i := range want {
+		if !runs[i].Equal(want[i]) {
+			t.Errorf("run %d: got %s, want %s", i, runs[i], want[i])
+		}
+	}
+}
+
+func TestCronTrigger_StopsFiringAfterEndDate(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	end := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", EndDate: &end, IsActive: true}
+	// open keeps firing, so the trigger always has a fire to wait for.
+	open := &idomain.Workflow{ID: uuid.New(), Name: "open", ScheduleCron: "0 * * * *", IsActive: true}
+	_ = wfRepo.Create(ctx, wf)
+	_ = wfRepo.Create(ctx, open)
+
+	fc := clock.NewFake(time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC))
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc))
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+
+	fc.BlockUntil(1)
+	for hour := 10; hour <= 13; hour++ {
+		fc.Set(time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC))
+		fc.BlockUntil(1)
+	}
+	if runs, _ := runRepo.ListByWorkflowID(ctx, open.ID); len(runs) != 4 {
+		t.Fatalf("open workflow: got %d runs, want 4", len(runs))
+	}
+	if runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID); len(runs) != 2 {
+		t.Errorf("got %d runs, want 2: at 10:00 and at the 11:00 end date", len(runs))
+	}
+	for _, f := range ct.NextFires() {
+		if f.WorkflowID == wf.ID {
+			t.Errorf("NextFires after the end date: got %s, want none", f.Next)
+		}
+	}
+}
+
+func TestCronTrigger_CatchesUpMissedFires(t *testing.T) {
+	last := time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC)
+	backfilled := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		policy idomain.CatchupPolicy
+		want   []int // hours of the catch-up runs' logical dates
+	}{
+		{policy: "", want: nil},
+		{policy: idomain.CatchupNone, want: nil},
+		{policy: idomain.CatchupLatest, want: []int{9}},
+		{policy: idomain.CatchupAll, want: []int{7, 9}},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			wfRepo := mock.NewWorkflowRepo()
+			runRepo := mock.NewWorkflowRunRepo()
+			wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", IsActive: true, Catchup: tc.policy}
+			_ = wfRepo.Create(ctx, wf)
+			// The trigger last fired at 06:00, and 08:00 has been backfilled.
+			_ = runRepo.Create(ctx, &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: idomain.StatusSuccess, StartedAt: last, LogicalDate: &last})
+			_ = runRepo.Create(ctx, &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: idomain.StatusSuccess, StartedAt: now,
+				LogicalDate: &backfilled, Labels: map[string]string{idomain.LabelBackfill: "true"}})
+
+			fc := clock.NewFake(now)
+			ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc))
+			if err := ct.Start(ctx); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			defer ct.Stop()
+
+			runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+			var got []int
+			for _, r := range runs {
+				if r.Labels[idomain.LabelCatchup] != "true" {
+					continue
+				}
+				if r.Status != idomain.StatusPending || !r.StartedAt.Equal(now) {
+					t.Errorf("catch-up run %s: status %s started %s, want pending at %s", r.LogicalDate, r.Status, r.StartedAt, now)
+				}
+				got = append(got, r.LogicalDate.Hour())
+			}
+			sort.Ints(got)
+			if len(runs)-2 != len(got) || fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Fatalf("catch-up runs for hours %v (%d runs in all), want %v", got, len(runs), tc.want)
+			}
+		})
+	}
+}
+
+func TestCronTrigger_JitterDelaysFire(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", IsActive: true}
+	_ = wfRepo.Create(ctx, wf)
+
+	fc := clock.NewFake(time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC))
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc), scheduler.WithJitter(10*time.Minute))
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+
+	hour := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	fires := ct.NextFires()
+	if len(fires) != 1 || fires[0].Next.Before(hour) || !fires[0].Next.Before(hour.Add(10*time.Minute)) {
+		t.Fatalf("NextFires = %+v, want one fire in [10:00, 10:10)", fires)
+	}
+	at := fires[0].Next
+
+	fc.BlockUntil(1)
+	fc.Set(at.Add(-time.Nanosecond))
+	if runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID); len(runs) != 0 {
+		t.Fatalf("fired before its jitter: %d runs", len(runs))
+	}
+	fc.Set(at)
+	fc.BlockUntil(1)
+	runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+	if len(runs) != 1 || !runs[0].LogicalDate.Equal(hour) || !runs[0].StartedAt.Equal(at) {
+		t.Fatalf("got %d runs (%+v), want one for 10:00 started at %s", len(runs), runs, at)
+	}
+}
+
+func TestCronTrigger_MisfireSkip(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", IsActive: true}
+	_ = wfRepo.Create(ctx, wf)
+
+	fc := clock.NewFake(time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC))
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc),
+		scheduler.WithMisfirePolicy(scheduler.MisfireSkip, time.Minute))
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+
+	// The trigger wakes five minutes late for 10:00, then within the grace
+	// for 11:00.
+	fc.BlockUntil(1)
+	fc.Set(time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC))
+	fc.BlockUntil(1)
+	fc.Set(time.Date(2024, 1, 1, 11, 0, 30, 0, time.UTC))
+	fc.BlockUntil(1)
+
+	runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+	byHour := map[int]*idomain.WorkflowRun{}
+	for _, r := range runs {
+		byHour[r.LogicalDate.Hour()] = r
+	}
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	if r := byHour[10]; r == nil || r.Status != idomain.StatusSkipped || r.Labels[idomain.LabelMisfire] != "true" {
+		t.Errorf("late fire: got %+v, want a skipped run labelled misfire", r)
+	}
+	if r := byHour[11]; r == nil || r.Status != idomain.StatusPending {
+		t.Errorf("fire within grace: got %+v, want a pending run", r)
+	}
+}
+
+func TestParseMisfirePolicy(t *testing.T) {
+	for in, want := range map[string]scheduler.MisfirePolicy{"": scheduler.MisfireFireNow, "skip": scheduler.MisfireSkip, "fire_now": scheduler.MisfireFireNow} {
+		if got, err := scheduler.ParseMisfirePolicy(in); err != nil || got != want {
+			t.Errorf("ParseMisfirePolicy(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := scheduler.ParseMisfirePolicy("later"); err == nil {
+		t.Error("ParseMisfirePolicy(later): expected an error")
+	}
+}
+
+func TestCronTrigger_ReloadPicksUpNewAndRemovedWorkflows(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	fc := clock.NewFake(time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC))
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc))
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", IsActive: true}
+	_ = wfRepo.Create(ctx, wf)
+	if err := ct.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if ct.Len() != 1 {
+		t.Fatalf("Len after reload = %d, want 1", ct.Len())
+	}
+	fc.BlockUntil(1)
+	fc.Set(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	fc.BlockUntil(1)
+	if runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID); len(runs) != 1 {
+		t.Fatalf("got %d runs, want the 10:00 fire of the reloaded workflow", len(runs))
+	}
+
+	wf.IsActive = false
+	_ = wfRepo.Update(ctx, wf)
+	if err := ct.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if ct.Len() != 0 || len(ct.NextFires()) != 0 {
+		t.Errorf("deactivated workflow still scheduled: %+v", ct.NextFires())
+	}
+}
+
+func TestCronTrigger_ReloadsOnWorkflowChangedEvent(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo := mock.NewWorkflowRunRepo()
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "wf", ScheduleCron: "0 * * * *", IsActive: true}
+	_ = wfRepo.Create(ctx, wf)
+	bus := events.NewMemBus()
+
+	fc := clock.NewFake(time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC))
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc), scheduler.WithReloadEvents(bus))
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+	fc.BlockUntil(1)
+
+	// Moving the schedule to :45 reschedules the next fire from 10:00 to
+	// 9:45 as soon as the change is announced.
+	wf.ScheduleCron = "45 * * * *"
+	_ = wfRepo.Update(ctx, wf)
+	_ = bus.Publish(ctx, events.Event{Type: events.WorkflowChanged, Payload: events.WorkflowChange{WorkflowID: wf.ID.String(), Action: "updated"}})
+	fc.BlockUntil(3)
+	fires := ct.NextFires()
+	want := time.Date(2024, 1, 1, 9, 45, 0, 0, time.UTC)
+	if len(fires) != 1 || !fires[0].Next.Equal(want) {
+		t.Fatalf("NextFires = %+v, want one at 9:45", fires)
+	}
+	// The two timers armed for 10:00 before the reload are still pending.
+	fc.Set(want)
+	fc.BlockUntil(3)
+	runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+	if len(runs) != 1 || !runs[0].LogicalDate.Equal(want) {
+		t.Fatalf("got %d runs (%+v), want one for 9:45", len(runs), runs)
+	}
+}
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// DefaultDatasetTriggerInterval is how often DatasetTrigger.Run evaluates
+// dataset-triggered workflows.
+const DefaultDatasetTriggerInterval = 10 * time.Second
+
+// DatasetTrigger creates a WorkflowRun when the TriggerDatasets of an active
+// workflow have been updated, according to its DatasetPolicy. An update is an
+// output lineage edge recorded by a task of another workflow after the
+// workflow's most recent run started (or after it was created, if it has
+// never run), so the cursor survives restarts without extra state.
+type DatasetTrigger struct {
+	workflows    repository.WorkflowRepository
+	workflowRuns repository.WorkflowRunRepository
+	lineage      repository.LineageRepository
+}
+
+// NewDatasetTrigger creates a DatasetTrigger backed by the supplied repositories.
+func NewDatasetTrigger(
+	workflows repository.WorkflowRepository,
+	workflowRuns repository.WorkflowRunRepository,
+	lineage repository.LineageRepository,
+) *DatasetTrigger {
+	return &DatasetTrigger{
+		workflows:    workflows,
+		workflowRuns: workflowRuns,
+		lineage:      lineage,
+	}
+}
+
+// Run calls Reconcile every DefaultDatasetTriggerInterval until ctx is cancelled.
+func (dt *DatasetTrigger) Run(ctx context.Context) error {
+	ticker := time.NewTicker(DefaultDatasetTriggerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := dt.Reconcile(ctx); err != nil {
+				log.Printf("DatasetTrigger: reconcile: %v", err)
+			}
+		}
+	}
+}
+
+// Reconcile evaluates every active workflow that declares trigger datasets.
+func (dt *DatasetTrigger) Reconcile(ctx context.Context) error {
+	active, err := dt.workflows.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("list active workflows: %w", err)
+	}
+	for _, wf := range active {
+		if len(wf.TriggerDatasets) == 0 {
+			continue
+		}
+		if _, err := dt.Evaluate(ctx, wf); err != nil {
+			log.Printf("DatasetTrigger: workflow %s: %v", wf.ID, err)
+		}
+	}
+	return nil
+}
+
+// Evaluate creates a run of wf if its trigger datasets have been updated
+// enough to satisfy its policy, and returns the run it created, if any.
+func (dt *DatasetTrigger) Evaluate(ctx context.Context, wf *domain.Workflow) (*domain.WorkflowRun, error) {
+	// Taken before reading lineage so that edges recorded while this pass
+	// runs are newer than the run it creates and count towards the next.
+	now := time.Now().UTC()
+
+	runs, err := dt.workflowRuns.ListByWorkflowID(ctx, wf.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list runs: %w", err)
+	}
+	cursor := wf.CreatedAt
+	for _, r := range runs {
+		if r.StartedAt.After(cursor) {
+			cursor = r.StartedAt
+		}
+	}
+
+	updated := 0
+	for _, ds := range wf.TriggerDatasets {
+		edges, err := dt.lineage.ListByDataset(ctx, ds, domain.LineageOutput, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("dataset %s: %w", ds, err)
+		}
+		for _, e := range edges {
+			if e.WorkflowID != wf.ID && e.CreatedAt.After(cursor) {
+				updated++
+				break
+			}
+		}
+	}
+	if !wf.DatasetPolicy.Satisfied(updated, len(wf.TriggerDatasets)) {
+		return nil, nil
+	}
+
+	run := &domain.WorkflowRun{
+		ID:         uuid.New(),
+		WorkflowID: wf.ID,
+		Status:     domain.StatusPending,
+		StartedAt:  now,
+	}
+	if err := dt.workflowRuns.Create(ctx, run); err != nil {
+		return nil, fmt.Errorf("create run: %w", err)
+	}
+	log.Printf("DatasetTrigger: workflow %s: %d of %d datasets updated; created run %s",
+		wf.ID, updated, len(wf.TriggerDatasets), run.ID)
+	return run, nil
+}
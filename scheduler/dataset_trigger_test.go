@@ -0,0 +1,73 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// produce records that a task of workflow wf wrote dataset at time at.
+func produce(lineage *mock.LineageRepo, wf uuid.UUID, dataset string, at time.Time) {
+	_ = lineage.Create(ctx, &idomain.LineageEdge{
+		ID: uuid.New(), Dataset: dataset, Direction: idomain.LineageOutput, WorkflowID: wf,
+		WorkflowRunID: uuid.New(), TaskID: uuid.New(), TaskRunID: uuid.New(), CreatedAt: at,
+	})
+}
+
+func TestDatasetTrigger_AllPolicyWaitsForEveryDataset(t *testing.T) {
+	workflows, runs, lineage := mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewLineageRepo()
+	dt := scheduler.NewDatasetTrigger(workflows, runs, lineage)
+	created := time.Now().UTC().Add(-time.Hour)
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "report", IsActive: true, CreatedAt: created,
+		TriggerDatasets: []string{"orders", "customers"}}
+	_ = workflows.Create(ctx, wf)
+	upstream := uuid.New()
+
+	produce(lineage, upstream, "orders", created.Add(time.Minute))
+	if err := dt.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if got, _ := runs.ListByWorkflowID(ctx, wf.ID); len(got) != 0 {
+		t.Fatalf("triggered with one of two datasets updated: %d runs", len(got))
+	}
+
+	produce(lineage, upstream, "customers", created.Add(2*time.Minute))
+	_ = dt.Reconcile(ctx)
+	got, _ := runs.ListByWorkflowID(ctx, wf.ID)
+	if len(got) != 1 || got[0].Status != idomain.StatusPending {
+		t.Fatalf("runs: got %d, want 1 pending", len(got))
+	}
+
+	// The updates were consumed by that run.
+	_ = dt.Reconcile(ctx)
+	if got, _ := runs.ListByWorkflowID(ctx, wf.ID); len(got) != 1 {
+		t.Errorf("re-triggered without new updates: %d runs", len(got))
+	}
+}
+
+func TestDatasetTrigger_AnyPolicyIgnoresOwnOutputs(t *testing.T) {
+	workflows, runs, lineage := mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo(), mock.NewLineageRepo()
+	dt := scheduler.NewDatasetTrigger(workflows, runs, lineage)
+	created := time.Now().UTC().Add(-time.Hour)
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "report", IsActive: true, CreatedAt: created,
+		TriggerDatasets: []string{"orders", "customers"}, DatasetPolicy: idomain.DatasetPolicyAny}
+	_ = workflows.Create(ctx, wf)
+
+	produce(lineage, wf.ID, "orders", created.Add(time.Minute))
+	if run, err := dt.Evaluate(ctx, wf); err != nil || run != nil {
+		t.Fatalf("own output triggered a run: %v, %v", run, err)
+	}
+
+	produce(lineage, uuid.New(), "customers", created.Add(2*time.Minute))
+	run, err := dt.Evaluate(ctx, wf)
+	if err != nil || run == nil {
+		t.Fatalf("Evaluate: run=%v err=%v, want a run", run, err)
+	}
+	if run.WorkflowID != wf.ID {
+		t.Errorf("WorkflowID: got %s, want %s", run.WorkflowID, wf.ID)
+	}
+}
@@ -0,0 +1,177 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// HeldTask is a task the Scheduler is holding back for a resource.
+type HeldTask struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	WorkflowID     string    `json:"workflow_id,omitempty"`
+	Pool           string    `json:"pool,omitempty"`
+	ConcurrencyKey string    `json:"concurrency_key,omitempty"`
+	HeldSince      time.Time `json:"held_since"`
+}
+
+// LoopStats describes the latest pass of the dispatch loop. Durations are
+// formatted as Go duration strings.
+type LoopStats struct {
+	Interval string `json:"interval"`
+	// LastTickAt is when the latest pass was due and LastRunAt when it
+	// started; Lag is the difference.
+	LastTickAt   *time.Time `json:"last_tick_at,omitempty"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	Lag          string     `json:"lag,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+}
+
+// DispatchState is a snapshot of the Scheduler's in-memory state.
+type DispatchState struct {
+	Paused          bool                 `json:"paused"`
+	PausedSince     *time.Time           `json:"paused_since,omitempty"`
+	Draining        bool                 `json:"draining,omitempty"`
+	Held            []HeldTask           `json:"held"`
+	Delayed         []DelayedTask        `json:"delayed"`
+	InFlight        int                  `json:"in_flight"`
+	ConcurrencyKeys map[string]string    `json:"concurrency_keys"`
+	Pools           map[string]PoolUsage `json:"pools"`
+	Limits          map[string]PoolUsage `json:"concurrency_limits"`
+	Breakers        []BreakerState       `json:"breakers"`
+	Loop            LoopStats            `json:"loop"`
+}
+
+// Inspect returns a snapshot of whether s is paused or draining, the tasks it is holding
+// back, delaying or tracking, the resources they occupy, and the timing of
+// the dispatch loop.
+func (s *Scheduler) Inspect() DispatchState {
+	s.mu.Lock()
+	st := DispatchState{
+		Held:            make([]HeldTask, len(s.held)),
+		InFlight:        len(s.inflight),
+		ConcurrencyKeys: make(map[string]string, len(s.keys)),
+		Loop:            LoopStats{Interval: s.dispatchInterval.String()},
+		Draining:        s.draining,
+	}
+	if !s.pausedAt.IsZero() {
+		since := s.pausedAt
+		st.Paused, st.PausedSince = true, &since
+	}
+	for i, t := range s.held {
+		st.Held[i] = HeldTask{
+			ID:             t.ID,
+			Name:           t.Name,
+			WorkflowID:     t.WorkflowID,
+			Pool:           t.Pool,
+			ConcurrencyKey: t.ConcurrencyKey,
+			HeldSince:      t.UpdatedAt,
+		}
+	}
+	st.Delayed = make([]DelayedTask, 0, len(s.delayed))
+	pending := append(delayHeap(nil), s.delayed...)
+	for pending.Len() > 0 {
+		t := heap.Pop(&pending).(delayedTask).task
+		st.Delayed = append(st.Delayed, DelayedTask{ID: t.ID, Name: t.Name, WorkflowID: t.WorkflowID, ScheduledAt: t.ScheduledAt})
+	}
+	for k, id := range s.keys {
+		st.ConcurrencyKeys[k] = id
+	}
+	if !s.lastRun.IsZero() {
+		tick, run := s.lastTick, s.lastRun
+		st.Loop.LastTickAt, st.Loop.LastRunAt = &tick, &run
+		st.Loop.Lag = run.Sub(tick).String()
+		st.Loop.LastDuration = s.lastDuration.String()
+	}
+	s.mu.Unlock()
+
+	st.Pools = s.pools.Usage()
+	st.Limits = map[string]PoolUsage{}
+	if s.limits != nil {
+		st.Limits = s.limits.Usage()
+	}
+	st.Breakers = []BreakerState{}
+	if s.breaker != nil {
+		st.Breakers = s.breaker.States()
+	}
+	return st
+}
+
+// QueueStats counts the tasks waiting in the queue and the queue tasks in
+// each non-terminal status.
+type QueueStats struct {
+	Depth    int                       `json:"depth"`
+	ByStatus map[domain.TaskStatus]int `json:"by_status"`
+}
+
+// Snapshot is the document served by Inspector.
+type Snapshot struct {
+	At       time.Time      `json:"at"`
+	Queue    QueueStats     `json:"queue"`
+	Dispatch *DispatchState `json:"dispatch,omitempty"`
+	Cron     []CronFire     `json:"cron"`
+}
+
+// Inspector serves a JSON Snapshot of a scheduler process, to diagnose why a
+// run is not starting: whether its tasks are queued, held back by a pool,
+// concurrency key or open circuit, or waiting on a slow dispatch loop, and
+// when each workflow's schedule fires next. Nil fields are left out of the
+// snapshot.
+type Inspector struct {
+	Scheduler *Scheduler
+	Cron      *CronTrigger
+	Queue     domain.Queue
+	Tasks     domain.TaskRepository
+}
+
+// Snapshot gathers the current state.
+func (in *Inspector) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snap := &Snapshot{
+		At:    time.Now().UTC(),
+		Queue: QueueStats{ByStatus: make(map[domain.TaskStatus]int)},
+		Cron:  []CronFire{},
+	}
+	if in.Queue != nil {
+		n, err := in.Queue.Len(ctx)
+		if err != nil {
+			return nil, err
+		}
+		snap.Queue.Depth = n
+	}
+	if in.Tasks != nil {
+		for _, status := range []domain.TaskStatus{
+			domain.TaskStatusPending, domain.TaskStatusQueued, domain.TaskStatusRunning,
+			domain.TaskStatusRetrying, domain.TaskStatusDeferred,
+		} {
+			tasks, err := in.Tasks.FindByStatus(ctx, status)
+			if err != nil {
+				return nil, err
+			}
+			snap.Queue.ByStatus[status] = len(tasks)
+		}
+	}
+	if in.Scheduler != nil {
+		st := in.Scheduler.Inspect()
+		snap.Dispatch = &st
+	}
+	if in.Cron != nil {
+		snap.Cron = in.Cron.NextFires()
+	}
+	return snap, nil
+}
+
+// ServeHTTP writes the current Snapshot as JSON.
+func (in *Inspector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snap, err := in.Snapshot(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(snap)
+}
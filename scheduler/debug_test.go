@@ -0,0 +1,60 @@
+package scheduler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+func TestInspector_ServeHTTP(t *testing.T) {
+	tr := newMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	sched := scheduler.New(tr, newMemWorkerRepo(), q,
+		scheduler.WithPools(scheduler.NewPools(map[string]int{"warehouse": 1})))
+	t1, t2 := validTask("t1"), validTask("t2")
+	t1.Pool, t2.Pool = "warehouse", "warehouse"
+	t1.ConcurrencyKey = "orders"
+	_ = sched.Submit(ctx, t1)
+	_ = sched.Submit(ctx, t2)
+
+	wfRepo := mock.NewWorkflowRepo()
+	wf := &idomain.Workflow{ID: uuid.New(), Name: "hourly", ScheduleCron: "0 * * * *", IsActive: true}
+	_ = wfRepo.Create(ctx, wf)
+	ct := scheduler.NewCronTrigger(wfRepo, mock.NewWorkflowRunRepo())
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+
+	in := &scheduler.Inspector{Scheduler: sched, Cron: ct, Queue: q, Tasks: tr}
+	w := httptest.NewRecorder()
+	in.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/scheduler", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var snap scheduler.Snapshot
+	if err := json.NewDecoder(w.Body).Decode(&snap); err != nil {
+		t.Fatal(err)
+	}
+
+	if snap.Queue.Depth != 1 || snap.Queue.ByStatus[domain.TaskStatusPending] != 1 || snap.Queue.ByStatus[domain.TaskStatusQueued] != 1 {
+		t.Errorf("queue = %+v, want t1 queued and t2 pending", snap.Queue)
+	}
+	d := snap.Dispatch
+	if d == nil || len(d.Held) != 1 || d.Held[0].ID != "t2" || d.InFlight != 1 {
+		t.Fatalf("dispatch = %+v, want t2 held and t1 in flight", d)
+	}
+	if d.ConcurrencyKeys["orders"] != "t1" || d.Pools["warehouse"].Used != 1 {
+		t.Errorf("resources: keys %v, pools %v", d.ConcurrencyKeys, d.Pools)
+	}
+	if len(snap.Cron) != 1 || snap.Cron[0].Name != "hourly" || snap.Cron[0].Next.Minute() != 0 {
+		t.Errorf("cron = %+v, want the next top of the hour for hourly", snap.Cron)
+	}
+}
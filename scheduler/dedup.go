@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// DedupMode selects what makes two submitted tasks duplicates of each other.
+type DedupMode string
+
+const (
+	// DedupByKey treats tasks with the same IdempotencyKey as duplicates.
+	// Tasks without a key are never deduplicated.
+	DedupByKey DedupMode = "key"
+	// DedupByContent also treats tasks that would do the same work in the
+	// same workflow run as duplicates: the same Group, Type, Name,
+	// WorkflowID, RunID, Profile, Payload (or PayloadRef) and Env.
+	DedupByContent DedupMode = "content"
+)
+
+// ParseDedupMode parses "key" or "content".
+func ParseDedupMode(s string) (DedupMode, error) {
+	switch m := DedupMode(s); m {
+	case DedupByKey, DedupByContent:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown dedup mode %q: want key or content", s)
+}
+
+// DedupKey returns the content key task is deduplicated by under mode, or
+// "" if mode does not deduplicate by content. The Scheduler keeps it in
+// task.DedupKey, apart from the caller's IdempotencyKey.
+func DedupKey(task *domain.Task, mode DedupMode) string {
+	if mode != DedupByContent {
+		return ""
+	}
+	b, _ := json.Marshal(struct {
+		Group, Type, Name, WorkflowID, RunID, Profile, PayloadRef string
+		Payload                                                   []byte
+		Env                                                       map[string]string // encoded in key order
+	}{task.Group, task.Type, task.Name, task.WorkflowID, task.RunID, task.Profile, task.PayloadRef, task.Payload, task.Env})
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// WithDedupMode sets what makes two submitted tasks duplicates under
+// WithIdempotencyWindow. With DedupByContent, Submit also sets every
+// task's DedupKey and claims it alongside any IdempotencyKey, so a task
+// doing the same work in the same run as one submitted within the window
+// is rejected like a repeated key. The default, DedupByKey, only checks
+// keys callers set.
+func WithDedupMode(mode DedupMode) Option {
+	return func(s *Scheduler) { s.dedupMode = mode }
+}
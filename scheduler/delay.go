@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// delayedTask is a task waiting in the delay heap for its ScheduledAt.
+type delayedTask struct {
+	task *domain.Task
+	seq  uint64 // submission order, breaking ties between equal tasks
+}
+
+// delayHeap is a min-heap of delayed tasks ordered by ScheduledAt, then
+// Priority (highest first), then submission order. It implements
+// heap.Interface.
+type delayHeap []delayedTask
+
+func (h delayHeap) Len() int { return len(h) }
+
+func (h delayHeap) Less(i, j int) bool {
+	a, b := h[i].task, h[j].task
+	if !a.ScheduledAt.Equal(b.ScheduledAt) {
+		return a.ScheduledAt.Before(b.ScheduledAt)
+	}
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h delayHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *delayHeap) Push(x any) { *h = append(*h, x.(delayedTask)) }
+
+func (h *delayHeap) Pop() any {
+	old := *h
+	n := len(old) - 1
+	x := old[n]
+	old[n] = delayedTask{}
+	*h = old[:n]
+	return x
+}
+
+// DelayedTask is a task the Scheduler is holding until its ScheduledAt.
+type DelayedTask struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	WorkflowID  string    `json:"workflow_id,omitempty"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
+// Delayed returns the number of tasks waiting for their ScheduledAt.
+func (s *Scheduler) Delayed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.delayed)
+}
+
+// delayLocked holds task until its ScheduledAt. Callers must hold s.mu.
+func (s *Scheduler) delayLocked(task *domain.Task) {
+	s.delaySeq++
+	heap.Push(&s.delayed, delayedTask{task: task, seq: s.delaySeq})
+	s.armLocked()
+}
+
+// armLocked sets the wake timer for the earliest delayed task, unless one
+// is already set for that time. Callers must hold s.mu.
+func (s *Scheduler) armLocked() {
+	if len(s.delayed) == 0 {
+		return
+	}
+	due := s.delayed[0].task.ScheduledAt
+	if s.wakeTimer != nil {
+		if s.wakeAt.Equal(due) {
+			return
+		}
+		s.wakeTimer.Stop()
+	}
+	s.wakeAt = due
+	s.wakeTimer = s.clock.AfterFunc(due.Sub(s.clock.Now()), func() {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	})
+}
+
+// releaseDue passes the delayed tasks whose ScheduledAt has come, earliest
+// first, on to dispatch, or holds them back like Submit if their resources
+// are busy. Tasks cancelled while delayed are dropped.
+func (s *Scheduler) releaseDue(ctx context.Context) {
+	now := s.clock.Now()
+	s.mu.Lock()
+	var due []*domain.Task
+	for len(s.delayed) > 0 && !s.delayed[0].task.ScheduledAt.After(now) {
+		due = append(due, heap.Pop(&s.delayed).(delayedTask).task)
+	}
+	if s.wakeTimer != nil {
+		s.wakeTimer.Stop()
+		s.wakeTimer = nil
+	}
+	s.armLocked()
+	s.mu.Unlock()
+
+	for _, t := range due {
+		if stored, err := s.tasks.FindByID(ctx, t.ID); err != nil || stored.IsTerminal() {
+			continue
+		}
+		if t.Expired(now) {
+			_ = s.expire(ctx, t)
+			continue
+		}
+		s.mu.Lock()
+		admitted := s.admitLocked(t)
+		if !admitted {
+			s.held = append(s.held, t)
+		}
+		s.mu.Unlock()
+		if admitted {
+			s.redispatch(ctx, t)
+		}
+	}
+}
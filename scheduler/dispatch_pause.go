@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// PauseObserver is told each time a Scheduler's dispatching is paused or
+// resumed. metrics.Collector implements it.
+type PauseObserver interface {
+	ObserveDispatchPaused(paused bool)
+}
+
+// WithPauseObserver reports every Pause and Resume to obs.
+func WithPauseObserver(obs PauseObserver) Option {
+	return func(s *Scheduler) { s.pauseObs = obs }
+}
+
+// Pause stops s dispatching tasks, e.g. for maintenance of the workers or
+// the queue. Submit keeps accepting tasks but holds them back as Pending,
+// as it does tasks whose resources are busy, and so are delayed tasks that
+// come due. Tasks already queued are left for the workers; worker dispatch
+// freezes stop those being taken. Pausing a paused Scheduler does nothing.
+//
+// The pause lives in s's memory: it does not survive a restart, and other
+// scheduler replicas keep dispatching.
+func (s *Scheduler) Pause(_ context.Context) {
+	s.mu.Lock()
+	if !s.pausedAt.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.pausedAt = s.clock.Now()
+	s.mu.Unlock()
+	log.Printf("Scheduler: dispatch paused")
+	if s.pauseObs != nil {
+		s.pauseObs.ObserveDispatchPaused(true)
+	}
+}
+
+// Resume lets s dispatch again and dispatches, through Reconcile, the tasks
+// held back while it was paused. Resuming a Scheduler that is not paused
+// does nothing.
+func (s *Scheduler) Resume(ctx context.Context) {
+	s.mu.Lock()
+	if s.pausedAt.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	since := s.pausedAt
+	s.pausedAt = time.Time{}
+	s.mu.Unlock()
+	log.Printf("Scheduler: dispatch resumed after %s", s.clock.Now().Sub(since).Truncate(time.Second))
+	if s.pauseObs != nil {
+		s.pauseObs.ObserveDispatchPaused(false)
+	}
+	s.Reconcile(ctx)
+}
+
+// Paused reports whether s is paused, and since when.
+func (s *Scheduler) Paused() (bool, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.pausedAt.IsZero(), s.pausedAt
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// Drain prepares s for shutdown without dropping queued work: Submit
+// refuses new tasks with domain.ErrDraining, tasks held back or delayed
+// stay pending in the store instead of being dispatched, and Drain waits
+// until workers have emptied the queue and finished every task running in
+// the store. It checks at the dispatch interval, so Run must keep running
+// meanwhile. If ctx is done first, Drain returns an error wrapping ctx's
+// error with what was left. Draining cannot be undone.
+func (s *Scheduler) Drain(ctx context.Context) error {
+	s.mu.Lock()
+	already := s.draining
+	s.draining = true
+	s.mu.Unlock()
+	if !already {
+		log.Printf("Scheduler: draining")
+	}
+
+	ticker := s.clock.NewTicker(s.dispatchInterval)
+	defer ticker.Stop()
+	for {
+		queued, running, err := s.outstanding(ctx)
+		if err == nil && queued == 0 && running == 0 {
+			log.Printf("Scheduler: drained")
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("drain: %w (last check: %v)", ctx.Err(), err)
+			}
+			return fmt.Errorf("drain: %w with %d tasks queued and %d running", ctx.Err(), queued, running)
+		case <-ticker.C():
+		}
+	}
+}
+
+// Draining reports whether Drain has been called.
+func (s *Scheduler) Draining() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.draining
+}
+
+// outstanding counts the tasks waiting in the queue and those a worker is
+// running.
+func (s *Scheduler) outstanding(ctx context.Context) (queued, running int, err error) {
+	if queued, err = s.queue.Len(ctx); err != nil {
+		return 0, 0, fmt.Errorf("queue depth: %w", err)
+	}
+	tasks, err := s.tasks.FindByStatus(ctx, domain.TaskStatusRunning)
+	if err != nil {
+		return 0, 0, fmt.Errorf("list running tasks: %w", err)
+	}
+	return queued, len(tasks), nil
+}
@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// FairQueue is a thread-safe in-memory domain.Queue that shares dequeues
+// among workflows by weighted round-robin, so a workflow that enqueues
+// thousands of tasks cannot starve the others. Each WorkflowID has a FIFO
+// of its own, tasks without one sharing a single FIFO. While workflows A
+// of weight 3 and B of weight 1 both have tasks waiting, A gets three of
+// every four dequeues and B the fourth, interleaved rather than in runs.
+// A workflow with nothing waiting keeps no share in reserve.
+type FairQueue struct {
+	weights map[string]int
+
+	mu     sync.Mutex
+	flows  map[string]*fairFlow
+	active []*fairFlow // flows with tasks waiting, in the order they arrived
+	n      int
+	sig    chan struct{}
+}
+
+// fairFlow is the FIFO of one workflow. current is its smooth weighted
+// round-robin credit: raised by its weight every dequeue and lowered by
+// the total weight when it is served.
+type fairFlow struct {
+	weight  int
+	current int
+	buf     []*domain.Task
+}
+
+// NewFairQueue creates an empty FairQueue giving each workflow the weight
+// weights has for its ID; workflows without one, and tasks without a
+// workflow, weigh 1.
+func NewFairQueue(weights map[string]int) *FairQueue {
+	w := make(map[string]int, len(weights))
+	for id, n := range weights {
+		if n > 0 {
+			w[id] = n
+		}
+	}
+	return &FairQueue{weights: w, flows: make(map[string]*fairFlow), sig: make(chan struct{}, 1)}
+}
+
+// Enqueue appends task to the FIFO of its workflow and notifies any
+// blocked Dequeue callers.
+func (q *FairQueue) Enqueue(_ context.Context, task *domain.Task) error {
+	q.mu.Lock()
+	f := q.flows[task.WorkflowID]
+	if f == nil {
+		f = &fairFlow{weight: 1}
+		if w, ok := q.weights[task.WorkflowID]; ok {
+			f.weight = w
+		}
+		q.flows[task.WorkflowID] = f
+		q.active = append(q.active, f)
+	}
+	f.buf = append(f.buf, task)
+	q.n++
+	q.mu.Unlock()
+	select {
+	case q.sig <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Dequeue removes and returns the head task of the workflow whose turn it
+// is. It blocks until a task is available or ctx is cancelled, in which
+// case domain.ErrQueueEmpty is returned.
+func (q *FairQueue) Dequeue(ctx context.Context) (*domain.Task, error) {
+	for {
+		q.mu.Lock()
+		if q.n > 0 {
+			t := q.nextLocked()
+			remaining := q.n
+			q.mu.Unlock()
+			if remaining > 0 {
+				select {
+				case q.sig <- struct{}{}:
+				default:
+				}
+			}
+			return t, nil
+		}
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, domain.ErrQueueEmpty
+		case <-q.sig:
+		}
+	}
+}
+
+// nextLocked pops the next task by smooth weighted round-robin over the
+// active flows. Callers must hold q.mu and ensure a task is waiting.
+func (q *FairQueue) nextLocked() *domain.Task {
+	total, best := 0, 0
+	for i, f := range q.active {
+		f.current += f.weight
+		total += f.weight
+		if f.current > q.active[best].current {
+			best = i
+		}
+	}
+	f := q.active[best]
+	f.current -= total
+	t := f.buf[0]
+	f.buf = f.buf[1:]
+	q.n--
+	if len(f.buf) == 0 {
+		q.active = append(q.active[:best], q.active[best+1:]...)
+		delete(q.flows, t.WorkflowID)
+	}
+	return t
+}
+
+// Len returns the number of tasks currently waiting in the queue.
+func (q *FairQueue) Len(_ context.Context) (int, error) {
+	q.mu.Lock()
+	n := q.n
+	q.mu.Unlock()
+	return n, nil
+}
@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// GroupQueues partitions the queue by worker group, so that each group's
+// tasks are only ever dequeued by that group's workers and one team's
+// backlog cannot occupy another team's capacity.
+//
+// As a domain.Queue, GroupQueues routes each enqueued task to the queue of
+// its Group, or to the domain.NamedQueue of its Queue if it names one;
+// Dequeue serves the default group and Len counts the tasks of every group
+// and named queue opened so far. Workers of a named group dequeue from
+// Queue(group) instead, and workers dedicated to named queues from Named.
+type GroupQueues struct {
+	def  domain.Queue
+	open func(group string) (domain.Queue, error)
+
+	mu     sync.Mutex
+	groups map[string]domain.Queue
+}
+
+// NewGroupQueues returns a GroupQueues whose default group uses def and
+// whose named groups use the queue open returns for them, opened the first
+// time each group is used. A nil open gives every group a MemQueue.
+func NewGroupQueues(def domain.Queue, open func(group string) (domain.Queue, error)) *GroupQueues {
+	if open == nil {
+		open = func(string) (domain.Queue, error) { return NewMemQueue(), nil }
+	}
+	return &GroupQueues{def: def, open: open, groups: make(map[string]domain.Queue)}
+}
+
+// Queue returns the queue of group, opening it if needed. The default group
+// is always available; other names must satisfy domain.ValidGroupName.
+func (g *GroupQueues) Queue(group string) (domain.Queue, error) {
+	if group == domain.DefaultGroup {
+		return g.def, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if q, ok := g.groups[group]; ok {
+		return q, nil
+	}
+	if !domain.ValidGroupName(group) {
+		return nil, fmt.Errorf("invalid worker group name %q", group)
+	}
+	q, err := g.open(group)
+	if err != nil {
+		return nil, fmt.Errorf("open queue of worker group %s: %w", group, err)
+	}
+	g.groups[group] = q
+	return q, nil
+}
+
+// Groups returns the named groups opened so far, sorted.
+func (g *GroupQueues) Groups() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	names := make([]string, 0, len(g.groups))
+	for name := range g.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Enqueue appends task to the named queue of its Queue, if it has one, or
+// else to the queue of its Group.
+func (g *GroupQueues) Enqueue(ctx context.Context, task *domain.Task) error {
+	name := task.Group
+	if task.Queue != "" {
+		name = domain.NamedQueue(task.Queue)
+	}
+	q, err := g.Queue(name)
+	if err != nil {
+		return err
+	}
+	return q.Enqueue(ctx, task)
+}
+
+// Dequeue removes and returns the head task of the default group.
+func (g *GroupQueues) Dequeue(ctx context.Context) (*domain.Task, error) {
+	return g.def.Dequeue(ctx)
+}
+
+// Len returns the number of tasks waiting in the default group and every
+// named group opened so far.
+func (g *GroupQueues) Len(ctx context.Context) (int, error) {
+	total, err := g.def.Len(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, name := range g.Groups() {
+		q, err := g.Queue(name)
+		if err != nil {
+			return 0, err
+		}
+		n, err := q.Len(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("worker group %s: %w", name, err)
+		}
+		total += n
+	}
+	return total, nil
+}
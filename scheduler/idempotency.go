@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// DuplicateTaskError is returned by Submit for a task whose IdempotencyKey
+// was already submitted within the idempotency window. It wraps
+// domain.ErrDuplicateTask.
+type DuplicateTaskError struct {
+	Key      string
+	TaskID   string       // the task first submitted with Key
+	Existing *domain.Task // that task as stored, or nil if it is gone
+}
+
+func (e *DuplicateTaskError) Error() string {
+	return fmt.Sprintf("%s: key %q was used by task %s", domain.ErrDuplicateTask, e.Key, e.TaskID)
+}
+
+func (e *DuplicateTaskError) Unwrap() error { return domain.ErrDuplicateTask }
+
+// WithIdempotencyWindow makes Submit reject a task whose IdempotencyKey,
+// or DedupKey under WithDedupMode, was claimed by a task with another ID
+// less than d ago, returning a *DuplicateTaskError carrying that task. Keys
+// are checked against the Scheduler's TaskRepository, so every Scheduler
+// sharing it sees the same claims. It must be a domain.IdempotencyStore, as
+// MemTaskRepo and the Postgres repository are; New logs that the window is
+// ignored otherwise.
+func WithIdempotencyWindow(d time.Duration) Option {
+	return func(s *Scheduler) { s.idemWindow = d }
+}
+
+// claimKey claims task's IdempotencyKey and DedupKey for it at now, saving
+// it, or returns a *DuplicateTaskError naming the key another task claimed
+// within the window.
+func (s *Scheduler) claimKey(ctx context.Context, task *domain.Task, now time.Time) error {
+	store, ok := s.tasks.(domain.IdempotencyStore)
+	if !ok {
+		return nil
+	}
+	held, err := store.ClaimKey(ctx, task, now.Add(-s.idemWindow))
+	if errors.Is(err, domain.ErrDuplicateTask) {
+		dup := &DuplicateTaskError{Key: task.IdempotencyKey, Existing: held}
+		if held != nil {
+			dup.TaskID = held.ID
+			if task.IdempotencyKey == "" || held.IdempotencyKey != task.IdempotencyKey {
+				dup.Key = task.DedupKey
+			}
+		}
+		return dup
+	}
+	if err != nil {
+		return fmt.Errorf("claim keys of task %s: %w", task.ID, err)
+	}
+	return nil
+}
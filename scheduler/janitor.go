@@ -0,0 +1,288 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+)
+
+// DefaultJanitorInterval is how often RunJanitor.Run looks for stale runs.
+const DefaultJanitorInterval = time.Minute
+
+// StalePolicy decides what RunJanitor does with a stale workflow run.
+type StalePolicy string
+
+const (
+	// StaleResubmit starts the run again if it is pending, or else submits
+	// again the tasks of the run that were lost before reaching the queue.
+	// A run still stale after twice the threshold is failed.
+	StaleResubmit StalePolicy = "resubmit"
+	// StaleFail fails the run and cancels its tasks.
+	StaleFail StalePolicy = "fail"
+)
+
+// Valid reports whether p is a known policy.
+func (p StalePolicy) Valid() bool {
+	return p == StaleResubmit || p == StaleFail
+}
+
+// Actions a RunJanitor reports to its StaleRunObserver.
+const (
+	StaleRunResubmitted = "resubmitted"
+	StaleRunFailed      = "failed"
+)
+
+// StaleRunObserver is told of every stale run a RunJanitor repairs, with
+// the action taken: StaleRunResubmitted or StaleRunFailed.
+// metrics.Collector implements it.
+type StaleRunObserver interface {
+	ObserveStaleRun(action string)
+}
+
+// RunJanitor finds workflow runs that have made no progress for longer than
+// its threshold, counted from their StartedAt, and repairs them according
+// to its StalePolicy. A run is stale while it is pending although its
+// workflow's MaxActiveRuns would let it start, or running with none of its
+// tasks dispatched to a worker or settled: the orchestrator never got to
+// it, or its tasks were lost, e.g. held by a scheduler that restarted.
+//
+// Runs waiting for an approval, and runs whose tasks sit in the queue, e.g.
+// behind a dispatch freeze, are stale too once the threshold passes, but
+// only failing helps them; pick a threshold above the longest expected wait.
+type RunJanitor struct {
+	o         *Orchestrator
+	threshold time.Duration
+	policy    StalePolicy
+	clock     clock.Clock
+	interval  time.Duration
+	observer  StaleRunObserver
+}
+
+// JanitorOption is a functional option for configuring a RunJanitor.
+type JanitorOption func(*RunJanitor)
+
+// WithJanitorClock sets the clock run ages are measured against. The default
+// is clock.Real.
+func WithJanitorClock(c clock.Clock) JanitorOption {
+	return func(j *RunJanitor) { j.clock = c }
+}
+
+// WithJanitorInterval sets how often Run looks for stale runs. The default
+// is DefaultJanitorInterval.
+func WithJanitorInterval(d time.Duration) JanitorOption {
+	return func(j *RunJanitor) { j.interval = d }
+}
+
+// WithStaleRunObserver reports each repaired run to obs.
+func WithStaleRunObserver(obs StaleRunObserver) JanitorOption {
+	return func(j *RunJanitor) { j.observer = obs }
+}
+
+// NewRunJanitor creates a RunJanitor that repairs, with policy, the runs
+// of o that have been stale for longer than threshold. An empty policy
+// means StaleResubmit.
+func NewRunJanitor(o *Orchestrator, threshold time.Duration, policy StalePolicy, opts ...JanitorOption) *RunJanitor {
+	if policy == "" {
+		policy = StaleResubmit
+	}
+	j := &RunJanitor{
+		o:         o,
+		threshold: threshold,
+		policy:    policy,
+		clock:     clock.Real,
+		interval:  DefaultJanitorInterval,
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j
+}
+
+// Run calls Sweep at the configured interval until ctx is cancelled.
+func (j *RunJanitor) Run(ctx context.Context) error {
+	ticker := j.clock.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+			if _, err := j.Sweep(ctx); err != nil {
+				log.Printf("RunJanitor: %v", err)
+			}
+		}
+	}
+}
+
+// JanitorResult lists the runs one Sweep repaired.
+type JanitorResult struct {
+	Resubmitted []uuid.UUID `json:"resubmitted"`
+	Failed      []uuid.UUID `json:"failed"`
+}
+
+// Sweep repairs every stale run once. A run that cannot be repaired is
+// logged and retried on the next sweep.
+func (j *RunJanitor) Sweep(ctx context.Context) (JanitorResult, error) {
+	var res JanitorResult
+	now := j.clock.Now()
+	cutoff := now.Add(-j.threshold)
+
+	pending, err := j.o.workflowRuns.ListByStatus(ctx, domain.StatusPending)
+	if err != nil {
+		return res, fmt.Errorf("list pending runs: %w", err)
+	}
+	// Runs MaxActiveRuns keeps waiting are not stale.
+	for _, run := range j.o.startable(ctx, slices.Clone(pending)) {
+		if run.StartedAt.After(cutoff) {
+			continue
+		}
+		j.repair(ctx, run, nil, now, &res)
+	}
+
+	running, err := j.o.workflowRuns.ListByStatus(ctx, domain.StatusRunning)
+	if err != nil {
+		return res, fmt.Errorf("list running runs: %w", err)
+	}
+	for _, run := range running {
+		if run.StartedAt.After(cutoff) {
+			continue
+		}
+		stuck, err := j.stuckTaskRuns(ctx, run)
+		if err != nil {
+			log.Printf("RunJanitor: workflow run %s: %v", run.ID, err)
+			continue
+		}
+		if stuck != nil {
+			j.repair(ctx, run, stuck, now, &res)
+		}
+	}
+	return res, nil
+}
+
+// stuckTask is a task run that never reached a worker, with the queue task
+// that was to execute it, if one was saved.
+type stuckTask struct {
+	run   *domain.TaskRun
+	queue *qdomain.Task
+}
+
+// stuckTaskRuns returns the task runs of the running run if none of them
+// was dispatched or settled, or nil if the run is making progress.
+func (j *RunJanitor) stuckTaskRuns(ctx context.Context, run *domain.WorkflowRun) ([]stuckTask, error) {
+	trs, err := j.o.taskRuns.ListByWorkflowRunID(ctx, run.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list task runs: %w", err)
+	}
+	stuck := make([]stuckTask, 0, len(trs))
+	for _, tr := range trs {
+		if tr.Status != domain.StatusRunning || tr.DispatchedAt != nil {
+			return nil, nil
+		}
+		qt, err := j.o.queueTasks.FindByID(ctx, tr.ID.String())
+		if err != nil && !errors.Is(err, qdomain.ErrTaskNotFound) {
+			return nil, fmt.Errorf("task run %s: %w", tr.ID, err)
+		}
+		if qt != nil && (qt.DispatchedAt != nil || qt.IsTerminal()) {
+			return nil, nil
+		}
+		stuck = append(stuck, stuckTask{run: tr, queue: qt})
+	}
+	return stuck, nil
+}
+
+// repair applies the policy to the stale run, whose stuck task runs are
+// given if it is running.
+func (j *RunJanitor) repair(ctx context.Context, run *domain.WorkflowRun, stuck []stuckTask, now time.Time, res *JanitorResult) {
+	age := now.Sub(run.StartedAt)
+	if j.policy == StaleResubmit && age < 2*j.threshold {
+		resubmitted, err := j.resubmit(ctx, run, stuck)
+		if err != nil {
+			log.Printf("RunJanitor: resubmit workflow run %s: %v", run.ID, err)
+			return
+		}
+		if resubmitted {
+			log.Printf("RunJanitor: workflow run %s stale for %s, resubmitted", run.ID, age.Truncate(time.Second))
+			res.Resubmitted = append(res.Resubmitted, run.ID)
+			j.observe(StaleRunResubmitted)
+		}
+		return
+	}
+	if err := j.fail(ctx, run, stuck, now); err != nil {
+		log.Printf("RunJanitor: fail workflow run %s: %v", run.ID, err)
+		return
+	}
+	log.Printf("RunJanitor: workflow run %s stale for %s, failed", run.ID, age.Truncate(time.Second))
+	res.Failed = append(res.Failed, run.ID)
+	j.observe(StaleRunFailed)
+}
+
+// resubmit starts the pending run, or resubmits the lost tasks of the
+// running one: a task run whose queue task was never saved goes back to
+// pending for the orchestrator to start again, and a pending queue task the
+// Scheduler has lost track of is submitted again. Tasks waiting in the
+// queue or held by the Scheduler are left alone. It reports whether
+// anything was resubmitted.
+func (j *RunJanitor) resubmit(ctx context.Context, run *domain.WorkflowRun, stuck []stuckTask) (bool, error) {
+	if run.Status == domain.StatusPending {
+		if err := j.o.workflowRuns.UpdateStatus(ctx, run.ID, domain.StatusRunning, nil); err != nil {
+			return false, err
+		}
+		run.Status = domain.StatusRunning
+		j.o.publish(ctx, events.WorkflowStatus, *run)
+		return true, nil
+	}
+	resubmitted := false
+	for _, st := range stuck {
+		switch {
+		case st.queue == nil:
+			if err := j.o.taskRuns.UpdateStatus(ctx, st.run.ID, domain.StatusPending, nil); err != nil {
+				return resubmitted, fmt.Errorf("task run %s: %w", st.run.ID, err)
+			}
+		case st.queue.Status == qdomain.TaskStatusPending && !j.o.sched.tracks(st.queue.ID):
+			if err := j.o.sched.Submit(ctx, st.queue); err != nil {
+				return resubmitted, fmt.Errorf("task run %s: %w", st.run.ID, err)
+			}
+		default:
+			continue
+		}
+		resubmitted = true
+	}
+	return resubmitted, nil
+}
+
+// fail fails the run, its stuck task runs and their queue tasks.
+func (j *RunJanitor) fail(ctx context.Context, run *domain.WorkflowRun, stuck []stuckTask, now time.Time) error {
+	for _, st := range stuck {
+		if st.queue != nil {
+			if err := j.o.sched.Cancel(ctx, st.queue.ID); err != nil {
+				return fmt.Errorf("cancel task %s: %w", st.queue.ID, err)
+			}
+		}
+		if err := j.o.taskRuns.UpdateStatus(ctx, st.run.ID, domain.StatusFailed, &now); err != nil {
+			return fmt.Errorf("task run %s: %w", st.run.ID, err)
+		}
+		st.run.Status, st.run.FinishedAt = domain.StatusFailed, &now
+		j.o.publish(ctx, events.TaskStatus, *st.run)
+	}
+	if err := j.o.workflowRuns.UpdateStatus(ctx, run.ID, domain.StatusFailed, &now); err != nil {
+		return err
+	}
+	run.Status, run.FinishedAt = domain.StatusFailed, &now
+	j.o.publish(ctx, events.WorkflowStatus, *run)
+	return nil
+}
+
+func (j *RunJanitor) observe(action string) {
+	if j.observer != nil {
+		j.observer.ObserveStaleRun(action)
+	}
+}
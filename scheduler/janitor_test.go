@@ -0,0 +1,187 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+// staleRunCounts records the actions a RunJanitor reports.
+type staleRunCounts map[string]int
+
+func (c staleRunCounts) ObserveStaleRun(action string) { c[action]++ }
+
+// staleRunFixture holds workflow runs started 90 minutes before the fake
+// clock's now, each stuck in a different way, and one fresh run.
+type staleRunFixture struct {
+	*orchFixture
+	fc *clock.Fake
+
+	pending    *idomain.WorkflowRun // never started
+	fresh      *idomain.WorkflowRun // pending, but only just created
+	held       *idomain.WorkflowRun // pending behind MaxActiveRuns
+	lost       *idomain.WorkflowRun // task run saved, queue task not
+	untracked  *idomain.WorkflowRun // queue task pending, Scheduler unaware
+	queued     *idomain.WorkflowRun // queue task waiting in the queue
+	dispatched *idomain.WorkflowRun // queue task taken by a worker
+}
+
+func newStaleRunFixture() *staleRunFixture {
+	wfs := mock.NewWorkflowRepo()
+	f := &staleRunFixture{orchFixture: newOrchFixture(scheduler.WithWorkflows(wfs)), fc: clock.NewFake(time.Now())}
+	limited := &idomain.Workflow{ID: uuid.New(), Name: "limited", MaxActiveRuns: 1}
+	_ = wfs.Create(ctx, &idomain.Workflow{ID: f.wfID, Name: "etl"})
+	_ = wfs.Create(ctx, limited)
+	extract := f.addTask("extract", idomain.TaskTypeCommand, "")
+
+	old := f.fc.Now().Add(-90 * time.Minute)
+	newRun := func(wfID uuid.UUID, status idomain.Status, started time.Time) *idomain.WorkflowRun {
+		run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: wfID, Status: status, StartedAt: started}
+		_ = f.runs.Create(ctx, run)
+		return run
+	}
+	// newTaskRun saves a running task run of run and, unless status is
+	// empty, the queue task executing it.
+	newTaskRun := func(run *idomain.WorkflowRun, status domain.TaskStatus) {
+		tr := &idomain.TaskRun{ID: uuid.New(), WorkflowRunID: run.ID, TaskID: extract.ID, Status: idomain.StatusRunning, StartedAt: old}
+		if status == domain.TaskStatusRunning {
+			tr.AssignedWorkerID, tr.DispatchedAt = "w1", &old
+		}
+		_ = f.taskRuns.Create(ctx, tr)
+		if status == "" {
+			return
+		}
+		qt := scheduler.QueueTask(tr.ID.String(), extract, old)
+		qt.Status = status
+		if status == domain.TaskStatusRunning {
+			qt.WorkerID, qt.DispatchedAt = "w1", &old
+		}
+		_ = f.qtasks.Save(ctx, qt)
+		if status == domain.TaskStatusQueued {
+			_ = f.queue.Enqueue(ctx, qt)
+		}
+	}
+
+	f.pending = newRun(f.wfID, idomain.StatusPending, old)
+	f.fresh = newRun(f.wfID, idomain.StatusPending, f.fc.Now())
+	newRun(limited.ID, idomain.StatusRunning, f.fc.Now())
+	f.held = newRun(limited.ID, idomain.StatusPending, old)
+	f.lost = newRun(f.wfID, idomain.StatusRunning, old)
+	newTaskRun(f.lost, "")
+	f.untracked = newRun(f.wfID, idomain.StatusRunning, old)
+	newTaskRun(f.untracked, domain.TaskStatusPending)
+	f.queued = newRun(f.wfID, idomain.StatusRunning, old)
+	newTaskRun(f.queued, domain.TaskStatusQueued)
+	f.dispatched = newRun(f.wfID, idomain.StatusRunning, old)
+	newTaskRun(f.dispatched, domain.TaskStatusRunning)
+	return f
+}
+
+func (f *staleRunFixture) runStatus(run *idomain.WorkflowRun) idomain.Status {
+	got, _ := f.runs.GetByID(ctx, run.ID)
+	return got.Status
+}
+
+func sameRuns(got []uuid.UUID, want ...*idomain.WorkflowRun) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	ids := make(map[uuid.UUID]bool, len(got))
+	for _, id := range got {
+		ids[id] = true
+	}
+	for _, run := range want {
+		if !ids[run.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRunJanitor_ResubmitsThenFailsStaleRuns(t *testing.T) {
+	f := newStaleRunFixture()
+	counts := staleRunCounts{}
+	janitor := scheduler.NewRunJanitor(f.orch, time.Hour, scheduler.StaleResubmit,
+		scheduler.WithJanitorClock(f.fc), scheduler.WithStaleRunObserver(counts))
+
+	res, err := janitor.Sweep(ctx)
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if !sameRuns(res.Resubmitted, f.pending, f.lost, f.untracked) || len(res.Failed) != 0 {
+		t.Errorf("first sweep: resubmitted %v, failed %v; want pending, lost and untracked resubmitted", res.Resubmitted, res.Failed)
+	}
+	if got := f.runStatus(f.pending); got != idomain.StatusRunning {
+		t.Errorf("pending run: got %q, want running", got)
+	}
+	for name, run := range map[string]*idomain.WorkflowRun{"fresh": f.fresh, "held": f.held} {
+		if got := f.runStatus(run); got != idomain.StatusPending {
+			t.Errorf("%s run: got %q, want pending", name, got)
+		}
+	}
+	if trs, _ := f.taskRuns.ListByWorkflowRunID(ctx, f.lost.ID); trs[0].Status != idomain.StatusPending {
+		t.Errorf("lost task run: got %q, want pending for the orchestrator to restart", trs[0].Status)
+	}
+	// The queued run's task was already there; the untracked one joins it.
+	if n, _ := f.queue.Len(ctx); n != 2 {
+		t.Errorf("queue depth: got %d, want 2", n)
+	}
+
+	// The orchestrator starts the resubmitted runs' tasks, but no worker
+	// takes them; once twice the threshold has passed the runs are failed.
+	// Reconcile also finishes the limited workflow's active run, which has
+	// no tasks, so the held run starts and is as stuck as the others.
+	if err := f.orch.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	f.fc.Advance(45 * time.Minute)
+	res, err = janitor.Sweep(ctx)
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if !sameRuns(res.Failed, f.pending, f.held, f.lost, f.untracked, f.queued) || len(res.Resubmitted) != 0 {
+		t.Errorf("second sweep: resubmitted %v, failed %v; want the five stuck runs failed", res.Resubmitted, res.Failed)
+	}
+	for _, run := range []*idomain.WorkflowRun{f.pending, f.held, f.lost, f.untracked, f.queued} {
+		trs, _ := f.taskRuns.ListByWorkflowRunID(ctx, run.ID)
+		for _, tr := range trs {
+			if qt, _ := f.qtasks.FindByID(ctx, tr.ID.String()); tr.Status != idomain.StatusFailed || qt.Status != domain.TaskStatusFailed {
+				t.Errorf("run %s: task run %q, queue task %q; want both failed", run.ID, tr.Status, qt.Status)
+			}
+		}
+	}
+	if got := f.runStatus(f.dispatched); got != idomain.StatusRunning {
+		t.Errorf("dispatched run: got %q, want it left running", got)
+	}
+	if counts[scheduler.StaleRunResubmitted] != 3 || counts[scheduler.StaleRunFailed] != 5 {
+		t.Errorf("observed %v, want 3 resubmitted and 5 failed", counts)
+	}
+}
+
+func TestRunJanitor_FailPolicy(t *testing.T) {
+	f := newStaleRunFixture()
+	janitor := scheduler.NewRunJanitor(f.orch, time.Hour, scheduler.StaleFail, scheduler.WithJanitorClock(f.fc))
+
+	res, err := janitor.Sweep(ctx)
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if !sameRuns(res.Failed, f.pending, f.lost, f.untracked, f.queued) || len(res.Resubmitted) != 0 {
+		t.Errorf("resubmitted %v, failed %v; want every stale run failed", res.Resubmitted, res.Failed)
+	}
+	for name, run := range map[string]*idomain.WorkflowRun{"pending": f.pending, "lost": f.lost, "untracked": f.untracked, "queued": f.queued} {
+		got, _ := f.runs.GetByID(ctx, run.ID)
+		if got.Status != idomain.StatusFailed || got.FinishedAt == nil {
+			t.Errorf("%s run: got %q (finished %v), want failed", name, got.Status, got.FinishedAt)
+		}
+	}
+	if got := f.runStatus(f.held); got != idomain.StatusPending {
+		t.Errorf("held run: got %q, want pending", got)
+	}
+}
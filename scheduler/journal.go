@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// journalCompactAfter is how many dequeues a journal records before it is
+// rewritten with only the tasks still queued, unless more tasks than that
+// are queued.
+const journalCompactAfter = 1024
+
+// journalRecord is one line of a queue journal: a task added to the queue,
+// or the head of the queue taken off it.
+type journalRecord struct {
+	Op   string       `json:"op"` // "enqueue" or "dequeue"
+	Task *domain.Task `json:"task,omitempty"`
+	ID   string       `json:"id,omitempty"` // of the task dequeued
+}
+
+// queueJournal is the append-only file a journaled MemQueue writes each
+// change to before applying it.
+type queueJournal struct {
+	path     string
+	f        *os.File
+	dequeues int // recorded since the journal was last compacted
+}
+
+// OpenMemQueue returns a MemQueue whose changes are written to the journal
+// at path, created if missing, before they take effect, so the tasks it
+// holds survive a restart of the process. The tasks the journal records as
+// queued are replayed, in order, into the returned queue. A task is dropped
+// from the journal once dequeued: one a worker was running when the process
+// stopped is not queued again.
+//
+// Every change is synced to disk before Enqueue or Dequeue return. The
+// journal is rewritten with only the queued tasks when opened and as
+// dequeues accumulate. Close the queue to close the file.
+func OpenMemQueue(path string) (*MemQueue, error) {
+	tasks, err := replayJournal(path)
+	if err != nil {
+		return nil, err
+	}
+	q := NewMemQueue()
+	q.buf = tasks
+	q.journal = &queueJournal{path: path}
+	if err := q.journal.compact(tasks); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// replayJournal returns the tasks the journal at path leaves queued. A
+// final line cut short by a crash is ignored.
+func replayJournal(path string) ([]*domain.Task, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("queue journal: %w", err)
+	}
+	var tasks []*domain.Task
+	r := bufio.NewReader(bytes.NewReader(data))
+	for line := 1; ; line++ {
+		b, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return tasks, nil // a partial last record was never acknowledged
+		}
+		var rec journalRecord
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return nil, fmt.Errorf("queue journal %s: line %d: %w", path, line, err)
+		}
+		switch {
+		case rec.Op == "enqueue" && rec.Task != nil:
+			tasks = insertByPriority(tasks, rec.Task)
+		case rec.Op == "dequeue" && len(tasks) > 0 && tasks[0].ID == rec.ID:
+			tasks = tasks[1:]
+		default:
+			return nil, fmt.Errorf("queue journal %s: line %d: %s of task %q does not follow from the records before it", path, line, rec.Op, rec.ID)
+		}
+	}
+}
+
+// append writes rec to the journal and syncs it.
+func (j *queueJournal) append(rec journalRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("queue journal: encode: %w", err)
+	}
+	if _, err := j.f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("queue journal: %w", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("queue journal: %w", err)
+	}
+	if rec.Op == "dequeue" {
+		j.dequeues++
+	}
+	return nil
+}
+
+// due reports whether the journal should be compacted while waiting tasks
+// remain queued.
+func (j *queueJournal) due(waiting int) bool {
+	return j.dequeues >= max(journalCompactAfter, waiting)
+}
+
+// compact replaces the journal with one recording tasks as queued. The new
+// journal is written beside the old one and renamed over it, so a crash
+// leaves one or the other.
+func (j *queueJournal) compact(tasks []*domain.Task) error {
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err != nil {
+		return fmt.Errorf("queue journal: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, t := range tasks {
+		if err := enc.Encode(journalRecord{Op: "enqueue", Task: t}); err != nil {
+			tmp.Close()
+			return fmt.Errorf("queue journal: encode task %s: %w", t.ID, err)
+		}
+	}
+	if err := errors.Join(w.Flush(), tmp.Sync(), tmp.Close()); err != nil {
+		return fmt.Errorf("queue journal: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return fmt.Errorf("queue journal: %w", err)
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("queue journal: %w", err)
+	}
+	if j.f != nil {
+		j.f.Close()
+	}
+	j.f, j.dequeues = f, 0
+	return nil
+}
@@ -0,0 +1,211 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+)
+
+// DefaultElectionInterval is how often an Elector tries to take, or
+// renew, the leader lock.
+const DefaultElectionInterval = 5 * time.Second
+
+// LeaderLock is one candidate's handle on a lock that at most one candidate
+// holds at a time. Every scheduler instance opens its own handle on the
+// same lock.
+type LeaderLock interface {
+	// TryLock takes the lock if it is free, or renews it if this handle
+	// holds it, and reports whether this handle holds it now. It does not
+	// wait for another holder to let go.
+	TryLock(ctx context.Context) (bool, error)
+	// Unlock lets go of the lock if this handle holds it.
+	Unlock(ctx context.Context) error
+}
+
+// Elector runs a function only while its instance holds a LeaderLock, so
+// that of several scheduler instances only one does the work that must not
+// be done twice, such as firing cron schedules. The others keep trying and
+// one of them takes over once the leader lets go of the lock or loses it,
+// e.g. because it died and its lease ran out.
+type Elector struct {
+	lock     LeaderLock
+	clock    clock.Clock
+	interval time.Duration
+	leader   atomic.Bool
+}
+
+// ElectorOption is a functional option for configuring an Elector.
+type ElectorOption func(*Elector)
+
+// WithElectionInterval sets how often the Elector tries to take or renew
+// the lock. The default is DefaultElectionInterval; keep it well under the
+// time a lost leader's lock takes to free up, such as a Redis lease's TTL.
+func WithElectionInterval(d time.Duration) ElectorOption {
+	return func(e *Elector) { e.interval = d }
+}
+
+// WithElectorClock sets the clock elections are timed by. The default is
+// clock.Real.
+func WithElectorClock(c clock.Clock) ElectorOption {
+	return func(e *Elector) { e.clock = c }
+}
+
+// NewElector creates an Elector that campaigns with lock.
+func NewElector(lock LeaderLock, opts ...ElectorOption) *Elector {
+	e := &Elector{lock: lock, clock: clock.Real, interval: DefaultElectionInterval}
+	for _, o := range opts {
+		o(e)
+	}
+	return e
+}
+
+// IsLeader reports whether the Elector holds the lock.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run tries to take the lock every interval until ctx is cancelled. Once it
+// has the lock, it calls lead with a context that is cancelled when the
+// lock cannot be renewed, and renews the lock every interval meanwhile. A
+// failed renewal steps down at once rather than risk two leaders. If lead
+// returns, Run lets go of the lock so another instance can lead, and keeps
+// campaigning. Run lets go of the lock, after lead has returned, before
+// returning itself.
+func (e *Elector) Run(ctx context.Context, lead func(context.Context) error) error {
+	ticker := e.clock.NewTicker(e.interval)
+	defer ticker.Stop()
+	var term *leaderTerm
+	defer func() { e.stepDown(ctx, term) }()
+	for {
+		held, err := e.lock.TryLock(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Elector: leader lock: %v", err)
+		}
+		switch {
+		case held && term == nil:
+			log.Println("Elector: elected leader")
+			term = e.startTerm(ctx, lead)
+		case !held && term != nil:
+			log.Println("Elector: lost the leader lock; stepping down")
+			e.stepDown(ctx, term)
+			term = nil
+		}
+
+		var ended <-chan struct{}
+		if term != nil {
+			ended = term.done
+		}
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ended:
+				if term.err != nil {
+					log.Printf("Elector: stepping down: %v", term.err)
+				}
+				e.stepDown(ctx, term)
+				term, ended = nil, nil
+			case <-ticker.C():
+				break wait
+			}
+		}
+	}
+}
+
+// leaderTerm is one call of the lead function.
+type leaderTerm struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error // set before done is closed
+}
+
+func (e *Elector) startTerm(ctx context.Context, lead func(context.Context) error) *leaderTerm {
+	lctx, cancel := context.WithCancel(ctx)
+	term := &leaderTerm{cancel: cancel, done: make(chan struct{})}
+	e.leader.Store(true)
+	go func() {
+		defer close(term.done)
+		term.err = lead(lctx)
+	}()
+	return term
+}
+
+// stepDown ends term, if any, waits for its lead function to return and
+// lets go of the lock.
+func (e *Elector) stepDown(ctx context.Context, term *leaderTerm) {
+	if term == nil {
+		return
+	}
+	term.cancel()
+	<-term.done
+	e.leader.Store(false)
+	if err := e.lock.Unlock(context.WithoutCancel(ctx)); err != nil {
+		log.Printf("Elector: release leader lock: %v", err)
+	}
+}
+
+// MemLeaderLock is a lock held in memory, for candidates in one process
+// such as tests and embedded engines. Each candidate takes its own handle
+// with Handle.
+type MemLeaderLock struct {
+	mu     sync.Mutex
+	holder *memLeaderHandle
+}
+
+// NewMemLeaderLock creates a free MemLeaderLock.
+func NewMemLeaderLock() *MemLeaderLock {
+	return &MemLeaderLock{}
+}
+
+// Handle returns a new candidate's handle on the lock.
+func (l *MemLeaderLock) Handle() LeaderLock {
+	return &memLeaderHandle{lock: l}
+}
+
+// Expire frees the lock whoever holds it, as a lease running out does, so
+// tests can act out a leader that stalled or lost its connection. The
+// holder's next TryLock fails to renew it.
+func (l *MemLeaderLock) Expire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder != nil {
+		l.holder.lost = true
+		l.holder = nil
+	}
+}
+
+type memLeaderHandle struct {
+	lock *MemLeaderLock
+	// lost is set once the lock expired under this handle, whose next
+	// TryLock then reports the renewal failed.
+	lost bool
+}
+
+func (h *memLeaderHandle) TryLock(context.Context) (bool, error) {
+	h.lock.mu.Lock()
+	defer h.lock.mu.Unlock()
+	switch {
+	case h.lock.holder == h:
+		return true, nil
+	case h.lock.holder == nil && !h.lost:
+		h.lock.holder = h
+		return true, nil
+	default:
+		h.lost = false
+		return false, nil
+	}
+}
+
+func (h *memLeaderHandle) Unlock(context.Context) error {
+	h.lock.mu.Lock()
+	defer h.lock.mu.Unlock()
+	if h.lock.holder == h {
+		h.lock.holder = nil
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+func TestElector_OneLeaderWithFailover(t *testing.T) {
+	fc := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	lock := scheduler.NewMemLeaderLock()
+	var leading atomic.Int32 // instances inside lead
+	var terms atomic.Int32
+	lead := func(ctx context.Context) error {
+		leading.Add(1)
+		terms.Add(1)
+		<-ctx.Done()
+		leading.Add(-1)
+		return nil
+	}
+
+	// elect advances the clock an interval at a time until cond holds.
+	elect := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			fc.Advance(scheduler.DefaultElectionInterval)
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	first := scheduler.NewElector(lock.Handle(), scheduler.WithElectorClock(fc))
+	second := scheduler.NewElector(lock.Handle(), scheduler.WithElectorClock(fc))
+	ctx1, stop1 := context.WithCancel(ctx)
+	done1 := make(chan error, 1)
+	go func() { done1 <- first.Run(ctx1, lead) }()
+	elect("the first elector to lead", first.IsLeader)
+
+	ctx2, stop2 := context.WithCancel(ctx)
+	defer stop2()
+	done2 := make(chan error, 1)
+	go func() { done2 <- second.Run(ctx2, lead) }()
+	for range 5 {
+		fc.Advance(scheduler.DefaultElectionInterval)
+		time.Sleep(time.Millisecond)
+	}
+	if !first.IsLeader() || second.IsLeader() {
+		t.Fatalf("leaders: first %v, second %v; want the first only", first.IsLeader(), second.IsLeader())
+	}
+
+	// The first leader's lease runs out, as if it stalled: it steps down
+	// on its next renewal and the second takes over.
+	lock.Expire()
+	elect("the second elector to take over", func() bool { return second.IsLeader() && !first.IsLeader() })
+	if n := leading.Load(); n != 1 {
+		t.Errorf("%d leading after the handover, want 1", n)
+	}
+
+	// The second stops and lets go; the first takes over again.
+	stop2()
+	<-done2
+	elect("the first elector to lead again", first.IsLeader)
+	if got := terms.Load(); got != 3 {
+		t.Errorf("%d terms, want 3", got)
+	}
+
+	stop1()
+	<-done1
+	if first.IsLeader() || leading.Load() != 0 {
+		t.Errorf("after Run returned: IsLeader %v, %d leading", first.IsLeader(), leading.Load())
+	}
+}
+
+func TestElector_StepsDownWhenLeadReturns(t *testing.T) {
+	fc := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	lock := scheduler.NewMemLeaderLock()
+	e := scheduler.NewElector(lock.Handle(), scheduler.WithElectorClock(fc), scheduler.WithElectionInterval(time.Second))
+	var calls atomic.Int32
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go e.Run(ctx, func(context.Context) error {
+		calls.Add(1)
+		return context.DeadlineExceeded // as a lead that failed to start
+	})
+
+	// Each failed term lets go of the lock, and the next waits an interval.
+	deadline := time.Now().Add(3 * time.Second)
+	for calls.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("lead called %d times, want a retry every interval", calls.Load())
+		}
+		fc.Advance(time.Second)
+		time.Sleep(time.Millisecond)
+	}
+	other := lock.Handle()
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	if ok, _ := other.TryLock(context.Background()); !ok {
+		t.Error("lock still held after Run returned")
+	}
+}
@@ -0,0 +1,353 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// MemTaskRepo is a thread-safe in-memory implementation of
+// domain.TaskRepository. State is lost on restart and is not shared between
+// processes; use it for tests and single-process deployments.
+type MemTaskRepo struct {
+	mu    sync.RWMutex
+	store map[string]*domain.Task
+}
+
+// NewMemTaskRepo creates an empty MemTaskRepo.
+func NewMemTaskRepo() *MemTaskRepo {
+	return &MemTaskRepo{store: make(map[string]*domain.Task)}
+}
+
+// Save creates or updates a copy of t. It never writes t's IdempotencyKey
+// or DedupKey, which only ClaimKey does: a created task holds no keys and
+// an updated one keeps the stored keys.
+func (r *MemTaskRepo) Save(_ context.Context, t *domain.Task) error {
+	r.mu.Lock()
+	cp := *t
+	cp.IdempotencyKey, cp.DedupKey = "", ""
+	if old, ok := r.store[t.ID]; ok {
+		cp.IdempotencyKey, cp.DedupKey = old.IdempotencyKey, old.DedupKey
+	}
+	r.store[t.ID] = &cp
+	r.mu.Unlock()
+	return nil
+}
+
+// ClaimKey saves a copy of t as the holder of its IdempotencyKey and
+// DedupKey unless a task created after since holds either; see
+// domain.IdempotencyStore.
+func (r *MemTaskRepo) ClaimKey(_ context.Context, t *domain.Task, since time.Time) (*domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var lapsed []*domain.Task
+	for _, held := range r.store {
+		idem := t.IdempotencyKey != "" && held.IdempotencyKey == t.IdempotencyKey
+		dedup := t.DedupKey != "" && held.DedupKey == t.DedupKey
+		if held.ID == t.ID || !idem && !dedup {
+			continue
+		}
+		if held.CreatedAt.After(since) {
+			cp := *held
+			return &cp, domain.ErrDuplicateTask
+		}
+		lapsed = append(lapsed, held)
+	}
+	for _, held := range lapsed {
+		if t.IdempotencyKey != "" && held.IdempotencyKey == t.IdempotencyKey {
+			held.IdempotencyKey = ""
+		}
+		if t.DedupKey != "" && held.DedupKey == t.DedupKey {
+			held.DedupKey = ""
+		}
+	}
+	cp := *t
+	r.store[t.ID] = &cp
+	return nil, nil
+}
+
+// FindByID returns a copy of the task or domain.ErrTaskNotFound.
+func (r *MemTaskRepo) FindByID(_ context.Context, id string) (*domain.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.store[id]
+	if !ok {
+		return nil, domain.ErrTaskNotFound
+	}
+	cp := *t
+	return &cp, nil
+}
+
+// FindByStatus returns copies of all tasks in status, highest priority first
+// and then earliest ScheduledAt.
+func (r *MemTaskRepo) FindByStatus(_ context.Context, status domain.TaskStatus) ([]*domain.Task, error) {
+	r.mu.RLock()
+	var out []*domain.Task
+	for _, t := range r.store {
+		if t.Status == status {
+			cp := *t
+			out = append(out, &cp)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Priority != out[j].Priority {
+			return out[i].Priority > out[j].Priority
+		}
+		return out[i].ScheduledAt.Before(out[j].ScheduledAt)
+	})
+	return out, nil
+}
+
+// Delete removes the task or returns domain.ErrTaskNotFound.
+func (r *MemTaskRepo) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.store[id]; !ok {
+		return domain.ErrTaskNotFound
+	}
+	delete(r.store, id)
+	return nil
+}
+
+// MemWorkerRepo is a thread-safe in-memory implementation of
+// domain.WorkerRepository.
+type MemWorkerRepo struct {
+	mu    sync.RWMutex
+	store map[string]*domain.Worker
+}
+
+// NewMemWorkerRepo creates an empty MemWorkerRepo.
+func NewMemWorkerRepo() *MemWorkerRepo {
+	return &MemWorkerRepo{store: make(map[string]*domain.Worker)}
+}
+
+// Save creates or updates a copy of w.
+func (r *MemWorkerRepo) Save(_ context.Context, w *domain.Worker) error {
+	r.mu.Lock()
+	cp := *w
+	r.store[w.ID] = &cp
+	r.mu.Unlock()
+	return nil
+}
+
+// FindByID returns a copy of the worker or domain.ErrWorkerNotFound.
+func (r *MemWorkerRepo) FindByID(_ context.Context, id string) (*domain.Worker, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	w, ok := r.store[id]
+	if !ok {
+		return nil, domain.ErrWorkerNotFound
+	}
+	cp := *w
+	return &cp, nil
+}
+
+// FindAvailable returns copies of the workers with spare capacity that
+// match f, least loaded first.
+func (r *MemWorkerRepo) FindAvailable(_ context.Context, f domain.WorkerFilter) ([]*domain.Worker, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*domain.Worker
+	for _, w := range r.store {
+		if w.Matches(f) {
+			cp := *w
+			out = append(out, &cp)
+		}
+	}
+	domain.SortByLoad(out)
+	return out, nil
+}
+
+// Delete removes the worker or returns domain.ErrWorkerNotFound.
+func (r *MemWorkerRepo) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.store[id]; !ok {
+		return domain.ErrWorkerNotFound
+	}
+	delete(r.store, id)
+	return nil
+}
+
+// DefaultHeartbeatRetention is how many heartbeats per worker
+// MemHeartbeatRepo keeps.
+const DefaultHeartbeatRetention = 100
+
+// MemHeartbeatRepo is a thread-safe in-memory implementation of
+// domain.HeartbeatRepository that keeps the latest
+// DefaultHeartbeatRetention heartbeats of each worker.
+type MemHeartbeatRepo struct {
+	mu    sync.RWMutex
+	store map[string][]domain.Heartbeat
+}
+
+// NewMemHeartbeatRepo creates an empty MemHeartbeatRepo.
+func NewMemHeartbeatRepo() *MemHeartbeatRepo {
+	return &MemHeartbeatRepo{store: make(map[string][]domain.Heartbeat)}
+}
+
+// Record appends hb, dropping the worker's oldest entry beyond the retention.
+func (r *MemHeartbeatRepo) Record(_ context.Context, hb domain.Heartbeat) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := append(r.store[hb.WorkerID], hb)
+	if len(h) > DefaultHeartbeatRetention {
+		h = h[len(h)-DefaultHeartbeatRetention:]
+	}
+	r.store[hb.WorkerID] = h
+	return nil
+}
+
+// ListRecent returns up to limit of the worker's heartbeats, newest first.
+func (r *MemHeartbeatRepo) ListRecent(_ context.Context, workerID string, limit int) ([]domain.Heartbeat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h := r.store[workerID]
+	out := make([]domain.Heartbeat, 0, min(len(h), max(limit, 0)))
+	for i := len(h) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, h[i])
+	}
+	return out, nil
+}
+
+// MemAttemptRepo is a thread-safe in-memory implementation of
+// domain.AttemptRepository.
+type MemAttemptRepo struct {
+	mu    sync.RWMutex
+	store map[string][]domain.Attempt
+}
+
+// NewMemAttemptRepo creates an empty MemAttemptRepo.
+func NewMemAttemptRepo() *MemAttemptRepo {
+	return &MemAttemptRepo{store: make(map[string][]domain.Attempt)}
+}
+
+// Record stores a copy of a, replacing an earlier attempt with its Number.
+func (r *MemAttemptRepo) Record(_ context.Context, a *domain.Attempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := r.store[a.TaskID]
+	i := sort.Search(len(list), func(i int) bool { return list[i].Number >= a.Number })
+	if i < len(list) && list[i].Number == a.Number {
+		list[i] = *a
+		return nil
+	}
+	r.store[a.TaskID] = slices.Insert(list, i, *a)
+	return nil
+}
+
+// ListByTaskID returns copies of the task's attempts ordered by Number.
+func (r *MemAttemptRepo) ListByTaskID(_ context.Context, taskID string) ([]*domain.Attempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := r.store[taskID]
+	out := make([]*domain.Attempt, len(list))
+	for i := range list {
+		cp := list[i]
+		out[i] = &cp
+	}
+	return out, nil
+}
+
+// MemFreezeRepo is a thread-safe in-memory implementation of
+// domain.FreezeRepository. The switch only affects workers in the same
+// process.
+type MemFreezeRepo struct {
+	mu     sync.RWMutex
+	freeze domain.DispatchFreeze
+}
+
+// NewMemFreezeRepo creates a MemFreezeRepo that is not frozen.
+func NewMemFreezeRepo() *MemFreezeRepo {
+	return &MemFreezeRepo{}
+}
+
+// Get returns a copy of the switch.
+func (r *MemFreezeRepo) Get(_ context.Context) (*domain.DispatchFreeze, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cp := r.freeze
+	return &cp, nil
+}
+
+// Save stores a copy of f.
+func (r *MemFreezeRepo) Save(_ context.Context, f *domain.DispatchFreeze) error {
+	r.mu.Lock()
+	r.freeze = *f
+	r.mu.Unlock()
+	return nil
+}
+
+// MemSecretStore is a thread-safe in-memory implementation of
+// domain.SecretStore.
+type MemSecretStore struct {
+	mu      sync.RWMutex
+	secrets map[string]string
+}
+
+// NewMemSecretStore creates an empty MemSecretStore.
+func NewMemSecretStore() *MemSecretStore {
+	return &MemSecretStore{secrets: make(map[string]string)}
+}
+
+// Get returns the value of the named secret.
+func (s *MemSecretStore) Get(_ context.Context, name string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.secrets[name]
+	if !ok {
+		return "", domain.ErrSecretNotFound
+	}
+	return v, nil
+}
+
+// Put stores value under name.
+func (s *MemSecretStore) Put(_ context.Context, name, value string) error {
+	s.mu.Lock()
+	s.secrets[name] = value
+	s.mu.Unlock()
+	return nil
+}
+
+// MemPayloadStore is a thread-safe in-memory implementation of
+// domain.PayloadStore.
+type MemPayloadStore struct {
+	mu       sync.RWMutex
+	payloads map[string][]byte
+}
+
+// NewMemPayloadStore creates an empty MemPayloadStore.
+func NewMemPayloadStore() *MemPayloadStore {
+	return &MemPayloadStore{payloads: make(map[string][]byte)}
+}
+
+// Put stores a copy of data under key.
+func (s *MemPayloadStore) Put(_ context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	s.payloads[key] = append([]byte(nil), data...)
+	s.mu.Unlock()
+	return nil
+}
+
+// Get returns the data stored under key.
+func (s *MemPayloadStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.payloads[key]
+	if !ok {
+		return nil, domain.ErrPayloadNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Len returns the number of payloads stored.
+func (s *MemPayloadStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.payloads)
+}
@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// MisfirePolicy decides what CronTrigger does with a fire it wakes up for
+// later than its misfire grace, because the process stalled or the clock
+// jumped.
+type MisfirePolicy string
+
+const (
+	// MisfireFireNow creates the run late, with the scheduled logical date.
+	// It is the default.
+	MisfireFireNow MisfirePolicy = "fire_now"
+	// MisfireSkip records a skipped run labelled misfire=true instead.
+	MisfireSkip MisfirePolicy = "skip"
+)
+
+// DefaultMisfireGrace is how late a fire may be before MisfireSkip skips
+// it, unless WithMisfirePolicy sets another grace.
+const DefaultMisfireGrace = time.Minute
+
+// ParseMisfirePolicy parses a MisfirePolicy; empty is MisfireFireNow.
+func ParseMisfirePolicy(s string) (MisfirePolicy, error) {
+	switch p := MisfirePolicy(s); p {
+	case "":
+		return MisfireFireNow, nil
+	case MisfireFireNow, MisfireSkip:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown misfire policy %q: want %s or %s", s, MisfireFireNow, MisfireSkip)
+}
+
+// WithJitter spreads fires that share a schedule, such as every workflow
+// on "0 * * * *", over max: each workflow fires a fixed offset below max,
+// derived from its ID, after its scheduled time. The run's LogicalDate is
+// still the scheduled time.
+func WithJitter(max time.Duration) CronTriggerOption {
+	return func(ct *CronTrigger) { ct.jitter = max }
+}
+
+// WithMisfirePolicy sets what the trigger does with a fire it wakes up for
+// more than grace after it was due, DefaultMisfireGrace if grace is not
+// positive. A fire's jitter counts towards when it was due.
+func WithMisfirePolicy(p MisfirePolicy, grace time.Duration) CronTriggerOption {
+	return func(ct *CronTrigger) {
+		ct.misfire, ct.misfireGrace = p, grace
+		if grace <= 0 {
+			ct.misfireGrace = DefaultMisfireGrace
+		}
+	}
+}
+
+// jitterFor returns the offset the workflow with the given ID fires at
+// after its scheduled times.
+func (ct *CronTrigger) jitterFor(workflowID uuid.UUID) time.Duration {
+	if ct.jitter <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(workflowID[:])
+	return time.Duration(h.Sum64() % uint64(ct.jitter))
+}
+
+// misfired reports whether a fire due at wake is too late at now to run
+// under the misfire policy.
+func (ct *CronTrigger) misfired(wake, now time.Time) bool {
+	return ct.misfire == MisfireSkip && now.Sub(wake) > ct.misfireGrace
+}
+
+// skipMisfire records the fire of logical, due at wake, as a skipped run.
+func (ct *CronTrigger) skipMisfire(ctx context.Context, workflowID uuid.UUID, logical, wake, at time.Time) {
+	logical, at = logical.UTC(), at.UTC()
+	run := &domain.WorkflowRun{
+		ID:          uuid.New(),
+		WorkflowID:  workflowID,
+		Status:      domain.StatusSkipped,
+		StartedAt:   at,
+		FinishedAt:  &at,
+		LogicalDate: &logical,
+		Labels:      map[string]string{domain.LabelMisfire: "true"},
+	}
+	log.Printf("CronTrigger: workflow %s: woke %s late for %s; run skipped",
+		workflowID, at.Sub(wake).Round(time.Millisecond), logical.Format(time.RFC3339))
+	ct.createRun(ctx, run)
+}
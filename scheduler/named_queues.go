@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// DefaultNamedQueuePoll is how long a NamedQueues Dequeue waits on one of
+// its queues before checking the others again.
+const DefaultNamedQueuePoll = time.Second
+
+// NamedQueues is the queue of a worker dedicated to a list of named queues,
+// e.g. "gpu" and "etl-heavy": Dequeue takes tasks from those queues only,
+// in turn, so a backlog on one does not starve the others. Enqueue routes
+// through the GroupQueues it came from, so a retried task goes back to the
+// queue it was taken from.
+type NamedQueues struct {
+	groups *GroupQueues
+	names  []string
+	queues []domain.Queue
+	poll   time.Duration
+
+	mu   sync.Mutex
+	next int
+}
+
+// Named returns the queue serving the domain.NamedQueue of each of names,
+// opening them if needed. Dequeue waits on each queue for at most poll at
+// a time; zero means DefaultNamedQueuePoll.
+func (g *GroupQueues) Named(poll time.Duration, names ...string) (*NamedQueues, error) {
+	if len(names) == 0 {
+		return nil, errors.New("no queue names")
+	}
+	if poll <= 0 {
+		poll = DefaultNamedQueuePoll
+	}
+	n := &NamedQueues{groups: g, names: names, poll: poll}
+	for _, name := range names {
+		if !domain.ValidGroupName(name) {
+			return nil, fmt.Errorf("invalid queue name %q", name)
+		}
+		q, err := g.Queue(domain.NamedQueue(name))
+		if err != nil {
+			return nil, err
+		}
+		n.queues = append(n.queues, q)
+	}
+	return n, nil
+}
+
+// Names returns the names of the queues n serves.
+func (n *NamedQueues) Names() []string {
+	return n.names
+}
+
+// Enqueue routes task like GroupQueues.Enqueue: to the named queue of its
+// Queue, or else to the queue of its Group.
+func (n *NamedQueues) Enqueue(ctx context.Context, task *domain.Task) error {
+	return n.groups.Enqueue(ctx, task)
+}
+
+// Dequeue takes a task waiting on any of the queues, starting after the
+// queue the last one came from, and otherwise waits on each in turn for up
+// to the poll interval until a task arrives or ctx is cancelled.
+func (n *NamedQueues) Dequeue(ctx context.Context) (*domain.Task, error) {
+	for {
+		n.mu.Lock()
+		start := n.next
+		n.mu.Unlock()
+		for i := range n.queues {
+			k := (start + i) % len(n.queues)
+			if l, err := n.queues[k].Len(ctx); err != nil || l == 0 {
+				continue
+			}
+			if task, err := n.take(ctx, k, n.poll); task != nil || !errors.Is(err, domain.ErrQueueEmpty) {
+				return task, err
+			}
+		}
+		// Nothing is waiting: block on the next queue in turn for a while.
+		task, err := n.take(ctx, start, n.poll)
+		if task != nil || !errors.Is(err, domain.ErrQueueEmpty) {
+			return task, err
+		}
+		if ctx.Err() != nil {
+			return nil, domain.ErrQueueEmpty
+		}
+		n.mu.Lock()
+		n.next = (start + 1) % len(n.queues)
+		n.mu.Unlock()
+	}
+}
+
+// take dequeues from the k-th queue, waiting at most wait, and moves the
+// turn past it if a task came.
+func (n *NamedQueues) take(ctx context.Context, k int, wait time.Duration) (*domain.Task, error) {
+	wctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	task, err := n.queues[k].Dequeue(wctx)
+	if err != nil {
+		if wctx.Err() != nil {
+			return nil, domain.ErrQueueEmpty
+		}
+		return nil, err
+	}
+	n.mu.Lock()
+	n.next = (k + 1) % len(n.queues)
+	n.mu.Unlock()
+	return task, nil
+}
+
+// Len returns the number of tasks waiting on the queues.
+func (n *NamedQueues) Len(ctx context.Context) (int, error) {
+	total := 0
+	for i, q := range n.queues {
+		l, err := q.Len(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("queue %s: %w", n.names[i], err)
+		}
+		total += l
+	}
+	return total, nil
+}
+
+// Ack passes the acknowledgement to every queue that takes them; those
+// task did not come from ignore it.
+func (n *NamedQueues) Ack(ctx context.Context, task *domain.Task) error {
+	for _, q := range n.queues {
+		if aq, ok := q.(domain.AckQueue); ok {
+			if err := aq.Ack(ctx, task); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
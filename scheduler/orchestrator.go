@@ -0,0 +1,646 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// DefaultOrchestrateInterval is how often Orchestrator.Run advances workflow
+// runs.
+const DefaultOrchestrateInterval = 2 * time.Second
+
+// Orchestrator turns WorkflowRuns into executed tasks. Each pass starts
+// pending runs, records the outcome of finished queue tasks on their task
+// runs, submits the tasks whose trigger rules are met to the Scheduler, and
+// completes runs once every task has settled.
+//
+// A task run and the queue task executing it share an ID, so task state
+// written by workers is found again with no extra bookkeeping.
+type Orchestrator struct {
+	tasks        repository.TaskRepository
+	deps         repository.TaskDependencyRepository
+	workflowRuns repository.WorkflowRunRepository
+	taskRuns     repository.TaskRunRepository
+
+	sched      *Scheduler
+	queueTasks qdomain.TaskRepository
+	events     events.Publisher
+	lineage    repository.LineageRepository
+	workflows  repository.WorkflowRepository
+	interval   time.Duration
+	clock      clock.Clock
+
+	mu      sync.Mutex
+	overdue map[uuid.UUID]bool // runs whose timeout alert is firing
+}
+
+// OrchestratorOption is a functional option for configuring an Orchestrator.
+type OrchestratorOption func(*Orchestrator)
+
+// WithRunEvents publishes every workflow run and task run state change the
+// Orchestrator makes to p.
+func WithRunEvents(p events.Publisher) OrchestratorOption {
+	return func(o *Orchestrator) { o.events = p }
+}
+
+// WithLineage records the datasets declared by each task as lineage edges of
+// its task runs: inputs when the run starts, outputs when it succeeds.
+func WithLineage(r repository.LineageRepository) OrchestratorOption {
+	return func(o *Orchestrator) { o.lineage = r }
+}
+
+// WithWorkflows lets the Orchestrator read workflow definitions. Without it
+// TriggerOnSuccess, MaxParallelTasks, MaxActiveRuns and run timeouts are
+// ignored and the workflow name of each task's ExecutionContext is empty.
+func WithWorkflows(r repository.WorkflowRepository) OrchestratorOption {
+	return func(o *Orchestrator) { o.workflows = r }
+}
+
+// WithOrchestrateInterval sets how often Run advances workflow runs. The
+// default is DefaultOrchestrateInterval.
+func WithOrchestrateInterval(d time.Duration) OrchestratorOption {
+	return func(o *Orchestrator) { o.interval = d }
+}
+
+// WithOrchestratorClock sets the clock Run ticks on and task runs and queue
+// tasks are stamped with. The default is clock.Real.
+func WithOrchestratorClock(c clock.Clock) OrchestratorOption {
+	return func(o *Orchestrator) { o.clock = c }
+}
+
+// NewOrchestrator creates an Orchestrator that dispatches through sched and
+// reads task outcomes from queueTasks, the repository sched writes to.
+func NewOrchestrator(
+	tasks repository.TaskRepository,
+	deps repository.TaskDependencyRepository,
+	workflowRuns repository.WorkflowRunRepository,
+	taskRuns repository.TaskRunRepository,
+	sched *Scheduler,
+	queueTasks qdomain.TaskRepository,
+	opts ...OrchestratorOption,
+) *Orchestrator {
+	o := &Orchestrator{
+		tasks:        tasks,
+		deps:         deps,
+		workflowRuns: workflowRuns,
+		taskRuns:     taskRuns,
+		sched:        sched,
+		queueTasks:   queueTasks,
+		events:       events.Discard,
+		interval:     DefaultOrchestrateInterval,
+		clock:        clock.Real,
+		overdue:      make(map[uuid.UUID]bool),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Run calls Reconcile at the configured interval until ctx is cancelled.
+func (o *Orchestrator) Run(ctx context.Context) error {
+	ticker := o.clock.NewTicker(o.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+			if err := o.Reconcile(ctx); err != nil {
+				log.Printf("Orchestrator: reconcile: %v", err)
+			}
+		}
+	}
+}
+
+// Reconcile starts the pending workflow runs their workflow's MaxActiveRuns
+// allows and advances every running one. A run that cannot be advanced is
+// logged and retried on the next pass.
+func (o *Orchestrator) Reconcile(ctx context.Context) error {
+	pending, err := o.workflowRuns.ListByStatus(ctx, domain.StatusPending)
+	if err != nil {
+		return err
+	}
+	for _, run := range o.startable(ctx, pending) {
+		if err := o.workflowRuns.UpdateStatus(ctx, run.ID, domain.StatusRunning, nil); err != nil {
+			return err
+		}
+		run.Status = domain.StatusRunning
+		o.publish(ctx, events.WorkflowStatus, *run)
+	}
+
+	running, err := o.workflowRuns.ListByStatus(ctx, domain.StatusRunning)
+	if err != nil {
+		return err
+	}
+	for _, run := range running {
+		if err := o.Advance(ctx, run); err != nil {
+			log.Printf("Orchestrator: workflow run %s: %v", run.ID, err)
+		}
+	}
+	return nil
+}
+
+// Advance moves a single running workflow run forward as far as it can go.
+func (o *Orchestrator) Advance(ctx context.Context, run *domain.WorkflowRun) error {
+	tasks, err := o.tasks.ListByWorkflowID(ctx, run.WorkflowID)
+	if err != nil {
+		return fmt.Errorf("list tasks: %w", err)
+	}
+	deps, err := o.deps.ListByWorkflowID(ctx, run.WorkflowID)
+	if err != nil {
+		return fmt.Errorf("list dependencies: %w", err)
+	}
+	dag, err := domain.NewDAG(tasks, deps)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uuid.UUID]*domain.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+
+	states, cleared, err := o.syncTaskRuns(ctx, run.ID, byID)
+	if err != nil {
+		return err
+	}
+
+	now := o.clock.Now().UTC()
+	wf := o.workflow(ctx, run)
+	deadline, timed := wf.RunDeadline(run.StartedAt)
+	overdue := timed && !now.Before(deadline)
+	policy := domain.RunTimeoutFail
+	if wf != nil && wf.RunTimeoutPolicy != "" {
+		policy = wf.RunTimeoutPolicy
+	}
+	ready, skipped := dag.Evaluate(states)
+	switch {
+	case overdue && policy == domain.RunTimeoutAlert:
+		o.alertTimeout(ctx, run, wf, now)
+	case overdue:
+		if err := o.timeOut(ctx, run, tasks, states, policy, now); err != nil {
+			return err
+		}
+		ready, skipped = nil, nil
+	}
+	for _, id := range skipped {
+		if err := o.skip(ctx, run.ID, id, cleared[id], now); err != nil {
+			return err
+		}
+		states[id] = domain.StatusSkipped
+	}
+	var ec domain.ExecutionContext
+	if len(ready) > 0 {
+		ready = limitParallel(ready, states, wf)
+		ec = o.executionContext(ctx, run, wf)
+	}
+	for _, id := range ready {
+		status, err := o.start(ctx, ec, wf, byID[id], cleared[id], now)
+		if err != nil {
+			return err
+		}
+		states[id] = status
+	}
+
+	status := dag.RunStatus(states)
+	if overdue && policy != domain.RunTimeoutAlert && status.IsTerminal() {
+		status = domain.StatusFailed
+	}
+	if p := runProgress(states, len(tasks)); p != run.Progress {
+		if err := o.workflowRuns.UpdateProgress(ctx, run.ID, p); err != nil {
+			return fmt.Errorf("update progress: %w", err)
+		}
+		// Announce each newly settled task; a finishing run announces its
+		// progress with its final status instead.
+		settled := p.Completed != run.Progress.Completed
+		run.Progress = p
+		if settled && !status.IsTerminal() {
+			o.publish(ctx, events.WorkflowStatus, *run)
+		}
+	}
+	if status.IsTerminal() {
+		if err := o.workflowRuns.UpdateStatus(ctx, run.ID, status, &now); err != nil {
+			return err
+		}
+		run.Status, run.FinishedAt = status, &now
+		o.publish(ctx, events.WorkflowStatus, *run)
+		if overdue && policy == domain.RunTimeoutAlert {
+			o.resolveTimeout(ctx, run, wf, now)
+		}
+		if status == domain.StatusSuccess {
+			o.triggerOnSuccess(ctx, run, wf)
+		}
+	}
+	return nil
+}
+
+// skip settles task id of the run runID as skipped: tr, its pending task
+// run, if it has one, or else a new task run.
+func (o *Orchestrator) skip(ctx context.Context, runID, id uuid.UUID, tr *domain.TaskRun, now time.Time) error {
+	if tr != nil {
+		if err := o.taskRuns.UpdateStatus(ctx, tr.ID, domain.StatusSkipped, &now); err != nil {
+			return fmt.Errorf("skip task run %s: %w", tr.ID, err)
+		}
+		tr.Status, tr.FinishedAt = domain.StatusSkipped, &now
+		o.publish(ctx, events.TaskStatus, *tr)
+		return nil
+	}
+	tr = &domain.TaskRun{
+		ID:            uuid.New(),
+		WorkflowRunID: runID,
+		TaskID:        id,
+		Status:        domain.StatusSkipped,
+		Attempt:       1,
+		StartedAt:     now,
+		FinishedAt:    &now,
+	}
+	if err := o.taskRuns.Create(ctx, tr); err != nil {
+		return fmt.Errorf("create skipped task run: %w", err)
+	}
+	o.publish(ctx, events.TaskStatus, *tr)
+	return nil
+}
+
+// runProgress counts the settled tasks among states, the latest status of
+// each task of a run with total tasks.
+func runProgress(states map[uuid.UUID]domain.Status, total int) domain.RunProgress {
+	completed := 0
+	for _, s := range states {
+		if s.IsTerminal() {
+			completed++
+		}
+	}
+	return domain.NewRunProgress(completed, total)
+}
+
+// workflow loads run's workflow, or returns nil when no WorkflowRepository
+// is configured or the lookup fails; callers treat nil as "no settings".
+func (o *Orchestrator) workflow(ctx context.Context, run *domain.WorkflowRun) *domain.Workflow {
+	if o.workflows == nil {
+		return nil
+	}
+	wf, err := o.workflows.GetByID(ctx, run.WorkflowID)
+	if err != nil {
+		log.Printf("Orchestrator: workflow run %s: load workflow: %v", run.ID, err)
+		return nil
+	}
+	return wf
+}
+
+// limitParallel trims ready so that, together with the run's running tasks,
+// no more than wf.MaxParallelTasks are dispatched.
+func limitParallel(ready []uuid.UUID, states map[uuid.UUID]domain.Status, wf *domain.Workflow) []uuid.UUID {
+	if wf == nil || wf.MaxParallelTasks <= 0 {
+		return ready
+	}
+	free := wf.MaxParallelTasks
+	for _, s := range states {
+		if s == domain.StatusRunning {
+			free--
+		}
+	}
+	if free <= 0 {
+		return nil
+	}
+	if free < len(ready) {
+		return ready[:free]
+	}
+	return ready
+}
+
+// triggerOnSuccess starts the workflows listed in the TriggerOnSuccess of
+// wf, run's workflow. The run is already final, so failures are logged
+// rather than retried.
+func (o *Orchestrator) triggerOnSuccess(ctx context.Context, run *domain.WorkflowRun, wf *domain.Workflow) {
+	if wf == nil {
+		return
+	}
+	for _, target := range wf.TriggerOnSuccess {
+		if _, err := o.trigger(ctx, target, run.ID); err != nil {
+			log.Printf("Orchestrator: workflow run %s: trigger workflow %s: %v", run.ID, target, err)
+		}
+	}
+}
+
+// trigger creates a pending run of workflowID started by the run byRunID.
+func (o *Orchestrator) trigger(ctx context.Context, workflowID, byRunID uuid.UUID) (*domain.WorkflowRun, error) {
+	triggered := &domain.WorkflowRun{
+		ID:               uuid.New(),
+		WorkflowID:       workflowID,
+		Status:           domain.StatusPending,
+		StartedAt:        o.clock.Now().UTC(),
+		TriggeredByRunID: &byRunID,
+	}
+	if err := o.workflowRuns.Create(ctx, triggered); err != nil {
+		return nil, err
+	}
+	o.publish(ctx, events.WorkflowStatus, *triggered)
+	return triggered, nil
+}
+
+// syncTaskRuns copies the worker assignment of dispatched queue tasks and
+// the outcome of finished ones onto the run's running task runs, each of
+// which follows the queue task with its ID or its QueueTaskID, and returns
+// the latest status of each task in the run, along with the latest attempts
+// still pending because the task was cleared or rate limited.
+func (o *Orchestrator) syncTaskRuns(ctx context.Context, runID uuid.UUID, tasks map[uuid.UUID]*domain.Task) (map[uuid.UUID]domain.Status, map[uuid.UUID]*domain.TaskRun, error) {
+	trs, err := o.taskRuns.ListByWorkflowRunID(ctx, runID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list task runs: %w", err)
+	}
+	states := make(map[uuid.UUID]domain.Status, len(trs))
+	latest := make(map[uuid.UUID]*domain.TaskRun, len(trs))
+	for _, tr := range trs {
+		if tr.Status == domain.StatusRunning {
+			qid := tr.ID.String()
+			if tr.QueueTaskID != "" {
+				qid = tr.QueueTaskID
+			}
+			qt, err := o.queueTasks.FindByID(ctx, qid)
+			if err != nil && !errors.Is(err, qdomain.ErrTaskNotFound) {
+				return nil, nil, fmt.Errorf("task run %s: %w", tr.ID, err)
+			}
+			if qt != nil && qt.DispatchedAt != nil &&
+				(tr.AssignedWorkerID != qt.WorkerID || tr.DispatchedAt == nil || !tr.DispatchedAt.Equal(*qt.DispatchedAt)) {
+				at := qt.DispatchedAt.UTC()
+				if err := o.taskRuns.UpdateAssignment(ctx, tr.ID, qt.WorkerID, at); err != nil {
+					return nil, nil, fmt.Errorf("task run %s: record assignment: %w", tr.ID, err)
+				}
+				tr.AssignedWorkerID, tr.DispatchedAt = qt.WorkerID, &at
+			}
+			if qt != nil && qt.IsTerminal() {
+				tr.Status = domain.StatusSuccess
+				if qt.Status != qdomain.TaskStatusSucceeded {
+					tr.Status = domain.StatusFailed
+				}
+				finished := o.clock.Now().UTC()
+				if qt.FinishedAt != nil {
+					finished = qt.FinishedAt.UTC()
+				}
+				// Outputs are recorded before the status so that a failure
+				// is retried on the next pass instead of losing the edges.
+				// They are stamped with the time they become visible, not
+				// the finish time, so DatasetTrigger never sees an update
+				// appear behind its cursor.
+				if t := tasks[tr.TaskID]; t != nil && tr.Status == domain.StatusSuccess {
+					if err := o.recordLineage(ctx, tr, t, domain.LineageOutput, t.Outputs, o.clock.Now().UTC()); err != nil {
+						return nil, nil, fmt.Errorf("task run %s: record outputs: %w", tr.ID, err)
+					}
+				}
+				if err := o.taskRuns.UpdateStatus(ctx, tr.ID, tr.Status, &finished); err != nil {
+					return nil, nil, fmt.Errorf("task run %s: %w", tr.ID, err)
+				}
+				tr.FinishedAt = &finished
+				o.publish(ctx, events.TaskStatus, *tr)
+			}
+		}
+		if prev := latest[tr.TaskID]; prev == nil || tr.Attempt >= prev.Attempt {
+			latest[tr.TaskID] = tr
+			states[tr.TaskID] = tr.Status
+		}
+	}
+	cleared := make(map[uuid.UUID]*domain.TaskRun)
+	for id, tr := range latest {
+		if tr.Status == domain.StatusPending {
+			cleared[id] = tr
+		}
+	}
+	return states, cleared, nil
+}
+
+// start creates the task run of t and hands it to whoever executes it:
+// approval tasks wait for a decision, trigger_workflow tasks are completed
+// here, and every other task is submitted to the Scheduler with its Command
+// and Env rendered for ec, to run on the workers of the worker group of wf,
+// if known, with the priority it inherits from wf. A cleared task
+// passes its pending attempt as cleared, which is started in place of a new
+// task run. An attempt whose submission is refused as a duplicate of
+// another run's follows that run's queue task instead of failing. It
+// returns the status the task run was left in.
+func (o *Orchestrator) start(ctx context.Context, ec domain.ExecutionContext, wf *domain.Workflow, t *domain.Task, cleared *domain.TaskRun, now time.Time) (domain.Status, error) {
+	tr := &domain.TaskRun{
+		ID:            uuid.New(),
+		WorkflowRunID: ec.Run.ID,
+		TaskID:        t.ID,
+		Status:        domain.StatusRunning,
+		Attempt:       1,
+		StartedAt:     now,
+	}
+	if cleared != nil {
+		tr = cleared
+		tr.Status = domain.StatusRunning
+	}
+	if t.RequiresApproval() {
+		tr.Status = domain.StatusAwaitingApproval
+	}
+	if cleared != nil {
+		if err := o.taskRuns.UpdateStatus(ctx, tr.ID, tr.Status, nil); err != nil {
+			return "", fmt.Errorf("start cleared task run: %w", err)
+		}
+	} else if err := o.taskRuns.Create(ctx, tr); err != nil {
+		return "", fmt.Errorf("create task run: %w", err)
+	}
+	o.publish(ctx, events.TaskStatus, *tr)
+	if err := o.recordLineage(ctx, tr, t, domain.LineageInput, t.Inputs, now); err != nil {
+		// The task run already exists, so returning would leave it
+		// unsubmitted; lineage is best effort at this point.
+		log.Printf("Orchestrator: task run %s: record inputs: %v", tr.ID, err)
+	}
+	if t.RequiresApproval() {
+		return tr.Status, nil
+	}
+	if t.Type == domain.TaskTypeTriggerWorkflow {
+		return o.runTrigger(ctx, tr, t, now)
+	}
+	ec.TaskName, ec.Attempt = t.Name, tr.Attempt
+	rendered, err := t.Render(ec)
+	if err == nil {
+		rendered.Priority = t.EffectivePriority(wf)
+		qt := QueueTask(tr.ID.String(), rendered, now)
+		if wf != nil {
+			qt.Group = wf.WorkerGroup
+		}
+		qt.IdempotencyKey = ec.Run.TaskIdempotencyKey(t.Name, tr.Attempt)
+		qt.RunID = ec.Run.ID.String()
+		err = o.sched.Submit(ctx, qt)
+	}
+	var dup *DuplicateTaskError
+	if errors.As(err, &dup) && dup.TaskID != "" {
+		// The attempt was already submitted, by another run with the same
+		// key: follow that queue task rather than fail this one.
+		if uerr := o.taskRuns.UpdateQueueTask(ctx, tr.ID, dup.TaskID); uerr != nil {
+			return "", fmt.Errorf("submit task %s: %v (and follow task %s: %w)", t.Name, err, dup.TaskID, uerr)
+		}
+		tr.QueueTaskID = dup.TaskID
+		return tr.Status, nil
+	}
+	if errors.Is(err, qdomain.ErrRateLimited) || errors.Is(err, qdomain.ErrDraining) {
+		// Park the attempt as pending, like a cleared one, so the next
+		// pass, or the next scheduler after a drain, submits it again.
+		if uerr := o.taskRuns.UpdateStatus(ctx, tr.ID, domain.StatusPending, nil); uerr != nil {
+			return "", fmt.Errorf("submit task %s: %v (and park it: %w)", t.Name, err, uerr)
+		}
+		tr.Status = domain.StatusPending
+		o.publish(ctx, events.TaskStatus, *tr)
+		return domain.StatusPending, nil
+	}
+	if err != nil {
+		// The task can never be dispatched, so fail it rather than retry
+		// the submission on every pass.
+		if uerr := o.taskRuns.UpdateStatus(ctx, tr.ID, domain.StatusFailed, &now); uerr != nil {
+			return "", fmt.Errorf("submit task %s: %v (and mark failed: %w)", t.Name, err, uerr)
+		}
+		log.Printf("Orchestrator: submit task %s: %v", t.Name, err)
+		tr.Status, tr.FinishedAt = domain.StatusFailed, &now
+		o.publish(ctx, events.TaskStatus, *tr)
+		return domain.StatusFailed, nil
+	}
+	return tr.Status, nil
+}
+
+// executionContext gathers the run-level part of the ExecutionContext of
+// run's tasks; wf may be nil. Lookups that fail are logged and leave their
+// field empty, so tasks still start.
+func (o *Orchestrator) executionContext(ctx context.Context, run *domain.WorkflowRun, wf *domain.Workflow) domain.ExecutionContext {
+	ec := domain.ExecutionContext{Run: run}
+	if wf != nil {
+		ec.WorkflowName = wf.Name
+	}
+	runs, err := o.workflowRuns.ListByWorkflowID(ctx, run.WorkflowID)
+	if err != nil {
+		log.Printf("Orchestrator: workflow run %s: list previous runs: %v", run.ID, err)
+		return ec
+	}
+	date := ec.LogicalDate()
+	for _, r := range runs {
+		if r.ID == run.ID || r.Status != domain.StatusSuccess {
+			continue
+		}
+		d := domain.ExecutionContext{Run: r}.LogicalDate()
+		if d.Before(date) && (ec.PrevSuccessDate == nil || d.After(*ec.PrevSuccessDate)) {
+			ec.PrevSuccessDate = &d
+		}
+	}
+	return ec
+}
+
+// runTrigger executes a trigger_workflow task: it starts a run of the
+// workflow named by t.Command and settles tr with the outcome.
+func (o *Orchestrator) runTrigger(ctx context.Context, tr *domain.TaskRun, t *domain.Task, now time.Time) (domain.Status, error) {
+	status := domain.StatusSuccess
+	target, err := uuid.Parse(t.Command)
+	if err == nil {
+		_, err = o.trigger(ctx, target, tr.WorkflowRunID)
+	}
+	if err != nil {
+		log.Printf("Orchestrator: task %s: trigger workflow %q: %v", t.Name, t.Command, err)
+		status = domain.StatusFailed
+	}
+	if err := o.taskRuns.UpdateStatus(ctx, tr.ID, status, &now); err != nil {
+		return "", fmt.Errorf("task %s: record trigger outcome: %w", t.Name, err)
+	}
+	tr.Status, tr.FinishedAt = status, &now
+	o.publish(ctx, events.TaskStatus, *tr)
+	return status, nil
+}
+
+// recordLineage stores one edge of the given direction per dataset. It is a
+// no-op when no LineageRepository is configured.
+func (o *Orchestrator) recordLineage(ctx context.Context, tr *domain.TaskRun, t *domain.Task, dir domain.LineageDirection, datasets []string, at time.Time) error {
+	if o.lineage == nil {
+		return nil
+	}
+	for _, ds := range datasets {
+		e := &domain.LineageEdge{
+			ID:            uuid.New(),
+			Dataset:       ds,
+			Direction:     dir,
+			WorkflowID:    t.WorkflowID,
+			WorkflowRunID: tr.WorkflowRunID,
+			TaskID:        t.ID,
+			TaskRunID:     tr.ID,
+			CreatedAt:     at,
+		}
+		if err := o.lineage.Create(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publish sends a state change to the configured event publisher. Payloads
+// are passed by value because subscribers may encode them after the
+// Orchestrator has moved on. Delivery failures are logged; they never hold
+// up orchestration.
+func (o *Orchestrator) publish(ctx context.Context, typ events.Type, payload any) {
+	if err := o.events.Publish(ctx, events.Event{Type: typ, Payload: payload}); err != nil {
+		log.Printf("Orchestrator: publish %s: %v", typ, err)
+	}
+}
+
+// QueueTask builds the queue task with the given ID that executes t,
+// scheduled at now, at t's Priority or, if it has none, normal priority,
+// and expiring TTLSeconds after now if t has a TTL.
+func QueueTask(id string, t *domain.Task, now time.Time) *qdomain.Task {
+	qt := &qdomain.Task{
+		ID:             id,
+		Name:           t.Name,
+		Payload:        []byte(t.Command),
+		Priority:       qdomain.PriorityNormal,
+		MaxRetries:     t.RetryCount,
+		ScheduledAt:    now,
+		Pool:           t.Pool,
+		ConcurrencyKey: t.ConcurrencyKey,
+		WorkflowID:     t.WorkflowID.String(),
+		Env:            t.Env,
+		Timeout:        time.Duration(t.TimeoutSeconds) * time.Second,
+		Profile:        t.Profile,
+		Queue:          t.Queue,
+	}
+	if t.Priority != 0 {
+		qt.Priority = qdomain.Priority(t.Priority)
+	}
+	if t.TTLSeconds > 0 {
+		expires := qt.ScheduledAt.Add(time.Duration(t.TTLSeconds) * time.Second)
+		qt.ExpiresAt = &expires
+	}
+	if t.Type != domain.TaskTypeCommand {
+		qt.Type = string(t.Type)
+	}
+	for _, h := range t.Hooks {
+		qt.Hooks = append(qt.Hooks, qdomain.Hook{
+			On:       string(h.On),
+			Kind:     string(h.Kind),
+			URL:      h.URL,
+			Command:  h.Command,
+			Notifier: h.Notifier,
+			Message:  h.Message,
+		})
+	}
+	// A retried task without retry settings waits out the worker's backoff.
+	if t.RetryCount > 0 && (t.RetryDelaySeconds != 0 || t.RetryMultiplier != 0 || t.RetryMaxDelaySeconds != 0 || t.RetryJitter) {
+		qt.Retry = TaskRetryPolicy(t)
+	}
+	return qt
+}
+
+// TaskRetryPolicy returns the retry policy t's retry settings describe.
+func TaskRetryPolicy(t *domain.Task) *qdomain.RetryPolicy {
+	return &qdomain.RetryPolicy{
+		InitialDelay: time.Duration(t.RetryDelaySeconds) * time.Second,
+		Multiplier:   t.RetryMultiplier,
+		MaxDelay:     time.Duration(t.RetryMaxDelaySeconds) * time.Second,
+		Jitter:       t.RetryJitter,
+	}
+}
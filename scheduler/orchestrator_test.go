@@ -0,0 +1,885 @@
+package scheduler_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+type orchFixture struct {
+	orch     *scheduler.Orchestrator
+	queue    *scheduler.MemQueue
+	qtasks   *scheduler.MemTaskRepo
+	tasks    *mock.TaskRepo
+	deps     *mock.TaskDependencyRepo
+	runs     *mock.WorkflowRunRepo
+	taskRuns *mock.TaskRunRepo
+	wfID     uuid.UUID
+}
+
+func newOrchFixture(opts ...scheduler.OrchestratorOption) *orchFixture {
+	f := &orchFixture{
+		queue:    scheduler.NewMemQueue(),
+		qtasks:   scheduler.NewMemTaskRepo(),
+		tasks:    mock.NewTaskRepo(),
+		runs:     mock.NewWorkflowRunRepo(),
+		taskRuns: mock.NewTaskRunRepo(),
+		wfID:     uuid.New(),
+	}
+	f.deps = mock.NewTaskDependencyRepo(f.tasks)
+	sched := scheduler.New(f.qtasks, scheduler.NewMemWorkerRepo(), f.queue)
+	f.orch = scheduler.NewOrchestrator(f.tasks, f.deps, f.runs, f.taskRuns, sched, f.qtasks, opts...)
+	return f
+}
+
+func (f *orchFixture) addTask(name string, typ idomain.TaskType, rule idomain.TriggerRule, upstream ...*idomain.Task) *idomain.Task {
+	t := &idomain.Task{ID: uuid.New(), WorkflowID: f.wfID, Name: name, Command: "echo " + name, Type: typ, TriggerRule: rule}
+	_ = f.tasks.Create(ctx, t)
+	for _, up := range upstream {
+		_ = f.deps.Create(ctx, &idomain.TaskDependency{ID: uuid.New(), TaskID: t.ID, DependsOnTaskID: up.ID})
+	}
+	return t
+}
+
+// work plays the part of a worker: it executes every queued task with the
+// given outcome per task name.
+func (f *orchFixture) work(t *testing.T, outcome map[string]domain.TaskStatus) {
+	t.Helper()
+	for {
+		n, _ := f.queue.Len(ctx)
+		if n == 0 {
+			return
+		}
+		qt, err := f.queue.Dequeue(ctx)
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		now := time.Now()
+		qt.Status = outcome[qt.Name]
+		qt.FinishedAt = &now
+		_ = f.qtasks.Save(ctx, qt)
+	}
+}
+
+func (f *orchFixture) statusOf(t *testing.T, runID uuid.UUID, task *idomain.Task) idomain.Status {
+	t.Helper()
+	trs, _ := f.taskRuns.ListByWorkflowRunID(ctx, runID)
+	for _, tr := range trs {
+		if tr.TaskID == task.ID {
+			return tr.Status
+		}
+	}
+	return ""
+}
+
+func TestOrchestrator_RunsTasksInDependencyOrder(t *testing.T) {
+	f := newOrchFixture()
+	extract := f.addTask("extract", idomain.TaskTypeCommand, "")
+	load := f.addTask("load", idomain.TaskTypeCommand, "", extract)
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	if err := f.orch.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if got := f.statusOf(t, run.ID, extract); got != idomain.StatusRunning {
+		t.Fatalf("extract: got %q, want running", got)
+	}
+	if got := f.statusOf(t, run.ID, load); got != "" {
+		t.Fatalf("load started before extract finished: %q", got)
+	}
+	if n, _ := f.queue.Len(ctx); n != 1 {
+		t.Fatalf("queue depth: got %d, want 1", n)
+	}
+
+	f.work(t, map[string]domain.TaskStatus{"extract": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+	if got := f.statusOf(t, run.ID, extract); got != idomain.StatusSuccess {
+		t.Fatalf("extract: got %q, want success", got)
+	}
+	if got := f.statusOf(t, run.ID, load); got != idomain.StatusRunning {
+		t.Fatalf("load: got %q, want running", got)
+	}
+
+	f.work(t, map[string]domain.TaskStatus{"load": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+	got, _ := f.runs.GetByID(ctx, run.ID)
+	if got.Status != idomain.StatusSuccess || got.FinishedAt == nil {
+		t.Errorf("run: got %q (finished %v), want success", got.Status, got.FinishedAt)
+	}
+}
+
+func TestOrchestrator_ExpiredTaskFailsItsTaskRun(t *testing.T) {
+	f := newOrchFixture()
+	report := f.addTask("report", idomain.TaskTypeCommand, "")
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusRunning, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	_ = f.orch.Reconcile(ctx)
+	f.work(t, map[string]domain.TaskStatus{"report": domain.TaskStatusExpired})
+	_ = f.orch.Reconcile(ctx)
+
+	if got := f.statusOf(t, run.ID, report); got != idomain.StatusFailed {
+		t.Errorf("report: got %q, want failed", got)
+	}
+}
+
+func TestOrchestrator_FailureSkipsDownstreamAndFailsRun(t *testing.T) {
+	f := newOrchFixture()
+	build := f.addTask("build", idomain.TaskTypeCommand, "")
+	deploy := f.addTask("deploy", idomain.TaskTypeCommand, "", build)
+	notify := f.addTask("notify", idomain.TaskTypeCommand, idomain.TriggerAllDone, deploy)
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusRunning, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	_ = f.orch.Reconcile(ctx)
+	f.work(t, map[string]domain.TaskStatus{"build": domain.TaskStatusFailed})
+	_ = f.orch.Reconcile(ctx)
+
+	if got := f.statusOf(t, run.ID, deploy); got != idomain.StatusSkipped {
+		t.Errorf("deploy: got %q, want skipped", got)
+	}
+	if got := f.statusOf(t, run.ID, notify); got != idomain.StatusRunning {
+		t.Fatalf("notify: got %q, want running", got)
+	}
+	f.work(t, map[string]domain.TaskStatus{"notify": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+
+	got, _ := f.runs.GetByID(ctx, run.ID)
+	if got.Status != idomain.StatusFailed {
+		t.Errorf("run: got %q, want failed", got.Status)
+	}
+}
+
+func TestOrchestrator_StartsClearedTaskRuns(t *testing.T) {
+	f := newOrchFixture()
+	build := f.addTask("build", idomain.TaskTypeCommand, "")
+	deploy := f.addTask("deploy", idomain.TaskTypeCommand, "", build)
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusRunning, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	_ = f.orch.Reconcile(ctx)
+	f.work(t, map[string]domain.TaskStatus{"build": domain.TaskStatusFailed})
+	_ = f.orch.Reconcile(ctx)
+
+	// Clear both tasks the way the API does: a pending attempt 2 each, and
+	// the run set back to running.
+	for _, task := range []*idomain.Task{build, deploy} {
+		_ = f.taskRuns.Create(ctx, &idomain.TaskRun{ID: uuid.New(), WorkflowRunID: run.ID, TaskID: task.ID, Status: idomain.StatusPending, Attempt: 2, StartedAt: time.Now()})
+	}
+	_ = f.runs.UpdateStatus(ctx, run.ID, idomain.StatusRunning, nil)
+
+	_ = f.orch.Reconcile(ctx)
+	qt, err := f.queue.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	if qt.Env[idomain.EnvAttempt] != "2" {
+		t.Errorf("attempt: got %q, want 2", qt.Env[idomain.EnvAttempt])
+	}
+	_ = f.queue.Enqueue(ctx, qt)
+	f.work(t, map[string]domain.TaskStatus{"build": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+	f.work(t, map[string]domain.TaskStatus{"deploy": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+
+	got, _ := f.runs.GetByID(ctx, run.ID)
+	if got.Status != idomain.StatusSuccess {
+		t.Errorf("run: got %q, want success", got.Status)
+	}
+	trs, _ := f.taskRuns.ListByWorkflowRunID(ctx, run.ID)
+	want := map[string]idomain.Status{
+		fmt.Sprint(build.ID, 1):  idomain.StatusFailed,
+		fmt.Sprint(build.ID, 2):  idomain.StatusSuccess,
+		fmt.Sprint(deploy.ID, 1): idomain.StatusSkipped,
+		fmt.Sprint(deploy.ID, 2): idomain.StatusSuccess,
+	}
+	if len(trs) != len(want) {
+		t.Fatalf("task runs: got %d, want %d", len(trs), len(want))
+	}
+	for _, tr := range trs {
+		if s := want[fmt.Sprint(tr.TaskID, tr.Attempt)]; tr.Status != s {
+			t.Errorf("task %s attempt %d: got %q, want %q", tr.TaskID, tr.Attempt, tr.Status, s)
+		}
+	}
+}
+
+func TestOrchestrator_RecordsWorkerAssignment(t *testing.T) {
+	f := newOrchFixture()
+	extract := f.addTask("extract", idomain.TaskTypeCommand, "")
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusRunning, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+	_ = f.orch.Reconcile(ctx)
+
+	// A worker takes the task but has not finished it.
+	qt, _ := f.queue.Dequeue(ctx)
+	dispatched := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	qt.Status, qt.WorkerID, qt.DispatchedAt = domain.TaskStatusRunning, "w1", &dispatched
+	_ = f.qtasks.Save(ctx, qt)
+	_ = f.orch.Reconcile(ctx)
+
+	trs, _ := f.taskRuns.ListByTaskID(ctx, extract.ID)
+	if len(trs) != 1 {
+		t.Fatalf("task runs: got %d, want 1", len(trs))
+	}
+	tr := trs[0]
+	if tr.Status != idomain.StatusRunning || tr.AssignedWorkerID != "w1" ||
+		tr.DispatchedAt == nil || !tr.DispatchedAt.Equal(dispatched) {
+		t.Errorf("task run: got status %q, worker %q, dispatched %v; want running on w1 at %s",
+			tr.Status, tr.AssignedWorkerID, tr.DispatchedAt, dispatched)
+	}
+}
+
+func TestOrchestrator_ApprovalTaskWaitsForDecision(t *testing.T) {
+	f := newOrchFixture()
+	gate := f.addTask("gate", idomain.TaskTypeApproval, "")
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusRunning, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	_ = f.orch.Reconcile(ctx)
+	if got := f.statusOf(t, run.ID, gate); got != idomain.StatusAwaitingApproval {
+		t.Errorf("gate: got %q, want awaiting_approval", got)
+	}
+	if n, _ := f.queue.Len(ctx); n != 0 {
+		t.Errorf("approval task was enqueued (depth %d)", n)
+	}
+}
+
+func TestOrchestrator_PublishesRunAndTaskEvents(t *testing.T) {
+	bus := events.NewMemBus()
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sub, _ := bus.Subscribe(sctx)
+
+	f := newOrchFixture(scheduler.WithRunEvents(bus))
+	f.addTask("only", idomain.TaskTypeCommand, "")
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	_ = f.orch.Reconcile(ctx)
+	f.work(t, map[string]domain.TaskStatus{"only": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+
+	var seq []string
+	for len(sub) > 0 {
+		switch e := <-sub; p := e.Payload.(type) {
+		case idomain.WorkflowRun:
+			seq = append(seq, "run:"+string(p.Status))
+		case idomain.TaskRun:
+			seq = append(seq, "task:"+string(p.Status))
+		default:
+			t.Fatalf("unexpected payload %T", e.Payload)
+		}
+	}
+	want := []string{"run:running", "task:running", "task:success", "run:success"}
+	if fmt.Sprint(seq) != fmt.Sprint(want) {
+		t.Errorf("events: got %v, want %v", seq, want)
+	}
+}
+
+func TestOrchestrator_TracksRunProgress(t *testing.T) {
+	bus := events.NewMemBus()
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sub, _ := bus.Subscribe(sctx)
+
+	f := newOrchFixture(scheduler.WithRunEvents(bus))
+	extract := f.addTask("extract", idomain.TaskTypeCommand, "")
+	f.addTask("load", idomain.TaskTypeCommand, "", extract)
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusRunning, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	progress := func() idomain.RunProgress {
+		got, _ := f.runs.GetByID(ctx, run.ID)
+		return got.Progress
+	}
+	_ = f.orch.Reconcile(ctx)
+	if got := progress(); got != idomain.NewRunProgress(0, 2) {
+		t.Fatalf("after start: got %+v, want 0 of 2", got)
+	}
+	f.work(t, map[string]domain.TaskStatus{"extract": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+	if got := progress(); got.Completed != 1 || got.Total != 2 || got.Percent != 50 {
+		t.Fatalf("after extract: got %+v, want 1 of 2 at 50%%", got)
+	}
+	f.work(t, map[string]domain.TaskStatus{"load": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+	if got := progress(); got.Percent != 100 {
+		t.Fatalf("after load: got %+v, want 100%%", got)
+	}
+
+	var seen []string
+	for len(sub) > 0 {
+		if p, ok := (<-sub).Payload.(idomain.WorkflowRun); ok {
+			seen = append(seen, fmt.Sprintf("%s:%d/%d", p.Status, p.Progress.Completed, p.Progress.Total))
+		}
+	}
+	want := []string{"running:1/2", "success:2/2"}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Errorf("run events: got %v, want %v", seen, want)
+	}
+}
+
+func TestOrchestrator_RecordsLineage(t *testing.T) {
+	lineage := mock.NewLineageRepo()
+	f := newOrchFixture(scheduler.WithLineage(lineage))
+	extract := f.addTask("extract", idomain.TaskTypeCommand, "")
+	extract.Inputs, extract.Outputs = []string{"db://raw.orders"}, []string{"s3://lake/orders"}
+	_ = f.tasks.Update(ctx, extract)
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	_ = f.orch.Reconcile(ctx)
+	ins, _ := lineage.ListByDataset(ctx, "db://raw.orders", idomain.LineageInput, time.Time{})
+	if len(ins) != 1 || ins[0].WorkflowRunID != run.ID || ins[0].TaskID != extract.ID {
+		t.Fatalf("input edges: got %+v", ins)
+	}
+	outs, _ := lineage.ListByDataset(ctx, "s3://lake/orders", idomain.LineageOutput, time.Time{})
+	if len(outs) != 0 {
+		t.Fatalf("output recorded before the task finished: %+v", outs)
+	}
+
+	f.work(t, map[string]domain.TaskStatus{"extract": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+	outs, _ = lineage.ListByDataset(ctx, "s3://lake/orders", idomain.LineageOutput, time.Time{})
+	if len(outs) != 1 || outs[0].TaskRunID != ins[0].TaskRunID || outs[0].WorkflowID != f.wfID {
+		t.Errorf("output edges: got %+v", outs)
+	}
+}
+
+func TestOrchestrator_TriggersDownstreamWorkflows(t *testing.T) {
+	workflows := mock.NewWorkflowRepo()
+	bus := events.NewMemBus()
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sub, _ := bus.Subscribe(sctx)
+	f := newOrchFixture(scheduler.WithWorkflows(workflows), scheduler.WithRunEvents(bus))
+	onSuccess, fromTask := uuid.New(), uuid.New()
+	_ = workflows.Create(ctx, &idomain.Workflow{ID: f.wfID, Name: "upstream", TriggerOnSuccess: []uuid.UUID{onSuccess}})
+
+	build := f.addTask("build", idomain.TaskTypeCommand, "")
+	kick := f.addTask("kick", idomain.TaskTypeTriggerWorkflow, "", build)
+	kick.Command = fromTask.String()
+	_ = f.tasks.Update(ctx, kick)
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	_ = f.orch.Reconcile(ctx)
+	f.work(t, map[string]domain.TaskStatus{"build": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+
+	if got := f.statusOf(t, run.ID, kick); got != idomain.StatusSuccess {
+		t.Fatalf("kick: got %q, want success", got)
+	}
+	if n, _ := f.queue.Len(ctx); n != 0 {
+		t.Errorf("trigger task was queued for a worker")
+	}
+	got, _ := f.runs.GetByID(ctx, run.ID)
+	if got.Status != idomain.StatusSuccess {
+		t.Fatalf("run: got %q, want success", got.Status)
+	}
+	for _, target := range []uuid.UUID{fromTask, onSuccess} {
+		runs, _ := f.runs.ListByWorkflowID(ctx, target)
+		if len(runs) != 1 || runs[0].TriggeredByRunID == nil || *runs[0].TriggeredByRunID != run.ID {
+			t.Errorf("workflow %s: got %d runs, want 1 triggered by %s", target, len(runs), run.ID)
+		}
+	}
+
+	// Each triggered run is announced as it is created.
+	announced := map[uuid.UUID]idomain.Status{}
+	for len(sub) > 0 {
+		if r, ok := (<-sub).Payload.(idomain.WorkflowRun); ok && r.WorkflowID != f.wfID {
+			announced[r.WorkflowID] = r.Status
+		}
+	}
+	if announced[fromTask] != idomain.StatusPending || announced[onSuccess] != idomain.StatusPending {
+		t.Errorf("announced triggered runs: got %v, want both pending", announced)
+	}
+}
+
+func TestOrchestrator_RendersCommandTemplates(t *testing.T) {
+	f := newOrchFixture()
+	good := f.addTask("good", idomain.TaskTypeCommand, "")
+	good.Command = "etl --date {{ .ds }} --table {{ .params.table }}"
+	good.Env = map[string]string{"RUN": "{{ .run_id }}"}
+	_ = f.tasks.Update(ctx, good)
+	bad := f.addTask("bad", idomain.TaskTypeCommand, "")
+	bad.Command = "etl {{ .params.missing }}"
+	_ = f.tasks.Update(ctx, bad)
+	logical := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending,
+		StartedAt: time.Now(), LogicalDate: &logical, Params: map[string]string{"table": "orders"}}
+	_ = f.runs.Create(ctx, run)
+
+	_ = f.orch.Reconcile(ctx)
+	if got := f.statusOf(t, run.ID, bad); got != idomain.StatusFailed {
+		t.Errorf("bad: got %q, want failed for a missing param", got)
+	}
+	qt, err := f.queue.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	if want := "etl --date 2024-03-01 --table orders"; string(qt.Payload) != want {
+		t.Errorf("Payload: got %q, want %q", qt.Payload, want)
+	}
+	if qt.Env["RUN"] != run.ID.String() {
+		t.Errorf("Env RUN: got %q, want %s", qt.Env["RUN"], run.ID)
+	}
+}
+
+func TestOrchestrator_InjectsExecutionContext(t *testing.T) {
+	workflows := mock.NewWorkflowRepo()
+	f := newOrchFixture(scheduler.WithWorkflows(workflows))
+	_ = workflows.Create(ctx, &idomain.Workflow{ID: f.wfID, Name: "nightly"})
+	f.addTask("load", idomain.TaskTypeCommand, "")
+
+	day := func(d int) *time.Time {
+		t := time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	for d, status := range map[int]idomain.Status{1: idomain.StatusSuccess, 2: idomain.StatusFailed, 4: idomain.StatusSuccess} {
+		_ = f.runs.Create(ctx, &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: status, StartedAt: *day(d), LogicalDate: day(d)})
+	}
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: time.Now(), LogicalDate: day(3)}
+	_ = f.runs.Create(ctx, run)
+
+	_ = f.orch.Reconcile(ctx)
+	qt, err := f.queue.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	want := map[string]string{
+		idomain.EnvRunID:           run.ID.String(),
+		idomain.EnvWorkflowName:    "nightly",
+		idomain.EnvTaskName:        "load",
+		idomain.EnvAttempt:         "1",
+		idomain.EnvLogicalDate:     "2024-03-03T00:00:00Z",
+		idomain.EnvPrevSuccessDate: "2024-03-01T00:00:00Z",
+	}
+	for k, v := range want {
+		if qt.Env[k] != v {
+			t.Errorf("%s: got %q, want %q", k, qt.Env[k], v)
+		}
+	}
+}
+
+func TestOrchestrator_LimitsParallelTasksPerRun(t *testing.T) {
+	workflows := mock.NewWorkflowRepo()
+	f := newOrchFixture(scheduler.WithWorkflows(workflows))
+	_ = workflows.Create(ctx, &idomain.Workflow{ID: f.wfID, Name: "fan-out", MaxParallelTasks: 2})
+	outcome := map[string]domain.TaskStatus{}
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("part-%d", i)
+		f.addTask(name, idomain.TaskTypeCommand, "")
+		outcome[name] = domain.TaskStatusSucceeded
+	}
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	for pass := 0; pass < 3; pass++ {
+		_ = f.orch.Reconcile(ctx)
+		if n, _ := f.queue.Len(ctx); n > 2 {
+			t.Fatalf("pass %d: %d tasks queued, want at most 2", pass, n)
+		}
+		_ = f.orch.Reconcile(ctx) // a second pass must not exceed the cap either
+		if n, _ := f.queue.Len(ctx); n > 2 {
+			t.Fatalf("pass %d: %d tasks queued after re-reconcile, want at most 2", pass, n)
+		}
+		f.work(t, outcome)
+	}
+	_ = f.orch.Reconcile(ctx)
+	got, _ := f.runs.GetByID(ctx, run.ID)
+	if got.Status != idomain.StatusSuccess {
+		t.Errorf("run: got %q, want success", got.Status)
+	}
+}
+
+func TestOrchestrator_QueuesRunsBeyondMaxActiveRuns(t *testing.T) {
+	workflows := mock.NewWorkflowRepo()
+	f := newOrchFixture(scheduler.WithWorkflows(workflows))
+	_ = workflows.Create(ctx, &idomain.Workflow{ID: f.wfID, Name: "nightly", MaxActiveRuns: 1})
+	f.addTask("only", idomain.TaskTypeCommand, "")
+	start := time.Now()
+	first := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: start.Add(time.Second)}
+	second := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: start.Add(2 * time.Second)}
+	_ = f.runs.Create(ctx, second)
+	_ = f.runs.Create(ctx, first)
+
+	_ = f.orch.Reconcile(ctx)
+	if got, _ := f.runs.GetByID(ctx, first.ID); got.Status != idomain.StatusRunning {
+		t.Fatalf("older run: got %q, want running", got.Status)
+	}
+	if got, _ := f.runs.GetByID(ctx, second.ID); got.Status != idomain.StatusPending {
+		t.Fatalf("newer run: got %q, want pending behind the older one", got.Status)
+	}
+
+	f.work(t, map[string]domain.TaskStatus{"only": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx) // completes the first run
+	_ = f.orch.Reconcile(ctx)
+	if got, _ := f.runs.GetByID(ctx, second.ID); got.Status != idomain.StatusRunning {
+		t.Errorf("newer run: got %q, want running once the older one finished", got.Status)
+	}
+}
+
+func TestOrchestrator_RetriesRateLimitedSubmissions(t *testing.T) {
+	fc := clock.NewFake(time.Now().Add(time.Hour)) // past every ScheduledAt
+	f := newOrchFixture()
+	limiter := scheduler.NewSubmitLimiter(map[string]scheduler.RateLimit{scheduler.GlobalRateLimit: {Rate: 0.1, Burst: 1}})
+	sched := scheduler.New(f.qtasks, scheduler.NewMemWorkerRepo(), f.queue, scheduler.WithClock(fc), scheduler.WithRateLimits(limiter))
+	f.orch = scheduler.NewOrchestrator(f.tasks, f.deps, f.runs, f.taskRuns, sched, f.qtasks)
+	a := f.addTask("a", idomain.TaskTypeCommand, "")
+	b := f.addTask("b", idomain.TaskTypeCommand, "")
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	_ = f.orch.Reconcile(ctx)
+	if n, _ := f.queue.Len(ctx); n != 1 {
+		t.Fatalf("first pass: %d tasks queued, want 1", n)
+	}
+	statuses := []idomain.Status{f.statusOf(t, run.ID, a), f.statusOf(t, run.ID, b)}
+	if fmt.Sprint(statuses) != "[running pending]" && fmt.Sprint(statuses) != "[pending running]" {
+		t.Fatalf("first pass: got %v, want one running and one pending", statuses)
+	}
+
+	fc.Advance(10 * time.Second)
+	_ = f.orch.Reconcile(ctx)
+	if n, _ := f.queue.Len(ctx); n != 2 {
+		t.Errorf("after the bucket refilled: %d tasks queued, want 2", n)
+	}
+	if trs, _ := f.taskRuns.ListByWorkflowRunID(ctx, run.ID); len(trs) != 2 {
+		t.Errorf("task runs: got %d, want the parked attempt reused", len(trs))
+	}
+}
+
+func TestOrchestrator_SubmitsToWorkflowWorkerGroup(t *testing.T) {
+	workflows := mock.NewWorkflowRepo()
+	f := newOrchFixture(scheduler.WithWorkflows(workflows))
+	_ = workflows.Create(ctx, &idomain.Workflow{ID: f.wfID, Name: "heavy", WorkerGroup: "analytics"})
+	f.addTask("crunch", idomain.TaskTypeCommand, "")
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	if err := f.orch.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	qt, err := f.queue.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	if qt.Group != "analytics" {
+		t.Errorf("Group: got %q, want analytics", qt.Group)
+	}
+}
+
+func TestOrchestrator_TasksInheritWorkflowPriority(t *testing.T) {
+	workflows := mock.NewWorkflowRepo()
+	f := newOrchFixture(scheduler.WithWorkflows(workflows))
+	_ = workflows.Create(ctx, &idomain.Workflow{ID: f.wfID, Name: "urgent", Priority: 9})
+	f.addTask("inherits", idomain.TaskTypeCommand, "")
+	own := f.addTask("overrides", idomain.TaskTypeCommand, "")
+	own.Priority = 2
+	_ = f.tasks.Update(ctx, own)
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: time.Now()}
+	_ = f.runs.Create(ctx, run)
+
+	if err := f.orch.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	got := map[string]domain.Priority{}
+	for range 2 {
+		qt, err := f.queue.Dequeue(ctx)
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		got[qt.Name] = qt.Priority
+	}
+	if got["inherits"] != 9 || got["overrides"] != 2 {
+		t.Errorf("priorities: got %v, want inherits:9 overrides:2", got)
+	}
+}
+
+func TestOrchestrator_StampsQueueTasksWithItsClock(t *testing.T) {
+	// Not far from the scheduler's real clock, so the task neither waits
+	// nor expires on its way to the queue.
+	now := time.Now().UTC().Truncate(time.Second)
+	f := newOrchFixture(scheduler.WithOrchestratorClock(clock.NewFake(now)))
+	task := f.addTask("ttl", idomain.TaskTypeCommand, "")
+	task.TTLSeconds = 3600
+	_ = f.tasks.Update(ctx, task)
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: now}
+	_ = f.runs.Create(ctx, run)
+
+	if err := f.orch.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	qt, err := f.queue.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	if !qt.ScheduledAt.Equal(now) || qt.ExpiresAt == nil || !qt.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("ScheduledAt %v, ExpiresAt %v; want %v and an hour later", qt.ScheduledAt, qt.ExpiresAt, now)
+	}
+}
+
+func TestOrchestrator_RunTicksOnItsClock(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	fc := clock.NewFake(now)
+	f := newOrchFixture(scheduler.WithOrchestratorClock(fc))
+	task := f.addTask("tick", idomain.TaskTypeCommand, "")
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: now}
+	_ = f.runs.Create(ctx, run)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- f.orch.Run(runCtx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	fc.BlockUntil(1)
+	if got := f.statusOf(t, run.ID, task); got != "" {
+		t.Fatalf("task started before the first tick: %q", got)
+	}
+	fc.Advance(scheduler.DefaultOrchestrateInterval)
+	deadline := time.Now().Add(3 * time.Second)
+	for f.statusOf(t, run.ID, task) != idomain.StatusRunning {
+		if time.Now().After(deadline) {
+			t.Fatal("task not started after a tick of the orchestrator's clock")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOrchestrator_QueuesTasksWithRunIdempotencyKey(t *testing.T) {
+	f := newOrchFixture()
+	f.addTask("a", idomain.TaskTypeCommand, "")
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: time.Now(), IdempotencyKey: "nightly-42"}
+	_ = f.runs.Create(ctx, run)
+
+	if err := f.orch.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	qt, err := f.queue.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	want := f.wfID.String() + "/nightly-42/a/1"
+	if qt.IdempotencyKey != want || run.TaskIdempotencyKey("a", 1) != want {
+		t.Errorf("IdempotencyKey: got %q, want %q", qt.IdempotencyKey, want)
+	}
+}
+
+func TestOrchestrator_DuplicateAttemptFollowsExistingTask(t *testing.T) {
+	f := newOrchFixture()
+	sched := scheduler.New(f.qtasks, scheduler.NewMemWorkerRepo(), f.queue, scheduler.WithIdempotencyWindow(time.Hour))
+	f.orch = scheduler.NewOrchestrator(f.tasks, f.deps, f.runs, f.taskRuns, sched, f.qtasks)
+	a := f.addTask("a", idomain.TaskTypeCommand, "")
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending, StartedAt: time.Now(), IdempotencyKey: "nightly-42"}
+	_ = f.runs.Create(ctx, run)
+	// Another scheduler already submitted the attempt under its key.
+	earlier := &domain.Task{ID: "earlier", Name: "a", Priority: domain.PriorityNormal, ScheduledAt: time.Now(),
+		IdempotencyKey: run.TaskIdempotencyKey("a", 1)}
+	if err := sched.Submit(ctx, earlier); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if err := f.orch.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	trs, _ := f.taskRuns.ListByWorkflowRunID(ctx, run.ID)
+	if len(trs) != 1 || trs[0].Status != idomain.StatusRunning || trs[0].QueueTaskID != "earlier" {
+		t.Fatalf("task runs: got %+v, want one running and following earlier", trs)
+	}
+	f.work(t, map[string]domain.TaskStatus{"a": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+	if got := f.statusOf(t, run.ID, a); got != idomain.StatusSuccess {
+		t.Errorf("task a: got %q, want success", got)
+	}
+}
+
+func TestQueueTask_DefaultsToNormalPriority(t *testing.T) {
+	task := &idomain.Task{ID: uuid.New(), Name: "t", Command: "run"}
+	if got := scheduler.QueueTask("id-1", task, time.Now()).Priority; got != domain.PriorityNormal {
+		t.Errorf("Priority: got %d, want %d", got, domain.PriorityNormal)
+	}
+}
+
+func TestQueueTask_MapsTTL(t *testing.T) {
+	task := &idomain.Task{ID: uuid.New(), Name: "t", Command: "run", TTLSeconds: 600}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	qt := scheduler.QueueTask("id-1", task, now)
+	if !qt.ScheduledAt.Equal(now) || qt.ExpiresAt == nil || !qt.ExpiresAt.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("ExpiresAt: got %v, want %v plus 10m", qt.ExpiresAt, now)
+	}
+	if scheduler.QueueTask("id-2", &idomain.Task{ID: uuid.New(), Name: "t"}, time.Now()).ExpiresAt != nil {
+		t.Error("ExpiresAt set for a task without a TTL")
+	}
+}
+
+func TestQueueTask_MapsRetryPolicy(t *testing.T) {
+	task := &idomain.Task{
+		ID: uuid.New(), WorkflowID: uuid.New(), Name: "t", Command: "run", Type: idomain.TaskTypeSensor,
+		RetryCount: 3, RetryDelaySeconds: 2, RetryMultiplier: 3, RetryMaxDelaySeconds: 10,
+	}
+	qt := scheduler.QueueTask("id-1", task, time.Now())
+	if err := qt.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if qt.Type != "sensor" || qt.MaxRetries != 3 || string(qt.Payload) != "run" || qt.WorkflowID != task.WorkflowID.String() {
+		t.Errorf("unexpected mapping: %+v", qt)
+	}
+	if qt.Retry == nil || qt.Retry.InitialDelay != 2*time.Second || qt.Retry.MaxDelay != 10*time.Second {
+		t.Errorf("Retry: got %+v", qt.Retry)
+	}
+}
+
+func TestQueueTask_KeepsRetryPolicyWithoutDelay(t *testing.T) {
+	task := &idomain.Task{ID: uuid.New(), Name: "t", Command: "run", RetryCount: 2, RetryMaxDelaySeconds: 30, RetryJitter: true}
+	qt := scheduler.QueueTask("id-1", task, time.Now())
+	if qt.MaxRetries != 2 || qt.Retry == nil || qt.Retry.MaxDelay != 30*time.Second || !qt.Retry.Jitter {
+		t.Errorf("retries: got %d, policy %+v", qt.MaxRetries, qt.Retry)
+	}
+	task = &idomain.Task{ID: uuid.New(), Name: "t", Command: "run", RetryCount: 2}
+	if qt := scheduler.QueueTask("id-2", task, time.Now()); qt.MaxRetries != 2 || qt.Retry != nil {
+		t.Errorf("without retry settings: got %d retries, policy %+v; want the worker's backoff", qt.MaxRetries, qt.Retry)
+	}
+}
+
+func TestQueueTask_MapsTimeout(t *testing.T) {
+	task := &idomain.Task{ID: uuid.New(), WorkflowID: uuid.New(), Name: "t", Command: "run", TimeoutSeconds: 90}
+	if got := scheduler.QueueTask("id-1", task, time.Now()).Timeout; got != 90*time.Second {
+		t.Errorf("Timeout: got %s, want 1m30s", got)
+	}
+}
+
+func TestQueueTask_MapsHooks(t *testing.T) {
+	task := &idomain.Task{ID: uuid.New(), Name: "t", Command: "run", Type: idomain.TaskTypeCommand, Hooks: []idomain.TaskHook{
+		{On: idomain.HookOnFailure, Kind: idomain.HookNotify, Notifier: "ops", Message: "t failed"},
+	}}
+	qt := scheduler.QueueTask("id-1", task, time.Now())
+	want := domain.Hook{On: "on_failure", Kind: "notify", Notifier: "ops", Message: "t failed"}
+	if len(qt.Hooks) != 1 || qt.Hooks[0] != want {
+		t.Errorf("Hooks: got %+v, want [%+v]", qt.Hooks, want)
+	}
+}
+
+func TestOrchestrator_RunTimeoutFailsRun(t *testing.T) {
+	workflows := mock.NewWorkflowRepo()
+	f := newOrchFixture(scheduler.WithWorkflows(workflows))
+	wf := &idomain.Workflow{ID: f.wfID, Name: "nightly", RunTimeoutSeconds: 3600}
+	_ = workflows.Create(ctx, wf)
+	extract := f.addTask("extract", idomain.TaskTypeCommand, "")
+	load := f.addTask("load", idomain.TaskTypeCommand, "", extract)
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending,
+		StartedAt: time.Now().Add(-2 * time.Minute)}
+	_ = f.runs.Create(ctx, run)
+	_ = f.orch.Reconcile(ctx)
+
+	wf.RunTimeoutSeconds = 60 // the running run is held to the new limit
+	_ = workflows.Update(ctx, wf)
+	_ = f.orch.Reconcile(ctx)
+
+	if got := f.statusOf(t, run.ID, extract); got != idomain.StatusFailed {
+		t.Errorf("extract: got %q, want failed", got)
+	}
+	if got := f.statusOf(t, run.ID, load); got != idomain.StatusSkipped {
+		t.Errorf("load: got %q, want skipped", got)
+	}
+	trs, _ := f.taskRuns.ListByWorkflowRunID(ctx, run.ID)
+	for _, tr := range trs {
+		if tr.TaskID != extract.ID {
+			continue
+		}
+		if qt, _ := f.qtasks.FindByID(ctx, tr.ID.String()); qt.Status != domain.TaskStatusFailed {
+			t.Errorf("extract queue task: got %q, want it cancelled", qt.Status)
+		}
+	}
+	got, _ := f.runs.GetByID(ctx, run.ID)
+	if got.Status != idomain.StatusFailed || got.FinishedAt == nil {
+		t.Errorf("run: got %q (finished %v), want failed", got.Status, got.FinishedAt)
+	}
+}
+
+func TestOrchestrator_RunTimeoutCancelsDownstream(t *testing.T) {
+	workflows := mock.NewWorkflowRepo()
+	f := newOrchFixture(scheduler.WithWorkflows(workflows))
+	wf := &idomain.Workflow{ID: f.wfID, Name: "nightly", RunTimeoutSeconds: 3600,
+		RunTimeoutPolicy: idomain.RunTimeoutCancelDownstream}
+	_ = workflows.Create(ctx, wf)
+	extract := f.addTask("extract", idomain.TaskTypeCommand, "")
+	load := f.addTask("load", idomain.TaskTypeCommand, "", extract)
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusPending,
+		StartedAt: time.Now().Add(-2 * time.Minute)}
+	_ = f.runs.Create(ctx, run)
+	_ = f.orch.Reconcile(ctx)
+
+	wf.RunTimeoutSeconds = 60
+	_ = workflows.Update(ctx, wf)
+	_ = f.orch.Reconcile(ctx)
+	if got := f.statusOf(t, run.ID, extract); got != idomain.StatusRunning {
+		t.Errorf("extract: got %q, want it left running", got)
+	}
+	if got := f.statusOf(t, run.ID, load); got != idomain.StatusSkipped {
+		t.Errorf("load: got %q, want skipped", got)
+	}
+
+	f.work(t, map[string]domain.TaskStatus{"extract": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+	if got := f.statusOf(t, run.ID, extract); got != idomain.StatusSuccess {
+		t.Errorf("extract: got %q, want success", got)
+	}
+	got, _ := f.runs.GetByID(ctx, run.ID)
+	if got.Status != idomain.StatusFailed {
+		t.Errorf("run: got %q, want failed", got.Status)
+	}
+}
+
+func TestOrchestrator_RunTimeoutAlertsOnce(t *testing.T) {
+	bus := events.NewMemBus()
+	sub, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch, _ := bus.Subscribe(sub)
+	workflows := mock.NewWorkflowRepo()
+	f := newOrchFixture(scheduler.WithWorkflows(workflows), scheduler.WithRunEvents(bus))
+	_ = workflows.Create(ctx, &idomain.Workflow{ID: f.wfID, Name: "nightly", RunTimeoutSeconds: 60,
+		RunTimeoutPolicy: idomain.RunTimeoutAlert})
+	extract := f.addTask("extract", idomain.TaskTypeCommand, "")
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: f.wfID, Status: idomain.StatusRunning,
+		StartedAt: time.Now().Add(-2 * time.Minute)}
+	_ = f.runs.Create(ctx, run)
+
+	_ = f.orch.Reconcile(ctx)
+	_ = f.orch.Reconcile(ctx)
+	if got := f.statusOf(t, run.ID, extract); got != idomain.StatusRunning {
+		t.Errorf("extract: got %q, want running", got)
+	}
+	f.work(t, map[string]domain.TaskStatus{"extract": domain.TaskStatusSucceeded})
+	_ = f.orch.Reconcile(ctx)
+
+	var alerts []events.AlertUpdate
+	for len(ch) > 0 {
+		if e := <-ch; e.Type == events.Alert {
+			alerts = append(alerts, e.Payload.(events.AlertUpdate))
+		}
+	}
+	if len(alerts) != 2 || !alerts[0].Firing || alerts[1].Firing {
+		t.Fatalf("alerts: got %+v, want one firing then one resolved", alerts)
+	}
+	if alerts[0].Name != scheduler.RunTimeoutAlertName(run.ID) {
+		t.Errorf("alert name: got %q", alerts[0].Name)
+	}
+	got, _ := f.runs.GetByID(ctx, run.ID)
+	if got.Status != idomain.StatusSuccess {
+		t.Errorf("run: got %q, want success", got.Status)
+	}
+}
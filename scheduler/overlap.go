@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// Overlapping returns the overlap policy a new run of wf falls under: ""
+// if the run may be created pending, because wf sets no MaxActiveRuns, has
+// fewer runs pending or running, or queues the runs beyond it; otherwise
+// domain.OverlapSkip or domain.OverlapReject. The API's TriggerWorkflow
+// and the CronTrigger both ask before creating a run.
+func Overlapping(ctx context.Context, runs repository.WorkflowRunRepository, wf *domain.Workflow) (domain.OverlapPolicy, error) {
+	if wf == nil || wf.MaxActiveRuns <= 0 || wf.Overlap() == domain.OverlapQueue {
+		return "", nil
+	}
+	n, err := countRuns(ctx, runs, wf.ID, wf.MaxActiveRuns, domain.StatusPending, domain.StatusRunning)
+	if err != nil || n < wf.MaxActiveRuns {
+		return "", err
+	}
+	return wf.Overlap(), nil
+}
+
+// countRuns returns how many runs of workflowID have one of statuses,
+// counting at most limit of each.
+func countRuns(ctx context.Context, runs repository.WorkflowRunRepository, workflowID uuid.UUID, limit int, statuses ...domain.Status) (int, error) {
+	n := 0
+	for _, s := range statuses {
+		page, err := runs.FindByWorkflowID(ctx, workflowID, repository.WorkflowRunFilter{Status: s, Limit: limit})
+		if err != nil {
+			return 0, err
+		}
+		n += len(page)
+	}
+	return n, nil
+}
+
+// startable returns the pending runs that may start now, oldest first: all
+// of them, except that a workflow with MaxActiveRuns only gets as many as
+// it has fewer running. The others stay pending for a later pass.
+func (o *Orchestrator) startable(ctx context.Context, pending []*domain.WorkflowRun) []*domain.WorkflowRun {
+	slices.SortStableFunc(pending, func(a, b *domain.WorkflowRun) int { return a.StartedAt.Compare(b.StartedAt) })
+	free := make(map[uuid.UUID]int) // remaining slots of each limited workflow
+	out := pending[:0]
+	for _, run := range pending {
+		n, seen := free[run.WorkflowID]
+		if !seen {
+			n = -1 // unlimited
+			if wf := o.workflow(ctx, run); wf != nil && wf.MaxActiveRuns > 0 {
+				running, err := countRuns(ctx, o.workflowRuns, wf.ID, wf.MaxActiveRuns, domain.StatusRunning)
+				if err != nil {
+					log.Printf("Orchestrator: workflow %s: count running runs: %v", wf.ID, err)
+					running = wf.MaxActiveRuns
+				}
+				n = max(wf.MaxActiveRuns-running, 0)
+			}
+		}
+		if n == 0 {
+			free[run.WorkflowID] = 0
+			continue
+		}
+		if n > 0 {
+			n--
+		}
+		free[run.WorkflowID] = n
+		out = append(out, run)
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// maxPauseChain bounds how many overlapping openings of one window
+// PausedAt follows to find where it closes.
+const maxPauseChain = 1000
+
+// PauseSchedule returns when w opens, evaluated in wf's timezone.
+func PauseSchedule(wf *domain.Workflow, w domain.PauseWindow) (cron.Schedule, error) {
+	return WorkflowSchedule(&domain.Workflow{ScheduleCron: w.Start, Timezone: wf.Timezone})
+}
+
+// PausedAt returns the first of wf's pause windows open at t, and when it
+// closes; nil when none is. A window is open from each of its start times
+// for its duration; openings that overlap extend each other. Windows with
+// an invalid start are ignored, as validation keeps them out.
+func PausedAt(wf *domain.Workflow, t time.Time) (*domain.PauseWindow, time.Time) {
+	for i, w := range wf.PauseWindows {
+		d := w.Duration()
+		if d <= 0 {
+			continue
+		}
+		sched, err := PauseSchedule(wf, w)
+		if err != nil {
+			continue
+		}
+		// The latest opening at or before t is the first one after t-d,
+		// if it is not after t.
+		start := sched.Next(t.Add(-d))
+		if start.IsZero() || start.After(t) {
+			continue
+		}
+		end := start.Add(d)
+		for n := 0; n < maxPauseChain; n++ {
+			next := sched.Next(start)
+			if next.IsZero() || !next.Before(end) {
+				break
+			}
+			start, end = next, next.Add(d)
+		}
+		return &wf.PauseWindows[i], end
+	}
+	return nil, time.Time{}
+}
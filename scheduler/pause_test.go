@@ -0,0 +1,104 @@
+package scheduler_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+func TestPausedAt(t *testing.T) {
+	// Sundays 00:00–04:00 in Berlin, i.e. Saturday 23:00 to Sunday 03:00 UTC
+	// in winter; and a 90 minute window every hour, whose openings overlap.
+	wf := &idomain.Workflow{Timezone: "Europe/Berlin", PauseWindows: []idomain.PauseWindow{
+		{Name: "maintenance", Start: "0 0 * * 0", DurationMinutes: 240},
+		{Name: "always", Start: "0 * 1 2 *", DurationMinutes: 90},
+	}}
+	for _, tc := range []struct {
+		at     time.Time
+		window string
+		until  time.Time
+	}{
+		{at: time.Date(2024, 1, 6, 22, 59, 59, 0, time.UTC)},
+		{at: time.Date(2024, 1, 6, 23, 0, 0, 0, time.UTC), window: "maintenance", until: time.Date(2024, 1, 7, 3, 0, 0, 0, time.UTC)},
+		{at: time.Date(2024, 1, 7, 2, 59, 0, 0, time.UTC), window: "maintenance", until: time.Date(2024, 1, 7, 3, 0, 0, 0, time.UTC)},
+		{at: time.Date(2024, 1, 7, 3, 0, 0, 0, time.UTC)},
+		// Hourly openings of 90 minutes chain until the last one of the day.
+		{at: time.Date(2024, 2, 1, 5, 0, 0, 0, time.UTC), window: "always", until: time.Date(2024, 2, 1, 23, 30, 0, 0, time.UTC)},
+	} {
+		w, until := scheduler.PausedAt(wf, tc.at)
+		var got string
+		if w != nil {
+			got = w.Name
+		}
+		if got != tc.window || !until.Equal(tc.until) {
+			t.Errorf("PausedAt(%s): got %q until %s, want %q until %s", tc.at, got, until, tc.window, tc.until)
+		}
+	}
+}
+
+func TestCronTrigger_PauseWindows(t *testing.T) {
+	wfRepo, runRepo := mock.NewWorkflowRepo(), mock.NewWorkflowRunRepo()
+	skip := &idomain.Workflow{ID: uuid.New(), Name: "skip", ScheduleCron: "0 * * * *", IsActive: true,
+		PauseWindows: []idomain.PauseWindow{{Name: "deploy", Start: "0 10 * * *", DurationMinutes: 90}}}
+	delay := &idomain.Workflow{ID: uuid.New(), Name: "delay", ScheduleCron: "0 * * * *", IsActive: true,
+		PauseWindows: []idomain.PauseWindow{{Name: "deploy", Start: "0 10 * * *", DurationMinutes: 90, Action: idomain.PauseDelay}}}
+	_ = wfRepo.Create(ctx, skip)
+	_ = wfRepo.Create(ctx, delay)
+
+	fc := clock.NewFake(time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC))
+	ct := scheduler.NewCronTrigger(wfRepo, runRepo, scheduler.WithCronClock(fc))
+	if err := ct.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer ct.Stop()
+	for _, hm := range [][2]int{{10, 0}, {11, 0}, {11, 30}, {12, 0}} {
+		fc.BlockUntil(1)
+		fc.Set(time.Date(2024, 1, 1, hm[0], hm[1], 0, 0, time.UTC))
+	}
+	fc.BlockUntil(1)
+
+	type run struct {
+		logical, started string
+		status           idomain.Status
+		window           string
+	}
+	collect := func(wf *idomain.Workflow) []run {
+		runs, _ := runRepo.ListByWorkflowID(ctx, wf.ID)
+		sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.Before(runs[j].StartedAt) })
+		out := make([]run, len(runs))
+		for i, r := range runs {
+			out[i] = run{r.LogicalDate.Format("15:04"), r.StartedAt.Format("15:04"), r.Status, r.Labels[idomain.LabelPauseWindow]}
+		}
+		return out
+	}
+	wantSkip := []run{
+		{"10:00", "10:00", idomain.StatusSkipped, "deploy"},
+		{"11:00", "11:00", idomain.StatusSkipped, "deploy"},
+		{"12:00", "12:00", idomain.StatusPending, ""},
+	}
+	// Both fires inside the window coalesce into one run when it closes.
+	wantDelay := []run{
+		{"10:00", "11:30", idomain.StatusPending, "deploy"},
+		{"12:00", "12:00", idomain.StatusPending, ""},
+	}
+	for _, tc := range []struct {
+		wf   *idomain.Workflow
+		want []run
+	}{{skip, wantSkip}, {delay, wantDelay}} {
+		got := collect(tc.wf)
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: got runs %+v, want %+v", tc.wf.Name, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s run %d: got %+v, want %+v", tc.wf.Name, i, got[i], tc.want[i])
+			}
+		}
+	}
+}
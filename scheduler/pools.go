@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PoolUsage reports the configured size and current occupancy of a pool.
+type PoolUsage struct {
+	Size int `json:"size"`
+	Used int `json:"used"`
+}
+
+// Pools tracks slot usage for named resource pools shared by all workflows.
+// A task referencing a pool occupies one slot from dispatch until it reaches
+// a terminal state. Pools that were never configured are unlimited.
+// Pools is safe for concurrent use.
+type Pools struct {
+	mu   sync.Mutex
+	size map[string]int
+	used map[string]int
+}
+
+// NewPools creates a Pools with the given slot count per pool name.
+func NewPools(sizes map[string]int) *Pools {
+	p := &Pools{size: make(map[string]int), used: make(map[string]int)}
+	for name, n := range sizes {
+		p.size[name] = n
+	}
+	return p
+}
+
+// TryAcquire takes a slot from the named pool and reports whether one was
+// free. An empty or unconfigured name always succeeds.
+func (p *Pools) TryAcquire(name string) bool {
+	if name == "" {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	size, ok := p.size[name]
+	if !ok {
+		return true
+	}
+	if p.used[name] >= size {
+		return false
+	}
+	p.used[name]++
+	return true
+}
+
+// Release returns a slot to the named pool. Releasing an unconfigured or
+// empty pool is a no-op.
+func (p *Pools) Release(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.used[name] > 0 {
+		p.used[name]--
+	}
+}
+
+// Usage returns a snapshot of every configured pool.
+func (p *Pools) Usage() map[string]PoolUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[string]PoolUsage, len(p.size))
+	for name, size := range p.size {
+		out[name] = PoolUsage{Size: size, Used: p.used[name]}
+	}
+	return out
+}
+
+// ParsePools parses a pool specification such as "warehouse=4,gpu=2".
+func ParsePools(spec string) (map[string]int, error) {
+	out := make(map[string]int)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, n, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("pool %q: expected name=slots", part)
+		}
+		slots, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil || slots <= 0 {
+			return nil, fmt.Errorf("pool %q: slots must be a positive integer", part)
+		}
+		out[strings.TrimSpace(name)] = slots
+	}
+	return out, nil
+}
@@ -4,17 +4,27 @@ package scheduler
 
 import (
 	"context"
+	"log"
+	"slices"
+	"sort"
 	"sync"
 
 	"github.com/sauravritesh63/GoLang-Project-/domain"
 )
 
 // MemQueue is a thread-safe, unbounded in-memory implementation of domain.Queue.
-// Tasks are served in FIFO order.
+// Tasks are served highest Priority first, and in FIFO order within a
+// priority. OpenMemQueue returns one that journals its tasks to a file.
 type MemQueue struct {
-	mu  sync.Mutex
-	buf []*domain.Task
-	sig chan struct{}
+	mu      sync.Mutex
+	buf     []*domain.Task // in dequeue order
+	sig     chan struct{}
+	journal *queueJournal
+
+	// draining is set by Drain; drained is closed when the queue empties
+	// while Drain waits for it.
+	draining bool
+	drained  chan struct{}
 }
 
 // NewMemQueue creates an empty MemQueue ready for use.
@@ -22,11 +32,21 @@ func NewMemQueue() *MemQueue {
 	return &MemQueue{sig: make(chan struct{}, 1)}
 }
 
-// Enqueue appends task to the tail of the queue and notifies any blocked
-// Dequeue callers.
+// Enqueue adds task behind every waiting task of its priority or higher and
+// notifies any blocked Dequeue callers. Once Drain has been called it returns domain.ErrDraining.
 func (q *MemQueue) Enqueue(_ context.Context, task *domain.Task) error {
 	q.mu.Lock()
-	q.buf = append(q.buf, task)
+	if q.draining {
+		q.mu.Unlock()
+		return domain.ErrDraining
+	}
+	if q.journal != nil {
+		if err := q.journal.append(journalRecord{Op: "enqueue", Task: task}); err != nil {
+			q.mu.Unlock()
+			return err
+		}
+	}
+	q.buf = insertByPriority(q.buf, task)
 	q.mu.Unlock()
 	select {
 	case q.sig <- struct{}{}:
@@ -42,8 +62,16 @@ func (q *MemQueue) Dequeue(ctx context.Context) (*domain.Task, error) {
 		q.mu.Lock()
 		if len(q.buf) > 0 {
 			t := q.buf[0]
+			if err := q.journalDequeue(t); err != nil {
+				q.mu.Unlock()
+				return nil, err
+			}
 			q.buf = q.buf[1:]
 			remaining := len(q.buf)
+			if remaining == 0 && q.drained != nil {
+				close(q.drained)
+				q.drained = nil
+			}
 			q.mu.Unlock()
 			// Re-signal so other waiting callers can wake up when tasks remain.
 			if remaining > 0 {
@@ -70,3 +98,65 @@ func (q *MemQueue) Len(_ context.Context) (int, error) {
 	q.mu.Unlock()
 	return n, nil
 }
+
+// Drain stops the queue accepting tasks and waits until workers have taken
+// every task already in it, or ctx is done, in which case it returns ctx's
+// error. Enqueue fails with domain.ErrDraining from then on, including for
+// tasks a worker puts back to retry, so drain a queue only once its
+// producers have stopped. Draining cannot be undone.
+func (q *MemQueue) Drain(ctx context.Context) error {
+	q.mu.Lock()
+	q.draining = true
+	if len(q.buf) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	if q.drained == nil {
+		q.drained = make(chan struct{})
+	}
+	drained := q.drained
+	q.mu.Unlock()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// insertByPriority inserts t into buf, which is in dequeue order, after the
+// last task whose priority is at least t's.
+func insertByPriority(buf []*domain.Task, t *domain.Task) []*domain.Task {
+	i := sort.Search(len(buf), func(i int) bool { return buf[i].Priority < t.Priority })
+	return slices.Insert(buf, i, t)
+}
+
+// journalDequeue records taking t off the head of the queue, compacting the
+// journal once enough dequeues have built up. q.mu must be held.
+func (q *MemQueue) journalDequeue(t *domain.Task) error {
+	if q.journal == nil {
+		return nil
+	}
+	if err := q.journal.append(journalRecord{Op: "dequeue", ID: t.ID}); err != nil {
+		return err
+	}
+	if q.journal.due(len(q.buf) - 1) {
+		if err := q.journal.compact(q.buf[1:]); err != nil {
+			log.Printf("MemQueue: %v", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the queue's journal, if it has one. The queue must not be
+// used afterwards.
+func (q *MemQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.journal == nil || q.journal.f == nil {
+		return nil
+	}
+	err := q.journal.f.Close()
+	q.journal.f = nil
+	return err
+}
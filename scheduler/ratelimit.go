@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// GlobalRateLimit is the SubmitLimiter key whose limit applies to every
+// submission, on top of any limit of the task's own Name.
+const GlobalRateLimit = "*"
+
+// RateLimit caps how fast tasks are submitted: Rate per second on average,
+// in bursts of up to Burst. A Burst of zero allows one second's worth.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitError is returned by Submit for a task over its rate limit. It
+// wraps domain.ErrRateLimited.
+type RateLimitError struct {
+	Key        string        // the limit exceeded: GlobalRateLimit or the task's Name
+	RetryAfter time.Duration // how long until the submission would be admitted
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: %s: retry in %s", domain.ErrRateLimited, e.Key, e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error { return domain.ErrRateLimited }
+
+// SubmitLimiter throttles task submissions with a token bucket per task
+// Name that has a limit, and one for GlobalRateLimit shared by every task.
+// It is safe for concurrent use.
+type SubmitLimiter struct {
+	limits map[string]RateLimit
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// NewSubmitLimiter returns a SubmitLimiter applying limits, keyed by task
+// Name; the GlobalRateLimit entry, if any, applies to every task.
+func NewSubmitLimiter(limits map[string]RateLimit) *SubmitLimiter {
+	l := &SubmitLimiter{limits: make(map[string]RateLimit, len(limits)), buckets: make(map[string]*tokenBucket)}
+	for key, lim := range limits {
+		if lim.Burst <= 0 {
+			lim.Burst = int(math.Max(1, math.Ceil(lim.Rate)))
+		}
+		l.limits[key] = lim
+	}
+	return l
+}
+
+// Allow takes a token for a task named name at now, or returns a
+// *RateLimitError if the global or the name's bucket is empty. A rejected
+// submission takes no token from either.
+func (l *SubmitLimiter) Allow(name string, now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var take []*tokenBucket
+	for _, key := range []string{GlobalRateLimit, name} {
+		lim, ok := l.limits[key]
+		if !ok || lim.Rate <= 0 {
+			continue
+		}
+		b := l.buckets[key]
+		if b == nil {
+			b = &tokenBucket{tokens: float64(lim.Burst), updated: now}
+			l.buckets[key] = b
+		}
+		if wait := b.wait(lim, now); wait > 0 {
+			return &RateLimitError{Key: key, RetryAfter: wait}
+		}
+		take = append(take, b)
+	}
+	for _, b := range take {
+		b.tokens--
+	}
+	return nil
+}
+
+// tokenBucket holds up to a RateLimit's Burst tokens, refilled at its Rate.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// wait refills b up to now and returns how long until it holds a token;
+// zero if it does.
+func (b *tokenBucket) wait(lim RateLimit, now time.Time) time.Duration {
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(float64(lim.Burst), b.tokens+elapsed.Seconds()*lim.Rate)
+		b.updated = now
+	}
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / lim.Rate * float64(time.Second))
+}
+
+// ParseRateLimits parses a rate limit specification such as
+// "*=100,send-email=5:20": per task Name, or GlobalRateLimit for every
+// task, a rate per second and optionally a burst.
+func ParseRateLimits(spec string) (map[string]RateLimit, error) {
+	out := make(map[string]RateLimit)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, v, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("rate limit %q: expected name=rate[:burst]", part)
+		}
+		rate, burst, hasBurst := strings.Cut(strings.TrimSpace(v), ":")
+		var lim RateLimit
+		var err error
+		if lim.Rate, err = strconv.ParseFloat(rate, 64); err != nil || lim.Rate <= 0 {
+			return nil, fmt.Errorf("rate limit %q: rate must be a positive number per second", part)
+		}
+		if hasBurst {
+			if lim.Burst, err = strconv.Atoi(burst); err != nil || lim.Burst <= 0 {
+				return nil, fmt.Errorf("rate limit %q: burst must be a positive integer", part)
+			}
+		}
+		out[strings.TrimSpace(name)] = lim
+	}
+	return out, nil
+}
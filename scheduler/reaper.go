@@ -0,0 +1,219 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// DefaultReapInterval is how often WorkerReaper.Run looks for stale workers.
+const DefaultReapInterval = domain.DefaultHeartbeatInterval
+
+// WorkerReaper marks offline the idle and busy workers that have gone
+// longer than its timeout without a heartbeat. FindAvailable matches idle
+// and busy workers only, so a worker that crashed or lost its connection
+// stops being picked for tasks; its next heartbeat, if it comes, brings it
+// back. WithOrphanedTasks also has it recover the tasks such workers left
+// running.
+type WorkerReaper struct {
+	workers  domain.WorkerRepository
+	timeout  time.Duration
+	clock    clock.Clock
+	interval time.Duration
+
+	tasks  domain.TaskRepository
+	queue  domain.Queue
+	orphan OrphanPolicy
+}
+
+// OrphanPolicy decides what WorkerReaper does with a task left running by a
+// worker that stopped heartbeating.
+type OrphanPolicy string
+
+const (
+	// OrphanRetry counts the lost attempt as a failed one: the task is
+	// queued again while it has retries left and fails otherwise, so a task
+	// that keeps bringing its workers down does not run forever.
+	OrphanRetry OrphanPolicy = "retry"
+	// OrphanRequeue queues the task again without using up a retry.
+	OrphanRequeue OrphanPolicy = "requeue"
+	// OrphanFail fails the task.
+	OrphanFail OrphanPolicy = "fail"
+	// OrphanIgnore leaves the task running, for its worker to finish
+	// should it come back.
+	OrphanIgnore OrphanPolicy = "ignore"
+)
+
+// Valid reports whether p is a known policy.
+func (p OrphanPolicy) Valid() bool {
+	return p == OrphanRetry || p == OrphanRequeue || p == OrphanFail || p == OrphanIgnore
+}
+
+// ReaperOption is a functional option for configuring a WorkerReaper.
+type ReaperOption func(*WorkerReaper)
+
+// WithReaperClock sets the clock heartbeat ages are measured against. The
+// default is clock.Real.
+func WithReaperClock(c clock.Clock) ReaperOption {
+	return func(r *WorkerReaper) { r.clock = c }
+}
+
+// WithReapInterval sets how often Run looks for stale workers. The default
+// is DefaultReapInterval.
+func WithReapInterval(d time.Duration) ReaperOption {
+	return func(r *WorkerReaper) { r.interval = d }
+}
+
+// WithOrphanedTasks makes the reaper also look, on each pass, for tasks in
+// tasks that are running on a worker that is unknown or has gone longer
+// than the timeout without a heartbeat, and apply policy to them. Tasks
+// queued again are enqueued on queue. An empty policy means OrphanRetry;
+// OrphanIgnore turns the search off.
+func WithOrphanedTasks(tasks domain.TaskRepository, queue domain.Queue, policy OrphanPolicy) ReaperOption {
+	if policy == "" {
+		policy = OrphanRetry
+	}
+	if policy == OrphanIgnore {
+		tasks, queue = nil, nil
+	}
+	return func(r *WorkerReaper) { r.tasks, r.queue, r.orphan = tasks, queue, policy }
+}
+
+// NewWorkerReaper creates a WorkerReaper that takes workers silent for
+// longer than timeout offline; a timeout of zero means
+// domain.DefaultHeartbeatTimeout.
+func NewWorkerReaper(workers domain.WorkerRepository, timeout time.Duration, opts ...ReaperOption) *WorkerReaper {
+	if timeout <= 0 {
+		timeout = domain.DefaultHeartbeatTimeout
+	}
+	r := &WorkerReaper{
+		workers:  workers,
+		timeout:  timeout,
+		clock:    clock.Real,
+		interval: DefaultReapInterval,
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// Run calls Reap at the configured interval until ctx is cancelled.
+func (r *WorkerReaper) Run(ctx context.Context) error {
+	ticker := r.clock.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+			if _, err := r.Reap(ctx); err != nil {
+				log.Printf("WorkerReaper: %v", err)
+			}
+			if _, err := r.RecoverOrphans(ctx); err != nil {
+				log.Printf("WorkerReaper: %v", err)
+			}
+		}
+	}
+}
+
+// Reap marks every stale idle or busy worker offline once and returns
+// their IDs.
+func (r *WorkerReaper) Reap(ctx context.Context) ([]string, error) {
+	candidates, err := r.workers.FindAvailable(ctx, domain.WorkerFilter{IgnoreCapacity: true})
+	if err != nil {
+		return nil, fmt.Errorf("list workers: %w", err)
+	}
+	now := r.clock.Now()
+	var reaped []string
+	for _, w := range candidates {
+		silent := now.Sub(w.LastHeartAt)
+		if silent <= r.timeout {
+			continue
+		}
+		w.Status = domain.WorkerStatusOffline
+		if err := r.workers.Save(ctx, w); err != nil {
+			return reaped, fmt.Errorf("mark worker %s offline: %w", w.ID, err)
+		}
+		log.Printf("WorkerReaper: worker %s offline, no heartbeat for %s", w.ID, silent.Truncate(time.Second))
+		reaped = append(reaped, w.ID)
+	}
+	return reaped, nil
+}
+
+// RecoverOrphans applies the WithOrphanedTasks policy once to every task
+// running on a worker that is unknown or silent for longer than the timeout,
+// and returns their IDs. Without WithOrphanedTasks it does nothing.
+func (r *WorkerReaper) RecoverOrphans(ctx context.Context) ([]string, error) {
+	if r.tasks == nil {
+		return nil, nil
+	}
+	running, err := r.tasks.FindByStatus(ctx, domain.TaskStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("list running tasks: %w", err)
+	}
+	now := r.clock.Now()
+	stale := make(map[string]bool)
+	var recovered []string
+	for _, t := range running {
+		if t.WorkerID == "" {
+			continue
+		}
+		gone, seen := stale[t.WorkerID]
+		if !seen {
+			w, err := r.workers.FindByID(ctx, t.WorkerID)
+			switch {
+			case errors.Is(err, domain.ErrWorkerNotFound):
+				gone = true
+			case err != nil:
+				return recovered, fmt.Errorf("find worker %s: %w", t.WorkerID, err)
+			default:
+				gone = now.Sub(w.LastHeartAt) > r.timeout
+			}
+			stale[t.WorkerID] = gone
+		}
+		if !gone {
+			continue
+		}
+		if err := r.recover(ctx, t, now); err != nil {
+			return recovered, err
+		}
+		recovered = append(recovered, t.ID)
+	}
+	return recovered, nil
+}
+
+// recover applies the orphan policy to t, whose worker is gone.
+func (r *WorkerReaper) recover(ctx context.Context, t *domain.Task, now time.Time) error {
+	lost := t.WorkerID
+	t.Error = fmt.Sprintf("worker %s stopped heartbeating while running the task", lost)
+	t.UpdatedAt = now
+	requeue := r.orphan == OrphanRequeue || (r.orphan == OrphanRetry && t.CanRetry())
+	if !requeue {
+		t.Status = domain.TaskStatusFailed
+		t.FinishedAt = &now
+		if err := r.tasks.Save(ctx, t); err != nil {
+			return fmt.Errorf("fail orphaned task %s: %w", t.ID, err)
+		}
+		log.Printf("WorkerReaper: task %s failed, worker %s stopped heartbeating", t.ID, lost)
+		return nil
+	}
+	if r.orphan == OrphanRetry {
+		t.RetryCount++
+	}
+	t.Status = domain.TaskStatusQueued
+	t.WorkerID, t.DispatchedAt, t.StartedAt = "", nil, nil
+	if err := r.tasks.Save(ctx, t); err != nil {
+		return fmt.Errorf("requeue orphaned task %s: %w", t.ID, err)
+	}
+	if err := r.queue.Enqueue(ctx, t); err != nil {
+		return fmt.Errorf("requeue orphaned task %s: %w", t.ID, err)
+	}
+	log.Printf("WorkerReaper: task %s queued again, worker %s stopped heartbeating", t.ID, lost)
+	return nil
+}
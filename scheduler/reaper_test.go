@@ -0,0 +1,121 @@
+package scheduler_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+func TestWorkerReaper_MarksStaleWorkersOffline(t *testing.T) {
+	fc := clock.NewFake(time.Now())
+	workers := scheduler.NewMemWorkerRepo()
+	for _, w := range []*domain.Worker{
+		{ID: "fresh", Status: domain.WorkerStatusBusy, Concurrency: 2, ActiveTasks: 1, LastHeartAt: fc.Now().Add(-10 * time.Second)},
+		{ID: "silent", Status: domain.WorkerStatusIdle, Concurrency: 2, LastHeartAt: fc.Now().Add(-2 * time.Minute)},
+		{ID: "drained", Status: domain.WorkerStatusDrained, Concurrency: 2, LastHeartAt: fc.Now().Add(-time.Hour)},
+	} {
+		_ = workers.Save(ctx, w)
+	}
+
+	reaper := scheduler.NewWorkerReaper(workers, time.Minute, scheduler.WithReaperClock(fc))
+	reaped, err := reaper.Reap(ctx)
+	if err != nil {
+		t.Fatalf("Reap: %v", err)
+	}
+	if got := strings.Join(reaped, ","); got != "silent" {
+		t.Errorf("reaped: got %q, want silent", got)
+	}
+	want := map[string]domain.WorkerStatus{
+		"fresh":   domain.WorkerStatusBusy,
+		"silent":  domain.WorkerStatusOffline,
+		"drained": domain.WorkerStatusDrained,
+	}
+	for id, status := range want {
+		if w, _ := workers.FindByID(ctx, id); w.Status != status {
+			t.Errorf("%s: got %q, want %q", id, w.Status, status)
+		}
+	}
+	if avail, _ := workers.FindAvailable(ctx, domain.WorkerFilter{}); len(avail) != 1 || avail[0].ID != "fresh" {
+		t.Errorf("FindAvailable after reaping: got %d workers, want only fresh", len(avail))
+	}
+
+	fc.Advance(time.Minute)
+	if reaped, _ := reaper.Reap(ctx); strings.Join(reaped, ",") != "fresh" {
+		t.Errorf("a minute later: reaped %v, want fresh", reaped)
+	}
+}
+
+func TestWorkerReaper_RecoversOrphanedTasks(t *testing.T) {
+	fc := clock.NewFake(time.Now())
+	workers := scheduler.NewMemWorkerRepo()
+	_ = workers.Save(ctx, &domain.Worker{ID: "alive", Status: domain.WorkerStatusBusy, Concurrency: 4, LastHeartAt: fc.Now()})
+	_ = workers.Save(ctx, &domain.Worker{ID: "dead", Status: domain.WorkerStatusBusy, Concurrency: 4, LastHeartAt: fc.Now().Add(-time.Hour)})
+
+	running := func(id, worker string, retries int) *domain.Task {
+		task := validTask(id)
+		task.Status = domain.TaskStatusRunning
+		task.WorkerID = worker
+		task.MaxRetries = retries
+		started := fc.Now()
+		task.StartedAt, task.DispatchedAt = &started, &started
+		return task
+	}
+	for name, policy := range map[string]scheduler.OrphanPolicy{"retry": scheduler.OrphanRetry, "requeue": scheduler.OrphanRequeue, "fail": scheduler.OrphanFail} {
+		t.Run(name, func(t *testing.T) {
+			tasks := scheduler.NewMemTaskRepo()
+			q := scheduler.NewMemQueue()
+			for _, task := range []*domain.Task{
+				running("healthy", "alive", 1),
+				running("retries-left", "dead", 1),
+				running("no-retries", "dead", 0),
+				running("unknown-worker", "gone", 1),
+			} {
+				_ = tasks.Save(ctx, task)
+			}
+			reaper := scheduler.NewWorkerReaper(workers, time.Minute, scheduler.WithReaperClock(fc),
+				scheduler.WithOrphanedTasks(tasks, q, policy))
+			recovered, err := reaper.RecoverOrphans(ctx)
+			if err != nil {
+				t.Fatalf("RecoverOrphans: %v", err)
+			}
+			if len(recovered) != 3 {
+				t.Errorf("recovered %v, want every task but healthy", recovered)
+			}
+
+			want := map[scheduler.OrphanPolicy]map[string]domain.TaskStatus{
+				scheduler.OrphanRetry:   {"retries-left": domain.TaskStatusQueued, "no-retries": domain.TaskStatusFailed, "unknown-worker": domain.TaskStatusQueued},
+				scheduler.OrphanRequeue: {"retries-left": domain.TaskStatusQueued, "no-retries": domain.TaskStatusQueued, "unknown-worker": domain.TaskStatusQueued},
+				scheduler.OrphanFail:    {"retries-left": domain.TaskStatusFailed, "no-retries": domain.TaskStatusFailed, "unknown-worker": domain.TaskStatusFailed},
+			}[policy]
+			want["healthy"] = domain.TaskStatusRunning
+			queued := 0
+			for id, status := range want {
+				got, _ := tasks.FindByID(ctx, id)
+				if got.Status != status {
+					t.Errorf("%s: got %q, want %q", id, got.Status, status)
+				}
+				if status == domain.TaskStatusQueued {
+					queued++
+					if got.WorkerID != "" || got.Error == "" {
+						t.Errorf("%s requeued with worker %q, error %q", id, got.WorkerID, got.Error)
+					}
+				}
+			}
+			if n, _ := q.Len(ctx); n != queued {
+				t.Errorf("queue holds %d tasks, want %d", n, queued)
+			}
+			if got, _ := tasks.FindByID(ctx, "retries-left"); policy == scheduler.OrphanRetry && got.RetryCount != 1 {
+				t.Errorf("retry policy: retry count %d, want the lost attempt counted", got.RetryCount)
+			}
+
+			// Recovered tasks are no longer running, so a second pass finds none.
+			if again, _ := reaper.RecoverOrphans(ctx); len(again) != 0 {
+				t.Errorf("second pass recovered %v", again)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository"
+)
+
+// DefaultRetentionInterval is how often Retention.Run enforces the
+// workflows' retention policies.
+const DefaultRetentionInterval = time.Hour
+
+// Retention deletes the finished runs that fall outside their workflow's
+// RetainRuns and RetainDays limits. Workflows without limits keep their
+// whole history.
+type Retention struct {
+	workflows repository.WorkflowRepository
+	retention repository.RetentionRepository
+}
+
+// NewRetention creates a Retention backed by the supplied repositories.
+func NewRetention(workflows repository.WorkflowRepository, retention repository.RetentionRepository) *Retention {
+	return &Retention{workflows: workflows, retention: retention}
+}
+
+// Run calls Enforce every DefaultRetentionInterval until ctx is cancelled.
+func (r *Retention) Run(ctx context.Context) error {
+	ticker := time.NewTicker(DefaultRetentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			counts, err := r.Enforce(ctx)
+			if err != nil {
+				log.Printf("Retention: enforce: %v", err)
+			}
+			if counts.WorkflowRuns > 0 {
+				log.Printf("Retention: deleted %d workflow runs and %d task runs", counts.WorkflowRuns, counts.TaskRuns)
+			}
+		}
+	}
+}
+
+// Enforce applies every workflow's retention policy once and returns the
+// total number of records deleted.
+func (r *Retention) Enforce(ctx context.Context) (domain.PurgeCounts, error) {
+	var total domain.PurgeCounts
+	wfs, err := r.workflows.List(ctx)
+	if err != nil {
+		return total, fmt.Errorf("list workflows: %w", err)
+	}
+	now := time.Now()
+	for _, wf := range wfs {
+		counts, err := r.apply(ctx, wf, now)
+		if err != nil {
+			log.Printf("Retention: workflow %s: %v", wf.ID, err)
+		}
+		total.WorkflowRuns += counts.WorkflowRuns
+		total.TaskRuns += counts.TaskRuns
+	}
+	return total, nil
+}
+
+// apply deletes wf's finished runs older than RetainDays and those beyond
+// the newest RetainRuns.
+func (r *Retention) apply(ctx context.Context, wf *domain.Workflow, now time.Time) (domain.PurgeCounts, error) {
+	var filters []repository.RunPurgeFilter
+	if wf.RetainDays > 0 {
+		filters = append(filters, repository.RunPurgeFilter{Before: now.AddDate(0, 0, -wf.RetainDays)})
+	}
+	if wf.RetainRuns > 0 {
+		filters = append(filters, repository.RunPurgeFilter{KeepNewest: wf.RetainRuns})
+	}
+	var total domain.PurgeCounts
+	for _, f := range filters {
+		f.WorkflowID, f.Statuses = wf.ID, domain.TerminalStatuses()
+		counts, err := r.retention.PurgeRuns(ctx, f, false)
+		if err != nil {
+			return total, err
+		}
+		total.WorkflowRuns += counts.WorkflowRuns
+		total.TaskRuns += counts.TaskRuns
+	}
+	return total, nil
+}
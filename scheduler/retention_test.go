@@ -0,0 +1,65 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
+	"github.com/sauravritesh63/GoLang-Project-/scheduler"
+)
+
+func TestRetention_EnforcesPerWorkflowPolicies(t *testing.T) {
+	wfRepo := mock.NewWorkflowRepo()
+	runRepo, taskRunRepo := mock.NewWorkflowRunRepo(), mock.NewTaskRunRepo()
+	byCount := &idomain.Workflow{ID: uuid.New(), Name: "noisy", RetainRuns: 2}
+	byAge := &idomain.Workflow{ID: uuid.New(), Name: "monitor", RetainDays: 7}
+	keepAll := &idomain.Workflow{ID: uuid.New(), Name: "audit"}
+	for _, wf := range []*idomain.Workflow{byCount, byAge, keepAll} {
+		_ = wfRepo.Create(ctx, wf)
+	}
+
+	// Each workflow has four finished runs started 1, 5, 10 and 20 days ago
+	// and one old run still running.
+	now := time.Now()
+	runs := make(map[uuid.UUID][]*idomain.WorkflowRun)
+	for _, wf := range []*idomain.Workflow{byCount, byAge, keepAll} {
+		for _, days := range []int{1, 5, 10, 20} {
+			wr := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: idomain.StatusSuccess, StartedAt: now.AddDate(0, 0, -days)}
+			_ = runRepo.Create(ctx, wr)
+			_ = taskRunRepo.Create(ctx, &idomain.TaskRun{ID: uuid.New(), WorkflowRunID: wr.ID, TaskID: uuid.New(), Status: idomain.StatusSuccess})
+			runs[wf.ID] = append(runs[wf.ID], wr)
+		}
+		_ = runRepo.Create(ctx, &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: wf.ID, Status: idomain.StatusRunning, StartedAt: now.AddDate(0, 0, -30)})
+	}
+
+	ret := scheduler.NewRetention(wfRepo, mock.NewRetentionRepo(runRepo, taskRunRepo))
+	counts, err := ret.Enforce(ctx)
+	if err != nil {
+		t.Fatalf("Enforce: %v", err)
+	}
+	if counts != (idomain.PurgeCounts{WorkflowRuns: 4, TaskRuns: 4}) {
+		t.Errorf("counts = %+v, want 4 runs and 4 task runs", counts)
+	}
+
+	for wf, want := range map[*idomain.Workflow]int{byCount: 3, byAge: 3, keepAll: 5} {
+		if left, _ := runRepo.ListByWorkflowID(ctx, wf.ID); len(left) != want {
+			t.Errorf("%s: %d runs left, want %d", wf.Name, len(left), want)
+		}
+	}
+	// The noisy workflow keeps its two newest finished runs; the monitor
+	// keeps those started within 7 days.
+	for _, wf := range []*idomain.Workflow{byCount, byAge} {
+		for i, wr := range runs[wf.ID] {
+			_, err := runRepo.GetByID(ctx, wr.ID)
+			if kept := i < 2; kept != (err == nil) {
+				t.Errorf("%s: run %d kept = %v, want %v", wf.Name, i, err == nil, kept)
+			}
+		}
+	}
+
+	if counts, _ = ret.Enforce(ctx); counts.WorkflowRuns != 0 {
+		t.Errorf("second pass deleted %d runs, want 0", counts.WorkflowRuns)
+	}
+}
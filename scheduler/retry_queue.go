@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// retryEntry is a task waiting in a MemRetryQueue until at.
+type retryEntry struct {
+	task *domain.Task
+	at   time.Time
+	seq  uint64 // scheduling order, breaking ties between equal times
+}
+
+// retryHeap is a min-heap of retry entries ordered by due time, then
+// scheduling order. It implements heap.Interface.
+type retryHeap []retryEntry
+
+func (h retryHeap) Len() int { return len(h) }
+
+func (h retryHeap) Less(i, j int) bool {
+	if !h[i].at.Equal(h[j].at) {
+		return h[i].at.Before(h[j].at)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h retryHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *retryHeap) Push(x any) { *h = append(*h, x.(retryEntry)) }
+
+func (h *retryHeap) Pop() any {
+	old := *h
+	n := len(old) - 1
+	x := old[n]
+	old[n] = retryEntry{}
+	*h = old[:n]
+	return x
+}
+
+// MemRetryQueue is an in-memory domain.RetryQueue that releases its tasks
+// onto a domain.Queue. It is safe for concurrent use.
+type MemRetryQueue struct {
+	queue domain.Queue
+
+	mu      sync.Mutex
+	waiting retryHeap
+	seq     uint64
+}
+
+// NewMemRetryQueue creates an empty MemRetryQueue releasing onto q.
+func NewMemRetryQueue(q domain.Queue) *MemRetryQueue {
+	return &MemRetryQueue{queue: q}
+}
+
+// Schedule holds task until at.
+func (r *MemRetryQueue) Schedule(_ context.Context, task *domain.Task, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	heap.Push(&r.waiting, retryEntry{task: task, at: at, seq: r.seq})
+	return nil
+}
+
+// Release enqueues up to limit tasks due at or before now, earliest first.
+// A task the queue refuses goes back to wait with its due time unchanged,
+// and Release stops there.
+func (r *MemRetryQueue) Release(ctx context.Context, now time.Time, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for len(r.waiting) > 0 && !r.waiting[0].at.After(now) && (limit <= 0 || n < limit) {
+		e := heap.Pop(&r.waiting).(retryEntry)
+		if err := r.queue.Enqueue(ctx, e.task); err != nil {
+			heap.Push(&r.waiting, e)
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Len returns the number of tasks waiting.
+func (r *MemRetryQueue) Len(_ context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.waiting), nil
+}
@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	qdomain "github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+)
+
+// AlertRunTimeout prefixes the name of the alert raised for a run of a
+// workflow with domain.RunTimeoutAlert that overruns its timeout. The full
+// name is RunTimeoutAlertName of the run.
+const AlertRunTimeout = "run_timeout"
+
+// RunTimeoutAlertName returns the name of the timeout alert of run runID.
+func RunTimeoutAlertName(runID uuid.UUID) string {
+	return AlertRunTimeout + ":" + runID.String()
+}
+
+// timeOut applies policy, domain.RunTimeoutFail or
+// domain.RunTimeoutCancelDownstream, to run, which is past its deadline:
+// the tasks yet to start are skipped and, when failing, the running ones
+// are cancelled and failed. states is updated to match.
+func (o *Orchestrator) timeOut(ctx context.Context, run *domain.WorkflowRun, tasks []*domain.Task, states map[uuid.UUID]domain.Status, policy domain.RunTimeoutPolicy, now time.Time) error {
+	trs, err := o.taskRuns.ListByWorkflowRunID(ctx, run.ID)
+	if err != nil {
+		return fmt.Errorf("list task runs: %w", err)
+	}
+	latest := make(map[uuid.UUID]*domain.TaskRun, len(trs))
+	for _, tr := range trs {
+		if prev := latest[tr.TaskID]; prev == nil || tr.Attempt >= prev.Attempt {
+			latest[tr.TaskID] = tr
+		}
+	}
+	acted := false
+	for _, t := range tasks {
+		tr := latest[t.ID]
+		switch s := states[t.ID]; {
+		case s.IsTerminal():
+			continue
+		case s == domain.StatusRunning && tr != nil:
+			if policy == domain.RunTimeoutCancelDownstream {
+				continue
+			}
+			if err := o.sched.Cancel(ctx, tr.ID.String()); err != nil && !errors.Is(err, qdomain.ErrTaskNotFound) {
+				return fmt.Errorf("cancel task run %s: %w", tr.ID, err)
+			}
+			if err := o.taskRuns.UpdateStatus(ctx, tr.ID, domain.StatusFailed, &now); err != nil {
+				return fmt.Errorf("fail task run %s: %w", tr.ID, err)
+			}
+			tr.Status, tr.FinishedAt = domain.StatusFailed, &now
+			o.publish(ctx, events.TaskStatus, *tr)
+			states[t.ID] = domain.StatusFailed
+		default:
+			if err := o.skip(ctx, run.ID, t.ID, tr, now); err != nil {
+				return err
+			}
+			states[t.ID] = domain.StatusSkipped
+		}
+		acted = true
+	}
+	if acted {
+		log.Printf("Orchestrator: workflow run %s: timed out after %s (%s)", run.ID, now.Sub(run.StartedAt).Truncate(time.Second), policy)
+	}
+	return nil
+}
+
+// alertTimeout raises the timeout alert of run, unless it is already
+// firing.
+func (o *Orchestrator) alertTimeout(ctx context.Context, run *domain.WorkflowRun, wf *domain.Workflow, now time.Time) {
+	o.mu.Lock()
+	firing := o.overdue[run.ID]
+	o.overdue[run.ID] = true
+	o.mu.Unlock()
+	if firing {
+		return
+	}
+	elapsed := now.Sub(run.StartedAt).Truncate(time.Second)
+	limit := time.Duration(wf.RunTimeoutSeconds) * time.Second
+	log.Printf("Orchestrator: workflow run %s: running for %s, timeout %s", run.ID, elapsed, limit)
+	o.publish(ctx, events.Alert, events.AlertUpdate{
+		Name:      RunTimeoutAlertName(run.ID),
+		Firing:    true,
+		Value:     elapsed.Seconds(),
+		Threshold: limit.Seconds(),
+		Message:   fmt.Sprintf("workflow %s run %s running for %s, timeout %s", wf.Name, run.ID, elapsed, limit),
+		At:        now,
+	})
+}
+
+// resolveTimeout resolves the timeout alert of run, which has finished.
+// It is published even if this Orchestrator did not raise the alert, so
+// one raised before a restart does not fire forever.
+func (o *Orchestrator) resolveTimeout(ctx context.Context, run *domain.WorkflowRun, wf *domain.Workflow, now time.Time) {
+	o.mu.Lock()
+	delete(o.overdue, run.ID)
+	o.mu.Unlock()
+	o.publish(ctx, events.Alert, events.AlertUpdate{
+		Name:      RunTimeoutAlertName(run.ID),
+		Value:     now.Sub(run.StartedAt).Seconds(),
+		Threshold: float64(wf.RunTimeoutSeconds),
+		Message:   fmt.Sprintf("workflow %s run %s finished %s", wf.Name, run.ID, run.Status),
+		At:        now,
+	})
+}
@@ -3,17 +3,132 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"log"
+	"sync"
 	"time"
 
+	"github.com/sauravritesh63/GoLang-Project-/clock"
 	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
 )
 
 // Scheduler implements domain.Scheduler. It validates and enqueues tasks,
 // tracks their status via the TaskRepository, and supports cancellation.
+//
+// Tasks that reference a resource pool with no free slot, that would exceed
+// a concurrency limit, or whose concurrency key is held by another
+// in-flight task, are held back in
+// TaskStatusPending and dispatched by Reconcile once the resource frees up.
+// The same applies while a circuit breaker has paused the task's name or
+// workflow, and to every task while the Scheduler itself is paused.
+//
+// A task whose ScheduledAt is in the future is persisted as Pending and held
+// in a min-heap until then; tasks due at the same time are dispatched by
+// Priority, then in submission order.
 type Scheduler struct {
 	tasks   domain.TaskRepository
 	workers domain.WorkerRepository
 	queue   domain.Queue
+
+	pools            *Pools
+	limits           *ConcurrencyLimits
+	breaker          *CircuitBreaker
+	limiter          *SubmitLimiter
+	dispatchInterval time.Duration
+	clock            clock.Clock
+	events           events.Publisher
+
+	// workerQueues holds the queue of each worker, for tasks placed on one
+	// worker by their RoutingKey (pinKeys) or by its free capacity
+	// (assign), among the workers that heartbeated within liveWithin,
+	// domain.DefaultHeartbeatTimeout unless configured.
+	workerQueues *GroupQueues
+	liveWithin   time.Duration
+	pinKeys      bool
+	assign       bool
+
+	mu       sync.Mutex
+	held     []*domain.Task          // FIFO of tasks waiting for resources
+	inflight map[string]*domain.Task // dispatched tasks tracked until terminal
+	keys     map[string]string       // concurrency key → holding task ID
+
+	// idemWindow is how long an idempotency key stays claimed.
+	idemWindow time.Duration
+	// dedupMode is what makes submitted tasks duplicates; see WithDedupMode.
+	dedupMode DedupMode
+
+	// delayed holds tasks until their ScheduledAt; wakeTimer signals wake
+	// when the earliest of them, due at wakeAt, comes due.
+	delayed   delayHeap
+	delaySeq  uint64
+	wake      chan struct{}
+	wakeTimer clock.Timer
+	wakeAt    time.Time
+
+	// pausedAt is when Pause stopped dispatching; zero while dispatching.
+	pausedAt time.Time
+	pauseObs PauseObserver
+
+	// draining is set by Drain: Submit refuses tasks and nothing more is
+	// dispatched.
+	draining bool
+
+	// Timing of the latest dispatch loop pass, reported by Inspect.
+	lastTick     time.Time
+	lastRun      time.Time
+	lastDuration time.Duration
+}
+
+// Option is a functional option for configuring a Scheduler.
+type Option func(*Scheduler)
+
+// WithPools enables resource-pool enforcement using p.
+func WithPools(p *Pools) Option {
+	return func(s *Scheduler) { s.pools = p }
+}
+
+// WithConcurrencyLimits holds back tasks while any of l's limits that
+// apply to them is full.
+func WithConcurrencyLimits(l *ConcurrencyLimits) Option {
+	return func(s *Scheduler) { s.limits = l }
+}
+
+// WithCircuitBreaker holds back tasks whose circuits in b are open and
+// records the outcome of every dispatched task in b.
+func WithCircuitBreaker(b *CircuitBreaker) Option {
+	return func(s *Scheduler) { s.breaker = b }
+}
+
+// WithRateLimits makes Submit reject tasks over l's rate limits with a
+// *RateLimitError, before the task is saved.
+func WithRateLimits(l *SubmitLimiter) Option {
+	return func(s *Scheduler) { s.limiter = l }
+}
+
+// WithTaskEvents publishes a TaskStatus event to p each time the Scheduler
+// changes a task's status: when it queues the task for a worker, holds it
+// back as pending, or cancels it.
+func WithTaskEvents(p events.Publisher) Option {
+	return func(s *Scheduler) { s.events = p }
+}
+
+// WithDispatchInterval sets how often Run reconciles in-flight tasks and
+// dispatches held ones. The default is 1 second.
+func WithDispatchInterval(d time.Duration) Option {
+	return func(s *Scheduler) { s.dispatchInterval = d }
+}
+
+// WithHeartbeatTimeout sets how long a worker may go without a heartbeat
+// and still have tasks routed to it by WithStickyRouting or
+// WithCapacityAssignment. The default is domain.DefaultHeartbeatTimeout.
+func WithHeartbeatTimeout(d time.Duration) Option {
+	return func(s *Scheduler) { s.liveWithin = d }
+}
+
+// WithClock sets the clock used for task timestamps and the dispatch loop.
+// The default is clock.Real.
+func WithClock(c clock.Clock) Option {
+	return func(s *Scheduler) { s.clock = c }
 }
 
 // New creates a Scheduler backed by the supplied repositories and queue.
@@ -21,26 +136,103 @@ func New(
 	tasks domain.TaskRepository,
 	workers domain.WorkerRepository,
 	queue domain.Queue,
+	opts ...Option,
 ) *Scheduler {
-	return &Scheduler{tasks: tasks, workers: workers, queue: queue}
+	s := &Scheduler{
+		tasks:            tasks,
+		workers:          workers,
+		queue:            queue,
+		pools:            NewPools(nil),
+		dispatchInterval: time.Second,
+		liveWithin:       domain.DefaultHeartbeatTimeout,
+		clock:            clock.Real,
+		events:           events.Discard,
+		inflight:         make(map[string]*domain.Task),
+		keys:             make(map[string]string),
+		wake:             make(chan struct{}, 1),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	if _, ok := tasks.(domain.IdempotencyStore); s.idemWindow > 0 && !ok {
+		log.Printf("Scheduler: task repository %T cannot claim keys; the idempotency window is ignored", tasks)
+	}
+	return s
 }
 
 // Submit validates task, transitions it to Queued, persists it, and enqueues
 // it for execution. Returns domain.ErrTaskInvalid (wrapped) if validation fails.
+// A task whose resources are exhausted is persisted as Pending and held back
+// until Reconcile can dispatch it; so is a task scheduled for later, until
+// its ScheduledAt. With WithRateLimits, a task over its rate limit is
+// rejected with a *RateLimitError wrapping domain.ErrRateLimited; with
+// WithIdempotencyWindow, a repeated IdempotencyKey is rejected with a
+// *DuplicateTaskError wrapping domain.ErrDuplicateTask. A task past its ExpiresAt
+// is expired rather than queued, and one the queue refuses is held back
+// like one whose resources are exhausted. Once Drain has been called, Submit returns
+// domain.ErrDraining.
 func (s *Scheduler) Submit(ctx context.Context, task *domain.Task) error {
 	if err := task.Validate(); err != nil {
 		return fmt.Errorf("%w: %s", domain.ErrTaskInvalid, err)
 	}
-	now := time.Now()
-	task.Status = domain.TaskStatusQueued
+	s.mu.Lock()
+	draining := s.draining
+	s.mu.Unlock()
+	if draining {
+		return domain.ErrDraining
+	}
+	now := s.clock.Now()
+	if s.limiter != nil {
+		if err := s.limiter.Allow(task.Name, now); err != nil {
+			return err
+		}
+	}
 	task.UpdatedAt = now
 	if task.CreatedAt.IsZero() {
 		task.CreatedAt = now
 	}
-	if err := s.tasks.Save(ctx, task); err != nil {
-		return err
+	// The key is claimed once the task is through the rate limiter, so a
+	// rate-limited submission holds no key.
+	if s.idemWindow > 0 {
+		task.DedupKey = DedupKey(task, s.dedupMode)
+		if task.IdempotencyKey != "" || task.DedupKey != "" {
+			if err := s.claimKey(ctx, task, now); err != nil {
+				return err
+			}
+		}
+	}
+	if task.Expired(now) {
+		return s.expire(ctx, task)
 	}
-	return s.queue.Enqueue(ctx, task)
+
+	if task.ScheduledAt.After(now) {
+		task.Status = domain.TaskStatusPending
+		if err := s.tasks.Save(ctx, task); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.delayLocked(task)
+		s.mu.Unlock()
+		s.announce(ctx, task)
+		return nil
+	}
+
+	s.mu.Lock()
+	admitted := s.admitLocked(task)
+	if !admitted {
+		s.held = append(s.held, task)
+	}
+	s.mu.Unlock()
+
+	if !admitted {
+		task.Status = domain.TaskStatusPending
+		if err := s.tasks.Save(ctx, task); err != nil {
+			return err
+		}
+		s.announce(ctx, task)
+		return nil
+	}
+	return s.dispatch(ctx, task)
 }
 
 // Cancel marks the task as Failed if it has not yet reached a terminal state.
@@ -54,8 +246,12 @@ func (s *Scheduler) Cancel(ctx context.Context, taskID string) error {
 		return nil
 	}
 	task.Status = domain.TaskStatusFailed
-	task.UpdatedAt = time.Now()
-	return s.tasks.Save(ctx, task)
+	task.UpdatedAt = s.clock.Now()
+	if err := s.tasks.Save(ctx, task); err != nil {
+		return err
+	}
+	s.announce(ctx, task)
+	return nil
 }
 
 // Status returns the current TaskStatus for the given taskID.
@@ -66,3 +262,302 @@ func (s *Scheduler) Status(ctx context.Context, taskID string) (domain.TaskStatu
 	}
 	return task.Status, nil
 }
+
+// Run restores the Pending tasks of the TaskRepository, then calls
+// Reconcile at the configured dispatch interval, and releases delayed tasks
+// as soon as they are due, until ctx is cancelled. It always returns nil
+// when the context expires; a failed restore is logged.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if err := s.Restore(ctx); err != nil {
+		log.Printf("Scheduler: %v", err)
+	}
+	ticker := s.clock.NewTicker(s.dispatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case tick := <-ticker.C():
+			start := s.clock.Now()
+			s.Reconcile(ctx)
+			s.mu.Lock()
+			s.lastTick, s.lastRun, s.lastDuration = tick, start, s.clock.Now().Sub(start)
+			s.mu.Unlock()
+		case <-s.wake:
+			s.releaseDue(ctx)
+		}
+	}
+}
+
+// Reconcile releases the resources of in-flight tasks that have reached a
+// terminal state (or disappeared), feeds their outcomes to the circuit
+// breaker, and dispatches held tasks, oldest first, whose resources have
+// become available. Delayed tasks that have come due are released first, and
+// with WithStickyRouting or WithCapacityAssignment the tasks waiting for
+// workers that went away are routed again. Held and delayed tasks found past
+// their ExpiresAt are expired instead of dispatched. A task that cannot be
+// persisted or enqueued is logged and held back again.
+func (s *Scheduler) Reconcile(ctx context.Context) {
+	s.releaseDue(ctx)
+	if s.workerQueues != nil {
+		s.rehome(ctx)
+	}
+
+	s.mu.Lock()
+	inflight := make([]*domain.Task, 0, len(s.inflight))
+	for _, t := range s.inflight {
+		inflight = append(inflight, t)
+	}
+	s.mu.Unlock()
+
+	for _, t := range inflight {
+		stored, err := s.tasks.FindByID(ctx, t.ID)
+		if err == nil && !stored.IsTerminal() {
+			continue
+		}
+		if err == nil && s.breaker != nil {
+			s.breaker.Record(stored)
+		}
+		s.mu.Lock()
+		s.releaseLocked(t)
+		s.mu.Unlock()
+	}
+
+	// Look the held tasks up without the lock, so Submit and dispatch do
+	// not wait on a store round trip per held task.
+	s.mu.Lock()
+	held := make([]string, len(s.held))
+	for i, t := range s.held {
+		held[i] = t.ID
+	}
+	s.mu.Unlock()
+	gone := make(map[string]bool)
+	for _, id := range held {
+		if stored, err := s.tasks.FindByID(ctx, id); err != nil || stored.IsTerminal() {
+			gone[id] = true // cancelled or deleted while held
+		}
+	}
+
+	now := s.clock.Now()
+	s.mu.Lock()
+	var ready, expired []*domain.Task
+	remaining := s.held[:0]
+	for _, t := range s.held {
+		if gone[t.ID] {
+			continue
+		}
+		if t.Expired(now) {
+			expired = append(expired, t)
+		} else if s.admitLocked(t) {
+			ready = append(ready, t)
+		} else {
+			remaining = append(remaining, t)
+		}
+	}
+	s.held = remaining
+	s.mu.Unlock()
+
+	for _, t := range expired {
+		_ = s.expire(ctx, t)
+	}
+	for _, t := range ready {
+		s.redispatch(ctx, t)
+	}
+}
+
+// Held returns the number of tasks waiting for resources, an open circuit
+// or the Scheduler to resume.
+func (s *Scheduler) Held() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.held)
+}
+
+// dispatch transitions task to Queued, persists it with the worker it is
+// routed to, if any, and enqueues it. If task cannot be persisted, its
+// resources are released and the error returned. If it cannot be enqueued,
+// it is held back; see holdBack.
+func (s *Scheduler) dispatch(ctx context.Context, task *domain.Task) error {
+	q := s.route(ctx, task)
+	task.Status = domain.TaskStatusQueued
+	task.UpdatedAt = s.clock.Now()
+	if err := s.tasks.Save(ctx, task); err != nil {
+		s.mu.Lock()
+		s.releaseLocked(task)
+		s.mu.Unlock()
+		task.Status = domain.TaskStatusPending
+		return fmt.Errorf("save task %s: %w", task.ID, err)
+	}
+	// Once enqueued, task belongs to whichever worker dequeues it, so the
+	// update is taken first and task is not read again.
+	update := taskUpdate(task)
+	if err := q.Enqueue(ctx, task); err != nil {
+		s.holdBack(ctx, task, err)
+		return nil
+	}
+	s.publishUpdate(ctx, update)
+	return nil
+}
+
+// holdBack releases the resources of task, which could not be enqueued
+// because of err, and holds it back as Pending, like a task whose resources
+// are busy, for Reconcile to dispatch again.
+func (s *Scheduler) holdBack(ctx context.Context, task *domain.Task, err error) {
+	s.mu.Lock()
+	s.releaseLocked(task)
+	s.held = append(s.held, task)
+	s.mu.Unlock()
+	log.Printf("Scheduler: enqueue task %s: %v; holding it back", task.ID, err)
+	task.Status = domain.TaskStatusPending
+	task.UpdatedAt = s.clock.Now()
+	if err := s.tasks.Save(ctx, task); err != nil {
+		log.Printf("Scheduler: task %s: record it held back: %v", task.ID, err)
+		return
+	}
+	s.announce(ctx, task)
+}
+
+// redispatch dispatches t, which Reconcile or releaseDue admitted, holding
+// it back again if it cannot be persisted.
+func (s *Scheduler) redispatch(ctx context.Context, t *domain.Task) {
+	if err := s.dispatch(ctx, t); err != nil {
+		log.Printf("Scheduler: dispatch: %v; holding it back", err)
+		s.mu.Lock()
+		s.held = append(s.held, t)
+		s.mu.Unlock()
+	}
+}
+
+// expire records that task, held back or delayed until past its ExpiresAt,
+// will never run.
+func (s *Scheduler) expire(ctx context.Context, task *domain.Task) error {
+	now := s.clock.Now()
+	task.Status = domain.TaskStatusExpired
+	task.Error = fmt.Sprintf("expired at %s before it was dispatched", task.ExpiresAt.UTC().Format(time.RFC3339))
+	task.FinishedAt, task.UpdatedAt = &now, now
+	if err := s.tasks.Save(ctx, task); err != nil {
+		return err
+	}
+	s.announce(ctx, task)
+	return nil
+}
+
+// announce publishes task's current status, in the shape workers publish
+// theirs. Delivery failures are ignored; they never hold up dispatch.
+func (s *Scheduler) announce(ctx context.Context, task *domain.Task) {
+	s.publishUpdate(ctx, taskUpdate(task))
+}
+
+// publishUpdate publishes u, ignoring delivery failures like announce.
+func (s *Scheduler) publishUpdate(ctx context.Context, u events.TaskUpdate) {
+	_ = s.events.Publish(ctx, events.Event{Type: events.TaskStatus, Payload: u})
+}
+
+// taskUpdate snapshots task's current status as an events.TaskUpdate.
+func taskUpdate(task *domain.Task) events.TaskUpdate {
+	return events.TaskUpdate{
+		TaskID:     task.ID,
+		Name:       task.Name,
+		Status:     string(task.Status),
+		WorkerID:   task.WorkerID,
+		RetryCount: task.RetryCount,
+		Error:      task.Error,
+		At:         task.UpdatedAt,
+	}
+}
+
+// admitLocked acquires every resource task needs, or none of them, and
+// reports whether the task may be dispatched. Nothing is while s is paused
+// or draining. Callers must hold s.mu.
+func (s *Scheduler) admitLocked(task *domain.Task) bool {
+	if !s.pausedAt.IsZero() || s.draining {
+		return false
+	}
+	if s.breaker != nil && !s.breaker.Allow(task) {
+		return false
+	}
+	if task.Pool == "" && task.ConcurrencyKey == "" && s.breaker == nil && s.limits == nil {
+		return true
+	}
+	if holder, busy := s.keys[task.ConcurrencyKey]; busy && holder != task.ID {
+		return false
+	}
+	if !s.pools.TryAcquire(task.Pool) {
+		return false
+	}
+	if s.limits != nil && !s.limits.TryAcquire(task) {
+		s.pools.Release(task.Pool)
+		return false
+	}
+	if task.ConcurrencyKey != "" {
+		s.keys[task.ConcurrencyKey] = task.ID
+	}
+	s.inflight[task.ID] = task
+	return true
+}
+
+// releaseLocked returns the resources held by task. Callers must hold s.mu.
+func (s *Scheduler) releaseLocked(task *domain.Task) {
+	if _, ok := s.inflight[task.ID]; !ok {
+		return
+	}
+	delete(s.inflight, task.ID)
+	if s.keys[task.ConcurrencyKey] == task.ID {
+		delete(s.keys, task.ConcurrencyKey)
+	}
+	s.pools.Release(task.Pool)
+	if s.limits != nil {
+		s.limits.Release(task)
+	}
+}
+
+// Restore picks up the Pending tasks in the TaskRepository that the
+// Scheduler is not tracking, such as those an earlier process held back or
+// delayed before it stopped: tasks scheduled for later are delayed again
+// and the rest held back for Reconcile to dispatch. Run calls it when it
+// starts.
+func (s *Scheduler) Restore(ctx context.Context) error {
+	pending, err := s.tasks.FindByStatus(ctx, domain.TaskStatusPending)
+	if err != nil {
+		return fmt.Errorf("restore pending tasks: %w", err)
+	}
+	now := s.clock.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range pending {
+		switch {
+		case s.tracksLocked(t.ID):
+		case t.ScheduledAt.After(now):
+			s.delayLocked(t)
+		default:
+			s.held = append(s.held, t)
+		}
+	}
+	return nil
+}
+
+// tracks reports whether the Scheduler is holding the task with the given
+// ID back, delaying it or counting it as in flight.
+func (s *Scheduler) tracks(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracksLocked(id)
+}
+
+// tracksLocked is tracks for callers holding s.mu.
+func (s *Scheduler) tracksLocked(id string) bool {
+	if _, ok := s.inflight[id]; ok {
+		return true
+	}
+	for _, t := range s.held {
+		if t.ID == id {
+			return true
+		}
+	}
+	for _, d := range s.delayed {
+		if d.task.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -3,11 +3,18 @@ package scheduler_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/sauravritesh63/GoLang-Project-/clock"
 	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
 	"github.com/sauravritesh63/GoLang-Project-/scheduler"
 )
 
@@ -94,16 +101,17 @@ func (r *memWorkerRepo) FindByID(_ context.Context, id string) (*domain.Worker,
 	return &cp, nil
 }
 
-func (r *memWorkerRepo) FindAvailable(_ context.Context) ([]*domain.Worker, error) {
+func (r *memWorkerRepo) FindAvailable(_ context.Context, f domain.WorkerFilter) ([]*domain.Worker, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	var out []*domain.Worker
 	for _, w := range r.store {
-		if w.HasCapacity() {
+		if w.Matches(f) {
 			cp := *w
 			out = append(out, &cp)
 		}
 	}
+	domain.SortByLoad(out)
 	return out, nil
 }
 
@@ -139,6 +147,69 @@ func newScheduler() (*scheduler.Scheduler, *memTaskRepo) {
 	return scheduler.New(tr, wr, q), tr
 }
 
+// ── MemWorkerRepo tests ───────────────────────────────────────────────────────
+
+func TestMemWorkerRepo_FindAvailable(t *testing.T) {
+	r := scheduler.NewMemWorkerRepo()
+	for _, w := range []*domain.Worker{
+		{ID: "busy", Status: domain.WorkerStatusBusy, Concurrency: 4, ActiveTasks: 3, Labels: map[string]string{"gpu": "a100"}},
+		{ID: "idle", Status: domain.WorkerStatusIdle, Concurrency: 4, Labels: map[string]string{"gpu": "a100"}, Queues: []string{"ml"}},
+		{ID: "cpu", Status: domain.WorkerStatusIdle, Concurrency: 2, LastHeartAt: time.Now()},
+		{ID: "full", Status: domain.WorkerStatusBusy, Concurrency: 2, ActiveTasks: 2},
+	} {
+		_ = r.Save(ctx, w)
+	}
+
+	ids := func(f domain.WorkerFilter) string {
+		ws, err := r.FindAvailable(ctx, f)
+		if err != nil {
+			t.Fatalf("FindAvailable: %v", err)
+		}
+		var out []string
+		for _, w := range ws {
+			out = append(out, w.ID)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := ids(domain.WorkerFilter{}); got != "cpu,idle,busy" {
+		t.Errorf("no filter: got %q, want least loaded first", got)
+	}
+	if got := ids(domain.WorkerFilter{Labels: map[string]string{"gpu": "a100"}, MinFreeSlots: 2}); got != "idle" {
+		t.Errorf("gpu with 2 slots: got %q, want idle", got)
+	}
+	if got := ids(domain.WorkerFilter{Queue: "etl"}); got != "cpu,busy" {
+		t.Errorf("queue etl: got %q, want cpu,busy", got)
+	}
+	if got := ids(domain.WorkerFilter{IgnoreCapacity: true, MinFreeSlots: 1}); got != "cpu,idle,busy,full" {
+		t.Errorf("ignoring capacity: got %q, want every live worker", got)
+	}
+	if got := ids(domain.WorkerFilter{HeartbeatSince: time.Now().Add(-time.Minute)}); got != "cpu" {
+		t.Errorf("heartbeat within a minute: got %q, want cpu", got)
+	}
+}
+
+func TestMemHeartbeatRepo_ListRecent(t *testing.T) {
+	r := scheduler.NewMemHeartbeatRepo()
+	start := time.Now()
+	for i := range scheduler.DefaultHeartbeatRetention + 5 {
+		_ = r.Record(ctx, domain.Heartbeat{WorkerID: "w1", At: start.Add(time.Duration(i) * time.Second), ActiveTasks: i})
+	}
+	_ = r.Record(ctx, domain.Heartbeat{WorkerID: "w2", At: start})
+
+	got, err := r.ListRecent(ctx, "w1", 3)
+	if err != nil {
+		t.Fatalf("ListRecent: %v", err)
+	}
+	last := scheduler.DefaultHeartbeatRetention + 4
+	if len(got) != 3 || got[0].ActiveTasks != last || got[2].ActiveTasks != last-2 {
+		t.Errorf("ListRecent(3) = %+v, want the 3 latest, newest first", got)
+	}
+	all, _ := r.ListRecent(ctx, "w1", 1000)
+	if len(all) != scheduler.DefaultHeartbeatRetention {
+		t.Errorf("kept %d heartbeats, want %d", len(all), scheduler.DefaultHeartbeatRetention)
+	}
+}
+
 // ── MemQueue tests ────────────────────────────────────────────────────────────
 
 func TestMemQueue_EnqueueDequeue(t *testing.T) {
@@ -173,6 +244,31 @@ func TestMemQueue_FIFOOrder(t *testing.T) {
 	}
 }
 
+func TestMemQueue_PriorityOrder(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	enqueue := func(id string, p domain.Priority) {
+		task := validTask(id)
+		task.Priority = p
+		_ = q.Enqueue(ctx, task)
+	}
+	enqueue("low", domain.PriorityLow)
+	enqueue("normal-1", domain.PriorityNormal)
+	enqueue("high", domain.PriorityHigh)
+	enqueue("normal-2", domain.PriorityNormal)
+
+	// A high-priority task overtakes the ones queued before it; equal
+	// priorities keep their FIFO order.
+	for _, want := range []string{"high", "normal-1", "normal-2", "low"} {
+		got, err := q.Dequeue(ctx)
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		if got.ID != want {
+			t.Errorf("priority order: got %q, want %q", got.ID, want)
+		}
+	}
+}
+
 func TestMemQueue_Len(t *testing.T) {
 	q := scheduler.NewMemQueue()
 	_ = q.Enqueue(ctx, validTask("t1"))
@@ -210,6 +306,204 @@ func TestMemQueue_Dequeue_ContextCancelled(t *testing.T) {
 	}
 }
 
+func TestMemQueue_DrainRefusesTasksAndWaitsForEmpty(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	_ = q.Enqueue(ctx, validTask("a"))
+	_ = q.Enqueue(ctx, validTask("b"))
+
+	// A Drain that gives up still leaves the queue draining.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := q.Drain(cancelled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Drain with a cancelled context: got %v, want Canceled", err)
+	}
+	if err := q.Enqueue(ctx, validTask("c")); !errors.Is(err, domain.ErrDraining) {
+		t.Fatalf("Enqueue while draining: got %v, want ErrDraining", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- q.Drain(ctx) }()
+	for _, want := range []string{"a", "b"} {
+		select {
+		case err := <-done:
+			t.Fatalf("Drain returned %v before the queue emptied", err)
+		default:
+		}
+		if got, err := q.Dequeue(ctx); err != nil || got.ID != want {
+			t.Fatalf("Dequeue: got %v, %v; want %s", got, err, want)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Drain: %v", err)
+	}
+
+}
+
+func TestMemQueue_JournalSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.journal")
+	q, err := scheduler.OpenMemQueue(path)
+	if err != nil {
+		t.Fatalf("OpenMemQueue: %v", err)
+	}
+	for _, id := range []string{"t1", "t2", "t3"} {
+		task := validTask(id)
+		task.Env = map[string]string{"ID": id}
+		_ = q.Enqueue(ctx, task)
+	}
+	if got, _ := q.Dequeue(ctx); got.ID != "t1" {
+		t.Fatalf("Dequeue = %s, want t1", got.ID)
+	}
+	_ = q.Close()
+
+	// A record cut short by a crash was never acknowledged and is ignored.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	_, _ = f.WriteString(`{"op":"enqueue","task":{"ID":"t4"`)
+	_ = f.Close()
+
+	q, err = scheduler.OpenMemQueue(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer q.Close()
+	if n, _ := q.Len(ctx); n != 2 {
+		t.Fatalf("Len after restart = %d, want 2", n)
+	}
+	for _, want := range []string{"t2", "t3"} {
+		got, _ := q.Dequeue(ctx)
+		if got.ID != want || got.Env["ID"] != want || got.Priority != domain.PriorityNormal {
+			t.Errorf("replayed %+v, want %s intact", got, want)
+		}
+	}
+}
+
+func TestMemQueue_JournalReplaysPriorityOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.journal")
+	q, err := scheduler.OpenMemQueue(path)
+	if err != nil {
+		t.Fatalf("OpenMemQueue: %v", err)
+	}
+	for _, id := range []string{"low", "high", "low-2"} {
+		task := validTask(id)
+		task.Priority = domain.PriorityLow
+		if id == "high" {
+			task.Priority = domain.PriorityHigh
+		}
+		_ = q.Enqueue(ctx, task)
+	}
+	if got, _ := q.Dequeue(ctx); got.ID != "high" {
+		t.Fatalf("Dequeue = %s, want high", got.ID)
+	}
+	_ = q.Close()
+
+	q, err = scheduler.OpenMemQueue(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer q.Close()
+	for _, want := range []string{"low", "low-2"} {
+		if got, _ := q.Dequeue(ctx); got.ID != want {
+			t.Errorf("replayed %s, want %s", got.ID, want)
+		}
+	}
+}
+
+func TestMemQueue_JournalCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.journal")
+	q, err := scheduler.OpenMemQueue(path)
+	if err != nil {
+		t.Fatalf("OpenMemQueue: %v", err)
+	}
+	defer q.Close()
+	// One task stays queued throughout, so compaction has one to keep.
+	_ = q.Enqueue(ctx, validTask("head"))
+	for i := range 1500 {
+		_ = q.Enqueue(ctx, validTask(fmt.Sprint(i)))
+		_, _ = q.Dequeue(ctx)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines > 1100 {
+		t.Errorf("journal has %d records after 1500 dequeues, want it compacted", lines)
+	}
+	q2, err := scheduler.OpenMemQueue(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer q2.Close()
+	if got, _ := q2.Dequeue(ctx); got.ID != "1499" {
+		t.Errorf("head after reopen = %s, want the one task left queued", got.ID)
+	}
+}
+
+func TestOpenMemQueue_RejectsCorruptJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.journal")
+	_ = os.WriteFile(path, []byte("{\"op\":\"dequeue\",\"id\":\"t1\"}\n"), 0o600)
+	if _, err := scheduler.OpenMemQueue(path); err == nil {
+		t.Error("OpenMemQueue accepted a dequeue of a task never queued")
+	}
+}
+
+func TestMemRetryQueue_ReleasesDueInOrder(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	rq := scheduler.NewMemRetryQueue(q)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_ = rq.Schedule(ctx, validTask("late"), now.Add(2*time.Minute))
+	_ = rq.Schedule(ctx, validTask("b"), now.Add(time.Minute))
+	_ = rq.Schedule(ctx, validTask("a"), now)
+	_ = rq.Schedule(ctx, validTask("c"), now.Add(time.Minute))
+
+	if n, _ := rq.Release(ctx, now.Add(time.Minute), 2); n != 2 {
+		t.Fatalf("first release: got %d, want 2 (the batch limit)", n)
+	}
+	if n, _ := rq.Release(ctx, now.Add(time.Minute), 0); n != 1 {
+		t.Fatalf("second release: got %d, want 1", n)
+	}
+	for _, want := range []string{"a", "b", "c"} {
+		got, _ := q.Dequeue(ctx)
+		if got.ID != want {
+			t.Errorf("released order: got %s, want %s", got.ID, want)
+		}
+	}
+	if n, _ := rq.Len(ctx); n != 1 {
+		t.Errorf("still waiting: got %d, want 1", n)
+	}
+}
+
+// ── FairQueue tests ───────────────────────────────────────────────────────────
+
+func TestFairQueue_WeightedRoundRobin(t *testing.T) {
+	q := scheduler.NewFairQueue(map[string]int{"bulk": 3})
+	for i := range 100 {
+		task := validTask(fmt.Sprintf("bulk-%d", i))
+		task.WorkflowID = "bulk"
+		_ = q.Enqueue(ctx, task)
+	}
+	for i := range 3 {
+		task := validTask(fmt.Sprintf("small-%d", i))
+		task.WorkflowID = "small"
+		_ = q.Enqueue(ctx, task)
+	}
+	_ = q.Enqueue(ctx, validTask("adhoc"))
+
+	var got []string
+	for range 10 {
+		task, err := q.Dequeue(ctx)
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		got = append(got, task.ID)
+	}
+	// bulk (weight 3) gets three of the first five turns, small and the
+	// task without a workflow one each; then three of every four, with
+	// each workflow's tasks in FIFO order.
+	want := "bulk-0,small-0,bulk-1,adhoc,bulk-2,bulk-3,bulk-4,small-1,bulk-5,bulk-6"
+	if strings.Join(got, ",") != want {
+		t.Errorf("dequeue order:\n got %s\nwant %s", strings.Join(got, ","), want)
+	}
+	if n, _ := q.Len(ctx); n != 94 {
+		t.Errorf("Len: got %d, want 94", n)
+	}
+}
+
 // ── Scheduler.Submit tests ────────────────────────────────────────────────────
 
 func TestScheduler_Submit_Valid(t *testing.T) {
@@ -266,6 +560,147 @@ func TestScheduler_Submit_SetsCreatedAt(t *testing.T) {
 	}
 }
 
+func TestScheduler_PublishesTaskEvents(t *testing.T) {
+	bus := events.NewMemBus()
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sub, _ := bus.Subscribe(sctx)
+	sched := scheduler.New(newMemTaskRepo(), newMemWorkerRepo(), scheduler.NewMemQueue(),
+		scheduler.WithPools(scheduler.NewPools(map[string]int{"db": 1})), scheduler.WithTaskEvents(bus))
+
+	first, second := validTask("t1"), validTask("t2")
+	first.Pool, second.Pool = "db", "db"
+	_ = sched.Submit(ctx, first)  // queued
+	_ = sched.Submit(ctx, second) // held back by the pool
+	_ = sched.Cancel(ctx, "t2")
+
+	var got []string
+	for len(sub) > 0 {
+		var u events.TaskUpdate
+		if err := (<-sub).DecodePayload(&u); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, u.TaskID+":"+u.Status)
+	}
+	if want := "[t1:queued t2:pending t2:failed]"; fmt.Sprint(got) != want {
+		t.Errorf("events: got %v, want %s", got, want)
+	}
+}
+
+// ── GroupQueues tests ─────────────────────────────────────────────────────────
+
+func TestScheduler_GroupQueues_RoutesTasksByGroup(t *testing.T) {
+	def := scheduler.NewMemQueue()
+	groups := scheduler.NewGroupQueues(def, nil)
+	sched := scheduler.New(newMemTaskRepo(), newMemWorkerRepo(), groups)
+
+	shared, etl := validTask("t1"), validTask("t2")
+	etl.Group = "etl"
+	for _, task := range []*domain.Task{shared, etl} {
+		if err := sched.Submit(ctx, task); err != nil {
+			t.Fatalf("Submit %s: %v", task.ID, err)
+		}
+	}
+
+	etlQueue, err := groups.Queue("etl")
+	if err != nil {
+		t.Fatalf("Queue: %v", err)
+	}
+	if got, _ := etlQueue.Dequeue(ctx); got == nil || got.ID != "t2" {
+		t.Errorf("etl queue: got %+v, want t2", got)
+	}
+	if got, _ := def.Dequeue(ctx); got == nil || got.ID != "t1" {
+		t.Errorf("default queue: got %+v, want t1", got)
+	}
+	if got := groups.Groups(); len(got) != 1 || got[0] != "etl" {
+		t.Errorf("Groups: got %v, want [etl]", got)
+	}
+}
+
+func TestGroupQueues_LenCountsEveryGroup(t *testing.T) {
+	groups := scheduler.NewGroupQueues(scheduler.NewMemQueue(), nil)
+	for i, group := range []string{"", "a", "b", "b"} {
+		task := validTask(string(rune('1' + i)))
+		task.Group = group
+		if err := groups.Enqueue(ctx, task); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	if n, err := groups.Len(ctx); err != nil || n != 4 {
+		t.Errorf("Len: got %d, %v; want 4", n, err)
+	}
+}
+
+func TestGroupQueues_RejectsInvalidGroup(t *testing.T) {
+	groups := scheduler.NewGroupQueues(scheduler.NewMemQueue(), nil)
+	if _, err := groups.Queue("team a/b"); err == nil {
+		t.Error("Queue: expected error for an invalid group name")
+	}
+	sched := scheduler.New(newMemTaskRepo(), newMemWorkerRepo(), groups)
+	task := validTask("t1")
+	task.Group = "team a/b"
+	if err := sched.Submit(ctx, task); !errors.Is(err, domain.ErrTaskInvalid) {
+		t.Errorf("Submit: got %v, want ErrTaskInvalid", err)
+	}
+}
+
+func TestGroupQueues_NamedQueuesServeOnlyTheirTasks(t *testing.T) {
+	def := scheduler.NewMemQueue()
+	groups := scheduler.NewGroupQueues(def, nil)
+	sched := scheduler.New(newMemTaskRepo(), newMemWorkerRepo(), groups)
+	gpu, err := groups.Named(10*time.Millisecond, "gpu", "etl-heavy")
+	if err != nil {
+		t.Fatalf("Named: %v", err)
+	}
+
+	plain, train, load := validTask("plain"), validTask("train"), validTask("load")
+	train.Queue, load.Queue = "gpu", "etl-heavy"
+	load.Group = "etl" // the named queue wins over the group
+	for _, task := range []*domain.Task{plain, train, load} {
+		if err := sched.Submit(ctx, task); err != nil {
+			t.Fatalf("Submit %s: %v", task.ID, err)
+		}
+	}
+	if n, _ := gpu.Len(ctx); n != 2 {
+		t.Errorf("Len: got %d, want the 2 tasks of the named queues", n)
+	}
+	got := map[string]bool{}
+	for range 2 {
+		dctx, cancel := context.WithTimeout(ctx, time.Second)
+		task, err := gpu.Dequeue(dctx)
+		cancel()
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		got[task.ID] = true
+	}
+	if !got["train"] || !got["load"] {
+		t.Errorf("named queues served %v, want train and load", got)
+	}
+	dctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if task, err := gpu.Dequeue(dctx); !errors.Is(err, domain.ErrQueueEmpty) {
+		t.Errorf("Dequeue with only the default queue full: got %+v, %v; want ErrQueueEmpty", task, err)
+	}
+	if n, _ := def.Len(ctx); n != 1 {
+		t.Errorf("default queue: got %d tasks, want plain", n)
+	}
+
+	// A retry goes back to the task's own named queue.
+	if err := gpu.Enqueue(ctx, train); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if q, _ := groups.Queue(domain.NamedQueue("gpu")); q == nil {
+		t.Fatal("gpu queue not opened")
+	} else if n, _ := q.Len(ctx); n != 1 {
+		t.Errorf("gpu queue after requeue: got %d, want 1", n)
+	}
+
+	if _, err := groups.Named(0, "no spaces"); err == nil {
+		t.Error("Named: expected error for an invalid queue name")
+	}
+}
+
 // ── Scheduler.Cancel tests ────────────────────────────────────────────────────
 
 func TestScheduler_Cancel_QueuedTask(t *testing.T) {
@@ -330,9 +765,898 @@ func TestScheduler_Status_NotFound(t *testing.T) {
 	}
 }
 
+// ── Resource pool tests ───────────────────────────────────────────────────────
+
+func TestParsePools(t *testing.T) {
+	got, err := scheduler.ParsePools("warehouse=4, gpu=2")
+	if err != nil {
+		t.Fatalf("ParsePools: %v", err)
+	}
+	if got["warehouse"] != 4 || got["gpu"] != 2 {
+		t.Errorf("ParsePools: got %v", got)
+	}
+	if _, err := scheduler.ParsePools("warehouse=zero"); err == nil {
+		t.Error("expected error for non-numeric slots")
+	}
+}
+
+func TestScheduler_Submit_RateLimited(t *testing.T) {
+	fc := clock.NewFake(time.Now().Add(time.Hour)) // past every ScheduledAt
+	tr := newMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	limiter := scheduler.NewSubmitLimiter(map[string]scheduler.RateLimit{
+		scheduler.GlobalRateLimit: {Rate: 10, Burst: 3},
+		"send-email":              {Rate: 1},
+	})
+	sched := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithClock(fc), scheduler.WithRateLimits(limiter))
+
+	submit := func(id, name string) error {
+		task := validTask(id)
+		task.Name = name
+		return sched.Submit(ctx, task)
+	}
+	if err := submit("e1", "send-email"); err != nil {
+		t.Fatalf("first email: %v", err)
+	}
+	err := submit("e2", "send-email")
+	var rl *scheduler.RateLimitError
+	if !errors.As(err, &rl) || !errors.Is(err, domain.ErrRateLimited) || rl.Key != "send-email" || rl.RetryAfter != time.Second {
+		t.Fatalf("second email: got %v, want a send-email RateLimitError with 1s to wait", err)
+	}
+	if _, err := tr.FindByID(ctx, "e2"); !errors.Is(err, domain.ErrTaskNotFound) {
+		t.Error("rejected task was saved")
+	}
+
+	// The rejected email took no global token: two more tasks fit the
+	// burst of three, the next does not.
+	for _, id := range []string{"r1", "r2"} {
+		if err := submit(id, "report"); err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+	}
+	if err := submit("r3", "report"); !errors.As(err, &rl) || rl.Key != scheduler.GlobalRateLimit {
+		t.Errorf("over the global burst: got %v, want a global RateLimitError", err)
+	}
+
+	fc.Advance(time.Second)
+	if err := submit("e2", "send-email"); err != nil {
+		t.Errorf("email after refill: %v", err)
+	}
+	if n, _ := q.Len(ctx); n != 4 {
+		t.Errorf("queue length: got %d, want 4", n)
+	}
+}
+
+func TestScheduler_Submit_IdempotencyKey(t *testing.T) {
+	fc := clock.NewFake(time.Now().Add(time.Hour)) // past every ScheduledAt
+	tr := scheduler.NewMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	sched := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithClock(fc), scheduler.WithIdempotencyWindow(time.Minute))
+	// A second replica sharing the task store sees the same claims.
+	replica := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithClock(fc), scheduler.WithIdempotencyWindow(time.Minute))
+
+	submit := func(s *scheduler.Scheduler, id string) error {
+		task := validTask(id)
+		task.CreatedAt = time.Time{}
+		task.IdempotencyKey = "order-42"
+		return s.Submit(ctx, task)
+	}
+	if err := submit(sched, "t1"); err != nil {
+		t.Fatalf("first submission: %v", err)
+	}
+	err := submit(replica, "t2")
+	var dup *scheduler.DuplicateTaskError
+	if !errors.As(err, &dup) || !errors.Is(err, domain.ErrDuplicateTask) {
+		t.Fatalf("repeat within the window: got %v, want a DuplicateTaskError", err)
+	}
+	if dup.TaskID != "t1" || dup.Existing == nil || dup.Existing.Status != domain.TaskStatusQueued {
+		t.Errorf("duplicate: got task %s, existing %+v; want queued t1", dup.TaskID, dup.Existing)
+	}
+	if _, err := tr.FindByID(ctx, "t2"); !errors.Is(err, domain.ErrTaskNotFound) {
+		t.Error("duplicate task was saved")
+	}
+	// Resubmitting the same task is not a duplicate.
+	if err := submit(sched, "t1"); err != nil {
+		t.Errorf("resubmitting t1: %v", err)
+	}
+
+	fc.Advance(time.Minute)
+	if err := submit(replica, "t3"); err != nil {
+		t.Errorf("after the window: %v", err)
+	}
+	if n, _ := q.Len(ctx); n != 3 {
+		t.Errorf("queue length: got %d, want 3", n)
+	}
+	// t3 took the key over; saving t1 again does not take it back.
+	first, _ := tr.FindByID(ctx, "t1")
+	_ = tr.Save(ctx, first)
+	if got, _ := tr.FindByID(ctx, "t1"); got.IdempotencyKey != "" {
+		t.Errorf("t1 key after the takeover: got %q, want none", got.IdempotencyKey)
+	}
+}
+
+func TestScheduler_Submit_RateLimitedTaskClaimsNoKey(t *testing.T) {
+	fc := clock.NewFake(time.Now().Add(time.Hour))
+	tr := scheduler.NewMemTaskRepo()
+	limits := scheduler.NewSubmitLimiter(map[string]scheduler.RateLimit{"*": {Rate: 1, Burst: 1}})
+	sched := scheduler.New(tr, newMemWorkerRepo(), scheduler.NewMemQueue(), scheduler.WithClock(fc),
+		scheduler.WithIdempotencyWindow(time.Minute), scheduler.WithRateLimits(limits))
+
+	submit := func(id, key string) error {
+		task := validTask(id)
+		task.IdempotencyKey = key
+		return sched.Submit(ctx, task)
+	}
+	_ = submit("t1", "a")
+	if err := submit("t2", "b"); !errors.Is(err, domain.ErrRateLimited) {
+		t.Fatalf("second submission: got %v, want rate limited", err)
+	}
+	fc.Advance(time.Second)
+	if err := submit("t3", "b"); err != nil {
+		t.Errorf("retry after the rate limit: %v", err)
+	}
+}
+
+func TestScheduler_Submit_DedupModes(t *testing.T) {
+	for _, mode := range []scheduler.DedupMode{scheduler.DedupByKey, scheduler.DedupByContent} {
+		t.Run(string(mode), func(t *testing.T) {
+			fc := clock.NewFake(time.Now())
+			tr := scheduler.NewMemTaskRepo()
+			sched := scheduler.New(tr, newMemWorkerRepo(), scheduler.NewMemQueue(), scheduler.WithClock(fc),
+				scheduler.WithIdempotencyWindow(time.Minute), scheduler.WithDedupMode(mode))
+			submit := func(id, key, runID, payload string) error {
+				task := validTask(id)
+				task.IdempotencyKey, task.RunID, task.Payload = key, runID, []byte(payload)
+				task.ScheduledAt, task.CreatedAt = fc.Now(), time.Time{}
+				return sched.Submit(ctx, task)
+			}
+			if err := submit("t1", "order-42", "run-1", "a"); err != nil {
+				t.Fatalf("first submission: %v", err)
+			}
+			if got, _ := tr.FindByID(ctx, "t1"); got.IdempotencyKey != "order-42" {
+				t.Errorf("IdempotencyKey: got %q, want the caller's order-42", got.IdempotencyKey)
+			}
+			// t2 shares only t1's key, t3 only its content and run.
+			var dup *scheduler.DuplicateTaskError
+			if err := submit("t2", "order-42", "run-1", "b"); !errors.As(err, &dup) || dup.TaskID != "t1" || dup.Key != "order-42" {
+				t.Errorf("same key: got %v, want a DuplicateTaskError naming t1", err)
+			}
+			err := submit("t3", "", "run-1", "a")
+			if mode == scheduler.DedupByKey && err != nil {
+				t.Errorf("by key: same content got %v", err)
+			}
+			if mode == scheduler.DedupByContent && (!errors.As(err, &dup) || dup.TaskID != "t1" || !errors.Is(err, domain.ErrDuplicateTask)) {
+				t.Errorf("by content: same content got %v, want a DuplicateTaskError naming t1", err)
+			}
+			// The same work in another run is not a duplicate.
+			if err := submit("t4", "", "run-2", "a"); err != nil {
+				t.Errorf("same content in another run: %v", err)
+			}
+			// A retry under its own ID is not a duplicate.
+			if err := submit("t1", "order-42", "run-1", "a"); err != nil {
+				t.Errorf("resubmitting t1: %v", err)
+			}
+
+			fc.Advance(time.Minute)
+			if err := submit("t5", "order-42", "run-1", "a"); err != nil {
+				t.Errorf("after the window: %v", err)
+			}
+		})
+	}
+}
+
+// pauseLog records what a Scheduler reports to its PauseObserver.
+type pauseLog []bool
+
+func (l *pauseLog) ObserveDispatchPaused(paused bool) { *l = append(*l, paused) }
+
+func TestScheduler_PauseHoldsTasksUntilResume(t *testing.T) {
+	fc := clock.NewFake(time.Now())
+	tr := newMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	var observed pauseLog
+	sched := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithClock(fc), scheduler.WithPauseObserver(&observed))
+
+	sched.Pause(ctx)
+	sched.Pause(ctx)
+	if paused, since := sched.Paused(); !paused || !since.Equal(fc.Now()) {
+		t.Fatalf("Paused: got %v since %v", paused, since)
+	}
+	if err := sched.Submit(ctx, validTask("now")); err != nil {
+		t.Fatalf("Submit while paused: %v", err)
+	}
+	later := validTask("later")
+	later.ScheduledAt = fc.Now().Add(time.Minute)
+	_ = sched.Submit(ctx, later)
+	fc.Advance(time.Minute)
+	sched.Reconcile(ctx)
+
+	if n, _ := q.Len(ctx); n != 0 {
+		t.Errorf("queue length while paused: got %d, want 0", n)
+	}
+	if got, _ := tr.FindByID(ctx, "now"); got.Status != domain.TaskStatusPending {
+		t.Errorf("task submitted while paused: got %q, want pending", got.Status)
+	}
+	if st := sched.Inspect(); !st.Paused || len(st.Held) != 2 {
+		t.Errorf("Inspect while paused: paused %v with %d held, want 2", st.Paused, len(st.Held))
+	}
+
+	sched.Resume(ctx)
+	if n, _ := q.Len(ctx); n != 2 {
+		t.Errorf("queue length after Resume: got %d, want 2", n)
+	}
+	if paused, _ := sched.Paused(); paused || sched.Held() != 0 {
+		t.Errorf("after Resume: paused %v with %d held", paused, sched.Held())
+	}
+	if fmt.Sprint(observed) != "[true false]" {
+		t.Errorf("observed %v, want one pause and one resume", observed)
+	}
+}
+
+func TestScheduler_DrainWaitsForQueuedAndRunningTasks(t *testing.T) {
+	fc := clock.NewFake(time.Now())
+	tr := newMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	sched := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithClock(fc))
+	queued := validTask("queued")
+	queued.ScheduledAt = fc.Now()
+	if err := sched.Submit(ctx, queued); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	later := validTask("later")
+	later.ScheduledAt = fc.Now().Add(time.Second)
+	_ = sched.Submit(ctx, later)
+
+	done := make(chan error, 1)
+	go func() { done <- sched.Drain(ctx) }()
+	fc.BlockUntil(2) // the delayed task's timer and Drain's ticker
+	if !sched.Draining() || !sched.Inspect().Draining {
+		t.Error("Draining: got false after Drain")
+	}
+	if err := sched.Submit(ctx, validTask("new")); !errors.Is(err, domain.ErrDraining) {
+		t.Errorf("Submit while draining: got %v, want ErrDraining", err)
+	}
+
+	// A worker takes the queued task and runs it; the delayed one comes
+	// due but stays pending.
+	task, _ := q.Dequeue(ctx)
+	task.Status = domain.TaskStatusRunning
+	_ = tr.Save(ctx, task)
+	fc.Advance(time.Second)
+	sched.Reconcile(ctx)
+	select {
+	case err := <-done:
+		t.Fatalf("Drain returned %v with a task running", err)
+	default:
+	}
+	if n, _ := q.Len(ctx); n != 0 {
+		t.Errorf("queue length while draining: got %d, want 0", n)
+	}
+	if got, _ := tr.FindByID(ctx, "later"); got.Status != domain.TaskStatusPending {
+		t.Errorf("delayed task: got %q, want pending", got.Status)
+	}
+
+	task.Status = domain.TaskStatusSucceeded
+	_ = tr.Save(ctx, task)
+	fc.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("Drain: %v", err)
+	}
+}
+
+func TestParseRateLimits(t *testing.T) {
+	got, err := scheduler.ParseRateLimits("*=100, send-email=0.5:5")
+	if err != nil {
+		t.Fatalf("ParseRateLimits: %v", err)
+	}
+	if got["*"] != (scheduler.RateLimit{Rate: 100}) || got["send-email"] != (scheduler.RateLimit{Rate: 0.5, Burst: 5}) {
+		t.Errorf("ParseRateLimits: got %v", got)
+	}
+	for _, spec := range []string{"send-email", "x=0", "x=1:zero", "=5"} {
+		if _, err := scheduler.ParseRateLimits(spec); err == nil {
+			t.Errorf("ParseRateLimits(%q): expected error", spec)
+		}
+	}
+}
+
+// downQueue refuses every task while down.
+type downQueue struct {
+	*scheduler.MemQueue
+	down atomic.Bool
+}
+
+func (q *downQueue) Enqueue(ctx context.Context, task *domain.Task) error {
+	if q.down.Load() {
+		return errors.New("queue unavailable")
+	}
+	return q.MemQueue.Enqueue(ctx, task)
+}
+
+func TestScheduler_EnqueueFailureHoldsTaskBack(t *testing.T) {
+	tr := newMemTaskRepo()
+	q := &downQueue{MemQueue: scheduler.NewMemQueue()}
+	pools := scheduler.NewPools(map[string]int{"warehouse": 1})
+	sched := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithPools(pools))
+
+	q.down.Store(true)
+	task := validTask("t1")
+	task.Pool = "warehouse"
+	if err := sched.Submit(ctx, task); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if got, _ := sched.Status(ctx, "t1"); got != domain.TaskStatusPending || sched.Held() != 1 {
+		t.Fatalf("after a failed enqueue: status %q, %d held; want pending and held", got, sched.Held())
+	}
+
+	// The pool slot was given back, so t1 is admitted again once the
+	// queue is back.
+	q.down.Store(false)
+	sched.Reconcile(ctx)
+	if n, _ := q.Len(ctx); n != 1 || sched.Held() != 0 {
+		t.Errorf("after recovery: queue length %d, %d held; want 1 and 0", n, sched.Held())
+	}
+	if got, _ := sched.Status(ctx, "t1"); got != domain.TaskStatusQueued {
+		t.Errorf("status: got %q, want queued", got)
+	}
+}
+
+func TestScheduler_Pool_HoldsTasksOverLimit(t *testing.T) {
+	tr := newMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	pools := scheduler.NewPools(map[string]int{"warehouse": 1})
+	sched := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithPools(pools))
+
+	t1, t2 := validTask("t1"), validTask("t2")
+	t1.Pool, t2.Pool = "warehouse", "warehouse"
+	_ = sched.Submit(ctx, t1)
+	_ = sched.Submit(ctx, t2)
+
+	if n, _ := q.Len(ctx); n != 1 {
+		t.Fatalf("queue length: got %d, want 1", n)
+	}
+	if got, _ := sched.Status(ctx, "t2"); got != domain.TaskStatusPending {
+		t.Errorf("held task status: got %q, want pending", got)
+	}
+	if sched.Held() != 1 {
+		t.Errorf("Held: got %d, want 1", sched.Held())
+	}
+
+	// Nothing changes while t1 is still running.
+	sched.Reconcile(ctx)
+	if n, _ := q.Len(ctx); n != 1 {
+		t.Fatalf("queue length before release: got %d, want 1", n)
+	}
+
+	stored, _ := tr.FindByID(ctx, "t1")
+	stored.Status = domain.TaskStatusSucceeded
+	_ = tr.Save(ctx, stored)
+	sched.Reconcile(ctx)
+
+	if n, _ := q.Len(ctx); n != 2 {
+		t.Errorf("queue length after release: got %d, want 2", n)
+	}
+	if got, _ := sched.Status(ctx, "t2"); got != domain.TaskStatusQueued {
+		t.Errorf("released task status: got %q, want queued", got)
+	}
+	if u := pools.Usage()["warehouse"]; u.Used != 1 {
+		t.Errorf("pool usage: got %d, want 1", u.Used)
+	}
+}
+
+func TestScheduler_Pool_CancelledHeldTaskIsDropped(t *testing.T) {
+	tr := newMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	sched := scheduler.New(tr, newMemWorkerRepo(), q,
+		scheduler.WithPools(scheduler.NewPools(map[string]int{"gpu": 1})))
+
+	t1, t2 := validTask("t1"), validTask("t2")
+	t1.Pool, t2.Pool = "gpu", "gpu"
+	_ = sched.Submit(ctx, t1)
+	_ = sched.Submit(ctx, t2)
+	_ = sched.Cancel(ctx, "t2")
+	sched.Reconcile(ctx)
+
+	if sched.Held() != 0 {
+		t.Errorf("Held: got %d, want 0", sched.Held())
+	}
+	if n, _ := q.Len(ctx); n != 1 {
+		t.Errorf("queue length: got %d, want 1", n)
+	}
+}
+
+// ── Concurrency limit tests ───────────────────────────────────────────────────
+
+func TestParseConcurrencyLimits(t *testing.T) {
+	got, err := scheduler.ParseConcurrencyLimits("*=50, task:load=2, workflow:wf-1=4")
+	if err != nil {
+		t.Fatalf("ParseConcurrencyLimits: %v", err)
+	}
+	if got["*"] != 50 || got["task:load"] != 2 || got["workflow:wf-1"] != 4 {
+		t.Errorf("ParseConcurrencyLimits: got %v", got)
+	}
+	for _, spec := range []string{"load=2", "task:=2", "task:load=0"} {
+		if _, err := scheduler.ParseConcurrencyLimits(spec); err == nil {
+			t.Errorf("ParseConcurrencyLimits(%q): expected error", spec)
+		}
+	}
+}
+
+func TestScheduler_ConcurrencyLimits_HoldTasksOverLimit(t *testing.T) {
+	tr := newMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	limits := scheduler.NewConcurrencyLimits(map[string]int{
+		scheduler.GlobalConcurrencyLimit:      3,
+		scheduler.TaskLimitPrefix + "load":    1,
+		scheduler.WorkflowLimitPrefix + "wf1": 2,
+	})
+	sched := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithConcurrencyLimits(limits))
+
+	tasks := []*domain.Task{validTask("a"), validTask("b"), validTask("c"), validTask("d"), validTask("e")}
+	tasks[0].Name, tasks[1].Name = "load", "load" // b waits for a
+	tasks[2].WorkflowID, tasks[3].WorkflowID = "wf1", "wf1"
+	tasks[0].WorkflowID = "wf1" // d waits for a or c
+	for _, task := range tasks {
+		_ = sched.Submit(ctx, task)
+	}
+	// a and c take wf1's slots, e the last global one.
+	if n, _ := q.Len(ctx); n != 3 {
+		t.Fatalf("queue length: got %d, want 3", n)
+	}
+	for _, id := range []string{"b", "d"} {
+		if got, _ := sched.Status(ctx, id); got != domain.TaskStatusPending {
+			t.Errorf("%s: got %q, want held pending", id, got)
+		}
+	}
+
+	stored, _ := tr.FindByID(ctx, "a")
+	stored.Status = domain.TaskStatusSucceeded
+	_ = tr.Save(ctx, stored)
+	sched.Reconcile(ctx)
+
+	// a's slots let b start; d still waits for the global slot b took.
+	if got, _ := sched.Status(ctx, "b"); got != domain.TaskStatusQueued {
+		t.Errorf("b: got %q, want queued once a finished", got)
+	}
+	if got, _ := sched.Status(ctx, "d"); got != domain.TaskStatusPending {
+		t.Errorf("d: got %q, want still held", got)
+	}
+	if u := limits.Usage()[scheduler.GlobalConcurrencyLimit]; u.Used != 3 {
+		t.Errorf("global usage: got %d, want 3", u.Used)
+	}
+}
+
+// ── Concurrency key tests ─────────────────────────────────────────────────────
+
+func TestScheduler_ConcurrencyKey_SerialisesTasks(t *testing.T) {
+	tr := newMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	sched := scheduler.New(tr, newMemWorkerRepo(), q)
+
+	t1, t2, t3 := validTask("t1"), validTask("t2"), validTask("t3")
+	t1.ConcurrencyKey, t2.ConcurrencyKey = "customer-42", "customer-42"
+	t3.ConcurrencyKey = "customer-7"
+	_ = sched.Submit(ctx, t1)
+	_ = sched.Submit(ctx, t2)
+	_ = sched.Submit(ctx, t3)
+
+	if n, _ := q.Len(ctx); n != 2 {
+		t.Fatalf("queue length: got %d, want 2 (t1 and t3)", n)
+	}
+	if got, _ := sched.Status(ctx, "t2"); got != domain.TaskStatusPending {
+		t.Errorf("conflicting task status: got %q, want pending", got)
+	}
+
+	stored, _ := tr.FindByID(ctx, "t1")
+	stored.Status = domain.TaskStatusFailed
+	_ = tr.Save(ctx, stored)
+	sched.Reconcile(ctx)
+
+	if got, _ := sched.Status(ctx, "t2"); got != domain.TaskStatusQueued {
+		t.Errorf("task status after key release: got %q, want queued", got)
+	}
+}
+
+func TestScheduler_RestartRestoresPendingTasks(t *testing.T) {
+	fc := clock.NewFake(time.Now())
+	tr, q := scheduler.NewMemTaskRepo(), scheduler.NewMemQueue()
+	pools := func() scheduler.Option { return scheduler.WithPools(scheduler.NewPools(map[string]int{"db": 1})) }
+	before := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithClock(fc), pools())
+
+	running, held, later := validTask("running"), validTask("held"), validTask("later")
+	running.Pool, held.Pool = "db", "db"
+	running.ScheduledAt, held.ScheduledAt, later.ScheduledAt = fc.Now(), fc.Now(), fc.Now().Add(time.Minute)
+	for _, task := range []*domain.Task{running, held, later} {
+		if err := before.Submit(ctx, task); err != nil {
+			t.Fatalf("Submit %s: %v", task.ID, err)
+		}
+	}
+	if before.Held() != 1 || before.Delayed() != 1 {
+		t.Fatalf("before the restart: %d held, %d delayed; want 1 and 1", before.Held(), before.Delayed())
+	}
+	_, _ = q.Dequeue(ctx)
+
+	// A new process over the same store picks up what the old one held.
+	after := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithClock(fc), pools())
+	if err := after.Restore(ctx); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if err := after.Restore(ctx); err != nil {
+		t.Fatalf("second Restore: %v", err)
+	}
+	if after.Held() != 1 || after.Delayed() != 1 {
+		t.Fatalf("after the restart: %d held, %d delayed; want 1 and 1", after.Held(), after.Delayed())
+	}
+	after.Reconcile(ctx)
+	fc.Advance(time.Minute)
+	after.Reconcile(ctx)
+	for _, id := range []string{"held", "later"} {
+		if got, _ := after.Status(ctx, id); got != domain.TaskStatusQueued {
+			t.Errorf("%s: got %q, want queued", id, got)
+		}
+	}
+	if n, _ := q.Len(ctx); n != 2 {
+		t.Errorf("queue length: got %d, want 2", n)
+	}
+}
+
+// ── Delayed task tests ────────────────────────────────────────────────────────
+
+// orderQueue records the order tasks are enqueued in, which a MemQueue's
+// priority order would hide.
+type orderQueue struct {
+	*scheduler.MemQueue
+	mu  sync.Mutex
+	ids []string
+}
+
+func (q *orderQueue) Enqueue(ctx context.Context, task *domain.Task) error {
+	q.mu.Lock()
+	q.ids = append(q.ids, task.ID)
+	q.mu.Unlock()
+	return q.MemQueue.Enqueue(ctx, task)
+}
+
+func TestScheduler_Delay_DispatchesInScheduledOrder(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := clock.NewFake(start)
+	tr := newMemTaskRepo()
+	q := &orderQueue{MemQueue: scheduler.NewMemQueue()}
+	sched := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithClock(fc))
+
+	at := func(id string, d time.Duration, p domain.Priority) {
+		task := validTask(id)
+		task.ScheduledAt, task.Priority = start.Add(d), p
+		if err := sched.Submit(ctx, task); err != nil {
+			t.Fatalf("Submit %s: %v", id, err)
+		}
+	}
+	at("late", 3*time.Minute, domain.PriorityNormal)
+	at("first", time.Minute, domain.PriorityNormal)
+	at("tie-normal", 2*time.Minute, domain.PriorityNormal)
+	at("tie-low", 2*time.Minute, domain.PriorityLow)
+	at("tie-high", 2*time.Minute, domain.PriorityHigh)
+	at("tie-normal-2", 2*time.Minute, domain.PriorityNormal)
+	at("now", 0, domain.PriorityNormal)
+
+	if n, _ := q.Len(ctx); n != 1 || sched.Delayed() != 6 {
+		t.Fatalf("queue length %d, %d delayed; want 1 and 6", n, sched.Delayed())
+	}
+	if got, _ := sched.Status(ctx, "first"); got != domain.TaskStatusPending {
+		t.Errorf("delayed task status: got %q, want pending", got)
+	}
+
+	fc.Set(start.Add(2 * time.Minute))
+	sched.Reconcile(ctx)
+	if sched.Delayed() != 1 {
+		t.Errorf("delayed after two minutes: got %d, want 1", sched.Delayed())
+	}
+	fc.Set(start.Add(5 * time.Minute))
+	sched.Reconcile(ctx)
+
+	if want := "now first tie-high tie-normal tie-normal-2 tie-low late"; strings.Join(q.ids, " ") != want {
+		t.Errorf("dispatch order: got %v, want %s", q.ids, want)
+	}
+}
+
+func TestScheduler_ExpiresTasksNotDispatchedInTime(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := clock.NewFake(start)
+	tr := newMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	sched := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithClock(fc),
+		scheduler.WithPools(scheduler.NewPools(map[string]int{"gpu": 1})))
+	expiring := func(id string, scheduled, ttl time.Duration) *domain.Task {
+		task := validTask(id)
+		task.ScheduledAt = start.Add(scheduled)
+		expires := task.ScheduledAt.Add(ttl)
+		task.ExpiresAt = &expires
+		return task
+	}
+
+	stale := expiring("stale", 0, -time.Second)
+	running, held := expiring("running", 0, time.Hour), expiring("held", 0, time.Minute)
+	running.Pool, held.Pool = "gpu", "gpu"
+	delayed := expiring("delayed", 2*time.Minute, -time.Minute)
+	for _, task := range []*domain.Task{stale, running, held, delayed} {
+		if err := sched.Submit(ctx, task); err != nil {
+			t.Fatalf("Submit %s: %v", task.ID, err)
+		}
+	}
+	if got, _ := sched.Status(ctx, "stale"); got != domain.TaskStatusExpired {
+		t.Errorf("task submitted past its expiry: got %q, want expired", got)
+	}
+
+	fc.Set(start.Add(2 * time.Minute))
+	sched.Reconcile(ctx)
+	for _, id := range []string{"held", "delayed"} {
+		if got, _ := sched.Status(ctx, id); got != domain.TaskStatusExpired {
+			t.Errorf("%s: got %q, want expired", id, got)
+		}
+	}
+	if sched.Held() != 0 || sched.Delayed() != 0 {
+		t.Errorf("held %d, delayed %d; want none", sched.Held(), sched.Delayed())
+	}
+	if n, _ := q.Len(ctx); n != 1 {
+		t.Errorf("queue length: got %d, want only the running task", n)
+	}
+}
+
+func TestScheduler_Delay_RunReleasesWhenDue(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := clock.NewFake(start)
+	tr := newMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	sched := scheduler.New(tr, newMemWorkerRepo(), q,
+		scheduler.WithClock(fc), scheduler.WithDispatchInterval(time.Hour))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() { _ = sched.Run(runCtx) }()
+
+	task := validTask("t1")
+	task.ScheduledAt = start.Add(30 * time.Second)
+	_ = sched.Submit(ctx, task)
+	cancelled := validTask("t2")
+	cancelled.ScheduledAt = start.Add(10 * time.Second)
+	_ = sched.Submit(ctx, cancelled)
+	_ = sched.Cancel(ctx, "t2")
+
+	// The dispatch ticker and the wake timer; no tick is needed.
+	fc.BlockUntil(2)
+	fc.Advance(10 * time.Second)
+	fc.BlockUntil(2)
+	if n, _ := q.Len(ctx); n != 0 {
+		t.Fatalf("queue length before t1 is due: got %d, want 0", n)
+	}
+	fc.Advance(20 * time.Second)
+
+	dctx, dcancel := context.WithTimeout(ctx, time.Second)
+	defer dcancel()
+	got, err := q.Dequeue(dctx)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	if got.ID != "t1" {
+		t.Errorf("dequeued %s, want t1", got.ID)
+	}
+	if status, _ := sched.Status(ctx, "t1"); status != domain.TaskStatusQueued {
+		t.Errorf("status once due: got %q, want queued", status)
+	}
+}
+
+// ── Sticky routing tests ──────────────────────────────────────────────────────
+
+func TestPickWorker_MovesOnlyKeysOfLostWorker(t *testing.T) {
+	workers := []*domain.Worker{{ID: "w1"}, {ID: "w2"}, {ID: "w3"}}
+	before := map[string]string{}
+	for i := range 200 {
+		key := fmt.Sprintf("customer-%d", i)
+		before[key] = scheduler.PickWorker(key, workers).ID
+	}
+	if scheduler.PickWorker("customer-1", nil) != nil {
+		t.Error("PickWorker with no workers: want nil")
+	}
+
+	remaining := []*domain.Worker{workers[0], workers[2]}
+	moved := 0
+	for key, was := range before {
+		now := scheduler.PickWorker(key, remaining).ID
+		if was != "w2" && now != was {
+			t.Errorf("key %s moved from %s to %s though %s is still there", key, was, now, was)
+		}
+		if was == "w2" {
+			moved++
+		}
+	}
+	if moved == 0 || moved == len(before) {
+		t.Errorf("w2 held %d of %d keys; want a share", moved, len(before))
+	}
+}
+
+func TestScheduler_StickyRouting(t *testing.T) {
+	fc := clock.NewFake(time.Now().Add(time.Hour)) // past every ScheduledAt
+	wr := newMemWorkerRepo()
+	for _, id := range []string{"w1", "w2"} {
+		_ = wr.Save(ctx, &domain.Worker{ID: id, Status: domain.WorkerStatusBusy, Concurrency: 1, ActiveTasks: 1, LastHeartAt: fc.Now()})
+	}
+	groups := scheduler.NewGroupQueues(scheduler.NewMemQueue(), nil)
+	sched := scheduler.New(newMemTaskRepo(), wr, groups, scheduler.WithClock(fc),
+		scheduler.WithStickyRouting(groups, time.Minute))
+
+	workers, _ := wr.FindAvailable(ctx, domain.WorkerFilter{IgnoreCapacity: true})
+	owner := scheduler.PickWorker("customer-7", workers).ID
+	for _, id := range []string{"t1", "t2"} {
+		task := validTask(id)
+		task.RoutingKey = "customer-7"
+		if err := sched.Submit(ctx, task); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	_ = sched.Submit(ctx, validTask("plain"))
+
+	pinned, _ := groups.Queue(domain.StickyQueue(owner))
+	if n, _ := pinned.Len(ctx); n != 2 {
+		t.Errorf("sticky queue of %s: got %d tasks, want 2 though it is full", owner, n)
+	}
+	if n, _ := groups.Len(ctx); n != 3 {
+		t.Errorf("queued tasks: got %d, want 3", n)
+	}
+
+	// The owner stops heartbeating: Reconcile moves its tasks to the other.
+	fc.Advance(2 * time.Minute)
+	for _, id := range []string{"w1", "w2"} {
+		if id != owner {
+			w, _ := wr.FindByID(ctx, id)
+			w.LastHeartAt = fc.Now()
+			_ = wr.Save(ctx, w)
+		}
+	}
+	sched.Reconcile(ctx)
+	if n, _ := pinned.Len(ctx); n != 0 {
+		t.Errorf("sticky queue of stale %s: got %d tasks, want 0", owner, n)
+	}
+	other := "w1"
+	if owner == "w1" {
+		other = "w2"
+	}
+	q, _ := groups.Queue(domain.StickyQueue(other))
+	if n, _ := q.Len(ctx); n != 2 {
+		t.Errorf("sticky queue of %s: got %d tasks, want 2", other, n)
+	}
+}
+
+func TestScheduler_CapacityAssignment(t *testing.T) {
+	fc := clock.NewFake(time.Now().Add(time.Hour)) // past every ScheduledAt
+	wr := newMemWorkerRepo()
+	for _, w := range []*domain.Worker{
+		{ID: "small", Status: domain.WorkerStatusIdle, Concurrency: 1},
+		{ID: "large", Status: domain.WorkerStatusBusy, Concurrency: 4, ActiveTasks: 2},
+		{ID: "full", Status: domain.WorkerStatusBusy, Concurrency: 2, ActiveTasks: 2},
+	} {
+		w.LastHeartAt = fc.Now()
+		_ = wr.Save(ctx, w)
+	}
+	tr := newMemTaskRepo()
+	groups := scheduler.NewGroupQueues(scheduler.NewMemQueue(), nil)
+	sched := scheduler.New(tr, wr, groups, scheduler.WithClock(fc),
+		scheduler.WithCapacityAssignment(groups, time.Minute))
+
+	var assigned []string
+	for i := range 4 {
+		id := fmt.Sprintf("t%d", i)
+		if err := sched.Submit(ctx, validTask(id)); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		stored, _ := tr.FindByID(ctx, id)
+		assigned = append(assigned, stored.WorkerID)
+	}
+	// large has 2 free slots to small's 1; the last task finds none left.
+	if got := strings.Join(assigned, ","); got != "large,small,large," {
+		t.Errorf("assignments: got %q, want large,small,large and one unassigned", got)
+	}
+	for id, want := range map[string]int{"small": 1, "large": 2, "full": 0} {
+		q, _ := groups.Queue(domain.StickyQueue(id))
+		if n, _ := q.Len(ctx); n != want {
+			t.Errorf("queue of %s: got %d tasks, want %d", id, n, want)
+		}
+	}
+	shared, _ := groups.Queue(domain.DefaultGroup)
+	if n, _ := shared.Len(ctx); n != 1 {
+		t.Errorf("shared queue: got %d tasks, want the unassigned one", n)
+	}
+}
+
+// ── Circuit breaker tests ─────────────────────────────────────────────────────
+
+func TestCircuitBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	var opened []string
+	b := scheduler.NewCircuitBreaker(scheduler.BreakerConfig{
+		Threshold: 2,
+		OnOpen:    func(key string, _ int) { opened = append(opened, key) },
+	})
+	task := validTask("t1")
+	task.WorkflowID = "wf-1"
+
+	task.Status = domain.TaskStatusFailed
+	b.Record(task)
+	task.Status = domain.TaskStatusSucceeded
+	b.Record(task) // success resets the streak
+	task.Status = domain.TaskStatusFailed
+	b.Record(task)
+	if !b.Allow(task) {
+		t.Fatal("circuit opened before reaching the threshold")
+	}
+	b.Record(task)
+	if b.Allow(task) {
+		t.Fatal("expected circuit to be open after 2 consecutive failures")
+	}
+	if len(opened) != 2 {
+		t.Errorf("OnOpen calls: got %v, want task and workflow circuits", opened)
+	}
+
+	b.Reset("task:" + task.Name)
+	if b.Allow(task) {
+		t.Error("workflow circuit should still hold the task")
+	}
+	b.Reset("workflow:wf-1")
+	if !b.Allow(task) {
+		t.Error("expected task to be allowed after both circuits were reset")
+	}
+}
+
+func TestScheduler_CircuitBreaker_HoldsUntilReset(t *testing.T) {
+	tr := newMemTaskRepo()
+	q := scheduler.NewMemQueue()
+	b := scheduler.NewCircuitBreaker(scheduler.BreakerConfig{Threshold: 1, OnOpen: func(string, int) {}})
+	sched := scheduler.New(tr, newMemWorkerRepo(), q, scheduler.WithCircuitBreaker(b))
+
+	t1 := validTask("t1")
+	_ = sched.Submit(ctx, t1)
+	_, _ = q.Dequeue(ctx)
+	stored, _ := tr.FindByID(ctx, "t1")
+	stored.Status = domain.TaskStatusFailed
+	_ = tr.Save(ctx, stored)
+	sched.Reconcile(ctx)
+
+	t2 := validTask("t2") // same Name as t1, so the same circuit
+	_ = sched.Submit(ctx, t2)
+	if got, _ := sched.Status(ctx, "t2"); got != domain.TaskStatusPending {
+		t.Fatalf("status while circuit open: got %q, want pending", got)
+	}
+
+	b.Reset("task:" + t2.Name)
+	sched.Reconcile(ctx)
+	if got, _ := sched.Status(ctx, "t2"); got != domain.TaskStatusQueued {
+		t.Errorf("status after reset: got %q, want queued", got)
+	}
+}
+
 // ── interface compliance ──────────────────────────────────────────────────────
 
 var (
 	_ domain.Queue     = (*scheduler.MemQueue)(nil)
+	_ domain.Queue     = (*scheduler.GroupQueues)(nil)
 	_ domain.Scheduler = (*scheduler.Scheduler)(nil)
 )
+
+func TestMemAttemptRepo_OrdersByNumber(t *testing.T) {
+	r := scheduler.NewMemAttemptRepo()
+	for _, n := range []int{2, 1, 3} {
+		_ = r.Record(ctx, &domain.Attempt{TaskID: "t1", Number: n, Status: domain.TaskStatusFailed})
+	}
+	_ = r.Record(ctx, &domain.Attempt{TaskID: "t1", Number: 3, Status: domain.TaskStatusSucceeded})
+
+	got, err := r.ListByTaskID(ctx, "t1")
+	if err != nil {
+		t.Fatalf("ListByTaskID: %v", err)
+	}
+	if len(got) != 3 || got[0].Number != 1 || got[1].Number != 2 || got[2].Number != 3 {
+		t.Fatalf("ListByTaskID = %+v, want attempts 1, 2 and 3", got)
+	}
+	if got[2].Status != domain.TaskStatusSucceeded {
+		t.Errorf("attempt 3: got %s, want the re-recorded succeeded", got[2].Status)
+	}
+	if none, _ := r.ListByTaskID(ctx, "t2"); len(none) != 0 {
+		t.Errorf("unknown task: got %d attempts, want none", len(none))
+	}
+}
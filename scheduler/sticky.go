@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// stickyDrainWait bounds each Dequeue that moves a task off the queue of a
+// worker that went away.
+const stickyDrainWait = time.Second
+
+// WithStickyRouting pins tasks with a RoutingKey to one worker of their
+// group: each is enqueued on the domain.StickyQueue of groups belonging to
+// the worker the key hashes to among the group's idle or busy workers that
+// heartbeated within heartbeatTimeout, or the Scheduler's
+// WithHeartbeatTimeout if it is zero. The hash is rendezvous hashing, so
+// a worker joining or leaving moves only the keys it gains or held.
+// Reconcile moves the tasks waiting for a worker that has gone back
+// through routing. Workers serve their sticky queue with
+// worker.WithStickyQueue.
+func WithStickyRouting(groups *GroupQueues, heartbeatTimeout time.Duration) Option {
+	return func(s *Scheduler) {
+		s.workerQueues = groups
+		if heartbeatTimeout > 0 {
+			s.liveWithin = heartbeatTimeout
+		}
+		s.pinKeys = true
+	}
+}
+
+// route returns the queue task goes on: the domain.StickyQueue of the
+// worker it is pinned or assigned to, whose ID it records in
+// task.WorkerID, or the queue if it has no such worker. A task bound for a
+// named queue always goes on the queue, which routes it there: the workers
+// of its group may not serve that queue.
+func (s *Scheduler) route(ctx context.Context, task *domain.Task) domain.Queue {
+	pinned := s.pinKeys && task.RoutingKey != ""
+	if s.workerQueues == nil || task.Queue != "" || (!pinned && !s.assign) {
+		return s.queue
+	}
+	workers, err := s.liveWorkers(ctx, task.Group)
+	if err != nil {
+		log.Printf("Scheduler: route task %s: %v; using its group queue", task.ID, err)
+		return s.queue
+	}
+	var w *domain.Worker
+	if pinned {
+		w = PickWorker(task.RoutingKey, workers)
+	} else {
+		w = s.assignee(ctx, workers)
+	}
+	if w == nil {
+		return s.queue
+	}
+	q, err := s.workerQueues.Queue(domain.StickyQueue(w.ID))
+	if err != nil {
+		log.Printf("Scheduler: route task %s to worker %s: %v; using its group queue", task.ID, w.ID, err)
+		return s.queue
+	}
+	task.WorkerID = w.ID
+	return q
+}
+
+// liveWorkers returns the idle or busy workers of group that heartbeated
+// within the routing timeout, whatever their free capacity.
+func (s *Scheduler) liveWorkers(ctx context.Context, group string) ([]*domain.Worker, error) {
+	f := domain.WorkerFilter{IgnoreCapacity: true, HeartbeatSince: s.clock.Now().Add(-s.liveWithin)}
+	if group != domain.DefaultGroup {
+		f.Labels = map[string]string{domain.LabelWorkerGroup: group}
+	}
+	candidates, err := s.workers.FindAvailable(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	live := candidates[:0]
+	for _, w := range candidates {
+		if w.Group() == group {
+			live = append(live, w)
+		}
+	}
+	return live, nil
+}
+
+// rehome routes again the tasks waiting on the queue of a worker that can
+// no longer take them, recording their new worker, if any, on the stored
+// task.
+func (s *Scheduler) rehome(ctx context.Context) {
+	for _, name := range s.workerQueues.Groups() {
+		id, ok := strings.CutPrefix(name, domain.StickyQueuePrefix)
+		if !ok {
+			continue
+		}
+		if w, err := s.workers.FindByID(ctx, id); err == nil &&
+			(w.Status == domain.WorkerStatusIdle || w.Status == domain.WorkerStatusBusy) &&
+			s.clock.Now().Sub(w.LastHeartAt) <= s.liveWithin {
+			continue
+		}
+		q, err := s.workerQueues.Queue(name)
+		if err != nil {
+			continue
+		}
+		for {
+			if n, err := q.Len(ctx); err != nil || n == 0 {
+				break
+			}
+			dctx, cancel := context.WithTimeout(ctx, stickyDrainWait)
+			task, err := q.Dequeue(dctx)
+			cancel()
+			if err != nil {
+				break
+			}
+			task.WorkerID = ""
+			if err := s.route(ctx, task).Enqueue(ctx, task); err != nil {
+				log.Printf("Scheduler: move task %s off worker %s: %v", task.ID, id, err)
+				_ = q.Enqueue(ctx, task)
+				break
+			}
+			if stored, err := s.tasks.FindByID(ctx, task.ID); err == nil &&
+				stored.Status == domain.TaskStatusQueued && stored.WorkerID != task.WorkerID {
+				stored.WorkerID = task.WorkerID
+				_ = s.tasks.Save(ctx, stored)
+			}
+		}
+	}
+}
+
+// PickWorker returns the worker among workers that key is pinned to, by
+// rendezvous hashing over worker IDs, or nil if workers is empty.
+func PickWorker(key string, workers []*domain.Worker) *domain.Worker {
+	var best *domain.Worker
+	var bestScore uint64
+	for _, w := range workers {
+		sum := sha256.Sum256([]byte(key + "\x00" + w.ID))
+		score := binary.BigEndian.Uint64(sum[:8])
+		if best == nil || score > bestScore || (score == bestScore && w.ID < best.ID) {
+			best, bestScore = w, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// MaxAttemptLogBytes caps the log kept for an attempt; past it only the
+// latest output is kept, as that is where failures usually show.
+const MaxAttemptLogBytes = 64 << 10
+
+// WithAttemptLog records every attempt the worker finishes, successful or
+// not, in repo, including the output its handler wrote to LogWriter.
+func WithAttemptLog(repo domain.AttemptRepository) Option {
+	return func(w *Worker) { w.attempts = repo }
+}
+
+// AttemptObserver receives the timing of every attempt a worker finishes:
+// its number, its outcome (TaskStatusSucceeded or TaskStatusFailed), how
+// long it waited on the queue once due and how long its handler ran.
+// metrics.Collector implements it.
+type AttemptObserver interface {
+	ObserveAttempt(number int, status string, wait, run time.Duration)
+}
+
+// WithAttemptObserver reports the timing of every attempt the worker
+// finishes to o. An attempt is due at the task's ScheduledAt, or its
+// CreatedAt if later: for a retry, when its backoff ended.
+func WithAttemptObserver(o AttemptObserver) Option {
+	return func(w *Worker) { w.observer = o }
+}
+
+type logWriterKey struct{}
+
+// LogWriter returns the writer for the log of the attempt ctx belongs to.
+// Outside an attempt recorded by WithAttemptLog it discards what is written.
+func LogWriter(ctx context.Context) io.Writer {
+	if lw, ok := ctx.Value(logWriterKey{}).(*attemptLog); ok {
+		return lw
+	}
+	return io.Discard
+}
+
+type clockKey struct{}
+
+// clockFrom returns the clock of the worker running the attempt ctx belongs
+// to, or the real clock outside one.
+func clockFrom(ctx context.Context) clock.Clock {
+	if c, ok := ctx.Value(clockKey{}).(clock.Clock); ok {
+		return c
+	}
+	return clock.Real
+}
+
+// attemptLog is the log of an attempt, keeping the last MaxAttemptLogBytes
+// written. Handlers may write to it from several goroutines.
+type attemptLog struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (l *attemptLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buf = append(l.buf, p...)
+	if over := len(l.buf) - MaxAttemptLogBytes; over > 0 {
+		l.buf = append(l.buf[:0], l.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (l *attemptLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return string(l.buf)
+}
+
+// recordAttempt stores the attempt of task that started at started and
+// whose handler returned err. Recording is best effort: a failure must not
+// change the task's outcome.
+func (w *Worker) recordAttempt(ctx context.Context, task *domain.Task, number int, started, finished time.Time, logs *attemptLog, err error) {
+	if w.attempts == nil {
+		return
+	}
+	a := &domain.Attempt{
+		TaskID:     task.ID,
+		Number:     number,
+		Status:     domain.TaskStatusSucceeded,
+		WorkerID:   w.id,
+		StartedAt:  started,
+		FinishedAt: finished,
+		Logs:       logs.String(),
+	}
+	if err != nil {
+		a.Status, a.Error = domain.TaskStatusFailed, err.Error()
+	}
+	_ = w.attempts.Record(ctx, a)
+}
+
+// observeAttempt reports the attempt of task that started at started to
+// the observer, if any.
+func (w *Worker) observeAttempt(task *domain.Task, number int, started, finished time.Time, err error) {
+	if w.observer == nil {
+		return
+	}
+	status := domain.TaskStatusSucceeded
+	if err != nil {
+		status = domain.TaskStatusFailed
+	}
+	due := task.ScheduledAt
+	if task.CreatedAt.After(due) {
+		due = task.CreatedAt
+	}
+	var wait time.Duration
+	if !due.IsZero() && started.After(due) {
+		wait = started.Sub(due)
+	}
+	w.observer.ObserveAttempt(number, string(status), wait, finished.Sub(started))
+}
@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// DefaultTriggerInterval is how often Triggerer.Run looks for deferred tasks
+// that are due to be polled.
+const DefaultTriggerInterval = time.Second
+
+// ErrNotDeferred is returned by Triggerer.Complete for a task that is not
+// waiting on an external operation.
+var ErrNotDeferred = errors.New("task is not deferred")
+
+// DeferError asks the worker to park the task in TaskStatusDeferred and free
+// its slot while the external operation identified by Token runs. Return it
+// from a Handler via Defer.
+type DeferError struct {
+	Token        string
+	PollInterval time.Duration
+}
+
+func (e *DeferError) Error() string {
+	return fmt.Sprintf("deferred on %q", e.Token)
+}
+
+// Defer returns a DeferError for the operation token. With a positive
+// pollInterval a Triggerer polls the operation; with zero the task waits for
+// Triggerer.Complete to be called, e.g. from a webhook.
+func Defer(token string, pollInterval time.Duration) error {
+	return &DeferError{Token: token, PollInterval: pollInterval}
+}
+
+// Poller checks whether the external operation of a deferred task has
+// finished. It returns done=false while the operation is still running; once
+// done, result is handed to the task's handler and opErr, if non-nil, fails
+// the task.
+type Poller func(ctx context.Context, task *domain.Task) (done bool, result []byte, opErr error)
+
+// Triggerer resumes deferred tasks. It polls tasks whose Deferral has a poll
+// interval using the Poller registered for their Type, and accepts
+// completion callbacks through Complete. A resumed task is queued again with
+// Deferral.Done set so its handler can finish without starting a new
+// operation.
+type Triggerer struct {
+	queue   domain.Queue
+	tasks   domain.TaskRepository
+	pollers map[string]Poller
+}
+
+// NewTriggerer creates a Triggerer. pollers maps domain.Task.Type to the
+// Poller for that type; it may be nil if all deferrals use callbacks.
+func NewTriggerer(queue domain.Queue, tasks domain.TaskRepository, pollers map[string]Poller) *Triggerer {
+	return &Triggerer{queue: queue, tasks: tasks, pollers: pollers}
+}
+
+// Run calls PollDue every DefaultTriggerInterval until ctx is cancelled. It
+// always returns nil when the context expires.
+func (t *Triggerer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(DefaultTriggerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := t.PollDue(ctx); err != nil {
+				log.Printf("Triggerer: %v", err)
+			}
+		}
+	}
+}
+
+// PollDue polls every deferred task whose next poll time has passed.
+func (t *Triggerer) PollDue(ctx context.Context) error {
+	deferred, err := t.tasks.FindByStatus(ctx, domain.TaskStatusDeferred)
+	if err != nil {
+		return fmt.Errorf("list deferred tasks: %w", err)
+	}
+	now := time.Now()
+	for _, task := range deferred {
+		d := task.Deferral
+		if d == nil || d.PollInterval <= 0 || now.Before(d.NextPollAt) {
+			continue
+		}
+		poll, ok := t.pollers[task.Type]
+		if !ok {
+			continue
+		}
+		done, result, opErr := poll(ctx, task)
+		if !done {
+			d.NextPollAt = now.Add(d.PollInterval)
+			task.UpdatedAt = now
+			_ = t.tasks.Save(ctx, task)
+			continue
+		}
+		if err := t.resume(ctx, task, result, opErr); err != nil {
+			log.Printf("Triggerer: resume task %s: %v", task.ID, err)
+		}
+	}
+	return nil
+}
+
+// Complete reports that the external operation of the deferred task taskID
+// has finished, and queues the task so its handler can complete.
+func (t *Triggerer) Complete(ctx context.Context, taskID string, result []byte, opErr error) error {
+	task, err := t.tasks.FindByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if task.Status != domain.TaskStatusDeferred || task.Deferral == nil {
+		return ErrNotDeferred
+	}
+	return t.resume(ctx, task, result, opErr)
+}
+
+// resume records the outcome on task's Deferral and puts it back on the queue.
+func (t *Triggerer) resume(ctx context.Context, task *domain.Task, result []byte, opErr error) error {
+	task.Deferral.Done = true
+	task.Deferral.Result = result
+	if opErr != nil {
+		task.Deferral.Error = opErr.Error()
+	}
+	task.Status = domain.TaskStatusQueued
+	task.UpdatedAt = time.Now()
+	if err := t.tasks.Save(ctx, task); err != nil {
+		return err
+	}
+	return t.queue.Enqueue(ctx, task)
+}
@@ -0,0 +1,256 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+)
+
+// Defaults for the zero fields of a HealthPolicy.
+const (
+	DefaultHealthWindow      = 20
+	DefaultHealthMinAttempts = 5
+	DefaultQuarantine        = 5 * time.Minute
+)
+
+// HealthPolicy makes a worker weigh its own recent attempts when taking
+// tasks. Workers pull from a shared queue, so a failing worker that holds
+// back leaves the tasks to its healthier peers.
+type HealthPolicy struct {
+	Window       int           // recent attempts considered; 0 means DefaultHealthWindow
+	MinAttempts  int           // attempts needed before the failure rate counts; 0 means DefaultHealthMinAttempts
+	MaxPollDelay time.Duration // pause before each poll at a 100% failure rate, scaled by the rate
+	QuarantineAt float64       // failure rate that quarantines the worker; 0 never does
+	Quarantine   time.Duration // how long a quarantine lasts; 0 means DefaultQuarantine
+}
+
+// WithHealthPolicy makes the worker back off as its recent attempts fail,
+// and quarantine itself once p.QuarantineAt is reached: it stops taking
+// tasks for p.Quarantine, marks its record quarantined and publishes a
+// WorkerQuarantined event. It then starts over with a clean record. The
+// worker tracks its failure rate and latency either way.
+func WithHealthPolicy(p HealthPolicy) Option {
+	return func(w *Worker) {
+		w.healthPolicy = p
+		if p.Window > 0 {
+			w.health = newHealthTracker(p.Window)
+		}
+	}
+}
+
+// ParseHealthPolicy parses a comma-separated list of key=value pairs, e.g.
+// "quarantine_at=0.5,quarantine=10m,max_poll_delay=5s". The keys are
+// window, min_attempts, max_poll_delay, quarantine_at and quarantine.
+func ParseHealthPolicy(s string) (HealthPolicy, error) {
+	var p HealthPolicy
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return HealthPolicy{}, fmt.Errorf("health policy %q: must be key=value", entry)
+		}
+		var err error
+		switch key {
+		case "window":
+			p.Window, err = strconv.Atoi(value)
+		case "min_attempts":
+			p.MinAttempts, err = strconv.Atoi(value)
+		case "max_poll_delay":
+			p.MaxPollDelay, err = time.ParseDuration(value)
+		case "quarantine_at":
+			p.QuarantineAt, err = strconv.ParseFloat(value, 64)
+			if err == nil && (p.QuarantineAt < 0 || p.QuarantineAt > 1) {
+				err = errors.New("must be between 0 and 1")
+			}
+		case "quarantine":
+			p.Quarantine, err = time.ParseDuration(value)
+		default:
+			return HealthPolicy{}, fmt.Errorf("health policy %q: unknown key %q", entry, key)
+		}
+		if err == nil && (p.Window < 0 || p.MinAttempts < 0 || p.MaxPollDelay < 0 || p.Quarantine < 0) {
+			err = errors.New("must not be negative")
+		}
+		if err != nil {
+			return HealthPolicy{}, fmt.Errorf("health policy %q: %v", entry, err)
+		}
+	}
+	return p, nil
+}
+
+// outcome is one attempt as seen by the health tracker.
+type outcome struct {
+	failed  bool
+	latency time.Duration
+}
+
+// healthTracker keeps a ring of the worker's latest attempts and the end
+// of its quarantine, if any.
+type healthTracker struct {
+	mu          sync.Mutex
+	ring        []outcome
+	next        int
+	full        bool
+	quarantined time.Time
+}
+
+func newHealthTracker(window int) *healthTracker {
+	return &healthTracker{ring: make([]outcome, window)}
+}
+
+func (h *healthTracker) record(o outcome) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ring[h.next] = o
+	h.next = (h.next + 1) % len(h.ring)
+	h.full = h.full || h.next == 0
+}
+
+// stats returns the failure rate and mean latency of the recorded
+// attempts, and how many there are.
+func (h *healthTracker) stats() (rate float64, avg time.Duration, n int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n = h.next
+	if h.full {
+		n = len(h.ring)
+	}
+	if n == 0 {
+		return 0, 0, 0
+	}
+	var failed int
+	var total time.Duration
+	for _, o := range h.ring[:n] {
+		if o.failed {
+			failed++
+		}
+		total += o.latency
+	}
+	return float64(failed) / float64(n), total / time.Duration(n), n
+}
+
+// quarantine starts a quarantine ending at until, unless one is on.
+func (h *healthTracker) quarantine(until time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.quarantined.IsZero() {
+		return false
+	}
+	h.quarantined = until
+	return true
+}
+
+// release ends the quarantine and forgets the attempts that led to it.
+func (h *healthTracker) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.quarantined = time.Time{}
+	h.next, h.full = 0, false
+}
+
+func (h *healthTracker) quarantinedUntil() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.quarantined
+}
+
+func (p HealthPolicy) minAttempts() int {
+	if p.MinAttempts > 0 {
+		return p.MinAttempts
+	}
+	return DefaultHealthMinAttempts
+}
+
+func (p HealthPolicy) quarantine() time.Duration {
+	if p.Quarantine > 0 {
+		return p.Quarantine
+	}
+	return DefaultQuarantine
+}
+
+// recordHealth adds an attempt to the worker's health and quarantines the
+// worker if its failure rate calls for it.
+func (w *Worker) recordHealth(ctx context.Context, failed bool, latency time.Duration) {
+	w.health.record(outcome{failed: failed, latency: latency})
+	p := w.healthPolicy
+	rate, avg, n := w.health.stats()
+	if p.QuarantineAt <= 0 || n < p.minAttempts() || rate < p.QuarantineAt {
+		return
+	}
+	now := w.clock.Now()
+	until := now.Add(p.quarantine())
+	if !w.health.quarantine(until) {
+		return
+	}
+	log.Printf("Worker %s: %.0f%% of the last %d attempts failed; quarantined until %s",
+		w.id, rate*100, n, until.Format(time.RFC3339))
+	w.setQuarantined(ctx, true)
+	_ = w.events.Publish(ctx, events.Event{Type: events.WorkerQuarantined, Payload: events.Quarantine{
+		WorkerID:     w.id,
+		FailureRate:  rate,
+		Attempts:     n,
+		AvgLatencyMS: avg.Milliseconds(),
+		Until:        until,
+		At:           now,
+	}})
+}
+
+// waitHealthy holds Run back while the worker is quarantined, then for the
+// pause its failure rate earns under the health policy. It returns false if
+// ctx is cancelled first.
+func (w *Worker) waitHealthy(ctx context.Context) bool {
+	if until := w.health.quarantinedUntil(); !until.IsZero() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-w.clock.After(until.Sub(w.clock.Now())):
+		}
+		w.health.release()
+		w.setQuarantined(ctx, false)
+		log.Printf("Worker %s: quarantine over; taking tasks again", w.id)
+	}
+	rate, _, n := w.health.stats()
+	if w.healthPolicy.MaxPollDelay <= 0 || n < w.healthPolicy.minAttempts() || rate == 0 {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-w.clock.After(time.Duration(rate * float64(w.healthPolicy.MaxPollDelay))):
+		return true
+	}
+}
+
+// setQuarantined moves the worker record into or out of quarantine. A
+// drained or offline worker keeps its status.
+func (w *Worker) setQuarantined(ctx context.Context, on bool) {
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+	wrk, err := w.workers.FindByID(ctx, w.id)
+	if err != nil {
+		return
+	}
+	switch {
+	case on && (wrk.Status == domain.WorkerStatusIdle || wrk.Status == domain.WorkerStatusBusy):
+		wrk.Status = domain.WorkerStatusQuarantined
+	case !on && wrk.Status == domain.WorkerStatusQuarantined:
+		wrk.Status = domain.WorkerStatusIdle
+		if w.active.Load() > 0 {
+			wrk.Status = domain.WorkerStatusBusy
+		}
+	default:
+		return
+	}
+	wrk.FailureRate, wrk.AvgLatency, _ = w.health.stats()
+	_ = w.workers.Save(ctx, wrk)
+}
@@ -0,0 +1,142 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// hookTimeout bounds each hook, so a slow endpoint cannot hold a worker
+// slot for long.
+const hookTimeout = 10 * time.Second
+
+// Notifier delivers the message of a notify hook, e.g. to a chat channel.
+type Notifier interface {
+	Notify(ctx context.Context, task *domain.Task, message string) error
+}
+
+// WithNotifier registers n for the notify hooks that name it.
+func WithNotifier(name string, n Notifier) Option {
+	return func(w *Worker) { w.notifiers[name] = n }
+}
+
+// WebhookNotifier posts {"text": message} to URL, the body Slack and
+// Mattermost incoming webhooks accept.
+type WebhookNotifier struct {
+	URL string
+}
+
+// Notify implements Notifier.
+func (n WebhookNotifier) Notify(ctx context.Context, _ *domain.Task, message string) error {
+	return postJSON(ctx, n.URL, map[string]string{"text": message})
+}
+
+// HookPayload is the JSON body an http hook receives.
+type HookPayload struct {
+	Event      string    `json:"event"`
+	TaskID     string    `json:"task_id"`
+	Name       string    `json:"name"`
+	WorkflowID string    `json:"workflow_id,omitempty"`
+	Status     string    `json:"status"`
+	RetryCount int       `json:"retry_count"`
+	Error      string    `json:"error,omitempty"`
+	WorkerID   string    `json:"worker_id"`
+	At         time.Time `json:"at"`
+}
+
+// runHooks runs task's hooks for event, in order. Hooks are best effort: a
+// failure is logged and the next hook still runs.
+func (w *Worker) runHooks(ctx context.Context, task *domain.Task, event idomain.HookEvent) {
+	for _, h := range task.Hooks {
+		if h.On != string(event) {
+			continue
+		}
+		hctx, cancel := context.WithTimeout(ctx, hookTimeout)
+		err := w.runHook(hctx, task, event, h)
+		cancel()
+		if err != nil {
+			log.Printf("Worker %s: task %s: %s %s hook: %v", w.id, task.ID, event, h.Kind, err)
+		}
+	}
+}
+
+func (w *Worker) runHook(ctx context.Context, task *domain.Task, event idomain.HookEvent, h domain.Hook) error {
+	switch idomain.HookKind(h.Kind) {
+	case idomain.HookHTTP:
+		return postJSON(ctx, h.URL, HookPayload{
+			Event:      string(event),
+			TaskID:     task.ID,
+			Name:       task.Name,
+			WorkflowID: task.WorkflowID,
+			Status:     string(task.Status),
+			RetryCount: task.RetryCount,
+			Error:      task.Error,
+			WorkerID:   w.id,
+			At:         task.UpdatedAt,
+		})
+	case idomain.HookEnqueue:
+		now := w.clock.Now()
+		follow := &domain.Task{
+			ID:          uuid.NewString(),
+			Name:        task.Name + "." + string(event),
+			Payload:     []byte(h.Command),
+			Status:      domain.TaskStatusQueued,
+			Priority:    task.Priority,
+			ScheduledAt: now,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			WorkflowID:  task.WorkflowID,
+			Group:       task.Group,
+			Env:         maps.Clone(task.Env),
+		}
+		if err := w.tasks.Save(ctx, follow); err != nil {
+			return err
+		}
+		return w.queue.Enqueue(ctx, follow)
+	case idomain.HookNotify:
+		n, ok := w.notifiers[h.Notifier]
+		if !ok {
+			return fmt.Errorf("no notifier %q", h.Notifier)
+		}
+		msg := h.Message
+		if msg == "" {
+			msg = fmt.Sprintf("task %s (%s): %s", task.Name, task.ID, task.Status)
+			if task.Error != "" {
+				msg += ": " + task.Error
+			}
+		}
+		return n.Notify(ctx, task, msg)
+	}
+	return fmt.Errorf("unknown kind %q", h.Kind)
+}
+
+// postJSON POSTs v to url as JSON and expects a 2xx response.
+func postJSON(ctx context.Context, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("POST %s: %s", url, resp.Status)
+	}
+	return nil
+}
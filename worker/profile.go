@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// DefaultProfile is the name of the profile a worker configured WithProfiles
+// runs tasks under when they name none.
+const DefaultProfile = "default"
+
+// ErrUnknownProfile is returned for a task naming an isolation profile the
+// worker does not have. The task fails without being retried.
+var ErrUnknownProfile = errors.New("unknown isolation profile")
+
+// NetworkPolicy restricts the network a task's process can reach.
+type NetworkPolicy string
+
+const (
+	// NetworkDefault leaves the executor's network as it is: the worker's
+	// own for the shell executor, Docker's default bridge for containers.
+	NetworkDefault NetworkPolicy = ""
+	// NetworkNone gives the process no network but loopback.
+	NetworkNone NetworkPolicy = "none"
+)
+
+// Profile is a named execution environment tasks reference through their
+// Profile field, so that what a class of tasks may touch is set by whoever
+// runs the workers rather than by each task. The shell and Docker executors
+// apply it with Apply and DockerArgs; custom handlers find the profile of
+// their attempt with Isolation.
+type Profile struct {
+	Name string `json:"name"`
+	// WorkDir is the working directory of the task's process; empty keeps
+	// the executor's.
+	WorkDir string `json:"work_dir,omitempty"`
+	// Env is the environment baseline: the process gets these variables
+	// and the task's Env on top, and nothing of the worker's environment.
+	Env map[string]string `json:"env,omitempty"`
+	// User runs the process as a user name or uid, optionally followed by
+	// ":group" or ":gid". Switching users needs the worker to be privileged.
+	User string `json:"user,omitempty"`
+	// Network restricts the process's network. NetworkNone under the shell
+	// executor needs the worker to be allowed to create network namespaces.
+	Network NetworkPolicy `json:"network,omitempty"`
+	// Image is the container image the Docker executor runs the task in,
+	// overriding its default.
+	Image string `json:"image,omitempty"`
+}
+
+// Validate checks that p is complete and its policies are known.
+func (p *Profile) Validate() error {
+	if p.Name == "" {
+		return errors.New("profile name must not be empty")
+	}
+	if p.WorkDir != "" && !filepath.IsAbs(p.WorkDir) {
+		return fmt.Errorf("profile %q: work_dir %q must be absolute", p.Name, p.WorkDir)
+	}
+	if p.Network != NetworkDefault && p.Network != NetworkNone {
+		return fmt.Errorf("profile %q: unknown network policy %q", p.Name, p.Network)
+	}
+	return nil
+}
+
+// ParseProfiles decodes a JSON array of profiles, e.g.
+//
+//	[{"name": "default", "env": {"PATH": "/usr/bin:/bin"}, "network": "none"},
+//	 {"name": "etl", "work_dir": "/srv/etl", "user": "etl", "image": "python:3.12"}]
+//
+// rejecting invalid profiles and duplicate names.
+func ParseProfiles(data []byte) ([]Profile, error) {
+	var profiles []Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("decode profiles: %w", err)
+	}
+	seen := make(map[string]bool, len(profiles))
+	for i := range profiles {
+		if err := profiles[i].Validate(); err != nil {
+			return nil, err
+		}
+		if seen[profiles[i].Name] {
+			return nil, fmt.Errorf("duplicate profile %q", profiles[i].Name)
+		}
+		seen[profiles[i].Name] = true
+	}
+	return profiles, nil
+}
+
+// LoadProfiles reads and parses the profiles file at path.
+func LoadProfiles(path string) ([]Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseProfiles(data)
+}
+
+// WithProfiles gives the worker the isolation profiles tasks may name. A task
+// naming none runs under DefaultProfile if it is among them, and a task
+// naming a profile the worker does not have fails with ErrUnknownProfile.
+// The handler gets the task with the profile's Env beneath its own.
+func WithProfiles(profiles ...Profile) Option {
+	return func(w *Worker) {
+		w.profiles = make(map[string]*Profile, len(profiles))
+		for i := range profiles {
+			p := profiles[i]
+			w.profiles[p.Name] = &p
+		}
+	}
+}
+
+// profile returns the profile task runs under, nil if none.
+func (w *Worker) profile(task *domain.Task) (*Profile, error) {
+	if task.Profile == "" {
+		return w.profiles[DefaultProfile], nil
+	}
+	if p, ok := w.profiles[task.Profile]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownProfile, task.Profile)
+}
+
+type profileKey struct{}
+
+// Isolation returns the profile the attempt ctx belongs to runs under, or nil
+// if it runs unconstrained.
+func Isolation(ctx context.Context) *Profile {
+	p, _ := ctx.Value(profileKey{}).(*Profile)
+	return p
+}
+
+// Apply configures cmd to run the task whose environment is env under p. On a
+// nil profile cmd inherits the worker's environment with env added.
+func (p *Profile) Apply(cmd *exec.Cmd, env map[string]string) error {
+	if p == nil {
+		cmd.Env = append(os.Environ(), environ(env)...)
+		return nil
+	}
+	attr, err := p.sysProcAttr()
+	if err != nil {
+		return err
+	}
+	cmd.Dir = p.WorkDir
+	cmd.Env = environ(env)
+	cmd.SysProcAttr = attr
+	return nil
+}
+
+// DockerArgs returns the "docker run" flags that apply p to a container
+// running the task whose environment is env. Variables are passed by name
+// only, so their values, secrets included, stay off the command line: the
+// docker client must run with env in its own environment. A nil profile
+// only passes env.
+func (p *Profile) DockerArgs(env map[string]string) []string {
+	var args []string
+	if p != nil {
+		if p.WorkDir != "" {
+			args = append(args, "--workdir", p.WorkDir)
+		}
+		if p.User != "" {
+			args = append(args, "--user", p.User)
+		}
+		if p.Network == NetworkNone {
+			args = append(args, "--network", "none")
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		args = append(args, "--env", k)
+	}
+	return args
+}
+
+// environ formats env as KEY=value pairs, sorted by key.
+func environ(env map[string]string) []string {
+	out := make([]string, 0, len(env))
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		out = append(out, k+"="+env[k])
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// sysProcAttr returns the process attributes that switch to p's user and cut
+// off its network.
+func (p *Profile) sysProcAttr() (*syscall.SysProcAttr, error) {
+	attr := &syscall.SysProcAttr{}
+	if p.User != "" {
+		cred, err := credential(p.User)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+		attr.Credential = cred
+	}
+	if p.Network == NetworkNone {
+		attr.Cloneflags = syscall.CLONE_NEWNET
+	}
+	return attr, nil
+}
+
+// credential resolves "user[:group]", each a name or a numeric ID. Without a
+// group the user's primary group is used.
+func credential(spec string) (*syscall.Credential, error) {
+	name, group, hasGroup := strings.Cut(spec, ":")
+	var uid, gid uint64
+	if n, err := strconv.ParseUint(name, 10, 32); err == nil {
+		uid, gid = n, n
+		if u, err := user.LookupId(name); err == nil {
+			gid, _ = strconv.ParseUint(u.Gid, 10, 32)
+		}
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		uid, _ = strconv.ParseUint(u.Uid, 10, 32)
+		gid, _ = strconv.ParseUint(u.Gid, 10, 32)
+	}
+	if hasGroup {
+		if n, err := strconv.ParseUint(group, 10, 32); err == nil {
+			gid = n
+		} else {
+			g, err := user.LookupGroup(group)
+			if err != nil {
+				return nil, err
+			}
+			gid, _ = strconv.ParseUint(g.Gid, 10, 32)
+		}
+	}
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil
+}
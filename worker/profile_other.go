@@ -0,0 +1,17 @@
+//go:build !linux
+
+package worker
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// sysProcAttr refuses the user and network policies, which the shell
+// executor can only enforce on Linux.
+func (p *Profile) sysProcAttr() (*syscall.SysProcAttr, error) {
+	if p.User != "" || p.Network != NetworkDefault {
+		return nil, fmt.Errorf("profile %q: user and network policies are only supported on Linux", p.Name)
+	}
+	return nil, nil
+}
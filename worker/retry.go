@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// DefaultRetryPoll is how often a worker releases due retries from its
+// retry queue when WithRetryQueue is given no interval.
+const DefaultRetryPoll = time.Second
+
+// WithRetryQueue parks retrying tasks on rq for their backoff, and
+// rescheduled tasks for their RescheduleError delay, and has Run release
+// the due ones onto the queue every poll, at most batch at a time (0
+// releases them all). Spreading the release caps how fast a burst of
+// failures comes back, and on a shared rq such as a RedisRetryQueue a
+// retry outlives the worker that scheduled it. Without a retry queue the
+// worker holds each retry on an in-process timer. Either way the task's
+// slot is free while it waits.
+func WithRetryQueue(rq domain.RetryQueue, poll time.Duration, batch int) Option {
+	return func(w *Worker) {
+		if poll <= 0 {
+			poll = DefaultRetryPoll
+		}
+		w.retries, w.retryPoll, w.retryBatch = rq, poll, batch
+	}
+}
+
+// scheduleRetry puts task back on the queue at at. It falls back to an
+// in-process timer if the retry queue refuses the task. A task pinned to
+// this worker always waits on the timer, so it comes back here.
+func (w *Worker) scheduleRetry(ctx context.Context, task *domain.Task, at time.Time) {
+	if w.retries != nil && !w.pinned(task) {
+		err := w.retries.Schedule(ctx, task, at)
+		if err == nil {
+			return
+		}
+		log.Printf("Worker %s: retry queue refused task %s, holding it here: %v", w.id, task.ID, err)
+	}
+	delay := at.Sub(w.clock.Now())
+	if delay <= 0 {
+		_ = w.requeue(ctx, task)
+		return
+	}
+	w.clock.AfterFunc(delay, func() {
+		if ctx.Err() == nil {
+			_ = w.requeue(ctx, task)
+		}
+	})
+}
+
+// retryLoop releases the due retries of the retry queue every poll until
+// ctx is cancelled.
+func (w *Worker) retryLoop(ctx context.Context) {
+	ticker := w.clock.NewTicker(w.retryPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if _, err := w.retries.Release(ctx, w.clock.Now(), w.retryBatch); err != nil {
+				log.Printf("Worker %s: release retries: %v", w.id, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/clock"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// secretCache resolves the secret references in task Env against a
+// SecretStore. Values are cached for ttl, or until a rotation of the
+// secret is announced through invalidate.
+type secretCache struct {
+	store domain.SecretStore
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// WithSecrets makes the worker replace task Env values of the form
+// "secret://name" with the value of secret name in store just before the
+// handler runs. The handler sees the values; the stored task keeps the
+// references. A value is cached for ttl (0 disables caching), and a
+// SecretRotated event on the WithControl bus drops it at once.
+func WithSecrets(store domain.SecretStore, ttl time.Duration) Option {
+	return func(w *Worker) {
+		w.secrets = &secretCache{store: store, ttl: ttl, entries: make(map[string]cachedSecret)}
+	}
+}
+
+// resolve returns a copy of env with every secret reference replaced by its
+// value. An unknown secret is an error.
+func (c *secretCache) resolve(ctx context.Context, env map[string]string) (map[string]string, error) {
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		if name, ok := domain.SecretRef(v); ok {
+			val, err := c.get(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("resolve secret %q for env %s: %w", name, k, err)
+			}
+			v = val
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (c *secretCache) get(ctx context.Context, name string) (string, error) {
+	now := c.clock.Now()
+	c.mu.Lock()
+	e, ok := c.entries[name]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.value, nil
+	}
+	v, err := c.store.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if c.ttl > 0 {
+		c.mu.Lock()
+		c.entries[name] = cachedSecret{value: v, expires: now.Add(c.ttl)}
+		c.mu.Unlock()
+	}
+	return v, nil
+}
+
+// invalidate drops the cached value of the named secret.
+func (c *secretCache) invalidate(name string) {
+	c.mu.Lock()
+	delete(c.entries, name)
+	c.mu.Unlock()
+}
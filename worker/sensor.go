@@ -0,0 +1,216 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+)
+
+// TaskTypeSensor is the domain.Task.Type handled by SensorHandler.
+const TaskTypeSensor = "sensor"
+
+// Sensor kinds understood by SensorHandler.
+const (
+	SensorFile = "file" // Path exists (local path or s3://bucket/key)
+	SensorHTTP = "http" // GET URL returns 200
+	SensorTime = "time" // the wall clock has reached At
+	// SensorExternalRun waits for the run of WorkflowID at ExecutionDate to
+	// reach Status.
+	SensorExternalRun = "external_run"
+)
+
+// Default poke interval and timeout applied when a SensorSpec omits them.
+const (
+	DefaultPokeInterval  = 30 * time.Second
+	DefaultSensorTimeout = time.Hour
+)
+
+// SensorHTTPTimeout bounds each poke of an http sensor made with the client
+// SensorHandler builds when given none, so a hung endpoint is "not yet"
+// rather than a held slot.
+const SensorHTTPTimeout = 10 * time.Second
+
+// ErrSensorTimeout is returned when a sensor's condition was not met before
+// its timeout elapsed. It is not retried.
+var ErrSensorTimeout = errors.New("sensor timed out")
+
+// ErrExternalRunFailed is returned by an external_run sensor when the run it
+// waits for finished in a status other than the expected one. It is not
+// retried.
+var ErrExternalRunFailed = errors.New("external workflow run finished in an unexpected status")
+
+// SensorSpec is the JSON document carried in a sensor task's Payload.
+type SensorSpec struct {
+	Kind                string    `json:"kind"`
+	Path                string    `json:"path,omitempty"`
+	URL                 string    `json:"url,omitempty"`
+	At                  time.Time `json:"at,omitempty"`
+	PokeIntervalSeconds int       `json:"poke_interval_seconds,omitempty"`
+	TimeoutSeconds      int       `json:"timeout_seconds,omitempty"`
+
+	// WorkflowID, ExecutionDate and Status configure SensorExternalRun.
+	// ExecutionDate is matched against the run's LogicalDate, or its
+	// StartedAt for runs without one; Status defaults to "success".
+	WorkflowID    uuid.UUID `json:"workflow_id,omitempty"`
+	ExecutionDate time.Time `json:"execution_date,omitempty"`
+	Status        string    `json:"status,omitempty"`
+}
+
+// WorkflowRuns looks up the runs of other workflows for external_run
+// sensors. repository.WorkflowRunRepository satisfies it.
+type WorkflowRuns interface {
+	ListByWorkflowID(ctx context.Context, workflowID uuid.UUID) ([]*idomain.WorkflowRun, error)
+}
+
+// SensorOption is a functional option for configuring SensorHandler.
+type SensorOption func(*sensorDeps)
+
+type sensorDeps struct {
+	runs WorkflowRuns
+}
+
+// WithWorkflowRuns enables external_run sensors, which fail without it.
+func WithWorkflowRuns(r WorkflowRuns) SensorOption {
+	return func(d *sensorDeps) { d.runs = r }
+}
+
+// ObjectStore answers existence checks for s3:// paths. Plug in an S3 (or
+// S3-compatible) client; SensorHandler rejects s3:// paths when it is nil.
+type ObjectStore interface {
+	Exists(ctx context.Context, bucket, key string) (bool, error)
+}
+
+// RescheduleError asks the worker to put the task back on the queue after
+// the given delay instead of holding a slot while it waits. It does not
+// count as a retry.
+type RescheduleError struct {
+	After time.Duration
+}
+
+func (e *RescheduleError) Error() string {
+	return fmt.Sprintf("rescheduled in %s", e.After)
+}
+
+// SensorHandler returns a Handler that pokes the condition described by the
+// task's SensorSpec once per execution. If the condition is not yet met it
+// returns a RescheduleError for the poke interval, releasing the worker slot
+// between pokes; once the timeout (measured from Task.CreatedAt) has elapsed
+// it returns ErrSensorTimeout. Time is read from the clock of the worker
+// running the task. A nil client is replaced by one with SensorHTTPTimeout.
+func SensorHandler(store ObjectStore, client *http.Client, opts ...SensorOption) Handler {
+	if client == nil {
+		client = &http.Client{Timeout: SensorHTTPTimeout}
+	}
+	var deps sensorDeps
+	for _, o := range opts {
+		o(&deps)
+	}
+	return func(ctx context.Context, task *domain.Task) error {
+		var spec SensorSpec
+		if err := json.Unmarshal(task.Payload, &spec); err != nil {
+			return fmt.Errorf("sensor: invalid spec: %w", err)
+		}
+		now := clockFrom(ctx).Now()
+		ok, err := poke(ctx, spec, now, store, client, deps)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		timeout := DefaultSensorTimeout
+		if spec.TimeoutSeconds > 0 {
+			timeout = time.Duration(spec.TimeoutSeconds) * time.Second
+		}
+		if now.Sub(task.CreatedAt) >= timeout {
+			return ErrSensorTimeout
+		}
+		interval := DefaultPokeInterval
+		if spec.PokeIntervalSeconds > 0 {
+			interval = time.Duration(spec.PokeIntervalSeconds) * time.Second
+		}
+		return &RescheduleError{After: interval}
+	}
+}
+
+// poke evaluates spec's condition once, at now.
+func poke(ctx context.Context, spec SensorSpec, now time.Time, store ObjectStore, client *http.Client, deps sensorDeps) (bool, error) {
+	switch spec.Kind {
+	case SensorFile:
+		if rest, ok := strings.CutPrefix(spec.Path, "s3://"); ok {
+			if store == nil {
+				return false, errors.New("sensor: no object store configured for s3:// paths")
+			}
+			bucket, key, _ := strings.Cut(rest, "/")
+			return store.Exists(ctx, bucket, key)
+		}
+		_, err := os.Stat(spec.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return err == nil, err
+	case SensorHTTP:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, spec.URL, nil)
+		if err != nil {
+			return false, fmt.Errorf("sensor: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			// An unreachable endpoint is "not yet", not a failure.
+			return false, nil
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode == http.StatusOK, nil
+	case SensorTime:
+		return !now.Before(spec.At), nil
+	case SensorExternalRun:
+		return pokeExternalRun(ctx, spec, deps.runs)
+	default:
+		return false, fmt.Errorf("sensor: unknown kind %q", spec.Kind)
+	}
+}
+
+// pokeExternalRun reports whether the run of spec.WorkflowID at
+// spec.ExecutionDate has reached spec.Status. A missing or unfinished run is
+// "not yet"; a run that finished in any other status fails the sensor.
+func pokeExternalRun(ctx context.Context, spec SensorSpec, runs WorkflowRuns) (bool, error) {
+	if runs == nil {
+		return false, errors.New("sensor: no workflow run store configured for external_run")
+	}
+	if spec.WorkflowID == uuid.Nil || spec.ExecutionDate.IsZero() {
+		return false, errors.New("sensor: external_run requires workflow_id and execution_date")
+	}
+	want := idomain.Status(spec.Status)
+	if want == "" {
+		want = idomain.StatusSuccess
+	}
+	list, err := runs.ListByWorkflowID(ctx, spec.WorkflowID)
+	if err != nil {
+		return false, fmt.Errorf("sensor: list runs: %w", err)
+	}
+	for _, r := range list {
+		date := r.StartedAt
+		if r.LogicalDate != nil {
+			date = *r.LogicalDate
+		}
+		if !date.Equal(spec.ExecutionDate) {
+			continue
+		}
+		if r.Status == want {
+			return true, nil
+		}
+		if r.Status.IsTerminal() {
+			return false, fmt.Errorf("%w: run %s is %s, want %s", ErrExternalRunFailed, r.ID, r.Status, want)
+		}
+	}
+	return false, nil
+}
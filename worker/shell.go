@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// ShellHandler is a Handler that runs the task's Payload with "sh -c" under
+// the attempt's isolation profile, with the task's Env. The command's output
+// goes to the attempt log.
+func ShellHandler(ctx context.Context, task *domain.Task) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", string(task.Payload))
+	if err := Isolation(ctx).Apply(cmd, task.Env); err != nil {
+		return err
+	}
+	cmd.Stdout, cmd.Stderr = LogWriter(ctx), LogWriter(ctx)
+	return cmd.Run()
+}
+
+// DockerHandler returns a Handler that runs the task's Payload with "sh -c"
+// in a fresh container of image, or of the image of the attempt's isolation
+// profile if it sets one, with the profile applied through DockerArgs.
+func DockerHandler(image string) Handler {
+	return func(ctx context.Context, task *domain.Task) error {
+		profile, img := Isolation(ctx), image
+		if profile != nil && profile.Image != "" {
+			img = profile.Image
+		}
+		if img == "" {
+			return errors.New("docker executor: no image configured for the task")
+		}
+		args := append([]string{"run", "--rm"}, profile.DockerArgs(task.Env)...)
+		args = append(args, img, "sh", "-c", string(task.Payload))
+		cmd := exec.CommandContext(ctx, "docker", args...)
+		cmd.Env = append(os.Environ(), environ(task.Env)...)
+		cmd.Stdout, cmd.Stderr = LogWriter(ctx), LogWriter(ctx)
+		return cmd.Run()
+	}
+}
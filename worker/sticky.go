@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/sauravritesh63/GoLang-Project-/domain"
+)
+
+// DefaultStickyPoll is how long a worker with a sticky queue waits on its
+// group queue before checking the sticky queue again, when WithStickyQueue
+// is given no interval.
+const DefaultStickyPoll = time.Second
+
+// WithStickyQueue makes the worker also serve q, the domain.StickyQueue
+// holding the tasks a scheduler with WithStickyRouting or
+// WithCapacityAssignment routed to it. Tasks
+// waiting there are taken before those of the group queue, which is waited
+// on for at most poll at a time so a task routed here never waits longer
+// than that. A task pinned by its RoutingKey that is retried or rescheduled
+// comes back to q; other retries go to the group queue.
+func WithStickyQueue(q domain.Queue, poll time.Duration) Option {
+	return func(w *Worker) {
+		if poll <= 0 {
+			poll = DefaultStickyPoll
+		}
+		w.sticky, w.stickyPoll = q, poll
+	}
+}
+
+// requeue puts task back on the queue it should next be taken from: the
+// sticky queue for a pinned task, the group queue otherwise.
+func (w *Worker) requeue(ctx context.Context, task *domain.Task) error {
+	if w.pinned(task) {
+		return w.sticky.Enqueue(ctx, task)
+	}
+	return w.queue.Enqueue(ctx, task)
+}
+
+// pinned reports whether task stays with this worker.
+func (w *Worker) pinned(task *domain.Task) bool {
+	return w.sticky != nil && task.RoutingKey != ""
+}
@@ -5,10 +5,19 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"maps"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/sauravritesh63/GoLang-Project-/clock"
 	"github.com/sauravritesh63/GoLang-Project-/domain"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
 )
 
 // Handler is the function type responsible for executing a task's payload.
@@ -49,25 +58,137 @@ type Worker struct {
 	workers domain.WorkerRepository
 	handler Handler
 
+	// handlers maps domain.Task.Type to a dedicated Handler; tasks whose type
+	// is not registered run on handler.
+	handlers map[string]Handler
+
 	heartbeatInterval time.Duration
 	backoff           BackoffFunc
+	events            events.Publisher
+	clock             clock.Clock
+	heartbeats        domain.HeartbeatRepository
+	attempts          domain.AttemptRepository
+	observer          AttemptObserver
+	freeze            domain.FreezeRepository
+	freezePoll        time.Duration
+	poll              domain.PollConfig
+	health            *healthTracker
+	healthPolicy      HealthPolicy
+	retries           domain.RetryQueue
+	retryPoll         time.Duration
+	retryBatch        int
+	control           events.Bus
+	secrets           *secretCache
+	payloads          domain.PayloadStore
+	sticky            domain.Queue
+	stickyPoll        time.Duration
+	group             string
+	notifiers         map[string]Notifier
+	profiles          map[string]*Profile
+
+	// active counts the tasks being executed. stateMu serialises the
+	// read-modify-write of the worker record between execute and the
+	// heartbeat loop so neither overwrites the other's update.
+	active  atomic.Int64
+	stateMu sync.Mutex
+
+	// concurrency caps active; slotFreed wakes Run when a task finishes or
+	// the cap is raised, and inflight lets Run wait for running tasks.
+	concurrency atomic.Int64
+	slotFreed   chan struct{}
+	inflight    sync.WaitGroup
 }
 
 // Option is a functional option for configuring a Worker.
 type Option func(*Worker)
 
 // WithHeartbeatInterval sets the interval between heartbeat updates.
-// The default is 15 seconds.
+// The default is domain.DefaultHeartbeatInterval.
 func WithHeartbeatInterval(d time.Duration) Option {
 	return func(w *Worker) { w.heartbeatInterval = d }
 }
 
 // WithBackoff sets the backoff function used to compute the delay before
-// each retry. The default is DefaultBackoff (exponential, capped at 30 s).
+// each retry of tasks without their own Retry policy. The default is
+// DefaultBackoff (exponential, capped at 30 s).
 func WithBackoff(fn BackoffFunc) Option {
 	return func(w *Worker) { w.backoff = fn }
 }
 
+// WithEvents publishes a TaskStatus event whenever the worker changes a
+// task's status, and a WorkerHeartbeat event on every heartbeat, to p.
+func WithEvents(p events.Publisher) Option {
+	return func(w *Worker) { w.events = p }
+}
+
+// WithHeartbeatLog records every heartbeat in repo, giving the worker a
+// heartbeat history alongside its LastHeartAt.
+func WithHeartbeatLog(repo domain.HeartbeatRepository) Option {
+	return func(w *Worker) { w.heartbeats = repo }
+}
+
+// WithDispatchFreeze makes the worker stop taking tasks off the queue while
+// the switch in repo is frozen, checking it again every poll interval.
+func WithDispatchFreeze(repo domain.FreezeRepository, poll time.Duration) Option {
+	return func(w *Worker) {
+		w.freeze = repo
+		w.freezePoll = poll
+	}
+}
+
+// WithPolling tunes how the worker polls its queue. With c.LongPoll each
+// Dequeue gives up after that long, so the worker re-checks the dispatch
+// freeze and drain commands between polls even on a queue that blocks;
+// after an empty poll it pauses for c.IdleDelay before the next. The
+// default blocks in Dequeue until a task arrives.
+func WithPolling(c domain.PollConfig) Option {
+	return func(w *Worker) { w.poll = c }
+}
+
+// WithControl subscribes the worker to WorkerCommand events on bus, so the
+// API can drain it or change its concurrency remotely, and to SecretRotated
+// events for WithSecrets.
+func WithControl(bus events.Bus) Option {
+	return func(w *Worker) { w.control = bus }
+}
+
+// WithClock sets the clock used for task timestamps, retry waits and the
+// heartbeat loop. The default is clock.Real.
+func WithClock(c clock.Clock) Option {
+	return func(w *Worker) { w.clock = c }
+}
+
+// WithConcurrency sets how many tasks the worker executes at once. The
+// default is 1; SetConcurrency changes it while the worker runs.
+func WithConcurrency(n int) Option {
+	return func(w *Worker) {
+		if n > 0 {
+			w.concurrency.Store(int64(n))
+		}
+	}
+}
+
+// WithHandler registers h for tasks whose Type equals taskType.
+func WithHandler(taskType string, h Handler) Option {
+	return func(w *Worker) { w.handlers[taskType] = h }
+}
+
+// WithPayloadStore loads the payloads of tasks whose PayloadRef points
+// into store, as an OffloadQueue from the queue package leaves them, just
+// before the handler runs. A payload that cannot be loaded fails the
+// attempt, which is retried as usual.
+func WithPayloadStore(store domain.PayloadStore) Option {
+	return func(w *Worker) { w.payloads = store }
+}
+
+// WithGroup records that the worker belongs to the named worker group, as
+// its domain.LabelWorkerGroup label. The worker's queue should be that
+// group's, e.g. from scheduler.GroupQueues.Queue, so it only takes the
+// group's tasks.
+func WithGroup(name string) Option {
+	return func(w *Worker) { w.group = name }
+}
+
 // New creates a Worker with the given ID, dependencies, and task handler.
 func New(
 	id string,
@@ -83,105 +204,523 @@ func New(
 		tasks:             tasks,
 		workers:           workers,
 		handler:           handler,
-		heartbeatInterval: 15 * time.Second,
+		handlers:          make(map[string]Handler),
+		notifiers:         make(map[string]Notifier),
+		heartbeatInterval: domain.DefaultHeartbeatInterval,
 		backoff:           DefaultBackoff,
+		events:            events.Discard,
+		clock:             clock.Real,
+		slotFreed:         make(chan struct{}, 1),
+		health:            newHealthTracker(DefaultHealthWindow),
 	}
+	w.concurrency.Store(1)
 	for _, o := range opts {
 		o(w)
 	}
+	if w.secrets != nil {
+		w.secrets.clock = w.clock
+	}
 	return w
 }
 
 // Run registers the worker, starts the heartbeat loop, and processes tasks
 // until ctx is cancelled. It always returns nil when the context expires.
+// A worker whose record was deactivated is not registered again: Run
+// returns an error wrapping domain.ErrWorkerDeactivated until it is
+// restored.
+//
+// With WithControl, a drain command makes Run stop taking tasks, finish the
+// one in progress, mark the worker drained and return nil.
 func (w *Worker) Run(ctx context.Context) error {
-	now := time.Now()
+	now := w.clock.Now()
 	wrk := &domain.Worker{
 		ID:           w.id,
 		Address:      w.id,
 		Status:       domain.WorkerStatusIdle,
-		Concurrency:  1,
+		Concurrency:  int(w.concurrency.Load()),
 		ActiveTasks:  0,
 		LastHeartAt:  now,
 		RegisteredAt: now,
 	}
+	if w.group != domain.DefaultGroup {
+		wrk.Labels = map[string]string{domain.LabelWorkerGroup: w.group}
+	}
+	if prev, err := w.workers.FindByID(ctx, w.id); err == nil && prev.Status == domain.WorkerStatusDeactivated {
+		return fmt.Errorf("worker register: %s: %w", w.id, domain.ErrWorkerDeactivated)
+	}
 	if err := w.workers.Save(ctx, wrk); err != nil {
 		return fmt.Errorf("worker register: %w", err)
 	}
 
+	// intake governs taking new tasks; cancelling it drains the worker
+	// while tasks in progress keep running on ctx.
+	intake, drain := context.WithCancel(ctx)
+	defer drain()
+	if w.control != nil {
+		sub, err := w.control.Subscribe(intake)
+		if err != nil {
+			return fmt.Errorf("worker control: %w", err)
+		}
+		go w.listen(ctx, sub, drain)
+	}
+
 	go w.heartbeatLoop(ctx)
+	if w.retries != nil {
+		go w.retryLoop(ctx)
+	}
 
-	for {
-		task, err := w.queue.Dequeue(ctx)
+	for empty := 0; ; {
+		if intake.Err() != nil || !w.waitUnfrozen(intake) || !w.waitForSlot(intake) || !w.waitHealthy(intake) {
+			return w.stop(ctx)
+		}
+		task, err := w.dequeue(intake)
 		if err != nil {
-			// Context cancelled — clean shutdown.
-			if ctx.Err() != nil {
-				return nil
+			// Context cancelled — clean shutdown or drain.
+			if intake.Err() != nil {
+				return w.stop(ctx)
+			}
+			if !errors.Is(err, domain.ErrQueueEmpty) {
+				return err
+			}
+			empty++
+			if !w.idle(intake, empty) {
+				return w.stop(ctx)
 			}
+			continue
+		}
+		empty = 0
+		// Dispatch may have been frozen while Dequeue was blocked: hold the
+		// task rather than run it, and hand it back if shutting down.
+		if !w.waitUnfrozen(intake) {
+			_ = w.requeue(context.WithoutCancel(ctx), task)
+			w.ack(ctx, task)
+			return w.stop(ctx)
+		}
+		if task.Expired(w.clock.Now()) {
+			w.expire(ctx, task)
+			w.ack(ctx, task)
+			continue
+		}
+		// The task is this worker's from here; execute persists the
+		// assignment with the running status.
+		dispatched := w.clock.Now()
+		task.WorkerID, task.DispatchedAt = w.id, &dispatched
+		w.track(ctx, task)
+	}
+}
+
+// listen applies the commands for this worker that arrive on sub: drain
+// calls drain, set_concurrency calls SetConcurrency. Secret rotations drop
+// the cached value.
+func (w *Worker) listen(ctx context.Context, sub <-chan events.Event, drain context.CancelFunc) {
+	for e := range sub {
+		if e.Type == events.SecretRotated && w.secrets != nil {
+			var s events.Secret
+			if e.DecodePayload(&s) == nil {
+				w.secrets.invalidate(s.Name)
+			}
+			continue
+		}
+		if e.Type != events.WorkerCommand {
+			continue
+		}
+		var cmd events.Command
+		if err := e.DecodePayload(&cmd); err != nil || cmd.WorkerID != w.id {
+			continue
+		}
+		switch cmd.Action {
+		case events.CommandDrain:
+			drain()
+		case events.CommandSetConcurrency:
+			_ = w.SetConcurrency(ctx, cmd.Concurrency)
+		}
+	}
+}
+
+// stop ends Run once the tasks in progress have finished. If ctx is still
+// live the worker was drained, which is recorded on its worker record
+// unless it has been deactivated meanwhile.
+func (w *Worker) stop(ctx context.Context) error {
+	w.inflight.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+	wrk, err := w.workers.FindByID(ctx, w.id)
+	if err != nil || wrk.Status == domain.WorkerStatusDeactivated {
+		return nil
+	}
+	wrk.Status = domain.WorkerStatusDrained
+	_ = w.workers.Save(ctx, wrk)
+	return nil
+}
+
+// dequeue takes the next task off the sticky queue, if it has one waiting,
+// or the queue, giving up with domain.ErrQueueEmpty after the long-poll
+// timeout set with WithPolling or the WithStickyQueue poll.
+func (w *Worker) dequeue(ctx context.Context) (*domain.Task, error) {
+	wait := w.poll.LongPoll
+	if w.sticky != nil {
+		// Only this worker takes from its sticky queue, so a task counted
+		// here is still there to dequeue.
+		if n, err := w.sticky.Len(ctx); err == nil && n > 0 {
+			return w.sticky.Dequeue(ctx)
+		}
+		if wait <= 0 || wait > w.stickyPoll {
+			wait = w.stickyPoll
+		}
+	}
+	if wait <= 0 {
+		return w.queue.Dequeue(ctx)
+	}
+	pctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-pctx.Done():
+		case <-w.clock.After(wait):
+			cancel()
+		}
+	}()
+	return w.queue.Dequeue(pctx)
+}
+
+// idle pauses after the given number of consecutive empty polls. It
+// returns false if ctx is cancelled first.
+func (w *Worker) idle(ctx context.Context, empty int) bool {
+	d := w.poll.IdleDelay(empty)
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-w.clock.After(d):
+		return true
+	}
+}
+
+// waitUnfrozen blocks while the dispatch freeze is on. It returns false if
+// ctx is cancelled first. A switch that cannot be read counts as unfrozen,
+// so a database hiccup does not stall every worker.
+func (w *Worker) waitUnfrozen(ctx context.Context) bool {
+	if w.freeze == nil {
+		return true
+	}
+	for {
+		f, err := w.freeze.Get(ctx)
+		if err != nil || !f.Frozen {
+			return ctx.Err() == nil
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-w.clock.After(w.freezePoll):
+		}
+	}
+}
+
+// run calls h with task. With WithSecrets, h gets a copy of task whose Env
+// has its secret references resolved, so the values are never saved; an
+// offloaded payload is likewise loaded into the copy only. Under an isolation
+// profile the copy's Env also includes the profile's baseline.
+func (w *Worker) run(ctx context.Context, h Handler, task *domain.Task) error {
+	profile, err := w.profile(task)
+	if err != nil {
+		return err
+	}
+	if profile != nil {
+		ctx = context.WithValue(ctx, profileKey{}, profile)
+	}
+	if w.secrets == nil && task.PayloadRef == "" && profile == nil {
+		return h(ctx, task)
+	}
+	resolved := *task
+	if profile != nil {
+		resolved.Env = maps.Clone(profile.Env)
+		if resolved.Env == nil {
+			resolved.Env = make(map[string]string, len(task.Env))
+		}
+		maps.Copy(resolved.Env, task.Env)
+	}
+	if w.secrets != nil {
+		env, err := w.secrets.resolve(ctx, resolved.Env)
+		if err != nil {
 			return err
 		}
+		resolved.Env = env
+	}
+	if task.PayloadRef != "" && len(task.Payload) == 0 {
+		if w.payloads == nil {
+			return fmt.Errorf("task %s has an offloaded payload and the worker has no payload store", task.ID)
+		}
+		payload, err := w.payloads.Get(ctx, task.PayloadRef)
+		if err != nil {
+			return fmt.Errorf("load payload %s: %w", task.PayloadRef, err)
+		}
+		resolved.Payload = payload
+	}
+	return h(ctx, &resolved)
+}
+
+// SetConcurrency changes how many tasks the worker executes at once. Raising
+// it lets Run take more tasks straight away; lowering it lets the tasks in
+// progress finish and only takes new ones once fewer than n are running.
+func (w *Worker) SetConcurrency(ctx context.Context, n int) error {
+	if n < 1 {
+		return fmt.Errorf("worker concurrency must be at least 1, got %d", n)
+	}
+	w.concurrency.Store(int64(n))
+	w.signalSlot()
+	w.saveState(ctx, false)
+	return nil
+}
+
+// waitForSlot blocks while the worker is running as many tasks as its
+// concurrency allows. It returns false if ctx is cancelled first.
+func (w *Worker) waitForSlot(ctx context.Context) bool {
+	for w.active.Load() >= w.concurrency.Load() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-w.slotFreed:
+		}
+	}
+	return true
+}
+
+func (w *Worker) signalSlot() {
+	select {
+	case w.slotFreed <- struct{}{}:
+	default:
+	}
+}
+
+// track executes task in its own goroutine while counting it in the
+// worker's ActiveTasks, so the persisted Status and ActiveTasks reflect the
+// work in progress.
+func (w *Worker) track(ctx context.Context, task *domain.Task) {
+	w.active.Add(1)
+	w.saveState(ctx, false)
+	w.inflight.Add(1)
+	go func() {
+		defer w.inflight.Done()
+		defer func() {
+			w.active.Add(-1)
+			w.saveState(ctx, false)
+			w.signalSlot()
+		}()
 		w.execute(ctx, task)
+		w.ack(ctx, task)
+	}()
+}
+
+// ack tells a queue that redelivers unacknowledged tasks that the worker
+// is done with task, even if ctx has been cancelled meanwhile.
+func (w *Worker) ack(ctx context.Context, task *domain.Task) {
+	// The task came from one of the queues; the other ignores the ack.
+	for _, queue := range []domain.Queue{w.queue, w.sticky} {
+		q, ok := queue.(domain.AckQueue)
+		if !ok {
+			continue
+		}
+		if err := q.Ack(context.WithoutCancel(ctx), task); err != nil {
+			log.Printf("Worker %s: ack task %s: %v", w.id, task.ID, err)
+		}
 	}
 }
 
+// saveState writes the current ActiveTasks, and the Status derived from it,
+// to the worker record, along with its recent failure rate and latency;
+// with heartbeat it also refreshes LastHeartAt, and brings a worker the
+// reaper marked offline back. Drained and quarantined workers, and offline
+// ones between heartbeats, keep their status. It returns the saved record,
+// or nil if the record could not be read.
+func (w *Worker) saveState(ctx context.Context, heartbeat bool) *domain.Worker {
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+	wrk, err := w.workers.FindByID(ctx, w.id)
+	if err != nil {
+		return nil
+	}
+	wrk.ActiveTasks = int(w.active.Load())
+	wrk.Concurrency = int(w.concurrency.Load())
+	wrk.FailureRate, wrk.AvgLatency, _ = w.health.stats()
+	if wrk.Status == domain.WorkerStatusIdle || wrk.Status == domain.WorkerStatusBusy ||
+		(heartbeat && wrk.Status == domain.WorkerStatusOffline) {
+		wrk.Status = domain.WorkerStatusIdle
+		if wrk.ActiveTasks > 0 {
+			wrk.Status = domain.WorkerStatusBusy
+		}
+	}
+	if heartbeat {
+		wrk.LastHeartAt = w.clock.Now()
+	}
+	_ = w.workers.Save(ctx, wrk)
+	return wrk
+}
+
 // execute runs a single task, handling status transitions and retry logic.
+// The task's hooks run once its new status is saved.
 func (w *Worker) execute(ctx context.Context, task *domain.Task) {
-	now := time.Now()
+	now := w.clock.Now()
 	task.Status = domain.TaskStatusRunning
 	task.StartedAt = &now
 	task.UpdatedAt = now
-	_ = w.tasks.Save(ctx, task)
+	w.saveTask(ctx, task)
+
+	// Queue-level retries happen after the command was rendered, so the
+	// attempt is the one execution context variable kept current here.
+	if task.Env == nil {
+		task.Env = make(map[string]string, 1)
+	}
+	attempt := task.RetryCount + 1
+	task.Env[idomain.EnvAttempt] = strconv.Itoa(attempt)
 
-	err := w.handler(ctx, task)
+	h := w.handler
+	if th, ok := w.handlers[task.Type]; ok {
+		h = th
+	}
+	logs := &attemptLog{}
+	runCtx := context.WithValue(ctx, logWriterKey{}, logs)
+	runCtx = context.WithValue(runCtx, clockKey{}, w.clock)
+	if task.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, task.Timeout)
+		defer cancel()
+	}
+	err := w.run(runCtx, h, task)
+	if err != nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s: %w", task.Timeout, err)
+	}
 
-	finished := time.Now()
+	finished := w.clock.Now()
 	task.UpdatedAt = finished
 
+	var deferred *DeferError
+	if errors.As(err, &deferred) {
+		// The handler started an external operation: park the task without
+		// a slot until a Triggerer sees the operation finish.
+		task.Status = domain.TaskStatusDeferred
+		task.Error = ""
+		task.Deferral = &domain.Deferral{
+			Token:        deferred.Token,
+			PollInterval: deferred.PollInterval,
+			NextPollAt:   finished.Add(deferred.PollInterval),
+		}
+		w.saveTask(ctx, task)
+		return
+	}
+
+	var resched *RescheduleError
+	if errors.As(err, &resched) {
+		// The handler is waiting on something external: free this slot and
+		// put the task back on the queue once the delay has passed, parked
+		// on the retry queue like a retry so the wait survives a restart.
+		task.Status = domain.TaskStatusQueued
+		task.Error = ""
+		task.ScheduledAt = finished.Add(resched.After)
+		w.saveTask(ctx, task)
+		w.scheduleRetry(ctx, task, task.ScheduledAt)
+		return
+	}
+
+	w.recordAttempt(ctx, task, attempt, now, finished, logs, err)
+	w.observeAttempt(task, attempt, now, finished, err)
+	w.recordHealth(ctx, err != nil, finished.Sub(now))
 	if err == nil {
 		task.FinishedAt = &finished
 		task.Status = domain.TaskStatusSucceeded
 		task.Error = ""
 	} else {
 		task.Error = err.Error()
-		if task.CanRetry() {
+		if task.CanRetry() && !errors.Is(err, ErrSensorTimeout) && !errors.Is(err, ErrExternalRunFailed) && !errors.Is(err, ErrUnknownProfile) {
 			task.RetryCount++
 			task.Status = domain.TaskStatusRetrying
-			_ = w.tasks.Save(ctx, task)
-			// Apply exponential backoff before re-enqueueing.
-			delay := w.backoff(task.RetryCount - 1)
-			if delay > 0 {
-				select {
-				case <-ctx.Done():
-					return
-				case <-time.After(delay):
-				}
-			}
-			// Re-enqueue for retry.
-			_ = w.queue.Enqueue(ctx, task)
+			// The retry is due once the task's own retry policy, or the
+			// worker's backoff, has been waited out, off the slot.
+			task.ScheduledAt = finished.Add(w.retryDelay(task))
+			w.saveTask(ctx, task)
+			w.runHooks(ctx, task, idomain.HookOnRetry)
+			w.scheduleRetry(ctx, task, task.ScheduledAt)
 			return
 		}
 		task.FinishedAt = &finished
 		task.Status = domain.TaskStatusFailed
 	}
+	w.saveTask(ctx, task)
+	if task.Status == domain.TaskStatusSucceeded {
+		w.runHooks(ctx, task, idomain.HookOnSuccess)
+	} else {
+		w.runHooks(ctx, task, idomain.HookOnFailure)
+	}
+}
+
+// expire settles task, which sat in the queue past its ExpiresAt, as
+// expired without running it. Its failure hooks still run, so whoever is
+// told of failures hears of it.
+func (w *Worker) expire(ctx context.Context, task *domain.Task) {
+	now := w.clock.Now()
+	task.Status = domain.TaskStatusExpired
+	task.Error = fmt.Sprintf("expired at %s while queued", task.ExpiresAt.UTC().Format(time.RFC3339))
+	task.FinishedAt, task.UpdatedAt = &now, now
+	w.saveTask(ctx, task)
+	w.runHooks(ctx, task, idomain.HookOnFailure)
+}
+
+// saveTask persists task and announces its new status.
+func (w *Worker) saveTask(ctx context.Context, task *domain.Task) {
 	_ = w.tasks.Save(ctx, task)
+	_ = w.events.Publish(ctx, events.Event{Type: events.TaskStatus, Payload: events.TaskUpdate{
+		TaskID:     task.ID,
+		Name:       task.Name,
+		Status:     string(task.Status),
+		WorkerID:   w.id,
+		RetryCount: task.RetryCount,
+		Error:      task.Error,
+		At:         task.UpdatedAt,
+	}})
 }
 
-// heartbeatLoop updates the worker's LastHeartAt at the configured interval
-// until ctx is cancelled.
+// retryDelay returns how long to wait before retrying task after its latest
+// failure.
+func (w *Worker) retryDelay(task *domain.Task) time.Duration {
+	if task.Retry != nil {
+		return task.Retry.Delay(task.RetryCount - 1)
+	}
+	return w.backoff(task.RetryCount - 1)
+}
+
+// heartbeatLoop updates the worker's LastHeartAt, along with its Status and
+// ActiveTasks, at the configured interval until ctx is cancelled, recording
+// each heartbeat in the heartbeat log if one is set.
 func (w *Worker) heartbeatLoop(ctx context.Context) {
-	ticker := time.NewTicker(w.heartbeatInterval)
+	ticker := w.clock.NewTicker(w.heartbeatInterval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			wrk, err := w.workers.FindByID(ctx, w.id)
-			if err != nil {
+		case <-ticker.C():
+			wrk := w.saveState(ctx, true)
+			if wrk == nil {
 				continue
 			}
-			wrk.LastHeartAt = time.Now()
-			_ = w.workers.Save(ctx, wrk)
+			if w.heartbeats != nil {
+				_ = w.heartbeats.Record(ctx, domain.Heartbeat{
+					WorkerID:    wrk.ID,
+					At:          wrk.LastHeartAt,
+					Status:      wrk.Status,
+					ActiveTasks: wrk.ActiveTasks,
+				})
+			}
+			_ = w.events.Publish(ctx, events.Event{Type: events.WorkerHeartbeat, Payload: events.Heartbeat{
+				WorkerID:    wrk.ID,
+				Status:      string(wrk.Status),
+				ActiveTasks: wrk.ActiveTasks,
+				At:          wrk.LastHeartAt,
+			}})
 		}
 	}
 }
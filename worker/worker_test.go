@@ -2,12 +2,26 @@ package worker_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/sauravritesh63/GoLang-Project-/clock"
 	"github.com/sauravritesh63/GoLang-Project-/domain"
+	idomain "github.com/sauravritesh63/GoLang-Project-/internal/domain"
+	"github.com/sauravritesh63/GoLang-Project-/internal/events"
+	"github.com/sauravritesh63/GoLang-Project-/internal/repository/mock"
 	"github.com/sauravritesh63/GoLang-Project-/scheduler"
 	"github.com/sauravritesh63/GoLang-Project-/worker"
 )
@@ -93,16 +107,17 @@ func (r *memWorkerRepo) FindByID(_ context.Context, id string) (*domain.Worker,
 	return &cp, nil
 }
 
-func (r *memWorkerRepo) FindAvailable(_ context.Context) ([]*domain.Worker, error) {
+func (r *memWorkerRepo) FindAvailable(_ context.Context, f domain.WorkerFilter) ([]*domain.Worker, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	var out []*domain.Worker
 	for _, w := range r.store {
-		if w.HasCapacity() {
+		if w.Matches(f) {
 			cp := *w
 			out = append(out, &cp)
 		}
 	}
+	domain.SortByLoad(out)
 	return out, nil
 }
 
@@ -232,6 +247,42 @@ func TestWorker_Run_SuccessfulTask(t *testing.T) {
 	}
 }
 
+func TestWorker_PublishesTaskEvents(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	bus := events.NewMemBus()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	sub, _ := bus.Subscribe(ctx)
+
+	task := validTask("t1")
+	_ = q.Enqueue(ctx, task)
+	w := worker.New("w1", q, tr, wr, worker.MockShellHandler, worker.WithEvents(bus))
+	go func() { _ = w.Run(ctx) }()
+
+	var got []string
+	for len(got) < 2 {
+		select {
+		case e := <-sub:
+			u, ok := e.Payload.(events.TaskUpdate)
+			if e.Type != events.TaskStatus || !ok {
+				t.Fatalf("unexpected event: %+v", e)
+			}
+			if u.TaskID != "t1" || u.WorkerID != "w1" {
+				t.Errorf("payload: got %+v", u)
+			}
+			got = append(got, u.Status)
+		case <-ctx.Done():
+			t.Fatalf("timed out; events so far: %v", got)
+		}
+	}
+	if got[0] != string(domain.TaskStatusRunning) || got[1] != string(domain.TaskStatusSucceeded) {
+		t.Errorf("statuses: got %v, want [running succeeded]", got)
+	}
+}
+
 func TestWorker_Run_FailedTaskWithRetry(t *testing.T) {
 	q := scheduler.NewMemQueue()
 	tr := newMemTaskRepo()
@@ -243,8 +294,10 @@ func TestWorker_Run_FailedTaskWithRetry(t *testing.T) {
 	_ = q.Enqueue(context.Background(), task)
 
 	attempts := 0
-	h := func(_ context.Context, _ *domain.Task) error {
+	var seen []string
+	h := func(_ context.Context, task *domain.Task) error {
 		attempts++
+		seen = append(seen, task.Env[idomain.EnvAttempt])
 		return errors.New("task failed")
 	}
 
@@ -276,6 +329,159 @@ func TestWorker_Run_FailedTaskWithRetry(t *testing.T) {
 	if attempts != 2 {
 		t.Errorf("expected 2 attempts (1 initial + 1 retry), got %d", attempts)
 	}
+	if len(seen) != 2 || seen[0] != "1" || seen[1] != "2" {
+		t.Errorf("%s per attempt: got %v, want [1 2]", idomain.EnvAttempt, seen)
+	}
+}
+
+func TestWorker_Run_RecordsAttempts(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	attempts := scheduler.NewMemAttemptRepo()
+
+	task := validTask("t1")
+	task.MaxRetries = 2
+	_ = tr.Save(context.Background(), task)
+	_ = q.Enqueue(context.Background(), task)
+
+	h := func(ctx context.Context, task *domain.Task) error {
+		n := task.Env[idomain.EnvAttempt]
+		fmt.Fprintf(worker.LogWriter(ctx), "attempt %s\n", n)
+		if n == "1" {
+			return errors.New("flaky")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	w := worker.New("w1", q, tr, newMemWorkerRepo(), h,
+		worker.WithBackoff(func(int) time.Duration { return 0 }),
+		worker.WithAttemptLog(attempts))
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Run(ctx) }()
+	poll(t, 2*time.Second, func() bool {
+		stored, _ := tr.FindByID(context.Background(), "t1")
+		return stored != nil && stored.IsTerminal()
+	})
+	cancel()
+	<-errCh
+
+	got, err := attempts.ListByTaskID(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("ListByTaskID: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("attempts: got %d, want 2", len(got))
+	}
+	first, second := got[0], got[1]
+	if first.Number != 1 || first.Status != domain.TaskStatusFailed || first.Error != "flaky" || first.Logs != "attempt 1\n" {
+		t.Errorf("attempt 1: got %+v", first)
+	}
+	if second.Number != 2 || second.Status != domain.TaskStatusSucceeded || second.Error != "" || second.Logs != "attempt 2\n" {
+		t.Errorf("attempt 2: got %+v", second)
+	}
+	if first.WorkerID != "w1" || first.FinishedAt.Before(first.StartedAt) {
+		t.Errorf("attempt 1: worker %q, %v to %v", first.WorkerID, first.StartedAt, first.FinishedAt)
+	}
+}
+
+// attemptTimings records the attempts reported to it.
+type attemptTimings struct {
+	mu  sync.Mutex
+	got []attemptTiming
+}
+
+type attemptTiming struct {
+	number    int
+	status    string
+	wait, run time.Duration
+}
+
+func (a *attemptTimings) ObserveAttempt(number int, status string, wait, run time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.got = append(a.got, attemptTiming{number, status, wait, run})
+}
+
+func (a *attemptTimings) list() []attemptTiming {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]attemptTiming(nil), a.got...)
+}
+
+func TestWorker_Run_ObservesAttemptTimings(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	timings := &attemptTimings{}
+
+	// The task has been due for five seconds when the worker starts.
+	task := validTask("t1")
+	task.ScheduledAt = time.Now().Add(-5 * time.Second)
+	task.CreatedAt = task.ScheduledAt
+	_ = tr.Save(context.Background(), task)
+	_ = q.Enqueue(context.Background(), task)
+	h := func(_ context.Context, task *domain.Task) error {
+		if task.RetryCount == 0 {
+			return errors.New("flaky")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	w := worker.New("w1", q, tr, newMemWorkerRepo(), h,
+		worker.WithBackoff(func(int) time.Duration { return 0 }),
+		worker.WithAttemptObserver(timings))
+	go func() { _ = w.Run(ctx) }()
+	poll(t, 2*time.Second, func() bool { return len(timings.list()) == 2 })
+
+	got := timings.list()
+	if got[0].number != 1 || got[0].status != string(domain.TaskStatusFailed) || got[0].wait < 5*time.Second {
+		t.Errorf("attempt 1: got %+v, want failed after waiting at least 5s", got[0])
+	}
+	// The retry waited from when its backoff ended, not the first schedule.
+	if got[1].number != 2 || got[1].status != string(domain.TaskStatusSucceeded) || got[1].wait >= time.Second {
+		t.Errorf("attempt 2: got %+v, want succeeded after a short wait", got[1])
+	}
+}
+
+func TestWorker_AttemptLogKeepsTail(t *testing.T) {
+	if worker.LogWriter(context.Background()) != io.Discard {
+		t.Error("LogWriter outside an attempt: want io.Discard")
+	}
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	attempts := scheduler.NewMemAttemptRepo()
+	task := validTask("t1")
+	_ = tr.Save(context.Background(), task)
+	_ = q.Enqueue(context.Background(), task)
+
+	h := func(ctx context.Context, _ *domain.Task) error {
+		lw := worker.LogWriter(ctx)
+		_, _ = lw.Write([]byte(strings.Repeat("x", worker.MaxAttemptLogBytes)))
+		_, _ = lw.Write([]byte("end"))
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	w := worker.New("w1", q, tr, newMemWorkerRepo(), h, worker.WithAttemptLog(attempts))
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Run(ctx) }()
+	poll(t, 2*time.Second, func() bool {
+		stored, _ := tr.FindByID(context.Background(), "t1")
+		return stored != nil && stored.IsTerminal()
+	})
+	cancel()
+	<-errCh
+
+	got, _ := attempts.ListByTaskID(context.Background(), "t1")
+	if len(got) != 1 {
+		t.Fatalf("attempts: got %d, want 1", len(got))
+	}
+	if logs := got[0].Logs; len(logs) != worker.MaxAttemptLogBytes || !strings.HasSuffix(logs, "xend") {
+		t.Errorf("log: got %d bytes ending %q, want the last %d bytes", len(logs), logs[max(len(logs)-4, 0):], worker.MaxAttemptLogBytes)
+	}
 }
 
 func TestWorker_Run_NoRetry_WhenMaxRetriesZero(t *testing.T) {
@@ -385,71 +591,1468 @@ func TestWorker_Run_HeartbeatUpdated(t *testing.T) {
 	}
 }
 
-func TestMockShellHandler(t *testing.T) {
+func TestWorker_Run_HeartbeatRevivesReapedWorker(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	wr := newMemWorkerRepo()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := func(_ context.Context, _ *domain.Task) error { return nil }
+	w := worker.New("w1", q, newMemTaskRepo(), wr, h,
+		worker.WithHeartbeatInterval(20*time.Millisecond),
+	)
+	go func() { _ = w.Run(ctx) }()
+	poll(t, time.Second, func() bool {
+		_, err := wr.FindByID(ctx, "w1")
+		return err == nil
+	})
+
+	// The reaper took the worker offline while its heartbeats were late.
+	wrk, _ := wr.FindByID(ctx, "w1")
+	wrk.Status = domain.WorkerStatusOffline
+	_ = wr.Save(ctx, wrk)
+
+	poll(t, time.Second, func() bool {
+		w, _ := wr.FindByID(ctx, "w1")
+		return w != nil && w.Status == domain.WorkerStatusIdle
+	})
+}
+
+func TestWorker_Run_RefusesDeactivatedWorker(t *testing.T) {
+	wr := newMemWorkerRepo()
 	ctx := context.Background()
+	_ = wr.Save(ctx, &domain.Worker{ID: "w1", Address: "w1", Status: domain.WorkerStatusDeactivated, Concurrency: 1})
 
-	// Task with payload.
-	task := validTask("t1")
-	task.Payload = []byte("echo hello")
-	if err := worker.MockShellHandler(ctx, task); err != nil {
-		t.Errorf("MockShellHandler with payload: unexpected error: %v", err)
+	h := func(_ context.Context, _ *domain.Task) error { return nil }
+	w := worker.New("w1", scheduler.NewMemQueue(), newMemTaskRepo(), wr, h)
+	if err := w.Run(ctx); !errors.Is(err, domain.ErrWorkerDeactivated) {
+		t.Fatalf("Run: got %v, want ErrWorkerDeactivated", err)
+	}
+	if wrk, _ := wr.FindByID(ctx, "w1"); wrk.Status != domain.WorkerStatusDeactivated {
+		t.Errorf("status = %s, want the record left deactivated", wrk.Status)
 	}
+}
 
-	// Task without payload.
-	empty := validTask("t2")
-	if err := worker.MockShellHandler(ctx, empty); err != nil {
-		t.Errorf("MockShellHandler without payload: unexpected error: %v", err)
+func TestWorker_Run_RecordsHeartbeats(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	log := scheduler.NewMemHeartbeatRepo()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := func(_ context.Context, _ *domain.Task) error { return nil }
+	w := worker.New("w1", q, tr, wr, h,
+		worker.WithHeartbeatInterval(20*time.Millisecond),
+		worker.WithHeartbeatLog(log),
+	)
+	go func() { _ = w.Run(ctx) }()
+
+	poll(t, time.Second, func() bool {
+		beats, _ := log.ListRecent(ctx, "w1", 10)
+		return len(beats) >= 2
+	})
+	beats, _ := log.ListRecent(ctx, "w1", 10)
+	if beats[0].Status != domain.WorkerStatusIdle || beats[0].At.Before(beats[1].At) {
+		t.Errorf("heartbeats = %+v, want idle, newest first", beats)
 	}
 }
 
-func TestWorker_ExponentialBackoff(t *testing.T) {
-	// Verify that the backoff delay is applied between retries.
+func TestWorker_Run_DispatchFreeze(t *testing.T) {
 	q := scheduler.NewMemQueue()
 	tr := newMemTaskRepo()
 	wr := newMemWorkerRepo()
+	freeze := scheduler.NewMemFreezeRepo()
+	_ = freeze.Save(context.Background(), &domain.DispatchFreeze{Frozen: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ran atomic.Int32
+	h := func(_ context.Context, _ *domain.Task) error {
+		ran.Add(1)
+		return nil
+	}
+	w := worker.New("w1", q, tr, wr, h, worker.WithDispatchFreeze(freeze, 10*time.Millisecond))
+	go func() { _ = w.Run(ctx) }()
 
 	task := validTask("t1")
-	task.MaxRetries = 1
-	_ = tr.Save(context.Background(), task)
-	_ = q.Enqueue(context.Background(), task)
+	_ = tr.Save(ctx, task)
+	_ = q.Enqueue(ctx, task)
 
-	// Record the timestamps of each attempt.
-	var (
-		mu         sync.Mutex
-		timestamps []time.Time
-	)
+	time.Sleep(100 * time.Millisecond)
+	if n, _ := q.Len(ctx); n != 1 || ran.Load() != 0 {
+		t.Fatalf("while frozen: queue length %d, %d tasks ran; want 1 and 0", n, ran.Load())
+	}
+
+	_ = freeze.Save(ctx, &domain.DispatchFreeze{})
+	poll(t, time.Second, func() bool { return ran.Load() == 1 })
+}
+
+func TestWorker_Run_RemoteDrain(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	bus := events.NewMemBus()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started, release := make(chan struct{}), make(chan struct{})
 	h := func(_ context.Context, _ *domain.Task) error {
-		mu.Lock()
-		timestamps = append(timestamps, time.Now())
-		mu.Unlock()
-		return errors.New("always fail")
+		close(started)
+		<-release
+		return nil
+	}
+	w := worker.New("w1", q, tr, wr, h, worker.WithControl(bus))
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	for _, id := range []string{"t1", "t2"} {
+		task := validTask(id)
+		_ = tr.Save(ctx, task)
+		_ = q.Enqueue(ctx, task)
 	}
+	<-started
 
-	const backoffDelay = 50 * time.Millisecond
-	fixedBackoff := worker.WithBackoff(func(int) time.Duration { return backoffDelay })
+	// A command for another worker is ignored; the one for w1 drains it
+	// while t1 is still running.
+	_ = bus.Publish(ctx, events.Event{Type: events.WorkerCommand, Payload: events.Command{WorkerID: "w2", Action: events.CommandDrain}})
+	_ = bus.Publish(ctx, events.Event{Type: events.WorkerCommand, Payload: events.Command{WorkerID: "w1", Action: events.CommandDrain}})
+	time.Sleep(50 * time.Millisecond)
+	close(release)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after drain")
+	}
+	if t1, _ := tr.FindByID(ctx, "t1"); t1.Status != domain.TaskStatusSucceeded {
+		t.Errorf("in-flight task status = %q, want succeeded", t1.Status)
+	}
+	if n, _ := q.Len(ctx); n != 1 {
+		t.Errorf("queue length = %d, want t2 left queued", n)
+	}
+	if wrk, _ := wr.FindByID(ctx, "w1"); wrk.Status != domain.WorkerStatusDrained {
+		t.Errorf("worker status = %q, want drained", wrk.Status)
+	}
+}
+
+func TestWorker_Run_AdjustsConcurrency(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	bus := events.NewMemBus()
+
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	w := worker.New("w1", q, tr, wr, h, fixedBackoff)
-	errCh := make(chan error, 1)
-	go func() { errCh <- w.Run(ctx) }()
+	var running atomic.Int64
+	release := make(chan struct{})
+	h := func(_ context.Context, _ *domain.Task) error {
+		running.Add(1)
+		defer running.Add(-1)
+		<-release
+		return nil
+	}
+	w := worker.New("w1", q, tr, wr, h, worker.WithControl(bus))
+	go func() { _ = w.Run(ctx) }()
 
-	// Wait for terminal state (2 attempts with MaxRetries=1).
-	poll(t, 2*time.Second, func() bool {
-		stored, _ := tr.FindByID(context.Background(), "t1")
-		return stored != nil && stored.IsTerminal()
+	enqueue := func(ids ...string) {
+		for _, id := range ids {
+			task := validTask(id)
+			_ = tr.Save(ctx, task)
+			_ = q.Enqueue(ctx, task)
+		}
+	}
+	enqueue("t1", "t2", "t3")
+	poll(t, time.Second, func() bool { return running.Load() == 1 })
+
+	// Raising the limit over the bus picks up the queued tasks.
+	_ = bus.Publish(ctx, events.Event{Type: events.WorkerCommand, Payload: events.Command{WorkerID: "w1", Action: events.CommandSetConcurrency, Concurrency: 3}})
+	poll(t, time.Second, func() bool { return running.Load() == 3 })
+	if wrk, _ := wr.FindByID(ctx, "w1"); wrk.Concurrency != 3 {
+		t.Errorf("worker concurrency = %d, want 3", wrk.Concurrency)
+	}
+
+	// Lowering it lets the running tasks finish and holds back new ones
+	// until fewer than the limit are running.
+	if err := w.SetConcurrency(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	enqueue("t4")
+	release <- struct{}{}
+	release <- struct{}{}
+	poll(t, time.Second, func() bool { return running.Load() == 1 })
+	time.Sleep(50 * time.Millisecond)
+	if n, _ := q.Len(ctx); n != 1 {
+		t.Fatalf("queue length = %d, want t4 held back", n)
+	}
+	release <- struct{}{}
+	poll(t, time.Second, func() bool {
+		t4, _ := tr.FindByID(ctx, "t4")
+		return t4.Status == domain.TaskStatusRunning
 	})
-	cancel()
-	<-errCh
+	close(release)
 
-	mu.Lock()
-	defer mu.Unlock()
-	if len(timestamps) != 2 {
-		t.Fatalf("expected 2 attempts, got %d", len(timestamps))
+	if err := w.SetConcurrency(ctx, 0); err == nil {
+		t.Error("SetConcurrency(0): expected an error")
 	}
-	gap := timestamps[1].Sub(timestamps[0])
-	if gap < backoffDelay {
-		t.Errorf("expected backoff delay ≥ %v between retries, got %v", backoffDelay, gap)
+}
+
+func TestWorker_Run_ResolvesSecretRefs(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	bus := events.NewMemBus()
+	secrets := scheduler.NewMemSecretStore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_ = secrets.Put(ctx, "db-password", "hunter2")
+
+	seen := make(chan string, 1)
+	h := func(_ context.Context, task *domain.Task) error {
+		seen <- task.Env["DB_PASSWORD"]
+		return nil
+	}
+	w := worker.New("w1", q, tr, wr, h, worker.WithSecrets(secrets, time.Hour), worker.WithControl(bus))
+	go func() { _ = w.Run(ctx) }()
+
+	run := func(id, ref string) string {
+		t.Helper()
+		task := validTask(id)
+		task.MaxRetries = 0
+		task.Env = map[string]string{"DB_PASSWORD": ref}
+		_ = tr.Save(ctx, task)
+		_ = q.Enqueue(ctx, task)
+		select {
+		case v := <-seen:
+			return v
+		case <-time.After(time.Second):
+			t.Fatalf("%s did not run", id)
+			return ""
+		}
+	}
+
+	if v := run("t1", "secret://db-password"); v != "hunter2" {
+		t.Errorf("handler saw %q, want the secret value", v)
+	}
+	poll(t, time.Second, func() bool {
+		t1, _ := tr.FindByID(ctx, "t1")
+		return t1.Status == domain.TaskStatusSucceeded
+	})
+	if t1, _ := tr.FindByID(ctx, "t1"); t1.Env["DB_PASSWORD"] != "secret://db-password" {
+		t.Errorf("stored env = %q, want the reference kept", t1.Env["DB_PASSWORD"])
+	}
+
+	// The cached value is used until the rotation is announced.
+	_ = secrets.Put(ctx, "db-password", "correct-horse")
+	if v := run("t2", "secret://db-password"); v != "hunter2" {
+		t.Errorf("before rotation event: handler saw %q, want the cached value", v)
+	}
+	_ = bus.Publish(ctx, events.Event{Type: events.SecretRotated, Payload: events.Secret{Name: "db-password"}})
+	time.Sleep(50 * time.Millisecond)
+	if v := run("t3", "secret://db-password"); v != "correct-horse" {
+		t.Errorf("after rotation: handler saw %q, want the new value", v)
+	}
+
+	// A task referencing an unknown secret fails without running.
+	task := validTask("t4")
+	task.MaxRetries = 0
+	task.Env = map[string]string{"DB_PASSWORD": "secret://missing"}
+	_ = tr.Save(ctx, task)
+	_ = q.Enqueue(ctx, task)
+	poll(t, time.Second, func() bool {
+		t4, _ := tr.FindByID(ctx, "t4")
+		return t4.Status == domain.TaskStatusFailed
+	})
+	if len(seen) != 0 {
+		t.Error("handler ran for a task with an unknown secret")
+	}
+}
+
+func TestWorker_Run_LoadsOffloadedPayload(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	payloads := scheduler.NewMemPayloadStore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_ = payloads.Put(ctx, "ref1", []byte("echo offloaded"))
+
+	seen := make(chan string, 2)
+	h := func(_ context.Context, task *domain.Task) error {
+		seen <- string(task.Payload)
+		return nil
+	}
+	w := worker.New("w1", q, tr, wr, h, worker.WithPayloadStore(payloads))
+	go func() { _ = w.Run(ctx) }()
+
+	for id, ref := range map[string]string{"t1": "ref1", "t2": "missing"} {
+		task := validTask(id)
+		task.MaxRetries = 0
+		task.PayloadRef = ref
+		_ = tr.Save(ctx, task)
+		_ = q.Enqueue(ctx, task)
+	}
+	select {
+	case v := <-seen:
+		if v != "echo offloaded" {
+			t.Errorf("handler saw payload %q, want the stored one", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("t1 did not run")
+	}
+	poll(t, time.Second, func() bool {
+		t1, _ := tr.FindByID(ctx, "t1")
+		t2, _ := tr.FindByID(ctx, "t2")
+		return t1.Status == domain.TaskStatusSucceeded && t2.Status == domain.TaskStatusFailed
+	})
+	if t1, _ := tr.FindByID(ctx, "t1"); len(t1.Payload) != 0 || t1.PayloadRef != "ref1" {
+		t.Errorf("stored task: got payload %q, ref %q; want only the ref", t1.Payload, t1.PayloadRef)
+	}
+	if t2, _ := tr.FindByID(ctx, "t2"); !strings.Contains(t2.Error, domain.ErrPayloadNotFound.Error()) {
+		t.Errorf("t2 error: got %q, want the missing payload", t2.Error)
+	}
+}
+
+func TestWorker_Run_TracksBusyState(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	h := func(_ context.Context, _ *domain.Task) error {
+		<-release
+		return nil
+	}
+	w := worker.New("w1", q, tr, wr, h)
+	go func() { _ = w.Run(ctx) }()
+
+	task := validTask("t1")
+	_ = tr.Save(ctx, task)
+	_ = q.Enqueue(ctx, task)
+
+	poll(t, time.Second, func() bool {
+		wrk, _ := wr.FindByID(ctx, "w1")
+		return wrk != nil && wrk.Status == domain.WorkerStatusBusy
+	})
+	busy, _ := wr.FindByID(ctx, "w1")
+	if busy.ActiveTasks != 1 || busy.HasCapacity() {
+		t.Errorf("while executing: ActiveTasks=%d HasCapacity=%v, want 1 and false", busy.ActiveTasks, busy.HasCapacity())
+	}
+	if running, _ := tr.FindByID(ctx, "t1"); running.WorkerID != "w1" || running.DispatchedAt == nil {
+		t.Errorf("running task WorkerID = %q, DispatchedAt = %v; want w1 and a dispatch time", running.WorkerID, running.DispatchedAt)
+	}
+
+	close(release)
+	poll(t, time.Second, func() bool {
+		wrk, _ := wr.FindByID(ctx, "w1")
+		return wrk != nil && wrk.Status == domain.WorkerStatusIdle
+	})
+	idle, _ := wr.FindByID(ctx, "w1")
+	if idle.ActiveTasks != 0 || !idle.HasCapacity() {
+		t.Errorf("after executing: ActiveTasks=%d HasCapacity=%v, want 0 and true", idle.ActiveTasks, idle.HasCapacity())
+	}
+}
+
+func TestMockShellHandler(t *testing.T) {
+	ctx := context.Background()
+
+	// Task with payload.
+	task := validTask("t1")
+	task.Payload = []byte("echo hello")
+	if err := worker.MockShellHandler(ctx, task); err != nil {
+		t.Errorf("MockShellHandler with payload: unexpected error: %v", err)
+	}
+
+	// Task without payload.
+	empty := validTask("t2")
+	if err := worker.MockShellHandler(ctx, empty); err != nil {
+		t.Errorf("MockShellHandler without payload: unexpected error: %v", err)
+	}
+}
+
+// ── isolation profiles ────────────────────────────────────────────────────────
+
+func TestParseProfiles(t *testing.T) {
+	profiles, err := worker.ParseProfiles([]byte(`[
+		{"name": "default", "env": {"PATH": "/usr/bin:/bin"}, "network": "none"},
+		{"name": "etl", "work_dir": "/srv/etl", "user": "etl:data", "image": "python:3.12"}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseProfiles: %v", err)
+	}
+	if len(profiles) != 2 || profiles[0].Network != worker.NetworkNone || profiles[1].User != "etl:data" {
+		t.Errorf("profiles = %+v", profiles)
+	}
+
+	for _, bad := range []string{
+		`{"name": "default"}`,
+		`[{"env": {"A": "1"}}]`,
+		`[{"name": "etl", "work_dir": "srv/etl"}]`,
+		`[{"name": "etl", "network": "host-only"}]`,
+		`[{"name": "etl"}, {"name": "etl"}]`,
+	} {
+		if _, err := worker.ParseProfiles([]byte(bad)); err == nil {
+			t.Errorf("ParseProfiles(%s) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestWorker_Run_AppliesProfiles(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type seen struct {
+		profile string
+		env     map[string]string
+	}
+	got := make(chan seen, 2)
+	h := func(ctx context.Context, task *domain.Task) error {
+		got <- seen{worker.Isolation(ctx).Name, task.Env}
+		return nil
+	}
+	w := worker.New("w1", q, tr, wr, h, worker.WithProfiles(
+		worker.Profile{Name: worker.DefaultProfile, Env: map[string]string{"PATH": "/bin", "MODE": "base"}},
+		worker.Profile{Name: "etl", WorkDir: "/srv/etl"},
+	))
+	go func() { _ = w.Run(ctx) }()
+
+	run := func(id, profile string) seen {
+		t.Helper()
+		task := validTask(id)
+		task.Profile = profile
+		task.Env = map[string]string{"MODE": "task"}
+		_ = tr.Save(ctx, task)
+		_ = q.Enqueue(ctx, task)
+		select {
+		case s := <-got:
+			return s
+		case <-time.After(time.Second):
+			t.Fatalf("%s did not run", id)
+			return seen{}
+		}
+	}
+
+	// A task naming no profile runs under the default one, its Env on top
+	// of the baseline.
+	if s := run("t1", ""); s.profile != worker.DefaultProfile || s.env["PATH"] != "/bin" || s.env["MODE"] != "task" {
+		t.Errorf("t1 ran under %q with env %v", s.profile, s.env)
+	}
+	if s := run("t2", "etl"); s.profile != "etl" || s.env["PATH"] != "" {
+		t.Errorf("t2 ran under %q with env %v", s.profile, s.env)
+	}
+	poll(t, time.Second, func() bool {
+		t1, _ := tr.FindByID(ctx, "t1")
+		return t1.Status == domain.TaskStatusSucceeded
+	})
+	if t1, _ := tr.FindByID(ctx, "t1"); t1.Env["PATH"] != "" {
+		t.Errorf("stored env = %v, want the baseline left out", t1.Env)
+	}
+
+	// A task naming an unknown profile fails at once, retries or not.
+	task := validTask("t3")
+	task.Profile = "privileged"
+	_ = tr.Save(ctx, task)
+	_ = q.Enqueue(ctx, task)
+	poll(t, time.Second, func() bool {
+		t3, _ := tr.FindByID(ctx, "t3")
+		return t3.Status == domain.TaskStatusFailed
+	})
+	if t3, _ := tr.FindByID(ctx, "t3"); t3.RetryCount != 0 || !strings.Contains(t3.Error, "privileged") {
+		t.Errorf("t3: retry count %d, error %q", t3.RetryCount, t3.Error)
+	}
+	if len(got) != 0 {
+		t.Error("handler ran for a task with an unknown profile")
+	}
+}
+
+func TestShellHandler_AppliesProfile(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	t.Setenv("WORKER_ONLY", "leaked")
+
+	dir := t.TempDir()
+	w := worker.New("w1", q, tr, wr, worker.ShellHandler, worker.WithProfiles(
+		worker.Profile{Name: "sandbox", WorkDir: dir, Env: map[string]string{"PATH": os.Getenv("PATH"), "BASE": "base"}},
+	))
+	go func() { _ = w.Run(ctx) }()
+
+	task := validTask("t1")
+	task.Profile = "sandbox"
+	task.Payload = []byte(`echo "$(pwd) $BASE $OWN [$WORKER_ONLY]" > out.txt`)
+	task.Env = map[string]string{"OWN": "own"}
+	_ = tr.Save(ctx, task)
+	_ = q.Enqueue(ctx, task)
+	poll(t, 2*time.Second, func() bool {
+		t1, _ := tr.FindByID(ctx, "t1")
+		return t1.Status == domain.TaskStatusSucceeded || t1.Status == domain.TaskStatusFailed
+	})
+	if t1, _ := tr.FindByID(ctx, "t1"); t1.Status != domain.TaskStatusSucceeded {
+		t.Fatalf("task %s: %s", t1.Status, t1.Error)
+	}
+	out, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	if err != nil {
+		t.Fatalf("command did not run in the profile's work dir: %v", err)
+	}
+	if got, want := strings.TrimSpace(string(out)), dir+" base own []"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestProfile_DockerArgs(t *testing.T) {
+	p := &worker.Profile{Name: "etl", WorkDir: "/srv/etl", User: "1000:1000", Network: worker.NetworkNone}
+	got := strings.Join(p.DockerArgs(map[string]string{"TOKEN": "s3cret", "A": "1"}), " ")
+	if want := "--workdir /srv/etl --user 1000:1000 --network none --env A --env TOKEN"; got != want {
+		t.Errorf("DockerArgs = %q, want %q", got, want)
+	}
+	var none *worker.Profile
+	if got := none.DockerArgs(map[string]string{"A": "1"}); len(got) != 2 {
+		t.Errorf("nil profile DockerArgs = %v, want only the env", got)
+	}
+}
+
+func TestWorker_ExponentialBackoff(t *testing.T) {
+	// Verify that the backoff delay is applied between retries.
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+
+	task := validTask("t1")
+	task.MaxRetries = 1
+	_ = tr.Save(context.Background(), task)
+	_ = q.Enqueue(context.Background(), task)
+
+	// Record the timestamps of each attempt.
+	var (
+		mu         sync.Mutex
+		timestamps []time.Time
+	)
+	h := func(_ context.Context, _ *domain.Task) error {
+		mu.Lock()
+		timestamps = append(timestamps, time.Now())
+		mu.Unlock()
+		return errors.New("always fail")
+	}
+
+	const backoffDelay = 50 * time.Millisecond
+	fixedBackoff := worker.WithBackoff(func(int) time.Duration { return backoffDelay })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	w := worker.New("w1", q, tr, wr, h, fixedBackoff)
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Run(ctx) }()
+
+	// Wait for terminal state (2 attempts with MaxRetries=1).
+	poll(t, 2*time.Second, func() bool {
+		stored, _ := tr.FindByID(context.Background(), "t1")
+		return stored != nil && stored.IsTerminal()
+	})
+	cancel()
+	<-errCh
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(timestamps) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(timestamps))
+	}
+	gap := timestamps[1].Sub(timestamps[0])
+	if gap < backoffDelay {
+		t.Errorf("expected backoff delay ≥ %v between retries, got %v", backoffDelay, gap)
+	}
+}
+
+func TestWorker_TaskRetryPolicyOverridesBackoff(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+
+	const policyDelay = 80 * time.Millisecond
+	task := validTask("t1")
+	task.MaxRetries = 1
+	task.Retry = &domain.RetryPolicy{InitialDelay: policyDelay}
+	_ = tr.Save(context.Background(), task)
+	_ = q.Enqueue(context.Background(), task)
+
+	var (
+		mu         sync.Mutex
+		timestamps []time.Time
+	)
+	h := func(_ context.Context, _ *domain.Task) error {
+		mu.Lock()
+		timestamps = append(timestamps, time.Now())
+		mu.Unlock()
+		return errors.New("always fail")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// The worker-wide backoff would retry immediately; the task's policy wins.
+	noBackoff := worker.WithBackoff(func(int) time.Duration { return 0 })
+	w := worker.New("w1", q, tr, wr, h, noBackoff)
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Run(ctx) }()
+
+	poll(t, 2*time.Second, func() bool {
+		stored, _ := tr.FindByID(context.Background(), "t1")
+		return stored != nil && stored.IsTerminal()
+	})
+	cancel()
+	<-errCh
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(timestamps) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(timestamps))
+	}
+	if gap := timestamps[1].Sub(timestamps[0]); gap < policyDelay {
+		t.Errorf("expected policy delay ≥ %v between retries, got %v", policyDelay, gap)
+	}
+}
+
+func TestWorker_FakeClock_HeartbeatAndBackoff(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := clock.NewFake(start)
+
+	task := validTask("t1")
+	task.MaxRetries = 1
+	_ = q.Enqueue(context.Background(), task)
+
+	attempts := make(chan time.Time, 2)
+	h := func(_ context.Context, _ *domain.Task) error {
+		attempts <- fc.Now()
+		return errors.New("always fail")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := worker.New("w1", q, tr, wr, h,
+		worker.WithClock(fc),
+		worker.WithHeartbeatInterval(15*time.Second),
+		worker.WithBackoff(func(int) time.Duration { return time.Hour }),
+	)
+	go func() { _ = w.Run(ctx) }()
+
+	// The heartbeat ticker and the retry wait are both pending, and no real
+	// time has to pass for either.
+	<-attempts
+	fc.BlockUntil(2)
+	fc.Advance(15 * time.Second)
+	poll(t, time.Second, func() bool {
+		wrk, _ := wr.FindByID(ctx, "w1")
+		return wrk != nil && wrk.LastHeartAt.Equal(start.Add(15*time.Second))
+	})
+	if stored, _ := tr.FindByID(ctx, "t1"); stored.Status != domain.TaskStatusRetrying {
+		t.Fatalf("status before backoff elapsed: got %q, want retrying", stored.Status)
+	}
+
+	fc.Advance(time.Hour)
+	select {
+	case at := <-attempts:
+		if at.Before(start.Add(time.Hour)) {
+			t.Errorf("retry ran at %s, before the one-hour backoff elapsed", at)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("retry did not run after the backoff elapsed")
+	}
+}
+
+// dequeueTimes records the fake time of every Dequeue call on its queue.
+type dequeueTimes struct {
+	domain.Queue
+	clock clock.Clock
+	calls chan time.Time
+}
+
+func (q *dequeueTimes) Dequeue(ctx context.Context) (*domain.Task, error) {
+	q.calls <- q.clock.Now()
+	return q.Queue.Dequeue(ctx)
+}
+
+func TestWorker_Run_PollingBacksOffWhileIdle(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := clock.NewFake(start)
+	q := &dequeueTimes{Queue: scheduler.NewMemQueue(), clock: fc, calls: make(chan time.Time, 1)}
+	tr := newMemTaskRepo()
+	cfg := domain.PollConfig{LongPoll: time.Second, Interval: time.Second, MaxIdleBackoff: 3 * time.Second}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var ran atomic.Int32
+	h := func(context.Context, *domain.Task) error {
+		ran.Add(1)
+		return nil
+	}
+	w := worker.New("w1", q, tr, newMemWorkerRepo(), h,
+		worker.WithClock(fc),
+		worker.WithHeartbeatInterval(time.Hour),
+		worker.WithPolling(cfg),
+	)
+	go func() { _ = w.Run(ctx) }()
+
+	// Each poll gives up after a second, then the pause doubles from one
+	// second up to three.
+	for i, want := range []time.Duration{0, 2 * time.Second, 5 * time.Second, 9 * time.Second, 13 * time.Second} {
+		if at := <-q.calls; !at.Equal(start.Add(want)) {
+			t.Fatalf("poll %d at +%s, want +%s", i, at.Sub(start), want)
+		}
+		if i == 4 {
+			break
+		}
+		// The heartbeat ticker and the long-poll timeout, then the pause.
+		fc.BlockUntil(2)
+		fc.Advance(cfg.LongPoll)
+		fc.BlockUntil(2)
+		fc.Advance(cfg.IdleDelay(i + 1))
+	}
+
+	task := validTask("t1")
+	_ = tr.Save(ctx, task)
+	_ = q.Enqueue(ctx, task)
+	poll(t, time.Second, func() bool { return ran.Load() == 1 })
+}
+
+// ── Retry queue tests ─────────────────────────────────────────────────────────
+
+func TestWorker_Run_RetryWaitFreesSlot(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flaky := validTask("flaky")
+	flaky.MaxRetries = 1
+	next := validTask("next")
+	for _, task := range []*domain.Task{flaky, next} {
+		_ = tr.Save(ctx, task)
+		_ = q.Enqueue(ctx, task)
+	}
+	h := func(_ context.Context, task *domain.Task) error {
+		if task.ID == "flaky" {
+			return errors.New("flaky")
+		}
+		return nil
+	}
+
+	// One slot and an hour of backoff: the next task only runs if the
+	// retry waits without holding the slot.
+	w := worker.New("w1", q, tr, wr, h,
+		worker.WithBackoff(func(int) time.Duration { return time.Hour }))
+	go func() { _ = w.Run(ctx) }()
+
+	poll(t, time.Second, func() bool {
+		stored, _ := tr.FindByID(ctx, "next")
+		return stored.Status == domain.TaskStatusSucceeded
+	})
+	if stored, _ := tr.FindByID(ctx, "flaky"); stored.Status != domain.TaskStatusRetrying {
+		t.Errorf("flaky status: got %q, want retrying", stored.Status)
+	}
+}
+
+func TestWorker_Run_RetryQueueHoldsRetryUntilDue(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	rq := scheduler.NewMemRetryQueue(q)
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	fc := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	task := validTask("t1")
+	task.MaxRetries = 1
+	_ = tr.Save(ctx, task)
+	_ = q.Enqueue(ctx, task)
+	var calls atomic.Int32
+	h := func(context.Context, *domain.Task) error {
+		if calls.Add(1) == 1 {
+			return errors.New("flaky")
+		}
+		return nil
+	}
+
+	w := worker.New("w1", q, tr, wr, h,
+		worker.WithClock(fc),
+		worker.WithBackoff(func(int) time.Duration { return time.Minute }),
+		worker.WithRetryQueue(rq, time.Second, 10),
+	)
+	go func() { _ = w.Run(ctx) }()
+
+	poll(t, time.Second, func() bool {
+		n, _ := rq.Len(ctx)
+		return n == 1
+	})
+	if n, _ := q.Len(ctx); n != 0 {
+		t.Fatalf("main queue holds %d tasks during the backoff, want 0", n)
+	}
+
+	fc.Advance(30 * time.Second)
+	time.Sleep(20 * time.Millisecond)
+	if calls.Load() != 1 {
+		t.Fatal("retry ran before its backoff elapsed")
+	}
+	fc.Advance(30 * time.Second)
+	poll(t, time.Second, func() bool {
+		stored, _ := tr.FindByID(ctx, "t1")
+		return stored.Status == domain.TaskStatusSucceeded
+	})
+	if n, _ := rq.Len(ctx); n != 0 {
+		t.Errorf("retry queue after release: got %d, want 0", n)
+	}
+}
+
+// ackQueue counts the acknowledgements of the tasks it hands out.
+type ackQueue struct {
+	domain.Queue
+	acked chan string
+}
+
+func (q *ackQueue) Ack(_ context.Context, task *domain.Task) error {
+	q.acked <- task.ID
+	return nil
+}
+
+func TestWorker_Run_TimeoutFailsAttemptAndAcks(t *testing.T) {
+	q := &ackQueue{Queue: scheduler.NewMemQueue(), acked: make(chan string, 1)}
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	task := validTask("t1")
+	task.MaxRetries = 0
+	task.Timeout = 20 * time.Millisecond
+	_ = tr.Save(ctx, task)
+	_ = q.Enqueue(ctx, task)
+	h := func(ctx context.Context, _ *domain.Task) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	w := worker.New("w1", q, tr, wr, h)
+	go func() { _ = w.Run(ctx) }()
+
+	select {
+	case id := <-q.acked:
+		if id != "t1" {
+			t.Fatalf("acked %s, want t1", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("task was not acked")
+	}
+	stored, _ := tr.FindByID(ctx, "t1")
+	if stored.Status != domain.TaskStatusFailed || !strings.Contains(stored.Error, "timed out after 20ms") {
+		t.Errorf("task: got status %q, error %q; want failed on its timeout", stored.Status, stored.Error)
+	}
+}
+
+func TestWorker_Run_ExpiresStaleTaskWithoutRunning(t *testing.T) {
+	q := &ackQueue{Queue: scheduler.NewMemQueue(), acked: make(chan string, 1)}
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	task := validTask("t1")
+	expires := time.Now().Add(-time.Minute)
+	task.ExpiresAt = &expires
+	_ = tr.Save(ctx, task)
+	_ = q.Enqueue(ctx, task)
+	h := func(context.Context, *domain.Task) error {
+		t.Error("expired task was executed")
+		return nil
+	}
+
+	w := worker.New("w1", q, tr, wr, h)
+	go func() { _ = w.Run(ctx) }()
+
+	select {
+	case <-q.acked:
+	case <-time.After(time.Second):
+		t.Fatal("task was not acked")
+	}
+	stored, _ := tr.FindByID(ctx, "t1")
+	if stored.Status != domain.TaskStatusExpired || stored.FinishedAt == nil || stored.StartedAt != nil {
+		t.Errorf("task: got status %q, started %v, finished %v; want expired without starting", stored.Status, stored.StartedAt, stored.FinishedAt)
+	}
+}
+
+// enqueueCounter counts the tasks enqueued on it.
+type enqueueCounter struct {
+	domain.Queue
+	n atomic.Int32
+}
+
+func (q *enqueueCounter) Enqueue(ctx context.Context, task *domain.Task) error {
+	q.n.Add(1)
+	return q.Queue.Enqueue(ctx, task)
+}
+
+func TestWorker_Run_ServesStickyQueue(t *testing.T) {
+	q := &enqueueCounter{Queue: scheduler.NewMemQueue()}
+	sticky := &enqueueCounter{Queue: scheduler.NewMemQueue()}
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	h := func(context.Context, *domain.Task) error {
+		if calls.Add(1) == 1 {
+			return errors.New("cold cache")
+		}
+		return nil
+	}
+	task := validTask("t1")
+	task.RoutingKey = "customer-7"
+	_ = tr.Save(ctx, task)
+	_ = sticky.Enqueue(ctx, task)
+
+	w := worker.New("w1", q, tr, wr, h,
+		worker.WithStickyQueue(sticky, 10*time.Millisecond),
+		worker.WithBackoff(func(int) time.Duration { return 0 }))
+	go func() { _ = w.Run(ctx) }()
+
+	poll(t, time.Second, func() bool {
+		stored, _ := tr.FindByID(ctx, "t1")
+		return stored.Status == domain.TaskStatusSucceeded
+	})
+	if calls.Load() != 2 || sticky.n.Load() != 2 || q.n.Load() != 0 {
+		t.Errorf("got %d attempts, %d sticky and %d group enqueues; want the retry back on the sticky queue",
+			calls.Load(), sticky.n.Load(), q.n.Load())
+	}
+}
+
+// ── Health tests ──────────────────────────────────────────────────────────────
+
+func TestWorker_Run_QuarantinesWhenFailing(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	bus := events.NewMemBus()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := clock.NewFake(start)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, _ := bus.Subscribe(ctx)
+
+	for _, id := range []string{"bad-1", "bad-2", "good"} {
+		task := validTask(id)
+		task.MaxRetries = 0
+		_ = tr.Save(ctx, task)
+		_ = q.Enqueue(ctx, task)
+	}
+	var ran atomic.Int32
+	h := func(_ context.Context, task *domain.Task) error {
+		ran.Add(1)
+		if strings.HasPrefix(task.ID, "bad") {
+			return errors.New("disk full")
+		}
+		return nil
+	}
+	w := worker.New("w1", q, tr, wr, h,
+		worker.WithClock(fc),
+		worker.WithHeartbeatInterval(time.Hour),
+		worker.WithEvents(bus),
+		worker.WithHealthPolicy(worker.HealthPolicy{MinAttempts: 2, QuarantineAt: 0.5, Quarantine: time.Minute}),
+	)
+	go func() { _ = w.Run(ctx) }()
+
+	var got events.Quarantine
+	for e := range sub {
+		if e.Type == events.WorkerQuarantined {
+			got = e.Payload.(events.Quarantine)
+			break
+		}
+	}
+	if got.WorkerID != "w1" || got.FailureRate != 1 || got.Attempts != 2 || !got.Until.Equal(start.Add(time.Minute)) {
+		t.Errorf("quarantine event: got %+v", got)
+	}
+	wrk, _ := wr.FindByID(ctx, "w1")
+	if wrk.Status != domain.WorkerStatusQuarantined || wrk.FailureRate != 1 {
+		t.Errorf("worker record: status %q, failure rate %v; want quarantined and 1", wrk.Status, wrk.FailureRate)
+	}
+
+	// The heartbeat ticker and the end of the quarantine.
+	fc.BlockUntil(2)
+	if n := ran.Load(); n != 2 {
+		t.Fatalf("tasks run while quarantined: got %d, want 2", n)
+	}
+	fc.Advance(time.Minute)
+	poll(t, time.Second, func() bool {
+		stored, _ := tr.FindByID(ctx, "good")
+		return stored.Status == domain.TaskStatusSucceeded
+	})
+	poll(t, time.Second, func() bool {
+		wrk, _ := wr.FindByID(ctx, "w1")
+		return wrk.Status == domain.WorkerStatusIdle && wrk.FailureRate == 0
+	})
+}
+
+func TestParseHealthPolicy(t *testing.T) {
+	p, err := worker.ParseHealthPolicy("window=50, min_attempts=10,max_poll_delay=5s,quarantine_at=0.8,quarantine=10m")
+	if err != nil {
+		t.Fatalf("ParseHealthPolicy: %v", err)
+	}
+	want := worker.HealthPolicy{Window: 50, MinAttempts: 10, MaxPollDelay: 5 * time.Second, QuarantineAt: 0.8, Quarantine: 10 * time.Minute}
+	if p != want {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+	for _, bad := range []string{"quarantine_at=2", "window=-1", "quarantine=soon", "retries=3", "window"} {
+		if _, err := worker.ParseHealthPolicy(bad); err == nil {
+			t.Errorf("ParseHealthPolicy(%q): expected error", bad)
+		}
+	}
+}
+
+// ── Sensor tests ──────────────────────────────────────────────────────────────
+
+func sensorTask(id, spec string) *domain.Task {
+	task := validTask(id)
+	task.Type = worker.TaskTypeSensor
+	task.Payload = []byte(spec)
+	return task
+}
+
+func TestSensorHandler_File(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "_SUCCESS")
+	h := worker.SensorHandler(nil, nil)
+	task := sensorTask("t1", `{"kind":"file","path":"`+path+`","poke_interval_seconds":7}`)
+
+	var resched *worker.RescheduleError
+	if err := h(ctx, task); !errors.As(err, &resched) {
+		t.Fatalf("expected RescheduleError for missing file, got %v", err)
+	}
+	if resched.After != 7*time.Second {
+		t.Errorf("reschedule delay: got %v, want 7s", resched.After)
+	}
+
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := h(ctx, task); err != nil {
+		t.Errorf("expected nil once file exists, got %v", err)
+	}
+}
+
+func TestSensorHandler_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	h := worker.SensorHandler(nil, srv.Client())
+	task := sensorTask("t1", `{"kind":"http","url":"`+srv.URL+`"}`)
+	if err := h(context.Background(), task); err != nil {
+		t.Errorf("expected nil for 200 response, got %v", err)
+	}
+}
+
+func TestSensorHandler_Timeout(t *testing.T) {
+	task := sensorTask("t1", `{"kind":"time","at":"2999-01-01T00:00:00Z","timeout_seconds":60}`)
+	task.CreatedAt = time.Now().Add(-2 * time.Minute)
+	err := worker.SensorHandler(nil, nil)(context.Background(), task)
+	if !errors.Is(err, worker.ErrSensorTimeout) {
+		t.Errorf("expected ErrSensorTimeout, got %v", err)
+	}
+}
+
+func TestSensorHandler_ExternalRun(t *testing.T) {
+	ctx := context.Background()
+	runs := mock.NewWorkflowRunRepo()
+	wfID := uuid.New()
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	h := worker.SensorHandler(nil, nil, worker.WithWorkflowRuns(runs))
+	task := sensorTask("t1", `{"kind":"external_run","workflow_id":"`+wfID.String()+`","execution_date":"2024-03-01T00:00:00Z"}`)
+
+	var resched *worker.RescheduleError
+	if err := h(ctx, task); !errors.As(err, &resched) {
+		t.Fatalf("expected RescheduleError before the run exists, got %v", err)
+	}
+
+	run := &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: wfID, Status: idomain.StatusRunning,
+		StartedAt: date.Add(5 * time.Minute), LogicalDate: &date}
+	if err := runs.Create(ctx, run); err != nil {
+		t.Fatal(err)
+	}
+	if err := h(ctx, task); !errors.As(err, &resched) {
+		t.Fatalf("expected RescheduleError while the run is running, got %v", err)
+	}
+
+	if err := runs.UpdateStatus(ctx, run.ID, idomain.StatusSuccess, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := h(ctx, task); err != nil {
+		t.Errorf("expected nil once the run succeeded, got %v", err)
+	}
+}
+
+func TestSensorHandler_ExternalRunFailed(t *testing.T) {
+	ctx := context.Background()
+	runs := mock.NewWorkflowRunRepo()
+	wfID := uuid.New()
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	_ = runs.Create(ctx, &idomain.WorkflowRun{ID: uuid.New(), WorkflowID: wfID,
+		Status: idomain.StatusFailed, StartedAt: date})
+
+	h := worker.SensorHandler(nil, nil, worker.WithWorkflowRuns(runs))
+	task := sensorTask("t1", `{"kind":"external_run","workflow_id":"`+wfID.String()+`","execution_date":"2024-03-01T00:00:00Z"}`)
+	if err := h(ctx, task); !errors.Is(err, worker.ErrExternalRunFailed) {
+		t.Errorf("expected ErrExternalRunFailed, got %v", err)
+	}
+}
+
+func TestSensorHandler_ExternalRunWithoutStore(t *testing.T) {
+	task := sensorTask("t1", `{"kind":"external_run","workflow_id":"`+uuid.NewString()+`","execution_date":"2024-03-01T00:00:00Z"}`)
+	var resched *worker.RescheduleError
+	err := worker.SensorHandler(nil, nil)(context.Background(), task)
+	if err == nil || errors.As(err, &resched) {
+		t.Errorf("expected a hard error without a workflow run store, got %v", err)
+	}
+}
+
+func TestWorker_Run_RescheduleDoesNotConsumeRetries(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+
+	task := sensorTask("t1", `{}`)
+	_ = tr.Save(context.Background(), task)
+	_ = q.Enqueue(context.Background(), task)
+
+	var pokes int
+	sensor := func(_ context.Context, _ *domain.Task) error {
+		pokes++
+		if pokes < 3 {
+			return &worker.RescheduleError{After: 10 * time.Millisecond}
+		}
+		return nil
+	}
+	defaultCalled := false
+	def := func(_ context.Context, _ *domain.Task) error {
+		defaultCalled = true
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	w := worker.New("w1", q, tr, wr, def, worker.WithHandler(worker.TaskTypeSensor, sensor))
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Run(ctx) }()
+
+	poll(t, 2*time.Second, func() bool {
+		stored, _ := tr.FindByID(context.Background(), "t1")
+		return stored != nil && stored.IsTerminal()
+	})
+	cancel()
+	<-errCh
+
+	stored, _ := tr.FindByID(context.Background(), "t1")
+	if stored.Status != domain.TaskStatusSucceeded {
+		t.Errorf("task status: got %q, want succeeded", stored.Status)
+	}
+	if stored.RetryCount != 0 {
+		t.Errorf("RetryCount: got %d, want 0", stored.RetryCount)
+	}
+	if defaultCalled {
+		t.Error("expected sensor task to bypass the default handler")
+	}
+}
+
+func TestWorker_Run_RescheduledSensorWaitsOnRetryQueue(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	rq := scheduler.NewMemRetryQueue(q)
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := clock.NewFake(start)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A time sensor reads the worker's clock, so it is met only once the
+	// fake clock has been advanced past At.
+	task := sensorTask("t1", `{"kind":"time","at":"2024-01-01T00:01:30Z","poke_interval_seconds":60}`)
+	task.CreatedAt = start
+	_ = tr.Save(ctx, task)
+	_ = q.Enqueue(ctx, task)
+
+	w := worker.New("w1", q, tr, wr, nil,
+		worker.WithClock(fc),
+		worker.WithHandler(worker.TaskTypeSensor, worker.SensorHandler(nil, nil)),
+		worker.WithRetryQueue(rq, time.Second, 10),
+	)
+	go func() { _ = w.Run(ctx) }()
+
+	poll(t, time.Second, func() bool {
+		n, _ := rq.Len(ctx)
+		return n == 1
+	})
+	stored, _ := tr.FindByID(ctx, "t1")
+	if stored.Status != domain.TaskStatusQueued || !stored.ScheduledAt.Equal(start.Add(time.Minute)) {
+		t.Fatalf("after first poke: got %s due %s, want queued due %s", stored.Status, stored.ScheduledAt, start.Add(time.Minute))
+	}
+
+	for i := 0; i < 2; i++ {
+		fc.Advance(time.Minute)
+		time.Sleep(20 * time.Millisecond)
+	}
+	poll(t, time.Second, func() bool {
+		stored, _ := tr.FindByID(ctx, "t1")
+		return stored.Status == domain.TaskStatusSucceeded
+	})
+	if stored, _ := tr.FindByID(ctx, "t1"); stored.RetryCount != 0 {
+		t.Errorf("RetryCount: got %d, want 0", stored.RetryCount)
+	}
+}
+
+// ── Deferrable task tests ─────────────────────────────────────────────────────
+
+// deferringHandler starts an "external job" on first execution and finishes
+// the task once the job's result has been delivered.
+func deferringHandler(poll time.Duration, got *[]byte) worker.Handler {
+	return func(_ context.Context, task *domain.Task) error {
+		if task.Deferral == nil {
+			return worker.Defer("job-"+task.ID, poll)
+		}
+		*got = task.Deferral.Result
+		if task.Deferral.Error != "" {
+			return errors.New(task.Deferral.Error)
+		}
+		return nil
+	}
+}
+
+func TestWorker_DeferredTask_ResumedByCallback(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	wr := newMemWorkerRepo()
+
+	var result []byte
+	w := worker.New("w1", q, tr, wr, deferringHandler(0, &result))
+	trig := worker.NewTriggerer(q, tr, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Run(ctx) }()
+
+	task := validTask("t1")
+	_ = tr.Save(ctx, task)
+	_ = q.Enqueue(ctx, task)
+
+	poll(t, time.Second, func() bool {
+		stored, _ := tr.FindByID(ctx, "t1")
+		return stored != nil && stored.Status == domain.TaskStatusDeferred
+	})
+	stored, _ := tr.FindByID(ctx, "t1")
+	if stored.Deferral == nil || stored.Deferral.Token != "job-t1" {
+		t.Fatalf("expected deferral token job-t1, got %+v", stored.Deferral)
+	}
+
+	if err := trig.Complete(ctx, "t1", []byte("42 rows"), nil); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	poll(t, time.Second, func() bool {
+		stored, _ := tr.FindByID(ctx, "t1")
+		return stored != nil && stored.IsTerminal()
+	})
+	cancel()
+	<-errCh
+
+	stored, _ = tr.FindByID(context.Background(), "t1")
+	if stored.Status != domain.TaskStatusSucceeded {
+		t.Errorf("status: got %q, want succeeded", stored.Status)
+	}
+	if string(result) != "42 rows" {
+		t.Errorf("handler result: got %q, want %q", result, "42 rows")
+	}
+	if err := trig.Complete(context.Background(), "t1", nil, nil); !errors.Is(err, worker.ErrNotDeferred) {
+		t.Errorf("second Complete: got %v, want ErrNotDeferred", err)
+	}
+}
+
+func TestTriggerer_PollDue_ResumesFinishedOperation(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	ctx := context.Background()
+
+	task := validTask("t1")
+	task.Type = "batch-job"
+	task.Status = domain.TaskStatusDeferred
+	task.Deferral = &domain.Deferral{Token: "job-1", PollInterval: time.Millisecond}
+	_ = tr.Save(ctx, task)
+
+	polls := 0
+	trig := worker.NewTriggerer(q, tr, map[string]worker.Poller{
+		"batch-job": func(context.Context, *domain.Task) (bool, []byte, error) {
+			polls++
+			return polls > 1, []byte("ok"), nil
+		},
+	})
+
+	_ = trig.PollDue(ctx)
+	if n, _ := q.Len(ctx); n != 0 {
+		t.Fatalf("queued after unfinished poll: got %d, want 0", n)
+	}
+	time.Sleep(2 * time.Millisecond)
+	_ = trig.PollDue(ctx)
+	if n, _ := q.Len(ctx); n != 1 {
+		t.Fatalf("queued after finished poll: got %d, want 1", n)
+	}
+	stored, _ := tr.FindByID(ctx, "t1")
+	if stored.Status != domain.TaskStatusQueued || !stored.Deferral.Done {
+		t.Errorf("expected queued task with Done deferral, got %q %+v", stored.Status, stored.Deferral)
+	}
+}
+
+// ── hooks ─────────────────────────────────────────────────────────────────────
+
+type recordingNotifier struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, _ *domain.Task, msg string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.msgs = append(n.msgs, msg)
+	return nil
+}
+
+func (n *recordingNotifier) messages() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.msgs...)
+}
+
+// TestWorker_RunsHooks verifies the hooks for each transition run once it
+// happens: on_retry after the failed first attempt, on_success after the
+// second, and on_failure never.
+func TestWorker_RunsHooks(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		posted  []worker.HookPayload
+		failure atomic.Int64
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/failure" {
+			failure.Add(1)
+			return
+		}
+		var p worker.HookPayload
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		mu.Lock()
+		posted = append(posted, p)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	task := validTask("t1")
+	task.MaxRetries = 1
+	task.Hooks = []domain.Hook{
+		{On: "on_retry", Kind: "http", URL: srv.URL + "/retry"},
+		{On: "on_failure", Kind: "http", URL: srv.URL + "/failure"},
+		{On: "on_success", Kind: "enqueue", Command: "report.sh"},
+		{On: "on_success", Kind: "notify", Notifier: "ops", Message: "send-email done"},
+		{On: "on_success", Kind: "notify", Notifier: "missing"},
+	}
+	_ = q.Enqueue(context.Background(), task)
+
+	var ran sync.Map // payloads executed, to attempts
+	h := func(_ context.Context, task *domain.Task) error {
+		n, _ := ran.LoadOrStore(string(task.Payload), new(atomic.Int64))
+		if n.(*atomic.Int64).Add(1) == 1 && task.ID == "t1" {
+			return errors.New("flaky")
+		}
+		return nil
+	}
+	ops := &recordingNotifier{}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	w := worker.New("w1", q, tr, newMemWorkerRepo(), h,
+		worker.WithBackoff(func(int) time.Duration { return 0 }), worker.WithNotifier("ops", ops))
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Run(ctx) }()
+
+	poll(t, 2*time.Second, func() bool {
+		_, ok := ran.Load("report.sh")
+		return ok
+	})
+	cancel()
+	<-errCh
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(posted) != 1 || posted[0].Event != "on_retry" || posted[0].TaskID != "t1" ||
+		posted[0].Status != string(domain.TaskStatusRetrying) || posted[0].Error != "flaky" || posted[0].WorkerID != "w1" {
+		t.Errorf("http hook payloads: got %+v, want one on_retry of t1", posted)
+	}
+	if failure.Load() != 0 {
+		t.Error("on_failure hook ran for a task that succeeded")
+	}
+	if got := ops.messages(); len(got) != 1 || got[0] != "send-email done" {
+		t.Errorf("notifications: got %q", got)
+	}
+	followUps, _ := tr.FindByStatus(context.Background(), domain.TaskStatusSucceeded)
+	var names []string
+	for _, f := range followUps {
+		names = append(names, f.Name)
+	}
+	if len(names) != 2 || !strings.Contains(strings.Join(names, ","), "send-email.on_success") {
+		t.Errorf("succeeded tasks: got %v, want send-email and its on_success follow-up", names)
+	}
+}
+
+// TestWorker_RunsFailureHook verifies an on_failure notification
+// describes the final error when no message is configured.
+func TestWorker_RunsFailureHook(t *testing.T) {
+	q := scheduler.NewMemQueue()
+	tr := newMemTaskRepo()
+	task := validTask("t1")
+	task.MaxRetries = 0
+	task.Hooks = []domain.Hook{{On: "on_failure", Kind: "notify", Notifier: "ops"}}
+	_ = q.Enqueue(context.Background(), task)
+
+	ops := &recordingNotifier{}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	w := worker.New("w1", q, tr, newMemWorkerRepo(),
+		func(context.Context, *domain.Task) error { return errors.New("disk full") }, worker.WithNotifier("ops", ops))
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Run(ctx) }()
+	poll(t, 2*time.Second, func() bool { return len(ops.messages()) > 0 })
+	cancel()
+	<-errCh
+
+	if got := ops.messages()[0]; got != "task send-email (t1): failed: disk full" {
+		t.Errorf("message: got %q", got)
 	}
 }